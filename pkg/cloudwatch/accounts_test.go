@@ -27,9 +27,12 @@ func (mcp mockConfigProvider) GetConfig(ctx context.Context, authSettings awsaut
 }
 func newTestDatasource(opts ...func(*DataSource)) *DataSource {
 	ds := &DataSource{
-		AWSConfigProvider: awsauth.NewFakeConfigProvider(false),
-		logger:            log.NewNullLogger(),
-		tagValueCache:     cache.New(0, 0),
+		AWSConfigProvider:  awsauth.NewFakeConfigProvider(false),
+		logger:             log.NewNullLogger(),
+		tagValueCache:      cache.New(0, 0),
+		discoveryCache:     cache.New(0, 0),
+		instanceNameCache:  cache.New(0, 0),
+		dimensionTagsCache: cache.New(0, 0),
 	}
 	ds.resourceHandler = httpadapter.New(ds.newResourceMux())
 	for _, opt := range opts {
@@ -68,6 +71,25 @@ func Test_accounts_route(t *testing.T) {
 		assert.JSONEq(t, `[{"value":{"id":"123456789012", "arn":"some arn", "isMonitoringAccount":true, "label":"some label"}}]`, rr.Body.String())
 	})
 
+	t.Run("filters accounts by label search", func(t *testing.T) {
+		mockAccountsService := mocks.AccountsServiceMock{}
+		mockAccountsService.On("GetAccountsForCurrentUserOrRole").Return([]resources.ResourceResponse[resources.Account]{
+			{Value: resources.Account{Id: "123456789012", Label: "monitoring"}, Label: "monitoring (123456789012)"},
+			{Value: resources.Account{Id: "123456789013", Label: "team-payments"}, Label: "team-payments (123456789013)"},
+		}, nil)
+		services.NewAccountsService = func(_ models.OAMAPIProvider) models.AccountsProvider {
+			return &mockAccountsService
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/accounts?region=us-east-1&labelSearch=PAY", nil)
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.AccountsHandler))
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"value":{"id":"123456789013", "arn":"", "isMonitoringAccount":false, "label":"team-payments"}, "label":"team-payments (123456789013)"}]`, rr.Body.String())
+	})
+
 	t.Run("rejects POST method", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		req := httptest.NewRequest("POST", "/accounts?region=us-east-1", nil)
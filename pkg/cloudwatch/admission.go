@@ -0,0 +1,67 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type admissionHandler struct{}
+
+// NewAdmissionHandler returns the handler rejecting data source settings that are inconsistent, so that they're
+// reported when saving the data source instead of when querying it.
+func NewAdmissionHandler() backend.AdmissionHandler {
+	return admissionHandler{}
+}
+
+func (admissionHandler) ValidateAdmission(_ context.Context, req *backend.AdmissionRequest) (*backend.ValidationResponse, error) {
+	result, err := validateSettingsAdmission(req)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.ValidationResponse{Allowed: result == nil, Result: result}, nil
+}
+
+// MutateAdmission doesn't change the settings, it only rejects invalid ones.
+func (admissionHandler) MutateAdmission(_ context.Context, req *backend.AdmissionRequest) (*backend.MutationResponse, error) {
+	result, err := validateSettingsAdmission(req)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		return &backend.MutationResponse{Allowed: false, Result: result}, nil
+	}
+	return &backend.MutationResponse{Allowed: true, ObjectBytes: req.ObjectBytes}, nil
+}
+
+// validateSettingsAdmission returns the status rejecting the data source of the request, or nil if it's valid.
+func validateSettingsAdmission(req *backend.AdmissionRequest) (*backend.StatusResult, error) {
+	if req.Operation == backend.AdmissionRequestDelete {
+		return nil, nil
+	}
+
+	settings, err := backend.DataSourceInstanceSettingsFromProto(req.ObjectBytes, req.PluginContext.PluginID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data source settings: %w", err)
+	}
+
+	errs := models.ValidateSettings(*settings)
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return &backend.StatusResult{
+		Status:  "Failure",
+		Message: fmt.Sprintf("invalid settings: %s", strings.Join(messages, "; ")),
+		Reason:  "BadRequest",
+		Code:    http.StatusBadRequest,
+	}, nil
+}
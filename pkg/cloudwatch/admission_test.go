@@ -0,0 +1,66 @@
+package cloudwatch
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func admissionRequest(t *testing.T, operation backend.AdmissionRequestOperation, jsonData string) *backend.AdmissionRequest {
+	t.Helper()
+	objectBytes, err := backend.DataSourceInstanceSettingsToProtoBytes(&backend.DataSourceInstanceSettings{
+		UID:      "cloudwatch",
+		JSONData: []byte(jsonData),
+	})
+	require.NoError(t, err)
+	return &backend.AdmissionRequest{
+		PluginContext: backend.PluginContext{PluginID: "grafana-cloudwatch-datasource"},
+		Operation:     operation,
+		ObjectBytes:   objectBytes,
+	}
+}
+
+func Test_admissionHandler(t *testing.T) {
+	handler := NewAdmissionHandler()
+
+	t.Run("allows valid settings", func(t *testing.T) {
+		req := admissionRequest(t, backend.AdmissionRequestCreate, `{"authType":"default","defaultRegion":"us-east-1"}`)
+
+		validation, err := handler.ValidateAdmission(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, validation.Allowed)
+		assert.Nil(t, validation.Result)
+
+		mutation, err := handler.MutateAdmission(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, mutation.Allowed)
+		assert.Equal(t, req.ObjectBytes, mutation.ObjectBytes)
+	})
+
+	t.Run("rejects invalid settings with the invalid fields", func(t *testing.T) {
+		req := admissionRequest(t, backend.AdmissionRequestUpdate, `{"assumeRoleArn":"arn:aws:iam::123456789012:role/grafana","defaultRegion":"us-east-1"}`)
+
+		validation, err := handler.ValidateAdmission(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, validation.Allowed)
+		require.NotNil(t, validation.Result)
+		assert.Equal(t, "invalid settings: jsonData.authType: an auth type is required to assume a role", validation.Result.Message)
+		assert.Equal(t, int32(http.StatusBadRequest), validation.Result.Code)
+
+		mutation, err := handler.MutateAdmission(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, mutation.Allowed)
+		assert.Equal(t, validation.Result, mutation.Result)
+		assert.Nil(t, mutation.ObjectBytes)
+	})
+
+	t.Run("allows deleting a data source with invalid settings", func(t *testing.T) {
+		validation, err := handler.ValidateAdmission(context.Background(), admissionRequest(t, backend.AdmissionRequestDelete, `{}`))
+		require.NoError(t, err)
+		assert.True(t, validation.Allowed)
+	})
+}
@@ -0,0 +1,163 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type alarmState struct {
+	name         string
+	state        string
+	reason       string
+	transitioned time.Time
+}
+
+// executeAlarmQueries returns the current state of the alarms of each query as a table, so that panels can show the
+// status of alarms. Like annotation queries, alarms are found by the prefixes of their names and actions when prefix
+// matching is enabled, and by their metric otherwise.
+func (ds *DataSource) executeAlarmQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		var model DataQueryJson
+		if err := json.Unmarshal(query.JSON, &model); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+
+		cli, err := ds.getCWClient(ctx, model.Region)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(fmt.Errorf("%v: %w", "failed to get client", err))
+			continue
+		}
+
+		var alarms []alarmState
+		if model.PrefixMatching != nil && *model.PrefixMatching {
+			alarms, err = describeAlarmsByPrefix(ctx, cli, model)
+		} else {
+			alarms, err = describeAlarmsOfMetric(ctx, cli, model)
+		}
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+
+		frame := alarmStatesFrame(alarms)
+		frame.RefID = query.RefID
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+	}
+	return resp, nil
+}
+
+// describeAlarmsByPrefix returns the metric and composite alarms whose names and actions start with the prefixes of
+// the query.
+func describeAlarmsByPrefix(ctx context.Context, cli models.CWClient, model DataQueryJson) ([]alarmState, error) {
+	paginator := cloudwatch.NewDescribeAlarmsPaginator(cli, &cloudwatch.DescribeAlarmsInput{
+		ActionPrefix:    model.ActionPrefix,
+		AlarmNamePrefix: model.AlarmNamePrefix,
+		AlarmTypes:      []cloudwatchtypes.AlarmType{cloudwatchtypes.AlarmTypeMetricAlarm, cloudwatchtypes.AlarmTypeCompositeAlarm},
+		MaxRecords:      aws.Int32(100),
+	})
+	var alarms []alarmState
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarms", err))
+		}
+		for _, alarm := range page.MetricAlarms {
+			alarms = append(alarms, metricAlarmState(alarm))
+		}
+		for _, alarm := range page.CompositeAlarms {
+			alarms = append(alarms, alarmState{
+				name:         aws.ToString(alarm.AlarmName),
+				state:        string(alarm.StateValue),
+				reason:       aws.ToString(alarm.StateReason),
+				transitioned: alarmTransitionTime(alarm.StateTransitionedTimestamp, alarm.StateUpdatedTimestamp),
+			})
+		}
+	}
+	return alarms, nil
+}
+
+// describeAlarmsOfMetric returns the alarms of the metric of the query, filtered by its dimensions, statistic and
+// period when they are set.
+func describeAlarmsOfMetric(ctx context.Context, cli models.CWClient, model DataQueryJson) ([]alarmState, error) {
+	if model.Namespace == "" || model.MetricName == nil || *model.MetricName == "" {
+		return nil, backend.DownstreamError(errors.New("invalid alarm query: a namespace and metric name are required unless prefix matching is enabled"))
+	}
+
+	input := &cloudwatch.DescribeAlarmsForMetricInput{
+		Namespace:  aws.String(model.Namespace),
+		MetricName: model.MetricName,
+	}
+	if model.Dimensions != nil {
+		for name, values := range *model.Dimensions {
+			for _, value := range values.ArrayOfString {
+				input.Dimensions = append(input.Dimensions, cloudwatchtypes.Dimension{Name: aws.String(name), Value: aws.String(value)})
+			}
+		}
+	}
+	if model.Statistic != nil && *model.Statistic != "" {
+		input.Statistic = cloudwatchtypes.Statistic(*model.Statistic)
+	}
+	if model.Period != nil && *model.Period != "" {
+		period, err := strconv.ParseInt(*model.Period, 10, 32)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("query period must be an int"))
+		}
+		input.Period = aws.Int32(int32(period))
+	}
+
+	resp, err := cli.DescribeAlarmsForMetric(ctx, input)
+	if err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("%v: %w", "failed to call cloudwatch:DescribeAlarmsForMetric", err))
+	}
+	alarms := make([]alarmState, 0, len(resp.MetricAlarms))
+	for _, alarm := range resp.MetricAlarms {
+		alarms = append(alarms, metricAlarmState(alarm))
+	}
+	return alarms, nil
+}
+
+func metricAlarmState(alarm cloudwatchtypes.MetricAlarm) alarmState {
+	return alarmState{
+		name:         aws.ToString(alarm.AlarmName),
+		state:        string(alarm.StateValue),
+		reason:       aws.ToString(alarm.StateReason),
+		transitioned: alarmTransitionTime(alarm.StateTransitionedTimestamp, alarm.StateUpdatedTimestamp),
+	}
+}
+
+// alarmTransitionTime returns when the alarm last changed state. Alarms that haven't changed state since the
+// transition time was introduced only have the time their state was last updated.
+func alarmTransitionTime(transitioned, updated *time.Time) time.Time {
+	if transitioned != nil {
+		return transitioned.UTC()
+	}
+	return aws.ToTime(updated).UTC()
+}
+
+func alarmStatesFrame(alarms []alarmState) *data.Frame {
+	frame := data.NewFrame("alarms",
+		data.NewField("name", nil, []string{}),
+		data.NewField("state", nil, []string{}),
+		data.NewField("reason", nil, []string{}),
+		data.NewField("stateTransitioned", nil, []time.Time{}).SetConfig(&data.FieldConfig{DisplayName: "State transitioned"}),
+	)
+	for _, alarm := range alarms {
+		frame.AppendRow(alarm.name, alarm.state, alarm.reason, alarm.transitioned)
+	}
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+	return frame
+}
@@ -0,0 +1,133 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_AlarmQuery(t *testing.T) {
+	ds := newTestDatasource()
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+
+	var client fakeCWAnnotationsClient
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &client
+	}
+
+	transitioned := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	t.Run("returns the state of the alarms of the metric as a table", func(t *testing.T) {
+		client = fakeCWAnnotationsClient{describeAlarmsForMetricOutput: &cloudwatch.DescribeAlarmsForMetricOutput{
+			MetricAlarms: []cloudwatchtypes.MetricAlarm{{
+				AlarmName:                  aws.String("high-cpu"),
+				StateValue:                 cloudwatchtypes.StateValueAlarm,
+				StateReason:                aws.String("Threshold Crossed"),
+				StateTransitionedTimestamp: &transitioned,
+				StateUpdatedTimestamp:      &updated,
+			}},
+		}}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID: "A",
+				JSON: json.RawMessage(`{
+					"type": "alarmQuery",
+					"queryMode": "Alarms",
+					"region": "us-east-1",
+					"namespace": "AWS/EC2",
+					"metricName": "CPUUtilization",
+					"dimensions": {"InstanceId": ["i-123"]}
+				}`),
+			}},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, client.calls.describeAlarmsForMetric, 1)
+		assert.Equal(t, &cloudwatch.DescribeAlarmsForMetricInput{
+			Namespace:  aws.String("AWS/EC2"),
+			MetricName: aws.String("CPUUtilization"),
+			Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-123")}},
+		}, client.calls.describeAlarmsForMetric[0])
+
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		frame := resp.Responses["A"].Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		require.Equal(t, 1, frame.Rows())
+		assert.Equal(t, []any{"high-cpu", "ALARM", "Threshold Crossed", transitioned}, frame.RowCopy(0))
+	})
+
+	t.Run("lists metric and composite alarms by prefix when prefix matching is enabled", func(t *testing.T) {
+		client = fakeCWAnnotationsClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{
+			MetricAlarms: []cloudwatchtypes.MetricAlarm{{
+				AlarmName:             aws.String("team-high-cpu"),
+				StateValue:            cloudwatchtypes.StateValueOk,
+				StateReason:           aws.String("Threshold not crossed"),
+				StateUpdatedTimestamp: &updated,
+			}},
+			CompositeAlarms: []cloudwatchtypes.CompositeAlarm{{
+				AlarmName:                  aws.String("team-service"),
+				StateValue:                 cloudwatchtypes.StateValueInsufficientData,
+				StateReason:                aws.String("Unchecked: Initial alarm creation"),
+				StateTransitionedTimestamp: &transitioned,
+			}},
+		}}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID: "A",
+				JSON: json.RawMessage(`{
+					"queryMode": "Alarms",
+					"region": "us-east-1",
+					"prefixMatching": true,
+					"alarmNamePrefix": "team-"
+				}`),
+			}},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, client.calls.describeAlarms, 1)
+		assert.Equal(t, aws.String("team-"), client.calls.describeAlarms[0].AlarmNamePrefix)
+		assert.Equal(t, []cloudwatchtypes.AlarmType{cloudwatchtypes.AlarmTypeMetricAlarm, cloudwatchtypes.AlarmTypeCompositeAlarm},
+			client.calls.describeAlarms[0].AlarmTypes)
+
+		frame := resp.Responses["A"].Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, []any{"team-high-cpu", "OK", "Threshold not crossed", updated}, frame.RowCopy(0))
+		assert.Equal(t, []any{"team-service", "INSUFFICIENT_DATA", "Unchecked: Initial alarm creation", transitioned}, frame.RowCopy(1))
+	})
+
+	t.Run("fails a query without a metric when prefix matching is disabled", func(t *testing.T) {
+		client = fakeCWAnnotationsClient{}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID: "A",
+				JSON:  json.RawMessage(`{"type": "alarmQuery", "region": "us-east-1"}`),
+			}},
+		})
+		require.NoError(t, err)
+
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+		assert.Empty(t, client.calls.describeAlarmsForMetric)
+	})
+}
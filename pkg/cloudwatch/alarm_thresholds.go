@@ -0,0 +1,117 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// setAlarmThresholds sets the thresholds of the series of a query to the thresholds of the alarms watching them, so
+// that panels show the same lines as the alarms. An alarm watches a series if it uses the statistic of the query and
+// its dimensions are labels of the series. Only builder queries of a metric are supported, and instant queries
+// aren't, as their table has no series. Failing to fetch the alarms only adds a notice, as the series are still valid.
+func (ds *DataSource) setAlarmThresholds(ctx context.Context, frames data.Frames, query *models.CloudWatchQuery) {
+	if !query.AlarmThresholds || query.Instant || len(frames) == 0 {
+		return
+	}
+	if mode := query.GetGetMetricDataAPIMode(); mode != models.GMDApiModeMetricStat && mode != models.GMDApiModeInferredSearchExpression {
+		return
+	}
+
+	alarms, err := ds.describeAlarmsForMetric(ctx, query)
+	if err != nil {
+		frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("The thresholds of the alarms of the metric couldn't be fetched: %s", err),
+		})
+		return
+	}
+
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if field.Labels == nil {
+				continue
+			}
+			var watching []cloudwatchtypes.MetricAlarm
+			for _, alarm := range alarms {
+				if alarmWatchesSeries(alarm, query.Statistic, field.Labels) {
+					watching = append(watching, alarm)
+				}
+			}
+			if thresholds := alarmThresholdsConfig(watching); thresholds != nil {
+				if field.Config == nil {
+					field.Config = &data.FieldConfig{}
+				}
+				field.Config.Thresholds = thresholds
+				if field.Config.Custom == nil {
+					field.Config.Custom = map[string]any{}
+				}
+				field.Config.Custom["thresholdsStyle"] = map[string]any{"mode": "line"}
+			}
+		}
+	}
+}
+
+func (ds *DataSource) describeAlarmsForMetric(ctx context.Context, query *models.CloudWatchQuery) ([]cloudwatchtypes.MetricAlarm, error) {
+	client, err := ds.getCWClient(ctx, query.Region)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.DescribeAlarmsForMetric(ctx, &cloudwatch.DescribeAlarmsForMetricInput{
+		Namespace:  aws.String(query.Namespace),
+		MetricName: aws.String(query.MetricName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.MetricAlarms, nil
+}
+
+func alarmWatchesSeries(alarm cloudwatchtypes.MetricAlarm, statistic string, labels data.Labels) bool {
+	if alarm.Threshold == nil || (string(alarm.Statistic) != statistic && aws.ToString(alarm.ExtendedStatistic) != statistic) {
+		return false
+	}
+	for _, dimension := range alarm.Dimensions {
+		if value, ok := labels[aws.ToString(dimension.Name)]; !ok || value != aws.ToString(dimension.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// alarmThresholdsConfig returns the thresholds of the alarms, red beyond the closest thresholds the alarms of either
+// direction fire at, or nil without alarms.
+func alarmThresholdsConfig(alarms []cloudwatchtypes.MetricAlarm) *data.ThresholdsConfig {
+	upper, lower := math.Inf(1), math.Inf(-1)
+	for _, alarm := range alarms {
+		threshold := aws.ToFloat64(alarm.Threshold)
+		switch operator := string(alarm.ComparisonOperator); {
+		case strings.HasPrefix(operator, "GreaterThan"):
+			upper = math.Min(upper, threshold)
+		case strings.HasPrefix(operator, "LessThan"):
+			lower = math.Max(lower, threshold)
+		}
+	}
+	if math.IsInf(upper, 1) && math.IsInf(lower, -1) {
+		return nil
+	}
+
+	thresholds := &data.ThresholdsConfig{Mode: data.ThresholdsModeAbsolute}
+	if math.IsInf(lower, -1) {
+		thresholds.Steps = append(thresholds.Steps, data.NewThreshold(math.Inf(-1), "green", ""))
+	} else {
+		thresholds.Steps = append(thresholds.Steps, data.NewThreshold(math.Inf(-1), "red", ""), data.NewThreshold(lower, "green", ""))
+	}
+	if !math.IsInf(upper, 1) {
+		thresholds.Steps = append(thresholds.Steps, data.NewThreshold(upper, "red", ""))
+	}
+	return thresholds
+}
@@ -0,0 +1,136 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type failingAlarmsClient struct {
+	fakeCWAnnotationsClient
+}
+
+func (c *failingAlarmsClient) DescribeAlarmsForMetric(context.Context, *cloudwatch.DescribeAlarmsForMetricInput, ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsForMetricOutput, error) {
+	return nil, errors.New("access denied")
+}
+
+func TestSetAlarmThresholds(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+
+	alarm := func(instanceId string, statistic cloudwatchtypes.Statistic, operator cloudwatchtypes.ComparisonOperator, threshold float64) cloudwatchtypes.MetricAlarm {
+		return cloudwatchtypes.MetricAlarm{
+			Dimensions:         []cloudwatchtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String(instanceId)}},
+			Statistic:          statistic,
+			ComparisonOperator: operator,
+			Threshold:          aws.Float64(threshold),
+		}
+	}
+	series := func(instanceId string) *data.Frame {
+		return data.NewFrame(instanceId,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{"InstanceId": instanceId}, []float64{}),
+		)
+	}
+	query := func() *models.CloudWatchQuery {
+		return &models.CloudWatchQuery{
+			Region:           "us-east-1",
+			Namespace:        "AWS/EC2",
+			MetricName:       "CPUUtilization",
+			Statistic:        "Average",
+			Dimensions:       map[string][]string{"InstanceId": {"*"}},
+			MetricQueryType:  models.MetricQueryTypeSearch,
+			MetricEditorMode: models.MetricEditorModeBuilder,
+			AlarmThresholds:  true,
+		}
+	}
+
+	t.Run("sets the thresholds of the alarms watching each series", func(t *testing.T) {
+		client := &fakeCWAnnotationsClient{describeAlarmsForMetricOutput: &cloudwatch.DescribeAlarmsForMetricOutput{
+			MetricAlarms: []cloudwatchtypes.MetricAlarm{
+				alarm("i-1", cloudwatchtypes.StatisticAverage, cloudwatchtypes.ComparisonOperatorGreaterThanThreshold, 90),
+				alarm("i-1", cloudwatchtypes.StatisticAverage, cloudwatchtypes.ComparisonOperatorGreaterThanOrEqualToThreshold, 80),
+				alarm("i-1", cloudwatchtypes.StatisticMaximum, cloudwatchtypes.ComparisonOperatorGreaterThanThreshold, 70),
+				alarm("i-2", cloudwatchtypes.StatisticAverage, cloudwatchtypes.ComparisonOperatorLessThanThreshold, 5),
+			},
+		}}
+		NewCWClient = func(aws.Config) models.CWClient {
+			return client
+		}
+		frames := data.Frames{series("i-1"), series("i-2"), series("i-3")}
+
+		newTestDatasource().setAlarmThresholds(context.Background(), frames, query())
+
+		require.Len(t, client.calls.describeAlarmsForMetric, 1)
+		assert.Equal(t, "AWS/EC2", *client.calls.describeAlarmsForMetric[0].Namespace)
+		assert.Equal(t, "CPUUtilization", *client.calls.describeAlarmsForMetric[0].MetricName)
+
+		assert.Equal(t, &data.ThresholdsConfig{Mode: data.ThresholdsModeAbsolute, Steps: []data.Threshold{
+			data.NewThreshold(math.Inf(-1), "green", ""),
+			data.NewThreshold(80, "red", ""),
+		}}, frames[0].Fields[1].Config.Thresholds)
+		assert.Equal(t, map[string]any{"mode": "line"}, frames[0].Fields[1].Config.Custom["thresholdsStyle"])
+		assert.Equal(t, &data.ThresholdsConfig{Mode: data.ThresholdsModeAbsolute, Steps: []data.Threshold{
+			data.NewThreshold(math.Inf(-1), "red", ""),
+			data.NewThreshold(5, "green", ""),
+		}}, frames[1].Fields[1].Config.Thresholds)
+		assert.Nil(t, frames[2].Fields[1].Config)
+		assert.Nil(t, frames[0].Fields[0].Config)
+	})
+
+	t.Run("adds a notice when the alarms can't be fetched", func(t *testing.T) {
+		NewCWClient = func(aws.Config) models.CWClient {
+			return &failingAlarmsClient{}
+		}
+		frames := data.Frames{series("i-1")}
+
+		newTestDatasource().setAlarmThresholds(context.Background(), frames, query())
+
+		require.Len(t, frames[0].Meta.Notices, 1)
+		assert.Equal(t, "The thresholds of the alarms of the metric couldn't be fetched: access denied", frames[0].Meta.Notices[0].Text)
+		assert.Nil(t, frames[0].Fields[1].Config)
+	})
+
+	t.Run("doesn't fetch the alarms of queries written in code or not asking for them", func(t *testing.T) {
+		client := &fakeCWAnnotationsClient{}
+		NewCWClient = func(aws.Config) models.CWClient {
+			return client
+		}
+
+		withoutThresholds := query()
+		withoutThresholds.AlarmThresholds = false
+		newTestDatasource().setAlarmThresholds(context.Background(), data.Frames{series("i-1")}, withoutThresholds)
+
+		code := query()
+		code.MetricEditorMode = models.MetricEditorModeRaw
+		code.Expression = "m1 * 2"
+		newTestDatasource().setAlarmThresholds(context.Background(), data.Frames{series("i-1")}, code)
+
+		assert.Empty(t, client.calls.describeAlarmsForMetric)
+	})
+}
+
+func TestAlarmThresholdsConfig(t *testing.T) {
+	assert.Nil(t, alarmThresholdsConfig(nil))
+	assert.Equal(t, &data.ThresholdsConfig{Mode: data.ThresholdsModeAbsolute, Steps: []data.Threshold{
+		data.NewThreshold(math.Inf(-1), "red", ""),
+		data.NewThreshold(10, "green", ""),
+		data.NewThreshold(90, "red", ""),
+	}}, alarmThresholdsConfig([]cloudwatchtypes.MetricAlarm{
+		{ComparisonOperator: cloudwatchtypes.ComparisonOperatorGreaterThanThreshold, Threshold: aws.Float64(90)},
+		{ComparisonOperator: cloudwatchtypes.ComparisonOperatorLessThanOrEqualToThreshold, Threshold: aws.Float64(10)},
+		{ComparisonOperator: cloudwatchtypes.ComparisonOperatorLessThanThreshold, Threshold: aws.Float64(2)},
+	}))
+}
@@ -0,0 +1,70 @@
+package cloudwatch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_anomaly_detectors_route(t *testing.T) {
+	origNewAnomalyDetectorsAPI := NewAnomalyDetectorsAPI
+	t.Cleanup(func() {
+		NewAnomalyDetectorsAPI = origNewAnomalyDetectorsAPI
+	})
+	ds := newTestDatasource()
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.AnomalyDetectorsHandler))
+
+	t.Run("returns the anomaly detectors of the metric", func(t *testing.T) {
+		client := &mocks.FakeAnomalyDetectorsClient{}
+		client.On("DescribeAnomalyDetectors", mock.Anything).Return(&cloudwatch.DescribeAnomalyDetectorsOutput{
+			AnomalyDetectors: []cloudwatchtypes.AnomalyDetector{{
+				SingleMetricAnomalyDetector: &cloudwatchtypes.SingleMetricAnomalyDetector{
+					Namespace:  aws.String("AWS/EC2"),
+					MetricName: aws.String("CPUUtilization"),
+					Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}},
+					Stat:       aws.String("Average"),
+				},
+				StateValue: cloudwatchtypes.AnomalyDetectorStateValueTrained,
+			}},
+		}, nil)
+		NewAnomalyDetectorsAPI = func(aws.Config) models.AnomalyDetectorsAPIProvider {
+			return client
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", `/anomaly-detectors?region=us-east-1&namespace=AWS/EC2&metricName=CPUUtilization&dimensionFilters={"InstanceId":"i-1"}`, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"value":{"namespace":"AWS/EC2","metricName":"CPUUtilization","dimensions":{"InstanceId":"i-1"},"stat":"Average","state":"TRAINED"}}]`, rr.Body.String())
+	})
+
+	t.Run("returns an error when the region is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/anomaly-detectors", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns an error when DescribeAnomalyDetectors fails", func(t *testing.T) {
+		client := &mocks.FakeAnomalyDetectorsClient{}
+		client.On("DescribeAnomalyDetectors", mock.Anything).Return(&cloudwatch.DescribeAnomalyDetectorsOutput{}, errors.New("access denied"))
+		NewAnomalyDetectorsAPI = func(aws.Config) models.AnomalyDetectorsAPIProvider {
+			return client
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/anomaly-detectors?region=us-east-1", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
@@ -0,0 +1,180 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const (
+	headerDashboardUID = "X-Dashboard-Uid"
+	headerPanelID      = "X-Panel-Id"
+	// headerAWSAPICalls is the response header of resource calls reporting the AWS API calls they made
+	headerAWSAPICalls = "X-Grafana-Aws-Api-Calls"
+
+	// apiBudgetWindow is the period dashboard API budgets are counted over
+	apiBudgetWindow = time.Minute
+)
+
+type dashboardKey struct{}
+
+type dashboardRequest struct {
+	uid     string
+	panelID string
+}
+
+// withDashboard stores the dashboard and panel a query was sent from in ctx, so AWS API calls can
+// be counted against the dashboard's budget.
+func withDashboard(ctx context.Context, getHeader func(string) string) context.Context {
+	uid := getHeader(headerDashboardUID)
+	if uid == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, dashboardKey{}, dashboardRequest{uid: uid, panelID: getHeader(headerPanelID)})
+}
+
+func dashboardFromContext(ctx context.Context) (dashboardRequest, bool) {
+	dashboard, ok := ctx.Value(dashboardKey{}).(dashboardRequest)
+	return dashboard, ok
+}
+
+type apiBudgetUsage struct {
+	windowStart time.Time
+	calls       int
+}
+
+// apiBudgetTracker counts AWS API calls per dashboard in fixed windows of apiBudgetWindow.
+type apiBudgetTracker struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	usage map[string]*apiBudgetUsage
+}
+
+func newAPIBudgetTracker() *apiBudgetTracker {
+	return &apiBudgetTracker{now: time.Now, usage: map[string]*apiBudgetUsage{}}
+}
+
+// take records a call for dashboardUID and reports whether it is within budget.
+func (t *apiBudgetTracker) take(dashboardUID string, budget int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	usage, ok := t.usage[dashboardUID]
+	if !ok || now.Sub(usage.windowStart) >= apiBudgetWindow {
+		t.pruneExpired(now)
+		usage = &apiBudgetUsage{windowStart: now}
+		t.usage[dashboardUID] = usage
+	}
+	if usage.calls >= budget {
+		return false
+	}
+	usage.calls++
+	return true
+}
+
+func (t *apiBudgetTracker) pruneExpired(now time.Time) {
+	for uid, usage := range t.usage {
+		if now.Sub(usage.windowStart) >= apiBudgetWindow {
+			delete(t.usage, uid)
+		}
+	}
+}
+
+// dashboardAPIBudget returns the number of AWS API calls dashboardUID may make per window, or 0 if unlimited.
+func (ds *DataSource) dashboardAPIBudget(dashboardUID string) int {
+	if budget, ok := ds.Settings.DashboardAPIBudgets[dashboardUID]; ok {
+		return budget
+	}
+	return ds.Settings.DashboardAPIBudget
+}
+
+// withAPIBudget returns a copy of cfg whose clients fail AWS API calls made on behalf of a dashboard
+// that has used up its budget, before they count towards the account-wide CloudWatch quotas.
+func (ds *DataSource) withAPIBudget(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(slices.Clone(cfg.APIOptions), func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("DashboardAPIBudget",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				dashboard, ok := dashboardFromContext(ctx)
+				if !ok {
+					return next.HandleInitialize(ctx, in)
+				}
+				budget := ds.dashboardAPIBudget(dashboard.uid)
+				if budget > 0 && !ds.apiBudgets.take(dashboard.uid, budget) {
+					return middleware.InitializeOutput{}, middleware.Metadata{}, backend.DownstreamError(fmt.Errorf(
+						"dashboard %s (panel %s) has used its budget of %d CloudWatch API calls per minute; reduce the number of queries or raise the refresh interval, or ask an administrator to raise the dashboard's budget in the data source settings",
+						dashboard.uid, dashboard.panelID, budget))
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.After)
+	})
+	return cfg
+}
+
+type requestAPICallsKey struct{}
+
+// requestAPICalls counts the AWS API calls made for a single QueryData or CallResource request, which may make at
+// most budget calls, or any number of calls if budget is 0.
+type requestAPICalls struct {
+	budget int64
+	calls  atomic.Int64
+}
+
+// withRequestAPICalls starts counting the AWS API calls made with ctx against the per-request budget of the data
+// source.
+func (ds *DataSource) withRequestAPICalls(ctx context.Context) (context.Context, *requestAPICalls) {
+	calls := &requestAPICalls{budget: int64(max(ds.Settings.RequestAPICallBudget, 0))}
+	return context.WithValue(ctx, requestAPICallsKey{}, calls), calls
+}
+
+func requestAPICallsFromContext(ctx context.Context) *requestAPICalls {
+	calls, _ := ctx.Value(requestAPICallsKey{}).(*requestAPICalls)
+	return calls
+}
+
+// count returns the number of AWS API calls made for the request so far.
+func (c *requestAPICalls) count() int64 {
+	return c.calls.Load()
+}
+
+// withRequestAPIBudget returns a copy of cfg whose clients fail AWS API calls once the request they're made for has
+// used up the per-request budget of the data source, so that a runaway chain of variables or a SEARCH expression
+// matching far more metrics than expected fails fast rather than exhausting the account-wide CloudWatch quotas.
+func withRequestAPIBudget(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(slices.Clone(cfg.APIOptions), func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("RequestAPIBudget",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				calls := requestAPICallsFromContext(ctx)
+				if calls == nil {
+					return next.HandleInitialize(ctx, in)
+				}
+				if count := calls.calls.Add(1); calls.budget > 0 && count > calls.budget {
+					return middleware.InitializeOutput{}, middleware.Metadata{}, backend.DownstreamError(fmt.Errorf(
+						"the request has used its budget of %d CloudWatch API calls; narrow down the queries or variables making the calls, e.g. SEARCH expressions or wildcard dimensions, or ask an administrator to raise the per-request budget in the data source settings",
+						calls.budget))
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.After)
+	})
+	return cfg
+}
+
+// withAPICallsHeader returns a sender adding the AWS API calls made for the request to the headers of the response
+// of a resource call.
+func withAPICallsHeader(sender backend.CallResourceResponseSender, calls *requestAPICalls) backend.CallResourceResponseSender {
+	return backend.CallResourceResponseSenderFunc(func(resp *backend.CallResourceResponse) error {
+		if resp.Headers == nil {
+			resp.Headers = map[string][]string{}
+		}
+		resp.Headers[headerAWSAPICalls] = []string{strconv.FormatInt(calls.count(), 10)}
+		return sender.Send(resp)
+	})
+}
@@ -0,0 +1,131 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_apiBudgetTracker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newAPIBudgetTracker()
+	tracker.now = func() time.Time { return now }
+
+	assert.True(t, tracker.take("dash-a", 2))
+	assert.True(t, tracker.take("dash-a", 2))
+	assert.False(t, tracker.take("dash-a", 2))
+	assert.True(t, tracker.take("dash-b", 2), "budgets are tracked per dashboard")
+
+	now = now.Add(apiBudgetWindow)
+	assert.True(t, tracker.take("dash-a", 2), "the budget resets after the window")
+	assert.NotContains(t, tracker.usage, "dash-b", "expired windows are pruned")
+}
+
+func Test_dashboardAPIBudget(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.DashboardAPIBudget = 100
+		ds.Settings.DashboardAPIBudgets = map[string]int{"busy": 500, "unlimited": 0}
+	})
+
+	assert.Equal(t, 100, ds.dashboardAPIBudget("other"))
+	assert.Equal(t, 500, ds.dashboardAPIBudget("busy"))
+	assert.Equal(t, 0, ds.dashboardAPIBudget("unlimited"))
+}
+
+func Test_withAPIBudget(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.apiBudgets = newAPIBudgetTracker()
+		ds.Settings.DashboardAPIBudget = 1
+	})
+	cfg := ds.withAPIBudget(aws.Config{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		HTTPClient:       failingHTTPClient{},
+		RetryMaxAttempts: 1,
+	})
+	client := cloudwatch.NewFromConfig(cfg)
+
+	req := &backend.QueryDataRequest{}
+	req.SetHTTPHeader(headerDashboardUID, "dash-a")
+	req.SetHTTPHeader(headerPanelID, "2")
+	ctx := withDashboard(context.Background(), req.GetHTTPHeader)
+
+	_, err := client.ListMetrics(ctx, &cloudwatch.ListMetricsInput{})
+	assert.ErrorIs(t, err, errReachedTransport)
+
+	_, err = client.ListMetrics(ctx, &cloudwatch.ListMetricsInput{})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, errReachedTransport)
+	assert.ErrorContains(t, err, "dashboard dash-a (panel 2) has used its budget of 1 CloudWatch API calls per minute")
+	assert.True(t, backend.IsDownstreamError(err))
+
+	_, err = client.ListMetrics(context.Background(), &cloudwatch.ListMetricsInput{})
+	assert.ErrorIs(t, err, errReachedTransport, "calls not made for a dashboard are not limited")
+}
+
+func Test_withRequestAPIBudget(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.RequestAPICallBudget = 2
+	})
+	client := cloudwatch.NewFromConfig(withRequestAPIBudget(aws.Config{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		HTTPClient:       failingHTTPClient{},
+		RetryMaxAttempts: 1,
+	}))
+
+	ctx, calls := ds.withRequestAPICalls(context.Background())
+	for range 2 {
+		_, err := client.ListMetrics(ctx, &cloudwatch.ListMetricsInput{})
+		assert.ErrorIs(t, err, errReachedTransport)
+	}
+	_, err := client.ListMetrics(ctx, &cloudwatch.ListMetricsInput{})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, errReachedTransport)
+	assert.ErrorContains(t, err, "the request has used its budget of 2 CloudWatch API calls")
+	assert.True(t, backend.IsDownstreamError(err))
+	assert.Equal(t, int64(3), calls.count())
+
+	otherCtx, _ := ds.withRequestAPICalls(context.Background())
+	_, err = client.ListMetrics(otherCtx, &cloudwatch.ListMetricsInput{})
+	assert.ErrorIs(t, err, errReachedTransport, "budgets are counted per request")
+	_, err = client.ListMetrics(context.Background(), &cloudwatch.ListMetricsInput{})
+	assert.ErrorIs(t, err, errReachedTransport, "calls not made for a request are not limited")
+}
+
+func Test_CallResource_reports_API_calls(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	NewCWClient = func(cfg aws.Config) models.CWClient {
+		return cloudwatch.NewFromConfig(cfg, func(o *cloudwatch.Options) {
+			o.Credentials = aws.AnonymousCredentials{}
+			o.HTTPClient = failingHTTPClient{}
+			o.RetryMaxAttempts = 1
+		})
+	}
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.Region = "us-east-1"
+		ds.Settings.GrafanaSettings.ListMetricsPageLimit = 1
+		ds.AWSConfigProvider = regionConfigProvider{}
+	})
+
+	sender := &mockedCallResourceResponseSenderForOauth{}
+	err := ds.CallResource(context.Background(), &backend.CallResourceRequest{
+		Method: "GET",
+		Path:   "/metrics?region=us-east-1&namespace=Custom",
+		URL:    "/metrics?region=us-east-1&namespace=Custom",
+	}, sender)
+	require.NoError(t, err)
+	require.NotNil(t, sender.Response)
+	assert.Equal(t, []string{"1"}, sender.Response.Headers[headerAWSAPICalls])
+}
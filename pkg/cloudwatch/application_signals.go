@@ -0,0 +1,219 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationsignals"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// sloNamespace is the namespace Application Signals publishes the metrics of the SLOs to, by the SloName dimension.
+const sloNamespace = "AWS/ApplicationSignals"
+
+// applicationSignalsServicesWindow is how far back the services listed by ApplicationSignalsServicesHandler must have
+// reported telemetry, as ListServices only lists the services of a time range.
+const applicationSignalsServicesWindow = 24 * time.Hour
+
+type sloQueryModel struct {
+	MetricQueryType       dataquery.MetricQueryType `json:"metricQueryType"`
+	SloName               string                    `json:"sloName"`
+	SloMetric             dataquery.SLOMetric       `json:"sloMetric"`
+	BurnRateWindowMinutes int64                     `json:"burnRateWindowMinutes"`
+	Statistic             string                    `json:"statistic"`
+}
+
+func isSLOQuery(query backend.DataQuery) bool {
+	var model sloQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return false
+	}
+	return model.MetricQueryType == dataquery.MetricQueryTypeSLO
+}
+
+// hasSLOQueries returns whether any of the time series queries queries the metrics of an Application Signals SLO.
+func hasSLOQueries(queries []backend.DataQuery) bool {
+	return slices.ContainsFunc(queries, isSLOQuery)
+}
+
+// expandSLOQueries expands the SLO queries into search queries of the metric Application Signals publishes for their
+// SLO, so that SLO dashboards don't need to know the namespace and dimensions of the metrics. The responses of the
+// queries that are invalid are set in resp and the other queries are returned.
+func expandSLOQueries(queries []backend.DataQuery, resp *backend.QueryDataResponse) []backend.DataQuery {
+	expanded := make([]backend.DataQuery, 0, len(queries))
+	for _, query := range queries {
+		if !isSLOQuery(query) {
+			expanded = append(expanded, query)
+			continue
+		}
+		expandedQuery, err := expandSLOQuery(query)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		expanded = append(expanded, expandedQuery)
+	}
+	return expanded
+}
+
+// expandSLOQuery returns query as a search query of the metric of its SLO.
+func expandSLOQuery(query backend.DataQuery) (backend.DataQuery, error) {
+	var model sloQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return query, backend.DownstreamError(err)
+	}
+	if model.SloName == "" {
+		return query, backend.DownstreamError(errors.New("invalid SLO query: an SLO name is required"))
+	}
+	dimensions := map[string]any{"SloName": []string{model.SloName}}
+	switch model.SloMetric {
+	case "":
+		model.SloMetric = dataquery.SLOMetricAttainmentRate
+	case dataquery.SLOMetricAttainmentRate, dataquery.SLOMetricErrorBudgetRemaining:
+	case dataquery.SLOMetricBurnRate:
+		if model.BurnRateWindowMinutes < 1 {
+			return query, backend.DownstreamError(errors.New("invalid SLO query: the burn rate needs the look-back window of one of the burn rate configurations of the SLO"))
+		}
+		dimensions["BurnRateWindowMinutes"] = []string{strconv.FormatInt(model.BurnRateWindowMinutes, 10)}
+	default:
+		return query, backend.DownstreamError(fmt.Errorf("invalid SLO query: unknown SLO metric %q", model.SloMetric))
+	}
+
+	var queryModel map[string]any
+	if err := json.Unmarshal(query.JSON, &queryModel); err != nil {
+		return query, backend.DownstreamError(err)
+	}
+	queryModel["namespace"] = sloNamespace
+	queryModel["metricName"] = string(model.SloMetric)
+	queryModel["dimensions"] = dimensions
+	queryModel["matchExact"] = true
+	if model.Statistic == "" {
+		queryModel["statistic"] = "Average"
+	}
+	queryModel["metricQueryType"] = dataquery.MetricQueryTypeSearch
+	queryModel["metricEditorMode"] = dataquery.MetricEditorModeBuilder
+	delete(queryModel, "sloName")
+	delete(queryModel, "sloMetric")
+	delete(queryModel, "burnRateWindowMinutes")
+	queryJSON, err := json.Marshal(queryModel)
+	if err != nil {
+		return query, err
+	}
+	query.JSON = queryJSON
+	return query, nil
+}
+
+// ApplicationSignalsServicesHandler returns the services Application Signals discovered in the region of the request
+// that reported telemetry in the last day.
+func (ds *DataSource) ApplicationSignalsServicesHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	request, err := resources.ParseApplicationSignalsServicesRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in ApplicationSignalsServicesHandler", http.StatusBadRequest, err)
+	}
+
+	cfg, err := ds.newAWSConfig(ctx, request.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in ApplicationSignalsServicesHandler", http.StatusInternalServerError, err)
+	}
+
+	now := time.Now()
+	input := &applicationsignals.ListServicesInput{
+		StartTime: aws.Time(now.Add(-applicationSignalsServicesWindow)),
+		EndTime:   aws.Time(now),
+	}
+	if request.ShouldTargetAllAccounts() {
+		input.IncludeLinkedAccounts = true
+	} else if request.AccountId != nil {
+		input.IncludeLinkedAccounts = true
+		input.AwsAccountId = request.AccountId
+	}
+
+	response := []resources.ResourceResponse[resources.ApplicationSignalsService]{}
+	paginator := applicationsignals.NewListServicesPaginator(NewApplicationSignalsAPI(cfg), input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, models.NewHttpError("error in ApplicationSignalsServicesHandler", http.StatusInternalServerError, fmt.Errorf("ListServices error: %w", err))
+		}
+		for _, service := range page.ServiceSummaries {
+			value := resources.ApplicationSignalsService{
+				Name:          service.KeyAttributes["Name"],
+				Environment:   service.KeyAttributes["Environment"],
+				KeyAttributes: service.KeyAttributes,
+			}
+			resourceResponse := resources.ResourceResponse[resources.ApplicationSignalsService]{Value: value}
+			if accountId, ok := service.KeyAttributes["AwsAccountId"]; ok {
+				resourceResponse.AccountId = aws.String(accountId)
+			}
+			response = append(response, resourceResponse)
+		}
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in ApplicationSignalsServicesHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
+
+// ServiceLevelObjectivesHandler returns the Application Signals SLOs of the region of the request, of the service of
+// the request if it has one.
+func (ds *DataSource) ServiceLevelObjectivesHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	request, err := resources.ParseServiceLevelObjectivesRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in ServiceLevelObjectivesHandler", http.StatusBadRequest, err)
+	}
+
+	cfg, err := ds.newAWSConfig(ctx, request.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in ServiceLevelObjectivesHandler", http.StatusInternalServerError, err)
+	}
+
+	input := &applicationsignals.ListServiceLevelObjectivesInput{
+		IncludeLinkedAccounts: request.ShouldTargetAllAccounts(),
+	}
+	if request.Service != "" {
+		input.KeyAttributes = map[string]string{"Type": "Service", "Name": request.Service}
+		if request.Environment != "" {
+			input.KeyAttributes["Environment"] = request.Environment
+		}
+	}
+
+	response := []resources.ResourceResponse[resources.ServiceLevelObjective]{}
+	paginator := applicationsignals.NewListServiceLevelObjectivesPaginator(NewApplicationSignalsAPI(cfg), input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, models.NewHttpError("error in ServiceLevelObjectivesHandler", http.StatusInternalServerError, fmt.Errorf("ListServiceLevelObjectives error: %w", err))
+		}
+		for _, slo := range page.SloSummaries {
+			response = append(response, resources.ResourceResponse[resources.ServiceLevelObjective]{
+				Value: resources.ServiceLevelObjective{
+					Name:           aws.ToString(slo.Name),
+					Arn:            aws.ToString(slo.Arn),
+					KeyAttributes:  slo.KeyAttributes,
+					OperationName:  aws.ToString(slo.OperationName),
+					EvaluationType: string(slo.EvaluationType),
+				},
+			})
+		}
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in ServiceLevelObjectivesHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
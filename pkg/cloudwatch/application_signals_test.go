@@ -0,0 +1,169 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationsignals"
+	applicationsignalstypes "github.com/aws/aws-sdk-go-v2/service/applicationsignals/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_SLOQueries(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	now := time.Now().Truncate(time.Minute)
+	queryData := func(t *testing.T, queryJSON string) (*backend.QueryDataResponse, *mocks.MetricsAPI) {
+		t.Helper()
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{}, nil)
+		NewCWClient = func(aws.Config) models.CWClient {
+			return api
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+		})
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON:      json.RawMessage(queryJSON),
+			}},
+		})
+		require.NoError(t, err)
+		return resp, api
+	}
+	metricStat := func(t *testing.T, api *mocks.MetricsAPI) *cloudwatch.GetMetricDataInput {
+		t.Helper()
+		require.Len(t, api.Calls, 1)
+		input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		require.Len(t, input.MetricDataQueries, 1)
+		return input
+	}
+
+	t.Run("queries the attainment rate of the SLO by default", func(t *testing.T) {
+		resp, api := queryData(t, `{"type":"timeSeriesQuery","id":"a","region":"default","period":"60","metricQueryType":4,
+			"sloName":"checkout-availability"}`)
+
+		require.NoError(t, resp.Responses["A"].Error)
+		input := metricStat(t, api)
+		stat := input.MetricDataQueries[0].MetricStat
+		require.NotNil(t, stat)
+		assert.Equal(t, "AWS/ApplicationSignals", aws.ToString(stat.Metric.Namespace))
+		assert.Equal(t, "AttainmentRate", aws.ToString(stat.Metric.MetricName))
+		assert.Equal(t, "Average", aws.ToString(stat.Stat))
+		require.Len(t, stat.Metric.Dimensions, 1)
+		assert.Equal(t, "SloName", aws.ToString(stat.Metric.Dimensions[0].Name))
+		assert.Equal(t, "checkout-availability", aws.ToString(stat.Metric.Dimensions[0].Value))
+	})
+
+	t.Run("queries the burn rate of the window of the query", func(t *testing.T) {
+		resp, api := queryData(t, `{"type":"timeSeriesQuery","id":"a","period":"60","metricQueryType":4,"statistic":"Maximum",
+			"sloName":"checkout-availability","sloMetric":"BurnRate","burnRateWindowMinutes":60}`)
+
+		require.NoError(t, resp.Responses["A"].Error)
+		stat := metricStat(t, api).MetricDataQueries[0].MetricStat
+		require.NotNil(t, stat)
+		assert.Equal(t, "BurnRate", aws.ToString(stat.Metric.MetricName))
+		assert.Equal(t, "Maximum", aws.ToString(stat.Stat))
+		dimensions := map[string]string{}
+		for _, dimension := range stat.Metric.Dimensions {
+			dimensions[aws.ToString(dimension.Name)] = aws.ToString(dimension.Value)
+		}
+		assert.Equal(t, map[string]string{"SloName": "checkout-availability", "BurnRateWindowMinutes": "60"}, dimensions)
+	})
+
+	t.Run("fails SLO queries that are invalid", func(t *testing.T) {
+		for name, queryJSON := range map[string]string{
+			"missing SLO name":    `{"type":"timeSeriesQuery","id":"a","period":"60","metricQueryType":4}`,
+			"missing window":      `{"type":"timeSeriesQuery","id":"a","period":"60","metricQueryType":4,"sloName":"slo","sloMetric":"BurnRate"}`,
+			"unknown SLO metrics": `{"type":"timeSeriesQuery","id":"a","period":"60","metricQueryType":4,"sloName":"slo","sloMetric":"Latency"}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				resp, api := queryData(t, queryJSON)
+
+				require.Error(t, resp.Responses["A"].Error)
+				assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+				assert.Empty(t, api.Calls)
+			})
+		}
+	})
+}
+
+func Test_application_signals_routes(t *testing.T) {
+	origNewApplicationSignalsAPI := NewApplicationSignalsAPI
+	t.Cleanup(func() {
+		NewApplicationSignalsAPI = origNewApplicationSignalsAPI
+	})
+	ds := newTestDatasource()
+
+	t.Run("returns the services of the region", func(t *testing.T) {
+		client := &mocks.FakeApplicationSignalsClient{}
+		client.On("ListServices", mock.Anything).Return(&applicationsignals.ListServicesOutput{
+			ServiceSummaries: []applicationsignalstypes.ServiceSummary{{
+				KeyAttributes: map[string]string{"Type": "Service", "Name": "checkout", "Environment": "eks:prod/default"},
+			}},
+		}, nil)
+		NewApplicationSignalsAPI = func(aws.Config) models.ApplicationSignalsAPIProvider {
+			return client
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.ApplicationSignalsServicesHandler))
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/application-signals-services?region=us-east-1", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"value":{"name":"checkout","environment":"eks:prod/default",
+			"keyAttributes":{"Type":"Service","Name":"checkout","Environment":"eks:prod/default"}}}]`, rr.Body.String())
+		input := client.Calls[0].Arguments.Get(0).(*applicationsignals.ListServicesInput)
+		assert.Equal(t, applicationSignalsServicesWindow, input.EndTime.Sub(*input.StartTime))
+	})
+
+	t.Run("returns the SLOs of the service of the request", func(t *testing.T) {
+		client := &mocks.FakeApplicationSignalsClient{}
+		client.On("ListServiceLevelObjectives", mock.Anything).Return(&applicationsignals.ListServiceLevelObjectivesOutput{
+			SloSummaries: []applicationsignalstypes.ServiceLevelObjectiveSummary{{
+				Name:           aws.String("checkout-availability"),
+				Arn:            aws.String("arn:aws:application-signals:us-east-1:123456789012:slo/checkout-availability"),
+				OperationName:  aws.String("POST /checkout"),
+				EvaluationType: applicationsignalstypes.EvaluationTypePeriodBased,
+			}},
+		}, nil)
+		NewApplicationSignalsAPI = func(aws.Config) models.ApplicationSignalsAPIProvider {
+			return client
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.ServiceLevelObjectivesHandler))
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/service-level-objectives?region=us-east-1&service=checkout&environment=eks%3Aprod%2Fdefault", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"value":{"name":"checkout-availability","arn":"arn:aws:application-signals:us-east-1:123456789012:slo/checkout-availability",
+			"operationName":"POST /checkout","evaluationType":"PeriodBased"}}]`, rr.Body.String())
+		input := client.Calls[0].Arguments.Get(0).(*applicationsignals.ListServiceLevelObjectivesInput)
+		assert.Equal(t, map[string]string{"Type": "Service", "Name": "checkout", "Environment": "eks:prod/default"}, input.KeyAttributes)
+	})
+
+	t.Run("returns an error when the region is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.ServiceLevelObjectivesHandler))
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/service-level-objectives", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
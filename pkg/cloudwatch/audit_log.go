@@ -0,0 +1,75 @@
+package cloudwatch
+
+import (
+	"context"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const auditLogMessage = "AWS API call"
+
+// withAuditLogging returns a copy of cfg whose clients write an audit log line for every AWS API call,
+// so that access to CloudWatch data can be attributed to the Grafana user that triggered it.
+func (ds *DataSource) withAuditLogging(cfg aws.Config) aws.Config {
+	credentials := cfg.Credentials
+	cfg.APIOptions = append(slices.Clone(cfg.APIOptions), func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AuditLog",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				ds.logAPICall(ctx, credentials, err)
+				return out, metadata, err
+			}), middleware.After)
+	})
+	return cfg
+}
+
+func (ds *DataSource) logAPICall(ctx context.Context, credentials aws.CredentialsProvider, err error) {
+	pCtx := backend.PluginConfigFromContext(ctx)
+	user, dsUID := "", ""
+	if pCtx.User != nil {
+		user = pCtx.User.Login
+	}
+	if pCtx.DataSourceInstanceSettings != nil {
+		dsUID = pCtx.DataSourceInstanceSettings.UID
+	}
+
+	params := []any{
+		"audit", true,
+		"user", user,
+		"datasourceUID", dsUID,
+		"service", awsmiddleware.GetServiceID(ctx),
+		"api", awsmiddleware.GetOperationName(ctx),
+		"region", awsmiddleware.GetRegion(ctx),
+		"account", ds.auditAccountID(ctx, credentials),
+	}
+	if err != nil {
+		params = append(params, "status", "error", "error", err.Error())
+	} else {
+		params = append(params, "status", "ok")
+	}
+	ds.logger.Info(auditLogMessage, params...)
+}
+
+// auditAccountID returns the AWS account the call was made as. Credentials are cached by the
+// provider so retrieving them again is cheap; if they don't carry an account ID the account of
+// the configured assume role is used instead.
+func (ds *DataSource) auditAccountID(ctx context.Context, credentials aws.CredentialsProvider) string {
+	if credentials != nil {
+		if creds, err := credentials.Retrieve(ctx); err == nil && creds.AccountID != "" {
+			return creds.AccountID
+		}
+	}
+	assumeRoleARN, err := ds.assumeRoleARN(ctx)
+	if err != nil {
+		return ""
+	}
+	if roleARN, err := arn.Parse(assumeRoleARN); err == nil {
+		return roleARN.AccountID
+	}
+	return ""
+}
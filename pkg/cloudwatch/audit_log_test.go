@@ -0,0 +1,105 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggedLine struct {
+	msg  string
+	args []any
+}
+
+type recordingLogger struct {
+	log.Logger
+	infos []loggedLine
+	warns []loggedLine
+}
+
+func (l *recordingLogger) Info(msg string, args ...any) {
+	l.infos = append(l.infos, loggedLine{msg: msg, args: args})
+}
+
+func (l *recordingLogger) Warn(msg string, args ...any) {
+	l.warns = append(l.warns, loggedLine{msg: msg, args: args})
+}
+
+func (l *recordingLogger) FromContext(context.Context) log.Logger {
+	return l
+}
+
+func Test_withAuditLogging(t *testing.T) {
+	ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{
+		User:                       &backend.User{Login: "alice"},
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "cw-uid"},
+	})
+
+	t.Run("logs the user, data source, api, region and account of each call", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewNullLogger()}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.logger = logger
+		})
+		cfg := ds.withAuditLogging(aws.Config{
+			Region: "eu-west-1",
+			Credentials: aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret", AccountID: "123456789012"}, nil
+			})),
+			HTTPClient:       failingHTTPClient{},
+			RetryMaxAttempts: 1,
+		})
+
+		_, err := cloudwatch.NewFromConfig(cfg).ListMetrics(ctx, &cloudwatch.ListMetricsInput{})
+		require.ErrorIs(t, err, errReachedTransport)
+
+		require.Len(t, logger.infos, 1)
+		assert.Equal(t, auditLogMessage, logger.infos[0].msg)
+		args := logger.infos[0].args
+		require.Len(t, args, 18)
+		assert.Equal(t, []any{
+			"audit", true,
+			"user", "alice",
+			"datasourceUID", "cw-uid",
+			"service", "CloudWatch",
+			"api", "ListMetrics",
+			"region", "eu-west-1",
+			"account", "123456789012",
+			"status", "error",
+			"error",
+		}, args[:17])
+		assert.Contains(t, args[17], "reached transport")
+	})
+
+	t.Run("falls back to the assume role account and logs calls blocked in read-only mode", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewNullLogger()}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.logger = logger
+			ds.Settings.AssumeRoleARN = "arn:aws:iam::210987654321:role/grafana"
+		})
+		cfg := withReadOnlyAPIGuard(ds.withAuditLogging(aws.Config{
+			Region:      "us-east-1",
+			Credentials: aws.AnonymousCredentials{},
+			HTTPClient:  failingHTTPClient{},
+		}))
+
+		_, err := cloudwatchlogs.NewFromConfig(cfg).CreateExportTask(ctx, &cloudwatchlogs.CreateExportTaskInput{
+			Destination:  aws.String("bucket"),
+			From:         aws.Int64(0),
+			To:           aws.Int64(1),
+			LogGroupName: aws.String("group"),
+		})
+		require.Error(t, err)
+
+		require.Len(t, logger.infos, 1)
+		assert.Contains(t, logger.infos[0].args, "CreateExportTask")
+		assert.Contains(t, logger.infos[0].args, "210987654321")
+		assert.Contains(t, logger.infos[0].args, "error")
+	})
+}
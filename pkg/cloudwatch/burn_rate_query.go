@@ -0,0 +1,225 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	burnRateGoodId    = "good"
+	burnRateTotalId   = "total"
+	burnRateLabel     = "window"
+	burnRatePeriod    = 60
+	maxBurnRateWindow = 30 * 24 * time.Hour
+)
+
+// defaultBurnRateWindows are the windows of the usual multi-window burn rate alerts
+var defaultBurnRateWindows = []string{"5m", "1h", "6h"}
+
+type burnRateQueryModel struct {
+	Region                  string   `json:"region"`
+	Period                  string   `json:"period"`
+	BurnRateGoodExpression  string   `json:"burnRateGoodExpression"`
+	BurnRateTotalExpression string   `json:"burnRateTotalExpression"`
+	SloObjective            *float64 `json:"sloObjective"`
+	BurnRateWindows         []string `json:"burnRateWindows"`
+}
+
+// burnRateWindow is a look-back window of a burn rate query, with its name as set on the query
+type burnRateWindow struct {
+	name     string
+	duration time.Duration
+}
+
+// executeBurnRateQueries executes the burn rate queries, which compute the burn rate of the error budget of an SLO
+// over several look-back windows from the good and total events of the SLO. Both are queried in one GetMetricData
+// request starting the longest window before the time range, so that the burn rates are known from its start. Each
+// query returns a series per window, labelled with the window.
+func (ds *DataSource) executeBurnRateQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		frames, err := ds.executeBurnRateQuery(ctx, query)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: frames}
+	}
+	return resp, nil
+}
+
+func (ds *DataSource) executeBurnRateQuery(ctx context.Context, query backend.DataQuery) (data.Frames, error) {
+	var model burnRateQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return nil, backend.DownstreamError(err)
+	}
+	if !query.TimeRange.From.Before(query.TimeRange.To) {
+		return nil, backend.DownstreamError(errors.New("invalid time range: start time must be before end time"))
+	}
+	if strings.TrimSpace(model.BurnRateGoodExpression) == "" || strings.TrimSpace(model.BurnRateTotalExpression) == "" {
+		return nil, backend.DownstreamError(errors.New("invalid burn rate query: the expressions of the good and total events are required"))
+	}
+	if model.SloObjective == nil || *model.SloObjective <= 0 || *model.SloObjective >= 100 {
+		return nil, backend.DownstreamError(errors.New("invalid burn rate query: the objective must be a percentage between 0 and 100"))
+	}
+	period, err := parseBurnRatePeriod(model.Period)
+	if err != nil {
+		return nil, backend.DownstreamError(err)
+	}
+	windows, err := parseBurnRateWindows(model.BurnRateWindows, period)
+	if err != nil {
+		return nil, backend.DownstreamError(err)
+	}
+
+	client, err := ds.getCWClient(ctx, model.Region)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to get client", err)
+	}
+	longestWindow := windows[len(windows)-1].duration
+	outputs, err := ds.executeRequest(ctx, client, &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(query.TimeRange.From.Add(-longestWindow)),
+		EndTime:   aws.Time(query.TimeRange.To),
+		ScanBy:    cloudwatchtypes.ScanByTimestampAscending,
+		MetricDataQueries: []cloudwatchtypes.MetricDataQuery{
+			{Id: aws.String(burnRateGoodId), Expression: aws.String(model.BurnRateGoodExpression), Period: aws.Int32(period)},
+			{Id: aws.String(burnRateTotalId), Expression: aws.String(model.BurnRateTotalExpression), Period: aws.Int32(period)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to call cloudwatch:GetMetricData", err)
+	}
+
+	good, total := sumBurnRateResults(outputs)
+	return burnRateFrames(query, good, total, windows, *model.SloObjective, period), nil
+}
+
+// parseBurnRatePeriod returns the period of a burn rate query in seconds. Burn rates are computed from the sums of
+// the events of each period, so an automatic period is the finest one rather than one picked by the time range.
+func parseBurnRatePeriod(periodString string) (int32, error) {
+	if periodString == "" || strings.ToLower(periodString) == "auto" {
+		return burnRatePeriod, nil
+	}
+	period, err := strconv.Atoi(periodString)
+	if err != nil {
+		d, err := time.ParseDuration(periodString)
+		if err != nil {
+			return 0, fmt.Errorf("invalid burn rate query: failed to parse period as duration: %v", err)
+		}
+		period = int(d.Seconds())
+	}
+	if period < 1 {
+		return 0, fmt.Errorf("invalid burn rate query: invalid period %q", periodString)
+	}
+	return int32(period), nil
+}
+
+// parseBurnRateWindows returns the windows of a burn rate query from the shortest to the longest.
+func parseBurnRateWindows(names []string, period int32) ([]burnRateWindow, error) {
+	if len(names) == 0 {
+		names = defaultBurnRateWindows
+	}
+	var windows []burnRateWindow
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		duration, err := time.ParseDuration(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid burn rate query: invalid window %q: %v", name, err)
+		}
+		if duration < time.Duration(period)*time.Second || duration > maxBurnRateWindow {
+			return nil, fmt.Errorf("invalid burn rate query: the window %s must be between the period and %d days", name,
+				int(maxBurnRateWindow.Hours()/24))
+		}
+		if !slices.ContainsFunc(windows, func(window burnRateWindow) bool { return window.duration == duration }) {
+			windows = append(windows, burnRateWindow{name: name, duration: duration})
+		}
+	}
+	slices.SortStableFunc(windows, func(a, b burnRateWindow) int {
+		return int(a.duration - b.duration)
+	})
+	return windows, nil
+}
+
+// sumBurnRateResults sums the values of the good and total events by timestamp, as search expressions return a
+// series per metric matching them.
+func sumBurnRateResults(outputs []*cloudwatch.GetMetricDataOutput) (good, total map[time.Time]float64) {
+	good, total = map[time.Time]float64{}, map[time.Time]float64{}
+	for _, output := range outputs {
+		for _, result := range output.MetricDataResults {
+			sums := good
+			if aws.ToString(result.Id) == burnRateTotalId {
+				sums = total
+			}
+			for i, timestamp := range result.Timestamps {
+				if i < len(result.Values) {
+					sums[timestamp.UTC()] += result.Values[i]
+				}
+			}
+		}
+	}
+	return good, total
+}
+
+// burnRateFrames returns a series per window of the burn rates at the timestamps of the total events in the time
+// range of the query. The burn rate at a timestamp is the error rate of the events of the window ending with it,
+// divided by the error rate the objective allows. It is null where there were no events in the window.
+func burnRateFrames(query backend.DataQuery, good, total map[time.Time]float64, windows []burnRateWindow, objective float64, period int32) data.Frames {
+	timestamps := make([]time.Time, 0, len(total))
+	for timestamp := range total {
+		timestamps = append(timestamps, timestamp)
+	}
+	slices.SortFunc(timestamps, time.Time.Compare)
+
+	// cumulative sums of the events, for the sums of any window to be the difference of two of them
+	goodSums := make([]float64, len(timestamps)+1)
+	totalSums := make([]float64, len(timestamps)+1)
+	for i, timestamp := range timestamps {
+		goodSums[i+1] = goodSums[i] + good[timestamp]
+		totalSums[i+1] = totalSums[i] + total[timestamp]
+	}
+
+	errorBudget := 1 - objective/100
+	frames := make(data.Frames, 0, len(windows))
+	for _, window := range windows {
+		times := []time.Time{}
+		values := []*float64{}
+		start := 0
+		for end, timestamp := range timestamps {
+			for !timestamps[start].After(timestamp.Add(-window.duration)) {
+				start++
+			}
+			if timestamp.Before(query.TimeRange.From) || timestamp.After(query.TimeRange.To) {
+				continue
+			}
+			times = append(times, timestamp)
+			windowTotal := totalSums[end+1] - totalSums[start]
+			if windowTotal <= 0 {
+				values = append(values, nil)
+				continue
+			}
+			burnRate := (1 - (goodSums[end+1]-goodSums[start])/windowTotal) / errorBudget
+			values = append(values, &burnRate)
+		}
+
+		name := window.name + " burn rate"
+		frame := data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times).SetConfig(periodFieldConfig(int(period))),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{burnRateLabel: window.name}, values).
+				SetConfig(&data.FieldConfig{DisplayNameFromDS: name}),
+		)
+		frame.RefID = query.RefID
+		frames = append(frames, frame)
+	}
+	return frames
+}
@@ -0,0 +1,115 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_BurnRate(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	now := time.Now().UTC().Truncate(time.Minute)
+	queryData := func(t *testing.T, api *mocks.MetricsAPI, queryJSON string) backend.DataResponse {
+		t.Helper()
+		NewCWClient = func(aws.Config) models.CWClient {
+			return api
+		}
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-2 * time.Minute), To: now},
+				JSON:      json.RawMessage(queryJSON),
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("computes a series per window from the sums of the good and total series", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		minutes := []time.Time{now.Add(-4 * time.Minute), now.Add(-3 * time.Minute), now.Add(-2 * time.Minute), now.Add(-time.Minute), now}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("good"), Values: []float64{100, 100, 98, 50, 0}, Timestamps: minutes},
+				{Id: aws.String("total"), Values: []float64{50, 50, 50, 50, 0}, Timestamps: minutes},
+				{Id: aws.String("total"), Values: []float64{50, 50, 50, 50, 0}, Timestamps: minutes},
+			},
+		}, nil)
+
+		res := queryData(t, api, `{"refId":"A","queryMode":"Metrics","metricQueryType":5,"region":"us-east-1",
+			"burnRateGoodExpression":"SEARCH('MetricName=\"Good\"', 'Sum')","burnRateTotalExpression":"m1 + m2",
+			"sloObjective":99,"burnRateWindows":["2m","1m"]}`)
+
+		require.NoError(t, res.Error)
+		input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Equal(t, now.Add(-4*time.Minute), *input.StartTime)
+		require.Len(t, input.MetricDataQueries, 2)
+		assert.Equal(t, "good", *input.MetricDataQueries[0].Id)
+		assert.Equal(t, "m1 + m2", *input.MetricDataQueries[1].Expression)
+		assert.Equal(t, aws.Int32(60), input.MetricDataQueries[1].Period)
+
+		require.Len(t, res.Frames, 2)
+		assert.Equal(t, "1m burn rate", res.Frames[0].Name)
+		assert.Equal(t, data.Labels{"window": "1m"}, res.Frames[0].Fields[1].Labels)
+		require.Equal(t, 3, res.Frames[0].Rows())
+		assert.InDelta(t, 2.0, *res.Frames[0].Fields[1].At(0).(*float64), 1e-9)
+		assert.InDelta(t, 50.0, *res.Frames[0].Fields[1].At(1).(*float64), 1e-9)
+		assert.Nil(t, res.Frames[0].Fields[1].At(2))
+
+		assert.Equal(t, "2m burn rate", res.Frames[1].Name)
+		assert.InDelta(t, 1.0, *res.Frames[1].Fields[1].At(0).(*float64), 1e-9)
+		assert.InDelta(t, 26.0, *res.Frames[1].Fields[1].At(1).(*float64), 1e-9)
+		assert.InDelta(t, 50.0, *res.Frames[1].Fields[1].At(2).(*float64), 1e-9)
+	})
+
+	t.Run("defaults to the 5m, 1h and 6h windows", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{}, nil)
+
+		res := queryData(t, api, `{"refId":"A","metricQueryType":5,"burnRateGoodExpression":"m1","burnRateTotalExpression":"m2",
+			"sloObjective":99.9}`)
+
+		require.NoError(t, res.Error)
+		input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Equal(t, now.Add(-2*time.Minute-6*time.Hour), *input.StartTime)
+		require.Len(t, res.Frames, 3)
+		assert.Equal(t, []string{"5m burn rate", "1h burn rate", "6h burn rate"},
+			[]string{res.Frames[0].Name, res.Frames[1].Name, res.Frames[2].Name})
+	})
+
+	for name, queryJSON := range map[string]string{
+		"missing expression": `{"metricQueryType":5,"burnRateGoodExpression":"m1","sloObjective":99}`,
+		"invalid objective":  `{"metricQueryType":5,"burnRateGoodExpression":"m1","burnRateTotalExpression":"m2","sloObjective":100}`,
+		"invalid window": `{"metricQueryType":5,"burnRateGoodExpression":"m1","burnRateTotalExpression":"m2","sloObjective":99,
+			"burnRateWindows":["5x"]}`,
+		"window shorter than the period": `{"metricQueryType":5,"burnRateGoodExpression":"m1","burnRateTotalExpression":"m2",
+			"sloObjective":99,"period":"300","burnRateWindows":["1m"]}`,
+	} {
+		t.Run("rejects a query with a "+name, func(t *testing.T) {
+			api := &mocks.MetricsAPI{}
+
+			res := queryData(t, api, queryJSON)
+
+			require.Error(t, res.Error)
+			assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+			api.AssertNotCalled(t, "GetMetricData", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -2,11 +2,16 @@ package cloudwatch
 
 import (
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/applicationsignals"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/oam"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/clients"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 )
 
@@ -17,6 +22,27 @@ var NewCWClient = func(cfg aws.Config) models.CWClient {
 	return cloudwatch.NewFromConfig(cfg)
 }
 
+// NewAnomalyDetectorsAPI is a CloudWatch anomaly detectors API factory.
+//
+// Stubbable by tests.
+var NewAnomalyDetectorsAPI = func(cfg aws.Config) models.AnomalyDetectorsAPIProvider {
+	return cloudwatch.NewFromConfig(cfg)
+}
+
+// NewInsightRulesAPI is a CloudWatch Contributor Insights API factory.
+//
+// Stubbable by tests.
+var NewInsightRulesAPI = func(cfg aws.Config) models.InsightRulesAPIProvider {
+	return cloudwatch.NewFromConfig(cfg)
+}
+
+// NewApplicationSignalsAPI is a CloudWatch Application Signals API factory.
+//
+// Stubbable by tests.
+var NewApplicationSignalsAPI = func(cfg aws.Config) models.ApplicationSignalsAPIProvider {
+	return applicationsignals.NewFromConfig(cfg)
+}
+
 // NewLogsAPI is a CloudWatch logs api factory.
 //
 // Stubbable by tests.
@@ -45,9 +71,40 @@ var NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
 	return cloudwatchlogs.NewFromConfig(cfg)
 }
 
+// NewLogsLiveTailClient is a CloudWatch logs Live Tail client factory.
+//
+// Stubbable by tests.
+var NewLogsLiveTailClient = func(cfg aws.Config) models.LogsLiveTailProvider {
+	return clients.NewLogsLiveTailClient(cloudwatchlogs.NewFromConfig(cfg))
+}
+
 // NewRGTAClient is a ResourceGroupsTaggingAPI Client factory.
 //
 // Stubbable by tests.
 var NewRGTAClient = func(cfg aws.Config) resourcegroupstaggingapi.GetResourcesAPIClient {
 	return resourcegroupstaggingapi.NewFromConfig(cfg)
 }
+
+// NewServiceQuotasAPI is a Service Quotas API factory, used to fetch the Logs Insights quotas of the account.
+//
+// Stubbable by tests.
+var NewServiceQuotasAPI = func(cfg aws.Config) servicequotas.ListServiceQuotasAPIClient {
+	return servicequotas.NewFromConfig(cfg)
+}
+
+// NewSTSAPI is a STS API factory, used to resolve the caller identity.
+//
+// Stubbable by tests.
+var NewSTSAPI = func(cfg aws.Config) models.STSAPIProvider {
+	return sts.NewFromConfig(cfg)
+}
+
+// NewWebIdentityCredentials is a credentials provider factory for exchanging a user's web identity token
+// for the credentials of roleARN.
+//
+// Stubbable by tests.
+var NewWebIdentityCredentials = func(cfg aws.Config, roleARN string, sessionName string, token stscreds.IdentityTokenRetriever) aws.CredentialsProvider {
+	return stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(cfg), roleARN, token, func(o *stscreds.WebIdentityRoleOptions) {
+		o.RoleSessionName = sessionName
+	})
+}
@@ -0,0 +1,24 @@
+package clients
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+type LogsLiveTailClient struct {
+	client *cloudwatchlogs.Client
+}
+
+func NewLogsLiveTailClient(client *cloudwatchlogs.Client) *LogsLiveTailClient {
+	return &LogsLiveTailClient{client: client}
+}
+
+// StartLiveTail starts a Live Tail session and returns its event stream, which the caller must close.
+func (c *LogsLiveTailClient) StartLiveTail(ctx context.Context, input *cloudwatchlogs.StartLiveTailInput) (cloudwatchlogs.StartLiveTailResponseStreamReader, error) {
+	output, err := c.client.StartLiveTail(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return output.GetStream(), nil
+}
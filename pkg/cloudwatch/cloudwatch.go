@@ -3,9 +3,11 @@ package cloudwatch
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,20 +15,29 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsauth"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/clients"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/proxy"
 	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
 	tagValueCacheExpiration = time.Hour * 24
+	regionsCacheExpiration  = time.Hour
+	// queryCacheCleanupInterval is how often expired query results are evicted, entries expire after the configured TTL
+	queryCacheCleanupInterval = time.Minute
+	// awsConfigCacheExpiration is how long the AWS config of a region and role is reused. The credentials of a config
+	// are cached, and refreshed when they expire, by the config itself.
+	awsConfigCacheExpiration = 15 * time.Minute
 
 	// headerFromExpression is used by datasources to identify expression queries
 	headerFromExpression = "X-Grafana-From-Expr"
@@ -38,13 +49,18 @@ const (
 	logsQueryMode = "Logs"
 	// QueryTypes
 	annotationQuery = "annotationQuery"
+	alarmQuery      = "alarmQuery"
 	logAction       = "logAction"
 	timeSeriesQuery = "timeSeriesQuery"
 )
 
 type DataQueryJson struct {
 	dataquery.CloudWatchAnnotationQuery
-	Type string `json:"type,omitempty"`
+	Type              string                    `json:"type,omitempty"`
+	RecordedQuery     string                    `json:"recordedQuery,omitempty"`
+	LogsMode          dataquery.LogsMode        `json:"logsMode,omitempty"`
+	MetricQueryType   dataquery.MetricQueryType `json:"metricQueryType,omitempty"`
+	MetricDataQueries string                    `json:"metricDataQueries,omitempty"`
 }
 
 type DataSource struct {
@@ -52,10 +68,35 @@ type DataSource struct {
 	ProxyOpts         *proxy.Options
 	AWSConfigProvider awsauth.ConfigProvider
 
-	logger          log.Logger
-	tagValueCache   *cache.Cache
-	resourceHandler backend.CallResourceHandler
-	requestContext  models.RequestContext
+	logger             log.Logger
+	tagValueCache      *cache.Cache
+	regionsCache       *cache.Cache
+	apiBudgets         *apiBudgetTracker
+	queryCache         *cache.Cache
+	metricDataCache    *cache.Cache
+	listMetricsCache   *cache.Cache
+	awsConfigCache     *cache.Cache
+	userCredsCache     *cache.Cache // credentials of users whose own identity is used, by user and role
+	deltaFetchCache    *cache.Cache
+	logsQueryIds       *cache.Cache
+	startingQueries    *singleflight.Group // coalesces identical Logs Insights queries started concurrently
+	liveQueries        *cache.Cache
+	liveTails          *cache.Cache
+	recordedQueries    *recordedQueries
+	maskingRules       []maskingRule
+	labelRules         []labelRule
+	dimensionTagLabels []dimensionTagLabel
+	querySnippets      []resources.QuerySnippet
+	logsPollPacer      *regionPacer
+	logsQuotas         *cache.Cache
+	discoveryCache     *cache.Cache
+	instanceNameCache  *cache.Cache
+	dimensionTagsCache *cache.Cache
+	runningLogs        *runningLogsQueries
+	logsPollers        *logsQueryPollers
+	operations         *operations
+	resourceHandler    backend.CallResourceHandler
+	requestContext     models.RequestContext
 }
 
 func (ds *DataSource) newAWSConfig(ctx context.Context, region string) (aws.Config, error) {
@@ -65,10 +106,26 @@ func (ds *DataSource) newAWSConfig(ctx context.Context, region string) (aws.Conf
 		}
 		region = ds.Settings.Region
 	}
+	assumeRoleARN, err := ds.assumeRoleARN(ctx)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	// configs are reused by region and role, so that the clients of resource calls and queries share the credentials
+	// of their config rather than resolving them again. The cache belongs to the instance of the data source, which
+	// is replaced when its settings change. Configs with the identity of the user are built for every request.
+	cacheKey := region + "|" + assumeRoleARN
+	cacheable := ds.awsConfigCache != nil && !ds.Settings.UserIdentityPassThrough
+	if cacheable {
+		if cached, ok := ds.awsConfigCache.Get(cacheKey); ok {
+			return cached.(aws.Config), nil
+		}
+	}
+
 	authSettings := awsauth.Settings{
 		CredentialsProfile: ds.Settings.Profile,
 		LegacyAuthType:     ds.Settings.AuthType,
-		AssumeRoleARN:      ds.Settings.AssumeRoleARN,
+		AssumeRoleARN:      assumeRoleARN,
 		ExternalID:         ds.Settings.GrafanaSettings.ExternalID,
 		Endpoint:           ds.Settings.Endpoint,
 		Region:             region,
@@ -82,6 +139,25 @@ func (ds *DataSource) newAWSConfig(ctx context.Context, region string) (aws.Conf
 	if err != nil {
 		return aws.Config{}, err
 	}
+	if ds.Settings.UserIdentityPassThrough {
+		cfg, err = ds.withUserIdentity(ctx, cfg)
+		if err != nil {
+			return aws.Config{}, err
+		}
+	}
+	cfg = ds.withAuditLogging(cfg)
+	cfg = withQueryTiming(cfg)
+	if ds.apiBudgets != nil {
+		cfg = ds.withAPIBudget(cfg)
+	}
+	cfg = withRequestAPIBudget(cfg)
+	cfg = ds.withRegionNotEnabledErrors(cfg)
+	if ds.Settings.ReadOnly {
+		cfg = withReadOnlyAPIGuard(cfg)
+	}
+	if cacheable {
+		ds.awsConfigCache.Set(cacheKey, cfg, cache.DefaultExpiration)
+	}
 	return cfg, nil
 }
 
@@ -96,18 +172,78 @@ func NewDatasource(ctx context.Context, settings backend.DataSourceInstanceSetti
 		return nil, err
 	}
 
-	ds := DataSource{
-		Settings: instanceSettings,
-		// this is used to build a custom dialer when secure socks proxy is enabled
-		ProxyOpts:         opts.ProxyOptions,
-		AWSConfigProvider: awsauth.NewConfigProvider(),
-		logger:            backend.NewLoggerWith("logger", "grafana-cloudwatch-datasource"),
-		tagValueCache:     cache.New(tagValueCacheExpiration, tagValueCacheExpiration*5),
+	maskingRules, err := compileMaskingRules(instanceSettings.MaskingRules)
+	if err != nil {
+		return nil, fmt.Errorf("error reading settings: %w", err)
 	}
-	ds.resourceHandler = httpadapter.New(ds.newResourceMux())
+
+	labelRules, err := compileLabelRules(instanceSettings.LabelRules)
+	if err != nil {
+		return nil, fmt.Errorf("error reading settings: %w", err)
+	}
+
+	dimensionTagLabels, err := compileDimensionTagLabels(instanceSettings.DimensionTagLabels)
+	if err != nil {
+		return nil, fmt.Errorf("error reading settings: %w", err)
+	}
+
+	querySnippets, err := compileQuerySnippets(instanceSettings.QuerySnippets)
+	if err != nil {
+		return nil, fmt.Errorf("error reading settings: %w", err)
+	}
+
+	logger := backend.NewLoggerWith("logger", "grafana-cloudwatch-datasource")
+	ds := newDataSource(instanceSettings, awsauth.NewConfigProvider(), logger,
+		loadRunningLogsQueries(runningLogsQueriesPath(settings.UID), logger))
+	// this is used to build a custom dialer when secure socks proxy is enabled
+	ds.ProxyOpts = opts.ProxyOptions
+	ds.maskingRules = maskingRules
+	ds.labelRules = labelRules
+	ds.dimensionTagLabels = dimensionTagLabels
+	ds.querySnippets = querySnippets
+	ds.restoreReusableLogsQueries()
+	if len(instanceSettings.RecordedQueries) > 0 {
+		ds.startRecordedQueries()
+	}
+	ds.warmLogsQuotas()
+	registerInstance(ds)
 	return ds, nil
 }
 
+// newDataSource returns a data source with settings whose caches and trackers are all set up, getting its AWS
+// configs from configProvider and tracking its running Logs Insights queries with runningLogs.
+func newDataSource(settings models.CloudWatchSettings, configProvider awsauth.ConfigProvider, logger log.Logger,
+	runningLogs *runningLogsQueries) *DataSource {
+	ds := &DataSource{
+		Settings:           settings,
+		AWSConfigProvider:  configProvider,
+		logger:             logger,
+		tagValueCache:      cache.New(tagValueCacheExpiration, tagValueCacheExpiration*5),
+		regionsCache:       cache.New(regionsCacheExpiration, regionsCacheExpiration*5),
+		apiBudgets:         newAPIBudgetTracker(),
+		queryCache:         cache.New(cache.NoExpiration, queryCacheCleanupInterval),
+		metricDataCache:    cache.New(cache.NoExpiration, queryCacheCleanupInterval),
+		listMetricsCache:   cache.New(cache.NoExpiration, queryCacheCleanupInterval),
+		awsConfigCache:     cache.New(awsConfigCacheExpiration, awsConfigCacheExpiration),
+		userCredsCache:     cache.New(userCredentialsExpiration, userCredentialsExpiration),
+		deltaFetchCache:    cache.New(deltaFetchExpiration, deltaFetchExpiration),
+		logsQueryIds:       cache.New(cache.NoExpiration, queryCacheCleanupInterval),
+		startingQueries:    &singleflight.Group{},
+		liveQueries:        cache.New(liveMetricsRegistration, liveMetricsRegistration),
+		liveTails:          cache.New(liveTailRegistration, liveTailRegistration),
+		logsPollPacer:      newRegionPacer(getQueryResultsInterval),
+		logsQuotas:         cache.New(logsQuotasExpiration, logsQuotasExpiration),
+		discoveryCache:     cache.New(discoveryCacheExpiration, discoveryCacheExpiration),
+		instanceNameCache:  cache.New(instanceNameCacheExpiration, instanceNameCacheExpiration),
+		dimensionTagsCache: cache.New(dimensionTagsCacheExpiration, dimensionTagsCacheExpiration),
+		runningLogs:        runningLogs,
+		logsPollers:        newLogsQueryPollers(),
+		operations:         newOperations(),
+	}
+	ds.resourceHandler = httpadapter.New(ds.newResourceMux())
+	return ds
+}
+
 // instrumentContext adds plugin key-values to the context; later, logger.FromContext(ctx) will provide a logger
 // that adds these values to its output.
 // TODO: move this into the sdk (see https://github.com/grafana/grafana/issues/82033)
@@ -141,7 +277,7 @@ func (ds *DataSource) getRequestContext(ctx context.Context, region string) (mod
 
 	return models.RequestContext{
 		OAMAPIProvider:        NewOAMAPI(cfg),
-		MetricsClientProvider: clients.NewMetricsClient(NewCWClient(cfg), ds.Settings.GrafanaSettings.ListMetricsPageLimit),
+		MetricsClientProvider: ds.newCachedMetricsClient(region, NewCWClient(cfg), ds.Settings.GrafanaSettings.ListMetricsPageLimit),
 		LogsAPIProvider:       NewLogsAPI(cfg),
 		EC2APIProvider:        ec2client,
 		Settings:              ds.Settings,
@@ -162,12 +298,39 @@ func (ds *DataSource) getRequestContextOnlySettings(ctx context.Context, _ strin
 }
 
 func (ds *DataSource) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	ctx, done, err := ds.operations.begin(ctx)
+	defer done()
+	if err != nil {
+		return sendShuttingDown(sender)
+	}
 	ctx = instrumentContext(ctx, string(backend.EndpointCallResource), req.PluginContext)
-	return ds.resourceHandler.CallResource(ctx, req, sender)
+	ctx = withWebIdentityToken(ctx, req.GetHTTPHeader)
+	ctx, calls := ds.withRequestAPICalls(ctx)
+	return ds.resourceHandler.CallResource(ctx, req, withAPICallsHeader(sender, calls))
 }
 
 func (ds *DataSource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	ctx, done, err := ds.operations.begin(ctx)
+	defer done()
+	if err != nil {
+		return shuttingDownQueryResponse(req), nil
+	}
 	ctx = instrumentContext(ctx, string(backend.EndpointQueryData), req.PluginContext)
+	ctx = withWebIdentityToken(ctx, req.GetHTTPHeader)
+	ctx = withDashboard(ctx, req.GetHTTPHeader)
+	ctx, timings := withQueryTimings(ctx)
+	timings.apiCallBudget = ds.Settings.RequestAPICallBudget
+	ctx, _ = ds.withRequestAPICalls(ctx)
+	start := time.Now()
+	resp, err := ds.queryDataByRole(ctx, req)
+	resp = ds.captureSlowQuery(ctx, req, resp, err, timings, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	return withQueryTimingsMeta(resp, timings), nil
+}
+
+func (ds *DataSource) queryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	q := req.Queries[0]
 	var model DataQueryJson
 	err := json.Unmarshal(q.JSON, &model)
@@ -175,6 +338,39 @@ func (ds *DataSource) QueryData(ctx context.Context, req *backend.QueryDataReque
 		return nil, err
 	}
 
+	if model.RecordedQuery != "" {
+		return ds.executeRecordedQueries(ctx, req)
+	}
+	if string(model.QueryMode) == logsQueryMode && model.LogsMode == dataquery.LogsModeEvents {
+		return ds.executeLogEventsQueries(ctx, req)
+	}
+	if string(model.QueryMode) == logsQueryMode && model.LogsMode == dataquery.LogsModeFilter {
+		return ds.executeLogFilterQueries(ctx, req)
+	}
+	if string(model.QueryMode) == logsQueryMode && model.LogsMode == dataquery.LogsModeContainerInsights {
+		return ds.executeContainerInsightsQueries(ctx, req)
+	}
+	if string(model.QueryMode) == logsQueryMode && model.LogsMode == dataquery.LogsModeVPCFlowLogs {
+		return ds.executeVPCFlowLogsQueries(ctx, req)
+	}
+	if string(model.QueryMode) == logsQueryMode && model.LogsMode == dataquery.LogsModeLiveTail {
+		return ds.executeLiveTailQueries(ctx, req)
+	}
+
+	// alarm queries are also recognized by their mode, as public dashboards and alerts don't set their type
+	if model.Type == alarmQuery || model.QueryMode == dataquery.CloudWatchQueryModeAlarms {
+		return ds.executeAlarmQueries(ctx, req)
+	}
+	if model.QueryMode == dataquery.CloudWatchQueryModeContributorInsights {
+		return ds.executeContributorInsightsQueries(ctx, req)
+	}
+	if (model.QueryMode == "" || model.QueryMode == dataquery.CloudWatchQueryModeMetrics) && model.MetricQueryType == dataquery.MetricQueryTypeJSON {
+		return ds.executeJSONQueries(ctx, req)
+	}
+	if (model.QueryMode == "" || model.QueryMode == dataquery.CloudWatchQueryModeMetrics) && model.MetricQueryType == dataquery.MetricQueryTypeBurnRate {
+		return ds.executeBurnRateQueries(ctx, req)
+	}
+
 	_, fromAlert := req.Headers[headerFromAlert]
 	fromExpression := req.GetHTTPHeader(headerFromExpression) != ""
 	// Public dashboard queries execute like alert queries, i.ds. they execute on the backend, therefore, we need to handle them synchronously.
@@ -196,39 +392,161 @@ func (ds *DataSource) QueryData(ctx context.Context, req *backend.QueryDataReque
 		result, err = ds.executeAnnotationQuery(ctx, model, q)
 	case logAction:
 		result, err = ds.executeLogActions(ctx, req)
+	case models.MatrixQueryType:
+		result, err = ds.executeMatrixQueries(ctx, req)
 	case timeSeriesQuery:
 		fallthrough
 	default:
-		result, err = ds.executeTimeSeriesQuery(ctx, req)
+		if ds.queryCache != nil && ds.Settings.QueryCacheTTL.Duration > 0 {
+			result, err = ds.executeTimeSeriesQueryWithCache(ctx, req)
+		} else {
+			result, err = ds.runTimeSeriesQuery(ctx, req)
+		}
+		if err == nil && ds.liveQueries != nil {
+			ds.registerLiveMetricsQueries(ctx, req, result)
+		}
 	}
 
 	return result, err
 }
 
+// runTimeSeriesQuery executes time series queries, incrementally if delta fetching is enabled.
+func (ds *DataSource) runTimeSeriesQuery(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if ds.deltaFetchCache != nil && ds.Settings.DeltaFetch {
+		return ds.executeTimeSeriesQueryWithDeltaFetch(ctx, req)
+	}
+	return ds.executeTimeSeriesQuery(ctx, req)
+}
+
 func (ds *DataSource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	ctx = instrumentContext(ctx, string(backend.EndpointCheckHealth), req.PluginContext)
+	ctx = withWebIdentityToken(ctx, req.GetHTTPHeader)
 	status := backend.HealthStatusOk
 	metricsTest := "Successfully queried the CloudWatch metrics API."
 	logsTest := "Successfully queried the CloudWatch logs API."
 
-	err := ds.checkHealthMetrics(ctx, req.PluginContext)
-	if err != nil {
+	// the checks run concurrently, each bounded by its own timeout, so that an unreachable API, e.g. logs blocked
+	// by an SCP, fails its check instead of holding the others up until the deadline of the request
+	var (
+		wg                            sync.WaitGroup
+		metricsErr, logsErr           error
+		metricsDuration, logsDuration time.Duration
+		details                       healthCheckDetails
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		metricsDuration, metricsErr = runHealthCheck(ctx, func(ctx context.Context) error {
+			return ds.checkHealthMetrics(ctx, req.PluginContext)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		logsDuration, logsErr = runHealthCheck(ctx, ds.checkHealthLogs)
+	}()
+	go func() {
+		defer wg.Done()
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		details = ds.checkHealthDetails(checkCtx)
+	}()
+	wg.Wait()
+
+	if metricsErr != nil {
+		status = backend.HealthStatusError
+		metricsTest = fmt.Sprintf("CloudWatch metrics query failed: %s", metricsErr.Error())
+	}
+	if logsErr != nil {
 		status = backend.HealthStatusError
-		metricsTest = fmt.Sprintf("CloudWatch metrics query failed: %s", err.Error())
+		logsTest = fmt.Sprintf("CloudWatch logs query failed: %s", logsErr.Error())
 	}
 
-	err = ds.checkHealthLogs(ctx)
+	details.MetricsCheckDurationMs = metricsDuration.Milliseconds()
+	details.LogsCheckDurationMs = logsDuration.Milliseconds()
+	jsonDetails, err := json.Marshal(details)
 	if err != nil {
-		status = backend.HealthStatusError
-		logsTest = fmt.Sprintf("CloudWatch logs query failed: %s", err.Error())
+		return nil, err
 	}
 
 	return &backend.CheckHealthResult{
-		Status:  status,
-		Message: fmt.Sprintf("1. %s\n2. %s", metricsTest, logsTest),
+		Status:      status,
+		Message:     fmt.Sprintf("1. %s\n2. %s", metricsTest, logsTest),
+		JSONDetails: jsonDetails,
 	}, nil
 }
 
+// healthCheckDetails is the diagnostic information attached to the health check result,
+// covering the things support usually needs to know about a misbehaving data source.
+type healthCheckDetails struct {
+	Identity       string `json:"identity"`
+	Region         string `json:"region"`
+	Endpoint       string `json:"endpoint"`
+	AuthType       string `json:"authType"`
+	AssumeRoleARN  string `json:"assumeRoleArn,omitempty"`
+	VerboseMessage string `json:"verboseMessage"`
+
+	MetricsCheckDurationMs int64 `json:"metricsCheckDurationMs"`
+	LogsCheckDurationMs    int64 `json:"logsCheckDurationMs"`
+}
+
+// healthCheckTimeout bounds each of the checks of CheckHealth.
+//
+// Stubbable by tests.
+var healthCheckTimeout = 10 * time.Second
+
+// runHealthCheck runs a check of CheckHealth with healthCheckTimeout, returning how long it took.
+func runHealthCheck(ctx context.Context, check func(context.Context) error) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", healthCheckTimeout)
+	}
+	return time.Since(start), err
+}
+
+func (ds *DataSource) checkHealthDetails(ctx context.Context) healthCheckDetails {
+	// an org without a mapped role fails the identity lookup below, which explains why
+	assumeRoleARN, _ := ds.assumeRoleARN(ctx)
+	details := healthCheckDetails{
+		Region:        ds.Settings.Region,
+		Endpoint:      ds.Settings.Endpoint,
+		AuthType:      ds.Settings.AuthType.String(),
+		AssumeRoleARN: assumeRoleARN,
+	}
+	if details.Endpoint == "" {
+		details.Endpoint = "default"
+	}
+
+	identity, err := ds.checkHealthIdentity(ctx)
+	if err != nil {
+		details.Identity = fmt.Sprintf("unable to resolve caller identity: %s", err.Error())
+	} else {
+		details.Identity = identity
+	}
+
+	details.VerboseMessage = fmt.Sprintf("Identity: %s\nDefault region: %s\nEndpoint: %s\nAuth type: %s",
+		details.Identity, details.Region, details.Endpoint, details.AuthType)
+	if details.AssumeRoleARN != "" {
+		details.VerboseMessage += fmt.Sprintf("\nAssume role ARN: %s", details.AssumeRoleARN)
+	}
+	return details
+}
+
+func (ds *DataSource) checkHealthIdentity(ctx context.Context) (string, error) {
+	cfg, err := ds.getAWSConfig(ctx, defaultRegion)
+	if err != nil {
+		return "", err
+	}
+	out, err := NewSTSAPI(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Arn), nil
+}
+
 func (ds *DataSource) checkHealthMetrics(ctx context.Context, _ backend.PluginContext) error {
 	namespace := "AWS/Billing"
 	metric := "EstimatedCharges"
@@ -280,6 +598,15 @@ func (ds *DataSource) getCWLogsClient(ctx context.Context, region string) (model
 	return logsClient, nil
 }
 
+func (ds *DataSource) getLogsLiveTailClient(ctx context.Context, region string) (models.LogsLiveTailProvider, error) {
+	cfg, err := ds.getAWSConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLogsLiveTailClient(cfg), nil
+}
+
 func (ds *DataSource) getEC2Client(ctx context.Context, region string) (models.EC2APIProvider, error) {
 	cfg, err := ds.getAWSConfig(ctx, region)
 	if err != nil {
@@ -124,6 +124,36 @@ func Test_CloudWatch_CallResource_Integration_Test(t *testing.T) {
 		assert.Equal(t, []resources.ResourceResponse[string]{{Value: "Test_DimensionName1"}, {Value: "Test_DimensionName2"}, {Value: "Test_DimensionName4"}, {Value: "Test_DimensionName5"}}, res)
 	})
 
+	t.Run("Should scan no more pages than the page limit of the request", func(t *testing.T) {
+		api = mocks.FakeMetricsAPI{Metrics: []cloudwatchtypes.Metric{
+			{MetricName: aws.String("Test_MetricName1"), Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("Test_DimensionName1")}}},
+			{MetricName: aws.String("Test_MetricName2"), Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("Test_DimensionName2")}}},
+			{MetricName: aws.String("Test_MetricName3"), Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("Test_DimensionName3")}}},
+		}, MetricsPerPage: 1}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.GrafanaSettings.ListMetricsPageLimit = 3
+		})
+
+		req := &backend.CallResourceRequest{
+			Method: "GET",
+			Path:   `/dimension-keys?region=us-east-2&namespace=AWS/EC2&metricName=CPUUtilization&dimensionFilters={"NodeID":["Shared"]}&pageLimit=2`,
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 0},
+				PluginID:                   "cloudwatch",
+			},
+		}
+		err := ds.CallResource(context.Background(), req, sender)
+
+		require.NoError(t, err)
+		sent := sender.Response
+		require.NotNil(t, sent)
+		require.Equal(t, http.StatusOK, sent.Status)
+		res := []resources.ResourceResponse[string]{}
+		err = json.Unmarshal(sent.Body, &res)
+		require.Nil(t, err)
+		assert.Equal(t, []resources.ResourceResponse[string]{{Value: "Test_DimensionName1"}, {Value: "Test_DimensionName2"}}, res)
+	})
+
 	t.Run("Should handle standard dimension key query and return hard coded keys", func(t *testing.T) {
 		api = mocks.FakeMetricsAPI{}
 		ds := newTestDatasource(func(ds *DataSource) {
@@ -2,6 +2,7 @@ package cloudwatch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsauth"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
@@ -20,6 +22,7 @@ import (
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/proxy"
+	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -89,7 +92,7 @@ func TestNewInstanceSettings(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			instance, err := NewDatasource(tt.settingCtx, tt.settings)
-			ds := instance.(DataSource)
+			ds := instance.(*DataSource)
 			tt.Err(t, err)
 			assert.Equal(t, tt.expectedDS.Settings.GrafanaSettings, ds.Settings.GrafanaSettings)
 			datasourceComparer := cmp.Comparer(func(d1 DataSource, d2 DataSource) bool {
@@ -103,7 +106,7 @@ func TestNewInstanceSettings(t *testing.T) {
 					d1.Settings.AccessKey == d2.Settings.AccessKey &&
 					d1.Settings.SecretKey == d2.Settings.SecretKey
 			})
-			if !cmp.Equal(instance.(DataSource), tt.expectedDS, datasourceComparer) {
+			if !cmp.Equal(*instance.(*DataSource), tt.expectedDS, datasourceComparer) {
 				t.Errorf("Unexpected result. Expecting\n%v \nGot:\n%v", instance, tt.expectedDS)
 			}
 		})
@@ -114,11 +117,13 @@ func Test_CheckHealth(t *testing.T) {
 	origNewCWClient := NewCWClient
 	origNewCWLogsClient := NewCWLogsClient
 	origNewLogsAPI := NewLogsAPI
+	origNewSTSAPI := NewSTSAPI
 
 	t.Cleanup(func() {
 		NewCWClient = origNewCWClient
 		NewCWLogsClient = origNewCWLogsClient
 		NewLogsAPI = origNewLogsAPI
+		NewSTSAPI = origNewSTSAPI
 	})
 
 	var client fakeCheckHealthClient
@@ -128,6 +133,9 @@ func Test_CheckHealth(t *testing.T) {
 	NewLogsAPI = func(aws.Config) models.CloudWatchLogsAPIProvider {
 		return client
 	}
+	NewSTSAPI = func(aws.Config) models.STSAPIProvider {
+		return client
+	}
 
 	t.Run("successfully query metrics and logs", func(t *testing.T) {
 		client = fakeCheckHealthClient{}
@@ -138,10 +146,9 @@ func Test_CheckHealth(t *testing.T) {
 			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}}})
 
 		assert.NoError(t, err)
-		assert.Equal(t, &backend.CheckHealthResult{
-			Status:  backend.HealthStatusOk,
-			Message: "1. Successfully queried the CloudWatch metrics API.\n2. Successfully queried the CloudWatch logs API.",
-		}, resp)
+		assert.Equal(t, backend.HealthStatusOk, resp.Status)
+		assert.Equal(t, "1. Successfully queried the CloudWatch metrics API.\n2. Successfully queried the CloudWatch logs API.", resp.Message)
+		assert.JSONEq(t, string(healthCheckDetailsJSON("arn:aws:iam::123456789012:user/grafana")), withoutCheckDurations(t, resp.JSONDetails))
 	})
 
 	t.Run("successfully queries metrics, fails during logs query", func(t *testing.T) {
@@ -158,10 +165,9 @@ func Test_CheckHealth(t *testing.T) {
 		})
 
 		assert.NoError(t, err)
-		assert.Equal(t, &backend.CheckHealthResult{
-			Status:  backend.HealthStatusError,
-			Message: "1. Successfully queried the CloudWatch metrics API.\n2. CloudWatch logs query failed: some logs query error",
-		}, resp)
+		assert.Equal(t, backend.HealthStatusError, resp.Status)
+		assert.Equal(t, "1. Successfully queried the CloudWatch metrics API.\n2. CloudWatch logs query failed: some logs query error", resp.Message)
+		assert.JSONEq(t, string(healthCheckDetailsJSON("arn:aws:iam::123456789012:user/grafana")), withoutCheckDurations(t, resp.JSONDetails))
 	})
 
 	t.Run("successfully queries logs, fails during metrics query", func(t *testing.T) {
@@ -178,10 +184,61 @@ func Test_CheckHealth(t *testing.T) {
 		})
 
 		assert.NoError(t, err)
-		assert.Equal(t, &backend.CheckHealthResult{
-			Status:  backend.HealthStatusError,
-			Message: "1. CloudWatch metrics query failed: some list metrics error\n2. Successfully queried the CloudWatch logs API.",
-		}, resp)
+		assert.Equal(t, backend.HealthStatusError, resp.Status)
+		assert.Equal(t, "1. CloudWatch metrics query failed: some list metrics error\n2. Successfully queried the CloudWatch logs API.", resp.Message)
+		assert.JSONEq(t, string(healthCheckDetailsJSON("arn:aws:iam::123456789012:user/grafana")), withoutCheckDurations(t, resp.JSONDetails))
+	})
+
+	t.Run("reports endpoint, auth type and assume role, and the identity error", func(t *testing.T) {
+		client = fakeCheckHealthClient{
+			getCallerIdentityFunction: func(context.Context, *sts.GetCallerIdentityInput, ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+				return nil, fmt.Errorf("access denied")
+			},
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "eu-west-1"
+			ds.Settings.Endpoint = "https://monitoring.example.com"
+			ds.Settings.AuthType = awsds.AuthTypeKeys
+			ds.Settings.AssumeRoleARN = "arn:aws:iam::123456789012:role/grafana"
+		})
+		resp, err := ds.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusOk, resp.Status)
+		assert.JSONEq(t, `{
+			"identity": "unable to resolve caller identity: access denied",
+			"region": "eu-west-1",
+			"endpoint": "https://monitoring.example.com",
+			"authType": "keys",
+			"assumeRoleArn": "arn:aws:iam::123456789012:role/grafana",
+			"verboseMessage": "Identity: unable to resolve caller identity: access denied\nDefault region: eu-west-1\nEndpoint: https://monitoring.example.com\nAuth type: keys\nAssume role ARN: arn:aws:iam::123456789012:role/grafana"
+		}`, withoutCheckDurations(t, resp.JSONDetails))
+	})
+
+	t.Run("times out the logs check without failing the metrics check", func(t *testing.T) {
+		origHealthCheckTimeout := healthCheckTimeout
+		t.Cleanup(func() { healthCheckTimeout = origHealthCheckTimeout })
+		healthCheckTimeout = 10 * time.Millisecond
+
+		client = fakeCheckHealthClient{
+			describeLogGroupsFunction: func(ctx context.Context, _ *cloudwatchlogs.DescribeLogGroupsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+		})
+		resp, err := ds.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusError, resp.Status)
+		assert.Equal(t, "1. Successfully queried the CloudWatch metrics API.\n2. CloudWatch logs query failed: timed out after 10ms", resp.Message)
+		assert.JSONEq(t, string(healthCheckDetailsJSON("arn:aws:iam::123456789012:user/grafana")), withoutCheckDurations(t, resp.JSONDetails))
 	})
 
 	t.Run("fail to get clients", func(t *testing.T) {
@@ -195,13 +252,31 @@ func Test_CheckHealth(t *testing.T) {
 		})
 
 		assert.NoError(t, err)
-		assert.Equal(t, &backend.CheckHealthResult{
-			Status:  backend.HealthStatusError,
-			Message: "1. CloudWatch metrics query failed: LoadDefaultConfig failed\n2. CloudWatch logs query failed: LoadDefaultConfig failed",
-		}, resp)
+		assert.Equal(t, backend.HealthStatusError, resp.Status)
+		assert.Equal(t, "1. CloudWatch metrics query failed: LoadDefaultConfig failed\n2. CloudWatch logs query failed: LoadDefaultConfig failed", resp.Message)
+		assert.JSONEq(t, string(healthCheckDetailsJSON("unable to resolve caller identity: LoadDefaultConfig failed")), withoutCheckDurations(t, resp.JSONDetails))
 	})
 }
 
+// withoutCheckDurations returns the health check details without the durations of the checks, which vary between runs.
+func withoutCheckDurations(t *testing.T, jsonDetails []byte) string {
+	t.Helper()
+	var details map[string]any
+	require.NoError(t, json.Unmarshal(jsonDetails, &details))
+	require.Contains(t, details, "metricsCheckDurationMs")
+	require.Contains(t, details, "logsCheckDurationMs")
+	delete(details, "metricsCheckDurationMs")
+	delete(details, "logsCheckDurationMs")
+	withoutDurations, err := json.Marshal(details)
+	require.NoError(t, err)
+	return string(withoutDurations)
+}
+
+func healthCheckDetailsJSON(identity string) []byte {
+	return []byte(fmt.Sprintf(`{"identity":%q,"region":"us-east-1","endpoint":"default","authType":"default","verboseMessage":%q}`,
+		identity, fmt.Sprintf("Identity: %s\nDefault region: us-east-1\nEndpoint: default\nAuth type: default", identity)))
+}
+
 func TestGetAWSConfig_passes_authSettings(t *testing.T) {
 	// TODO: update this for the new auth structure, or remove it
 	t.Skip()
@@ -223,6 +298,49 @@ func TestGetAWSConfig_passes_authSettings(t *testing.T) {
 	require.NoError(t, err)
 }
 
+type countingConfigProvider struct {
+	calls []awsauth.Settings
+}
+
+func (p *countingConfigProvider) GetConfig(_ context.Context, authSettings awsauth.Settings) (aws.Config, error) {
+	p.calls = append(p.calls, authSettings)
+	return aws.Config{Region: authSettings.Region}, nil
+}
+
+func TestNewAWSConfig_cache(t *testing.T) {
+	newDatasource := func(opts ...func(*DataSource)) (*DataSource, *countingConfigProvider) {
+		provider := &countingConfigProvider{}
+		ds := newTestDatasource(append([]func(*DataSource){func(ds *DataSource) {
+			ds.AWSConfigProvider = provider
+			ds.awsConfigCache = cache.New(awsConfigCacheExpiration, 0)
+			ds.operations = newOperations()
+			ds.runningLogs = newRunningLogsQueries()
+			ds.Settings.Region = "us-east-1"
+		}}, opts...)...)
+		return ds, provider
+	}
+
+	t.Run("reuses the config of a region and role", func(t *testing.T) {
+		ds, provider := newDatasource()
+
+		for _, region := range []string{"us-east-1", defaultRegion, "eu-west-1", "us-east-1"} {
+			_, err := ds.newAWSConfig(context.Background(), region)
+			require.NoError(t, err)
+		}
+		_, err := ds.newAWSConfig(withQueryRole(context.Background(), "arn:aws:iam::123456789012:role/other"), "us-east-1")
+		require.NoError(t, err)
+
+		require.Len(t, provider.calls, 3)
+		assert.Equal(t, "eu-west-1", provider.calls[1].Region)
+		assert.Equal(t, "arn:aws:iam::123456789012:role/other", provider.calls[2].AssumeRoleARN)
+
+		ds.Dispose()
+		_, err = ds.newAWSConfig(context.Background(), "us-east-1")
+		require.NoError(t, err)
+		assert.Len(t, provider.calls, 4, "the configs are dropped with the instance")
+	})
+}
+
 func TestQuery_ResourceRequest_DescribeLogGroups_with_CrossAccountQuerying(t *testing.T) {
 	sender := &mockedCallResourceResponseSenderForOauth{}
 	origNewMetricsAPI := NewCWClient
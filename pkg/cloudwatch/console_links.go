@@ -0,0 +1,56 @@
+package cloudwatch
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// identityCenterConsoleLink returns link to the console through the access portal of IAM Identity Center, which
+// signs the user in to accountId with the permission set of the data source before redirecting them to link, or link
+// itself if the data source isn't set up for it or the account isn't known.
+func (ds *DataSource) identityCenterConsoleLink(link, accountId string) string {
+	startURL := strings.TrimRight(ds.Settings.IdentityCenterStartURL, "/")
+	if startURL == "" || ds.Settings.IdentityCenterRoleName == "" || link == "" {
+		return link
+	}
+	if accountId == "" || accountId == "all" {
+		accountId = ds.Settings.IdentityCenterAccountId
+	}
+	if accountId == "" {
+		return link
+	}
+
+	parameters := url.Values{}
+	parameters.Set("account_id", accountId)
+	parameters.Set("role_name", ds.Settings.IdentityCenterRoleName)
+	parameters.Set("destination", link)
+	return startURL + "/#/console?" + parameters.Encode()
+}
+
+// setIdentityCenterConsoleLinks makes the console links of the frames of query go through the access portal of IAM
+// Identity Center, so that clicking through lands users in the console of the account of the query already signed in.
+func (ds *DataSource) setIdentityCenterConsoleLinks(frames data.Frames, query *models.CloudWatchQuery) {
+	if ds.Settings.IdentityCenterStartURL == "" {
+		return
+	}
+	accountId := ""
+	if query.AccountId != nil {
+		accountId = *query.AccountId
+	}
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if field.Config == nil {
+				continue
+			}
+			for i, link := range field.Config.Links {
+				if link.Title == consoleLinkTitle {
+					field.Config.Links[i].URL = ds.identityCenterConsoleLink(link.URL, accountId)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cloudwatch
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_setIdentityCenterConsoleLinks(t *testing.T) {
+	const consoleLink = "https://us-east-1.console.aws.amazon.com/cloudwatch/deeplink.js?region=us-east-1#metricsV2:graph=x"
+	newFrames := func() data.Frames {
+		return data.Frames{data.NewFrame("",
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, nil, []float64{}).SetConfig(&data.FieldConfig{Links: []data.DataLink{
+				{Title: consoleLinkTitle, URL: consoleLink},
+				{Title: "Runbook", URL: "https://runbooks.example.com"},
+			}}),
+		)}
+	}
+	newDatasource := func() *DataSource {
+		return newTestDatasource(func(ds *DataSource) {
+			ds.Settings.IdentityCenterStartURL = "https://my-org.awsapps.com/start/"
+			ds.Settings.IdentityCenterRoleName = "ReadOnly"
+			ds.Settings.IdentityCenterAccountId = "111111111111"
+		})
+	}
+	portalLink := func(t *testing.T, frames data.Frames) url.Values {
+		t.Helper()
+		links := frames[0].Fields[1].Config.Links
+		assert.Equal(t, "https://runbooks.example.com", links[1].URL, "other links are left untouched")
+		link, found := strings.CutPrefix(links[0].URL, "https://my-org.awsapps.com/start/#/console?")
+		require.True(t, found, links[0].URL)
+		parameters, err := url.ParseQuery(link)
+		require.NoError(t, err)
+		return parameters
+	}
+
+	t.Run("links to the account of the query", func(t *testing.T) {
+		frames := newFrames()
+		newDatasource().setIdentityCenterConsoleLinks(frames, &models.CloudWatchQuery{AccountId: aws.String("222222222222")})
+
+		parameters := portalLink(t, frames)
+		assert.Equal(t, "222222222222", parameters.Get("account_id"))
+		assert.Equal(t, "ReadOnly", parameters.Get("role_name"))
+		assert.Equal(t, consoleLink, parameters.Get("destination"))
+	})
+
+	t.Run("links to the account of the data source when the query has none", func(t *testing.T) {
+		for _, accountId := range []*string{nil, aws.String("all")} {
+			frames := newFrames()
+			newDatasource().setIdentityCenterConsoleLinks(frames, &models.CloudWatchQuery{AccountId: accountId})
+
+			assert.Equal(t, "111111111111", portalLink(t, frames).Get("account_id"))
+		}
+	})
+
+	t.Run("leaves the links untouched without an account or role", func(t *testing.T) {
+		frames := newFrames()
+		ds := newDatasource()
+		ds.Settings.IdentityCenterAccountId = ""
+		ds.setIdentityCenterConsoleLinks(frames, &models.CloudWatchQuery{})
+		assert.Equal(t, consoleLink, frames[0].Fields[1].Config.Links[0].URL)
+
+		ds = newDatasource()
+		ds.Settings.IdentityCenterRoleName = ""
+		ds.setIdentityCenterConsoleLinks(frames, &models.CloudWatchQuery{AccountId: aws.String("222222222222")})
+		assert.Equal(t, consoleLink, frames[0].Fields[1].Config.Links[0].URL)
+	})
+}
@@ -1,42 +1,112 @@
 package constants
 
+import "strings"
+
 type RegionsSet map[string]struct{}
 
+const (
+	PartitionAWS      = "aws"
+	PartitionAWSCN    = "aws-cn"
+	PartitionAWSUSGov = "aws-us-gov"
+	PartitionAWSISO   = "aws-iso"
+	PartitionAWSISOB  = "aws-iso-b"
+)
+
+// regionsByPartition are the static region lists that are returned when the regions of an account can't be fetched
+var regionsByPartition = map[string][]string{
+	PartitionAWS: {
+		"af-south-1",
+		"ap-east-1",
+		"ap-northeast-1",
+		"ap-northeast-2",
+		"ap-northeast-3",
+		"ap-south-1",
+		"ap-south-2",
+		"ap-southeast-1",
+		"ap-southeast-2",
+		"ap-southeast-3",
+		"ap-southeast-4",
+		"ap-southeast-5",
+		"ap-southeast-7",
+		"ca-central-1",
+		"ca-west-1",
+		"eu-central-1",
+		"eu-central-2",
+		"eu-north-1",
+		"eu-south-1",
+		"eu-south-2",
+		"eu-west-1",
+		"eu-west-2",
+		"eu-west-3",
+		"il-central-1",
+		"me-central-1",
+		"me-south-1",
+		"mx-central-1",
+		"sa-east-1",
+		"us-east-1",
+		"us-east-2",
+		"us-west-1",
+		"us-west-2",
+	},
+	PartitionAWSCN: {
+		"cn-north-1",
+		"cn-northwest-1",
+	},
+	PartitionAWSUSGov: {
+		"us-gov-east-1",
+		"us-gov-west-1",
+	},
+	PartitionAWSISO: {
+		"us-iso-east-1",
+	},
+	PartitionAWSISOB: {
+		"us-isob-east-1",
+	},
+}
+
+// partitionsByRegionPrefix are the partitions whose regions are named with a prefix of their own. The regions of
+// the aws partition have no prefix of their own. Longer prefixes come first, as us-isob- starts like us-iso-.
+var partitionsByRegionPrefix = []struct {
+	prefix    string
+	partition string
+}{
+	{"cn-", PartitionAWSCN},
+	{"us-gov-", PartitionAWSUSGov},
+	{"us-isob-", PartitionAWSISOB},
+	{"us-iso-", PartitionAWSISO},
+}
+
+// PartitionForRegion returns the partition a region belongs to, the aws partition for regions of no other partition.
+func PartitionForRegion(region string) string {
+	for _, p := range partitionsByRegionPrefix {
+		if strings.HasPrefix(region, p.prefix) {
+			return p.partition
+		}
+	}
+	return PartitionAWS
+}
+
+// Regions returns the static regions of all partitions
 func Regions() RegionsSet {
-	return RegionsSet{
-		"af-south-1":     {},
-		"ap-east-1":      {},
-		"ap-northeast-1": {},
-		"ap-northeast-2": {},
-		"ap-northeast-3": {},
-		"ap-south-1":     {},
-		"ap-south-2":     {},
-		"ap-southeast-1": {},
-		"ap-southeast-2": {},
-		"ap-southeast-3": {},
-		"ap-southeast-4": {},
-		"ca-central-1":   {},
-		"cn-north-1":     {},
-		"cn-northwest-1": {},
-		"eu-central-1":   {},
-		"eu-central-2":   {},
-		"eu-north-1":     {},
-		"eu-south-1":     {},
-		"eu-south-2":     {},
-		"eu-west-1":      {},
-		"eu-west-2":      {},
-		"eu-west-3":      {},
-		"il-central-1":   {},
-		"me-central-1":   {},
-		"me-south-1":     {},
-		"sa-east-1":      {},
-		"us-east-1":      {},
-		"us-east-2":      {},
-		"us-gov-east-1":  {},
-		"us-gov-west-1":  {},
-		"us-iso-east-1":  {},
-		"us-isob-east-1": {},
-		"us-west-1":      {},
-		"us-west-2":      {},
+	regions := RegionsSet{}
+	for _, partitionRegions := range regionsByPartition {
+		for _, region := range partitionRegions {
+			regions[region] = struct{}{}
+		}
+	}
+	return regions
+}
+
+// RegionsForPartition returns the static regions of the partition. Unknown partitions get the regions of the aws partition.
+func RegionsForPartition(partition string) RegionsSet {
+	partitionRegions, ok := regionsByPartition[partition]
+	if !ok {
+		partitionRegions = regionsByPartition[PartitionAWS]
+	}
+
+	regions := make(RegionsSet, len(partitionRegions))
+	for _, region := range partitionRegions {
+		regions[region] = struct{}{}
 	}
+	return regions
 }
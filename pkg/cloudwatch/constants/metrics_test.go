@@ -0,0 +1,16 @@
+package constants
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionForRegion(t *testing.T) {
+	assert.Equal(t, "aws", PartitionForRegion("eu-west-1"))
+	assert.Equal(t, "aws-us-gov", PartitionForRegion("us-gov-east-1"))
+	assert.Equal(t, "aws-cn", PartitionForRegion("cn-northwest-1"))
+	assert.Equal(t, "aws-iso", PartitionForRegion("us-iso-east-1"))
+	assert.Equal(t, "aws-iso-b", PartitionForRegion("us-isob-east-1"))
+	assert.Equal(t, "aws", PartitionForRegion(""))
+}
@@ -0,0 +1,154 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// containerInsightsAggregation is a pre-built Logs Insights aggregation of the Container Insights performance logs.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/Container-Insights-reference-performance-entries-EKS.html
+type containerInsightsAggregation struct {
+	// logType is the Type of the performance log events aggregated
+	logType string
+	// stat is the aggregation of the events, aliased as the name of the series values
+	stat string
+	// groups are the fields identifying the series
+	groups []string
+	unit   string
+}
+
+var containerInsightsAggregations = map[dataquery.ContainerInsightsQuery]containerInsightsAggregation{
+	dataquery.ContainerInsightsQueryPodCPUUtilization: {
+		logType: "Pod",
+		stat:    "avg(pod_cpu_utilization) as pod_cpu_utilization",
+		groups:  []string{"Namespace", "PodName"},
+		unit:    "percent",
+	},
+	dataquery.ContainerInsightsQueryPodMemoryUtilization: {
+		logType: "Pod",
+		stat:    "avg(pod_memory_utilization) as pod_memory_utilization",
+		groups:  []string{"Namespace", "PodName"},
+		unit:    "percent",
+	},
+	dataquery.ContainerInsightsQueryPodRestarts: {
+		logType: "Pod",
+		stat:    "max(pod_number_of_container_restarts) as pod_number_of_container_restarts",
+		groups:  []string{"Namespace", "PodName"},
+		unit:    "none",
+	},
+	dataquery.ContainerInsightsQueryNodeCPUUtilization: {
+		logType: "Node",
+		stat:    "avg(node_cpu_utilization) as node_cpu_utilization",
+		groups:  []string{"NodeName"},
+		unit:    "percent",
+	},
+	dataquery.ContainerInsightsQueryNodeMemoryUtilization: {
+		logType: "Node",
+		stat:    "avg(node_memory_utilization) as node_memory_utilization",
+		groups:  []string{"NodeName"},
+		unit:    "percent",
+	},
+}
+
+// validClusterName matches the names ECS and EKS allow for clusters
+var validClusterName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// executeContainerInsightsQueries runs the pre-built aggregations of the queries as Logs Insights queries of the
+// performance log group of their cluster, returning a labeled series per pod or node.
+func (ds *DataSource) executeContainerInsightsQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	insightsReq := &backend.QueryDataRequest{PluginContext: req.PluginContext, Headers: req.Headers}
+	units := map[string]string{}
+	for _, query := range req.Queries {
+		var logsQuery models.LogsQuery
+		if err := json.Unmarshal(query.JSON, &logsQuery); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+		insightsQuery, unit, err := buildContainerInsightsQuery(logsQuery)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		query.JSON, err = json.Marshal(insightsQuery)
+		if err != nil {
+			return nil, err
+		}
+		insightsReq.Queries = append(insightsReq.Queries, query)
+		units[query.RefID] = unit
+	}
+	if len(insightsReq.Queries) == 0 {
+		return resp, nil
+	}
+
+	insightsResp, err := executeSyncLogQuery(ctx, ds, insightsReq)
+	if err != nil {
+		return nil, err
+	}
+	for refId, response := range insightsResp.Responses {
+		for _, frame := range response.Frames {
+			frame.RefID = refId
+			setValueFieldsUnit(frame, units[refId])
+		}
+		sortFramesBySeries(response.Frames)
+		resp.Responses[refId] = response
+	}
+	return resp, nil
+}
+
+// buildContainerInsightsQuery returns the Logs Insights query running the Container Insights aggregation of the
+// query, along with the unit of its values.
+func buildContainerInsightsQuery(logsQuery models.LogsQuery) (dataquery.CloudWatchLogsQuery, string, error) {
+	if logsQuery.ContainerInsightsQuery == nil {
+		return dataquery.CloudWatchLogsQuery{}, "", backend.DownstreamError(errors.New("parameter 'containerInsightsQuery' is required"))
+	}
+	aggregation, ok := containerInsightsAggregations[*logsQuery.ContainerInsightsQuery]
+	if !ok {
+		return dataquery.CloudWatchLogsQuery{}, "", backend.DownstreamError(fmt.Errorf("unknown Container Insights query %q", *logsQuery.ContainerInsightsQuery))
+	}
+	if logsQuery.ClusterName == nil || !validClusterName.MatchString(*logsQuery.ClusterName) {
+		return dataquery.CloudWatchLogsQuery{}, "", backend.DownstreamError(errors.New("parameter 'clusterName' must be the name of a cluster"))
+	}
+
+	expression := fmt.Sprintf("filter Type = %s", strconv.Quote(aggregation.logType))
+	if logsQuery.KubernetesNamespace != nil && *logsQuery.KubernetesNamespace != "" && aggregation.logType == "Pod" {
+		expression += fmt.Sprintf(" and Namespace = %s", strconv.Quote(*logsQuery.KubernetesNamespace))
+	}
+	expression += fmt.Sprintf(" | stats %s by bin(auto)", aggregation.stat)
+	for _, group := range aggregation.groups {
+		expression += ", " + group
+	}
+
+	return dataquery.CloudWatchLogsQuery{
+		QueryMode:     dataquery.CloudWatchQueryModeLogs,
+		Id:            logsQuery.Id,
+		Region:        logsQuery.Region,
+		Expression:    &expression,
+		LogGroupNames: []string{fmt.Sprintf("/aws/containerinsights/%s/performance", *logsQuery.ClusterName)},
+		StatsGroups:   aggregation.groups,
+		RefId:         logsQuery.RefId,
+	}, aggregation.unit, nil
+}
+
+// setValueFieldsUnit sets the unit of the fields of the frame that aren't time fields.
+func setValueFieldsUnit(frame *data.Frame, unit string) {
+	for _, field := range frame.Fields {
+		if field.Type().Time() {
+			continue
+		}
+		if field.Config == nil {
+			field.Config = &data.FieldConfig{}
+		}
+		field.Config.Unit = unit
+	}
+}
@@ -0,0 +1,105 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_buildContainerInsightsQuery(t *testing.T) {
+	query := func(containerInsightsQuery dataquery.ContainerInsightsQuery, clusterName string) models.LogsQuery {
+		return models.LogsQuery{CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{
+			ContainerInsightsQuery: &containerInsightsQuery,
+			ClusterName:            &clusterName,
+			KubernetesNamespace:    aws.String("kube-system"),
+		}}
+	}
+
+	insightsQuery, unit, err := buildContainerInsightsQuery(query(dataquery.ContainerInsightsQueryPodCPUUtilization, "prod"))
+	require.NoError(t, err)
+	assert.Equal(t, `filter Type = "Pod" and Namespace = "kube-system" | stats avg(pod_cpu_utilization) as pod_cpu_utilization by bin(auto), Namespace, PodName`, *insightsQuery.Expression)
+	assert.Equal(t, []string{"/aws/containerinsights/prod/performance"}, insightsQuery.LogGroupNames)
+	assert.Equal(t, []string{"Namespace", "PodName"}, insightsQuery.StatsGroups)
+	assert.Equal(t, "percent", unit)
+
+	t.Run("node aggregations aren't restricted to the namespace", func(t *testing.T) {
+		insightsQuery, _, err := buildContainerInsightsQuery(query(dataquery.ContainerInsightsQueryNodeMemoryUtilization, "prod"))
+		require.NoError(t, err)
+		assert.Equal(t, `filter Type = "Node" | stats avg(node_memory_utilization) as node_memory_utilization by bin(auto), NodeName`, *insightsQuery.Expression)
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		_, _, err := buildContainerInsightsQuery(query("Unknown", "prod"))
+		assert.ErrorContains(t, err, `unknown Container Insights query "Unknown"`)
+
+		_, _, err = buildContainerInsightsQuery(query(dataquery.ContainerInsightsQueryPodRestarts, "prod/../other"))
+		assert.ErrorContains(t, err, "parameter 'clusterName' must be the name of a cluster")
+	})
+}
+
+func TestQuery_ContainerInsights(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	cli := fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{
+		Status: cloudwatchlogstypes.QueryStatusComplete,
+		Results: [][]cloudwatchlogstypes.ResultField{
+			{
+				{Field: aws.String("bin(5m)"), Value: aws.String("2024-01-01 00:00:00.000")},
+				{Field: aws.String("node_cpu_utilization"), Value: aws.String("40.5")},
+				{Field: aws.String("NodeName"), Value: aws.String("node-b")},
+			},
+			{
+				{Field: aws.String("bin(5m)"), Value: aws.String("2024-01-01 00:00:00.000")},
+				{Field: aws.String("node_cpu_utilization"), Value: aws.String("12.5")},
+				{Field: aws.String("NodeName"), Value: aws.String("node-a")},
+			},
+		},
+	}}
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return &cli
+	}
+	ds := newTestDatasource()
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)},
+				JSON:      json.RawMessage(`{"queryMode": "Logs", "logsMode": "ContainerInsights", "containerInsightsQuery": "NodeCPUUtilization", "clusterName": "prod"}`),
+			},
+			{
+				RefID: "B",
+				JSON:  json.RawMessage(`{"queryMode": "Logs", "logsMode": "ContainerInsights", "containerInsightsQuery": "NodeCPUUtilization"}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, cli.calls.startQuery, 1)
+	assert.Equal(t, []string{"/aws/containerinsights/prod/performance"}, cli.calls.startQuery[0].LogGroupNames)
+
+	frames := resp.Responses["A"].Frames
+	require.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, frames, 2)
+	assert.Equal(t, "node-a", frames[0].Name)
+	assert.Equal(t, "A", frames[0].RefID)
+	assert.Equal(t, "node-a", frames[0].Fields[1].Labels["NodeName"])
+	assert.Equal(t, "percent", frames[0].Fields[1].Config.Unit)
+	assert.Equal(t, "node-b", frames[1].Name)
+
+	assert.ErrorContains(t, resp.Responses["B"].Error, "parameter 'clusterName' must be the name of a cluster")
+}
@@ -0,0 +1,208 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// insightRulePeriods are the periods picked for Contributor Insights queries without one, the shortest one keeping the
+// datapoints of each contributor under maxInsightRuleDatapoints.
+var insightRulePeriods = []int{60, 300, 900, 3600, 21600, 86400}
+
+const maxInsightRuleDatapoints = 1440
+
+// executeContributorInsightsQueries returns the top contributors of the Contributor Insights rule of each query, either
+// as a time series per contributor or as a table of their aggregate values over the time range.
+func (ds *DataSource) executeContributorInsightsQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		var model dataquery.CloudWatchContributorInsightsQuery
+		if err := json.Unmarshal(query.JSON, &model); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+
+		input, err := insightRuleReportInput(model, query.TimeRange)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+
+		cfg, err := ds.getAWSConfig(ctx, model.Region)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(fmt.Errorf("%v: %w", "failed to get client", err))
+			continue
+		}
+		report, err := NewInsightRulesAPI(cfg).GetInsightRuleReport(ctx, input)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(fmt.Errorf("%v: %w", "failed to call cloudwatch:GetInsightRuleReport", err)))
+			continue
+		}
+
+		var frames data.Frames
+		if model.Format != nil && *model.Format == dataquery.ContributorInsightsFormatTable {
+			frames = data.Frames{contributorsTableFrame(report)}
+		} else {
+			frames = contributorsTimeSeriesFrames(report)
+		}
+		for _, frame := range frames {
+			frame.RefID = query.RefID
+		}
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: frames}
+	}
+	return resp, nil
+}
+
+func insightRuleReportInput(model dataquery.CloudWatchContributorInsightsQuery, timeRange backend.TimeRange) (*cloudwatch.GetInsightRuleReportInput, error) {
+	if model.RuleName == "" {
+		return nil, errors.New("invalid Contributor Insights query: a rule name is required")
+	}
+
+	period := insightRulePeriod(timeRange.Duration())
+	if model.Period != nil && *model.Period != "" && strings.ToLower(*model.Period) != "auto" {
+		requested, err := strconv.Atoi(*model.Period)
+		if err != nil || requested < 1 {
+			return nil, fmt.Errorf("query period must be a positive number of seconds, got %q", *model.Period)
+		}
+		period = requested
+	}
+
+	orderBy := "Sum"
+	if model.OrderBy != nil && *model.OrderBy != "" {
+		orderBy = *model.OrderBy
+	}
+	if orderBy != "Sum" && orderBy != "Maximum" {
+		return nil, fmt.Errorf("contributors can only be ordered by Sum or Maximum, got %q", orderBy)
+	}
+
+	input := &cloudwatch.GetInsightRuleReportInput{
+		RuleName:  aws.String(model.RuleName),
+		StartTime: aws.Time(timeRange.From),
+		EndTime:   aws.Time(timeRange.To),
+		Period:    aws.Int32(int32(period)),
+		OrderBy:   aws.String(orderBy),
+	}
+	if model.MaxContributorCount != nil {
+		if *model.MaxContributorCount < 1 || *model.MaxContributorCount > 100 {
+			return nil, fmt.Errorf("the maximum number of contributors must be between 1 and 100, got %d", *model.MaxContributorCount)
+		}
+		input.MaxContributorCount = model.MaxContributorCount
+	}
+	return input, nil
+}
+
+func insightRulePeriod(timeRange time.Duration) int {
+	datapoints := int(math.Ceil(timeRange.Seconds() / maxInsightRuleDatapoints))
+	for _, period := range insightRulePeriods {
+		if datapoints <= period {
+			return period
+		}
+	}
+	return insightRulePeriods[len(insightRulePeriods)-1]
+}
+
+// contributorsTimeSeriesFrames returns a time series per contributor, labelled by the keys of the contributor.
+func contributorsTimeSeriesFrames(report *cloudwatch.GetInsightRuleReportOutput) data.Frames {
+	frames := make(data.Frames, 0, len(report.Contributors))
+	for _, contributor := range report.Contributors {
+		labels := data.Labels{}
+		for i, key := range contributor.Keys {
+			if i < len(report.KeyLabels) {
+				labels[report.KeyLabels[i]] = key
+			}
+		}
+		times := make([]time.Time, 0, len(contributor.Datapoints))
+		values := make([]*float64, 0, len(contributor.Datapoints))
+		for _, datapoint := range contributor.Datapoints {
+			times = append(times, aws.ToTime(datapoint.Timestamp))
+			values = append(values, datapoint.ApproximateValue)
+		}
+
+		name := strings.Join(contributor.Keys, ", ")
+		frame := data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			data.NewField(data.TimeSeriesValueFieldName, labels, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name}),
+		)
+		frame.Meta = &data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// contributorsTableFrame returns a table of the keys of the contributors and their aggregate values, in the order of
+// the report.
+func contributorsTableFrame(report *cloudwatch.GetInsightRuleReportOutput) *data.Frame {
+	frame := data.NewFrame("contributors")
+	for i, label := range report.KeyLabels {
+		keys := make([]string, 0, len(report.Contributors))
+		for _, contributor := range report.Contributors {
+			key := ""
+			if i < len(contributor.Keys) {
+				key = contributor.Keys[i]
+			}
+			keys = append(keys, key)
+		}
+		frame.Fields = append(frame.Fields, data.NewField(label, nil, keys))
+	}
+	values := make([]*float64, 0, len(report.Contributors))
+	for _, contributor := range report.Contributors {
+		values = append(values, contributor.ApproximateAggregateValue)
+	}
+	frame.Fields = append(frame.Fields, data.NewField("value", nil, values).SetConfig(&data.FieldConfig{DisplayName: "Value"}))
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+	return frame
+}
+
+// InsightRulesHandler returns the Contributor Insights rules of the region of the request.
+func (ds *DataSource) InsightRulesHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	request, err := resources.ParseInsightRulesRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in InsightRulesHandler", http.StatusBadRequest, err)
+	}
+
+	cfg, err := ds.newAWSConfig(ctx, request.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in InsightRulesHandler", http.StatusInternalServerError, err)
+	}
+
+	response := []resources.ResourceResponse[resources.InsightRule]{}
+	paginator := cloudwatch.NewDescribeInsightRulesPaginator(NewInsightRulesAPI(cfg), &cloudwatch.DescribeInsightRulesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, models.NewHttpError("error in InsightRulesHandler", http.StatusInternalServerError, fmt.Errorf("DescribeInsightRules error: %w", err))
+		}
+		for _, rule := range page.InsightRules {
+			response = append(response, resources.ResourceResponse[resources.InsightRule]{
+				Value: resources.InsightRule{
+					Name:        aws.ToString(rule.Name),
+					State:       aws.ToString(rule.State),
+					ManagedRule: aws.ToBool(rule.ManagedRule),
+				},
+			})
+		}
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in InsightRulesHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
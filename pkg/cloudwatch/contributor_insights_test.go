@@ -0,0 +1,160 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_ContributorInsightsQuery(t *testing.T) {
+	ds := newTestDatasource()
+	origNewInsightRulesAPI := NewInsightRulesAPI
+	t.Cleanup(func() {
+		NewInsightRulesAPI = origNewInsightRulesAPI
+	})
+
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	report := &cloudwatch.GetInsightRuleReportOutput{
+		KeyLabels: []string{"sourceAddress", "action"},
+		Contributors: []cloudwatchtypes.InsightRuleContributor{
+			{
+				Keys:                      []string{"10.0.0.1", "REJECT"},
+				ApproximateAggregateValue: aws.Float64(30),
+				Datapoints: []cloudwatchtypes.InsightRuleContributorDatapoint{
+					{Timestamp: aws.Time(from), ApproximateValue: aws.Float64(10)},
+					{Timestamp: aws.Time(from.Add(time.Minute)), ApproximateValue: aws.Float64(20)},
+				},
+			},
+			{
+				Keys:                      []string{"10.0.0.2", "ACCEPT"},
+				ApproximateAggregateValue: aws.Float64(5),
+				Datapoints: []cloudwatchtypes.InsightRuleContributorDatapoint{
+					{Timestamp: aws.Time(from), ApproximateValue: aws.Float64(5)},
+				},
+			},
+		},
+	}
+	query := func(t *testing.T, client *mocks.FakeInsightRulesClient, queryJSON string) backend.DataResponse {
+		t.Helper()
+		NewInsightRulesAPI = func(aws.Config) models.InsightRulesAPIProvider {
+			return client
+		}
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: from.Add(3 * time.Hour)},
+				JSON:      json.RawMessage(queryJSON),
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("returns a time series per contributor", func(t *testing.T) {
+		client := &mocks.FakeInsightRulesClient{}
+		client.On("GetInsightRuleReport", mock.Anything).Return(report, nil)
+
+		resp := query(t, client, `{"queryMode": "ContributorInsights", "region": "us-east-1", "ruleName": "rejected-flows", "maxContributorCount": 2}`)
+
+		client.AssertCalled(t, "GetInsightRuleReport", &cloudwatch.GetInsightRuleReportInput{
+			RuleName:            aws.String("rejected-flows"),
+			StartTime:           aws.Time(from),
+			EndTime:             aws.Time(from.Add(3 * time.Hour)),
+			Period:              aws.Int32(60),
+			OrderBy:             aws.String("Sum"),
+			MaxContributorCount: aws.Int32(2),
+		})
+		require.NoError(t, resp.Error)
+		require.Len(t, resp.Frames, 2)
+		frame := resp.Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, data.Labels{"sourceAddress": "10.0.0.1", "action": "REJECT"}, frame.Fields[1].Labels)
+		assert.Equal(t, "10.0.0.1, REJECT", frame.Fields[1].Config.DisplayNameFromDS)
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, []any{from.Add(time.Minute), aws.Float64(20)}, frame.RowCopy(1))
+	})
+
+	t.Run("returns a table of the aggregate values of the contributors", func(t *testing.T) {
+		client := &mocks.FakeInsightRulesClient{}
+		client.On("GetInsightRuleReport", mock.Anything).Return(report, nil)
+
+		resp := query(t, client, `{"queryMode": "ContributorInsights", "region": "us-east-1", "ruleName": "rejected-flows", "orderBy": "Maximum", "period": "300", "format": "Table"}`)
+
+		input := client.Calls[0].Arguments.Get(0).(*cloudwatch.GetInsightRuleReportInput)
+		assert.Equal(t, int32(300), *input.Period)
+		assert.Equal(t, "Maximum", *input.OrderBy)
+		require.NoError(t, resp.Error)
+		require.Len(t, resp.Frames, 1)
+		frame := resp.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, []any{"10.0.0.1", "REJECT", aws.Float64(30)}, frame.RowCopy(0))
+		assert.Equal(t, []any{"10.0.0.2", "ACCEPT", aws.Float64(5)}, frame.RowCopy(1))
+	})
+
+	t.Run("returns an error when the query is invalid", func(t *testing.T) {
+		client := &mocks.FakeInsightRulesClient{}
+
+		resp := query(t, client, `{"queryMode": "ContributorInsights", "region": "us-east-1", "ruleName": "rejected-flows", "orderBy": "Average"}`)
+
+		assert.ErrorContains(t, resp.Error, `contributors can only be ordered by Sum or Maximum, got "Average"`)
+		client.AssertNotCalled(t, "GetInsightRuleReport", mock.Anything)
+	})
+}
+
+func Test_insightRulePeriod(t *testing.T) {
+	assert.Equal(t, 60, insightRulePeriod(time.Hour))
+	assert.Equal(t, 300, insightRulePeriod(2*24*time.Hour))
+	assert.Equal(t, 86400, insightRulePeriod(10*365*24*time.Hour))
+}
+
+func Test_insight_rules_route(t *testing.T) {
+	origNewInsightRulesAPI := NewInsightRulesAPI
+	t.Cleanup(func() {
+		NewInsightRulesAPI = origNewInsightRulesAPI
+	})
+	ds := newTestDatasource()
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.InsightRulesHandler))
+
+	t.Run("returns the rules of the region", func(t *testing.T) {
+		client := &mocks.FakeInsightRulesClient{}
+		client.On("DescribeInsightRules", mock.Anything).Return(&cloudwatch.DescribeInsightRulesOutput{
+			InsightRules: []cloudwatchtypes.InsightRule{{
+				Name:        aws.String("rejected-flows"),
+				State:       aws.String("ENABLED"),
+				ManagedRule: aws.Bool(false),
+			}},
+		}, nil)
+		NewInsightRulesAPI = func(aws.Config) models.InsightRulesAPIProvider {
+			return client
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/insight-rules?region=us-east-1", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"value":{"name":"rejected-flows","state":"ENABLED","managedRule":false}}]`, rr.Body.String())
+	})
+
+	t.Run("returns an error when the region is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/insight-rules", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,176 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/features"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// deltaFetchExpiration is how long the last result of a query is kept for its next refresh.
+const deltaFetchExpiration = 10 * time.Minute
+
+type deltaFetchEntry struct {
+	response  backend.DataResponse
+	timeRange backend.TimeRange
+	period    int
+}
+
+// executeTimeSeriesQueryWithDeltaFetch re-uses the last result of each query when a panel refreshes with a time range
+// that has moved forward, and only fetches the datapoints from the last returned timestamp onwards.
+//
+// Only queries whose datapoints don't depend on the rest of the time range are fetched incrementally, and only when
+// every query in the request qualifies, so that expressions never reference queries fetched for another range.
+func (ds *DataSource) executeTimeSeriesQueryWithDeltaFetch(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	parsedQueries, ok := ds.parseDeltaFetchQueries(ctx, req.Queries)
+	if !ok {
+		return ds.executeTimeSeriesQuery(ctx, req)
+	}
+
+	keysByRefId := map[string]string{}
+	entriesByRefId := map[string]deltaFetchEntry{}
+	deltaFromByRefId := map[string]time.Time{}
+	deltaQueries := make([]backend.DataQuery, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		period := parsedQueries[query.RefID].Period
+		identity := query
+		identity.TimeRange = backend.TimeRange{}
+		key, err := queryCacheKey(ctx, identity, ds.Settings.UserIdentityPassThrough)
+		if err != nil {
+			return ds.executeTimeSeriesQuery(ctx, req)
+		}
+		keysByRefId[query.RefID] = key
+
+		if cached, found := ds.deltaFetchCache.Get(key); found {
+			entry := cached.(deltaFetchEntry)
+			if deltaFrom, ok := deltaFetchStart(entry, query.TimeRange, period); ok {
+				entriesByRefId[query.RefID] = entry
+				deltaFromByRefId[query.RefID] = deltaFrom
+				query.TimeRange.From = deltaFrom
+			}
+		}
+
+		// keep the period of the whole time range rather than the one that would be picked for the delta
+		query.JSON, err = withPeriod(query.JSON, period)
+		if err != nil {
+			return ds.executeTimeSeriesQuery(ctx, req)
+		}
+		deltaQueries = append(deltaQueries, query)
+	}
+
+	deltaReq := *req
+	deltaReq.Queries = deltaQueries
+	resp, err := ds.executeTimeSeriesQuery(ctx, &deltaReq)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range req.Queries {
+		response, ok := resp.Responses[query.RefID]
+		if !ok || response.Error != nil {
+			continue
+		}
+		if entry, ok := entriesByRefId[query.RefID]; ok {
+			response = mergeDeltaResponse(entry.response, response, query.TimeRange.From, deltaFromByRefId[query.RefID])
+			resp.Responses[query.RefID] = response
+		}
+		ds.deltaFetchCache.Set(keysByRefId[query.RefID], deltaFetchEntry{
+			response:  response,
+			timeRange: query.TimeRange,
+			period:    parsedQueries[query.RefID].Period,
+		}, deltaFetchExpiration)
+	}
+	return resp, nil
+}
+
+// parseDeltaFetchQueries parses the queries of the request, and reports whether all of them can be fetched incrementally.
+func (ds *DataSource) parseDeltaFetchQueries(ctx context.Context, queries []backend.DataQuery) (map[string]*models.CloudWatchQuery, bool) {
+	parsedQueries := map[string]*models.CloudWatchQuery{}
+	for _, query := range queries {
+		parsed, err := models.ParseMetricDataQueries([]backend.DataQuery{query}, query.TimeRange.From, query.TimeRange.To, ds.Settings.Region,
+			ds.logger.FromContext(ctx), features.IsEnabled(ctx, features.FlagCloudWatchCrossAccountQuerying), ds.Settings.Variables)
+		if err != nil || len(parsed) != 1 {
+			return nil, false
+		}
+		mode := parsed[0].GetGetMetricDataAPIMode()
+		if mode != models.GMDApiModeMetricStat && mode != models.GMDApiModeInferredSearchExpression {
+			return nil, false
+		}
+		// the results of instant queries are tables of the latest datapoints rather than series that can be extended, and
+		// which series are kept by sorted and limited queries depends on the datapoints of the whole time range
+		if parsed[0].Instant || parsed[0].SeriesSortBy != "" || parsed[0].SeriesLimit > 0 {
+			return nil, false
+		}
+		parsedQueries[query.RefID] = parsed[0]
+	}
+	return parsedQueries, true
+}
+
+// deltaFetchStart returns where to start fetching datapoints for the time range given the last result of the query.
+// The last datapoint of each series is fetched again, as its period may not have been complete yet.
+func deltaFetchStart(entry deltaFetchEntry, timeRange backend.TimeRange, period int) (time.Time, bool) {
+	if entry.period != period || timeRange.From.Before(entry.timeRange.From) || !timeRange.To.After(entry.timeRange.To) ||
+		!timeRange.From.Before(entry.timeRange.To) {
+		return time.Time{}, false
+	}
+
+	deltaFrom := entry.timeRange.To
+	for _, frame := range entry.response.Frames {
+		if frame.Rows() == 0 || len(frame.Fields) == 0 {
+			continue
+		}
+		if last, ok := frame.Fields[0].At(frame.Rows() - 1).(time.Time); ok && last.Before(deltaFrom) {
+			deltaFrom = last
+		}
+	}
+	if deltaFrom.Before(timeRange.From) {
+		deltaFrom = timeRange.From
+	}
+	return deltaFrom, true
+}
+
+// mergeDeltaResponse appends the datapoints fetched from deltaFrom to the previous result of a query, dropping the
+// datapoints that fell out of the time range starting at from as well as those that were fetched again.
+func mergeDeltaResponse(previous backend.DataResponse, delta backend.DataResponse, from time.Time, deltaFrom time.Time) backend.DataResponse {
+	merged := delta
+	merged.Frames = data.Frames{}
+
+	matched := map[*data.Frame]bool{}
+	for _, previousFrame := range previous.Frames {
+		frame := previousFrame.EmptyCopy()
+		for row := 0; row < previousFrame.Rows(); row++ {
+			timestamp, ok := previousFrame.Fields[0].At(row).(time.Time)
+			if ok && !timestamp.Before(from) && timestamp.Before(deltaFrom) {
+				frame.AppendRow(previousFrame.RowCopy(row)...)
+			}
+		}
+		if deltaFrame := findSeriesFrame(delta.Frames, previousFrame); deltaFrame != nil {
+			matched[deltaFrame] = true
+			for row := 0; row < deltaFrame.Rows(); row++ {
+				frame.AppendRow(deltaFrame.RowCopy(row)...)
+			}
+		}
+		merged.Frames = append(merged.Frames, frame)
+	}
+	for _, deltaFrame := range delta.Frames {
+		if !matched[deltaFrame] {
+			merged.Frames = append(merged.Frames, deltaFrame)
+		}
+	}
+	return merged
+}
+
+// withPeriod sets the period of a query model.
+func withPeriod(queryJSON json.RawMessage, period int) (json.RawMessage, error) {
+	var model map[string]any
+	if err := json.Unmarshal(queryJSON, &model); err != nil {
+		return nil, err
+	}
+	model["period"] = strconv.Itoa(period)
+	return json.Marshal(model)
+}
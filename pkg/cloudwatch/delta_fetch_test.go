@@ -0,0 +1,153 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func seriesFrame(name string, timestamps ...time.Time) *data.Frame {
+	values := make([]float64, len(timestamps))
+	for i := range timestamps {
+		values[i] = float64(i)
+	}
+	return data.NewFrame(name,
+		data.NewField(data.TimeSeriesTimeFieldName, nil, timestamps),
+		data.NewField(data.TimeSeriesValueFieldName, data.Labels{"InstanceId": "i-1"}, values))
+}
+
+func Test_deltaFetchStart(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	entry := deltaFetchEntry{
+		response:  backend.DataResponse{Frames: data.Frames{seriesFrame("cpu", start, start.Add(time.Minute), start.Add(2*time.Minute))}},
+		timeRange: backend.TimeRange{From: start, To: start.Add(150 * time.Second)},
+		period:    60,
+	}
+	moved := backend.TimeRange{From: start.Add(time.Minute), To: start.Add(210 * time.Second)}
+
+	t.Run("starts at the last datapoint", func(t *testing.T) {
+		deltaFrom, ok := deltaFetchStart(entry, moved, 60)
+		require.True(t, ok)
+		assert.Equal(t, start.Add(2*time.Minute), deltaFrom)
+	})
+
+	t.Run("starts at the earliest last datapoint of all series", func(t *testing.T) {
+		entry := entry
+		entry.response.Frames = append(data.Frames{seriesFrame("network", start)}, entry.response.Frames...)
+		deltaFrom, ok := deltaFetchStart(entry, moved, 60)
+		require.True(t, ok)
+		assert.Equal(t, start.Add(time.Minute), deltaFrom, "clamped to the start of the time range")
+	})
+
+	t.Run("refetches everything when the time range didn't move forward or the period changed", func(t *testing.T) {
+		_, ok := deltaFetchStart(entry, moved, 300)
+		assert.False(t, ok)
+		_, ok = deltaFetchStart(entry, entry.timeRange, 60)
+		assert.False(t, ok)
+		_, ok = deltaFetchStart(entry, backend.TimeRange{From: start.Add(-time.Minute), To: moved.To}, 60)
+		assert.False(t, ok)
+		_, ok = deltaFetchStart(entry, backend.TimeRange{From: start.Add(time.Hour), To: start.Add(2 * time.Hour)}, 60)
+		assert.False(t, ok)
+	})
+}
+
+func Test_mergeDeltaResponse(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	previous := backend.DataResponse{Frames: data.Frames{seriesFrame("cpu", start, start.Add(time.Minute), start.Add(2*time.Minute))}}
+	delta := backend.DataResponse{Frames: data.Frames{
+		seriesFrame("cpu", start.Add(2*time.Minute), start.Add(3*time.Minute)),
+		seriesFrame("network", start.Add(3*time.Minute)),
+	}}
+
+	merged := mergeDeltaResponse(previous, delta, start.Add(time.Minute), start.Add(2*time.Minute))
+
+	require.Len(t, merged.Frames, 2)
+	cpu := merged.Frames[0]
+	require.Equal(t, 3, cpu.Rows())
+	assert.Equal(t, start.Add(time.Minute), cpu.Fields[0].At(0), "datapoints before the time range are dropped")
+	assert.Equal(t, start.Add(2*time.Minute), cpu.Fields[0].At(1))
+	assert.Equal(t, 0.0, cpu.Fields[1].At(1), "refetched datapoints replace the previous ones")
+	assert.Equal(t, start.Add(3*time.Minute), cpu.Fields[0].At(2))
+	assert.Equal(t, "network", merged.Frames[1].Name)
+	assert.Equal(t, 3, previous.Frames[0].Rows(), "the previous result is left untouched")
+}
+
+func TestQueryData_deltaFetch(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+	now := time.Now().Truncate(time.Minute)
+	result := func(timestamps ...time.Time) *cloudwatch.GetMetricDataOutput {
+		values := make([]float64, len(timestamps))
+		for i := range timestamps {
+			values[i] = float64(timestamps[i].Unix())
+		}
+		return &cloudwatch.GetMetricDataOutput{MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+			StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: values, Timestamps: timestamps,
+		}}}
+	}
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).
+		Return(result(now.Add(-3*time.Minute), now.Add(-2*time.Minute), now.Add(-time.Minute)), nil).Once()
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).
+		Return(result(now.Add(-time.Minute), now), nil).Once()
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.deltaFetchCache = cache.New(deltaFetchExpiration, 0)
+		ds.Settings.DeltaFetch = true
+	})
+	query := func(from, to time.Time) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON: json.RawMessage(`{
+					"type": "timeSeriesQuery",
+					"namespace": "AWS/EC2",
+					"metricName": "CPUUtilization",
+					"dimensions": {"InstanceId": "i-1"},
+					"region": "us-east-1",
+					"id": "a",
+					"statistic": "Average",
+					"period": "60",
+					"matchExact": true,
+					"refId": "A"
+				}`),
+			}},
+		}
+	}
+
+	_, err := ds.QueryData(context.Background(), query(now.Add(-4*time.Minute), now.Add(-30*time.Second)))
+	require.NoError(t, err)
+	second, err := ds.QueryData(context.Background(), query(now.Add(-2*time.Minute), now.Add(30*time.Second)))
+	require.NoError(t, err)
+
+	api.AssertNumberOfCalls(t, "GetMetricData", 2)
+	input := api.Calls[1].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+	assert.Equal(t, now.Add(-time.Minute), *input.StartTime, "only datapoints from the last one onwards are fetched")
+
+	require.Len(t, second.Responses["A"].Frames, 1)
+	frame := second.Responses["A"].Frames[0]
+	require.Equal(t, 3, frame.Rows())
+	assert.Equal(t, now.Add(-2*time.Minute), frame.Fields[0].At(0))
+	assert.Equal(t, now.Add(-time.Minute), frame.Fields[0].At(1))
+	assert.Equal(t, now, frame.Fields[0].At(2))
+}
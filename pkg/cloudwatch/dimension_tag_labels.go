@@ -0,0 +1,136 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	resourcegroupstaggingapitypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// dimensionTagsCacheExpiration is how long the tags of the resources of a namespace are kept, so that every refresh of
+// a dashboard doesn't list the resources again
+const dimensionTagsCacheExpiration = time.Hour
+
+// dimensionTagLabel is a validated models.DimensionTagLabel
+type dimensionTagLabel struct {
+	namespace string
+	dimension string
+	tag       string
+	label     string
+	resource  tagQueryResource
+}
+
+func compileDimensionTagLabels(labels []models.DimensionTagLabel) ([]dimensionTagLabel, error) {
+	compiled := make([]dimensionTagLabel, 0, len(labels))
+	for i, label := range labels {
+		resource, ok := tagQueryResources[label.Namespace]
+		if !ok {
+			return nil, backend.DownstreamError(fmt.Errorf("dimension tag label %d: the resources of namespace %q can't be looked up by tag", i+1, label.Namespace))
+		}
+		if label.Dimension != resource.dimensionKey {
+			return nil, backend.DownstreamError(fmt.Errorf("dimension tag label %d: the resources of %s are identified by the %s dimension, not %q",
+				i+1, label.Namespace, resource.dimensionKey, label.Dimension))
+		}
+		if label.Tag == "" {
+			return nil, backend.DownstreamError(fmt.Errorf("dimension tag label %d: missing tag", i+1))
+		}
+		name := label.Label
+		if name == "" {
+			name = label.Dimension + label.Tag
+		}
+		compiled = append(compiled, dimensionTagLabel{
+			namespace: label.Namespace,
+			dimension: label.Dimension,
+			tag:       label.Tag,
+			label:     name,
+			resource:  resource,
+		})
+	}
+	return compiled, nil
+}
+
+// setDimensionTagLabels labels the series of a query with the tags of the resources their dimensions identify, as the
+// dimension tag labels of the data source configure for its namespace. Series whose resource doesn't have the tag
+// are left as they are. Failing to look the tags up only adds a notice, as the series are still valid.
+func (ds *DataSource) setDimensionTagLabels(ctx context.Context, frames data.Frames, query *models.CloudWatchQuery) {
+	for _, rule := range ds.dimensionTagLabels {
+		if rule.namespace != query.Namespace || !hasLabel(frames, rule.dimension) {
+			continue
+		}
+
+		tags, err := ds.getDimensionTags(ctx, query.Region, rule)
+		if err != nil {
+			frames[0].AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("The %s tags of the %s resources couldn't be fetched: %s", rule.tag, rule.namespace, err),
+			})
+			continue
+		}
+		for _, frame := range frames {
+			for _, field := range frame.Fields {
+				value, ok := field.Labels[rule.dimension]
+				if !ok {
+					continue
+				}
+				if tag, ok := tags[value]; ok {
+					field.Labels[rule.label] = tag
+				}
+			}
+		}
+	}
+}
+
+func hasLabel(frames data.Frames, name string) bool {
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if _, ok := field.Labels[name]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getDimensionTags returns the values of the tag of a rule by the dimension value of their resource. All the tagged
+// resources of the namespace are listed at once, as a dashboard usually shows many of them.
+func (ds *DataSource) getDimensionTags(ctx context.Context, region string, rule dimensionTagLabel) (map[string]string, error) {
+	key, err := ds.roleScopedCacheKey(ctx, map[string]any{"region": region, "namespace": rule.namespace, "tag": rule.tag})
+	if err != nil {
+		return nil, err
+	}
+	if tags, ok := ds.dimensionTagsCache.Get(key); ok {
+		return tags.(map[string]string), nil
+	}
+
+	filters := []resourcegroupstaggingapitypes.TagFilter{{Key: aws.String(rule.tag)}}
+	resources, err := ds.resourceGroupsGetResources(ctx, region, filters, []string{rule.resource.resourceType})
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for _, mapping := range resources.ResourceTagMappingList {
+		resourceARN, err := arn.Parse(aws.ToString(mapping.ResourceARN))
+		if err != nil {
+			continue
+		}
+		value, ok := rule.resource.dimensionValue(resourceARN.Resource)
+		if !ok {
+			continue
+		}
+		for _, tag := range mapping.Tags {
+			if aws.ToString(tag.Key) == rule.tag {
+				tags[value] = aws.ToString(tag.Value)
+			}
+		}
+	}
+	ds.dimensionTagsCache.Set(key, tags, cache.DefaultExpiration)
+	return tags, nil
+}
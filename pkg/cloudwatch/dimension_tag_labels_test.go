@@ -0,0 +1,111 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	resourcegroupstaggingapitypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_compileDimensionTagLabels(t *testing.T) {
+	_, err := compileDimensionTagLabels([]models.DimensionTagLabel{{Namespace: "AWS/Route53", Dimension: "HealthCheckId", Tag: "Name"}})
+	assert.ErrorContains(t, err, `dimension tag label 1: the resources of namespace "AWS/Route53" can't be looked up by tag`)
+	_, err = compileDimensionTagLabels([]models.DimensionTagLabel{{Namespace: "AWS/RDS", Dimension: "DBClusterIdentifier", Tag: "Name"}})
+	assert.ErrorContains(t, err, `dimension tag label 1: the resources of AWS/RDS are identified by the DBInstanceIdentifier dimension, not "DBClusterIdentifier"`)
+	_, err = compileDimensionTagLabels([]models.DimensionTagLabel{{Namespace: "AWS/RDS", Dimension: "DBInstanceIdentifier"}})
+	assert.ErrorContains(t, err, "dimension tag label 1: missing tag")
+
+	labels, err := compileDimensionTagLabels([]models.DimensionTagLabel{
+		{Namespace: "AWS/RDS", Dimension: "DBInstanceIdentifier", Tag: "Name"},
+		{Namespace: "AWS/ApplicationELB", Dimension: "LoadBalancer", Tag: "team", Label: "owner"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "DBInstanceIdentifierName", labels[0].label)
+	assert.Equal(t, "owner", labels[1].label)
+}
+
+func TestSetDimensionTagLabels(t *testing.T) {
+	origNewRGTAClient := NewRGTAClient
+	t.Cleanup(func() {
+		NewRGTAClient = origNewRGTAClient
+	})
+	calls := 0
+	NewRGTAClient = func(aws.Config) resourcegroupstaggingapi.GetResourcesAPIClient {
+		calls++
+		return fakeRGTAClient{tagMapping: []resourcegroupstaggingapitypes.ResourceTagMapping{
+			{
+				ResourceARN: aws.String("arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/checkout/50dc6c495c0c9188"),
+				Tags:        []resourcegroupstaggingapitypes.Tag{{Key: aws.String("Name"), Value: aws.String("checkout-alb")}},
+			},
+			{
+				ResourceARN: aws.String("arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/payments/60dc6c495c0c9188"),
+				Tags:        []resourcegroupstaggingapitypes.Tag{{Key: aws.String("Name"), Value: aws.String("payments-nlb")}},
+			},
+		}}
+	}
+	labels, err := compileDimensionTagLabels([]models.DimensionTagLabel{{Namespace: "AWS/ApplicationELB", Dimension: "LoadBalancer", Tag: "Name"}})
+	require.NoError(t, err)
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.dimensionTagLabels = labels
+	})
+	series := func(loadBalancer string) *data.Frame {
+		return data.NewFrame(loadBalancer,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{"LoadBalancer": loadBalancer}, []float64{}),
+		)
+	}
+	query := &models.CloudWatchQuery{Region: "us-east-1", Namespace: "AWS/ApplicationELB", MetricName: "RequestCount"}
+
+	t.Run("labels the series with the tag of their resource", func(t *testing.T) {
+		frames := data.Frames{series("app/checkout/50dc6c495c0c9188"), series("app/search/70dc6c495c0c9188")}
+
+		ds.setDimensionTagLabels(context.Background(), frames, query)
+
+		assert.Equal(t, data.Labels{"LoadBalancer": "app/checkout/50dc6c495c0c9188", "LoadBalancerName": "checkout-alb"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, data.Labels{"LoadBalancer": "app/search/70dc6c495c0c9188"}, frames[1].Fields[1].Labels)
+	})
+
+	t.Run("reuses the tags of the resources", func(t *testing.T) {
+		frames := data.Frames{series("app/checkout/50dc6c495c0c9188")}
+
+		ds.setDimensionTagLabels(context.Background(), frames, query)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "checkout-alb", frames[0].Fields[1].Labels["LoadBalancerName"])
+	})
+
+	t.Run("doesn't label the series of other namespaces", func(t *testing.T) {
+		frames := data.Frames{series("net/payments/60dc6c495c0c9188")}
+
+		ds.setDimensionTagLabels(context.Background(), frames, &models.CloudWatchQuery{Region: "us-east-1", Namespace: "AWS/NetworkELB"})
+
+		assert.Equal(t, data.Labels{"LoadBalancer": "net/payments/60dc6c495c0c9188"}, frames[0].Fields[1].Labels)
+	})
+
+	t.Run("doesn't share the tags of a user with another when their own identity is used", func(t *testing.T) {
+		calls = 0
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.dimensionTagLabels = labels
+			ds.Settings.UserIdentityPassThrough = true
+			ds.Settings.WebIdentityRoleMap = map[string]string{webIdentityRoleMapWildcard: "arn:aws:iam::123456789012:role/users"}
+		})
+		userCtx := func(login string) context.Context {
+			ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{User: &backend.User{Login: login}})
+			return context.WithValue(ctx, webIdentityTokenKey{}, login+"-token")
+		}
+
+		ds.setDimensionTagLabels(userCtx("alice"), data.Frames{series("app/checkout/50dc6c495c0c9188")}, query)
+		ds.setDimensionTagLabels(userCtx("bob"), data.Frames{series("app/checkout/50dc6c495c0c9188")}, query)
+		ds.setDimensionTagLabels(userCtx("alice"), data.Frames{series("app/checkout/50dc6c495c0c9188")}, query)
+
+		assert.Equal(t, 2, calls)
+	})
+}
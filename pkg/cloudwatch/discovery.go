@@ -0,0 +1,196 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/clients"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/features"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+)
+
+const (
+	// discoveryProbeExpiration is how long the namespaces found in a region are reused, as probing every region takes
+	// a ListMetrics call per page of the recently active metrics of each
+	discoveryProbeExpiration = 30 * time.Minute
+	// maxConcurrentDiscoveryProbes is the number of regions probed at the same time
+	maxConcurrentDiscoveryProbes = 5
+	// discoveryOwnAccountLabel labels the account of the data source's credentials when the accounts can't be listed
+	discoveryOwnAccountLabel = "Data source account"
+)
+
+// discoveryProbe is the namespaces of the metrics recently published in a region, by owning account. The account is
+// empty when linked accounts aren't listed.
+type discoveryProbe map[string][]string
+
+// DiscoveryHandler returns the accounts the credentials of the data source can query, the regions they recently
+// published metrics in and the namespaces of these metrics, for users who don't know what exists to browse their
+// way to a metric. The accounts are those of the monitoring account when cross-account observability is set up, and
+// regions are probed for recently active metrics with ListMetrics. Regions whose probe fails, e.g. as they are
+// disabled by policy, are left out.
+func (ds *DataSource) DiscoveryHandler(ctx context.Context, _ url.Values) ([]byte, *models.HttpError) {
+	var accounts []resources.Account
+	if features.IsEnabled(ctx, features.FlagCloudWatchCrossAccountQuerying) {
+		service, err := ds.GetAccountsService(ctx, defaultRegion)
+		if err != nil {
+			return nil, models.NewHttpError("error in DiscoveryHandler", http.StatusInternalServerError, err)
+		}
+		accountResponses, err := service.GetAccountsForCurrentUserOrRole(ctx)
+		if err != nil {
+			ds.logger.FromContext(ctx).Debug("Failed to list the accounts to discover, only discovering the data source account", "error", err)
+		}
+		for _, account := range accountResponses {
+			accounts = append(accounts, account.Value)
+		}
+	}
+
+	regionsService, err := ds.GetRegionsService(ctx, defaultRegion)
+	if err != nil {
+		if errors.Is(err, models.ErrMissingRegion) {
+			return nil, models.NewHttpError("error in DiscoveryHandler", http.StatusBadRequest, err)
+		}
+		return nil, models.NewHttpError("error in DiscoveryHandler", http.StatusInternalServerError, err)
+	}
+	regions, err := regionsService.GetRegions(ctx)
+	if err != nil {
+		return nil, models.NewHttpError("error in DiscoveryHandler", http.StatusInternalServerError, err)
+	}
+
+	includeLinkedAccounts := len(accounts) > 0
+	var mu sync.Mutex
+	var probeErrors []error
+	probes := map[string]discoveryProbe{}
+	eg, ectx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxConcurrentDiscoveryProbes)
+	for _, region := range regions {
+		// regions the account didn't opt in to can't be called
+		if region.Value.OptInStatus == services.OptInStatusNotOptedIn {
+			continue
+		}
+		eg.Go(func() error {
+			probe, err := ds.probeDiscoveryRegion(ectx, region.Value.Name, includeLinkedAccounts)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				ds.logger.FromContext(ctx).Debug("Failed to discover the namespaces of a region", "region", region.Value.Name, "error", err)
+				probeErrors = append(probeErrors, err)
+				return nil
+			}
+			probes[region.Value.Name] = probe
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	if len(probes) == 0 && len(probeErrors) > 0 {
+		return nil, newDiscoveryHttpError("error in DiscoveryHandler", probeErrors[0])
+	}
+
+	discoveryResponse, err := json.Marshal(discoveredAccounts(accounts, probes))
+	if err != nil {
+		return nil, models.NewHttpError("error in DiscoveryHandler", http.StatusInternalServerError, err)
+	}
+	return discoveryResponse, nil
+}
+
+// probeDiscoveryRegion returns the namespaces of the metrics recently published in region, by owning account. Probes
+// are cached by role, as the organizations mapped to different roles don't see the same metrics.
+func (ds *DataSource) probeDiscoveryRegion(ctx context.Context, region string, includeLinkedAccounts bool) (discoveryProbe, error) {
+	roleARN, err := ds.assumeRoleARN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := "discovery-probe|" + roleARN + "|" + region + "|" + strconv.FormatBool(includeLinkedAccounts)
+	if cached, ok := ds.discoveryCache.Get(key); ok {
+		return cached.(discoveryProbe), nil
+	}
+
+	awsConfig, err := ds.newAWSConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	input := &cloudwatch.ListMetricsInput{RecentlyActive: cloudwatchtypes.RecentlyActivePt3h}
+	if includeLinkedAccounts {
+		input.IncludeLinkedAccounts = aws.Bool(true)
+	}
+	metricsClient := clients.NewMetricsClient(NewCWClient(awsConfig), ds.Settings.GrafanaSettings.ListMetricsPageLimit)
+	metrics, err := metricsClient.ListMetricsWithPageLimit(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := map[string]map[string]bool{}
+	for _, metric := range metrics {
+		accountId := ""
+		if includeLinkedAccounts {
+			accountId = aws.ToString(metric.AccountId)
+		}
+		if namespaces[accountId] == nil {
+			namespaces[accountId] = map[string]bool{}
+		}
+		namespaces[accountId][aws.ToString(metric.Metric.Namespace)] = true
+	}
+	probe := discoveryProbe{}
+	for accountId, accountNamespaces := range namespaces {
+		probe[accountId] = slices.Sorted(maps.Keys(accountNamespaces))
+	}
+	ds.discoveryCache.Set(key, probe, discoveryProbeExpiration)
+	return probe, nil
+}
+
+// discoveredAccounts nests the probes of the regions under the accounts, in the order of the accounts with the
+// accounts the probes found metrics of but that aren't linked anymore last. Without accounts, the probes are of the
+// account of the data source.
+func discoveredAccounts(accounts []resources.Account, probes map[string]discoveryProbe) []resources.ResourceResponse[resources.DiscoveredAccount] {
+	if len(accounts) == 0 {
+		accounts = []resources.Account{{Label: discoveryOwnAccountLabel}}
+	}
+	var unknownIds []string
+	for _, probe := range probes {
+		for accountId := range probe {
+			if accountId != "" && !slices.Contains(unknownIds, accountId) &&
+				!slices.ContainsFunc(accounts, func(account resources.Account) bool { return account.Id == accountId }) {
+				unknownIds = append(unknownIds, accountId)
+			}
+		}
+	}
+	slices.Sort(unknownIds)
+	for _, accountId := range unknownIds {
+		accounts = append(accounts, resources.Account{Id: accountId, Label: accountId})
+	}
+
+	regionNames := slices.Sorted(maps.Keys(probes))
+	response := make([]resources.ResourceResponse[resources.DiscoveredAccount], 0, len(accounts))
+	for _, account := range accounts {
+		discovered := resources.DiscoveredAccount{
+			Id:                  account.Id,
+			Label:               account.Label,
+			IsMonitoringAccount: account.IsMonitoringAccount,
+			Regions:             []resources.DiscoveredRegion{},
+		}
+		for _, region := range regionNames {
+			if namespaces := probes[region][account.Id]; len(namespaces) > 0 {
+				discovered.Regions = append(discovered.Regions, resources.DiscoveredRegion{Name: region, Namespaces: namespaces})
+			}
+		}
+		response = append(response, resources.ResourceResponse[resources.DiscoveredAccount]{
+			Value: discovered,
+			Label: account.Label,
+		})
+	}
+	return response
+}
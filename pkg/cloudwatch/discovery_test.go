@@ -0,0 +1,145 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/features"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+)
+
+func TestDiscoveryHandler(t *testing.T) {
+	origNewRegionsService := services.NewRegionsService
+	origNewAccountsService := services.NewAccountsService
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		services.NewRegionsService = origNewRegionsService
+		services.NewAccountsService = origNewAccountsService
+		NewCWClient = origNewCWClient
+	})
+	regionsService := &mocks.RegionsService{}
+	regionsService.On("GetRegions").Return([]resources.ResourceResponse[resources.Region]{
+		{Value: resources.Region{Name: "eu-west-1"}},
+		{Value: resources.Region{Name: "me-south-1", OptInStatus: "not-opted-in"}},
+		{Value: resources.Region{Name: "us-east-1"}},
+	}, nil)
+	services.NewRegionsService = func(models.EC2APIProvider, log.Logger, string, *cache.Cache, string) models.RegionsAPIProvider {
+		return regionsService
+	}
+	metric := func(namespace string) cloudwatchtypes.Metric {
+		return cloudwatchtypes.Metric{Namespace: aws.String(namespace), MetricName: aws.String("Metric")}
+	}
+	var mu sync.Mutex
+	var probedRegions []string
+	newCWClient := func(metricsByRegion map[string][]cloudwatchtypes.Metric, accountsByRegion map[string][]string) func(aws.Config) models.CWClient {
+		return func(cfg aws.Config) models.CWClient {
+			mu.Lock()
+			probedRegions = append(probedRegions, cfg.Region)
+			mu.Unlock()
+			if metrics, ok := metricsByRegion[cfg.Region]; ok {
+				return &mocks.FakeMetricsAPI{Metrics: metrics, OwningAccounts: accountsByRegion[cfg.Region]}
+			}
+			api := &mocks.MetricsAPI{}
+			api.On("ListMetrics").Return(errors.New("access denied"))
+			return api
+		}
+	}
+	discover := func(t *testing.T, ctx context.Context, ds *DataSource) []resources.ResourceResponse[resources.DiscoveredAccount] {
+		t.Helper()
+		body, httpError := ds.DiscoveryHandler(ctx, nil)
+		require.Nil(t, httpError)
+		var response []resources.ResourceResponse[resources.DiscoveredAccount]
+		require.NoError(t, json.Unmarshal(body, &response))
+		return response
+	}
+
+	t.Run("nests the namespaces of the regions probed under the data source account", func(t *testing.T) {
+		probedRegions = nil
+		NewCWClient = newCWClient(map[string][]cloudwatchtypes.Metric{
+			"us-east-1": {metric("AWS/EC2"), metric("AWS/Lambda"), metric("AWS/EC2")},
+		}, nil)
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = regionConfigProvider{}
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.GrafanaSettings.ListMetricsPageLimit = 10
+		})
+
+		response := discover(t, context.Background(), ds)
+
+		assert.Equal(t, []resources.ResourceResponse[resources.DiscoveredAccount]{{
+			Label: "Data source account",
+			Value: resources.DiscoveredAccount{
+				Label:   "Data source account",
+				Regions: []resources.DiscoveredRegion{{Name: "us-east-1", Namespaces: []string{"AWS/EC2", "AWS/Lambda"}}},
+			},
+		}}, response)
+		assert.ElementsMatch(t, []string{"eu-west-1", "us-east-1"}, probedRegions)
+
+		probedRegions = nil
+		discover(t, context.Background(), ds)
+		assert.Equal(t, []string{"eu-west-1"}, probedRegions, "successful probes are cached")
+	})
+
+	t.Run("nests the namespaces under the accounts of the monitoring account", func(t *testing.T) {
+		accountsService := &mocks.AccountsServiceMock{}
+		accountsService.On("GetAccountsForCurrentUserOrRole").Return([]resources.ResourceResponse[resources.Account]{
+			{Value: resources.Account{Id: "111", Label: "monitoring", IsMonitoringAccount: true}},
+			{Value: resources.Account{Id: "222", Label: "source"}},
+		}, nil)
+		services.NewAccountsService = func(models.OAMAPIProvider) models.AccountsProvider {
+			return accountsService
+		}
+		NewCWClient = newCWClient(map[string][]cloudwatchtypes.Metric{
+			"eu-west-1": {metric("AWS/S3"), metric("AWS/SQS")},
+			"us-east-1": {metric("AWS/EC2"), metric("AWS/EC2")},
+		}, map[string][]string{
+			"eu-west-1": {"222", "333"},
+			"us-east-1": {"111", "222"},
+		})
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = regionConfigProvider{}
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.GrafanaSettings.ListMetricsPageLimit = 10
+		})
+
+		response := discover(t, contextWithFeaturesEnabled(features.FlagCloudWatchCrossAccountQuerying), ds)
+
+		require.Len(t, response, 3)
+		assert.Equal(t, resources.DiscoveredAccount{
+			Id: "111", Label: "monitoring", IsMonitoringAccount: true,
+			Regions: []resources.DiscoveredRegion{{Name: "us-east-1", Namespaces: []string{"AWS/EC2"}}},
+		}, response[0].Value)
+		assert.Equal(t, []resources.DiscoveredRegion{
+			{Name: "eu-west-1", Namespaces: []string{"AWS/S3"}},
+			{Name: "us-east-1", Namespaces: []string{"AWS/EC2"}},
+		}, response[1].Value.Regions)
+		assert.Equal(t, "333", response[2].Value.Label)
+	})
+
+	t.Run("fails when every probe failed", func(t *testing.T) {
+		NewCWClient = newCWClient(nil, nil)
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = regionConfigProvider{}
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.GrafanaSettings.ListMetricsPageLimit = 10
+		})
+
+		_, httpError := ds.DiscoveryHandler(context.Background(), nil)
+
+		require.NotNil(t, httpError)
+		assert.Contains(t, httpError.Message, "access denied")
+	})
+}
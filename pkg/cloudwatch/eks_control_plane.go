@@ -0,0 +1,53 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// eksControlPlanePresets are queries of the control plane logs EKS sends to the log group of a cluster. The log
+// stream names tell the components apart. See https://docs.aws.amazon.com/eks/latest/userguide/control-plane-logs.html
+var eksControlPlanePresets = []logsQueryPreset{
+	{
+		id:          "apiServerErrors",
+		label:       "API server errors",
+		description: "Error lines logged by the Kubernetes API server.",
+		query:       "fields @timestamp, @logStream, @message | filter @logStream like /^kube-apiserver-/ and @logStream not like /^kube-apiserver-audit-/ and @message like /^E\\d{4}/ | sort @timestamp desc",
+	},
+	{
+		id:          "auditEventsByUser",
+		label:       "Audit events by user",
+		description: "Number of requests to the Kubernetes API recorded in the audit log for each user.",
+		query:       "filter @logStream like /^kube-apiserver-audit-/ | stats count(*) as events by bin(auto), user.username",
+		statsGroups: []string{"user.username"},
+	},
+	{
+		id:          "schedulerFailures",
+		label:       "Scheduler failures",
+		description: "Pods the Kubernetes scheduler failed to place on a node.",
+		query:       "fields @timestamp, @logStream, @message | filter @logStream like /^kube-scheduler-/ and @message like /(?i)(failed|unschedulable)/ | sort @timestamp desc",
+	},
+}
+
+// EKSControlPlanePresetsHandler returns the query presets of the control plane logs of the EKS cluster given by the
+// clusterName parameter.
+func (ds *DataSource) EKSControlPlanePresetsHandler(_ context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	clusterName := parameters.Get("clusterName")
+	if !validClusterName.MatchString(clusterName) {
+		return nil, models.NewHttpError("error in EKSControlPlanePresetsHandler", http.StatusBadRequest, errors.New("parameter 'clusterName' must be the name of a cluster"))
+	}
+
+	logGroupName := fmt.Sprintf("/aws/eks/%s/cluster", clusterName)
+	response := logsQueryPresetsResponse(eksControlPlanePresets, logGroupName, "")
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in EKSControlPlanePresetsHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
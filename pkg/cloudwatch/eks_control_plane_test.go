@@ -0,0 +1,55 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func Test_eks_control_plane_presets_route(t *testing.T) {
+	ds := newTestDatasource()
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.EKSControlPlanePresetsHandler))
+
+	t.Run("returns the presets of the log group of the cluster", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/eks-control-plane-presets?clusterName=prod-eu", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var presets []resources.ResourceResponse[resources.LogsQueryPreset]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &presets))
+		require.Len(t, presets, len(eksControlPlanePresets))
+		assert.Equal(t, resources.ResourceResponse[resources.LogsQueryPreset]{
+			Label: "Audit events by user",
+			Value: resources.LogsQueryPreset{
+				Id:            "auditEventsByUser",
+				Description:   "Number of requests to the Kubernetes API recorded in the audit log for each user.",
+				Expression:    "filter @logStream like /^kube-apiserver-audit-/ | stats count(*) as events by bin(auto), user.username",
+				LogGroupNames: []string{"/aws/eks/prod-eu/cluster"},
+				StatsGroups:   []string{"user.username"},
+			},
+		}, presets[1])
+		assert.Equal(t, "apiServerErrors", presets[0].Value.Id)
+		assert.Empty(t, presets[0].Value.StatsGroups)
+		assert.Equal(t, "schedulerFailures", presets[2].Value.Id)
+	})
+
+	t.Run("rejects a missing cluster name", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/eks-control-plane-presets", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("rejects a cluster name that isn't one", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/eks-control-plane-presets?clusterName=prod%2Fcluster", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,161 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsauth"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// MetricsEngine runs CloudWatch metric queries outside of the plugin protocol, so that other backend plugins and
+// services can reuse the query logic of the data source.
+type MetricsEngine interface {
+	// QueryMetrics runs queries with the credentials and default region of cfg and returns the frames of all of
+	// them. Queries run together, so that math expressions can reference the other queries.
+	QueryMetrics(ctx context.Context, cfg aws.Config, queries ...MetricsQuery) (data.Frames, error)
+}
+
+// LogsEngine runs CloudWatch Logs queries outside of the plugin protocol, so that other backend plugins and services
+// can reuse the query logic of the data source.
+type LogsEngine interface {
+	// QueryLogs runs queries to completion with the credentials and default region of cfg and returns the frames of
+	// all of them.
+	QueryLogs(ctx context.Context, cfg aws.Config, queries ...LogsQuery) (data.Frames, error)
+}
+
+// MetricsQuery is a metric query model as saved in panels, with the time range it is run for. Interval is the
+// interval of the panel, the floor of automatically picked periods, and may be left unset.
+type MetricsQuery struct {
+	Query     dataquery.CloudWatchMetricsQuery
+	TimeRange backend.TimeRange
+	Interval  time.Duration
+}
+
+// LogsQuery is a logs query model as saved in panels, with the time range it is run for.
+type LogsQuery struct {
+	Query     dataquery.CloudWatchLogsQuery
+	TimeRange backend.TimeRange
+}
+
+// Engine runs the queries of the data source for callers that hold an AWS config rather than data source settings.
+// It isn't a separate implementation: each call runs the queries with a data source of its own, created with default
+// settings, so that nothing cached for one config is reused for another.
+type Engine struct {
+	logger log.Logger
+}
+
+var (
+	_ MetricsEngine = (*Engine)(nil)
+	_ LogsEngine    = (*Engine)(nil)
+)
+
+// NewEngine returns an engine logging to logger, or to the default logger of the SDK if logger is nil.
+func NewEngine(logger log.Logger) *Engine {
+	if logger == nil {
+		logger = backend.NewLoggerWith("logger", "grafana-cloudwatch-datasource")
+	}
+	return &Engine{logger: logger}
+}
+
+func (e *Engine) QueryMetrics(ctx context.Context, cfg aws.Config, queries ...MetricsQuery) (data.Frames, error) {
+	if len(queries) == 0 {
+		return data.Frames{}, nil
+	}
+	req := &backend.QueryDataRequest{}
+	for i, query := range queries {
+		model := query.Query
+		if model.QueryMode == nil {
+			queryMode := dataquery.CloudWatchQueryModeMetrics
+			model.QueryMode = &queryMode
+		}
+		if *model.QueryMode != dataquery.CloudWatchQueryModeMetrics {
+			return nil, backend.DownstreamError(fmt.Errorf("query %d is a %s query, not a metrics query", i, *model.QueryMode))
+		}
+		dataQuery, err := engineDataQuery(i, model.RefId, model, query.TimeRange)
+		if err != nil {
+			return nil, err
+		}
+		dataQuery.Interval = query.Interval
+		req.Queries = append(req.Queries, dataQuery)
+	}
+
+	ds, err := e.dataSource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ds.executeQueriesToCompletion(ctx, req)
+}
+
+func (e *Engine) QueryLogs(ctx context.Context, cfg aws.Config, queries ...LogsQuery) (data.Frames, error) {
+	if len(queries) == 0 {
+		return data.Frames{}, nil
+	}
+	req := &backend.QueryDataRequest{}
+	for i, query := range queries {
+		model := query.Query
+		if model.QueryMode == "" {
+			model.QueryMode = dataquery.CloudWatchQueryModeLogs
+		}
+		if model.QueryMode != dataquery.CloudWatchQueryModeLogs {
+			return nil, backend.DownstreamError(fmt.Errorf("query %d is a %s query, not a logs query", i, model.QueryMode))
+		}
+		dataQuery, err := engineDataQuery(i, model.RefId, model, query.TimeRange)
+		if err != nil {
+			return nil, err
+		}
+		req.Queries = append(req.Queries, dataQuery)
+	}
+
+	ds, err := e.dataSource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ds.executeQueriesToCompletion(ctx, req)
+}
+
+// engineDataQuery returns the data query of the model of the index-th query of a call to the engine. Queries
+// without a ref ID are given one by their index, so that their frames can be told apart.
+func engineDataQuery(index int, refId string, model any, timeRange backend.TimeRange) (backend.DataQuery, error) {
+	queryJSON, err := json.Marshal(model)
+	if err != nil {
+		return backend.DataQuery{}, err
+	}
+	if refId == "" {
+		refId = fmt.Sprintf("query%d", index)
+	}
+	return backend.DataQuery{RefID: refId, TimeRange: timeRange, JSON: queryJSON}, nil
+}
+
+// dataSource returns a data source with default settings whose clients use cfg, in its region by default. Its running
+// Logs Insights queries aren't persisted, as they're run to completion by the call.
+func (e *Engine) dataSource(ctx context.Context, cfg aws.Config) (*DataSource, error) {
+	settings, err := models.LoadCloudWatchSettings(ctx, backend.DataSourceInstanceSettings{})
+	if err != nil {
+		return nil, err
+	}
+	settings.Region = cfg.Region
+	return newDataSource(settings, staticConfigProvider{cfg: cfg}, e.logger, newRunningLogsQueries()), nil
+}
+
+// staticConfigProvider provides the AWS config the engine was called with, in the region asked for. The auth
+// settings of the data source are ignored, as the caller already resolved the credentials of the config.
+type staticConfigProvider struct {
+	cfg aws.Config
+}
+
+func (p staticConfigProvider) GetConfig(_ context.Context, settings awsauth.Settings) (aws.Config, error) {
+	cfg := p.cfg.Copy()
+	if settings.Region != "" {
+		cfg.Region = settings.Region
+	}
+	return cfg, nil
+}
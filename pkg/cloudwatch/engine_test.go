@@ -0,0 +1,119 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestEngine_QueryMetrics(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	var regions []string
+	NewCWClient = func(cfg aws.Config) models.CWClient {
+		regions = append(regions, cfg.Region)
+		return &api
+	}
+	now := time.Now().Truncate(time.Minute)
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{
+			{StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: []float64{42}, Timestamps: []time.Time{now}},
+		}}, nil)
+
+	statistic := "Average"
+	frames, err := NewEngine(log.NewNullLogger()).QueryMetrics(context.Background(), aws.Config{Region: "eu-west-1"}, MetricsQuery{
+		Query: dataquery.CloudWatchMetricsQuery{
+			RefId:      "A",
+			Id:         "a",
+			Region:     "default",
+			Namespace:  "AWS/EC2",
+			MetricName: aws.String("CPUUtilization"),
+			Dimensions: &dataquery.Dimensions{"InstanceId": {String: aws.String("i-123")}},
+			Statistic:  &statistic,
+			Period:     aws.String("60"),
+			MatchExact: aws.Bool(true),
+		},
+		TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"eu-west-1"}, regions, "the default region is the region of the config")
+	require.Len(t, frames, 1)
+	assert.Equal(t, "A", frames[0].RefID)
+	assert.Equal(t, 42.0, frames[0].Fields[1].At(0))
+
+	_, err = NewEngine(nil).QueryMetrics(context.Background(), aws.Config{Region: "eu-west-1"}, MetricsQuery{
+		Query: dataquery.CloudWatchMetricsQuery{QueryMode: func() *dataquery.CloudWatchQueryMode {
+			queryMode := dataquery.CloudWatchQueryModeLogs
+			return &queryMode
+		}()},
+	})
+	assert.EqualError(t, err, "query 0 is a Logs query, not a metrics query")
+}
+
+func TestEngine_QueryLogs(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	cli := fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{
+		Status: "Complete",
+		Results: [][]cloudwatchlogstypes.ResultField{{
+			{Field: aws.String("@message"), Value: aws.String("hello")},
+		}},
+	}}
+	var regions []string
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		regions = append(regions, cfg.Region)
+		return &cli
+	}
+
+	expression := "fields @message"
+	frames, err := NewEngine(log.NewNullLogger()).QueryLogs(context.Background(), aws.Config{Region: "eu-west-1"}, LogsQuery{
+		Query: dataquery.CloudWatchLogsQuery{
+			Region:        "us-east-2",
+			Expression:    &expression,
+			LogGroupNames: []string{"group"},
+		},
+		TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(60, 0)},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, regions, "us-east-2")
+	require.Len(t, cli.calls.startQuery, 1)
+	assert.Contains(t, *cli.calls.startQuery[0].QueryString, "fields @message")
+	require.Len(t, frames, 1)
+	assert.Equal(t, "query0", frames[0].RefID)
+	message, _ := frames[0].FieldByName("@message")
+	require.NotNil(t, message)
+	assert.Equal(t, "hello", *message.At(0).(*string))
+}
+
+func TestEngine_dataSource(t *testing.T) {
+	ds, err := NewEngine(log.NewNullLogger()).dataSource(context.Background(), aws.Config{Region: "eu-west-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "eu-west-1", ds.Settings.Region)
+	assert.NotNil(t, ds.queryCache)
+	assert.NotNil(t, ds.runningLogs)
+	assert.NotNil(t, ds.operations)
+	assert.NotNil(t, ds.resourceHandler)
+}
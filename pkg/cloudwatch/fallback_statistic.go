@@ -0,0 +1,53 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// applyFallbackStatistic runs a query again with its fallback statistic when its series have no datapoints of its
+// statistic, e.g. because a metric temporarily stopped publishing it, and replaces the frames of the result with
+// those of the fallback statistic if they have datapoints. The original result is kept if the fallback fails, as it
+// is still a valid, if empty, result.
+func (ds *DataSource) applyFallbackStatistic(ctx context.Context, result *responseWrapper, query *models.CloudWatchQuery) {
+	if query.FallbackStatistic == "" || query.FallbackStatistic == query.Statistic || hasDatapoints(result.DataResponse.Frames) {
+		return
+	}
+	if mode := query.GetGetMetricDataAPIMode(); mode != models.GMDApiModeMetricStat && mode != models.GMDApiModeInferredSearchExpression {
+		return
+	}
+
+	fallback := *query
+	fallback.Statistic = query.FallbackStatistic
+	fallback.FallbackStatistic = ""
+	responses, err := ds.runMetricDataQueries(ctx, ctx, query.Region, query.StartTime, query.EndTime, []*models.CloudWatchQuery{&fallback})
+	if err != nil {
+		ds.logger.FromContext(ctx).Warn("Failed to query the fallback statistic", "refId", query.RefId, "statistic", fallback.Statistic, "error", err)
+		return
+	}
+
+	for _, response := range responses {
+		if response.RefId != query.RefId || response.DataResponse.Error != nil || !hasDatapoints(response.DataResponse.Frames) {
+			continue
+		}
+		response.DataResponse.Frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     fmt.Sprintf("The series have no datapoints of the %s statistic, showing the %s statistic instead", query.Statistic, fallback.Statistic),
+		})
+		result.DataResponse.Frames = response.DataResponse.Frames
+		return
+	}
+}
+
+func hasDatapoints(frames data.Frames) bool {
+	for _, frame := range frames {
+		if frame.Rows() > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_executeTimeSeriesQuery_fallback_statistic(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	now := time.Now()
+	empty := &cloudwatch.GetMetricDataOutput{MetricDataResults: []cloudwatchtypes.MetricDataResult{
+		{StatusCode: "Complete", Id: aws.String("m1"), Label: aws.String("Invocations")},
+	}}
+	sampleCounts := &cloudwatch.GetMetricDataOutput{MetricDataResults: []cloudwatchtypes.MetricDataResult{
+		{StatusCode: "Complete", Id: aws.String("m1"), Label: aws.String("Invocations"), Values: []float64{12}, Timestamps: []time.Time{now}},
+	}}
+	queryData := func(t *testing.T, api *mocks.MetricsAPI, fallbackStatistic string) backend.DataResponse {
+		t.Helper()
+		NewCWClient = func(aws.Config) models.CWClient {
+			return api
+		}
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON: json.RawMessage(`{"type":"timeSeriesQuery","metricQueryType":0,"metricEditorMode":0,"namespace":"AWS/Lambda",
+					"metricName":"Invocations","dimensions":{"FunctionName":["checkout"]},"statistic":"Average",
+					"fallbackStatistic":"` + fallbackStatistic + `","period":"300","region":"us-east-1","id":"m1"}`),
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+	statisticOfCall := func(api *mocks.MetricsAPI, i int) string {
+		return *api.Calls[i].Arguments.Get(1).(*cloudwatch.GetMetricDataInput).MetricDataQueries[0].MetricStat.Stat
+	}
+
+	t.Run("queries the fallback statistic when the series have no datapoints", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(empty, nil).Once()
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(sampleCounts, nil).Once()
+
+		res := queryData(t, api, "SampleCount")
+
+		require.Len(t, api.Calls, 2)
+		assert.Equal(t, "Average", statisticOfCall(api, 0))
+		assert.Equal(t, "SampleCount", statisticOfCall(api, 1))
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		assert.Equal(t, 1, res.Frames[0].Rows())
+		assert.Equal(t, []data.Notice{{
+			Severity: data.NoticeSeverityInfo,
+			Text:     "The series have no datapoints of the Average statistic, showing the SampleCount statistic instead",
+		}}, res.Frames[0].Meta.Notices)
+	})
+
+	t.Run("keeps the empty series when the fallback statistic has no datapoints either", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(empty, nil)
+
+		res := queryData(t, api, "SampleCount")
+
+		require.Len(t, api.Calls, 2)
+		require.Len(t, res.Frames, 1)
+		assert.Equal(t, 0, res.Frames[0].Rows())
+		assert.Empty(t, res.Frames[0].Meta.Notices)
+	})
+
+	t.Run("doesn't query the fallback statistic when the series have datapoints", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(sampleCounts, nil)
+
+		queryData(t, api, "SampleCount")
+
+		require.Len(t, api.Calls, 1)
+	})
+}
@@ -2,7 +2,6 @@ package cloudwatch
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/clients"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/features"
@@ -23,6 +22,11 @@ func shouldSkipFetchingWildcards(ctx context.Context, q *models.CloudWatchQuery)
 		return true
 	}
 
+	// the values of the first namespace wouldn't label the series of the others
+	if len(q.ExtraNamespaces) > 0 {
+		return true
+	}
+
 	return false
 }
 
@@ -55,7 +59,16 @@ func (ds *DataSource) getDimensionValuesForWildcards(
 			if query.AccountId != nil {
 				accountID = *query.AccountId
 			}
-			cacheKey := fmt.Sprintf("%s-%s-%s-%s-%s", region, accountID, query.Namespace, query.MetricName, dimensionKey)
+			cacheKey, err := ds.roleScopedCacheKey(ctx, map[string]any{
+				"region":       region,
+				"accountId":    accountID,
+				"namespace":    query.Namespace,
+				"metricName":   query.MetricName,
+				"dimensionKey": dimensionKey,
+			})
+			if err != nil {
+				return nil, err
+			}
 			cachedDimensions, found := tagValueCache.Get(cacheKey)
 			if found {
 				ds.logger.FromContext(ctx).Debug("Fetching dimension values from cache")
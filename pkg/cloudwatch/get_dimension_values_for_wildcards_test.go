@@ -10,8 +10,10 @@ import (
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func noSkip(context.Context, *models.CloudWatchQuery) bool { return false }
@@ -71,6 +73,32 @@ func TestGetDimensionValuesForWildcards(t *testing.T) {
 			assert.Equal(t, map[string][]string{"Test_DimensionName2": {"Value"}}, queries[0].Dimensions)
 			api.AssertExpectations(t)
 		})
+
+		t.Run("Should not share cached values between orgs mapped to different roles", func(t *testing.T) {
+			ds := newTestDatasource(func(ds *DataSource) {
+				ds.Settings.OrgRoleMap = map[string]string{
+					"1": "arn:aws:iam::111111111111:role/org-1",
+					"2": "arn:aws:iam::222222222222:role/org-2",
+				}
+			})
+			query := getBaseQuery()
+			query.MetricName = "Test_MetricName"
+			query.Dimensions = map[string][]string{"Test_DimensionName3": {"*"}}
+			query.MetricQueryType = models.MetricQueryTypeSearch
+			orgValues := func(orgID int64, value string) map[string][]string {
+				api := &mocks.MetricsAPI{Metrics: []cloudwatchtypes.Metric{
+					{MetricName: utils.Pointer("Test_MetricName"), Dimensions: []cloudwatchtypes.Dimension{{Name: utils.Pointer("Test_DimensionName3"), Value: utils.Pointer(value)}}},
+				}}
+				api.On("ListMetrics").Return(nil)
+				orgCtx := backend.WithPluginContext(ctx, backend.PluginContext{OrgID: orgID})
+				queries, err := ds.getDimensionValuesForWildcards(orgCtx, "us-east-1", api, []*models.CloudWatchQuery{query}, tagValueCache, 50, noSkip)
+				require.NoError(t, err)
+				return queries[0].Dimensions
+			}
+
+			assert.Equal(t, map[string][]string{"Test_DimensionName3": {"org-1-value"}}, orgValues(1, "org-1-value"))
+			assert.Equal(t, map[string][]string{"Test_DimensionName3": {"org-2-value"}}, orgValues(2, "org-2-value"))
+		})
 	})
 
 	t.Run("Should skip queries", func(t *testing.T) {
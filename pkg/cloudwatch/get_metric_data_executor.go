@@ -2,6 +2,7 @@ package cloudwatch
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -11,6 +12,7 @@ import (
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 func (ds *DataSource) executeRequest(ctx context.Context, client models.CWClient,
@@ -37,8 +39,29 @@ func (ds *DataSource) executeRequest(ctx context.Context, client models.CWClient
 		if resp.NextToken == nil || *resp.NextToken == "" {
 			break
 		}
+		// the NextToken of the last page is left set, for the results to be flagged as truncated
+		if ds.Settings.MetricDataMaxPages > 0 && len(mdo) >= ds.Settings.MetricDataMaxPages {
+			break
+		}
 		nextToken = *resp.NextToken
 	}
 
 	return mdo, nil
 }
+
+// truncatedPages returns the number of pages outputs were cut short after by the page cap of the data source, or 0 if
+// every page of the request was followed.
+func truncatedPages(outputs []*cloudwatch.GetMetricDataOutput) int {
+	if len(outputs) == 0 || aws.ToString(outputs[len(outputs)-1].NextToken) == "" {
+		return 0
+	}
+	return len(outputs)
+}
+
+// pagesTruncatedNotice returns the notice of results cut short after pages pages.
+func pagesTruncatedNotice(pages int) data.Notice {
+	return data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text:     fmt.Sprintf("Results truncated after %d pages of GetMetricData. Narrow down the query or reduce the time range to get complete series.", pages),
+	}
+}
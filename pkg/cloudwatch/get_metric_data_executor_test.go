@@ -54,3 +54,25 @@ func TestGetMetricDataExecutorTestResponse(t *testing.T) {
 	assert.Equal(t, 23.5, res[0].MetricDataResults[0].Values[1])
 	assert.Equal(t, 100.0, res[1].MetricDataResults[0].Values[0])
 }
+
+func TestGetMetricDataExecutorTestPageCap(t *testing.T) {
+	page := &cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{{Values: []float64{1}}},
+		NextToken:         aws.String("next"),
+	}
+	mockMetricClient := &mocks.MetricsAPI{}
+	mockMetricClient.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(page, nil)
+
+	executor := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.MetricDataMaxPages = 3
+	})
+	inputs := &cloudwatch.GetMetricDataInput{EndTime: aws.Time(time.Now()), MetricDataQueries: []cloudwatchtypes.MetricDataQuery{}}
+	res, err := executor.executeRequest(context.Background(), mockMetricClient, inputs)
+	require.NoError(t, err)
+	assert.Len(t, res, 3)
+	mockMetricClient.AssertNumberOfCalls(t, "GetMetricData", 3)
+	assert.Equal(t, 3, truncatedPages(res))
+
+	res[2] = &cloudwatch.GetMetricDataOutput{}
+	assert.Equal(t, 0, truncatedPages(res), "results of requests whose pages were all followed aren't truncated")
+}
@@ -10,6 +10,9 @@ import (
 // nonWordRegex is for spliting the expressions to just functions and ids
 var nonWordRegex = regexp.MustCompile(`\W+`)
 
+// maxMetricDataQueriesPerRequest is the number of MetricDataQueries a GetMetricData request can hold
+const maxMetricDataQueriesPerRequest = 500
+
 // getMetricQueryBatches separates queries into batches if necessary. Metric Insight queries cannot run together, and math expressions must be run
 // with all the queries they reference.
 func getMetricQueryBatches(queries []*models.CloudWatchQuery, logger log.Logger) [][]*models.CloudWatchQuery {
@@ -89,3 +92,90 @@ func hasMultipleMetricInsights(queries []*models.CloudWatchQuery) bool {
 	}
 	return false
 }
+
+// splitBatchByRequestLimit splits a batch of queries into batches of at most maxQueries MetricDataQueries each, so
+// that each fits in a GetMetricData request. Math expressions stay in the batch of the queries they reference; a set
+// of connected queries that doesn't fit on its own is left whole, for CloudWatch to reject it with its own error.
+func splitBatchByRequestLimit(queries []*models.CloudWatchQuery, maxQueries int) [][]*models.CloudWatchQuery {
+	if metricDataQueryCount(queries) <= maxQueries {
+		return [][]*models.CloudWatchQuery{queries}
+	}
+
+	batches := [][]*models.CloudWatchQuery{}
+	var batch []*models.CloudWatchQuery
+	batchSize := 0
+	for _, group := range groupConnectedQueries(queries) {
+		groupSize := metricDataQueryCount(group)
+		if len(batch) > 0 && batchSize+groupSize > maxQueries {
+			batches = append(batches, batch)
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, group...)
+		batchSize += groupSize
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// groupConnectedQueries groups queries with the math expressions referencing them, keeping the order queries come in.
+func groupConnectedQueries(queries []*models.CloudWatchQuery) [][]*models.CloudWatchQuery {
+	groupOf := make(map[string]int, len(queries))
+	groups := [][]*models.CloudWatchQuery{}
+	for _, query := range queries {
+		groupOf[query.Id] = len(groups)
+		groups = append(groups, []*models.CloudWatchQuery{query})
+	}
+
+	merge := func(a, b int) int {
+		if a == b {
+			return a
+		}
+		if b < a {
+			a, b = b, a
+		}
+		for _, query := range groups[b] {
+			groupOf[query.Id] = a
+		}
+		groups[a] = append(groups[a], groups[b]...)
+		groups[b] = nil
+		return a
+	}
+	for _, query := range queries {
+		if query.GetGetMetricDataAPIMode() != models.GMDApiModeMathExpression {
+			continue
+		}
+		for _, id := range nonWordRegex.Split(query.Expression, -1) {
+			if group, ok := groupOf[id]; ok {
+				merge(groupOf[query.Id], group)
+			}
+		}
+	}
+
+	connected := make([][]*models.CloudWatchQuery, 0, len(groups))
+	connectedIndex := map[int]int{}
+	for _, query := range queries {
+		group := groupOf[query.Id]
+		index, ok := connectedIndex[group]
+		if !ok {
+			index = len(connected)
+			connectedIndex[group] = index
+			connected = append(connected, nil)
+		}
+		connected[index] = append(connected[index], query)
+	}
+	return connected
+}
+
+// metricDataQueryCount returns the number of MetricDataQueries queries make up, counting the SampleCount query of
+// those including it.
+func metricDataQueryCount(queries []*models.CloudWatchQuery) int {
+	count := len(queries)
+	for _, query := range queries {
+		if query.IncludeSampleCount {
+			count++
+		}
+	}
+	return count
+}
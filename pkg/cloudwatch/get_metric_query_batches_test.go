@@ -1,11 +1,13 @@
 package cloudwatch
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetMetricQueryBatches(t *testing.T) {
@@ -180,3 +182,62 @@ func TestGetMetricQueryBatches(t *testing.T) {
 		assert.ElementsMatch(t, []*models.CloudWatchQuery{&insight1, &insight3, &m4_ref_i1_i3}, result[2])
 	})
 }
+
+func TestSplitBatchByRequestLimit(t *testing.T) {
+	newQuery := func(id, expression string) *models.CloudWatchQuery {
+		query := &models.CloudWatchQuery{Id: id, MetricQueryType: models.MetricQueryTypeSearch, MetricEditorMode: models.MetricEditorModeBuilder}
+		if expression != "" {
+			query.MetricEditorMode = models.MetricEditorModeRaw
+			query.Expression = expression
+		}
+		return query
+	}
+	ids := func(batches [][]*models.CloudWatchQuery) [][]string {
+		result := make([][]string, len(batches))
+		for i, batch := range batches {
+			for _, query := range batch {
+				result[i] = append(result[i], query.Id)
+			}
+		}
+		return result
+	}
+
+	t.Run("leaves a batch within the limit whole", func(t *testing.T) {
+		batch := []*models.CloudWatchQuery{newQuery("a", ""), newQuery("b", "")}
+		assert.Equal(t, [][]string{{"a", "b"}}, ids(splitBatchByRequestLimit(batch, 2)))
+	})
+
+	t.Run("splits a batch over the limit", func(t *testing.T) {
+		var batch []*models.CloudWatchQuery
+		for i := 0; i < 1100; i++ {
+			batch = append(batch, newQuery(fmt.Sprintf("q%d", i), ""))
+		}
+		batches := splitBatchByRequestLimit(batch, maxMetricDataQueriesPerRequest)
+		require.Len(t, batches, 3)
+		assert.Len(t, batches[0], 500)
+		assert.Len(t, batches[1], 500)
+		assert.Len(t, batches[2], 100)
+	})
+
+	t.Run("keeps math expressions with the queries they reference", func(t *testing.T) {
+		batch := []*models.CloudWatchQuery{
+			newQuery("a", ""),
+			newQuery("b", ""),
+			newQuery("c", ""),
+			newQuery("d", ""),
+			newQuery("e", "SUM([a, d])"),
+		}
+		assert.Equal(t, [][]string{{"a", "d", "e"}, {"b", "c"}}, ids(splitBatchByRequestLimit(batch, 3)))
+	})
+
+	t.Run("counts the SampleCount queries", func(t *testing.T) {
+		a, b := newQuery("a", ""), newQuery("b", "")
+		a.IncludeSampleCount = true
+		assert.Equal(t, [][]string{{"a"}, {"b"}}, ids(splitBatchByRequestLimit([]*models.CloudWatchQuery{a, b}, 2)))
+	})
+
+	t.Run("leaves connected queries over the limit together", func(t *testing.T) {
+		batch := []*models.CloudWatchQuery{newQuery("a", ""), newQuery("b", ""), newQuery("c", "a + b"), newQuery("d", "")}
+		assert.Equal(t, [][]string{{"a", "b", "c"}, {"d"}}, ids(splitBatchByRequestLimit(batch, 2)))
+	})
+}
@@ -0,0 +1,125 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const (
+	// instanceNameCacheExpiration is how long the Name tags of instances are kept. Renaming an instance is rare, and
+	// a dashboard refreshing every few seconds would otherwise call DescribeInstances on every refresh.
+	instanceNameCacheExpiration = time.Hour
+	// instanceNameLabel is the label the Name tag of the instance of a series is set as.
+	instanceNameLabel = "InstanceName"
+	// maxInstanceIdsPerFilter is the number of values DescribeInstances accepts in a filter.
+	maxInstanceIdsPerFilter = 200
+)
+
+// setInstanceNames labels the EC2 series of a query with the Name tag of their instance, and shows the name in place
+// of the instance ID in their display name, so that legends read web-1 rather than i-0123456789abcdef. The names are
+// looked up with the role of the query, so the instances of other accounts are only named if the role can describe
+// them, and the series of instances without a name are left as they are. Failing to look the names up only adds a
+// notice, as the series are still valid.
+func (ds *DataSource) setInstanceNames(ctx context.Context, frames data.Frames, query *models.CloudWatchQuery) {
+	if !query.InstanceNames || query.Namespace != "AWS/EC2" || len(frames) == 0 {
+		return
+	}
+
+	var instanceIds []string
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if id := field.Labels["InstanceId"]; id != "" && !slices.Contains(instanceIds, id) {
+				instanceIds = append(instanceIds, id)
+			}
+		}
+	}
+	if len(instanceIds) == 0 {
+		return
+	}
+
+	names, err := ds.getInstanceNames(ctx, query.Region, instanceIds)
+	if err != nil {
+		frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("The names of the instances couldn't be fetched: %s", err),
+		})
+		return
+	}
+
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			id := field.Labels["InstanceId"]
+			name := names[id]
+			if name == "" {
+				continue
+			}
+			field.Labels[instanceNameLabel] = name
+			if field.Config != nil && field.Config.DisplayNameFromDS != "" {
+				field.Config.DisplayNameFromDS = strings.ReplaceAll(field.Config.DisplayNameFromDS, id, name)
+			}
+		}
+	}
+}
+
+// getInstanceNames returns the Name tags of instances by their ID, fetching those that aren't cached yet. Instances
+// that don't exist, can't be described or have no Name tag are cached with an empty name, so that they aren't
+// looked up again on every refresh.
+func (ds *DataSource) getInstanceNames(ctx context.Context, region string, instanceIds []string) (map[string]string, error) {
+	// names are cached per role and user, as instances are only visible to the accounts they're described with
+	scope, err := ds.roleScopedCacheKey(ctx, map[string]any{"region": region})
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := func(instanceId string) string {
+		return scope + "|" + instanceId
+	}
+
+	names := make(map[string]string, len(instanceIds))
+	var uncached []string
+	for _, id := range instanceIds {
+		if name, ok := ds.instanceNameCache.Get(cacheKey(id)); ok {
+			names[id] = name.(string)
+			continue
+		}
+		uncached = append(uncached, id)
+	}
+
+	for chunk := range slices.Chunk(uncached, maxInstanceIdsPerFilter) {
+		// filtering by ID, rather than passing the IDs, doesn't fail the whole call on an instance that doesn't exist
+		filters := []ec2types.Filter{{Name: aws.String("instance-id"), Values: chunk}}
+		resp, err := ds.ec2DescribeInstances(ctx, region, filters, nil)
+		if err != nil {
+			return nil, err
+		}
+		fetched := make(map[string]string, len(chunk))
+		for _, reservation := range resp.Reservations {
+			for _, instance := range reservation.Instances {
+				fetched[aws.ToString(instance.InstanceId)] = instanceName(instance)
+			}
+		}
+		for _, id := range chunk {
+			names[id] = fetched[id]
+			ds.instanceNameCache.Set(cacheKey(id), fetched[id], cache.DefaultExpiration)
+		}
+	}
+	return names, nil
+}
+
+func instanceName(instance ec2types.Instance) string {
+	for _, tag := range instance.Tags {
+		if aws.ToString(tag.Key) == "Name" {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type fakeInstanceNamesClient struct {
+	oldEC2Client
+	names map[string]string
+	err   error
+	calls []*ec2.DescribeInstancesInput
+}
+
+func (c *fakeInstanceNamesClient) DescribeInstances(_ context.Context, in *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	c.calls = append(c.calls, in)
+	if c.err != nil {
+		return nil, c.err
+	}
+	var instances []ec2types.Instance
+	for _, id := range in.Filters[0].Values {
+		name, ok := c.names[id]
+		if !ok {
+			continue
+		}
+		instance := ec2types.Instance{InstanceId: aws.String(id)}
+		if name != "" {
+			instance.Tags = []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String(name)}}
+		}
+		instances = append(instances, instance)
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: instances}}}, nil
+}
+
+func TestSetInstanceNames(t *testing.T) {
+	origNewEC2API := NewEC2API
+	t.Cleanup(func() {
+		NewEC2API = origNewEC2API
+	})
+
+	series := func(instanceId string) *data.Frame {
+		return data.NewFrame(instanceId,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{"InstanceId": instanceId}, []float64{}).
+				SetConfig(&data.FieldConfig{DisplayNameFromDS: instanceId + " CPUUtilization"}),
+		)
+	}
+	query := func() *models.CloudWatchQuery {
+		return &models.CloudWatchQuery{
+			Region:        "us-east-1",
+			Namespace:     "AWS/EC2",
+			MetricName:    "CPUUtilization",
+			InstanceNames: true,
+		}
+	}
+
+	t.Run("labels the series with the names of their instances", func(t *testing.T) {
+		client := &fakeInstanceNamesClient{names: map[string]string{"i-1": "web-1", "i-2": ""}}
+		NewEC2API = func(aws.Config) models.EC2APIProvider {
+			return client
+		}
+		frames := data.Frames{series("i-1"), series("i-2"), series("i-3")}
+
+		newTestDatasource().setInstanceNames(context.Background(), frames, query())
+
+		require.Len(t, client.calls, 1)
+		assert.Equal(t, []string{"i-1", "i-2", "i-3"}, client.calls[0].Filters[0].Values)
+		assert.Equal(t, data.Labels{"InstanceId": "i-1", "InstanceName": "web-1"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, "web-1 CPUUtilization", frames[0].Fields[1].Config.DisplayNameFromDS)
+		assert.Equal(t, data.Labels{"InstanceId": "i-2"}, frames[1].Fields[1].Labels)
+		assert.Equal(t, "i-2 CPUUtilization", frames[1].Fields[1].Config.DisplayNameFromDS)
+		assert.Equal(t, data.Labels{"InstanceId": "i-3"}, frames[2].Fields[1].Labels)
+	})
+
+	t.Run("looks up only the instances that aren't cached", func(t *testing.T) {
+		client := &fakeInstanceNamesClient{names: map[string]string{"i-1": "web-1", "i-2": "web-2"}}
+		NewEC2API = func(aws.Config) models.EC2APIProvider {
+			return client
+		}
+		ds := newTestDatasource()
+
+		ds.setInstanceNames(context.Background(), data.Frames{series("i-1"), series("i-3")}, query())
+		frames := data.Frames{series("i-1"), series("i-2"), series("i-3")}
+		ds.setInstanceNames(context.Background(), frames, query())
+
+		require.Len(t, client.calls, 2)
+		assert.Equal(t, []string{"i-2"}, client.calls[1].Filters[0].Values)
+		assert.Equal(t, "web-1", frames[0].Fields[1].Labels["InstanceName"])
+		assert.Equal(t, "web-2", frames[1].Fields[1].Labels["InstanceName"])
+	})
+
+	t.Run("doesn't share the cached names of a user with another when their own identity is used", func(t *testing.T) {
+		client := &fakeInstanceNamesClient{names: map[string]string{"i-1": "web-1"}}
+		NewEC2API = func(aws.Config) models.EC2APIProvider {
+			return client
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.UserIdentityPassThrough = true
+			ds.Settings.WebIdentityRoleMap = map[string]string{webIdentityRoleMapWildcard: "arn:aws:iam::123456789012:role/users"}
+		})
+		userCtx := func(login string) context.Context {
+			ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{User: &backend.User{Login: login}})
+			return context.WithValue(ctx, webIdentityTokenKey{}, login+"-token")
+		}
+
+		ds.setInstanceNames(userCtx("alice"), data.Frames{series("i-1")}, query())
+		ds.setInstanceNames(userCtx("bob"), data.Frames{series("i-1")}, query())
+		ds.setInstanceNames(userCtx("alice"), data.Frames{series("i-1")}, query())
+
+		assert.Len(t, client.calls, 2)
+	})
+
+	t.Run("adds a notice when the names can't be fetched", func(t *testing.T) {
+		NewEC2API = func(aws.Config) models.EC2APIProvider {
+			return &fakeInstanceNamesClient{err: errors.New("access denied")}
+		}
+		frames := data.Frames{series("i-1")}
+
+		newTestDatasource().setInstanceNames(context.Background(), frames, query())
+
+		require.Len(t, frames[0].Meta.Notices, 1)
+		assert.Equal(t, "The names of the instances couldn't be fetched: describe instances pager failed: access denied", frames[0].Meta.Notices[0].Text)
+		assert.Equal(t, data.Labels{"InstanceId": "i-1"}, frames[0].Fields[1].Labels)
+	})
+
+	t.Run("doesn't look up names for other namespaces or queries not asking for them", func(t *testing.T) {
+		client := &fakeInstanceNamesClient{}
+		NewEC2API = func(aws.Config) models.EC2APIProvider {
+			return client
+		}
+
+		withoutNames := query()
+		withoutNames.InstanceNames = false
+		newTestDatasource().setInstanceNames(context.Background(), data.Frames{series("i-1")}, withoutNames)
+
+		otherNamespace := query()
+		otherNamespace.Namespace = "AWS/EBS"
+		newTestDatasource().setInstanceNames(context.Background(), data.Frames{series("i-1")}, otherNamespace)
+
+		assert.Empty(t, client.calls)
+	})
+}
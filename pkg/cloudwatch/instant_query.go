@@ -0,0 +1,115 @@
+package cloudwatch
+
+import (
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// instantFrame reduces the series frames of an instant query to a table with a row per series holding its latest
+// datapoint, so that stat panels and tables don't need to fetch and reduce the whole time range. Series without
+// datapoints get a row without value.
+func instantFrame(frames data.Frames, query *models.CloudWatchQuery) *data.Frame {
+	var dimensions []string
+	for _, frame := range frames {
+		for dimension := range seriesFrameLabels(frame) {
+			if dimension != "Series" && !slices.Contains(dimensions, dimension) {
+				dimensions = append(dimensions, dimension)
+			}
+		}
+	}
+	slices.Sort(dimensions)
+
+	metricField := data.NewField("Metric", nil, make([]string, 0, len(frames)))
+	dimensionFields := make([]*data.Field, len(dimensions))
+	for i, dimension := range dimensions {
+		dimensionFields[i] = data.NewField(dimension, nil, make([]*string, 0, len(frames)))
+	}
+	valueField := data.NewField("Value", nil, make([]*float64, 0, len(frames)))
+	timeField := data.NewField("Time", nil, make([]*time.Time, 0, len(frames)))
+
+	table := data.NewFrame(query.RefId, append(append([]*data.Field{metricField}, dimensionFields...), valueField, timeField)...)
+	table.RefID = query.RefId
+	table.Meta = createMeta(query)
+	table.Meta.PreferredVisualization = data.VisTypeTable
+	if stats, ok := metricQueryStatsOf(frames); ok {
+		setQueryStats(data.Frames{table}, stats)
+	}
+
+	for _, frame := range frames {
+		metricField.Append(frame.Name)
+		labels := seriesFrameLabels(frame)
+		for i, dimension := range dimensions {
+			if value, ok := labels[dimension]; ok {
+				dimensionFields[i].Append(&value)
+			} else {
+				dimensionFields[i].Append(nil)
+			}
+		}
+
+		value, timestamp, ok := latestDatapoint(frame)
+		if ok {
+			valueField.Append(&value)
+			timeField.Append(&timestamp)
+		} else {
+			valueField.Append(nil)
+			timeField.Append(nil)
+		}
+
+		if frame.Meta != nil {
+			for _, notice := range frame.Meta.Notices {
+				if !slices.Contains(table.Meta.Notices, notice) {
+					table.AppendNotices(notice)
+				}
+			}
+		}
+	}
+
+	return table
+}
+
+// seriesFrameLabels returns the labels of the value field of a series frame.
+func seriesFrameLabels(frame *data.Frame) data.Labels {
+	for _, field := range frame.Fields {
+		if field.Labels != nil {
+			return field.Labels
+		}
+	}
+	return nil
+}
+
+// latestDatapoint returns the value and timestamp of the latest datapoint of a series frame that has a value.
+func latestDatapoint(frame *data.Frame) (float64, time.Time, bool) {
+	timeIndex, valueIndex := -1, -1
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			timeIndex = i
+		} else if field.Type().Numeric() {
+			valueIndex = i
+		}
+	}
+	if timeIndex == -1 || valueIndex == -1 {
+		return 0, time.Time{}, false
+	}
+
+	var latestValue float64
+	var latestTime time.Time
+	found := false
+	for row := 0; row < frame.Rows(); row++ {
+		timestamp, ok := frame.Fields[timeIndex].ConcreteAt(row)
+		if !ok {
+			continue
+		}
+		value, err := frame.Fields[valueIndex].NullableFloatAt(row)
+		if err != nil || value == nil {
+			continue
+		}
+		if t := timestamp.(time.Time); !found || t.After(latestTime) {
+			latestValue, latestTime, found = *value, t, true
+		}
+	}
+	return latestValue, latestTime, found
+}
@@ -0,0 +1,115 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_instantFrame(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	query := &models.CloudWatchQuery{RefId: "A", Id: "a", Period: 60}
+
+	series := func(name string, labels data.Labels, times []time.Time, values []*float64) *data.Frame {
+		return data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			data.NewField(data.TimeSeriesValueFieldName, labels, values),
+		)
+	}
+	value := func(v float64) *float64 { return &v }
+
+	notice := data.Notice{Severity: data.NoticeSeverityWarning, Text: "cut short"}
+	cpu := series("CPUUtilization", data.Labels{"InstanceId": "i-1"}, []time.Time{now.Add(-time.Minute), now, now.Add(-2 * time.Minute)},
+		[]*float64{value(2), value(3), value(1)})
+	cpu.AppendNotices(notice)
+	network := series("NetworkIn", data.Labels{"InstanceId": "i-2", "AutoScalingGroupName": "asg"}, []time.Time{now.Add(-time.Minute), now},
+		[]*float64{value(10), nil})
+	network.AppendNotices(notice)
+	empty := series("NetworkOut", data.Labels{"Series": "NetworkOut"}, []time.Time{}, []*float64{})
+
+	table := instantFrame(data.Frames{cpu, network, empty}, query)
+
+	require.Equal(t, 3, table.Rows())
+	assert.Equal(t, "A", table.RefID)
+	assert.Equal(t, data.VisTypeTable, string(table.Meta.PreferredVisualization))
+	assert.Equal(t, []data.Notice{notice}, table.Meta.Notices)
+
+	names := make([]string, len(table.Fields))
+	for i, field := range table.Fields {
+		names[i] = field.Name
+	}
+	assert.Equal(t, []string{"Metric", "AutoScalingGroupName", "InstanceId", "Value", "Time"}, names)
+
+	assert.Equal(t, []any{"CPUUtilization", nil, "i-1", 3.0, now}, concreteRow(table, 0))
+	assert.Equal(t, []any{"NetworkIn", "asg", "i-2", 10.0, now.Add(-time.Minute)}, concreteRow(table, 1))
+	assert.Equal(t, []any{"NetworkOut", nil, nil, nil, nil}, concreteRow(table, 2))
+}
+
+func concreteRow(frame *data.Frame, row int) []any {
+	values := make([]any, len(frame.Fields))
+	for i, field := range frame.Fields {
+		if value, ok := field.ConcreteAt(row); ok {
+			values[i] = value
+		}
+	}
+	return values
+}
+
+func Test_executeTimeSeriesQuery_instant(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+
+	now := time.Now().Truncate(time.Minute)
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{
+			{StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("i-1"), Values: []float64{5, 4}, Timestamps: []time.Time{now, now.Add(-time.Minute)}},
+			{StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("i-2"), Values: []float64{7}, Timestamps: []time.Time{now.Add(-time.Minute)}},
+		}}, nil)
+
+	ds := newTestDatasource()
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+			JSON: json.RawMessage(`{
+				"type": "timeSeriesQuery",
+				"namespace": "AWS/EC2",
+				"metricName": "CPUUtilization",
+				"dimensions": {"InstanceId": ["i-1", "i-2"]},
+				"region": "us-east-1",
+				"id": "a",
+				"statistic": "Average",
+				"period": "60",
+				"instant": true
+			}`),
+		}},
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+	table := resp.Responses["A"].Frames[0]
+	require.Equal(t, 2, table.Rows())
+	assert.Equal(t, []any{"i-1", "i-1", 5.0, now}, concreteRow(table, 0))
+	assert.Equal(t, []any{"i-2", "i-2", 7.0, now.Add(-time.Minute)}, concreteRow(table, 1))
+}
@@ -0,0 +1,121 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// executeJSONQueries executes advanced JSON queries, whose MetricDataQueries are given as they are sent to the
+// GetMetricData API, so that API features the editor doesn't support yet can be used. Each query returns a time
+// series per MetricDataResult, named by the label of the result.
+func (ds *DataSource) executeJSONQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		var model DataQueryJson
+		if err := json.Unmarshal(query.JSON, &model); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+		if !query.TimeRange.From.Before(query.TimeRange.To) {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(
+				fmt.Errorf("invalid time range: start time must be before end time")))
+			continue
+		}
+		metricDataQueries, err := models.ParseMetricDataQueriesJSON(model.MetricDataQueries)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+
+		client, err := ds.getCWClient(ctx, model.Region)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(fmt.Errorf("%v: %w", "failed to get client", err))
+			continue
+		}
+		outputs, err := ds.executeRequest(ctx, client, &cloudwatch.GetMetricDataInput{
+			StartTime:         aws.Time(query.TimeRange.From),
+			EndTime:           aws.Time(query.TimeRange.To),
+			ScanBy:            cloudwatchtypes.ScanByTimestampAscending,
+			MetricDataQueries: metricDataQueries,
+		})
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(
+				fmt.Errorf("%v: %w", "failed to call cloudwatch:GetMetricData", err))
+			continue
+		}
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: metricDataResultFrames(query.RefID, outputs)}
+	}
+	return resp, nil
+}
+
+// metricDataResultFrames returns a frame per MetricDataResult of outputs, joining the results of the same query
+// spread over several pages. Messages of the API are added to the frames as notices.
+func metricDataResultFrames(refID string, outputs []*cloudwatch.GetMetricDataOutput) data.Frames {
+	var frames data.Frames
+	framesByID := map[string]*data.Frame{}
+	var notices []data.Notice
+	addNotice := func(message cloudwatchtypes.MessageData) {
+		notice := data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("%s: %s", aws.ToString(message.Code), aws.ToString(message.Value)),
+		}
+		if !slices.Contains(notices, notice) {
+			notices = append(notices, notice)
+		}
+	}
+
+	for _, output := range outputs {
+		for _, message := range output.Messages {
+			addNotice(message)
+		}
+		for _, result := range output.MetricDataResults {
+			for _, message := range result.Messages {
+				addNotice(message)
+			}
+			id := aws.ToString(result.Id)
+			frame, ok := framesByID[id]
+			if !ok {
+				name := aws.ToString(result.Label)
+				if name == "" {
+					name = id
+				}
+				frame = data.NewFrame(name,
+					data.NewField(data.TimeSeriesTimeFieldName, nil, []time.Time{}),
+					data.NewField(data.TimeSeriesValueFieldName, data.Labels{"id": id}, []float64{}).
+						SetConfig(&data.FieldConfig{DisplayNameFromDS: name}),
+				)
+				frame.RefID = refID
+				framesByID[id] = frame
+				frames = append(frames, frame)
+			}
+			for i, timestamp := range result.Timestamps {
+				if i < len(result.Values) {
+					frame.AppendRow(timestamp, result.Values[i])
+				}
+			}
+		}
+	}
+
+	if pages := truncatedPages(outputs); pages > 0 {
+		notices = append(notices, pagesTruncatedNotice(pages))
+	}
+	if len(notices) > 0 {
+		if len(frames) == 0 {
+			frames = append(frames, data.NewFrame(refID))
+			frames[0].RefID = refID
+		}
+		frames[0].AppendNotices(notices...)
+	}
+	return frames
+}
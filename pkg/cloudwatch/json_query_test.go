@@ -0,0 +1,91 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_JSONQuery(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	now := time.Now().Truncate(time.Minute)
+	queryData := func(t *testing.T, api *mocks.MetricsAPI, queryJSON string) backend.DataResponse {
+		t.Helper()
+		NewCWClient = func(aws.Config) models.CWClient {
+			return api
+		}
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON:      json.RawMessage(queryJSON),
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("sends the queries verbatim and returns a series per result", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("e1"), Label: aws.String("Doubled"), Values: []float64{2}, Timestamps: []time.Time{now.Add(-5 * time.Minute)},
+					StatusCode: cloudwatchtypes.StatusCodePartialData},
+			},
+			NextToken: aws.String("next"),
+		}, nil).Once()
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("e1"), Label: aws.String("Doubled"), Values: []float64{4}, Timestamps: []time.Time{now}},
+				{Id: aws.String("e2"), Messages: []cloudwatchtypes.MessageData{{Code: aws.String("ArithmeticError"), Value: aws.String("division by zero")}}},
+			},
+		}, nil).Once()
+
+		res := queryData(t, api, `{"refId":"A","queryMode":"Metrics","metricQueryType":2,"region":"us-east-1","metricDataQueries":
+			"[{\"Id\":\"m1\",\"MetricStat\":{\"Metric\":{\"Namespace\":\"AWS/EC2\",\"MetricName\":\"CPUUtilization\"},\"Period\":300,\"Stat\":\"Sum\"},\"ReturnData\":false},{\"Id\":\"e1\",\"Expression\":\"m1 * 2\"},{\"Id\":\"e2\",\"Expression\":\"m1 / 0\"}]"}`)
+
+		require.NoError(t, res.Error)
+		input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		require.Len(t, input.MetricDataQueries, 3)
+		assert.Equal(t, aws.Int32(300), input.MetricDataQueries[0].MetricStat.Period)
+		assert.Equal(t, aws.String("m1 * 2"), input.MetricDataQueries[1].Expression)
+		assert.Equal(t, now.Add(-time.Hour), *input.StartTime)
+
+		require.Len(t, res.Frames, 2)
+		assert.Equal(t, "Doubled", res.Frames[0].Name)
+		assert.Equal(t, "A", res.Frames[0].RefID)
+		require.Equal(t, 2, res.Frames[0].Rows())
+		assert.Equal(t, []any{now, 4.0}, res.Frames[0].RowCopy(1))
+		assert.Equal(t, "e2", res.Frames[1].Name)
+		assert.Equal(t, []data.Notice{{Severity: data.NoticeSeverityWarning, Text: "ArithmeticError: division by zero"}},
+			res.Frames[0].Meta.Notices)
+	})
+
+	t.Run("fails invalid queries without calling the API", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+
+		res := queryData(t, api, `{"refId":"A","metricQueryType":2,"region":"us-east-1","metricDataQueries":"[{\"Id\":\"e1\"}]"}`)
+
+		require.Error(t, res.Error)
+		assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+		assert.Contains(t, res.Error.Error(), "exactly one of MetricStat and Expression")
+		api.AssertNotCalled(t, "GetMetricData", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
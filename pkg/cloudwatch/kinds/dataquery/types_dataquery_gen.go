@@ -18,7 +18,7 @@ import (
 )
 
 type MetricStat struct {
-	// AWS region to query for the metric
+	// AWS region to query for the metric. Metrics queries may query several regions, given as a comma separated list or `all` for every enabled region, their series being labelled with their region.
 	Region string `json:"region"`
 	// A namespace is a container for CloudWatch metrics. Metrics in different namespaces are isolated from each other, so that metrics from different applications are not mistakenly aggregated into the same statistics. For example, Amazon EC2 uses the AWS/EC2 namespace.
 	Namespace string `json:"namespace"`
@@ -48,9 +48,9 @@ type Dimensions map[string]StringOrArrayOfString
 
 // Shape of a CloudWatch Metrics query
 type CloudWatchMetricsQuery struct {
-	// Whether a query is a Metrics, Logs, or Annotations query
+	// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
 	QueryMode *CloudWatchQueryMode `json:"queryMode,omitempty"`
-	// Whether to use a metric search or metric insights query
+	// Whether to use a metric search, metric insights, advanced JSON, query by tag or Application Signals SLO query
 	MetricQueryType *MetricQueryType `json:"metricQueryType,omitempty"`
 	// Whether to use the query builder or code editor to create the query
 	MetricEditorMode *MetricEditorMode `json:"metricEditorMode,omitempty"`
@@ -59,7 +59,7 @@ type CloudWatchMetricsQuery struct {
 	// Deprecated: use label
 	// @deprecated use label
 	Alias *string `json:"alias,omitempty"`
-	// Change the time series legend names using dynamic labels. See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/graph-dynamic-labels.html for more details.
+	// Change the time series legend names using dynamic labels. See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/graph-dynamic-labels.html for more details. The labels of math expressions may also use {{id}}, {{label}} and {{index}} to name each series the expression returns.
 	Label *string `json:"label,omitempty"`
 	// Math expression query
 	Expression *string `json:"expression,omitempty"`
@@ -74,7 +74,7 @@ type CloudWatchMetricsQuery struct {
 	// Specify the query flavor
 	// TODO make this required and give it a default
 	QueryType *string `json:"queryType,omitempty"`
-	// AWS region to query for the metric
+	// AWS region to query for the metric. Metrics queries may query several regions, given as a comma separated list or `all` for every enabled region, their series being labelled with their region.
 	Region string `json:"region"`
 	// A namespace is a container for CloudWatch metrics. Metrics in different namespaces are isolated from each other, so that metrics from different applications are not mistakenly aggregated into the same statistics. For example, Amazon EC2 uses the AWS/EC2 namespace.
 	Namespace string `json:"namespace"`
@@ -92,6 +92,62 @@ type CloudWatchMetricsQuery struct {
 	Statistic *string `json:"statistic,omitempty"`
 	// When the metric query type is set to `Insights` and the `metricEditorMode` is set to `Builder`, this field is used to build up an object representation of a SQL query.
 	Sql *SQLExpression `json:"sql,omitempty"`
+	// Whether to stream new datapoints of the query to the panel over Grafana Live.
+	Live *bool `json:"live,omitempty"`
+	// Whether to return only the latest datapoint of each series, as a table with a row per series, instead of the time series of the time range.
+	Instant *bool `json:"instant,omitempty"`
+	// Further namespaces to search for the metric in addition to `namespace`, so that series of several namespaces can be shown in one query. Only used by search queries in the builder.
+	AdditionalNamespaces []string `json:"additionalNamespaces,omitempty"`
+	// Orders the series of the query by a value of their datapoints, so that only the top or bottom series are returned when combined with `seriesLimit`.
+	SeriesSortBy *SeriesSortBy `json:"seriesSortBy,omitempty"`
+	// Whether series are ordered by descending or ascending value. Defaults to descending.
+	SeriesSortOrder *SeriesSortOrder `json:"seriesSortOrder,omitempty"`
+	// The maximum number of series returned by the query, after they have been ordered. 0 returns every series.
+	SeriesLimit *int64 `json:"seriesLimit,omitempty"`
+	// Regular expression the returned series must match, e.g. to drop canary instances from the results of a search. Matched against the series name, or against the value of `seriesFilterDimension` if set.
+	SeriesFilter *string `json:"seriesFilter,omitempty"`
+	// The dimension whose value `seriesFilter` is matched against.
+	SeriesFilterDimension *string `json:"seriesFilterDimension,omitempty"`
+	// Whether the series matching `seriesFilter` are dropped rather than kept.
+	SeriesFilterExclude *bool `json:"seriesFilterExclude,omitempty"`
+	// Whether to set the thresholds of the returned series to the thresholds of the CloudWatch alarms of their metric, so that panels show the lines the alarms use.
+	AlarmThresholds *bool `json:"alarmThresholds,omitempty"`
+	// Whether to label the series of EC2 instances with the Name tag of their instance, as the InstanceName label, and show the name in place of the instance ID in their legend.
+	InstanceNames *bool `json:"instanceNames,omitempty"`
+	// Statistic requested again when the series of the query have no datapoints of `statistic`, e.g. SampleCount when a metric stops publishing Average, so that health panels show whether the metric is still published. Only used by queries in the builder.
+	FallbackStatistic *string `json:"fallbackStatistic,omitempty"`
+	// Whether to also query the SampleCount of the metric, and return it as a second field of each series, e.g. to hide averages computed from too few samples. Only used by queries in the builder.
+	IncludeSampleCount *bool `json:"includeSampleCount,omitempty"`
+	// Dimension the series of the query are aggregated across with `aggregateFunction`, e.g. InstanceId to sum a metric over every instance. The metric is searched for every value of the dimension, filtered by the other dimensions, and the matching series are aggregated into one. Only used by queries in the builder.
+	AggregateDimension *string `json:"aggregateDimension,omitempty"`
+	// Function aggregating the series across `aggregateDimension`. Defaults to `SUM`.
+	AggregateFunction *AggregateFunction `json:"aggregateFunction,omitempty"`
+	// Percentiles queried in place of `statistic` in one request, e.g. p50, p90, p99 and p99.9, and returned in ascending order as one frame per series with a field per percentile, e.g. for heatmap and percentiles panels. Series filters, limits and instant mode don't apply to them. Only used by search queries in the builder.
+	Percentiles []string `json:"percentiles,omitempty"`
+	// Regions a matrix query queries the metric in. A matrix query returns a table of the latest value of the metric in each combination of `matrixRegions` and `matrixAccountIds`, e.g. for global health panels.
+	MatrixRegions []string `json:"matrixRegions,omitempty"`
+	// Accounts a matrix query queries the metric in, in each of `matrixRegions`. Only the account of the data source is queried if empty.
+	MatrixAccountIds []string `json:"matrixAccountIds,omitempty"`
+	// When the metric query type is set to `JSON`, the MetricDataQueries array of the GetMetricData request, as given to the API. Used for API features the editor doesn't support yet.
+	MetricDataQueries *string `json:"metricDataQueries,omitempty"`
+	// When the metric query type is set to `Tags`, the tags of the resources to query the metric of. The resources matching them are looked up with the tagging API on every run, and expanded into the values of the dimension identifying them in the namespace.
+	TagFilters *Dimensions `json:"tagFilters,omitempty"`
+	// When the metric query type is set to `SLO`, the name of the Application Signals service level objective to query the metrics of.
+	SloName *string `json:"sloName,omitempty"`
+	// When the metric query type is set to `SLO`, the metric of the SLO to query. Defaults to `AttainmentRate`.
+	SloMetric *SLOMetric `json:"sloMetric,omitempty"`
+	// When `sloMetric` is `BurnRate`, the look-back window of the burn rate in minutes. Must be the window of one of the burn rate configurations of the SLO.
+	BurnRateWindowMinutes *int64 `json:"burnRateWindowMinutes,omitempty"`
+	// When the metric query type is set to `BurnRate`, the metric math or search expression of the good events of the SLO, e.g. the requests that succeeded. The series it returns are summed.
+	BurnRateGoodExpression *string `json:"burnRateGoodExpression,omitempty"`
+	// When the metric query type is set to `BurnRate`, the metric math or search expression of all the events of the SLO. The series it returns are summed.
+	BurnRateTotalExpression *string `json:"burnRateTotalExpression,omitempty"`
+	// When the metric query type is set to `BurnRate`, the objective of the SLO in percent, e.g. 99.9.
+	SloObjective *float64 `json:"sloObjective,omitempty"`
+	// When the metric query type is set to `BurnRate`, the look-back windows to compute the burn rate over, as durations such as `5m` or `1h`. Defaults to 5m, 1h and 6h.
+	BurnRateWindows []string `json:"burnRateWindows,omitempty"`
+	// Role to assume instead of the data source's role, so that one data source can query several accounts without cross-account observability. Must be one of the roles the data source settings allow queries to assume.
+	AssumeRoleArn *string `json:"assumeRoleArn,omitempty"`
 	// For mixed data sources the selected datasource is on the query level.
 	// For non mixed scenarios this is undefined.
 	// TODO find a better way to do this ^ that's friendly to schema
@@ -109,9 +165,11 @@ func NewCloudWatchMetricsQuery() *CloudWatchMetricsQuery {
 type CloudWatchQueryMode string
 
 const (
-	CloudWatchQueryModeMetrics     CloudWatchQueryMode = "Metrics"
-	CloudWatchQueryModeLogs        CloudWatchQueryMode = "Logs"
-	CloudWatchQueryModeAnnotations CloudWatchQueryMode = "Annotations"
+	CloudWatchQueryModeMetrics             CloudWatchQueryMode = "Metrics"
+	CloudWatchQueryModeLogs                CloudWatchQueryMode = "Logs"
+	CloudWatchQueryModeAnnotations         CloudWatchQueryMode = "Annotations"
+	CloudWatchQueryModeAlarms              CloudWatchQueryMode = "Alarms"
+	CloudWatchQueryModeContributorInsights CloudWatchQueryMode = "ContributorInsights"
 )
 
 type MetricQueryType int64
@@ -119,6 +177,10 @@ type MetricQueryType int64
 const (
 	MetricQueryTypeSearch   MetricQueryType = 0
 	MetricQueryTypeInsights MetricQueryType = 1
+	MetricQueryTypeJSON     MetricQueryType = 2
+	MetricQueryTypeTags     MetricQueryType = 3
+	MetricQueryTypeSLO      MetricQueryType = 4
+	MetricQueryTypeBurnRate MetricQueryType = 5
 )
 
 type MetricEditorMode int64
@@ -128,6 +190,38 @@ const (
 	MetricEditorModeCode    MetricEditorMode = 1
 )
 
+type SeriesSortBy string
+
+const (
+	SeriesSortByLast SeriesSortBy = "Last"
+	SeriesSortByAvg  SeriesSortBy = "Avg"
+	SeriesSortByMax  SeriesSortBy = "Max"
+)
+
+type SeriesSortOrder string
+
+const (
+	SeriesSortOrderDesc SeriesSortOrder = "Desc"
+	SeriesSortOrderAsc  SeriesSortOrder = "Asc"
+)
+
+type SLOMetric string
+
+const (
+	SLOMetricAttainmentRate       SLOMetric = "AttainmentRate"
+	SLOMetricErrorBudgetRemaining SLOMetric = "ErrorBudgetRemaining"
+	SLOMetricBurnRate             SLOMetric = "BurnRate"
+)
+
+type AggregateFunction string
+
+const (
+	AggregateFunctionSUM AggregateFunction = "SUM"
+	AggregateFunctionAVG AggregateFunction = "AVG"
+	AggregateFunctionMIN AggregateFunction = "MIN"
+	AggregateFunctionMAX AggregateFunction = "MAX"
+)
+
 type SQLExpression struct {
 	// SELECT part of the SQL expression
 	Select *QueryEditorFunctionExpression `json:"select,omitempty"`
@@ -292,9 +386,44 @@ const (
 	LogsQueryLanguagePPL  LogsQueryLanguage = "PPL"
 )
 
+type LogsMode string
+
+const (
+	LogsModeInsights          LogsMode = "Insights"
+	LogsModeEvents            LogsMode = "Events"
+	LogsModeFilter            LogsMode = "Filter"
+	LogsModeContainerInsights LogsMode = "ContainerInsights"
+	LogsModeVPCFlowLogs       LogsMode = "VPCFlowLogs"
+	LogsModeLiveTail          LogsMode = "LiveTail"
+)
+
+type ContainerInsightsQuery string
+
+const (
+	ContainerInsightsQueryPodCPUUtilization     ContainerInsightsQuery = "PodCPUUtilization"
+	ContainerInsightsQueryPodMemoryUtilization  ContainerInsightsQuery = "PodMemoryUtilization"
+	ContainerInsightsQueryPodRestarts           ContainerInsightsQuery = "PodRestarts"
+	ContainerInsightsQueryNodeCPUUtilization    ContainerInsightsQuery = "NodeCPUUtilization"
+	ContainerInsightsQueryNodeMemoryUtilization ContainerInsightsQuery = "NodeMemoryUtilization"
+)
+
+type VPCFlowLogsQuery string
+
+const (
+	VPCFlowLogsQueryRecords             VPCFlowLogsQuery = "Records"
+	VPCFlowLogsQueryTopTalkers          VPCFlowLogsQuery = "TopTalkers"
+	VPCFlowLogsQueryRejectedConnections VPCFlowLogsQuery = "RejectedConnections"
+)
+
+type LogsSupplementaryType string
+
+const (
+	LogsSupplementaryTypeLogsVolume LogsSupplementaryType = "LogsVolume"
+)
+
 // Shape of a CloudWatch Logs query
 type CloudWatchLogsQuery struct {
-	// Whether a query is a Metrics, Logs, or Annotations query
+	// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
 	QueryMode CloudWatchQueryMode `json:"queryMode"`
 	Id        string              `json:"id"`
 	// AWS region to query for the logs
@@ -318,6 +447,38 @@ type CloudWatchLogsQuery struct {
 	QueryType *string `json:"queryType,omitempty"`
 	// Language used for querying logs, can be CWLI, SQL, or PPL. If empty, the default language is CWLI.
 	QueryLanguage *LogsQueryLanguage `json:"queryLanguage,omitempty"`
+	// Name of a recorded query configured in the data source settings to serve the last result of
+	RecordedQuery *string `json:"recordedQuery,omitempty"`
+	// Whether to query the log groups with Logs Insights, to read the events of a single log stream, to match the events of the log groups against a filter pattern, or to tail the new events of the log groups matching a filter pattern with Live Tail. If empty, the default mode is Insights.
+	LogsMode *LogsMode `json:"logsMode,omitempty"`
+	// Log stream to read the events of when the logs mode is Events, or to only tail when the logs mode is LiveTail
+	LogStreamName *string `json:"logStreamName,omitempty"`
+	// Whether to read the earliest events of the time range rather than the latest when the logs mode is Events
+	StartFromHead *bool `json:"startFromHead,omitempty"`
+	// Whether to extract the key=value pairs of logfmt formatted log lines into fields
+	ParseLogfmt *bool `json:"parseLogfmt,omitempty"`
+	// Dot separated paths of JSON log lines whose values are extracted into fields, e.g. request.status
+	JsonPaths []string `json:"jsonPaths,omitempty"`
+	// Whether to extract the metrics and dimensions declared in the _aws metadata of Embedded Metric Format log lines into fields
+	ParseEmf *bool `json:"parseEmf,omitempty"`
+	// Pre-built aggregation of the Container Insights performance logs to run when the logs mode is ContainerInsights
+	ContainerInsightsQuery *ContainerInsightsQuery `json:"containerInsightsQuery,omitempty"`
+	// Name of the cluster whose Container Insights performance logs are queried
+	ClusterName *string `json:"clusterName,omitempty"`
+	// Kubernetes namespace to restrict the pod aggregations of Container Insights to
+	KubernetesNamespace *string `json:"kubernetesNamespace,omitempty"`
+	// Records or canned aggregation of the VPC Flow Logs of the log groups to run when the logs mode is VPCFlowLogs
+	VpcFlowLogsQuery *VPCFlowLogsQuery `json:"vpcFlowLogsQuery,omitempty"`
+	// Format of the flow log records, the list of ${field} placeholders the flow log was created with. If empty, the default version 2 format.
+	FlowLogFormat *string `json:"flowLogFormat,omitempty"`
+	// Role to assume instead of the data source's role, so that one data source can query several accounts without cross-account observability. Must be one of the roles the data source settings allow queries to assume.
+	AssumeRoleArn *string `json:"assumeRoleArn,omitempty"`
+	// ID of a saved Logs Insights query definition to run in place of the expression. The log groups of the definition are queried unless the query selects log groups.
+	QueryDefinitionId *string `json:"queryDefinitionId,omitempty"`
+	// Maximum number of log events the Logs Insights query returns, capped by the maximum of the data source settings. If empty, the maximum of the data source settings or of Logs Insights, 10000.
+	Limit *int32 `json:"limit,omitempty"`
+	// Supplementary query the logs query is run as, LogsVolume counting the log events matching it by time and level for the histogram of Explore
+	SupplementaryType *LogsSupplementaryType `json:"supplementaryType,omitempty"`
 	// For mixed data sources the selected datasource is on the query level.
 	// For non mixed scenarios this is undefined.
 	// TODO find a better way to do this ^ that's friendly to schema
@@ -350,7 +511,7 @@ func NewLogGroup() *LogGroup {
 // TS type is CloudWatchDefaultQuery = Omit<CloudWatchLogsQuery, 'queryMode'> & CloudWatchMetricsQuery, declared in veneer
 // #CloudWatchDefaultQuery: #CloudWatchLogsQuery & #CloudWatchMetricsQuery @cuetsy(kind="type")
 type CloudWatchAnnotationQuery struct {
-	// Whether a query is a Metrics, Logs, or Annotations query
+	// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
 	QueryMode CloudWatchQueryMode `json:"queryMode"`
 	// Enable matching on the prefix of the action name or alarm name, specify the prefixes with actionPrefix and/or alarmNamePrefix
 	PrefixMatching *bool `json:"prefixMatching,omitempty"`
@@ -369,7 +530,7 @@ type CloudWatchAnnotationQuery struct {
 	// Specify the query flavor
 	// TODO make this required and give it a default
 	QueryType *string `json:"queryType,omitempty"`
-	// AWS region to query for the metric
+	// AWS region to query for the metric. Metrics queries may query several regions, given as a comma separated list or `all` for every enabled region, their series being labelled with their region.
 	Region string `json:"region"`
 	// A namespace is a container for CloudWatch metrics. Metrics in different namespaces are isolated from each other, so that metrics from different applications are not mistakenly aggregated into the same statistics. For example, Amazon EC2 uses the AWS/EC2 namespace.
 	Namespace string `json:"namespace"`
@@ -403,6 +564,50 @@ func NewCloudWatchAnnotationQuery() *CloudWatchAnnotationQuery {
 	return &CloudWatchAnnotationQuery{}
 }
 
+// Shape of a CloudWatch Contributor Insights query
+type CloudWatchContributorInsightsQuery struct {
+	// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
+	QueryMode CloudWatchQueryMode `json:"queryMode"`
+	// A unique identifier for the query within the list of targets.
+	// In server side expressions, the refId is used as a variable name to identify results.
+	// By default, the UI will assign A->Z; however setting meaningful names may be useful.
+	RefId string `json:"refId"`
+	// If hide is set to true, Grafana will filter out the response(s) associated with this query before returning it to the panel.
+	Hide *bool `json:"hide,omitempty"`
+	// Specify the query flavor
+	// TODO make this required and give it a default
+	QueryType *string `json:"queryType,omitempty"`
+	// AWS region of the Contributor Insights rule
+	Region string `json:"region"`
+	// Name of the Contributor Insights rule to report the top contributors of
+	RuleName string `json:"ruleName"`
+	// Statistic the contributors are ranked by, Sum or Maximum. If empty, Sum.
+	OrderBy *string `json:"orderBy,omitempty"`
+	// Maximum number of top contributors to return, from 1 to 100. If empty, 10.
+	MaxContributorCount *int32 `json:"maxContributorCount,omitempty"`
+	// The period of the datapoints of the contributors in seconds. If empty, it's picked from the time range.
+	Period *string `json:"period,omitempty"`
+	// Whether to return a time series per contributor or a table of the aggregate values of the contributors. If empty, TimeSeries.
+	Format *ContributorInsightsFormat `json:"format,omitempty"`
+	// For mixed data sources the selected datasource is on the query level.
+	// For non mixed scenarios this is undefined.
+	// TODO find a better way to do this ^ that's friendly to schema
+	// TODO this shouldn't be unknown but DataSourceRef | null
+	Datasource any `json:"datasource,omitempty"`
+}
+
+// NewCloudWatchContributorInsightsQuery creates a new CloudWatchContributorInsightsQuery object.
+func NewCloudWatchContributorInsightsQuery() *CloudWatchContributorInsightsQuery {
+	return &CloudWatchContributorInsightsQuery{}
+}
+
+type ContributorInsightsFormat string
+
+const (
+	ContributorInsightsFormatTimeSeries ContributorInsightsFormat = "TimeSeries"
+	ContributorInsightsFormatTable      ContributorInsightsFormat = "Table"
+)
+
 type QueryEditorArrayExpressionType string
 
 const (
@@ -0,0 +1,80 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// labelRule is a compiled models.LabelRule
+type labelRule struct {
+	labels      *regexp.Regexp
+	rename      string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func compileLabelRules(rules []models.LabelRule) ([]labelRule, error) {
+	compiled := make([]labelRule, 0, len(rules))
+	for i, rule := range rules {
+		labels, err := regexp.Compile(rule.Labels)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("label rule %d: invalid labels expression: %w", i+1, err))
+		}
+		var pattern *regexp.Regexp
+		if rule.Pattern != "" {
+			pattern, err = regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, backend.DownstreamError(fmt.Errorf("label rule %d: invalid pattern: %w", i+1, err))
+			}
+		}
+		compiled = append(compiled, labelRule{labels: labels, rename: rule.Rename, pattern: pattern, replacement: rule.Replacement})
+	}
+	return compiled, nil
+}
+
+// rewriteLabels applies the label rules of the data source to the labels of metric series frames, so that naming
+// conventions of an organization, e.g. a `function` label instead of the FunctionName dimension, don't have to be
+// implemented by every dashboard. Rules are applied in order, a rule seeing the labels rewritten by the previous ones.
+func (ds *DataSource) rewriteLabels(frames data.Frames) {
+	if len(ds.labelRules) == 0 {
+		return
+	}
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if field.Labels == nil {
+				continue
+			}
+			for _, rule := range ds.labelRules {
+				field.Labels = rewriteFieldLabels(field.Labels, rule)
+			}
+		}
+	}
+}
+
+func rewriteFieldLabels(labels data.Labels, rule labelRule) data.Labels {
+	rewritten := make(data.Labels, len(labels))
+	// labels not matching the rule are copied first, so that a renamed label replaces the label it's renamed to
+	for name, value := range labels {
+		if !rule.labels.MatchString(name) {
+			rewritten[name] = value
+		}
+	}
+	for name, value := range labels {
+		if !rule.labels.MatchString(name) {
+			continue
+		}
+		if rule.pattern != nil {
+			value = rule.pattern.ReplaceAllString(value, rule.replacement)
+		}
+		if rule.rename != "" {
+			name = rule.labels.ReplaceAllString(name, rule.rename)
+		}
+		rewritten[name] = value
+	}
+	return rewritten
+}
@@ -0,0 +1,56 @@
+package cloudwatch
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_compileLabelRules(t *testing.T) {
+	_, err := compileLabelRules([]models.LabelRule{{Labels: "("}})
+	assert.ErrorContains(t, err, "label rule 1: invalid labels expression")
+
+	_, err = compileLabelRules([]models.LabelRule{{Labels: "^FunctionName$", Rename: "function"}, {Pattern: "[a-"}})
+	assert.ErrorContains(t, err, "label rule 2: invalid pattern")
+}
+
+func Test_rewriteLabels(t *testing.T) {
+	rules, err := compileLabelRules([]models.LabelRule{
+		{Labels: "^FunctionName$", Rename: "function"},
+		{Labels: "^(TargetGroup|LoadBalancer)$", Pattern: `^arn:aws:[^:]*:[^:]*:[^:]*:`},
+		{Labels: "^Instance(Id)$", Rename: "instance_$1", Pattern: `^i-(\w+)$`, Replacement: "$1"},
+		{Labels: "^Region$", Rename: "region"},
+	})
+	require.NoError(t, err)
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.labelRules = rules
+	})
+
+	frames := data.Frames{
+		data.NewFrame("Invocations",
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{"Series": "Invocations", "FunctionName": "checkout"}, []float64{}),
+		),
+		data.NewFrame("RequestCount",
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{
+				"TargetGroup":  "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/73e2d6bc24d8a067",
+				"LoadBalancer": "app/web/50dc6c495c0c9188",
+			}, []float64{}),
+		),
+		data.NewFrame("CPUUtilization",
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{"InstanceId": "i-0abc", "Region": "eu-west-1", "region": "us-east-1"}, []float64{}),
+		),
+	}
+	ds.rewriteLabels(frames)
+
+	assert.Nil(t, frames[0].Fields[0].Labels)
+	assert.Equal(t, data.Labels{"Series": "Invocations", "function": "checkout"}, frames[0].Fields[1].Labels)
+	assert.Equal(t, data.Labels{"TargetGroup": "targetgroup/web/73e2d6bc24d8a067", "LoadBalancer": "app/web/50dc6c495c0c9188"}, frames[1].Fields[1].Labels)
+	assert.Equal(t, data.Labels{"instance_Id": "0abc", "region": "eu-west-1"}, frames[2].Fields[1].Labels)
+}
@@ -0,0 +1,44 @@
+package cloudwatch
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// labelTemplate matches the placeholders the plugin fills in in the labels of math expressions. CloudWatch gives all
+// the series returned by an expression, e.g. a function of a SEARCH, the same label unless it is dynamic, so the
+// placeholders let users tell them apart by the id of the expression, the label of each series and its position.
+var labelTemplate = regexp.MustCompile(`\{\{\s*(id|label|index)\s*\}\}`)
+
+// mathExpressionLabel returns the label sent to CloudWatch for a math expression query. {{id}} is replaced with the id
+// of the query and {{label}} with the dynamic label of each series, while {{index}} is kept for expandSeriesIndex to
+// replace once the series are returned.
+func mathExpressionLabel(query *models.CloudWatchQuery) string {
+	return labelTemplate.ReplaceAllStringFunc(query.Label, func(placeholder string) string {
+		switch labelTemplate.FindStringSubmatch(placeholder)[1] {
+		case "id":
+			return query.Id
+		case "label":
+			return "${LABEL}"
+		default:
+			return placeholder
+		}
+	})
+}
+
+// expandSeriesIndex replaces the {{index}} placeholders of a series name with the 1-based position of the series in
+// the results of its query.
+func expandSeriesIndex(name string, index int) string {
+	if !strings.Contains(name, "{{") {
+		return name
+	}
+	return labelTemplate.ReplaceAllStringFunc(name, func(placeholder string) string {
+		if labelTemplate.FindStringSubmatch(placeholder)[1] == "index" {
+			return strconv.Itoa(index)
+		}
+		return placeholder
+	})
+}
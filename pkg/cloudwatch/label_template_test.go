@@ -0,0 +1,20 @@
+package cloudwatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_mathExpressionLabel(t *testing.T) {
+	query := &models.CloudWatchQuery{Id: "cpu", Label: "{{id}} {{ label }} #{{index}} {{other}}"}
+	assert.Equal(t, "cpu ${LABEL} #{{index}} {{other}}", mathExpressionLabel(query))
+}
+
+func Test_expandSeriesIndex(t *testing.T) {
+	assert.Equal(t, "cpu #2", expandSeriesIndex("cpu #{{ index }}", 2))
+	assert.Equal(t, "{{label}} 3", expandSeriesIndex("{{label}} {{index}}", 3))
+	assert.Equal(t, "cpu", expandSeriesIndex("cpu", 1))
+}
@@ -0,0 +1,78 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// lambdaInsightsLogGroup is the log group Lambda Insights writes the performance events of all functions to
+const lambdaInsightsLogGroup = "/aws/lambda-insights"
+
+// lambdaGBSecondPrice is the on-demand price of a GB-second of x86 Lambda compute in us-east-1, in USD, which the
+// cost preset estimates the cost of the functions with
+const lambdaGBSecondPrice = 0.0000166667
+
+// lambdaInsightsPresets are queries of the Embedded Metric Format events of Lambda Insights. See
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/Lambda-Insights-metrics.html
+var lambdaInsightsPresets = []logsQueryPreset{
+	{
+		id:          "memoryUtilization",
+		label:       "Memory utilization",
+		description: "Average share of the configured memory used by the invocations of each function.",
+		query:       "stats avg(memory_utilization) as memory_utilization by bin(auto), function_name",
+		statsGroups: []string{"function_name"},
+	},
+	{
+		id:          "maxMemoryUsed",
+		label:       "Max memory used",
+		description: "Most memory used by an invocation of each function.",
+		query:       "stats max(used_memory_max) as used_memory_max by bin(auto), function_name",
+		statsGroups: []string{"function_name"},
+	},
+	{
+		id:          "initDuration",
+		label:       "Init duration",
+		description: "Average time spent initializing the function on cold starts.",
+		query:       "filter ispresent(init_duration) | stats avg(init_duration) as init_duration by bin(auto), function_name",
+		statsGroups: []string{"function_name"},
+	},
+	{
+		id:          "coldStarts",
+		label:       "Cold starts",
+		description: "Number of invocations of each function that started a new execution environment.",
+		query:       "filter ispresent(init_duration) | stats count(*) as cold_starts by bin(auto), function_name",
+		statsGroups: []string{"function_name"},
+	},
+	{
+		id:          "cost",
+		label:       "Cost",
+		description: "Compute cost of each function, estimated from the billed duration and memory at the x86 on-demand price of us-east-1.",
+		query:       "stats sum(billed_mb_ms) / 1024 / 1000 * " + strconv.FormatFloat(lambdaGBSecondPrice, 'f', -1, 64) + " as cost by bin(auto), function_name",
+		statsGroups: []string{"function_name"},
+	},
+}
+
+// LambdaInsightsPresetsHandler returns the Lambda Insights query presets, restricted to the function given by the
+// functionName parameter if there is one.
+func (ds *DataSource) LambdaInsightsPresetsHandler(_ context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	response := lambdaInsightsPresetsResponse(parameters.Get("functionName"))
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in LambdaInsightsPresetsHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
+
+func lambdaInsightsPresetsResponse(functionName string) []resources.ResourceResponse[resources.LogsQueryPreset] {
+	filter := ""
+	if functionName != "" {
+		filter = "filter function_name = " + strconv.Quote(functionName) + " | "
+	}
+	return logsQueryPresetsResponse(lambdaInsightsPresets, lambdaInsightsLogGroup, filter)
+}
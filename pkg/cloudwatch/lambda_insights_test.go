@@ -0,0 +1,49 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func Test_lambda_insights_presets_route(t *testing.T) {
+	ds := newTestDatasource()
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.LambdaInsightsPresetsHandler))
+
+	t.Run("returns the presets for all functions", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/lambda-insights-presets", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var presets []resources.ResourceResponse[resources.LogsQueryPreset]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &presets))
+		require.Len(t, presets, len(lambdaInsightsPresets))
+		assert.Equal(t, resources.ResourceResponse[resources.LogsQueryPreset]{
+			Label: "Memory utilization",
+			Value: resources.LogsQueryPreset{
+				Id:            "memoryUtilization",
+				Description:   "Average share of the configured memory used by the invocations of each function.",
+				Expression:    "stats avg(memory_utilization) as memory_utilization by bin(auto), function_name",
+				LogGroupNames: []string{"/aws/lambda-insights"},
+				StatsGroups:   []string{"function_name"},
+			},
+		}, presets[0])
+		assert.Equal(t, "stats sum(billed_mb_ms) / 1024 / 1000 * 0.0000166667 as cost by bin(auto), function_name", presets[4].Value.Expression)
+	})
+
+	t.Run("restricts the presets to a function", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", `/lambda-insights-presets?functionName=checkout-"api"`, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var presets []resources.ResourceResponse[resources.LogsQueryPreset]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &presets))
+		assert.Equal(t, `filter function_name = "checkout-\"api\"" | filter ispresent(init_duration) | stats avg(init_duration) as init_duration by bin(auto), function_name`, presets[2].Value.Expression)
+	})
+}
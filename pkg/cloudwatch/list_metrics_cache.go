@@ -0,0 +1,129 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/clients"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// cachedMetricsClient reuses the metrics listed by identical ListMetrics calls for the list metrics cache TTL, as the
+// query editor lists the metrics of a namespace again for every dimension key and value it completes.
+type cachedMetricsClient struct {
+	models.MetricsClientProvider
+
+	ds     *DataSource
+	region string
+}
+
+// newCachedMetricsClient returns a metrics client listing the metrics of region with client, cached when the list
+// metrics cache is enabled.
+func (ds *DataSource) newCachedMetricsClient(region string, client cloudwatch.ListMetricsAPIClient,
+	pageLimit int) models.MetricsClientProvider {
+	metricsClient := clients.NewMetricsClient(client, pageLimit)
+	if ds.listMetricsCache == nil || ds.Settings.ListMetricsCacheTTL.Duration <= 0 {
+		return metricsClient
+	}
+	if region == defaultRegion {
+		region = ds.Settings.Region
+	}
+	return &cachedMetricsClient{MetricsClientProvider: metricsClient, ds: ds, region: region}
+}
+
+func (c *cachedMetricsClient) ListMetricsWithPageLimit(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]resources.MetricResponse, error) {
+	key, err := c.ds.listMetricsCacheKey(ctx, c.region, params)
+	if err != nil {
+		return c.MetricsClientProvider.ListMetricsWithPageLimit(ctx, params)
+	}
+	if cached, found := c.ds.listMetricsCache.Get(key); found {
+		return cached.([]resources.MetricResponse), nil
+	}
+
+	metrics, err := c.MetricsClientProvider.ListMetricsWithPageLimit(ctx, params)
+	if err != nil {
+		return metrics, err
+	}
+	c.ds.listMetricsCache.Set(key, metrics, c.ds.Settings.ListMetricsCacheTTL.Duration)
+	return metrics, nil
+}
+
+// listMetricsCacheKey identifies the metrics listed with params. The key starts with the region, namespace and
+// account they were listed in, for the cache to be invalidated by them, and ends with a hash of the call scoped like
+// query results are.
+func (ds *DataSource) listMetricsCacheKey(ctx context.Context, region string, params *cloudwatch.ListMetricsInput) (string, error) {
+	roleARN, err := ds.assumeRoleARN(ctx)
+	if err != nil {
+		return "", err
+	}
+	hash, err := scopedCacheKey(ctx, ds.Settings.UserIdentityPassThrough, map[string]any{
+		"role":   roleARN,
+		"params": params,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{region, aws.ToString(params.Namespace), aws.ToString(params.OwningAccount), hash}, "|"), nil
+}
+
+// invalidateListMetricsCache removes the metrics listed in region, namespace and account from the cache, with empty
+// filters matching any value, and returns how many entries were removed.
+func (ds *DataSource) invalidateListMetricsCache(region, namespace, account string) int {
+	if ds.listMetricsCache == nil {
+		return 0
+	}
+	invalidated := 0
+	for key := range ds.listMetricsCache.Items() {
+		parts := strings.SplitN(key, "|", 4)
+		if len(parts) != 4 ||
+			(region != "" && parts[0] != region) ||
+			(namespace != "" && parts[1] != namespace) ||
+			(account != "" && parts[2] != account) {
+			continue
+		}
+		ds.listMetricsCache.Delete(key)
+		invalidated++
+	}
+	return invalidated
+}
+
+// handleInvalidateCache removes the listed metrics cached for the region, namespace and accountId parameters, each
+// optional, so that users can see the metrics of resources they just created without waiting for the cache TTL.
+// The namespaces discovered in the region are invalidated with them.
+func (ds *DataSource) handleInvalidateCache(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		respondWithError(rw, models.NewHttpError("Invalid method", http.StatusMethodNotAllowed, nil))
+		return
+	}
+
+	query := req.URL.Query()
+	region := query.Get("region")
+	if region == defaultRegion {
+		region = ds.Settings.Region
+	}
+	invalidated := ds.invalidateListMetricsCache(region, query.Get("namespace"), query.Get("accountId"))
+	for key := range ds.discoveryCache.Items() {
+		// discovery probes are keyed by "discovery-probe|role|region|includeLinkedAccounts"
+		parts := strings.Split(key, "|")
+		if parts[0] == "discovery-probe" && len(parts) == 4 && (region == "" || parts[2] == region) {
+			ds.discoveryCache.Delete(key)
+			invalidated++
+		}
+	}
+
+	response, err := json.Marshal(map[string]int{"invalidated": invalidated})
+	if err != nil {
+		respondWithError(rw, models.NewHttpError("error in InvalidateCacheHandler", http.StatusInternalServerError, err))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	if _, err := rw.Write(response); err != nil {
+		ds.logger.FromContext(req.Context()).Error("Error writing cache invalidation response", "error", err)
+	}
+}
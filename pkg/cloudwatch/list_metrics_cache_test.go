@@ -0,0 +1,60 @@
+package cloudwatch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestListMetricsCache(t *testing.T) {
+	api := &mocks.MetricsAPI{Metrics: []cloudwatchtypes.Metric{{Namespace: aws.String("AWS/EC2"), MetricName: aws.String("CPUUtilization")}}}
+	api.On("ListMetrics").Return(nil)
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.listMetricsCache = cache.New(cache.NoExpiration, 0)
+		ds.Settings.Region = "us-east-1"
+		ds.Settings.ListMetricsCacheTTL = models.Duration{Duration: time.Hour}
+	})
+	list := func(t *testing.T, region, namespace string) {
+		t.Helper()
+		metrics, err := ds.newCachedMetricsClient(region, api, 10).
+			ListMetricsWithPageLimit(context.Background(), &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
+		require.NoError(t, err)
+		require.Len(t, metrics, 1)
+	}
+	invalidate := func(t *testing.T, method, parameters string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		ds.handleInvalidateCache(rr, httptest.NewRequest(method, "/cache/invalidate"+parameters, nil))
+		return rr
+	}
+
+	list(t, "us-east-1", "AWS/EC2")
+	list(t, defaultRegion, "AWS/EC2")
+	list(t, "us-east-1", "AWS/Lambda")
+	api.AssertNumberOfCalls(t, "ListMetrics", 2)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, invalidate(t, http.MethodGet, "").Code)
+
+	rr := invalidate(t, http.MethodPost, "?region=default&namespace=AWS/EC2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"invalidated":1}`, rr.Body.String())
+	list(t, "us-east-1", "AWS/EC2")
+	list(t, "us-east-1", "AWS/Lambda")
+	api.AssertNumberOfCalls(t, "ListMetrics", 3)
+
+	assert.JSONEq(t, `{"invalidated":2}`, invalidate(t, http.MethodPost, "").Body.String())
+	list(t, "us-east-1", "AWS/Lambda")
+	api.AssertNumberOfCalls(t, "ListMetrics", 4)
+}
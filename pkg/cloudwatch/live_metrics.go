@@ -0,0 +1,225 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/features"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+)
+
+const (
+	// liveMetricsPathPrefix is the stream path prefix used to push new datapoints of a live metrics query.
+	// The full path has the format metrics/<key>
+	liveMetricsPathPrefix = "metrics/"
+
+	// liveMetricsRegistration is how long a live metrics query stays registered after it was last queried
+	liveMetricsRegistration  = time.Hour
+	liveMetricsMinPollPeriod = 10 * time.Second
+	// liveMetricsLookbackPeriods is the number of periods fetched on every poll, as datapoints may arrive late
+	liveMetricsLookbackPeriods = 3
+)
+
+type liveMetricsQuery struct {
+	query  backend.DataQuery
+	period int
+	// lastTimestamps holds the last datapoint of each series the panel already has
+	lastTimestamps map[string]time.Time
+}
+
+// registerLiveMetricsQueries registers the metric queries of the request that should be streamed, and sets the live
+// channel of the registered query on its frames so that the panel subscribes to it.
+func (ds *DataSource) registerLiveMetricsQueries(ctx context.Context, req *backend.QueryDataRequest, resp *backend.QueryDataResponse) {
+	ctx = backend.WithPluginContext(ctx, req.PluginContext)
+	for _, query := range req.Queries {
+		var model struct {
+			Live bool `json:"live"`
+		}
+		if err := json.Unmarshal(query.JSON, &model); err != nil || !model.Live {
+			continue
+		}
+		response, ok := resp.Responses[query.RefID]
+		if !ok || response.Error != nil {
+			continue
+		}
+
+		liveQuery, err := ds.newLiveMetricsQuery(ctx, query, response.Frames)
+		if err != nil {
+			ds.logger.FromContext(ctx).Warn("Query can't be streamed", "refId", query.RefID, "error", err)
+			continue
+		}
+		key, err := liveMetricsQueryKey(ctx, query, ds.Settings.UserIdentityPassThrough)
+		if err != nil {
+			continue
+		}
+		channel := liveMetricsChannel(ctx, key)
+		if channel == "" {
+			continue
+		}
+
+		ds.liveQueries.Set(key, liveQuery, liveMetricsRegistration)
+		for _, frame := range response.Frames {
+			if frame.Meta == nil {
+				frame.Meta = &data.FrameMeta{}
+			}
+			frame.Meta.Channel = channel
+		}
+	}
+}
+
+// newLiveMetricsQuery pins the period picked for the time range of the query, so that streamed datapoints have the
+// same resolution as the ones the panel already has. Math expressions can't be streamed, as the queries they
+// reference aren't part of the stream.
+func (ds *DataSource) newLiveMetricsQuery(ctx context.Context, query backend.DataQuery, frames data.Frames) (liveMetricsQuery, error) {
+	parsed, err := models.ParseMetricDataQueries([]backend.DataQuery{query}, query.TimeRange.From, query.TimeRange.To, ds.Settings.Region,
+		ds.logger.FromContext(ctx), features.IsEnabled(ctx, features.FlagCloudWatchCrossAccountQuerying), ds.Settings.Variables)
+	if err != nil {
+		return liveMetricsQuery{}, err
+	}
+	if len(parsed) != 1 {
+		return liveMetricsQuery{}, fmt.Errorf("expected a single metric query")
+	}
+	if parsed[0].GetGetMetricDataAPIMode() == models.GMDApiModeMathExpression {
+		return liveMetricsQuery{}, fmt.Errorf("math expressions can't be streamed")
+	}
+
+	query.JSON, err = withPeriod(query.JSON, parsed[0].Period)
+	if err != nil {
+		return liveMetricsQuery{}, err
+	}
+	lastTimestamps := map[string]time.Time{}
+	for _, frame := range frames {
+		if frame.Rows() == 0 || len(frame.Fields) == 0 {
+			continue
+		}
+		if last, ok := frame.Fields[0].At(frame.Rows() - 1).(time.Time); ok {
+			lastTimestamps[seriesKey(frame)] = last
+		}
+	}
+	return liveMetricsQuery{query: query, period: parsed[0].Period, lastTimestamps: lastTimestamps}, nil
+}
+
+// liveMetricsQueryKey identifies a live metrics query regardless of its time range.
+func liveMetricsQueryKey(ctx context.Context, query backend.DataQuery, perUser bool) (string, error) {
+	query.TimeRange = backend.TimeRange{}
+	return queryCacheKey(ctx, query, perUser)
+}
+
+// subscribeLiveMetrics reports whether the subscriber may receive the stream of the live metrics query at path. The
+// key of the query is derived from the subscriber's org, and user when their own identity is used to query AWS, so
+// that subscribers can't receive another org's or user's results.
+func (ds *DataSource) subscribeLiveMetrics(ctx context.Context, pCtx backend.PluginContext, path string) bool {
+	if ds.liveQueries == nil {
+		return false
+	}
+	key := strings.TrimPrefix(path, liveMetricsPathPrefix)
+	registered, found := ds.liveQueries.Get(key)
+	if !found {
+		return false
+	}
+	subscriberKey, err := liveMetricsQueryKey(backend.WithPluginContext(ctx, pCtx), registered.(liveMetricsQuery).query, ds.Settings.UserIdentityPassThrough)
+	return err == nil && subscriberKey == key
+}
+
+// streamLiveMetrics executes the live metrics query registered under key once per period, and sends the datapoints
+// of each series that are newer than the last one sent. It returns once the subscription is closed.
+func (ds *DataSource) streamLiveMetrics(ctx context.Context, pCtx backend.PluginContext, key string, sender *backend.StreamSender) error {
+	if ds.liveQueries == nil {
+		return fmt.Errorf("live metrics query %s is not registered", key)
+	}
+	registered, found := ds.liveQueries.Get(key)
+	if !found {
+		return fmt.Errorf("live metrics query %s is not registered", key)
+	}
+	liveQuery := registered.(liveMetricsQuery)
+	ctx, err := ds.withRoleOfQuery(ctx, liveQuery.query)
+	if err != nil {
+		return err
+	}
+
+	lastTimestamps := make(map[string]time.Time, len(liveQuery.lastTimestamps))
+	for series, last := range liveQuery.lastTimestamps {
+		lastTimestamps[series] = last
+	}
+
+	period := time.Duration(liveQuery.period) * time.Second
+	ticker := time.NewTicker(max(period, liveMetricsMinPollPeriod))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			now := time.Now()
+			query := liveQuery.query
+			query.TimeRange = backend.TimeRange{From: now.Add(-liveMetricsLookbackPeriods * period), To: now}
+			resp, err := ds.executeTimeSeriesQuery(ctx, &backend.QueryDataRequest{PluginContext: pCtx, Queries: []backend.DataQuery{query}})
+			if err != nil {
+				return err
+			}
+			response := resp.Responses[query.RefID]
+			if response.Error != nil {
+				return response.Error
+			}
+			for _, frame := range response.Frames {
+				newDatapoints := liveNewDatapoints(frame, lastTimestamps)
+				if newDatapoints.Rows() == 0 {
+					continue
+				}
+				if err := sender.SendFrame(newDatapoints, data.IncludeAll); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// liveNewDatapoints returns the datapoints of frame newer than the last ones sent for the series, and records the
+// last one sent.
+func liveNewDatapoints(frame *data.Frame, lastTimestamps map[string]time.Time) *data.Frame {
+	newDatapoints := frame.EmptyCopy()
+	if len(frame.Fields) == 0 {
+		return newDatapoints
+	}
+	series := seriesKey(frame)
+	last, sent := lastTimestamps[series]
+	for row := 0; row < frame.Rows(); row++ {
+		timestamp, ok := frame.Fields[0].At(row).(time.Time)
+		if !ok || (sent && !timestamp.After(last)) {
+			continue
+		}
+		newDatapoints.AppendRow(frame.RowCopy(row)...)
+		lastTimestamps[series] = timestamp
+		last, sent = timestamp, true
+	}
+	return newDatapoints
+}
+
+// seriesKey identifies the series a frame holds by its name and the labels of its value field.
+func seriesKey(frame *data.Frame) string {
+	if len(frame.Fields) > 1 && len(frame.Fields[1].Labels) > 0 {
+		return frame.Name + "{" + frame.Fields[1].Labels.String() + "}"
+	}
+	return frame.Name
+}
+
+// liveMetricsChannel returns the live channel that streams the live metrics query with the given key, or an empty
+// string if the datasource uid is not known.
+func liveMetricsChannel(ctx context.Context, key string) string {
+	pCtx := backend.PluginConfigFromContext(ctx)
+	if pCtx.DataSourceInstanceSettings == nil || pCtx.DataSourceInstanceSettings.UID == "" {
+		return ""
+	}
+	return live.Channel{
+		Scope:     live.ScopeDatasource,
+		Namespace: pCtx.DataSourceInstanceSettings.UID,
+		Path:      liveMetricsPathPrefix + key,
+	}.String()
+}
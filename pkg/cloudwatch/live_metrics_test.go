@@ -0,0 +1,102 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_liveNewDatapoints(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	lastTimestamps := map[string]time.Time{}
+	frame := seriesFrame("cpu", start, start.Add(time.Minute), start.Add(2*time.Minute))
+	lastTimestamps[seriesKey(frame)] = start.Add(time.Minute)
+
+	newDatapoints := liveNewDatapoints(frame, lastTimestamps)
+	require.Equal(t, 1, newDatapoints.Rows())
+	assert.Equal(t, start.Add(2*time.Minute), newDatapoints.Fields[0].At(0))
+	assert.Equal(t, start.Add(2*time.Minute), lastTimestamps[seriesKey(frame)])
+
+	assert.Equal(t, 0, liveNewDatapoints(frame, lastTimestamps).Rows(), "datapoints are only sent once")
+	assert.Equal(t, 1, liveNewDatapoints(seriesFrame("network", start), lastTimestamps).Rows(), "new series are sent in full")
+}
+
+func TestQueryData_registersLiveMetricsQueries(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+	now := time.Now()
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+			StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: []float64{1}, Timestamps: []time.Time{now},
+		}}}, nil)
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.liveQueries = cache.New(liveMetricsRegistration, 0)
+	})
+	pluginContext := backend.PluginContext{OrgID: 1, DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "cw"}}
+	query := func(live bool) *backend.QueryDataRequest {
+		model := map[string]any{
+			"type": "timeSeriesQuery", "namespace": "AWS/EC2", "metricName": "CPUUtilization", "dimensions": map[string]any{"InstanceId": "i-1"},
+			"region": "us-east-1", "id": "a", "statistic": "Average", "period": "60", "matchExact": true, "refId": "A", "live": live,
+		}
+		queryJSON, err := json.Marshal(model)
+		require.NoError(t, err)
+		return &backend.QueryDataRequest{
+			PluginContext: pluginContext,
+			Queries:       []backend.DataQuery{{RefID: "A", TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now}, JSON: queryJSON}},
+		}
+	}
+
+	resp, err := ds.QueryData(context.Background(), query(false))
+	require.NoError(t, err)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+	assert.Empty(t, resp.Responses["A"].Frames[0].Meta.Channel)
+	assert.Zero(t, ds.liveQueries.ItemCount())
+
+	resp, err = ds.QueryData(context.Background(), query(true))
+	require.NoError(t, err)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+	channel := resp.Responses["A"].Frames[0].Meta.Channel
+	require.True(t, strings.HasPrefix(channel, "ds/cw/"+liveMetricsPathPrefix), channel)
+	path := strings.TrimPrefix(channel, "ds/cw/")
+
+	subscribe, err := ds.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pluginContext, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusOK, subscribe.Status)
+
+	otherOrg := pluginContext
+	otherOrg.OrgID = 2
+	subscribe, err = ds.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: otherOrg, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe.Status, "other orgs can't subscribe")
+
+	subscribe, err = ds.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pluginContext, Path: liveMetricsPathPrefix + "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe.Status)
+
+	registered, found := ds.liveQueries.Get(strings.TrimPrefix(path, liveMetricsPathPrefix))
+	require.True(t, found)
+	liveQuery := registered.(liveMetricsQuery)
+	assert.Equal(t, 60, liveQuery.period)
+	require.Len(t, liveQuery.lastTimestamps, 1)
+	assert.WithinDuration(t, now, liveQuery.lastTimestamps[seriesKey(resp.Responses["A"].Frames[0])], time.Millisecond)
+}
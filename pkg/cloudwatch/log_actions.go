@@ -26,10 +26,13 @@ import (
 )
 
 const (
-	defaultEventLimit           = int32(10)
-	defaultLogGroupLimit        = int32(50)
+	defaultEventLimit    = int32(10)
+	defaultLogGroupLimit = int32(50)
+	// maxLogsInsightsLimit is the most log events a Logs Insights query can return, which it also returns by default
+	maxLogsInsightsLimit        = int32(10000)
 	logIdentifierInternal       = "__log__grafana_internal__"
 	logStreamIdentifierInternal = "__logstream__grafana_internal__"
+	logPtrInternal              = "__ptr__grafana_internal__"
 )
 
 type AWSError struct {
@@ -65,6 +68,13 @@ func (ds *DataSource) executeLogActions(ctx context.Context, req *backend.QueryD
 				return nil
 			}
 
+			if logsQuery.Subtype == "GetQueryResults" && isLogsVolumeQuery(logsQuery) {
+				resultChan <- backend.Responses{
+					query.RefID: backend.DataResponse{Frames: logsVolumeFrames(dataframe)},
+				}
+				return nil
+			}
+
 			groupedFrames, err := groupResponseFrame(dataframe, logsQuery.StatsGroups)
 			if err != nil {
 				return err
@@ -108,7 +118,7 @@ func (ds *DataSource) executeLogAction(ctx context.Context, logsQuery models.Log
 	var frame *data.Frame
 	switch logsQuery.Subtype {
 	case "StartQuery":
-		frame, err = ds.handleStartQuery(ctx, logsClient, logsQuery, query.TimeRange, query.RefID)
+		frame, err = ds.handleStartQuery(ctx, logsClient, logsQuery, query)
 	case "StopQuery":
 		frame, err = ds.handleStopQuery(ctx, logsClient, logsQuery)
 	case "GetQueryResults":
@@ -156,14 +166,21 @@ func (ds *DataSource) handleGetLogEvents(ctx context.Context, logsClient models.
 		return nil, backend.DownstreamError(err)
 	}
 
+	frame := logEventsFrame(logEvents.Events)
+	ds.maskLogsFrame(frame)
+	return frame, nil
+}
+
+// logEventsFrame returns the events as a frame, newest first.
+func logEventsFrame(events []cloudwatchlogstypes.OutputLogEvent) *data.Frame {
 	messages := make([]*string, 0)
 	timestamps := make([]time.Time, 0)
 
-	sort.Slice(logEvents.Events, func(i, j int) bool {
-		return *(logEvents.Events[i].Timestamp) > *(logEvents.Events[j].Timestamp)
+	sort.Slice(events, func(i, j int) bool {
+		return *(events[i].Timestamp) > *(events[j].Timestamp)
 	})
 
-	for _, event := range logEvents.Events {
+	for _, event := range events {
 		messages = append(messages, event.Message)
 		timestamps = append(timestamps, time.UnixMilli(*event.Timestamp).UTC())
 	}
@@ -173,22 +190,44 @@ func (ds *DataSource) handleGetLogEvents(ctx context.Context, logsClient models.
 
 	messageField := data.NewField("line", nil, messages)
 
-	return data.NewFrame("logEvents", timestampField, messageField), nil
+	return data.NewFrame("logEvents", timestampField, messageField)
 }
 
 func (ds *DataSource) executeStartQuery(ctx context.Context, logsClient models.CWLogsClient,
-	logsQuery models.LogsQuery, timeRange backend.TimeRange) (*cloudwatchlogs.StartQueryOutput, error) {
-	startTime := timeRange.From
-	endTime := timeRange.To
+	logsQuery models.LogsQuery, query backend.DataQuery) (*cloudwatchlogs.StartQueryOutput, error) {
+	startTime := query.TimeRange.From
+	endTime := query.TimeRange.To
 
 	if !startTime.Before(endTime) {
 		return nil, backend.DownstreamError(fmt.Errorf("invalid time range: start time must be before end time"))
 	}
+	if aws.ToString(logsQuery.QueryDefinitionId) != "" {
+		var err error
+		if logsQuery, err = withQueryDefinition(ctx, logsClient, logsQuery); err != nil {
+			return nil, err
+		}
+	}
 	if logsQuery.QueryLanguage == nil {
 		cwli := dataquery.LogsQueryLanguageCWLI
 		logsQuery.QueryLanguage = &cwli
 	}
 
+	// the global variables are left in the queries of alert rules and public dashboards, which the frontend doesn't
+	// interpolate
+	logsQuery.QueryString = models.InterpolateGlobalVariables(logsQuery.QueryString, query.TimeRange, query.Interval)
+
+	if isLogsVolumeQuery(logsQuery) {
+		if *logsQuery.QueryLanguage != dataquery.LogsQueryLanguageCWLI {
+			return nil, backend.DownstreamError(fmt.Errorf("the logs volume can only be shown for Logs Insights QL queries"))
+		}
+		queryString, err := logsVolumeQueryString(logsQuery.QueryString, autoBinSize(query))
+		if err != nil {
+			return nil, backend.DownstreamError(err)
+		}
+		// the limit of the query would cap the bins rather than the log events counted
+		logsQuery.QueryString, logsQuery.Limit = queryString, nil
+	}
+
 	finalQueryString := logsQuery.QueryString
 	// Only for CWLI queries
 	// The fields @log and @logStream are always included in the results of a user's query
@@ -197,7 +236,7 @@ func (ds *DataSource) executeStartQuery(ctx context.Context, logsClient models.C
 	// CloudWatch wouldn't consider a query using a non-alised @log/@logStream valid.
 	if *logsQuery.QueryLanguage == dataquery.LogsQueryLanguageCWLI {
 		finalQueryString = "fields @timestamp,ltrim(@log) as " + logIdentifierInternal + ",ltrim(@logStream) as " +
-			logStreamIdentifierInternal + "|" + logsQuery.QueryString
+			logStreamIdentifierInternal + "|" + resolveAutoBins(logsQuery.QueryString, query)
 	}
 
 	startQueryInput := &cloudwatchlogs.StartQueryInput{
@@ -227,29 +266,48 @@ func (ds *DataSource) executeStartQuery(ctx context.Context, logsClient models.C
 		}
 	}
 
-	if logsQuery.Limit != nil {
-		startQueryInput.Limit = aws.Int32(*logsQuery.Limit)
+	if limit := ds.logsInsightsLimit(logsQuery.Limit); logsQuery.Limit != nil || limit < maxLogsInsightsLimit {
+		startQueryInput.Limit = aws.Int32(limit)
 	}
 	if logsQuery.QueryLanguage != nil {
 		startQueryInput.QueryLanguage = cloudwatchlogstypes.QueryLanguage(*logsQuery.QueryLanguage)
 	}
 
+	queryIdKey := ds.logsQueryIdKey(ctx, logsQuery.Region, startQueryInput)
+	if queryId, ok := ds.reusableLogsQueryId(queryIdKey); ok {
+		ds.logger.FromContext(ctx).Debug("Reusing identical Logs Insights query", "queryId", queryId)
+		return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String(queryId)}, nil
+	}
+
+	region := ds.logsRegion(logsQuery.Region)
+	quotas := ds.getLogsQuotas(ctx, region)
+	role, _ := ds.logsQueryRole(ctx)
+	if running := ds.runningLogs.count(region, role); running >= quotas.concurrentQueries {
+		return nil, backend.DownstreamError(concurrentQueriesExceededError(region, running))
+	}
+
 	ds.logger.FromContext(ctx).Debug("Calling startquery with context with input", "input", startQueryInput)
-	resp, err := logsClient.StartQuery(ctx, startQueryInput)
+	resp, err := ds.startLogsQuery(ctx, logsClient, logsQuery.Region, startQueryInput)
 	if err != nil {
 		if errors.Is(err, &cloudwatchlogstypes.LimitExceededException{}) {
 			ds.logger.FromContext(ctx).Debug("ExecuteStartQuery limit exceeded", "err", err)
+			err = fmt.Errorf("%w (the account can run %d Logs Insights queries concurrently in %s)",
+				err, quotas.concurrentQueries, region)
 		} else if errors.Is(err, &cloudwatchlogstypes.ThrottlingException{}) {
 			ds.logger.FromContext(ctx).Debug("ExecuteStartQuery rate exceeded", "err", err)
 		}
 		err = backend.DownstreamError(err)
+	} else if resp.QueryId != nil {
+		ds.rememberLogsQueryId(queryIdKey, *resp.QueryId)
+		ds.runningLogs.add(ds.newRunningLogsQuery(ctx, region, *resp.QueryId, queryIdKey), quotas.queryTimeout)
 	}
 	return resp, err
 }
 
 func (ds *DataSource) handleStartQuery(ctx context.Context, logsClient models.CWLogsClient,
-	logsQuery models.LogsQuery, timeRange backend.TimeRange, refID string) (*data.Frame, error) {
-	startQueryResponse, err := ds.executeStartQuery(ctx, logsClient, logsQuery, timeRange)
+	logsQuery models.LogsQuery, query backend.DataQuery) (*data.Frame, error) {
+	refID := query.RefID
+	startQueryResponse, err := ds.executeStartQuery(ctx, logsClient, logsQuery, query)
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +323,10 @@ func (ds *DataSource) handleStartQuery(ctx context.Context, logsClient models.CW
 	dataFrame.Meta = &data.FrameMeta{
 		Custom: map[string]any{
 			"Region": region,
+			// sent back with the GetQueryResults requests, so that their results tell whether they reached it
+			"Limit": ds.logsInsightsLimit(logsQuery.Limit),
 		},
+		Channel: logsProgressChannel(ctx, region, *startQueryResponse.QueryId),
 	}
 
 	return dataFrame, nil
@@ -277,6 +338,8 @@ func (ds *DataSource) executeStopQuery(ctx context.Context, logsClient models.CW
 		QueryId: aws.String(logsQuery.QueryId),
 	}
 
+	ds.forgetLogsQueryId(logsQuery.QueryId)
+	ds.runningLogs.remove(ds.logsRegion(logsQuery.Region), logsQuery.QueryId)
 	response, err := logsClient.StopQuery(ctx, queryInput)
 	if err != nil {
 		// If the query has already stopped by the time CloudWatch receives the stop query request,
@@ -310,6 +373,11 @@ func (ds *DataSource) executeGetQueryResults(ctx context.Context, logsClient mod
 		QueryId: aws.String(logsQuery.QueryId),
 	}
 
+	region := ds.logsRegion(logsQuery.Region)
+	if err := ds.logsPollPacer.wait(ctx, region); err != nil {
+		return nil, err
+	}
+
 	getQueryResultsResponse, err := logsClient.GetQueryResults(ctx, queryInput)
 	if err != nil {
 		var awsErr smithy.APIError
@@ -317,6 +385,15 @@ func (ds *DataSource) executeGetQueryResults(ctx context.Context, logsClient mod
 			err = &AWSError{Code: awsErr.ErrorCode(), Message: awsErr.ErrorMessage()}
 		}
 		err = backend.DownstreamError(err)
+	} else if getQueryResultsResponse != nil {
+		if isIncompleteLogsQueryStatus(getQueryResultsResponse.Status) {
+			ds.forgetLogsQueryId(logsQuery.QueryId)
+		}
+		if isTerminated(getQueryResultsResponse.Status) {
+			ds.runningLogs.remove(region, logsQuery.QueryId)
+		} else {
+			ds.runningLogs.polled(region, logsQuery.QueryId)
+		}
 	}
 	return getQueryResultsResponse, err
 }
@@ -332,6 +409,12 @@ func (ds *DataSource) handleGetQueryResults(ctx context.Context, logsClient mode
 	if err != nil {
 		return nil, err
 	}
+	extractMessageFields(dataFrame, logsQuery)
+	ds.maskLogsFrame(dataFrame)
+	formatPatternResults(dataFrame)
+	if logsQuery.Limit != nil {
+		setLogsLimitMeta(dataFrame, *logsQuery.Limit)
+	}
 
 	dataFrame.Name = refID
 	dataFrame.RefID = refID
@@ -339,6 +422,41 @@ func (ds *DataSource) handleGetQueryResults(ctx context.Context, logsClient mode
 	return dataFrame, nil
 }
 
+// logsInsightsLimit returns the number of log events a Logs Insights query with the limit returns at most: the limit,
+// capped by the maximum of the data source settings, which also applies to queries without one.
+func (ds *DataSource) logsInsightsLimit(limit *int32) int32 {
+	effective := maxLogsInsightsLimit
+	if maxLimit := ds.Settings.MaxLogsQueryLimit; maxLimit > 0 && maxLimit < int(effective) {
+		effective = int32(maxLimit)
+	}
+	if limit != nil && *limit > 0 && *limit < effective {
+		effective = *limit
+	}
+	return effective
+}
+
+// setLogsLimitMeta sets the effective limit of the query of a logs result in its meta, and warns when the result
+// reached it as there may be more log events matching the query.
+func setLogsLimitMeta(frame *data.Frame, limit int32) {
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	if frame.Meta.Custom == nil {
+		frame.Meta.Custom = map[string]any{}
+	}
+	custom, ok := frame.Meta.Custom.(map[string]any)
+	if !ok {
+		return
+	}
+	custom["Limit"] = limit
+	if frame.Rows() >= int(limit) && custom["Status"] == string(cloudwatchlogstypes.QueryStatusComplete) {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("The query returned its limit of %d log events, more of them may match it", limit),
+		})
+	}
+}
+
 func groupResponseFrame(frame *data.Frame, statsGroups []string) (data.Frames, error) {
 	var dataFrames data.Frames
 
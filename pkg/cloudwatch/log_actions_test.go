@@ -274,6 +274,7 @@ func TestQuery_StartQuery(t *testing.T) {
 		expFrame.Meta = &data.FrameMeta{
 			Custom: map[string]any{
 				"Region": "default",
+				"Limit":  int32(50),
 			},
 		}
 		assert.Equal(t, &backend.QueryDataResponse{Responses: backend.Responses{
@@ -545,6 +546,112 @@ func Test_executeStartQuery(t *testing.T) {
 		}, cli.calls.startQuery)
 	})
 
+	t.Run("runs the query definition of the query and its log groups", func(t *testing.T) {
+		cli = fakeCWLogsClient{queryDefinitions: []cloudwatchlogstypes.QueryDefinition{
+			{QueryDefinitionId: aws.String("other"), QueryString: aws.String("fields @timestamp")},
+			{
+				QueryDefinitionId: aws.String("errors"),
+				QueryString:       aws.String("filter @message like /ERROR/"),
+				LogGroupNames:     []string{"/aws/lambda/checkout"},
+			},
+		}}
+		ds := newTestDatasource()
+		_, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)},
+					JSON: json.RawMessage(`{
+						"type":    "logAction",
+						"subtype": "StartQuery",
+						"queryDefinitionId": "errors"
+					}`),
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*cloudwatchlogs.StartQueryInput{
+			{
+				StartTime:     aws.Int64(0),
+				EndTime:       aws.Int64(1),
+				QueryString:   aws.String("fields @timestamp,ltrim(@log) as __log__grafana_internal__,ltrim(@logStream) as __logstream__grafana_internal__|filter @message like /ERROR/"),
+				LogGroupNames: []string{"/aws/lambda/checkout"},
+				QueryLanguage: cloudwatchlogstypes.QueryLanguageCwli,
+			},
+		}, cli.calls.startQuery)
+	})
+
+	t.Run("fails queries of a query definition that doesn't exist", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newTestDatasource()
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)},
+					JSON:      json.RawMessage(`{"type":"logAction","subtype":"StartQuery","queryDefinitionId":"deleted"}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Contains(t, resp.Responses["A"].Error.Error(), `query definition "deleted" not found`)
+		assert.Empty(t, cli.calls.startQuery)
+	})
+
+	t.Run("interpolates the global variables of the query string", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newTestDatasource()
+		_, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)},
+					JSON: json.RawMessage(`{
+						"type":        "logAction",
+						"subtype":     "StartQuery",
+						"queryString": "stats count(*) by bin($__range)"
+					}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, cli.calls.startQuery, 1)
+		assert.Contains(t, aws.ToString(cli.calls.startQuery[0].QueryString), "stats count(*) by bin(3600s)")
+	})
+
+	t.Run("caps the limit by the maximum of the settings", func(t *testing.T) {
+		for _, tc := range []struct {
+			query    string
+			expected *int32
+		}{
+			{query: `{"type":"logAction","subtype":"StartQuery","queryString":"fields @message","limit":500}`, expected: aws.Int32(100)},
+			{query: `{"type":"logAction","subtype":"StartQuery","queryString":"fields @message","limit":20}`, expected: aws.Int32(20)},
+			{query: `{"type":"logAction","subtype":"StartQuery","queryString":"fields @message"}`, expected: aws.Int32(100)},
+		} {
+			cli = fakeCWLogsClient{}
+			ds := newTestDatasource(func(ds *DataSource) {
+				ds.Settings.MaxLogsQueryLimit = 100
+			})
+			_, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+				Queries: []backend.DataQuery{
+					{
+						RefID:     "A",
+						TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)},
+						JSON:      json.RawMessage(tc.query),
+					},
+				},
+			})
+			require.NoError(t, err)
+			require.Len(t, cli.calls.startQuery, 1)
+			assert.Equal(t, tc.expected, cli.calls.startQuery[0].Limit, tc.query)
+		}
+	})
+
 	t.Run("ignores logGroups if feature flag is disabled even if logGroupNames is not present", func(t *testing.T) {
 		cli = fakeCWLogsClient{}
 		ds := newTestDatasource()
@@ -762,11 +869,17 @@ func TestQuery_GetQueryResults(t *testing.T) {
 	expField2 := data.NewField("field_b", nil, []*string{
 		aws.String("b_1"), aws.String("b_2"),
 	})
-	expFrame := data.NewFrame(refID, expField1, expField2)
+	// the results don't identify the log stream of their events, so their pointer is kept for their log context
+	expField3 := data.NewField(logPtrInternal, nil, []*string{
+		aws.String("abcdefg"), aws.String("hijklmnop"),
+	})
+	expField3.SetConfig(&data.FieldConfig{Custom: map[string]any{"hidden": true}})
+	expFrame := data.NewFrame(refID, expField1, expField2, expField3)
 	expFrame.RefID = refID
 	expFrame.Meta = &data.FrameMeta{
 		Custom: map[string]any{
 			"Status": "Complete",
+			"stats":  logsQueryStats{RecordsScanned: 1024, RecordsMatched: 256, BytesScanned: 512},
 		},
 		Stats: []data.QueryStat{
 			{
@@ -793,6 +906,28 @@ func TestQuery_GetQueryResults(t *testing.T) {
 	}, resp)
 }
 
+func Test_setLogsLimitMeta(t *testing.T) {
+	newFrame := func(status string, rows int) *data.Frame {
+		frame := data.NewFrame("CloudWatchLogsResponse", data.NewField("@message", nil, make([]string, rows)))
+		frame.Meta = &data.FrameMeta{Custom: map[string]any{"Status": status}}
+		return frame
+	}
+
+	frame := newFrame("Complete", 2)
+	setLogsLimitMeta(frame, 2)
+	assert.Equal(t, int32(2), frame.Meta.Custom.(map[string]any)["Limit"])
+	require.Len(t, frame.Meta.Notices, 1)
+	assert.Equal(t, "The query returned its limit of 2 log events, more of them may match it", frame.Meta.Notices[0].Text)
+
+	frame = newFrame("Complete", 1)
+	setLogsLimitMeta(frame, 2)
+	assert.Empty(t, frame.Meta.Notices)
+
+	frame = newFrame("Running", 2)
+	setLogsLimitMeta(frame, 2)
+	assert.Empty(t, frame.Meta.Notices)
+}
+
 func TestGroupResponseFrame(t *testing.T) {
 	t.Run("Doesn't group results without time field", func(t *testing.T) {
 		frame := data.NewFrameOfFieldTypes("test", 0, data.FieldTypeString, data.FieldTypeInt32)
@@ -0,0 +1,74 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// defaultAutoBinMaxDataPoints is the number of bins aimed for when the query doesn't specify its max data points,
+// e.g. when it's executed by an alert rule
+const defaultAutoBinMaxDataPoints = 1000
+
+// autoBinPattern matches bin(auto) and bin() in Logs Insights queries
+var autoBinPattern = regexp.MustCompile(`(?i)\bbin\(\s*(auto)?\s*\)`)
+
+// autoBinSizes are the bin sizes bin(auto) resolves to, from finest to coarsest
+var autoBinSizes = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// resolveAutoBins replaces bin(auto) and bin() in the query string with the bin size suiting the panel the query is
+// executed for, so that stats by time are graphed at a resolution appropriate for the zoom level.
+func resolveAutoBins(queryString string, query backend.DataQuery) string {
+	if !autoBinPattern.MatchString(queryString) {
+		return queryString
+	}
+	return autoBinPattern.ReplaceAllString(queryString, fmt.Sprintf("bin(%s)", formatBinSize(autoBinSize(query))))
+}
+
+// autoBinSize returns the finest bin size that is at least the panel interval and doesn't produce more bins than the
+// panel's max data points.
+func autoBinSize(query backend.DataQuery) time.Duration {
+	maxDataPoints := query.MaxDataPoints
+	if maxDataPoints <= 0 {
+		maxDataPoints = defaultAutoBinMaxDataPoints
+	}
+	interval := max(query.Interval, query.TimeRange.Duration()/time.Duration(maxDataPoints))
+	for _, size := range autoBinSizes {
+		if size >= interval {
+			return size
+		}
+	}
+	return autoBinSizes[len(autoBinSizes)-1]
+}
+
+func formatBinSize(size time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case size%day == 0:
+		return fmt.Sprintf("%dd", size/day)
+	case size%time.Hour == 0:
+		return fmt.Sprintf("%dh", size/time.Hour)
+	case size%time.Minute == 0:
+		return fmt.Sprintf("%dm", size/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", size/time.Second)
+	}
+}
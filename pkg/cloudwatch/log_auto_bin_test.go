@@ -0,0 +1,93 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_resolveAutoBins(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		queryString string
+		query       backend.DataQuery
+		expected    string
+	}{
+		"bin(auto) resolves to the panel interval": {
+			queryString: "stats count(*) by bin(auto)",
+			query:       backend.DataQuery{Interval: time.Minute, MaxDataPoints: 1000, TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)}},
+			expected:    "stats count(*) by bin(1m)",
+		},
+		"empty bin resolves like bin(auto)": {
+			queryString: "stats count(*) by BIN( )",
+			query:       backend.DataQuery{Interval: 20 * time.Second, MaxDataPoints: 1000, TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)}},
+			expected:    "stats count(*) by bin(30s)",
+		},
+		"max data points limits the number of bins": {
+			queryString: "stats avg(@duration) by bin(auto), @logStream",
+			query:       backend.DataQuery{Interval: time.Second, MaxDataPoints: 100, TimeRange: backend.TimeRange{From: from, To: from.Add(7 * 24 * time.Hour)}},
+			expected:    "stats avg(@duration) by bin(3h), @logStream",
+		},
+		"without max data points a default number of bins is used": {
+			queryString: "stats count(*) by bin(auto)",
+			query:       backend.DataQuery{TimeRange: backend.TimeRange{From: from, To: from.Add(24 * time.Hour)}},
+			expected:    "stats count(*) by bin(5m)",
+		},
+		"very long ranges resolve to the coarsest bin size": {
+			queryString: "stats count(*) by bin(auto)",
+			query:       backend.DataQuery{MaxDataPoints: 10, TimeRange: backend.TimeRange{From: from, To: from.Add(365 * 24 * time.Hour)}},
+			expected:    "stats count(*) by bin(7d)",
+		},
+		"explicit bin sizes are kept": {
+			queryString: "stats count(*) by bin(5m)",
+			query:       backend.DataQuery{Interval: time.Hour, TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)}},
+			expected:    "stats count(*) by bin(5m)",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, resolveAutoBins(tc.queryString, tc.query))
+		})
+	}
+}
+
+func TestQuery_StartQueryResolvesAutoBins(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli fakeCWLogsClient
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return &cli
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{{
+			RefID:         "A",
+			Interval:      5 * time.Minute,
+			MaxDataPoints: 500,
+			TimeRange:     backend.TimeRange{From: from, To: from.Add(6 * time.Hour)},
+			JSON: json.RawMessage(`{
+				"type":        "logAction",
+				"subtype":     "StartQuery",
+				"queryString": "stats count(*) by bin(auto)",
+				"logGroupNames": ["group"]
+			}`),
+		}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, cli.calls.startQuery, 1)
+	assert.Equal(t, "fields @timestamp,ltrim(@log) as __log__grafana_internal__,ltrim(@logStream) as __logstream__grafana_internal__|stats count(*) by bin(5m)",
+		aws.ToString(cli.calls.startQuery[0].QueryString))
+}
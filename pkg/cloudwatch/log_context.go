@@ -0,0 +1,94 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// accountIdPrefix matches the account ID Logs Insights prefixes the @log field of events with
+var accountIdPrefix = regexp.MustCompile(`^\d{12}:`)
+
+// LogContextHandler returns the events surrounding an event of a log stream, before or after it, for the log context
+// of the Logs panel. The event is identified either by its log stream and timestamp or by its @ptr, which the results
+// of SQL and PPL queries only have.
+func (ds *DataSource) LogContextHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	request, err := resources.ParseLogContextRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in LogContextHandler", http.StatusBadRequest, err)
+	}
+
+	logsClient, err := ds.getCWLogsClient(ctx, request.Region)
+	if err != nil {
+		return nil, models.NewHttpError("newCWLogsClient error", http.StatusInternalServerError, err)
+	}
+
+	if request.LogGroupName == "" || request.LogStreamName == "" || request.Timestamp == 0 {
+		if request, err = resolveLogRecord(ctx, logsClient, request); err != nil {
+			return nil, newResourceHttpError("GetLogRecord error", err)
+		}
+	}
+
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(request.LogGroupName),
+		LogStreamName: aws.String(request.LogStreamName),
+		StartFromHead: aws.Bool(request.Forward),
+	}
+	if request.Forward {
+		input.StartTime = aws.Int64(request.Timestamp)
+	} else {
+		input.EndTime = aws.Int64(request.Timestamp)
+	}
+	events, err := getLogEventsPages(ctx, logsClient, input, request.Limit)
+	if err != nil {
+		return nil, newResourceHttpError("GetLogEvents error", err)
+	}
+
+	frame := logEventsFrame(events)
+	ds.maskLogsFrame(frame)
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, models.NewHttpError("LogContextHandler json error", http.StatusInternalServerError, err)
+	}
+	return frameJSON, nil
+}
+
+// resolveLogRecord sets the log group, log stream and timestamp of the event the pointer of the request points to.
+func resolveLogRecord(ctx context.Context, logsClient models.CWLogsClient, request resources.LogContextRequest) (resources.LogContextRequest, error) {
+	output, err := logsClient.GetLogRecord(ctx, &cloudwatchlogs.GetLogRecordInput{LogRecordPointer: aws.String(request.Ptr)})
+	if err != nil {
+		return request, backend.DownstreamError(err)
+	}
+	record := output.LogRecord
+
+	if request.LogGroupName == "" {
+		request.LogGroupName = accountIdPrefix.ReplaceAllString(record["@log"], "")
+	}
+	if request.LogStreamName == "" {
+		request.LogStreamName = record["@logStream"]
+	}
+	if request.Timestamp == 0 {
+		timestamp := record["@timestamp"]
+		if milliseconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+			request.Timestamp = milliseconds
+		} else if parsed, err := time.Parse(cloudWatchTSFormat, timestamp); err == nil {
+			request.Timestamp = parsed.UnixMilli()
+		}
+	}
+	if request.LogGroupName == "" || request.LogStreamName == "" || request.Timestamp == 0 {
+		return request, backend.DownstreamError(fmt.Errorf("the log record doesn't identify its log stream"))
+	}
+	return request, nil
+}
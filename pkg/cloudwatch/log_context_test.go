@@ -0,0 +1,74 @@
+package cloudwatch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestLogContextRoute(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli fakeCWLogsClient
+	NewCWLogsClient = func(aws.Config) models.CWLogsClient {
+		return &cli
+	}
+
+	t.Run("reads the events before the event from its log stream", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("GET",
+			"/log-context?region=us-east-1&logGroupName=/aws/lambda/checkout&logStreamName=stream&timestamp=1704067200000&limit=20", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"name":"logEvents"`)
+		require.Len(t, cli.calls.getEvents, 1)
+		assert.Equal(t, "/aws/lambda/checkout", aws.ToString(cli.calls.getEvents[0].LogGroupName))
+		assert.Equal(t, "stream", aws.ToString(cli.calls.getEvents[0].LogStreamName))
+		assert.Equal(t, int64(1704067200000), aws.ToInt64(cli.calls.getEvents[0].EndTime))
+		assert.Nil(t, cli.calls.getEvents[0].StartTime)
+		assert.False(t, aws.ToBool(cli.calls.getEvents[0].StartFromHead))
+		assert.Equal(t, int32(20), aws.ToInt32(cli.calls.getEvents[0].Limit))
+	})
+
+	t.Run("reads the events after the event of a pointer", func(t *testing.T) {
+		cli = fakeCWLogsClient{logRecords: map[string]map[string]string{
+			"ptr": {"@log": "123456789012:/aws/lambda/checkout", "@logStream": "stream", "@timestamp": "1704067200000"},
+		}}
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("GET",
+			"/log-context?region=us-east-1&ptr=ptr&direction=forward", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		require.Len(t, cli.calls.getEvents, 1)
+		assert.Equal(t, "/aws/lambda/checkout", aws.ToString(cli.calls.getEvents[0].LogGroupName))
+		assert.Equal(t, "stream", aws.ToString(cli.calls.getEvents[0].LogStreamName))
+		assert.Equal(t, int64(1704067200000), aws.ToInt64(cli.calls.getEvents[0].StartTime))
+		assert.True(t, aws.ToBool(cli.calls.getEvents[0].StartFromHead))
+		assert.Equal(t, int32(10), aws.ToInt32(cli.calls.getEvents[0].Limit))
+	})
+
+	t.Run("returns 400 for an invalid pointer", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("GET", "/log-context?region=us-east-1&ptr=unknown", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Empty(t, cli.calls.getEvents)
+	})
+
+	t.Run("returns 400 if the event isn't identified", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("GET", "/log-context?region=us-east-1&logGroupName=/aws/lambda/checkout", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,134 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// emfRecord is the metadata of a log line in the Embedded Metric Format. See
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+type emfRecord struct {
+	AWS *struct {
+		CloudWatchMetrics []struct {
+			Dimensions [][]string `json:"Dimensions"`
+			Metrics    []struct {
+				Name string `json:"Name"`
+				Unit string `json:"Unit"`
+			} `json:"Metrics"`
+		} `json:"CloudWatchMetrics"`
+	} `json:"_aws"`
+}
+
+// emfUnits maps the EMF units to the Grafana units closest to them
+var emfUnits = map[string]string{
+	"Seconds":          "s",
+	"Microseconds":     "µs",
+	"Milliseconds":     "ms",
+	"Bytes":            "decbytes",
+	"Kilobytes":        "deckbytes",
+	"Megabytes":        "decmbytes",
+	"Gigabytes":        "decgbytes",
+	"Bits":             "decbits",
+	"Percent":          "percent",
+	"Bytes/Second":     "Bps",
+	"Bits/Second":      "bps",
+	"Count/Second":     "cps",
+	"Kilobytes/Second": "KBs",
+	"Megabytes/Second": "MBs",
+}
+
+// addEMFFields adds the metrics declared by the Embedded Metric Format log lines of the frame as numeric fields, and
+// their dimensions as string fields, in the order they're first seen. Metrics with an array of values get the average
+// of the values. Names that already are fields of the frame are left alone.
+func addEMFFields(frame *data.Frame) {
+	messageField := messageField(frame)
+	if messageField == nil {
+		return
+	}
+
+	existing := map[string]bool{}
+	for _, field := range frame.Fields {
+		existing[field.Name] = true
+	}
+
+	rows := messageField.Len()
+	metrics := map[string][]*float64{}
+	units := map[string]string{}
+	dimensions := map[string][]*string{}
+	names := []string{}
+	for row := 0; row < rows; row++ {
+		line, ok := messageAt(messageField, row)
+		if !ok {
+			continue
+		}
+		var record emfRecord
+		var body map[string]any
+		if json.Unmarshal([]byte(line), &record) != nil || record.AWS == nil || json.Unmarshal([]byte(line), &body) != nil {
+			continue
+		}
+
+		for _, directive := range record.AWS.CloudWatchMetrics {
+			for _, metric := range directive.Metrics {
+				value, ok := emfMetricValue(body[metric.Name])
+				if !ok || existing[metric.Name] || dimensions[metric.Name] != nil {
+					continue
+				}
+				if _, ok := metrics[metric.Name]; !ok {
+					metrics[metric.Name] = make([]*float64, rows)
+					units[metric.Name] = emfUnits[metric.Unit]
+					names = append(names, metric.Name)
+				}
+				metrics[metric.Name][row] = &value
+			}
+			for _, dimensionSet := range directive.Dimensions {
+				for _, dimension := range dimensionSet {
+					value, ok := body[dimension].(string)
+					if !ok || existing[dimension] || metrics[dimension] != nil {
+						continue
+					}
+					if _, ok := dimensions[dimension]; !ok {
+						dimensions[dimension] = make([]*string, rows)
+						names = append(names, dimension)
+					}
+					dimensions[dimension][row] = &value
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		values, isMetric := metrics[name]
+		if !isMetric {
+			frame.Fields = append(frame.Fields, data.NewField(name, nil, dimensions[name]))
+			continue
+		}
+		field := data.NewField(name, nil, values)
+		if unit := units[name]; unit != "" {
+			field.SetConfig(&data.FieldConfig{Unit: unit})
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+}
+
+// emfMetricValue returns the value of an EMF metric, which is either a number or an array of numbers.
+func emfMetricValue(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case []any:
+		sum, count := 0.0, 0
+		for _, item := range typed {
+			if number, ok := item.(float64); ok {
+				sum += number
+				count++
+			}
+		}
+		if count == 0 {
+			return 0, false
+		}
+		return sum / float64(count), true
+	default:
+		return 0, false
+	}
+}
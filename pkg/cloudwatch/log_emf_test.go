@@ -0,0 +1,34 @@
+package cloudwatch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_extractMessageFields_emf(t *testing.T) {
+	frame := data.NewFrame("logs",
+		data.NewField("@message", nil, []*string{
+			aws.String(`{"_aws":{"Timestamp":1700000000000,"CloudWatchMetrics":[{"Namespace":"app","Dimensions":[["Service","Operation"]],"Metrics":[{"Name":"Latency","Unit":"Milliseconds"},{"Name":"Errors","Unit":"Count"}]}]},"Service":"api","Operation":"get","Latency":[10,20],"Errors":1}`),
+			aws.String(`{"level":"info","Latency":5}`),
+			aws.String(`{"_aws":{"CloudWatchMetrics":[{"Namespace":"app","Dimensions":[["Service"]],"Metrics":[{"Name":"Latency"}]}]},"Service":"worker","Latency":7}`),
+			aws.String(`not json`),
+		}),
+	)
+
+	extractMessageFields(frame, models.LogsQuery{CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{ParseEmf: aws.Bool(true)}})
+
+	latency := data.NewField("Latency", nil, []*float64{aws.Float64(15), nil, aws.Float64(7), nil})
+	latency.SetConfig(&data.FieldConfig{Unit: "ms"})
+	assert.Equal(t, []*data.Field{
+		latency,
+		data.NewField("Errors", nil, []*float64{aws.Float64(1), nil, nil, nil}),
+		data.NewField("Service", nil, []*string{aws.String("api"), nil, aws.String("worker"), nil}),
+		data.NewField("Operation", nil, []*string{aws.String("get"), nil, nil, nil}),
+	}, frame.Fields[1:])
+}
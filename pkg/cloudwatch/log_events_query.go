@@ -0,0 +1,116 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const (
+	defaultLogEventsQueryLimit = int32(1000)
+	// maxLogEventsPageSize is the most events GetLogEvents returns per call
+	maxLogEventsPageSize = int32(10000)
+)
+
+// executeLogEventsQueries reads the events of a single log stream with GetLogEvents rather than running a Logs
+// Insights query, which is cheaper and faster when only the latest, or earliest, lines of a stream are needed.
+func (ds *DataSource) executeLogEventsQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		var logsQuery models.LogsQuery
+		if err := json.Unmarshal(query.JSON, &logsQuery); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+
+		frame, err := ds.executeLogEventsQuery(ctx, query.TimeRange, logsQuery)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		extractMessageFields(frame, logsQuery)
+		ds.maskLogsFrame(frame)
+		frame.RefID = query.RefID
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+	}
+	return resp, nil
+}
+
+func (ds *DataSource) executeLogEventsQuery(ctx context.Context, timeRange backend.TimeRange, logsQuery models.LogsQuery) (*data.Frame, error) {
+	logGroups := len(logsQuery.LogGroups) + len(logsQuery.LogGroupNames)
+	if logGroups != 1 {
+		return nil, backend.DownstreamError(fmt.Errorf("log events are read from a single log stream, select exactly one log group instead of %d", logGroups))
+	}
+	if logsQuery.LogStreamName == "" {
+		return nil, backend.DownstreamError(fmt.Errorf("parameter 'logStreamName' is required"))
+	}
+
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogStreamName: aws.String(logsQuery.LogStreamName),
+		StartFromHead: aws.Bool(logsQuery.StartFromHead),
+		StartTime:     aws.Int64(timeRange.From.UnixMilli()),
+		EndTime:       aws.Int64(timeRange.To.UnixMilli()),
+	}
+	if len(logsQuery.LogGroups) > 0 {
+		// the log group picker stores ARNs ending with :*, which GetLogEvents doesn't accept
+		input.LogGroupIdentifier = aws.String(strings.TrimSuffix(logsQuery.LogGroups[0].Arn, ":*"))
+	} else {
+		input.LogGroupName = aws.String(logsQuery.LogGroupNames[0])
+	}
+
+	limit := defaultLogEventsQueryLimit
+	if logsQuery.Limit != nil && *logsQuery.Limit > 0 {
+		limit = *logsQuery.Limit
+	}
+
+	region := logsQuery.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	logsClient, err := ds.getCWLogsClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := getLogEventsPages(ctx, logsClient, input, limit)
+	if err != nil {
+		return nil, err
+	}
+	frame := logEventsFrame(events)
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeLogs}
+	return frame, nil
+}
+
+// getLogEventsPages pages through the events of a log stream, towards the end of the time range when reading from
+// its start and towards its start otherwise, until limit events have been read or the stream has no more events.
+func getLogEventsPages(ctx context.Context, logsClient models.CWLogsClient, input *cloudwatchlogs.GetLogEventsInput,
+	limit int32) ([]cloudwatchlogstypes.OutputLogEvent, error) {
+	events := []cloudwatchlogstypes.OutputLogEvent{}
+	for {
+		input.Limit = aws.Int32(min(limit-int32(len(events)), maxLogEventsPageSize))
+		output, err := logsClient.GetLogEvents(ctx, input)
+		if err != nil {
+			return nil, backend.DownstreamError(err)
+		}
+		events = append(events, output.Events...)
+
+		nextToken := output.NextBackwardToken
+		if aws.ToBool(input.StartFromHead) {
+			nextToken = output.NextForwardToken
+		}
+		// GetLogEvents returns the token it was called with once the end of the stream has been reached
+		if len(output.Events) == 0 || int32(len(events)) >= limit || nextToken == nil || aws.ToString(nextToken) == aws.ToString(input.NextToken) {
+			return events, nil
+		}
+		input.NextToken = nextToken
+	}
+}
@@ -0,0 +1,162 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
+)
+
+func logEvents(timestamps ...int64) []cloudwatchlogstypes.OutputLogEvent {
+	events := make([]cloudwatchlogstypes.OutputLogEvent, 0, len(timestamps))
+	for _, timestamp := range timestamps {
+		events = append(events, cloudwatchlogstypes.OutputLogEvent{Message: utils.Pointer("message"), Timestamp: utils.Pointer(timestamp)})
+	}
+	return events
+}
+
+func TestQuery_LogEventsMode(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli *mocks.MockLogEvents
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return cli
+	}
+	timeRange := backend.TimeRange{From: time.UnixMilli(1000), To: time.UnixMilli(2000)}
+
+	t.Run("reads the log stream of the selected log group", func(t *testing.T) {
+		cli = &mocks.MockLogEvents{}
+		cli.On("GetLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetLogEventsOutput{
+			Events:            logEvents(1100, 1200),
+			NextBackwardToken: utils.Pointer("b/1"),
+		}, nil).Once()
+		cli.On("GetLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetLogEventsOutput{
+			Events:            logEvents(),
+			NextBackwardToken: utils.Pointer("b/1"),
+		}, nil).Once()
+
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: timeRange,
+				JSON: json.RawMessage(`{
+					"queryMode": "Logs",
+					"logsMode": "Events",
+					"region": "us-east-1",
+					"logGroups": [{"arn": "arn:aws:logs:us-east-1:123456789012:log-group:group:*", "name": "group"}],
+					"logStreamName": "stream"
+				}`),
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		assert.Equal(t, "A", frames[0].RefID)
+		assert.Equal(t, data.VisTypeLogs, string(frames[0].Meta.PreferredVisualization))
+		assert.Equal(t, 2, frames[0].Rows())
+		assert.Equal(t, time.UnixMilli(1200).UTC(), frames[0].Fields[0].At(0))
+
+		input := cli.Calls[0].Arguments.Get(1).(*cloudwatchlogs.GetLogEventsInput)
+		assert.Equal(t, "arn:aws:logs:us-east-1:123456789012:log-group:group", aws.ToString(input.LogGroupIdentifier))
+		assert.Nil(t, input.LogGroupName)
+		assert.Equal(t, "stream", aws.ToString(input.LogStreamName))
+		assert.Equal(t, int64(1000), aws.ToInt64(input.StartTime))
+		assert.Equal(t, int64(2000), aws.ToInt64(input.EndTime))
+		assert.False(t, aws.ToBool(input.StartFromHead))
+	})
+
+	t.Run("requires a single log group", func(t *testing.T) {
+		cli = &mocks.MockLogEvents{}
+
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: timeRange,
+				JSON:      json.RawMessage(`{"queryMode": "Logs", "logsMode": "Events", "logGroupNames": ["a", "b"], "logStreamName": "stream"}`),
+			}},
+		})
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Contains(t, resp.Responses["A"].Error.Error(), "select exactly one log group instead of 2")
+		assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+		cli.AssertNotCalled(t, "GetLogEvents", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("requires a log stream", func(t *testing.T) {
+		cli = &mocks.MockLogEvents{}
+
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: timeRange,
+				JSON:      json.RawMessage(`{"queryMode": "Logs", "logsMode": "Events", "logGroupNames": ["a"]}`),
+			}},
+		})
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Contains(t, resp.Responses["A"].Error.Error(), "logStreamName")
+	})
+}
+
+func Test_getLogEventsPages(t *testing.T) {
+	t.Run("follows the forward token when reading from the start of the stream", func(t *testing.T) {
+		cli := &mocks.MockLogEvents{}
+		cli.On("GetLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetLogEventsOutput{
+			Events:            logEvents(1, 2),
+			NextForwardToken:  utils.Pointer("f/1"),
+			NextBackwardToken: utils.Pointer("b/1"),
+		}, nil).Once()
+		cli.On("GetLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetLogEventsOutput{
+			Events:           logEvents(3),
+			NextForwardToken: utils.Pointer("f/1"),
+		}, nil).Once()
+
+		input := &cloudwatchlogs.GetLogEventsInput{StartFromHead: aws.Bool(true)}
+		events, err := getLogEventsPages(context.Background(), cli, input, 10)
+		require.NoError(t, err)
+
+		assert.Len(t, events, 3)
+		cli.AssertNumberOfCalls(t, "GetLogEvents", 2)
+		assert.Equal(t, "f/1", aws.ToString(input.NextToken))
+	})
+
+	t.Run("stops once the limit is reached", func(t *testing.T) {
+		cli := &mocks.MockLogEvents{}
+		cli.On("GetLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetLogEventsOutput{
+			Events:            logEvents(1, 2),
+			NextBackwardToken: utils.Pointer("b/1"),
+		}, nil).Once()
+		cli.On("GetLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetLogEventsOutput{
+			Events:            logEvents(3),
+			NextBackwardToken: utils.Pointer("b/2"),
+		}, nil).Once()
+
+		input := &cloudwatchlogs.GetLogEventsInput{}
+		events, err := getLogEventsPages(context.Background(), cli, input, 3)
+		require.NoError(t, err)
+
+		assert.Len(t, events, 3)
+		cli.AssertNumberOfCalls(t, "GetLogEvents", 2)
+		assert.Equal(t, int32(1), aws.ToInt32(input.Limit))
+	})
+}
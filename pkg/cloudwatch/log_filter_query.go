@@ -0,0 +1,210 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// insightsQueryStart matches the commands a Logs Insights query starts with, which filter patterns don't
+var insightsQueryStart = regexp.MustCompile(`(?i)^\s*(fields|filter|stats|parse|display|dedup)\s`)
+
+// executeLogFilterQueries matches the events of the log groups against the filter pattern of the queries with
+// FilterLogEvents, which avoids the charges and startup latency of Logs Insights for basic searches. Queries whose
+// expression is a Logs Insights query rather than a filter pattern are executed with Logs Insights instead.
+func (ds *DataSource) executeLogFilterQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	insightsQueries := []backend.DataQuery{}
+	for _, query := range req.Queries {
+		var logsQuery models.LogsQuery
+		if err := json.Unmarshal(query.JSON, &logsQuery); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+		if isInsightsQuery(aws.ToString(logsQuery.Expression)) {
+			insightsQueries = append(insightsQueries, query)
+			continue
+		}
+
+		frame, err := ds.executeLogFilterQuery(ctx, query.TimeRange, logsQuery)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		extractMessageFields(frame, logsQuery)
+		ds.maskLogsFrame(frame)
+		frame.RefID = query.RefID
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+	}
+
+	if len(insightsQueries) == 0 {
+		return resp, nil
+	}
+	insightsResp, err := executeSyncLogQuery(ctx, ds, &backend.QueryDataRequest{
+		PluginContext: req.PluginContext,
+		Headers:       req.Headers,
+		Queries:       insightsQueries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for refId, response := range insightsResp.Responses {
+		for _, frame := range response.Frames {
+			if frame.Meta == nil {
+				frame.Meta = &data.FrameMeta{}
+			}
+			frame.Meta.Notices = append(frame.Meta.Notices, data.Notice{
+				Severity: data.NoticeSeverityInfo,
+				Text:     "The expression isn't a filter pattern, so it was executed as a Logs Insights query.",
+			})
+		}
+		resp.Responses[refId] = response
+	}
+	return resp, nil
+}
+
+// isInsightsQuery reports whether expression is a Logs Insights query rather than a filter pattern. Filter patterns
+// only contain a pipe inside a JSON or space-delimited pattern, where it's part of a || condition.
+func isInsightsQuery(expression string) bool {
+	if insightsQueryStart.MatchString(expression) {
+		return true
+	}
+	depth := 0
+	quoted := false
+	for i, r := range expression {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			depth--
+		case r == '|' && depth == 0:
+			if !strings.HasPrefix(expression[i:], "||") && (i == 0 || expression[i-1] != '|') {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ds *DataSource) executeLogFilterQuery(ctx context.Context, timeRange backend.TimeRange, logsQuery models.LogsQuery) (*data.Frame, error) {
+	type logGroup struct{ identifier, name string }
+	logGroups := make([]logGroup, 0, len(logsQuery.LogGroups)+len(logsQuery.LogGroupNames))
+	for _, group := range logsQuery.LogGroups {
+		// the log group picker stores ARNs ending with :*, which FilterLogEvents doesn't accept
+		logGroups = append(logGroups, logGroup{identifier: strings.TrimSuffix(group.Arn, ":*"), name: group.Name})
+	}
+	for _, name := range logsQuery.LogGroupNames {
+		logGroups = append(logGroups, logGroup{identifier: name, name: name})
+	}
+	if len(logGroups) == 0 {
+		return nil, backend.DownstreamError(fmt.Errorf("select at least one log group to filter the events of"))
+	}
+
+	limit := defaultLogEventsQueryLimit
+	if logsQuery.Limit != nil && *logsQuery.Limit > 0 {
+		limit = *logsQuery.Limit
+	}
+
+	region := logsQuery.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	logsClient, err := ds.getCWLogsClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// each log group is filtered separately, so that every event can be attributed to the log group it belongs to
+	var events []cloudwatchlogstypes.FilteredLogEvent
+	var groupNames []string
+	for _, group := range logGroups {
+		input := &cloudwatchlogs.FilterLogEventsInput{
+			LogGroupIdentifier: aws.String(group.identifier),
+			FilterPattern:      aws.String(strings.TrimSpace(aws.ToString(logsQuery.Expression))),
+			StartTime:          aws.Int64(timeRange.From.UnixMilli()),
+			EndTime:            aws.Int64(timeRange.To.UnixMilli()),
+		}
+		groupEvents, err := filterLogEventsPages(ctx, logsClient, input, limit)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, groupEvents...)
+		for range groupEvents {
+			groupNames = append(groupNames, group.name)
+		}
+	}
+
+	return logFilterFrame(events, groupNames, limit), nil
+}
+
+// filterLogEventsPages pages through the events matching the filter pattern, oldest first, until limit events have
+// been read or no more events match.
+func filterLogEventsPages(ctx context.Context, logsClient models.CWLogsClient, input *cloudwatchlogs.FilterLogEventsInput,
+	limit int32) ([]cloudwatchlogstypes.FilteredLogEvent, error) {
+	events := []cloudwatchlogstypes.FilteredLogEvent{}
+	for {
+		input.Limit = aws.Int32(min(limit-int32(len(events)), maxLogEventsPageSize))
+		output, err := logsClient.FilterLogEvents(ctx, input)
+		if err != nil {
+			return nil, backend.DownstreamError(err)
+		}
+		events = append(events, output.Events...)
+		if int32(len(events)) >= limit || output.NextToken == nil {
+			return events, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// logFilterFrame returns at most limit of the events as a frame, newest first. The fields are named like the ones of
+// Logs Insights results, so that the log context of a row can be shown.
+func logFilterFrame(events []cloudwatchlogstypes.FilteredLogEvent, groupNames []string, limit int32) *data.Frame {
+	order := make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return aws.ToInt64(events[order[i]].Timestamp) > aws.ToInt64(events[order[j]].Timestamp)
+	})
+	if len(order) > int(limit) {
+		order = order[:limit]
+	}
+
+	timestamps := make([]time.Time, 0, len(order))
+	messages := make([]*string, 0, len(order))
+	logStreams := make([]*string, 0, len(order))
+	logs := make([]*string, 0, len(order))
+	for _, i := range order {
+		timestamps = append(timestamps, time.UnixMilli(aws.ToInt64(events[i].Timestamp)).UTC())
+		messages = append(messages, events[i].Message)
+		logStreams = append(logStreams, events[i].LogStreamName)
+		logs = append(logs, aws.String(groupNames[i]))
+	}
+
+	timestampField := data.NewField("@timestamp", nil, timestamps)
+	timestampField.SetConfig(&data.FieldConfig{DisplayName: "Time"})
+	hidden := &data.FieldConfig{Custom: map[string]any{"hidden": true}}
+	frame := data.NewFrame("logEvents",
+		timestampField,
+		data.NewField("@message", nil, messages),
+		data.NewField(logStreamIdentifierInternal, nil, logStreams).SetConfig(hidden),
+		data.NewField(logIdentifierInternal, nil, logs).SetConfig(hidden),
+	)
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeLogs}
+	return frame
+}
@@ -0,0 +1,169 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
+)
+
+func filteredLogEvent(timestamp int64, logStream string) cloudwatchlogstypes.FilteredLogEvent {
+	return cloudwatchlogstypes.FilteredLogEvent{
+		Message:       utils.Pointer("message"),
+		Timestamp:     utils.Pointer(timestamp),
+		LogStreamName: utils.Pointer(logStream),
+	}
+}
+
+func filterQueryRequest(queryJSON string) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			TimeRange: backend.TimeRange{From: time.UnixMilli(1000), To: time.UnixMilli(2000)},
+			JSON:      json.RawMessage(queryJSON),
+		}},
+	}
+}
+
+func TestQuery_LogFilterMode(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli *mocks.MockLogEvents
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return cli
+	}
+
+	t.Run("filters the events of each log group", func(t *testing.T) {
+		cli = &mocks.MockLogEvents{}
+		cli.On("FilterLogEvents", mock.Anything, mock.MatchedBy(func(input *cloudwatchlogs.FilterLogEventsInput) bool {
+			return aws.ToString(input.LogGroupIdentifier) == "arn:aws:logs:us-east-1:123456789012:log-group:first"
+		}), mock.Anything).Return(&cloudwatchlogs.FilterLogEventsOutput{
+			Events: []cloudwatchlogstypes.FilteredLogEvent{filteredLogEvent(1100, "a"), filteredLogEvent(1300, "a")},
+		}, nil)
+		cli.On("FilterLogEvents", mock.Anything, mock.MatchedBy(func(input *cloudwatchlogs.FilterLogEventsInput) bool {
+			return aws.ToString(input.LogGroupIdentifier) == "second"
+		}), mock.Anything).Return(&cloudwatchlogs.FilterLogEventsOutput{
+			Events: []cloudwatchlogstypes.FilteredLogEvent{filteredLogEvent(1200, "b")},
+		}, nil)
+
+		resp, err := newTestDatasource().QueryData(context.Background(), filterQueryRequest(`{
+			"queryMode": "Logs",
+			"logsMode": "Filter",
+			"region": "us-east-1",
+			"expression": " { $.level = \"error\" || $.level = \"warn\" } ",
+			"logGroups": [{"arn": "arn:aws:logs:us-east-1:123456789012:log-group:first:*", "name": "first"}],
+			"logGroupNames": ["second"]
+		}`))
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		assert.Equal(t, "A", frames[0].RefID)
+		assert.Equal(t, data.VisTypeLogs, string(frames[0].Meta.PreferredVisualization))
+		require.Equal(t, 3, frames[0].Rows())
+		timestamps := frames[0].Fields[0]
+		assert.Equal(t, time.UnixMilli(1300).UTC(), timestamps.At(0))
+		assert.Equal(t, time.UnixMilli(1200).UTC(), timestamps.At(1))
+		assert.Equal(t, time.UnixMilli(1100).UTC(), timestamps.At(2))
+		logs, _ := frames[0].FieldByName(logIdentifierInternal)
+		assert.Equal(t, "second", *logs.At(1).(*string))
+		logStreams, _ := frames[0].FieldByName(logStreamIdentifierInternal)
+		assert.Equal(t, "a", *logStreams.At(0).(*string))
+
+		input := cli.Calls[0].Arguments.Get(1).(*cloudwatchlogs.FilterLogEventsInput)
+		assert.Equal(t, `{ $.level = "error" || $.level = "warn" }`, aws.ToString(input.FilterPattern))
+		assert.Equal(t, int64(1000), aws.ToInt64(input.StartTime))
+		assert.Equal(t, int64(2000), aws.ToInt64(input.EndTime))
+	})
+
+	t.Run("falls back to Logs Insights for Logs Insights queries", func(t *testing.T) {
+		cli = &mocks.MockLogEvents{}
+		var insightsRequest *backend.QueryDataRequest
+		stubExecuteSyncLogQuery(t, func(req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			insightsRequest = req
+			return &backend.QueryDataResponse{Responses: backend.Responses{
+				"A": {Frames: data.Frames{data.NewFrame("A")}},
+			}}, nil
+		})
+
+		resp, err := newTestDatasource().QueryData(context.Background(), filterQueryRequest(`{
+			"queryMode": "Logs",
+			"logsMode": "Filter",
+			"expression": "fields @message | filter @message like /error/",
+			"logGroupNames": ["first"]
+		}`))
+		require.NoError(t, err)
+
+		require.NotNil(t, insightsRequest)
+		assert.Len(t, insightsRequest.Queries, 1)
+		cli.AssertNotCalled(t, "FilterLogEvents", mock.Anything, mock.Anything, mock.Anything)
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Len(t, frames[0].Meta.Notices, 1)
+		assert.Contains(t, frames[0].Meta.Notices[0].Text, "executed as a Logs Insights query")
+	})
+
+	t.Run("requires a log group", func(t *testing.T) {
+		cli = &mocks.MockLogEvents{}
+
+		resp, err := newTestDatasource().QueryData(context.Background(), filterQueryRequest(`{"queryMode": "Logs", "logsMode": "Filter", "expression": "error"}`))
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+	})
+}
+
+func Test_isInsightsQuery(t *testing.T) {
+	for expression, expected := range map[string]bool{
+		"":                                       false,
+		"ERROR":                                  false,
+		`"connection refused" -timeout`:          false,
+		`{ $.status = 500 || $.status = 503 }`:   false,
+		`[ip, user, status = 4* || status = 5*]`: false,
+		`"a | b"`:                                false,
+		"fields @timestamp, @message":            true,
+		"FILTER @message like /error/":           true,
+		"error | limit 10":                       true,
+		"stats count(*) by bin(5m)":              true,
+	} {
+		assert.Equal(t, expected, isInsightsQuery(expression), expression)
+	}
+}
+
+func Test_filterLogEventsPages(t *testing.T) {
+	cli := &mocks.MockLogEvents{}
+	cli.On("FilterLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.FilterLogEventsOutput{
+		Events:    []cloudwatchlogstypes.FilteredLogEvent{filteredLogEvent(1, "a"), filteredLogEvent(2, "a")},
+		NextToken: utils.Pointer("next"),
+	}, nil).Once()
+	cli.On("FilterLogEvents", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.FilterLogEventsOutput{
+		Events:    []cloudwatchlogstypes.FilteredLogEvent{filteredLogEvent(3, "a")},
+		NextToken: utils.Pointer("last"),
+	}, nil).Once()
+
+	input := &cloudwatchlogs.FilterLogEventsInput{}
+	events, err := filterLogEventsPages(context.Background(), cli, input, 3)
+	require.NoError(t, err)
+
+	assert.Len(t, events, 3)
+	cli.AssertNumberOfCalls(t, "FilterLogEvents", 2)
+	assert.Equal(t, "next", aws.ToString(input.NextToken))
+	assert.Equal(t, int32(1), aws.ToInt32(input.Limit))
+}
@@ -0,0 +1,233 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const (
+	// liveTailPathPrefix is the stream path prefix used to push the events of a Live Tail session.
+	// The full path has the format logs-tail/<key>
+	liveTailPathPrefix = "logs-tail/"
+
+	// liveTailRegistration is how long a Live Tail query stays registered after it was last queried
+	liveTailRegistration = time.Hour
+	// maxLiveTailLogGroups is the number of log groups a Live Tail session can tail
+	maxLiveTailLogGroups = 10
+)
+
+type liveTailQuery struct {
+	query     backend.DataQuery
+	logsQuery models.LogsQuery
+}
+
+// executeLiveTailQueries registers the Live Tail queries of the request, and returns an empty logs frame per query
+// with the live channel of the query set, so that the panel subscribes to the events of the log groups as they
+// arrive rather than reading the events of the time range.
+func (ds *DataSource) executeLiveTailQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	ctx = backend.WithPluginContext(ctx, req.PluginContext)
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		var logsQuery models.LogsQuery
+		if err := json.Unmarshal(query.JSON, &logsQuery); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+		if _, err := liveTailInput(logsQuery); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+
+		key, err := liveTailQueryKey(ctx, query, ds.Settings.UserIdentityPassThrough)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		channel := liveTailChannel(ctx, key)
+		if channel == "" || ds.liveTails == nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(fmt.Errorf("live tail can't be streamed without a data source uid"))
+			continue
+		}
+
+		ds.liveTails.Set(key, liveTailQuery{query: query, logsQuery: logsQuery}, liveTailRegistration)
+		frame := liveTailFrame(nil)
+		frame.RefID = query.RefID
+		frame.Meta.Channel = channel
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+	}
+	return resp, nil
+}
+
+// liveTailInput returns the StartLiveTail input of the log groups, filter pattern and log stream of the query.
+func liveTailInput(logsQuery models.LogsQuery) (*cloudwatchlogs.StartLiveTailInput, error) {
+	input := &cloudwatchlogs.StartLiveTailInput{}
+	for _, group := range logsQuery.LogGroups {
+		// the log group picker stores ARNs ending with :*, which StartLiveTail doesn't accept
+		input.LogGroupIdentifiers = append(input.LogGroupIdentifiers, strings.TrimSuffix(group.Arn, ":*"))
+	}
+	if len(input.LogGroupIdentifiers) == 0 {
+		return nil, backend.DownstreamError(fmt.Errorf("select at least one log group to tail"))
+	}
+	if len(input.LogGroupIdentifiers) > maxLiveTailLogGroups {
+		return nil, backend.DownstreamError(fmt.Errorf("live tail can tail at most %d log groups, %d are selected",
+			maxLiveTailLogGroups, len(input.LogGroupIdentifiers)))
+	}
+	if pattern := strings.TrimSpace(aws.ToString(logsQuery.Expression)); pattern != "" {
+		input.LogEventFilterPattern = aws.String(pattern)
+	}
+	if logsQuery.LogStreamName != "" {
+		if len(input.LogGroupIdentifiers) > 1 {
+			return nil, backend.DownstreamError(fmt.Errorf("a log stream can only be tailed in a single log group"))
+		}
+		input.LogStreamNames = []string{logsQuery.LogStreamName}
+	}
+	return input, nil
+}
+
+// liveTailQueryKey identifies a Live Tail query regardless of its time range.
+func liveTailQueryKey(ctx context.Context, query backend.DataQuery, perUser bool) (string, error) {
+	query.TimeRange = backend.TimeRange{}
+	return queryCacheKey(ctx, query, perUser)
+}
+
+// subscribeLiveTail reports whether the subscriber may receive the stream of the Live Tail query at path, which like
+// for live metrics queries requires the key of the query derived from the subscriber's org and user to match.
+func (ds *DataSource) subscribeLiveTail(ctx context.Context, pCtx backend.PluginContext, path string) bool {
+	if ds.liveTails == nil {
+		return false
+	}
+	key := strings.TrimPrefix(path, liveTailPathPrefix)
+	registered, found := ds.liveTails.Get(key)
+	if !found {
+		return false
+	}
+	subscriberKey, err := liveTailQueryKey(backend.WithPluginContext(ctx, pCtx), registered.(liveTailQuery).query, ds.Settings.UserIdentityPassThrough)
+	return err == nil && subscriberKey == key
+}
+
+// streamLiveTail starts a Live Tail session for the query registered under key, and sends the events of every
+// update of the session as they arrive. It returns once the subscription is closed or the session ends, which
+// CloudWatch does after three hours.
+func (ds *DataSource) streamLiveTail(ctx context.Context, key string, sender *backend.StreamSender) error {
+	if ds.liveTails == nil {
+		return fmt.Errorf("live tail query %s is not registered", key)
+	}
+	registered, found := ds.liveTails.Get(key)
+	if !found {
+		return fmt.Errorf("live tail query %s is not registered", key)
+	}
+	logsQuery := registered.(liveTailQuery).logsQuery
+	ctx, err := ds.withRoleOfQuery(ctx, registered.(liveTailQuery).query)
+	if err != nil {
+		return err
+	}
+
+	input, err := liveTailInput(logsQuery)
+	if err != nil {
+		return err
+	}
+	region := logsQuery.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	client, err := ds.getLogsLiveTailClient(ctx, region)
+	if err != nil {
+		return err
+	}
+	stream, err := client.StartLiveTail(ctx, input)
+	if err != nil {
+		return backend.DownstreamError(err)
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			ds.logger.FromContext(ctx).Warn("Failed to close the live tail session", "error", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-stream.Events():
+			if !ok {
+				return stream.Err()
+			}
+			update, ok := event.(*cloudwatchlogstypes.StartLiveTailResponseStreamMemberSessionUpdate)
+			if !ok || len(update.Value.SessionResults) == 0 {
+				continue
+			}
+			frame := liveTailFrame(update.Value.SessionResults)
+			ds.maskLogsFrame(frame)
+			if update.Value.SessionMetadata != nil && update.Value.SessionMetadata.Sampled {
+				frame.AppendNotices(data.Notice{
+					Severity: data.NoticeSeverityInfo,
+					Text:     "Live tail is only showing a sample of the events, as more than 500 events per second match.",
+				})
+			}
+			if err := sender.SendFrame(frame, data.IncludeAll); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// liveTailFrame returns the events of a Live Tail update as a logs frame with the fields of the frames of filter
+// queries, so that the log context of a row can be shown.
+func liveTailFrame(events []cloudwatchlogstypes.LiveTailSessionLogEvent) *data.Frame {
+	timestamps := make([]time.Time, 0, len(events))
+	messages := make([]*string, 0, len(events))
+	logStreams := make([]*string, 0, len(events))
+	logs := make([]*string, 0, len(events))
+	for _, event := range events {
+		timestamps = append(timestamps, time.UnixMilli(aws.ToInt64(event.Timestamp)).UTC())
+		messages = append(messages, event.Message)
+		logStreams = append(logStreams, event.LogStreamName)
+		logs = append(logs, aws.String(liveTailLogGroupName(aws.ToString(event.LogGroupIdentifier))))
+	}
+
+	timestampField := data.NewField("@timestamp", nil, timestamps)
+	timestampField.SetConfig(&data.FieldConfig{DisplayName: "Time"})
+	hidden := &data.FieldConfig{Custom: map[string]any{"hidden": true}}
+	frame := data.NewFrame("liveTail",
+		timestampField,
+		data.NewField("@message", nil, messages),
+		data.NewField(logStreamIdentifierInternal, nil, logStreams).SetConfig(hidden),
+		data.NewField(logIdentifierInternal, nil, logs).SetConfig(hidden),
+	)
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeLogs}
+	return frame
+}
+
+// liveTailLogGroupName returns the name of the log group of a Live Tail event, which is identified by its ARN.
+func liveTailLogGroupName(identifier string) string {
+	if _, name, found := strings.Cut(identifier, ":log-group:"); found {
+		return name
+	}
+	return identifier
+}
+
+// liveTailChannel returns the live channel that streams the Live Tail query with the given key, or an empty string
+// if the datasource uid is not known.
+func liveTailChannel(ctx context.Context, key string) string {
+	pCtx := backend.PluginConfigFromContext(ctx)
+	if pCtx.DataSourceInstanceSettings == nil || pCtx.DataSourceInstanceSettings.UID == "" {
+		return ""
+	}
+	return live.Channel{
+		Scope:     live.ScopeDatasource,
+		Namespace: pCtx.DataSourceInstanceSettings.UID,
+		Path:      liveTailPathPrefix + key,
+	}.String()
+}
@@ -0,0 +1,155 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type fakeLiveTailStream struct {
+	events chan cloudwatchlogstypes.StartLiveTailResponseStream
+	closed bool
+}
+
+func (s *fakeLiveTailStream) Events() <-chan cloudwatchlogstypes.StartLiveTailResponseStream {
+	return s.events
+}
+
+func (s *fakeLiveTailStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *fakeLiveTailStream) Err() error {
+	return nil
+}
+
+type fakeLogsLiveTailClient struct {
+	stream *fakeLiveTailStream
+	inputs []*cloudwatchlogs.StartLiveTailInput
+}
+
+func (c *fakeLogsLiveTailClient) StartLiveTail(_ context.Context, input *cloudwatchlogs.StartLiveTailInput) (cloudwatchlogs.StartLiveTailResponseStreamReader, error) {
+	c.inputs = append(c.inputs, input)
+	return c.stream, nil
+}
+
+func Test_liveTailInput(t *testing.T) {
+	logGroup := func(name string) dataquery.LogGroup {
+		return dataquery.LogGroup{Arn: "arn:aws:logs:us-east-1:123456789012:log-group:" + name + ":*", Name: name}
+	}
+
+	input, err := liveTailInput(models.LogsQuery{
+		CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{LogGroups: []dataquery.LogGroup{logGroup("api")}, Expression: aws.String(" ERROR ")},
+		LogStreamName:       "i-0abc",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &cloudwatchlogs.StartLiveTailInput{
+		LogGroupIdentifiers:   []string{"arn:aws:logs:us-east-1:123456789012:log-group:api"},
+		LogEventFilterPattern: aws.String("ERROR"),
+		LogStreamNames:        []string{"i-0abc"},
+	}, input)
+
+	_, err = liveTailInput(models.LogsQuery{})
+	assert.EqualError(t, err, "select at least one log group to tail")
+
+	tooMany := models.LogsQuery{}
+	for i := 0; i <= maxLiveTailLogGroups; i++ {
+		tooMany.LogGroups = append(tooMany.LogGroups, logGroup("group"))
+	}
+	_, err = liveTailInput(tooMany)
+	assert.EqualError(t, err, "live tail can tail at most 10 log groups, 11 are selected")
+
+	_, err = liveTailInput(models.LogsQuery{
+		CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{LogGroups: []dataquery.LogGroup{logGroup("api"), logGroup("worker")}},
+		LogStreamName:       "i-0abc",
+	})
+	assert.EqualError(t, err, "a log stream can only be tailed in a single log group")
+}
+
+func TestLiveTail(t *testing.T) {
+	origNewLogsLiveTailClient := NewLogsLiveTailClient
+	t.Cleanup(func() {
+		NewLogsLiveTailClient = origNewLogsLiveTailClient
+	})
+	client := &fakeLogsLiveTailClient{stream: &fakeLiveTailStream{events: make(chan cloudwatchlogstypes.StartLiveTailResponseStream, 3)}}
+	NewLogsLiveTailClient = func(aws.Config) models.LogsLiveTailProvider {
+		return client
+	}
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.liveTails = cache.New(liveTailRegistration, 0)
+	})
+	pluginContext := backend.PluginContext{OrgID: 1, DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "cw"}}
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: pluginContext,
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+			JSON: json.RawMessage(`{"queryMode":"Logs","logsMode":"LiveTail","region":"us-east-1","expression":"ERROR",
+				"logGroups":[{"arn":"arn:aws:logs:us-east-1:123456789012:log-group:api:*","name":"api"}]}`),
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+	assert.Equal(t, 0, resp.Responses["A"].Frames[0].Rows())
+	channel := resp.Responses["A"].Frames[0].Meta.Channel
+	require.True(t, strings.HasPrefix(channel, "ds/cw/"+liveTailPathPrefix), channel)
+	path := strings.TrimPrefix(channel, "ds/cw/")
+
+	subscribe, err := ds.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pluginContext, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusOK, subscribe.Status)
+
+	otherOrg := pluginContext
+	otherOrg.OrgID = 2
+	subscribe, err = ds.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: otherOrg, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe.Status, "other orgs can't subscribe")
+
+	now := time.Now().Truncate(time.Millisecond).UTC()
+	client.stream.events <- &cloudwatchlogstypes.StartLiveTailResponseStreamMemberSessionStart{}
+	client.stream.events <- &cloudwatchlogstypes.StartLiveTailResponseStreamMemberSessionUpdate{Value: cloudwatchlogstypes.LiveTailSessionUpdate{
+		SessionMetadata: &cloudwatchlogstypes.LiveTailSessionMetadata{Sampled: true},
+		SessionResults: []cloudwatchlogstypes.LiveTailSessionLogEvent{{
+			LogGroupIdentifier: aws.String("arn:aws:logs:us-east-1:123456789012:log-group:api"),
+			LogStreamName:      aws.String("i-0abc"),
+			Message:            aws.String("ERROR timeout"),
+			Timestamp:          aws.Int64(now.UnixMilli()),
+		}},
+	}}
+	close(client.stream.events)
+
+	packetSender := &fakeStreamPacketSender{}
+	err = ds.RunStream(context.Background(), &backend.RunStreamRequest{PluginContext: pluginContext, Path: path}, backend.NewStreamSender(packetSender))
+	require.NoError(t, err)
+	assert.True(t, client.stream.closed)
+	require.Len(t, client.inputs, 1)
+	assert.Equal(t, []string{"arn:aws:logs:us-east-1:123456789012:log-group:api"}, client.inputs[0].LogGroupIdentifiers)
+	assert.Equal(t, "ERROR", *client.inputs[0].LogEventFilterPattern)
+
+	require.Len(t, packetSender.packets, 1)
+	var frame data.Frame
+	require.NoError(t, json.Unmarshal(packetSender.packets[0].Data, &frame))
+	require.Equal(t, 1, frame.Rows())
+	assert.Equal(t, now, frame.Fields[0].At(0))
+	assert.Equal(t, "ERROR timeout", *frame.Fields[1].At(0).(*string))
+	assert.Equal(t, "i-0abc", *frame.Fields[2].At(0).(*string))
+	assert.Equal(t, "api", *frame.Fields[3].At(0).(*string))
+	require.Len(t, frame.Meta.Notices, 1)
+}
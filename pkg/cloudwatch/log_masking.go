@@ -0,0 +1,76 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const defaultMaskReplacement = "****"
+
+// maskingRule is a compiled models.MaskingRule
+type maskingRule struct {
+	fields      *regexp.Regexp
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func compileMaskingRules(rules []models.MaskingRule) ([]maskingRule, error) {
+	compiled := make([]maskingRule, 0, len(rules))
+	for i, rule := range rules {
+		fields, err := regexp.Compile(rule.Fields)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("masking rule %d: invalid fields expression: %w", i+1, err))
+		}
+		pattern := rule.Pattern
+		if pattern == "" {
+			pattern = `(?s)^.*$`
+		}
+		patternRegexp, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("masking rule %d: invalid pattern: %w", i+1, err))
+		}
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = defaultMaskReplacement
+		}
+		compiled = append(compiled, maskingRule{fields: fields, pattern: patternRegexp, replacement: replacement})
+	}
+	return compiled, nil
+}
+
+// maskLogsFrame applies the masking rules of the data source to the string fields of a logs frame. The hidden fields
+// identifying the log group and stream of each row are left alone, as they're needed to retrieve the row's context.
+func (ds *DataSource) maskLogsFrame(frame *data.Frame) {
+	if frame == nil || len(ds.maskingRules) == 0 {
+		return
+	}
+	for _, field := range frame.Fields {
+		if field.Name == logIdentifierInternal || field.Name == logStreamIdentifierInternal {
+			continue
+		}
+		for _, rule := range ds.maskingRules {
+			if rule.fields.MatchString(field.Name) {
+				maskField(field, rule)
+			}
+		}
+	}
+}
+
+func maskField(field *data.Field, rule maskingRule) {
+	for i := 0; i < field.Len(); i++ {
+		switch value := field.At(i).(type) {
+		case string:
+			field.Set(i, rule.pattern.ReplaceAllString(value, rule.replacement))
+		case *string:
+			if value != nil {
+				masked := rule.pattern.ReplaceAllString(*value, rule.replacement)
+				field.Set(i, &masked)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_compileMaskingRules(t *testing.T) {
+	_, err := compileMaskingRules([]models.MaskingRule{{Fields: "("}})
+	assert.ErrorContains(t, err, "masking rule 1: invalid fields expression")
+
+	_, err = compileMaskingRules([]models.MaskingRule{{Pattern: "email"}, {Pattern: "[a-"}})
+	assert.ErrorContains(t, err, "masking rule 2: invalid pattern")
+}
+
+func Test_maskLogsFrame(t *testing.T) {
+	rules, err := compileMaskingRules([]models.MaskingRule{
+		{Pattern: `[\w.+-]+@[\w-]+\.[\w.]+`, Replacement: "<email>"},
+		{Fields: `^(token|password)$`},
+		{Fields: `^@message$`, Pattern: `(Bearer )\S+`, Replacement: "${1}****"},
+	})
+	require.NoError(t, err)
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.maskingRules = rules
+	})
+
+	frame := data.NewFrame("logs",
+		data.NewField("@message", nil, []*string{aws.String("login by jane.doe@example.com with Bearer abc.def"), nil}),
+		data.NewField("token", nil, []string{"secret", ""}),
+		data.NewField("status", nil, []*float64{aws.Float64(200), nil}),
+		data.NewField(logIdentifierInternal, nil, []*string{aws.String("group@example.com"), aws.String("group")}),
+	)
+	ds.maskLogsFrame(frame)
+
+	assert.Equal(t, []*string{aws.String("login by <email> with Bearer ****"), nil}, fieldValues[*string](frame.Fields[0]))
+	assert.Equal(t, []string{"****", "****"}, fieldValues[string](frame.Fields[1]))
+	assert.Equal(t, []*float64{aws.Float64(200), nil}, fieldValues[*float64](frame.Fields[2]))
+	assert.Equal(t, []*string{aws.String("group@example.com"), aws.String("group")}, fieldValues[*string](frame.Fields[3]))
+}
+
+func fieldValues[T any](field *data.Field) []T {
+	values := make([]T, field.Len())
+	for i := range values {
+		values[i] = field.At(i).(T)
+	}
+	return values
+}
+
+func TestQuery_GetQueryResultsMasksFields(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	cli := fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{
+		Status: cloudwatchlogstypes.QueryStatusComplete,
+		Results: [][]cloudwatchlogstypes.ResultField{{
+			{Field: aws.String("@timestamp"), Value: aws.String("2024-01-01 00:00:00.000")},
+			{Field: aws.String("email"), Value: aws.String("jane.doe@example.com")},
+		}},
+	}}
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return &cli
+	}
+	rules, err := compileMaskingRules([]models.MaskingRule{{Fields: "email"}})
+	require.NoError(t, err)
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.maskingRules = rules
+	})
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)},
+			JSON:      json.RawMessage(`{"type": "logAction", "subtype": "GetQueryResults", "queryId": "abcd"}`),
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+
+	field, _ := resp.Responses["A"].Frames[0].FieldByName("email")
+	require.NotNil(t, field)
+	assert.Equal(t, "****", *field.At(0).(*string))
+}
@@ -0,0 +1,280 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// messageFieldNames are the names of the field holding the log line in the frames of the different logs modes
+var messageFieldNames = []string{"@message", "line"}
+
+// extractMessageFields adds the fields the parsers enabled on the query extract from the log lines of the frame, so
+// that they can be filtered on in Explore without repeating the parsing in every query.
+func extractMessageFields(frame *data.Frame, logsQuery models.LogsQuery) {
+	if frame == nil {
+		return
+	}
+	if logsQuery.ParseLogfmt != nil && *logsQuery.ParseLogfmt {
+		addDetectedFields(frame, parseLogfmt)
+	}
+	if len(logsQuery.JsonPaths) > 0 {
+		addJSONPathFields(frame, logsQuery.JsonPaths)
+	}
+	if logsQuery.ParseEmf != nil && *logsQuery.ParseEmf {
+		addEMFFields(frame)
+	}
+}
+
+// messageField returns the field of the frame holding the log lines, or nil if it has none.
+func messageField(frame *data.Frame) *data.Field {
+	for _, field := range frame.Fields {
+		if slices.Contains(messageFieldNames, field.Name) {
+			return field
+		}
+	}
+	return nil
+}
+
+// messageAt returns the log line of the row, if it has one.
+func messageAt(field *data.Field, row int) (string, bool) {
+	line, ok := field.ConcreteAt(row)
+	if !ok {
+		return "", false
+	}
+	lineString, ok := line.(string)
+	return lineString, ok
+}
+
+// addDetectedFields adds a string field to the frame for every key parse returns for one of its log lines, in the
+// order the keys are first seen. Keys that already are fields of the frame are left alone.
+func addDetectedFields(frame *data.Frame, parse func(line string) [][2]string) {
+	messageField := messageField(frame)
+	if messageField == nil {
+		return
+	}
+
+	existing := map[string]bool{}
+	for _, field := range frame.Fields {
+		existing[field.Name] = true
+	}
+
+	detected := map[string][]*string{}
+	keys := []string{}
+	for row := 0; row < messageField.Len(); row++ {
+		line, ok := messageAt(messageField, row)
+		if !ok {
+			continue
+		}
+		for _, pair := range parse(line) {
+			key, value := pair[0], pair[1]
+			if existing[key] {
+				continue
+			}
+			if _, ok := detected[key]; !ok {
+				detected[key] = make([]*string, messageField.Len())
+				keys = append(keys, key)
+			}
+			detected[key][row] = &value
+		}
+	}
+
+	for _, key := range keys {
+		frame.Fields = append(frame.Fields, data.NewField(key, nil, detected[key]))
+	}
+}
+
+// parseLogfmt returns the key=value pairs of a logfmt line. Values may be double quoted, with backslash escapes. Words
+// that aren't followed by an equals sign are skipped, as they usually are free text rather than logfmt flags.
+func parseLogfmt(line string) [][2]string {
+	pairs := [][2]string{}
+	i := 0
+	for i < len(line) {
+		// skip to the start of the next word
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '"' {
+			i++
+		}
+		key := line[start:i]
+		if i >= len(line) || line[i] != '=' || !isLogfmtKey(key) {
+			// not a key, skip the rest of the word, including any quoted part of it
+			i = skipLogfmtWord(line, i)
+			continue
+		}
+		i++ // the equals sign
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			value, i = readLogfmtQuoted(line, i)
+		} else {
+			start := i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = line[start:i]
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs
+}
+
+func isLogfmtKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && (i == 0 || (!isDigit && r != '.' && r != '-')) {
+			return false
+		}
+	}
+	return true
+}
+
+func skipLogfmtWord(line string, i int) int {
+	for i < len(line) && line[i] != ' ' {
+		if line[i] == '"' {
+			_, i = readLogfmtQuoted(line, i)
+			continue
+		}
+		i++
+	}
+	return i
+}
+
+// readLogfmtQuoted reads the quoted string starting at i, returning it unescaped along with the index following it.
+func readLogfmtQuoted(line string, i int) (string, int) {
+	var value strings.Builder
+	i++ // the opening quote
+	for i < len(line) {
+		switch line[i] {
+		case '\\':
+			if i+1 < len(line) {
+				value.WriteByte(line[i+1])
+				i += 2
+				continue
+			}
+		case '"':
+			return value.String(), i + 1
+		}
+		value.WriteByte(line[i])
+		i++
+	}
+	return value.String(), i
+}
+
+// addJSONPathFields adds a field to the frame for each of the dot separated paths, holding the value found at the path
+// in the JSON log lines of the frame. Fields whose values are all numbers or all booleans are typed accordingly, other
+// fields hold the values as strings, with objects and arrays in JSON. Paths that already are fields of the frame are
+// left alone.
+func addJSONPathFields(frame *data.Frame, paths []string) {
+	messageField := messageField(frame)
+	if messageField == nil {
+		return
+	}
+
+	rows := messageField.Len()
+	values := make([][]any, len(paths))
+	for i := range values {
+		values[i] = make([]any, rows)
+	}
+	for row := 0; row < rows; row++ {
+		line, ok := messageAt(messageField, row)
+		if !ok {
+			continue
+		}
+		var body any
+		if err := json.Unmarshal([]byte(line), &body); err != nil {
+			// not every line of a log group is necessarily JSON
+			continue
+		}
+		for i, path := range paths {
+			values[i][row] = jsonPathValue(body, path)
+		}
+	}
+
+	existing := map[string]bool{}
+	for _, field := range frame.Fields {
+		existing[field.Name] = true
+	}
+	for i, path := range paths {
+		if existing[path] {
+			continue
+		}
+		existing[path] = true
+		frame.Fields = append(frame.Fields, data.NewField(path, nil, typedJSONValues(values[i])))
+	}
+}
+
+// jsonPathValue returns the value at the dot separated path of the JSON value, indexing arrays by number, or nil if
+// there is none.
+func jsonPathValue(value any, path string) any {
+	for _, key := range strings.Split(path, ".") {
+		switch typed := value.(type) {
+		case map[string]any:
+			value = typed[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil
+			}
+			value = typed[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// typedJSONValues returns the values as numbers or booleans if all of them that aren't null are, and as strings
+// otherwise.
+func typedJSONValues(values []any) any {
+	numbers := make([]*float64, len(values))
+	booleans := make([]*bool, len(values))
+	strs := make([]*string, len(values))
+	allNumbers, allBooleans := true, true
+	for i, value := range values {
+		switch typed := value.(type) {
+		case nil:
+			continue
+		case float64:
+			numbers[i] = &typed
+			allBooleans = false
+		case bool:
+			booleans[i] = &typed
+			allNumbers = false
+		default:
+			allNumbers, allBooleans = false, false
+		}
+		str := jsonValueString(value)
+		strs[i] = &str
+	}
+	switch {
+	case allNumbers:
+		return numbers
+	case allBooleans:
+		return booleans
+	default:
+		return strs
+	}
+}
+
+func jsonValueString(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
@@ -0,0 +1,104 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_parseLogfmt(t *testing.T) {
+	testCases := map[string]struct {
+		line     string
+		expected [][2]string
+	}{
+		"plain pairs": {
+			line:     "level=info msg=started duration_ms=12",
+			expected: [][2]string{{"level", "info"}, {"msg", "started"}, {"duration_ms", "12"}},
+		},
+		"quoted values with escapes": {
+			line:     `level=error msg="request \"GET /\" failed" path=/api`,
+			expected: [][2]string{{"level", "error"}, {"msg", `request "GET /" failed`}, {"path", "/api"}},
+		},
+		"free text is skipped": {
+			line:     `2024-01-01T00:00:00Z INFO handled "quoted text" user.id=42 = trace-id=abc`,
+			expected: [][2]string{{"user.id", "42"}, {"trace-id", "abc"}},
+		},
+		"empty values": {
+			line:     `a= b="" c=1`,
+			expected: [][2]string{{"a", ""}, {"b", ""}, {"c", "1"}},
+		},
+		"not logfmt": {
+			line:     `{"level":"info"}`,
+			expected: [][2]string{},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseLogfmt(tc.line))
+		})
+	}
+}
+
+func Test_extractMessageFields_logfmt(t *testing.T) {
+	newFrame := func() *data.Frame {
+		return data.NewFrame("logs",
+			data.NewField("@timestamp", nil, []*time.Time{aws.Time(time.Unix(0, 0)), aws.Time(time.Unix(1, 0)), aws.Time(time.Unix(2, 0))}),
+			data.NewField("@message", nil, []*string{
+				aws.String("level=info status=200"),
+				nil,
+				aws.String("level=warn user=bob @message=overridden"),
+			}),
+		)
+	}
+
+	t.Run("adds a field per detected key", func(t *testing.T) {
+		frame := newFrame()
+		extractMessageFields(frame, models.LogsQuery{CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{ParseLogfmt: aws.Bool(true)}})
+
+		assert.Equal(t, []*data.Field{
+			data.NewField("level", nil, []*string{aws.String("info"), nil, aws.String("warn")}),
+			data.NewField("status", nil, []*string{aws.String("200"), nil, nil}),
+			data.NewField("user", nil, []*string{nil, nil, aws.String("bob")}),
+		}, frame.Fields[2:])
+	})
+
+	t.Run("does nothing unless enabled", func(t *testing.T) {
+		frame := newFrame()
+		extractMessageFields(frame, models.LogsQuery{CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{ParseLogfmt: aws.Bool(false)}})
+
+		assert.Len(t, frame.Fields, 2)
+	})
+}
+
+func Test_extractMessageFields_jsonPaths(t *testing.T) {
+	frame := data.NewFrame("logs",
+		data.NewField("@message", nil, []*string{
+			aws.String(`{"request":{"method":"GET","status":200,"cached":true},"tags":["a","b"]}`),
+			aws.String(`not json`),
+			aws.String(`{"request":{"method":"POST","status":"n/a","cached":false},"tags":[]}`),
+		}),
+	)
+
+	extractMessageFields(frame, models.LogsQuery{CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{
+		JsonPaths: []string{"request.method", "request.cached", "tags.1", "tags", "missing", "@message"},
+	}})
+
+	assert.Equal(t, []*data.Field{
+		data.NewField("request.method", nil, []*string{aws.String("GET"), nil, aws.String("POST")}),
+		data.NewField("request.cached", nil, []*bool{aws.Bool(true), nil, aws.Bool(false)}),
+		data.NewField("tags.1", nil, []*string{aws.String("b"), nil, nil}),
+		data.NewField("tags", nil, []*string{aws.String(`["a","b"]`), nil, aws.String(`[]`)}),
+		data.NewField("missing", nil, []*float64{nil, nil, nil}),
+	}, frame.Fields[1:])
+
+	t.Run("numbers are typed unless mixed with other values", func(t *testing.T) {
+		assert.Equal(t, []*float64{aws.Float64(200), nil}, typedJSONValues([]any{float64(200), nil}))
+		assert.Equal(t, []*string{aws.String("200"), aws.String("n/a")}, typedJSONValues([]any{float64(200), "n/a"}))
+	})
+}
@@ -0,0 +1,106 @@
+package cloudwatch
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// patternFieldName is the field of the results of the pattern command holding the patterns, which tells them apart
+// from the results of other queries. See
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/CWL_QuerySyntax-Pattern.html
+const patternFieldName = "@pattern"
+
+// patternFields are the fields of the results of the pattern command shown in the table, in the order they're shown
+// in, with how they're shown. The other fields, e.g. @tokens and @regexString, are kept hidden for the data links of
+// the table to use.
+var patternFields = []struct {
+	name   string
+	config data.FieldConfig
+}{
+	{name: patternFieldName, config: data.FieldConfig{DisplayName: "Pattern"}},
+	{name: "@severityLabel", config: data.FieldConfig{
+		DisplayName: "Severity",
+		Mappings: data.ValueMappings{data.ValueMapper{
+			"ERROR": {Color: "red", Index: 0},
+			"FATAL": {Color: "dark-red", Index: 1},
+			"WARN":  {Color: "orange", Index: 2},
+			"INFO":  {Color: "green", Index: 3},
+			"DEBUG": {Color: "blue", Index: 4},
+		}},
+		Custom: map[string]any{"cellOptions": map[string]any{"type": "color-text"}},
+	}},
+	{name: "@ratio", config: data.FieldConfig{DisplayName: "Ratio", Unit: "percentunit"}},
+	{name: "@sampleCount", config: data.FieldConfig{DisplayName: "Samples"}},
+	{name: "@logSamples", config: data.FieldConfig{DisplayName: "Sample logs"}},
+}
+
+// formatPatternResults shows the results of a pattern command as a table of the patterns, ordered by their number of
+// samples, with their severity and ratio first. Results of other queries are left as they are.
+func formatPatternResults(frame *data.Frame) {
+	if frame == nil || frameField(frame, patternFieldName) == nil {
+		return
+	}
+
+	fields := make([]*data.Field, 0, len(frame.Fields))
+	for _, patternField := range patternFields {
+		for _, field := range frame.Fields {
+			if field.Name == patternField.name {
+				config := patternField.config
+				field.SetConfig(&config)
+				fields = append(fields, field)
+			}
+		}
+	}
+	for _, field := range frame.Fields {
+		if !slices.Contains(fields, field) {
+			field.SetConfig(&data.FieldConfig{Custom: map[string]any{"hidden": true}})
+			fields = append(fields, field)
+		}
+	}
+	frame.Fields = fields
+
+	if samples := frameField(frame, "@sampleCount"); samples != nil && samples.Type() == data.FieldTypeNullableFloat64 {
+		sort.Stable(bySamples{frame: frame, samples: samples})
+	}
+	setPreferredVisType(frame, data.VisTypeTable)
+}
+
+func frameField(frame *data.Frame, name string) *data.Field {
+	for _, field := range frame.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// bySamples implements sort.Interface for the results of a pattern command, most frequent patterns first
+type bySamples struct {
+	frame   *data.Frame
+	samples *data.Field
+}
+
+func (a bySamples) Len() int {
+	return a.samples.Len()
+}
+
+func (a bySamples) Swap(i, j int) {
+	for _, field := range a.frame.Fields {
+		temp := field.At(i)
+		field.Set(i, field.At(j))
+		field.Set(j, temp)
+	}
+}
+
+func (a bySamples) Less(i, j int) bool {
+	return sampleCount(a.samples, i) > sampleCount(a.samples, j)
+}
+
+func sampleCount(samples *data.Field, i int) float64 {
+	if value := samples.At(i).(*float64); value != nil {
+		return *value
+	}
+	return 0
+}
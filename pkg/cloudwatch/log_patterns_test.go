@@ -0,0 +1,61 @@
+package cloudwatch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_formatPatternResults(t *testing.T) {
+	patternResult := func(pattern, severity, ratio, samples string) []cloudwatchlogstypes.ResultField {
+		return []cloudwatchlogstypes.ResultField{
+			{Field: aws.String("@regexString"), Value: aws.String(pattern + ".*")},
+			{Field: aws.String("@sampleCount"), Value: aws.String(samples)},
+			{Field: aws.String("@pattern"), Value: aws.String(pattern + " <*>")},
+			{Field: aws.String("@ratio"), Value: aws.String(ratio)},
+			{Field: aws.String("@severityLabel"), Value: aws.String(severity)},
+		}
+	}
+
+	t.Run("shows the patterns as a table ordered by their samples", func(t *testing.T) {
+		frame, err := logsResultsToDataframes(&cloudwatchlogs.GetQueryResultsOutput{
+			Results: [][]cloudwatchlogstypes.ResultField{
+				patternResult("Started", "INFO", "0.2", "20"),
+				patternResult("Failed", "ERROR", "0.7", "70"),
+				patternResult("Retrying", "WARN", "0.1", "10"),
+			},
+			Status: "Complete",
+		}, []string{})
+		require.NoError(t, err)
+
+		formatPatternResults(frame)
+
+		names := make([]string, 0, len(frame.Fields))
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		assert.Equal(t, []string{"@pattern", "@severityLabel", "@ratio", "@sampleCount", "@regexString"}, names)
+		assert.Equal(t, "Pattern", frame.Fields[0].Config.DisplayName)
+		assert.Equal(t, "percentunit", frame.Fields[2].Config.Unit)
+		assert.Equal(t, map[string]any{"hidden": true}, frame.Fields[4].Config.Custom)
+		assert.Equal(t, []string{"Failed <*>", "Started <*>", "Retrying <*>"}, []string{
+			*frame.Fields[0].At(0).(*string), *frame.Fields[0].At(1).(*string), *frame.Fields[0].At(2).(*string),
+		})
+		assert.Equal(t, "Failed.*", *frame.Fields[4].At(0).(*string))
+		assert.Equal(t, data.VisTypeTable, string(frame.Meta.PreferredVisualization))
+	})
+
+	t.Run("leaves the results of other queries as they are", func(t *testing.T) {
+		frame := data.NewFrame("CloudWatchLogsResponse", data.NewField("@message", nil, []string{"b", "a"}))
+
+		formatPatternResults(frame)
+
+		assert.Nil(t, frame.Fields[0].Config)
+		assert.Nil(t, frame.Meta)
+	})
+}
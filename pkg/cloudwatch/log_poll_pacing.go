@@ -0,0 +1,83 @@
+package cloudwatch
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	// getQueryResultsInterval spaces the GetQueryResults calls of a region, whose default quota is 5 transactions
+	// per second per account and region
+	getQueryResultsInterval = 200 * time.Millisecond
+	// pollJitter is the fraction of a poll period it is randomly lengthened or shortened by
+	pollJitter = 0.2
+)
+
+// randFloat64 returns a random number in [0.0, 1.0).
+//
+// Stubbable by tests.
+var randFloat64 = rand.Float64
+
+// regionPacer spreads out calls to an API made at the same time, e.g. by the panels of a dashboard polling the
+// results of their logs queries after a refresh, by giving them slots of a region interval apart, so that
+// synchronized bursts don't trip the throttling of the API.
+type regionPacer struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newRegionPacer(interval time.Duration) *regionPacer {
+	return &regionPacer{now: time.Now, interval: interval, next: map[string]time.Time{}}
+}
+
+// wait blocks until the next free slot of region, or until ctx is done. A nil pacer doesn't pace calls.
+func (p *regionPacer) wait(ctx context.Context, region string) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	now := p.now()
+	slot := p.next[region]
+	if slot.Before(now) {
+		slot = now
+	}
+	p.next[region] = slot.Add(p.interval)
+	p.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// jitteredPeriod randomly lengthens or shortens period by up to pollJitter of it, so that queries started
+// together don't keep polling together.
+func jitteredPeriod(period time.Duration) time.Duration {
+	return period + time.Duration((randFloat64()*2-1)*pollJitter*float64(period))
+}
+
+// waitPollPeriod waits for period, jittered, and returns the time waited, or returns the error of ctx once it's done.
+func waitPollPeriod(ctx context.Context, period time.Duration) (time.Duration, error) {
+	period = jitteredPeriod(period)
+	timer := time.NewTimer(period)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+		return period, nil
+	}
+}
@@ -0,0 +1,67 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionPacer(t *testing.T) {
+	t.Run("gives calls of a region slots an interval apart", func(t *testing.T) {
+		now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		pacer := newRegionPacer(time.Millisecond)
+		pacer.now = func() time.Time { return now }
+
+		for range 3 {
+			require.NoError(t, pacer.wait(context.Background(), "us-east-1"))
+		}
+		require.NoError(t, pacer.wait(context.Background(), "eu-west-1"))
+
+		assert.Equal(t, map[string]time.Time{
+			"us-east-1": now.Add(3 * time.Millisecond),
+			"eu-west-1": now.Add(time.Millisecond),
+		}, pacer.next)
+	})
+
+	t.Run("frees the slots of the past", func(t *testing.T) {
+		now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		pacer := newRegionPacer(time.Millisecond)
+		pacer.now = func() time.Time { return now }
+		require.NoError(t, pacer.wait(context.Background(), "us-east-1"))
+
+		now = now.Add(time.Second)
+		require.NoError(t, pacer.wait(context.Background(), "us-east-1"))
+		assert.Equal(t, now.Add(time.Millisecond), pacer.next["us-east-1"])
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		pacer := newRegionPacer(time.Hour)
+		require.NoError(t, pacer.wait(context.Background(), "us-east-1"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, pacer.wait(ctx, "us-east-1"), context.Canceled)
+	})
+
+	t.Run("doesn't pace calls without pacer", func(t *testing.T) {
+		var pacer *regionPacer
+		assert.NoError(t, pacer.wait(context.Background(), "us-east-1"))
+	})
+}
+
+func TestJitteredPeriod(t *testing.T) {
+	origRandFloat64 := randFloat64
+	t.Cleanup(func() {
+		randFloat64 = origRandFloat64
+	})
+
+	randFloat64 = func() float64 { return 0 }
+	assert.Equal(t, 800*time.Millisecond, jitteredPeriod(time.Second))
+	randFloat64 = func() float64 { return 0.5 }
+	assert.Equal(t, time.Second, jitteredPeriod(time.Second))
+	randFloat64 = func() float64 { return 0.75 }
+	assert.Equal(t, 1100*time.Millisecond, jitteredPeriod(time.Second))
+}
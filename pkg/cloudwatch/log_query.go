@@ -5,8 +5,10 @@ import (
 	"slices"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 
@@ -40,62 +42,44 @@ func logsResultsToDataframes(response *cloudwatchlogs.GetQueryResultsOutput, gro
 
 	rowCount := len(nonEmptyRows)
 
-	fieldValues := make(map[string]any)
+	rawValues := make(map[string][]*string)
 
 	// Maintaining a list of field names in the order returned from CloudWatch
-	// as just iterating over fieldValues would not give a consistent order
+	// as just iterating over rawValues would not give a consistent order
 	fieldNames := make([]string, 0)
 
+	// @ptr is only kept, hidden, when the results don't identify the log stream of their events, e.g. those of SQL
+	// and PPL queries, as the log context of their events is then looked up by pointer
+	keepPtr := !slices.ContainsFunc(nonEmptyRows, func(row []cloudwatchlogstypes.ResultField) bool {
+		return slices.ContainsFunc(row, func(field cloudwatchlogstypes.ResultField) bool {
+			return aws.ToString(field.Field) == logStreamIdentifierInternal
+		})
+	})
+
 	for i, row := range nonEmptyRows {
 		for _, resultField := range row {
-			// Strip @ptr field from results as it's not needed
 			if *resultField.Field == "@ptr" {
-				continue
+				if !keepPtr {
+					continue
+				}
+				resultField.Field = aws.String(logPtrInternal)
 			}
 
-			if _, exists := fieldValues[*resultField.Field]; !exists {
+			if _, exists := rawValues[*resultField.Field]; !exists {
 				fieldNames = append(fieldNames, *resultField.Field)
-
-				// Check if it's a cloudWatchTSFormat field or one of the known timestamp fields:
-				// https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/CWL_AnalyzeLogData-discoverable-fields.html
-				// which can be in a millisecond format as well as cloudWatchTSFormat string format
-				if _, err := time.Parse(cloudWatchTSFormat, *resultField.Value); err == nil || isTimestampField(*resultField.Field) {
-					fieldValues[*resultField.Field] = make([]*time.Time, rowCount)
-				} else if slices.Contains[[]string, string](groupingFieldNames, *resultField.Field) {
-					fieldValues[*resultField.Field] = make([]*string, rowCount)
-				} else if _, err := strconv.ParseFloat(*resultField.Value, 64); err == nil {
-					fieldValues[*resultField.Field] = make([]*float64, rowCount)
-				} else {
-					fieldValues[*resultField.Field] = make([]*string, rowCount)
-				}
+				rawValues[*resultField.Field] = make([]*string, rowCount)
 			}
+			rawValues[*resultField.Field][i] = resultField.Value
+		}
+	}
 
-			if timeField, ok := fieldValues[*resultField.Field].([]*time.Time); ok {
-				parsedTime, err := time.Parse(cloudWatchTSFormat, *resultField.Value)
-				if err != nil {
-					unixTimeMs, err := strconv.ParseInt(*resultField.Value, 10, 64)
-					if err == nil {
-						parsedTime = time.Unix(unixTimeMs/1000, (unixTimeMs%1000)*int64(time.Millisecond))
-					} else {
-						return nil, err
-					}
-				}
-				timeField[i] = &parsedTime
-			} else if numericField, ok := fieldValues[*resultField.Field].([]*float64); ok {
-				parsedFloat, err := strconv.ParseFloat(*resultField.Value, 64)
-
-				if err != nil {
-					// This can happen if a field has a mix of numeric and non-numeric values.
-					// In that case, we change the field from a numeric field to a string field.
-					fieldValues[*resultField.Field] = changeToStringField(rowCount, nonEmptyRows[:i+1], *resultField.Field)
-					continue
-				}
-
-				numericField[i] = &parsedFloat
-			} else {
-				fieldValues[*resultField.Field].([]*string)[i] = resultField.Value
-			}
+	fieldValues := make(map[string]any, len(fieldNames))
+	for _, fieldName := range fieldNames {
+		values, err := typedFieldValues(fieldName, rawValues[fieldName], groupingFieldNames)
+		if err != nil {
+			return nil, err
 		}
+		fieldValues[fieldName] = values
 	}
 
 	newFields := make([]*data.Field, 0, len(fieldNames))
@@ -104,7 +88,7 @@ func logsResultsToDataframes(response *cloudwatchlogs.GetQueryResultsOutput, gro
 
 		if fieldName == "@timestamp" {
 			newFields[len(newFields)-1].SetConfig(&data.FieldConfig{DisplayName: "Time"})
-		} else if fieldName == logStreamIdentifierInternal || fieldName == logIdentifierInternal {
+		} else if fieldName == logStreamIdentifierInternal || fieldName == logIdentifierInternal || fieldName == logPtrInternal {
 			newFields[len(newFields)-1].SetConfig(
 				&data.FieldConfig{
 					Custom: map[string]any{
@@ -146,23 +130,113 @@ func logsResultsToDataframes(response *cloudwatchlogs.GetQueryResultsOutput, gro
 	frame.Meta.Custom = map[string]any{
 		"Status": string(response.Status),
 	}
+	if response.Statistics != nil {
+		setQueryStats(data.Frames{frame}, newLogsQueryStats(response.Statistics))
+	}
 
 	// Results aren't guaranteed to come ordered by time (ascending), so we need to sort
 	sort.Sort(ByTime(*frame))
 	return frame, nil
 }
 
-func changeToStringField(lengthOfValues int, rows [][]cloudwatchlogstypes.ResultField, logEventField string) []*string {
-	fieldValuesAsStrings := make([]*string, lengthOfValues)
-	for i, resultFields := range rows {
-		for _, field := range resultFields {
-			if *field.Field == logEventField {
-				fieldValuesAsStrings[i] = field.Value
+// stringOnlyFields are never converted to another type, whatever their values look like
+var stringOnlyFields = []string{"@message", "@log", "@logStream", logIdentifierInternal, logStreamIdentifierInternal}
+
+// typedFieldValues converts the values of a result field to the type they all share, so that stats outputs can be
+// graphed and thresholded. Fields whose values are times are converted to times, fields whose values are all numbers or
+// all booleans to numbers or booleans, with empty values as nulls, and everything else is kept as strings.
+func typedFieldValues(fieldName string, values []*string, groupingFieldNames []string) (any, error) {
+	// Check if it's a cloudWatchTSFormat field or one of the known timestamp fields:
+	// https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/CWL_AnalyzeLogData-discoverable-fields.html
+	// which can be in a millisecond format as well as cloudWatchTSFormat string format
+	if first := firstValue(values); isTimestampField(fieldName) || (first != nil && isCloudWatchTimestamp(*first)) {
+		return timeFieldValues(values)
+	}
+	if slices.Contains(groupingFieldNames, fieldName) || slices.Contains(stringOnlyFields, fieldName) {
+		return values, nil
+	}
+	if numbers, ok := numericFieldValues(values); ok {
+		return numbers, nil
+	}
+	if booleans, ok := booleanFieldValues(values); ok {
+		return booleans, nil
+	}
+	return values, nil
+}
+
+func firstValue(values []*string) *string {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+func isCloudWatchTimestamp(value string) bool {
+	_, err := time.Parse(cloudWatchTSFormat, value)
+	return err == nil
+}
+
+func timeFieldValues(values []*string) ([]*time.Time, error) {
+	times := make([]*time.Time, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		parsedTime, err := time.Parse(cloudWatchTSFormat, *value)
+		if err != nil {
+			unixTimeMs, err := strconv.ParseInt(*value, 10, 64)
+			if err != nil {
+				return nil, err
 			}
+			parsedTime = time.Unix(unixTimeMs/1000, (unixTimeMs%1000)*int64(time.Millisecond))
 		}
+		times[i] = &parsedTime
 	}
+	return times, nil
+}
 
-	return fieldValuesAsStrings
+// numericFieldValues returns the values as numbers if there is at least one and all of them are numbers.
+func numericFieldValues(values []*string) ([]*float64, bool) {
+	numbers := make([]*float64, len(values))
+	found := false
+	for i, value := range values {
+		if value == nil || *value == "" {
+			continue
+		}
+		number, err := strconv.ParseFloat(*value, 64)
+		if err != nil {
+			// This can happen if a field has a mix of numeric and non-numeric values.
+			return nil, false
+		}
+		numbers[i] = &number
+		found = true
+	}
+	return numbers, found
+}
+
+// booleanFieldValues returns the values as booleans if there is at least one and all of them are true or false.
+func booleanFieldValues(values []*string) ([]*bool, bool) {
+	booleans := make([]*bool, len(values))
+	found := false
+	for i, value := range values {
+		if value == nil || *value == "" {
+			continue
+		}
+		var boolean bool
+		switch strings.ToLower(*value) {
+		case "true":
+			boolean = true
+		case "false":
+			boolean = false
+		default:
+			return nil, false
+		}
+		booleans[i] = &boolean
+		found = true
+	}
+	return booleans, found
 }
 
 func groupResults(results *data.Frame, groupingFieldNames []string, fromSyncQuery bool) ([]*data.Frame, error) {
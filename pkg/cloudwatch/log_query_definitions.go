@@ -0,0 +1,39 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+)
+
+// withQueryDefinition returns logsQuery running the saved query definition it refers to by ID. The query string and
+// language are the definition's, and so are the log groups unless the query selects some.
+func withQueryDefinition(ctx context.Context, api models.QueryDefinitionsAPI, logsQuery models.LogsQuery) (models.LogsQuery, error) {
+	id := aws.ToString(logsQuery.QueryDefinitionId)
+	// DescribeQueryDefinitions can't be filtered by ID, so the definition is looked up among all of them
+	queryDefinitions, err := services.ListQueryDefinitions(ctx, api, nil)
+	if err != nil {
+		return logsQuery, backend.DownstreamError(fmt.Errorf("failed to get the query definitions: %w", err))
+	}
+	for _, queryDefinition := range queryDefinitions {
+		if aws.ToString(queryDefinition.QueryDefinitionId) != id {
+			continue
+		}
+		logsQuery.QueryString = aws.ToString(queryDefinition.QueryString)
+		if queryDefinition.QueryLanguage != "" {
+			queryLanguage := dataquery.LogsQueryLanguage(queryDefinition.QueryLanguage)
+			logsQuery.QueryLanguage = &queryLanguage
+		}
+		if len(logsQuery.LogGroups) == 0 && len(logsQuery.LogGroupNames) == 0 {
+			logsQuery.LogGroupNames = queryDefinition.LogGroupNames
+		}
+		return logsQuery, nil
+	}
+	return logsQuery, backend.DownstreamError(fmt.Errorf("query definition %q not found", id))
+}
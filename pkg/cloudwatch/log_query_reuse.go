@@ -0,0 +1,120 @@
+package cloudwatch
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// logsQueryIdKey identifies the Logs Insights query started with input, so that identical queries started within the
+// reuse TTL read the results of the first one rather than scanning the same log events again. It returns an empty
+// string if queries aren't reused.
+func (ds *DataSource) logsQueryIdKey(ctx context.Context, region string, input *cloudwatchlogs.StartQueryInput) string {
+	ttl := ds.Settings.LogsQueryReuseTTL.Duration
+	if ds.logsQueryIds == nil || ttl <= 0 {
+		return ""
+	}
+	// only the key is aligned to the reuse TTL, so that refreshes within the same window reuse the query while
+	// queries read the time range that was requested
+	aligned := alignTimeRange(backend.TimeRange{
+		From: time.Unix(aws.ToInt64(input.StartTime), 0),
+		To:   time.Unix(aws.ToInt64(input.EndTime), 0),
+	}, ttl)
+	keyInput := *input
+	keyInput.StartTime = aws.Int64(aligned.From.Unix())
+	keyInput.EndTime = aws.Int64(aligned.To.Unix())
+	return logsQueryKey(ctx, ds, region, &keyInput)
+}
+
+// logsQueryKey identifies the Logs Insights query started with input in region for the requesting org and role and,
+// with user identity pass-through, user.
+func logsQueryKey(ctx context.Context, ds *DataSource, region string, input *cloudwatchlogs.StartQueryInput) string {
+	if region == "" || region == defaultRegion {
+		region = ds.Settings.Region
+	}
+	key, err := ds.roleScopedCacheKey(ctx, map[string]any{
+		"region": region,
+		"input":  input,
+	})
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// startLogsQuery starts the query, coalescing identical queries started concurrently, e.g. by several panels of a
+// dashboard refreshing at once, onto a single StartQuery call whose query id they all poll. Only queries of the same
+// org, role and user are coalesced, and the call isn't cancelled with the request that made it, as the others
+// wait for it too.
+func (ds *DataSource) startLogsQuery(ctx context.Context, logsClient models.CWLogsClient, region string,
+	input *cloudwatchlogs.StartQueryInput) (*cloudwatchlogs.StartQueryOutput, error) {
+	if ds.startingQueries == nil {
+		return logsClient.StartQuery(ctx, input)
+	}
+	key := logsQueryKey(ctx, ds, region, input)
+	if key == "" {
+		return logsClient.StartQuery(ctx, input)
+	}
+	results := ds.startingQueries.DoChan(key, func() (any, error) {
+		return logsClient.StartQuery(context.WithoutCancel(ctx), input)
+	})
+	var result singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-results:
+	}
+	if result.Shared {
+		ds.logger.FromContext(ctx).Debug("Coalesced identical concurrent Logs Insights query", "key", key)
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Val.(*cloudwatchlogs.StartQueryOutput), nil
+}
+
+func (ds *DataSource) reusableLogsQueryId(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	queryId, found := ds.logsQueryIds.Get(key)
+	if !found {
+		return "", false
+	}
+	return queryId.(string), true
+}
+
+func (ds *DataSource) rememberLogsQueryId(key string, queryId string) {
+	if key == "" {
+		return
+	}
+	ds.logsQueryIds.Set(key, queryId, ds.Settings.LogsQueryReuseTTL.Duration)
+}
+
+// forgetLogsQueryId stops reusing a query that was stopped or didn't complete, as its results are incomplete.
+func (ds *DataSource) forgetLogsQueryId(queryId string) {
+	if ds.logsQueryIds == nil {
+		return
+	}
+	for key, item := range ds.logsQueryIds.Items() {
+		if item.Object == queryId {
+			ds.logsQueryIds.Delete(key)
+		}
+	}
+}
+
+// isIncompleteLogsQueryStatus reports whether a query with the status will never have complete results.
+func isIncompleteLogsQueryStatus(status cloudwatchlogstypes.QueryStatus) bool {
+	switch status {
+	case cloudwatchlogstypes.QueryStatusFailed, cloudwatchlogstypes.QueryStatusCancelled, cloudwatchlogstypes.QueryStatusTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_reuseLogsQueries(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli fakeCWLogsClient
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return &cli
+	}
+
+	windowStart := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	logActionWithRole := func(ds *DataSource, subtype string, to time.Time, roleARN string) backend.DataResponse {
+		t.Helper()
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+				JSON: json.RawMessage(`{
+					"type":        "logAction",
+					"subtype":     "` + subtype + `",
+					"region":      "us-east-1",
+					"queryId":     "abcd-efgh-ijkl-mnop",
+					"queryString": "fields @message",
+					"logGroupNames": ["group"],
+					"assumeRoleArn": "` + roleARN + `"
+				}`),
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		return resp.Responses["A"]
+	}
+	logAction := func(ds *DataSource, subtype string, to time.Time) backend.DataResponse {
+		t.Helper()
+		return logActionWithRole(ds, subtype, to, "")
+	}
+	newReusingDatasource := func() *DataSource {
+		return newTestDatasource(func(ds *DataSource) {
+			ds.logsQueryIds = cache.New(cache.NoExpiration, 0)
+			ds.Settings.LogsQueryReuseTTL = models.Duration{Duration: time.Minute}
+		})
+	}
+
+	t.Run("identical queries within the same window reuse the started query", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newReusingDatasource()
+
+		first := logAction(ds, "StartQuery", windowStart.Add(10*time.Second))
+		second := logAction(ds, "StartQuery", windowStart.Add(40*time.Second))
+
+		require.Len(t, cli.calls.startQuery, 1)
+		assert.Equal(t, first.Frames[0].Fields[0].At(0), second.Frames[0].Fields[0].At(0))
+		// only the key is aligned to the reuse TTL, the query reads the requested time range
+		assert.Equal(t, windowStart.Add(10*time.Second-time.Hour).Unix(), aws.ToInt64(cli.calls.startQuery[0].StartTime))
+		assert.Equal(t, windowStart.Add(10*time.Second).Unix(), aws.ToInt64(cli.calls.startQuery[0].EndTime))
+
+		logAction(ds, "StartQuery", windowStart.Add(70*time.Second))
+		assert.Len(t, cli.calls.startQuery, 2)
+	})
+
+	t.Run("queries assuming different roles don't reuse each other's query", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newReusingDatasource()
+		ds.AWSConfigProvider = &roleRecordingConfigProvider{}
+		ds.Settings.QueryRoleARNs = []string{"arn:aws:iam::222222222222:role/grafana"}
+
+		logAction(ds, "StartQuery", windowStart.Add(10*time.Second))
+		logActionWithRole(ds, "StartQuery", windowStart.Add(10*time.Second), "arn:aws:iam::222222222222:role/grafana")
+
+		assert.Len(t, cli.calls.startQuery, 2)
+	})
+
+	t.Run("stopped queries aren't reused", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newReusingDatasource()
+
+		logAction(ds, "StartQuery", windowStart.Add(10*time.Second))
+		logAction(ds, "StopQuery", windowStart.Add(10*time.Second))
+		logAction(ds, "StartQuery", windowStart.Add(20*time.Second))
+
+		assert.Len(t, cli.calls.startQuery, 2)
+	})
+
+	t.Run("failed queries aren't reused", func(t *testing.T) {
+		cli = fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{Status: cloudwatchlogstypes.QueryStatusFailed}}
+		ds := newReusingDatasource()
+
+		logAction(ds, "StartQuery", windowStart.Add(10*time.Second))
+		logAction(ds, "GetQueryResults", windowStart.Add(10*time.Second))
+		logAction(ds, "StartQuery", windowStart.Add(20*time.Second))
+
+		assert.Len(t, cli.calls.startQuery, 2)
+	})
+
+	t.Run("queries aren't reused without a reuse TTL", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newTestDatasource()
+
+		logAction(ds, "StartQuery", windowStart.Add(10*time.Second))
+		logAction(ds, "StartQuery", windowStart.Add(10*time.Second))
+
+		assert.Len(t, cli.calls.startQuery, 2)
+	})
+}
+
+// blockingStartQueryClient counts StartQuery calls and blocks them until released.
+type blockingStartQueryClient struct {
+	fakeCWLogsClient
+	started atomic.Int32
+	release chan struct{}
+}
+
+func (c *blockingStartQueryClient) StartQuery(ctx context.Context, _ *cloudwatchlogs.StartQueryInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	c.started.Add(1)
+	<-c.release
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String("abcd-efgh-ijkl-mnop")}, nil
+}
+
+func TestQuery_coalesceConcurrentLogsQueries(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	cli := &blockingStartQueryClient{release: make(chan struct{})}
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return cli
+	}
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.startingQueries = &singleflight.Group{}
+	})
+
+	to := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	responses := make([]backend.DataResponse, 3)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+				Queries: []backend.DataQuery{{
+					RefID:     "A",
+					TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+					JSON: json.RawMessage(`{
+						"type":        "logAction",
+						"subtype":     "StartQuery",
+						"region":      "us-east-1",
+						"queryString": "fields @message",
+						"logGroupNames": ["group"]
+					}`),
+				}},
+			})
+			if assert.NoError(t, err) {
+				responses[i] = resp.Responses["A"]
+			}
+		}()
+	}
+	// give every query the chance to join the first StartQuery call before it returns
+	require.Eventually(t, func() bool { return cli.started.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(cli.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), cli.started.Load())
+	for _, response := range responses {
+		require.NoError(t, response.Error)
+		assert.Equal(t, "abcd-efgh-ijkl-mnop", response.Frames[0].Fields[0].At(0))
+	}
+}
+
+func TestDataSource_startLogsQuery_cancelledCaller(t *testing.T) {
+	cli := &blockingStartQueryClient{release: make(chan struct{})}
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.startingQueries = &singleflight.Group{}
+	})
+	input := &cloudwatchlogs.StartQueryInput{QueryString: aws.String("fields @message")}
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := ds.startLogsQuery(firstCtx, cli, "us-east-1", input)
+		firstErr <- err
+	}()
+	require.Eventually(t, func() bool { return cli.started.Load() == 1 }, time.Second, time.Millisecond)
+
+	second := make(chan *cloudwatchlogs.StartQueryOutput, 1)
+	go func() {
+		resp, err := ds.startLogsQuery(context.Background(), cli, "us-east-1", input)
+		assert.NoError(t, err)
+		second <- resp
+	}()
+	// give the second query the chance to join the first StartQuery call before it's cancelled
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(cli.release)
+
+	resp := <-second
+	require.NotNil(t, resp)
+	assert.Equal(t, "abcd-efgh-ijkl-mnop", aws.ToString(resp.QueryId))
+	assert.Equal(t, int32(1), cli.started.Load())
+}
@@ -198,6 +198,7 @@ func TestLogsResultsToDataframes(t *testing.T) {
 		Meta: &data.FrameMeta{
 			Custom: map[string]any{
 				"Status": "ok",
+				"stats":  logsQueryStats{RecordsScanned: 5000, RecordsMatched: 3, BytesScanned: 2000},
 			},
 			Stats: []data.QueryStat{
 				{
@@ -399,6 +400,34 @@ func TestLogsResultsToDataframes_With_Int_Grouping_Field(t *testing.T) {
 	assert.ElementsMatch(t, expectedDataframe.Fields, dataframes.Fields)
 }
 
+func TestLogsResultsToDataframes_InfersFieldTypes(t *testing.T) {
+	row := func(count, success, message, status string) []cloudwatchlogstypes.ResultField {
+		return []cloudwatchlogstypes.ResultField{
+			{Field: aws.String("count"), Value: aws.String(count)},
+			{Field: aws.String("success"), Value: aws.String(success)},
+			{Field: aws.String("@message"), Value: aws.String(message)},
+			{Field: aws.String("status"), Value: aws.String(status)},
+		}
+	}
+
+	dataframes, err := logsResultsToDataframes(&cloudwatchlogs.GetQueryResultsOutput{
+		Results: [][]cloudwatchlogstypes.ResultField{
+			row("", "true", "42", "200"),
+			row("3.5", "FALSE", "43", "OK"),
+			row("7", "", "44", "500"),
+		},
+		Status: "Complete",
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*data.Field{
+		data.NewField("count", nil, []*float64{nil, aws.Float64(3.5), aws.Float64(7)}),
+		data.NewField("success", nil, []*bool{aws.Bool(true), aws.Bool(false), nil}),
+		data.NewField("@message", nil, []*string{aws.String("42"), aws.String("43"), aws.String("44")}),
+		data.NewField("status", nil, []*string{aws.String("200"), aws.String("OK"), aws.String("500")}),
+	}, dataframes.Fields)
+}
+
 func TestGroupKeyGeneration(t *testing.T) {
 	logField := data.NewField("@log", data.Labels{}, []*string{
 		aws.String("fakelog-a"),
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
@@ -17,6 +19,10 @@ import (
 
 const initialAlertPollPeriod = time.Second
 
+// stopAbandonedQueryTimeout bounds stopping a query whose request was cancelled, as the request's context can't be
+// used for it anymore
+const stopAbandonedQueryTimeout = 5 * time.Second
+
 var executeSyncLogQuery = func(ctx context.Context, ds *DataSource, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	resp := backend.NewQueryDataResponse()
 
@@ -61,6 +67,10 @@ var executeSyncLogQuery = func(ctx context.Context, ds *DataSource, req *backend
 		if err != nil {
 			return nil, err
 		}
+		extractMessageFields(dataframe, logsQuery)
+		ds.maskLogsFrame(dataframe)
+		formatPatternResults(dataframe)
+		setLogsLimitMeta(dataframe, ds.logsInsightsLimit(logsQuery.Limit))
 
 		var frames []*data.Frame
 		if len(logsQuery.StatsGroups) > 0 && len(dataframe.Fields) > 0 {
@@ -82,7 +92,7 @@ var executeSyncLogQuery = func(ctx context.Context, ds *DataSource, req *backend
 
 func (ds *DataSource) syncQuery(ctx context.Context, logsClient models.CWLogsClient,
 	queryContext backend.DataQuery, logsQuery models.LogsQuery, logsTimeout time.Duration) (*cloudwatchlogs.GetQueryResultsOutput, error) {
-	startQueryOutput, err := ds.executeStartQuery(ctx, logsClient, logsQuery, queryContext.TimeRange)
+	startQueryOutput, err := ds.executeStartQuery(ctx, logsClient, logsQuery, queryContext)
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +104,16 @@ func (ds *DataSource) syncQuery(ctx context.Context, logsClient models.CWLogsCli
 		QueryId: *startQueryOutput.QueryId,
 	}
 
+	// the query is stopped if it's abandoned before finishing, e.g. because the request was cancelled, so that it
+	// doesn't keep scanning, and billing, log events nobody reads
+	ds.logsPollers.acquire(requestParams.QueryId)
+	terminated := false
+	defer func() {
+		if ds.logsPollers.release(requestParams.QueryId) && !terminated {
+			ds.stopAbandonedQuery(ctx, logsClient, requestParams)
+		}
+	}()
+
 	/*
 		Unlike many other data sources, with Cloudwatch Logs query requests don't receive the results as the response
 		to the query, but rather an ID is first returned. Following this, a client is expected to send requests along
@@ -102,24 +122,78 @@ func (ds *DataSource) syncQuery(ctx context.Context, logsClient models.CWLogsCli
 		frontend, but because alerts and expressions are executed on the backend the logic needs to be reimplemented here.
 	*/
 
-	ticker := time.NewTicker(initialAlertPollPeriod)
-	defer ticker.Stop()
+	var polled time.Duration
+	for {
+		waited, err := waitPollPeriod(ctx, initialAlertPollPeriod)
+		if err != nil {
+			return nil, err
+		}
+		polled += waited
 
-	attemptCount := 1
-	for range ticker.C {
 		res, err := ds.executeGetQueryResults(ctx, logsClient, requestParams)
 		if err != nil {
 			return nil, err
 		}
+		terminated = isTerminated(res.Status)
+		if res.Status == cloudwatchlogstypes.QueryStatusTimeout {
+			quotas := ds.getLogsQuotas(ctx, ds.logsRegion(logsQuery.Region))
+			return res, backend.DownstreamError(fmt.Errorf("the query was cancelled by CloudWatch after the Logs Insights "+
+				"query timeout of the account, %s; narrow the time range or the log groups of the query", quotas.queryTimeout))
+		}
 		if isTerminated(res.Status) {
 			return res, err
 		}
-		if time.Duration(attemptCount)*time.Second >= logsTimeout {
+		if polled >= logsTimeout {
 			return res, fmt.Errorf("time to fetch query results exceeded logs timeout")
 		}
+	}
+}
+
+// stopAbandonedQuery stops a query nothing polls anymore. Queries that identical queries may reuse are left running,
+// as a later request could still read their results.
+func (ds *DataSource) stopAbandonedQuery(ctx context.Context, logsClient models.CWLogsClient, logsQuery models.LogsQuery) {
+	if ds.Settings.LogsQueryReuseTTL.Duration > 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), stopAbandonedQueryTimeout)
+	defer cancel()
+	if _, err := ds.executeStopQuery(ctx, logsClient, logsQuery); err != nil {
+		ds.logger.FromContext(ctx).Warn("Failed to stop an abandoned Logs Insights query", "queryId", logsQuery.QueryId, "error", err)
+	}
+}
+
+// logsQueryPollers counts the requests polling each Logs Insights query, as identical queries started concurrently
+// share a single query, which is only abandoned once none of them polls it anymore.
+type logsQueryPollers struct {
+	mu      sync.Mutex
+	pollers map[string]int // query id -> number of requests polling it
+}
 
-		attemptCount++
+func newLogsQueryPollers() *logsQueryPollers {
+	return &logsQueryPollers{pollers: map[string]int{}}
+}
+
+func (p *logsQueryPollers) acquire(queryId string) {
+	if p == nil {
+		return
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pollers[queryId]++
+}
 
-	return nil, nil
+// release reports whether the request was the last one polling the query. A nil tracker considers every request the
+// last one.
+func (p *logsQueryPollers) release(queryId string) bool {
+	if p == nil {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pollers[queryId]--
+	if p.pollers[queryId] > 0 {
+		return false
+	}
+	delete(p.pollers, queryId)
+	return true
 }
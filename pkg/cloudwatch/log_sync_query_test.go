@@ -170,6 +170,32 @@ func Test_executeSyncLogQuery(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, true, syncCalled)
 	})
+
+	t.Run("starts queries in their query language", func(t *testing.T) {
+		cli = fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{Status: "Complete"}}
+		ds := newTestDatasource()
+
+		_, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Headers:       map[string]string{headerFromAlert: "some value"},
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{
+					TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)},
+					JSON: json.RawMessage(`{
+						"queryMode":     "Logs",
+						"region":        "us-east-1",
+						"queryLanguage": "PPL",
+						"queryString":   "source = logs | stats count() by status"
+					}`),
+				},
+			},
+		})
+
+		assert.NoError(t, err)
+		require.Len(t, cli.calls.startQuery, 1)
+		assert.Equal(t, cloudwatchlogstypes.QueryLanguagePpl, cli.calls.startQuery[0].QueryLanguage)
+		assert.Equal(t, "source = logs | stats count() by status", aws.ToString(cli.calls.startQuery[0].QueryString))
+	})
 }
 func Test_executeSyncLogQuery_handles_RefId_from_input_queries(t *testing.T) {
 	origNewCWClient := NewCWClient
@@ -377,3 +403,64 @@ func Test_executeSyncLogQuery_handles_RefId_from_input_queries(t *testing.T) {
 		require.Nil(t, err)
 	})
 }
+
+func Test_syncQuery_stops_abandoned_queries(t *testing.T) {
+	query := backend.DataQuery{TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)}}
+	logsQuery := models.LogsQuery{}
+	logsQuery.QueryString = "fields @message"
+
+	t.Run("stops the query when the request is cancelled", func(t *testing.T) {
+		cli := &fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{Status: "Running"}}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.logsPollers = newLogsQueryPollers()
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := ds.syncQuery(ctx, cli, query, logsQuery, time.Minute)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		require.Len(t, cli.calls.stopQuery, 1)
+		assert.Equal(t, "abcd-efgh-ijkl-mnop", aws.ToString(cli.calls.stopQuery[0].QueryId))
+		assert.Empty(t, ds.logsPollers.pollers)
+	})
+
+	t.Run("doesn't stop a query another request polls", func(t *testing.T) {
+		cli := &fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{Status: "Running"}}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.logsPollers = newLogsQueryPollers()
+		})
+		ds.logsPollers.acquire("abcd-efgh-ijkl-mnop")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := ds.syncQuery(ctx, cli, query, logsQuery, time.Minute)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, cli.calls.stopQuery)
+	})
+
+	t.Run("doesn't stop a query identical queries may reuse", func(t *testing.T) {
+		cli := &fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{Status: "Running"}}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.LogsQueryReuseTTL = models.Duration{Duration: time.Minute}
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := ds.syncQuery(ctx, cli, query, logsQuery, time.Minute)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, cli.calls.stopQuery)
+	})
+
+	t.Run("doesn't stop a finished query", func(t *testing.T) {
+		cli := &fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{Status: "Complete"}}
+		ds := newTestDatasource()
+
+		_, err := ds.syncQuery(context.Background(), cli, query, logsQuery, time.Minute)
+
+		assert.NoError(t, err)
+		assert.Empty(t, cli.calls.stopQuery)
+	})
+}
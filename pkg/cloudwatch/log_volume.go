@@ -0,0 +1,145 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const (
+	// logsVolumeLevelField is the field logs volume queries count the log events by. It's the level field of
+	// structured logs, or else the first level found in the message of the log events.
+	logsVolumeLevelField = "grafanaLogsVolumeLevel"
+	logsVolumeCountField = "grafanaLogsVolumeCount"
+	logsVolumeLabel      = "level"
+	logsVolumeNoLevel    = "unknown"
+)
+
+// logsVolumeLevelParse extracts the level of the log events that don't have a level field from their message
+const logsVolumeLevelParse = `parse @message /(?i)\b(?<grafanaDetectedLevel>critical|fatal|error|err|warning|warn|info|debug|trace)\b/`
+
+// logsVolumeDroppedCommands are the commands of a query that don't change which log events match it, and that are
+// dropped so that all the matching log events are counted
+var logsVolumeDroppedCommands = []string{"sort", "limit", "display"}
+
+// logsVolumeUnsupportedCommands are the commands of queries whose results aren't log events
+var logsVolumeUnsupportedCommands = []string{"stats", "pattern", "diff", "anomaly"}
+
+func isLogsVolumeQuery(logsQuery models.LogsQuery) bool {
+	return logsQuery.SupplementaryType != nil && *logsQuery.SupplementaryType == dataquery.LogsSupplementaryTypeLogsVolume
+}
+
+// logsVolumeQueryString rewrites a Logs Insights query into one counting the log events matching it by bins of
+// binSize and level, for the logs volume histogram Explore shows above the log events.
+func logsVolumeQueryString(queryString string, binSize time.Duration) (string, error) {
+	commands := []string{}
+	for _, command := range splitLogsInsightsCommands(queryString) {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			continue
+		}
+		name := strings.ToLower(strings.Fields(command)[0])
+		if slices.Contains(logsVolumeUnsupportedCommands, name) {
+			return "", fmt.Errorf("the logs volume can't be shown for queries using the %s command, as their results aren't log events", name)
+		}
+		if !slices.Contains(logsVolumeDroppedCommands, name) {
+			commands = append(commands, command)
+		}
+	}
+	commands = append(commands,
+		logsVolumeLevelParse,
+		fmt.Sprintf("fields coalesce(level, grafanaDetectedLevel) as %s", logsVolumeLevelField),
+		fmt.Sprintf("stats count(*) as %s by bin(%s), %s", logsVolumeCountField, formatBinSize(binSize), logsVolumeLevelField),
+	)
+	return strings.Join(commands, " | "), nil
+}
+
+// splitLogsInsightsCommands splits a query into its commands, ignoring the pipes of strings and regular expressions.
+// A slash only starts a regular expression after a space, an opening parenthesis, a comma or a tilde, so that
+// divisions such as bytes/1024 aren't mistaken for one.
+func splitLogsInsightsCommands(queryString string) []string {
+	var commands []string
+	var delimiter rune
+	start := 0
+	previous := ' '
+	for i, char := range queryString {
+		switch {
+		case delimiter != 0:
+			if char == delimiter && previous != '\\' {
+				delimiter = 0
+			}
+		case char == '"' || char == '\'' || char == '`':
+			delimiter = char
+		case char == '/' && strings.ContainsRune(" \t\n(,~", previous):
+			delimiter = char
+		case char == '|':
+			commands = append(commands, queryString[start:i])
+			start = i + 1
+		}
+		previous = char
+	}
+	return append(commands, queryString[start:])
+}
+
+// logsVolumeFrames turns the results of a logs volume query into a series per level, labelled with the level as
+// Explore expects for its logs volume histogram. Results without rows are returned as they are, for their status.
+func logsVolumeFrames(frame *data.Frame) data.Frames {
+	var timeField, levelField, countField *data.Field
+	for _, field := range frame.Fields {
+		switch {
+		case field.Name == logsVolumeLevelField:
+			levelField = field
+		case field.Name == logsVolumeCountField:
+			countField = field
+		case field.Type() == data.FieldTypeNullableTime:
+			timeField = field
+		}
+	}
+	if timeField == nil || countField == nil || frame.Rows() == 0 {
+		return data.Frames{frame}
+	}
+
+	var levels []string
+	framesByLevel := map[string]*data.Frame{}
+	for row := 0; row < frame.Rows(); row++ {
+		timestamp, ok := timeField.ConcreteAt(row)
+		if !ok {
+			continue
+		}
+		count, err := countField.NullableFloatAt(row)
+		if err != nil || count == nil {
+			continue
+		}
+		level := logsVolumeNoLevel
+		if levelField != nil {
+			if value, ok := levelField.ConcreteAt(row); ok && fmt.Sprint(value) != "" {
+				level = strings.ToLower(fmt.Sprint(value))
+			}
+		}
+
+		levelFrame, ok := framesByLevel[level]
+		if !ok {
+			valueField := data.NewField(data.TimeSeriesValueFieldName, data.Labels{logsVolumeLabel: level}, []float64{})
+			valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: level})
+			levelFrame = data.NewFrame(level, data.NewField(data.TimeSeriesTimeFieldName, nil, []time.Time{}), valueField)
+			levelFrame.RefID = frame.RefID
+			levelFrame.Meta = frame.Meta
+			framesByLevel[level] = levelFrame
+			levels = append(levels, level)
+		}
+		levelFrame.AppendRow(timestamp.(time.Time), *count)
+	}
+
+	slices.Sort(levels)
+	frames := make(data.Frames, 0, len(levels))
+	for _, level := range levels {
+		frames = append(frames, framesByLevel[level])
+	}
+	return frames
+}
@@ -0,0 +1,145 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_logsVolumeQueryString(t *testing.T) {
+	t.Run("counts the log events matching the query by bin and level", func(t *testing.T) {
+		queryString, err := logsVolumeQueryString(`fields @timestamp, @message | filter @message like /a|b/ and status = "5|x" | sort @timestamp desc | limit 20`, time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, `fields @timestamp, @message | filter @message like /a|b/ and status = "5|x" | `+logsVolumeLevelParse+
+			` | fields coalesce(level, grafanaDetectedLevel) as grafanaLogsVolumeLevel`+
+			` | stats count(*) as grafanaLogsVolumeCount by bin(1m), grafanaLogsVolumeLevel`, queryString)
+	})
+
+	t.Run("counts all the log events of empty queries", func(t *testing.T) {
+		queryString, err := logsVolumeQueryString("", 5*time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, logsVolumeLevelParse+` | fields coalesce(level, grafanaDetectedLevel) as grafanaLogsVolumeLevel`+
+			` | stats count(*) as grafanaLogsVolumeCount by bin(5m), grafanaLogsVolumeLevel`, queryString)
+	})
+
+	t.Run("fails queries whose results aren't log events", func(t *testing.T) {
+		_, err := logsVolumeQueryString("fields bytes/1024 as kb | STATS avg(kb) by bin(1h)", time.Minute)
+
+		assert.EqualError(t, err, "the logs volume can't be shown for queries using the stats command, as their results aren't log events")
+	})
+}
+
+func Test_logsVolumeFrames(t *testing.T) {
+	t.Run("returns a series per level", func(t *testing.T) {
+		bin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		frame := data.NewFrame("A",
+			data.NewField("bin(1m)", nil, []*time.Time{&bin, &bin, aws.Time(bin.Add(time.Minute))}),
+			data.NewField(logsVolumeLevelField, nil, []*string{aws.String("ERROR"), nil, aws.String("error")}),
+			data.NewField(logsVolumeCountField, nil, []*float64{aws.Float64(2), aws.Float64(5), aws.Float64(1)}),
+		)
+		frame.RefID = "A"
+
+		frames := logsVolumeFrames(frame)
+
+		require.Len(t, frames, 2)
+		assert.Equal(t, data.Labels{"level": "error"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, []time.Time{bin, bin.Add(time.Minute)}, []time.Time{frames[0].Fields[0].At(0).(time.Time), frames[0].Fields[0].At(1).(time.Time)})
+		assert.Equal(t, []float64{2, 1}, []float64{frames[0].Fields[1].At(0).(float64), frames[0].Fields[1].At(1).(float64)})
+		assert.Equal(t, data.Labels{"level": "unknown"}, frames[1].Fields[1].Labels)
+		assert.Equal(t, "A", frames[1].RefID)
+	})
+
+	t.Run("returns results without rows as they are", func(t *testing.T) {
+		frame := data.NewFrame("A")
+
+		assert.Equal(t, data.Frames{frame}, logsVolumeFrames(frame))
+	})
+}
+
+func TestQuery_LogsVolume(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli fakeCWLogsClient
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return &cli
+	}
+	queryData := func(t *testing.T, queryJSON string) backend.DataResponse {
+		t.Helper()
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:         "A",
+				TimeRange:     backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)},
+				MaxDataPoints: 60,
+				JSON:          json.RawMessage(queryJSON),
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("starts the logs volume query of the query", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+
+		response := queryData(t, `{"type":"logAction","subtype":"StartQuery","limit":20,"supplementaryType":"LogsVolume",
+			"queryString":"fields @message | limit 20"}`)
+
+		require.NoError(t, response.Error)
+		require.Len(t, cli.calls.startQuery, 1)
+		assert.Nil(t, cli.calls.startQuery[0].Limit)
+		assert.Contains(t, aws.ToString(cli.calls.startQuery[0].QueryString),
+			"|fields @message | "+logsVolumeLevelParse)
+		assert.Contains(t, aws.ToString(cli.calls.startQuery[0].QueryString), "by bin(1m), grafanaLogsVolumeLevel")
+	})
+
+	t.Run("returns the results of the logs volume query by level", func(t *testing.T) {
+		cli = fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{
+			Status: cloudwatchlogstypes.QueryStatusComplete,
+			Results: [][]cloudwatchlogstypes.ResultField{
+				{
+					{Field: aws.String("bin(1m)"), Value: aws.String("2024-01-01 00:00:00.000")},
+					{Field: aws.String(logsVolumeLevelField), Value: aws.String("info")},
+					{Field: aws.String(logsVolumeCountField), Value: aws.String("3")},
+				},
+				{
+					{Field: aws.String("bin(1m)"), Value: aws.String("2024-01-01 00:00:00.000")},
+					{Field: aws.String(logsVolumeLevelField), Value: aws.String("warn")},
+					{Field: aws.String(logsVolumeCountField), Value: aws.String("1")},
+				},
+			},
+		}}
+
+		response := queryData(t, `{"type":"logAction","subtype":"GetQueryResults","queryId":"abc","supplementaryType":"LogsVolume"}`)
+
+		require.NoError(t, response.Error)
+		require.Len(t, response.Frames, 2)
+		assert.Equal(t, "info", response.Frames[0].Fields[1].Labels["level"])
+		assert.Equal(t, "warn", response.Frames[1].Fields[1].Labels["level"])
+		assert.Equal(t, "Complete", response.Frames[1].Meta.Custom.(map[string]any)["Status"])
+	})
+
+	t.Run("fails the logs volume of SQL queries", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+
+		response := queryData(t, `{"type":"logAction","subtype":"StartQuery","queryLanguage":"SQL","supplementaryType":"LogsVolume",
+			"queryString":"SELECT * FROM logs"}`)
+
+		require.Error(t, response.Error)
+		assert.Empty(t, cli.calls.startQuery)
+	})
+}
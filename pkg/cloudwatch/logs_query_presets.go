@@ -0,0 +1,33 @@
+package cloudwatch
+
+import (
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// logsQueryPreset is a ready-made Logs Insights query served by one of the preset resource routes
+type logsQueryPreset struct {
+	id          string
+	label       string
+	description string
+	// query is the part of the query following the filter restricting it to what the presets are requested for
+	query       string
+	statsGroups []string
+}
+
+// logsQueryPresetsResponse returns the presets as queries of the log group, starting with the filter.
+func logsQueryPresetsResponse(presets []logsQueryPreset, logGroupName string, filter string) []resources.ResourceResponse[resources.LogsQueryPreset] {
+	response := make([]resources.ResourceResponse[resources.LogsQueryPreset], 0, len(presets))
+	for _, preset := range presets {
+		response = append(response, resources.ResourceResponse[resources.LogsQueryPreset]{
+			Label: preset.label,
+			Value: resources.LogsQueryPreset{
+				Id:            preset.id,
+				Description:   preset.description,
+				Expression:    filter + preset.query,
+				LogGroupNames: []string{logGroupName},
+				StatsGroups:   append([]string{}, preset.statsGroups...),
+			},
+		})
+	}
+	return response
+}
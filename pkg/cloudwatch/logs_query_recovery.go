@@ -0,0 +1,164 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// runningLogsQueriesDir is where the running Logs Insights queries of each data source are persisted. It doesn't
+// need to outlive the host, as CloudWatch cancels the queries by their query timeout anyway.
+var runningLogsQueriesDir = filepath.Join(os.TempDir(), "grafana-cloudwatch-datasource", "running-logs-queries")
+
+// runningLogsQueriesPath returns the file the running queries of the data source are persisted to, or an empty string
+// if they can't be, as the data source doesn't have a UID.
+func runningLogsQueriesPath(uid string) string {
+	if uid == "" {
+		return ""
+	}
+	return filepath.Join(runningLogsQueriesDir, uid+".json")
+}
+
+// loadRunningLogsQueries returns a tracker of the queries persisted to path that haven't timed out yet, which persists
+// the queries it tracks to path. Failing to read them only loses them, as the tracker is best effort.
+func loadRunningLogsQueries(path string, logger log.Logger) *runningLogsQueries {
+	running := newRunningLogsQueries()
+	running.logger = logger
+	if path == "" {
+		return running
+	}
+	running.path = path
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("Failed to read the running Logs Insights queries", "path", path, "error", err)
+		}
+		return running
+	}
+	var queries []runningLogsQuery
+	if err := json.Unmarshal(content, &queries); err != nil {
+		logger.Warn("Failed to read the running Logs Insights queries", "path", path, "error", err)
+		return running
+	}
+	for _, query := range queries {
+		// the panels of the recovered queries are given the time to re-attach to them
+		query.PolledAt = running.now()
+		if running.queries[query.Region] == nil {
+			running.queries[query.Region] = map[string]runningLogsQuery{}
+		}
+		running.queries[query.Region][query.QueryId] = query
+	}
+	running.expire()
+	return running
+}
+
+// save persists the queries to the path of the tracker, if it has one. r.mu must be held.
+func (r *runningLogsQueries) save() {
+	if r.path == "" {
+		return
+	}
+	queries := []runningLogsQuery{}
+	for _, regionQueries := range r.queries {
+		for _, query := range regionQueries {
+			queries = append(queries, query)
+		}
+	}
+	if err := writeFileAtomically(r.path, queries); err != nil && r.logger != nil {
+		r.logger.Warn("Failed to persist the running Logs Insights queries", "path", r.path, "error", err)
+	}
+}
+
+// flush persists the queries that haven't timed out yet, so that the next instance doesn't recover expired ones.
+func (r *runningLogsQueries) flush() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire()
+	r.save()
+}
+
+// writeFileAtomically writes value as JSON to a temporary file renamed to path, so that a restart of the plugin while
+// writing doesn't leave a truncated file behind.
+func writeFileAtomically(path string, value any) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// restoreReusableLogsQueries lets identical queries reuse the recovered queries again, so that refreshing a dashboard
+// after a restart of the plugin re-attaches to its queries instead of running them from scratch.
+func (ds *DataSource) restoreReusableLogsQueries() {
+	ttl := ds.Settings.LogsQueryReuseTTL.Duration
+	if ds.logsQueryIds == nil || ttl <= 0 {
+		return
+	}
+	ds.runningLogs.mu.Lock()
+	defer ds.runningLogs.mu.Unlock()
+	for _, regionQueries := range ds.runningLogs.queries {
+		for _, query := range regionQueries {
+			if remaining := ttl - time.Since(query.StartedAt); query.Key != "" && remaining > 0 {
+				ds.logsQueryIds.Set(query.Key, query.QueryId, remaining)
+			}
+		}
+	}
+}
+
+// RunningLogsQueriesHandler returns the Logs Insights queries the data source started for the org of the request, and
+// user when users' own identity is used to query AWS, and hasn't seen finish yet, restricted to the region given by the region parameter if there is one, so that the
+// frontend can re-attach to them by their query ID.
+func (ds *DataSource) RunningLogsQueriesHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	region := parameters.Get("region")
+	if region != "" {
+		region = ds.logsRegion(region)
+	}
+
+	pCtx := backend.PluginConfigFromContext(ctx)
+	response := []resources.RunningLogsQuery{}
+	for _, query := range ds.runningLogs.list(region) {
+		if !ds.isRunningLogsQueryOf(pCtx, query) {
+			continue
+		}
+		response = append(response, resources.RunningLogsQuery{
+			QueryId:   query.QueryId,
+			Region:    query.Region,
+			StartedAt: query.StartedAt,
+		})
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in RunningLogsQueriesHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
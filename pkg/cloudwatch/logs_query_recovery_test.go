@@ -0,0 +1,111 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func Test_loadRunningLogsQueries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "datasource.json")
+
+	running := loadRunningLogsQueries(path, log.NewNullLogger())
+	running.add(runningLogsQuery{Region: "us-east-1", QueryId: "a", OrgId: 1, Key: "key"}, time.Hour)
+	running.add(runningLogsQuery{Region: "us-east-1", QueryId: "b", OrgId: 1}, time.Hour)
+	running.add(runningLogsQuery{Region: "eu-west-1", QueryId: "c", OrgId: 1}, -time.Minute)
+	running.remove("us-east-1", "b")
+
+	recovered := loadRunningLogsQueries(path, log.NewNullLogger())
+	queries := recovered.list("")
+	require.Len(t, queries, 1)
+	assert.Equal(t, "a", queries[0].QueryId)
+	assert.Equal(t, "key", queries[0].Key)
+	assert.Equal(t, 1, recovered.count("us-east-1", ""))
+	assert.Equal(t, 0, recovered.count("eu-west-1", ""))
+
+	t.Run("starts empty if the queries can't be read", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+		assert.Equal(t, 0, loadRunningLogsQueries(path, log.NewNullLogger()).count("us-east-1", ""))
+	})
+}
+
+func Test_restoreReusableLogsQueries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "datasource.json")
+	running := loadRunningLogsQueries(path, log.NewNullLogger())
+	running.add(runningLogsQuery{Region: "us-east-1", QueryId: "a", Key: "key"}, time.Hour)
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.LogsQueryReuseTTL = models.Duration{Duration: time.Minute}
+		ds.logsQueryIds = cache.New(cache.NoExpiration, 0)
+		ds.runningLogs = loadRunningLogsQueries(path, log.NewNullLogger())
+	})
+	ds.restoreReusableLogsQueries()
+
+	queryId, ok := ds.reusableLogsQueryId("key")
+	assert.True(t, ok)
+	assert.Equal(t, "a", queryId)
+}
+
+func Test_running_logs_queries_route(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.Region = "us-east-1"
+		ds.runningLogs = newRunningLogsQueries()
+	})
+	ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "a", OrgId: 1}, time.Hour)
+	ds.runningLogs.add(runningLogsQuery{Region: "eu-west-1", QueryId: "b", OrgId: 1}, time.Hour)
+	ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "c", OrgId: 2}, time.Hour)
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.RunningLogsQueriesHandler))
+	getQueries := func(t *testing.T, query string) []resources.RunningLogsQuery {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 1, User: &backend.User{Login: "alice"}})
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/running-logs-queries"+query, nil).WithContext(ctx))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var queries []resources.RunningLogsQuery
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &queries))
+		return queries
+	}
+
+	t.Run("returns the queries of the org", func(t *testing.T) {
+		queries := getQueries(t, "")
+
+		require.Len(t, queries, 2)
+		assert.Equal(t, "a", queries[0].QueryId)
+		assert.Equal(t, "b", queries[1].QueryId)
+	})
+
+	t.Run("returns the queries of the default region", func(t *testing.T) {
+		queries := getQueries(t, "?region=default")
+
+		require.Len(t, queries, 1)
+		assert.Equal(t, "a", queries[0].QueryId)
+		assert.Equal(t, "us-east-1", queries[0].Region)
+	})
+
+	t.Run("returns the queries of the user with user identity pass-through", func(t *testing.T) {
+		ds.Settings.UserIdentityPassThrough = true
+		t.Cleanup(func() { ds.Settings.UserIdentityPassThrough = false })
+		ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "d", OrgId: 1, User: "alice"}, time.Hour)
+		ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "e", OrgId: 1, User: "bob"}, time.Hour)
+
+		queries := getQueries(t, "")
+
+		require.Len(t, queries, 1)
+		assert.Equal(t, "d", queries[0].QueryId)
+	})
+}
@@ -0,0 +1,363 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	// logsServiceCode is the Service Quotas code of CloudWatch Logs
+	logsServiceCode = "logs"
+	// logsQuotasExpiration is how long the Logs Insights quotas of a region are cached
+	logsQuotasExpiration = time.Hour
+	// logsQuotasFetchTimeout bounds fetching the quotas of the default region when the data source is created
+	logsQuotasFetchTimeout = 10 * time.Second
+	// abandonedLogsQueryTimeout is how long a running query is tracked without being polled, after which it's
+	// considered abandoned, e.g. by a panel that was closed, and no longer counts against the quota
+	abandonedLogsQueryTimeout = 5 * time.Minute
+
+	// defaultLogsConcurrentQueries and defaultLogsQueryTimeout are the default Logs Insights quotas, used when the
+	// quotas of the account can't be fetched, e.g. because the credentials aren't allowed to list them
+	defaultLogsConcurrentQueries = 30
+	defaultLogsQueryTimeout      = time.Hour
+)
+
+// logsQuotas are the Logs Insights quotas of an account in a region. Accounts are identified by the role queries run
+// with, as organizations mapped to different roles may query different accounts.
+type logsQuotas struct {
+	concurrentQueries int
+	queryTimeout      time.Duration
+}
+
+func defaultLogsQuotas() logsQuotas {
+	return logsQuotas{concurrentQueries: defaultLogsConcurrentQueries, queryTimeout: defaultLogsQueryTimeout}
+}
+
+// logsRegion returns the region logs queries of region run in, resolving the default region of the data source.
+func (ds *DataSource) logsRegion(region string) string {
+	if region == "" || region == defaultRegion {
+		return ds.Settings.Region
+	}
+	return region
+}
+
+// warmLogsQuotas fetches the Logs Insights quotas of the default region in the background, so that the first logs
+// query doesn't wait for them. They aren't warmed when the role of queries depends on the org or user of the request,
+// as there's no request to resolve it for.
+func (ds *DataSource) warmLogsQuotas() {
+	if ds.Settings.Region == "" || len(ds.Settings.OrgRoleMap) > 0 || ds.Settings.UserIdentityPassThrough {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), logsQuotasFetchTimeout)
+		defer cancel()
+		ds.getLogsQuotas(ctx, ds.Settings.Region)
+	}()
+}
+
+// getLogsQuotas returns the Logs Insights quotas of region in the account of the request, which are cached. Failing
+// to fetch them falls back to the default quotas, as they only make the errors of the data source clearer.
+func (ds *DataSource) getLogsQuotas(ctx context.Context, region string) logsQuotas {
+	if ds.logsQuotas == nil {
+		return defaultLogsQuotas()
+	}
+	role, err := ds.logsQueryRole(ctx)
+	if err != nil {
+		return defaultLogsQuotas()
+	}
+	key := logsQuotasKey(region, role)
+	if cached, found := ds.logsQuotas.Get(key); found {
+		return cached.(logsQuotas)
+	}
+	quotas, err := ds.fetchLogsQuotas(ctx, region)
+	if err != nil {
+		ds.logger.FromContext(ctx).Debug("Failed to fetch the Logs Insights quotas, using the default quotas", "region", region, "error", err)
+		quotas = defaultLogsQuotas()
+	}
+	ds.logsQuotas.Set(key, quotas, cache.DefaultExpiration)
+	return quotas
+}
+
+func logsQuotasKey(region, role string) string {
+	return region + "|" + role
+}
+
+// logsQueryRole returns the role the Logs Insights queries of the request run with, the role mapped to the user when
+// their own identity is used to query AWS.
+func (ds *DataSource) logsQueryRole(ctx context.Context) (string, error) {
+	if ds.Settings.UserIdentityPassThrough {
+		return ds.webIdentityRoleForUser(backend.PluginConfigFromContext(ctx).User)
+	}
+	return ds.assumeRoleARN(ctx)
+}
+
+func (ds *DataSource) fetchLogsQuotas(ctx context.Context, region string) (logsQuotas, error) {
+	cfg, err := ds.getAWSConfig(ctx, region)
+	if err != nil {
+		return logsQuotas{}, err
+	}
+	quotas := defaultLogsQuotas()
+	paginator := servicequotas.NewListServiceQuotasPaginator(NewServiceQuotasAPI(cfg), &servicequotas.ListServiceQuotasInput{
+		ServiceCode: aws.String(logsServiceCode),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return logsQuotas{}, err
+		}
+		for _, quota := range page.Quotas {
+			quotas.apply(quota)
+		}
+	}
+	return quotas, nil
+}
+
+// apply sets the Logs Insights quota the service quota is, if any. Quotas are matched by name, as their codes aren't
+// documented.
+func (q *logsQuotas) apply(quota servicequotastypes.ServiceQuota) {
+	name := strings.ToLower(aws.ToString(quota.QuotaName))
+	if quota.Value == nil || *quota.Value <= 0 || !strings.Contains(name, "insights") {
+		return
+	}
+	switch {
+	case strings.Contains(name, "concurrent"):
+		q.concurrentQueries = int(*quota.Value)
+	case strings.Contains(name, "timeout"):
+		q.queryTimeout = quotaDuration(*quota.Value, aws.ToString(quota.Unit))
+	}
+}
+
+// quotaDuration returns the duration of a quota value, which is in minutes unless its unit says otherwise.
+func quotaDuration(value float64, unit string) time.Duration {
+	switch strings.ToLower(unit) {
+	case "seconds":
+		return time.Duration(value * float64(time.Second))
+	case "milliseconds":
+		return time.Duration(value * float64(time.Millisecond))
+	case "hours":
+		return time.Duration(value * float64(time.Hour))
+	}
+	return time.Duration(value * float64(time.Minute))
+}
+
+// concurrentQueriesExceededError returns the error of starting a query while the queries running in region are
+// already at the concurrent queries quota. It's a LimitExceededException like the error of CloudWatch, so that the
+// frontend retries the query the same way.
+func concurrentQueriesExceededError(region string, running int) error {
+	return &cloudwatchlogstypes.LimitExceededException{Message: aws.String(fmt.Sprintf(
+		"%d Logs Insights queries are already running in %s, which is the concurrent queries quota of the account; "+
+			"wait for them to finish or request a quota increase", running, region))}
+}
+
+// runningLogsQueries tracks the Logs Insights queries the data source started and hasn't seen finish yet, per
+// region, so that queries beyond the concurrent queries quota of their account fail before being started. It's best
+// effort, as the account may run other queries too. Queries are forgotten once they finish, are stopped, aren't
+// polled anymore, or their query timeout has passed, as CloudWatch cancels them by then. With a path, the queries are
+// persisted to it, so that they're recovered after a restart of the plugin.
+type runningLogsQueries struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	path    string
+	logger  log.Logger
+	queries map[string]map[string]runningLogsQuery // region -> query id -> query
+}
+
+// runningLogsQuery is a Logs Insights query the data source started
+type runningLogsQuery struct {
+	QueryId string `json:"queryId"`
+	Region  string `json:"region"`
+	// OrgId is the org the query was started for, as only its users may re-attach to it
+	OrgId int64 `json:"orgId"`
+	// User is the login of the user the query was started for when their own identity is used to query AWS
+	User string `json:"user,omitempty"`
+	// Role is the role the query was started with, which identifies the account it runs in
+	Role string `json:"role,omitempty"`
+	// Key identifies the query for identical queries to reuse it, empty if queries aren't reused
+	Key        string    `json:"key,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	TimesOutAt time.Time `json:"timesOutAt"`
+	// PolledAt is when the results of the query were last read
+	PolledAt time.Time `json:"polledAt"`
+}
+
+// newRunningLogsQuery returns the query with queryId started in region for the org, user and role of ctx.
+func (ds *DataSource) newRunningLogsQuery(ctx context.Context, region, queryId, key string) runningLogsQuery {
+	pCtx := backend.PluginConfigFromContext(ctx)
+	query := runningLogsQuery{QueryId: queryId, Region: region, OrgId: pCtx.OrgID, Key: key}
+	if ds.Settings.UserIdentityPassThrough && pCtx.User != nil {
+		query.User = pCtx.User.Login
+	}
+	query.Role, _ = ds.logsQueryRole(ctx)
+	return query
+}
+
+// runningLogsQueryContext returns ctx with the org, user and role the query was started with, so that the calls made
+// for the query once the request that started it is gone, e.g. to stop it, are made in the account it runs in.
+func (ds *DataSource) runningLogsQueryContext(ctx context.Context, query runningLogsQuery) context.Context {
+	pCtx := backend.PluginConfigFromContext(ctx)
+	pCtx.OrgID = query.OrgId
+	if query.User != "" {
+		pCtx.User = &backend.User{Login: query.User}
+	}
+	ctx = backend.WithPluginContext(ctx, pCtx)
+	if ds.Settings.UserIdentityPassThrough {
+		return withUserRole(ctx, query.Role)
+	}
+	return withQueryRole(ctx, query.Role)
+}
+
+// isRunningLogsQueryOf reports whether the query was started by the org of pCtx and, when users' own identity is used
+// to query AWS, its user.
+func (ds *DataSource) isRunningLogsQueryOf(pCtx backend.PluginContext, query runningLogsQuery) bool {
+	if query.OrgId != pCtx.OrgID {
+		return false
+	}
+	if !ds.Settings.UserIdentityPassThrough {
+		return true
+	}
+	return pCtx.User != nil && query.User == pCtx.User.Login
+}
+
+func newRunningLogsQueries() *runningLogsQueries {
+	return &runningLogsQueries{now: time.Now, queries: map[string]map[string]runningLogsQuery{}}
+}
+
+// count returns the number of queries running in region with role. A nil tracker tracks no queries.
+func (r *runningLogsQueries) count(region, role string) int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire()
+	count := 0
+	for _, query := range r.queries[region] {
+		if query.Role == role {
+			count++
+		}
+	}
+	return count
+}
+
+// get returns the query with queryId running in region.
+func (r *runningLogsQueries) get(region, queryId string) (runningLogsQuery, bool) {
+	if r == nil {
+		return runningLogsQuery{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire()
+	query, ok := r.queries[region][queryId]
+	return query, ok
+}
+
+// add tracks a query started now, which CloudWatch cancels after timeout.
+func (r *runningLogsQueries) add(query runningLogsQuery, timeout time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	query.StartedAt = r.now()
+	query.TimesOutAt = query.StartedAt.Add(timeout)
+	query.PolledAt = query.StartedAt
+	if r.queries[query.Region] == nil {
+		r.queries[query.Region] = map[string]runningLogsQuery{}
+	}
+	r.queries[query.Region][query.QueryId] = query
+	r.save()
+}
+
+// polled records that the results of a query were read. It isn't persisted, as recovered queries are given the time
+// to be polled again anyway.
+func (r *runningLogsQueries) polled(region, queryId string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if query, ok := r.queries[region][queryId]; ok {
+		query.PolledAt = r.now()
+		r.queries[region][queryId] = query
+	}
+}
+
+func (r *runningLogsQueries) remove(region, queryId string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.queries[region][queryId]; !ok {
+		return
+	}
+	delete(r.queries[region], queryId)
+	r.save()
+}
+
+// list returns the queries running in region, or in every region if it's empty, oldest first.
+func (r *runningLogsQueries) list(region string) []runningLogsQuery {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire()
+	var queries []runningLogsQuery
+	for queryRegion, regionQueries := range r.queries {
+		if region != "" && queryRegion != region {
+			continue
+		}
+		for _, query := range regionQueries {
+			queries = append(queries, query)
+		}
+	}
+	slices.SortFunc(queries, func(a, b runningLogsQuery) int {
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.QueryId, b.QueryId)
+	})
+	return queries
+}
+
+// all returns the queries running in every region, of every org.
+func (r *runningLogsQueries) all() []runningLogsQuery {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire()
+	var queries []runningLogsQuery
+	for _, regionQueries := range r.queries {
+		for _, query := range regionQueries {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+// expire forgets the queries whose timeout has passed, or that were abandoned. r.mu must be held.
+func (r *runningLogsQueries) expire() {
+	now := r.now()
+	for _, regionQueries := range r.queries {
+		for queryId, query := range regionQueries {
+			if !now.Before(query.TimesOutAt) || !now.Before(query.PolledAt.Add(abandonedLogsQueryTimeout)) {
+				delete(regionQueries, queryId)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type fakeServiceQuotasClient struct {
+	quotas []servicequotastypes.ServiceQuota
+	err    error
+	calls  []*servicequotas.ListServiceQuotasInput
+}
+
+func (c *fakeServiceQuotasClient) ListServiceQuotas(_ context.Context, input *servicequotas.ListServiceQuotasInput, _ ...func(*servicequotas.Options)) (*servicequotas.ListServiceQuotasOutput, error) {
+	c.calls = append(c.calls, input)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &servicequotas.ListServiceQuotasOutput{Quotas: c.quotas}, nil
+}
+
+func Test_getLogsQuotas(t *testing.T) {
+	origNewServiceQuotasAPI := NewServiceQuotasAPI
+	t.Cleanup(func() {
+		NewServiceQuotasAPI = origNewServiceQuotasAPI
+	})
+
+	newDatasource := func() *DataSource {
+		return newTestDatasource(func(ds *DataSource) {
+			ds.logsQuotas = cache.New(logsQuotasExpiration, logsQuotasExpiration)
+		})
+	}
+
+	t.Run("uses the Logs Insights quotas of the account and caches them", func(t *testing.T) {
+		client := &fakeServiceQuotasClient{quotas: []servicequotastypes.ServiceQuota{
+			{QuotaName: aws.String("Concurrent CloudWatch Logs Insights queries"), Value: aws.Float64(50), Unit: aws.String("None")},
+			{QuotaName: aws.String("CloudWatch Logs Insights query timeout"), Value: aws.Float64(15)},
+			{QuotaName: aws.String("Log groups"), Value: aws.Float64(1000000)},
+		}}
+		NewServiceQuotasAPI = func(aws.Config) servicequotas.ListServiceQuotasAPIClient { return client }
+		ds := newDatasource()
+
+		assert.Equal(t, logsQuotas{concurrentQueries: 50, queryTimeout: 15 * time.Minute}, ds.getLogsQuotas(context.Background(), "us-east-1"))
+		assert.Equal(t, logsQuotas{concurrentQueries: 50, queryTimeout: 15 * time.Minute}, ds.getLogsQuotas(context.Background(), "us-east-1"))
+		require.Len(t, client.calls, 1)
+		assert.Equal(t, "logs", aws.ToString(client.calls[0].ServiceCode))
+	})
+
+	t.Run("falls back to the default quotas when they can't be fetched", func(t *testing.T) {
+		client := &fakeServiceQuotasClient{err: errors.New("AccessDeniedException")}
+		NewServiceQuotasAPI = func(aws.Config) servicequotas.ListServiceQuotasAPIClient { return client }
+		ds := newDatasource()
+
+		assert.Equal(t, defaultLogsQuotas(), ds.getLogsQuotas(context.Background(), "us-east-1"))
+		assert.Equal(t, defaultLogsQuotas(), ds.getLogsQuotas(context.Background(), "us-east-1"))
+		assert.Len(t, client.calls, 1)
+	})
+
+	t.Run("caches the quotas per role", func(t *testing.T) {
+		client := &fakeServiceQuotasClient{}
+		NewServiceQuotasAPI = func(aws.Config) servicequotas.ListServiceQuotasAPIClient { return client }
+		ds := newDatasource()
+		ds.Settings.OrgRoleMap = map[string]string{
+			"1": "arn:aws:iam::111111111111:role/org-1",
+			"2": "arn:aws:iam::222222222222:role/org-2",
+		}
+		orgCtx := func(orgId int64) context.Context {
+			return backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: orgId})
+		}
+
+		ds.getLogsQuotas(orgCtx(1), "us-east-1")
+		ds.getLogsQuotas(orgCtx(2), "us-east-1")
+		ds.getLogsQuotas(orgCtx(2), "us-east-1")
+		assert.Len(t, client.calls, 2)
+
+		// the quotas of orgs without a role aren't fetched
+		assert.Equal(t, defaultLogsQuotas(), ds.getLogsQuotas(orgCtx(3), "us-east-1"))
+		assert.Len(t, client.calls, 2)
+	})
+}
+
+func Test_runningLogsQueries(t *testing.T) {
+	now := time.Unix(0, 0)
+	running := newRunningLogsQueries()
+	running.now = func() time.Time { return now }
+
+	running.add(runningLogsQuery{Region: "us-east-1", QueryId: "a"}, time.Minute)
+	running.add(runningLogsQuery{Region: "us-east-1", QueryId: "b"}, time.Hour)
+	running.add(runningLogsQuery{Region: "eu-west-1", QueryId: "c"}, time.Hour)
+	running.add(runningLogsQuery{Region: "us-east-1", QueryId: "d", Role: "arn:aws:iam::222222222222:role/grafana"}, time.Hour)
+	assert.Equal(t, 2, running.count("us-east-1", ""))
+	assert.Equal(t, 1, running.count("us-east-1", "arn:aws:iam::222222222222:role/grafana"))
+
+	running.remove("us-east-1", "b")
+	assert.Equal(t, 1, running.count("us-east-1", ""))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 0, running.count("us-east-1", ""))
+	assert.Equal(t, 1, running.count("eu-west-1", ""))
+
+	t.Run("forgets the queries that aren't polled anymore", func(t *testing.T) {
+		now := time.Unix(0, 0)
+		running := newRunningLogsQueries()
+		running.now = func() time.Time { return now }
+		running.add(runningLogsQuery{Region: "us-east-1", QueryId: "a"}, time.Hour)
+		running.add(runningLogsQuery{Region: "us-east-1", QueryId: "b"}, time.Hour)
+
+		now = now.Add(abandonedLogsQueryTimeout - time.Second)
+		running.polled("us-east-1", "a")
+		now = now.Add(time.Second)
+		queries := running.list("")
+		require.Len(t, queries, 1)
+		assert.Equal(t, "a", queries[0].QueryId)
+	})
+}
+
+func Test_executeStartQuery_concurrent_queries_quota(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.Region = "us-east-1"
+		ds.logsQuotas = cache.New(logsQuotasExpiration, logsQuotasExpiration)
+		ds.logsQuotas.Set(logsQuotasKey("us-east-1", ""), logsQuotas{concurrentQueries: 1, queryTimeout: time.Hour}, cache.DefaultExpiration)
+		ds.runningLogs = newRunningLogsQueries()
+	})
+	cli := &fakeCWLogsClient{}
+	query := backend.DataQuery{RefID: "A", TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(1, 0)}}
+	logsQuery := models.LogsQuery{CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{Region: "default"}, QueryString: "fields @message"}
+
+	_, err := ds.executeStartQuery(context.Background(), cli, logsQuery, query)
+	require.NoError(t, err)
+
+	_, err = ds.executeStartQuery(context.Background(), cli, logsQuery, query)
+	require.Error(t, err)
+	var limitErr *cloudwatchlogstypes.LimitExceededException
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Contains(t, err.Error(), "1 Logs Insights queries are already running in us-east-1")
+	assert.Len(t, cli.calls.startQuery, 1)
+
+	ds.runningLogs.remove("us-east-1", "abcd-efgh-ijkl-mnop")
+	_, err = ds.executeStartQuery(context.Background(), cli, logsQuery, query)
+	require.NoError(t, err)
+	assert.Len(t, cli.calls.startQuery, 2)
+}
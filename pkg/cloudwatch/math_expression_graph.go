@@ -0,0 +1,136 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+var (
+	// expressionStringRegex matches the string literals of an expression, e.g. the search term of SEARCH, which can't
+	// reference ids
+	expressionStringRegex = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+	// expressionIdRegex matches the ids an expression references. Ids start with a lowercase letter, while the functions
+	// and keywords of metric math are uppercase.
+	expressionIdRegex = regexp.MustCompile(`\b[a-z][a-zA-Z0-9_]*\b`)
+)
+
+// validateMathExpressions validates the references between the math expressions of the queries of a GetMetricData
+// request, so that an invalid expression fails its own query with a targeted error instead of AWS failing the whole
+// request with a generic ValidationError. An expression is invalid if it references an id which isn't a query of the
+// request, is part of a circular reference, references queries of different periods, or references an invalid query.
+// requestIds are the ids of all the queries of the data request, to tell ids of other regions and time ranges apart.
+// It returns the errors by refId.
+func validateMathExpressions(queries []*models.CloudWatchQuery, requestIds map[string]bool) map[string]error {
+	byId := make(map[string]*models.CloudWatchQuery, len(queries))
+	for _, query := range queries {
+		byId[query.Id] = query
+	}
+
+	references := map[string][]string{}
+	errs := map[string]error{}
+	for _, query := range queries {
+		if query.GetGetMetricDataAPIMode() != models.GMDApiModeMathExpression {
+			continue
+		}
+		for _, id := range expressionReferences(query.Expression) {
+			if _, ok := byId[id]; ok {
+				references[query.Id] = append(references[query.Id], id)
+				continue
+			}
+			if requestIds[id] {
+				errs[query.Id] = fmt.Errorf("math expression references %q, which is a query of another region or time range", id)
+			} else {
+				errs[query.Id] = fmt.Errorf("math expression references %q, which is not the id of a query", id)
+			}
+			break
+		}
+		if errs[query.Id] != nil || len(references[query.Id]) == 0 {
+			continue
+		}
+		first := byId[references[query.Id][0]]
+		for _, id := range references[query.Id][1:] {
+			if period := byId[id].Period; period != first.Period {
+				errs[query.Id] = fmt.Errorf("math expression references queries of different periods, %s of %ds and %s of %ds",
+					first.Id, first.Period, id, period)
+				break
+			}
+		}
+	}
+
+	v := &expressionGraphValidator{references: references, errs: errs, state: map[string]int{}}
+	for _, query := range queries {
+		v.visit(query.Id)
+	}
+
+	refIdErrs := map[string]error{}
+	for id, err := range errs {
+		refIdErrs[byId[id].RefId] = backend.DownstreamError(err)
+	}
+	return refIdErrs
+}
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// expressionGraphValidator walks the references of math expressions depth first, to find circular references and
+// expressions referencing invalid queries.
+type expressionGraphValidator struct {
+	references map[string][]string
+	errs       map[string]error
+	state      map[string]int
+	path       []string
+}
+
+func (v *expressionGraphValidator) visit(id string) {
+	if v.state[id] != unvisited {
+		return
+	}
+	v.state[id] = visiting
+	v.path = append(v.path, id)
+	for _, reference := range v.references[id] {
+		if v.state[reference] == visiting {
+			v.setCycleErrors(reference)
+			continue
+		}
+		v.visit(reference)
+		if v.errs[reference] != nil && v.errs[id] == nil {
+			v.errs[id] = fmt.Errorf("math expression references %q, which is an invalid query", reference)
+		}
+	}
+	v.path = v.path[:len(v.path)-1]
+	v.state[id] = visited
+}
+
+// setCycleErrors fails the expressions of the circular reference from id to the end of the current path.
+func (v *expressionGraphValidator) setCycleErrors(id string) {
+	start := len(v.path) - 1
+	for v.path[start] != id {
+		start--
+	}
+	cycle := append(append([]string{}, v.path[start:]...), id)
+	for _, cycleId := range cycle[:len(cycle)-1] {
+		v.errs[cycleId] = fmt.Errorf("math expression has a circular reference: %s", strings.Join(cycle, " -> "))
+	}
+}
+
+// expressionReferences returns the ids an expression references, in order and without duplicates.
+func expressionReferences(expression string) []string {
+	expression = expressionStringRegex.ReplaceAllString(expression, "")
+	var ids []string
+	seen := map[string]bool{}
+	for _, id := range expressionIdRegex.FindAllString(expression, -1) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,137 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_validateMathExpressions(t *testing.T) {
+	metric := func(refId, id string, period int) *models.CloudWatchQuery {
+		return &models.CloudWatchQuery{RefId: refId, Id: id, Period: period,
+			MetricQueryType: models.MetricQueryTypeSearch, MetricEditorMode: models.MetricEditorModeBuilder}
+	}
+	expression := func(refId, id, expression string) *models.CloudWatchQuery {
+		return &models.CloudWatchQuery{RefId: refId, Id: id, Period: 300, Expression: expression,
+			MetricQueryType: models.MetricQueryTypeSearch, MetricEditorMode: models.MetricEditorModeRaw}
+	}
+	errorMessages := func(errs map[string]error) map[string]string {
+		messages := map[string]string{}
+		for refId, err := range errs {
+			messages[refId] = err.Error()
+		}
+		return messages
+	}
+
+	t.Run("valid expressions have no errors", func(t *testing.T) {
+		errs := validateMathExpressions([]*models.CloudWatchQuery{
+			metric("A", "m1", 300),
+			metric("B", "m2", 300),
+			expression("C", "e1", "FILL(m1, REPEAT) / (m2 + 1e3) * 100"),
+			expression("D", "e2", `RATE(e1) + SUM(METRICS("m"))`),
+			expression("E", "e3", `SEARCH('{AWS/EC2,InstanceId} MetricName="CPUUtilization"', 'Average', 300)`),
+		}, map[string]bool{"m1": true, "m2": true, "e1": true, "e2": true, "e3": true})
+
+		assert.Empty(t, errs)
+	})
+
+	t.Run("expressions referencing missing ids", func(t *testing.T) {
+		errs := validateMathExpressions([]*models.CloudWatchQuery{
+			metric("A", "m1", 300),
+			expression("B", "e1", "m1 + m2"),
+			expression("C", "e2", "m1 + m3"),
+		}, map[string]bool{"m1": true, "e1": true, "e2": true, "m3": true})
+
+		assert.Equal(t, map[string]string{
+			"B": `math expression references "m2", which is not the id of a query`,
+			"C": `math expression references "m3", which is a query of another region or time range`,
+		}, errorMessages(errs))
+	})
+
+	t.Run("expressions of a circular reference and expressions referencing them", func(t *testing.T) {
+		errs := validateMathExpressions([]*models.CloudWatchQuery{
+			expression("A", "e1", "e2 * 2"),
+			expression("B", "e2", "e3 + 1"),
+			expression("C", "e3", "e2 - 1"),
+			expression("D", "e4", "e4"),
+			metric("E", "m1", 300),
+		}, map[string]bool{"e1": true, "e2": true, "e3": true, "e4": true, "m1": true})
+
+		assert.Equal(t, map[string]string{
+			"A": `math expression references "e2", which is an invalid query`,
+			"B": "math expression has a circular reference: e2 -> e3 -> e2",
+			"C": "math expression has a circular reference: e2 -> e3 -> e2",
+			"D": "math expression has a circular reference: e4 -> e4",
+		}, errorMessages(errs))
+	})
+
+	t.Run("expressions referencing queries of different periods", func(t *testing.T) {
+		errs := validateMathExpressions([]*models.CloudWatchQuery{
+			metric("A", "m1", 60),
+			metric("B", "m2", 300),
+			expression("C", "e1", "m1 + m2"),
+			expression("D", "e2", "m2 * 2"),
+		}, map[string]bool{"m1": true, "m2": true, "e1": true, "e2": true})
+
+		assert.Equal(t, map[string]string{
+			"C": "math expression references queries of different periods, m1 of 60s and m2 of 300s",
+		}, errorMessages(errs))
+	})
+}
+
+func Test_executeTimeSeriesQuery_fails_invalid_math_expressions_per_query(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{
+			{StatusCode: "Complete", Id: aws.String("m1"), Label: aws.String("CPUUtilization"), Values: []float64{1}, Timestamps: []time.Time{time.Now()}},
+		}}, nil)
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+
+	timeRange := backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()}
+	resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: timeRange,
+				JSON: json.RawMessage(`{"type":"timeSeriesQuery","metricQueryType":0,"metricEditorMode":0,"namespace":"AWS/EC2",
+					"metricName":"CPUUtilization","statistic":"Average","period":"300","region":"us-east-1","id":"m1"}`),
+			},
+			{
+				RefID:     "B",
+				TimeRange: timeRange,
+				JSON: json.RawMessage(`{"type":"timeSeriesQuery","metricQueryType":0,"metricEditorMode":1,
+					"expression":"m1 + m2","statistic":"Average","period":"300","region":"us-east-1","id":"e1"}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+	assert.EqualError(t, resp.Responses["B"].Error, `math expression references "m2", which is not the id of a query`)
+	assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses["B"].ErrorSource)
+
+	require.Len(t, api.Calls, 1)
+	input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+	require.Len(t, input.MetricDataQueries, 1)
+	assert.Equal(t, "m1", *input.MetricDataQueries[0].Id)
+}
@@ -0,0 +1,148 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// matrixCell is the latest value of the metric of a matrix query in a region and account.
+type matrixCell struct {
+	region    string
+	accountId string
+	value     *float64
+	timestamp *time.Time
+}
+
+// executeMatrixQueries runs the metric of each matrix query in every combination of its regions and accounts, and
+// returns a table with the latest value of each, so that a single query can feed panels showing the health of a
+// service across regions and accounts.
+func (ds *DataSource) executeMatrixQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, dataQuery := range req.Queries {
+		if !dataQuery.TimeRange.From.Before(dataQuery.TimeRange.To) {
+			resp.Responses[dataQuery.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(
+				fmt.Errorf("invalid time range: start time must be before end time")))
+			continue
+		}
+		queries, err := models.ParseMatrixQueries([]backend.DataQuery{dataQuery}, dataQuery.TimeRange.From, dataQuery.TimeRange.To,
+			ds.Settings.Region, ds.logger.FromContext(ctx), ds.Settings.Variables)
+		if err != nil {
+			resp.Responses[dataQuery.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		for _, query := range queries {
+			resp.Responses[query.RefId] = backend.DataResponse{Frames: data.Frames{ds.queryMatrix(ctx, query)}}
+		}
+	}
+	return resp, nil
+}
+
+// queryMatrix queries the regions of the matrix concurrently. A region failing, e.g. because it isn't enabled in
+// the account, leaves its cells without value and adds a notice, as the other regions are still worth showing.
+func (ds *DataSource) queryMatrix(ctx context.Context, query *models.CloudWatchQuery) *data.Frame {
+	cells := make([][]matrixCell, len(query.MatrixRegions))
+	errs := make([]error, len(query.MatrixRegions))
+	var wg sync.WaitGroup
+	for i, region := range query.MatrixRegions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cells[i], errs[i] = ds.queryMatrixRegion(ctx, query, region)
+		}()
+	}
+	wg.Wait()
+
+	frame := data.NewFrame(query.RefId,
+		data.NewField("region", nil, []string{}),
+		data.NewField("account", nil, []string{}),
+		data.NewField("value", nil, []*float64{}),
+		data.NewField("time", nil, []*time.Time{}),
+	)
+	frame.RefID = query.RefId
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+	for i, region := range query.MatrixRegions {
+		if errs[i] != nil {
+			ds.logger.FromContext(ctx).Debug("Failed to query a region of a matrix query", "region", region, "error", errs[i])
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("The metric couldn't be queried in %s: %s", region, errs[i]),
+			})
+			cells[i] = emptyMatrixCells(query, region)
+		}
+		for _, cell := range cells[i] {
+			frame.AppendRow(cell.region, cell.accountId, cell.value, cell.timestamp)
+		}
+	}
+	return frame
+}
+
+// queryMatrixRegion queries the metric in every account of the matrix in a single GetMetricData request.
+func (ds *DataSource) queryMatrixRegion(ctx context.Context, query *models.CloudWatchQuery, region string) ([]matrixCell, error) {
+	cells := emptyMatrixCells(query, region)
+	cellQueries := make([]*models.CloudWatchQuery, len(cells))
+	for i, cell := range cells {
+		cellQuery := *query
+		cellQuery.Region = region
+		cellQuery.Id = fmt.Sprintf("matrix%d", i)
+		cellQuery.AccountId = nil
+		if cell.accountId != "" {
+			cellQuery.AccountId = aws.String(cell.accountId)
+		}
+		cellQueries[i] = &cellQuery
+	}
+
+	client, err := ds.getCWClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	metricDataInput, err := ds.buildMetricDataInput(ctx, query.StartTime, query.EndTime, cellQueries)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := ds.executeRequest(ctx, client, metricDataInput)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]*matrixCell, len(cells))
+	for i := range cells {
+		byId[cellQueries[i].Id] = &cells[i]
+	}
+	for _, output := range outputs {
+		for _, result := range output.MetricDataResults {
+			cell, ok := byId[aws.ToString(result.Id)]
+			if !ok {
+				continue
+			}
+			for j, timestamp := range result.Timestamps {
+				if j < len(result.Values) && (cell.timestamp == nil || timestamp.After(*cell.timestamp)) {
+					cell.value = aws.Float64(result.Values[j])
+					cell.timestamp = aws.Time(timestamp)
+				}
+			}
+		}
+	}
+	return cells, nil
+}
+
+// emptyMatrixCells returns a cell without value per account of the matrix in region. The account of the data source
+// has an empty account id.
+func emptyMatrixCells(query *models.CloudWatchQuery, region string) []matrixCell {
+	accountIds := query.MatrixAccountIds
+	if len(accountIds) == 0 {
+		accountIds = []string{""}
+	}
+	cells := make([]matrixCell, len(accountIds))
+	for i, accountId := range accountIds {
+		cells[i] = matrixCell{region: region, accountId: accountId}
+	}
+	return cells
+}
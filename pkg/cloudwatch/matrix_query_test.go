@@ -0,0 +1,131 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsauth"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// regionConfigProvider returns configs of the requested region, so that tests can stub a client per region.
+type regionConfigProvider struct{}
+
+func (regionConfigProvider) GetConfig(_ context.Context, authSettings awsauth.Settings) (aws.Config, error) {
+	return aws.Config{Region: authSettings.Region}, nil
+}
+
+func Test_executeMatrixQueries(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	now := time.Now().Truncate(time.Minute)
+	queryData := func(t *testing.T, apis map[string]*mocks.MetricsAPI, queryJSON string) backend.DataResponse {
+		t.Helper()
+		NewCWClient = func(cfg aws.Config) models.CWClient {
+			return apis[cfg.Region]
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = regionConfigProvider{}
+		})
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON:      json.RawMessage(queryJSON),
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("returns the latest value of the metric in each region and account", func(t *testing.T) {
+		east := &mocks.MetricsAPI{}
+		east.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("matrix0"), Values: []float64{1, 2}, Timestamps: []time.Time{now.Add(-10 * time.Minute), now.Add(-5 * time.Minute)}},
+				{Id: aws.String("matrix1"), Values: []float64{3}, Timestamps: []time.Time{now.Add(-5 * time.Minute)}},
+			},
+		}, nil)
+		west := &mocks.MetricsAPI{}
+		west.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("matrix0"), Values: []float64{4}, Timestamps: []time.Time{now.Add(-5 * time.Minute)}},
+				{Id: aws.String("matrix1")},
+			},
+		}, nil)
+
+		res := queryData(t, map[string]*mocks.MetricsAPI{"us-east-1": east, "eu-west-1": west}, `{"type":"matrixQuery",
+			"namespace":"AWS/Lambda","metricName":"Errors","dimensions":{"FunctionName":["checkout"]},"statistic":"Sum",
+			"period":"300","matrixRegions":["us-east-1","eu-west-1"],"matrixAccountIds":["111111111111","222222222222"]}`)
+
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, 4, frame.Rows())
+		assert.Equal(t, []any{"us-east-1", "111111111111", aws.Float64(2), aws.Time(now.Add(-5 * time.Minute))}, frame.RowCopy(0))
+		assert.Equal(t, []any{"us-east-1", "222222222222", aws.Float64(3), aws.Time(now.Add(-5 * time.Minute))}, frame.RowCopy(1))
+		assert.Equal(t, []any{"eu-west-1", "111111111111", aws.Float64(4), aws.Time(now.Add(-5 * time.Minute))}, frame.RowCopy(2))
+		assert.Equal(t, []any{"eu-west-1", "222222222222", (*float64)(nil), (*time.Time)(nil)}, frame.RowCopy(3))
+
+		require.Len(t, east.Calls, 1)
+		input := east.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		require.Len(t, input.MetricDataQueries, 2)
+		assert.Equal(t, aws.String("111111111111"), input.MetricDataQueries[0].AccountId)
+		assert.Equal(t, aws.String("222222222222"), input.MetricDataQueries[1].AccountId)
+		assert.Equal(t, "Sum", *input.MetricDataQueries[0].MetricStat.Stat)
+	})
+
+	t.Run("a failing region leaves its cells empty and adds a notice", func(t *testing.T) {
+		east := &mocks.MetricsAPI{}
+		east.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("matrix0"), Values: []float64{1}, Timestamps: []time.Time{now.Add(-5 * time.Minute)}},
+			},
+		}, nil)
+		south := &mocks.MetricsAPI{}
+		south.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return((*cloudwatch.GetMetricDataOutput)(nil), errors.New("UnrecognizedClientException"))
+
+		res := queryData(t, map[string]*mocks.MetricsAPI{"us-east-1": east, "af-south-1": south}, `{"type":"matrixQuery",
+			"namespace":"AWS/Lambda","metricName":"Errors","statistic":"Sum","matrixRegions":["us-east-1","af-south-1"]}`)
+
+		require.NoError(t, res.Error)
+		frame := res.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, []any{"us-east-1", "", aws.Float64(1), aws.Time(now.Add(-5 * time.Minute))}, frame.RowCopy(0))
+		assert.Equal(t, []any{"af-south-1", "", (*float64)(nil), (*time.Time)(nil)}, frame.RowCopy(1))
+		require.Len(t, frame.Meta.Notices, 1)
+		assert.Contains(t, frame.Meta.Notices[0].Text, "The metric couldn't be queried in af-south-1")
+		assert.Nil(t, east.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput).MetricDataQueries[0].AccountId)
+	})
+
+	t.Run("fails queries of multiple series", func(t *testing.T) {
+		res := queryData(t, map[string]*mocks.MetricsAPI{}, `{"type":"matrixQuery","namespace":"AWS/Lambda","metricName":"Errors",
+			"dimensions":{"FunctionName":["*"]},"statistic":"Sum","matrixRegions":["us-east-1"]}`)
+
+		require.Error(t, res.Error)
+		assert.Contains(t, res.Error.Error(), "a matrix query must query a single metric")
+	})
+
+	t.Run("fails queries without regions", func(t *testing.T) {
+		res := queryData(t, map[string]*mocks.MetricsAPI{}, `{"type":"matrixQuery","namespace":"AWS/Lambda","metricName":"Errors",
+			"statistic":"Sum","matrixRegions":[" "]}`)
+
+		require.Error(t, res.Error)
+		assert.Contains(t, res.Error.Error(), "a matrix query must query at least one region")
+	})
+}
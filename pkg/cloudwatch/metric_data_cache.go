@@ -0,0 +1,62 @@
+package cloudwatch
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// executeRequestWithCache executes a GetMetricData request, reusing the responses of an identical request made
+// within the metric data cache TTL. Requests are identical when they are made in the same region with the same role,
+// MetricDataQueries and label options, and their time ranges fall in the same TTL-sized buckets, so that dashboards
+// whose panels share queries make one request per query and refresh. Only successful responses are cached. The
+// returned flag is set when the responses come from the cache.
+func (ds *DataSource) executeRequestWithCache(ctx context.Context, region string, client models.CWClient,
+	metricDataInput *cloudwatch.GetMetricDataInput) ([]*cloudwatch.GetMetricDataOutput, bool, error) {
+	ttl := ds.Settings.MetricDataCacheTTL.Duration
+	if ds.metricDataCache == nil || ttl <= 0 {
+		outputs, err := ds.executeRequest(ctx, client, metricDataInput)
+		return outputs, false, err
+	}
+
+	// the key is computed before the request is executed, as executing it sets its next token and end time
+	key, err := ds.metricDataCacheKey(ctx, region, metricDataInput, ttl)
+	if err != nil {
+		outputs, err := ds.executeRequest(ctx, client, metricDataInput)
+		return outputs, false, err
+	}
+	if cached, found := ds.metricDataCache.Get(key); found {
+		return cached.([]*cloudwatch.GetMetricDataOutput), true, nil
+	}
+
+	outputs, err := ds.executeRequest(ctx, client, metricDataInput)
+	if err != nil {
+		return outputs, false, err
+	}
+	ds.metricDataCache.Set(key, outputs, ttl)
+	return outputs, false, nil
+}
+
+// metricDataCacheKey identifies the responses of a GetMetricData request, scoped like query results are.
+func (ds *DataSource) metricDataCacheKey(ctx context.Context, region string, metricDataInput *cloudwatch.GetMetricDataInput,
+	ttl time.Duration) (string, error) {
+	roleARN, err := ds.assumeRoleARN(ctx)
+	if err != nil {
+		return "", err
+	}
+	values := map[string]any{
+		"region":  region,
+		"role":    roleARN,
+		"queries": metricDataInput.MetricDataQueries,
+		"labels":  metricDataInput.LabelOptions,
+		"scanBy":  metricDataInput.ScanBy,
+	}
+	if metricDataInput.StartTime != nil && metricDataInput.EndTime != nil {
+		values["start"] = metricDataInput.StartTime.Truncate(ttl).UnixMilli()
+		values["end"] = metricDataInput.EndTime.Truncate(ttl).UnixMilli()
+	}
+	return scopedCacheKey(ctx, ds.Settings.UserIdentityPassThrough, values)
+}
@@ -0,0 +1,80 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQueryData_metricDataCache(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+	now := time.Now()
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+			StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: []float64{1}, Timestamps: []time.Time{now},
+		}}}, nil)
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.metricDataCache = cache.New(cache.NoExpiration, 0)
+		ds.Settings.MetricDataCacheTTL = models.Duration{Duration: time.Hour}
+	})
+	request := func(refId, metricName string) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     refId,
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON: json.RawMessage(`{
+					"type": "timeSeriesQuery",
+					"namespace": "AWS/EC2",
+					"metricName": "` + metricName + `",
+					"dimensions": {"InstanceId": "i-1"},
+					"region": "us-east-1",
+					"id": "a",
+					"statistic": "Average",
+					"period": "60",
+					"matchExact": true,
+					"refId": "` + refId + `"
+				}`),
+			}},
+		}
+	}
+
+	first, err := ds.QueryData(context.Background(), request("A", "CPUUtilization"))
+	require.NoError(t, err)
+	require.Len(t, first.Responses["A"].Frames, 1)
+	stats, _ := metricQueryStatsOf(first.Responses["A"].Frames)
+	assert.Equal(t, metricQueryStats{GetMetricDataCalls: 1, Pages: 1, Datapoints: 1}, stats)
+
+	second, err := ds.QueryData(context.Background(), request("B", "CPUUtilization"))
+	require.NoError(t, err)
+	require.Len(t, second.Responses["B"].Frames, 1)
+	assert.Equal(t, "B", second.Responses["B"].Frames[0].RefID, "responses are parsed for the query reusing them")
+	stats, _ = metricQueryStatsOf(second.Responses["B"].Frames)
+	assert.Equal(t, metricQueryStats{Pages: 1, Datapoints: 1}, stats, "cached responses take no calls")
+	api.AssertNumberOfCalls(t, "GetMetricData", 1)
+
+	_, err = ds.QueryData(context.Background(), request("A", "NetworkIn"))
+	require.NoError(t, err)
+	api.AssertNumberOfCalls(t, "GetMetricData", 2)
+}
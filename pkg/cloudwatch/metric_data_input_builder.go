@@ -33,6 +33,9 @@ func (ds *DataSource) buildMetricDataInput(ctx context.Context, startTime time.T
 			return nil, &models.QueryError{Err: err, RefID: query.RefId}
 		}
 		metricDataInput.MetricDataQueries = append(metricDataInput.MetricDataQueries, metricDataQuery)
+		if sampleCountQuery, ok := sampleCountMetricDataQuery(query, metricDataQuery); ok {
+			metricDataInput.MetricDataQueries = append(metricDataInput.MetricDataQueries, sampleCountQuery)
+		}
 	}
 
 	return metricDataInput, nil
@@ -3,6 +3,7 @@ package cloudwatch
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
 
@@ -16,6 +17,10 @@ import (
 const keySeparator = "|&|"
 
 func (ds *DataSource) buildMetricDataQuery(ctx context.Context, query *models.CloudWatchQuery) (cloudwatchtypes.MetricDataQuery, error) {
+	if err := ds.scopeQuery(query); err != nil {
+		return cloudwatchtypes.MetricDataQuery{}, err
+	}
+
 	mdq := cloudwatchtypes.MetricDataQuery{
 		Id:         aws.String(query.Id),
 		ReturnData: aws.Bool(query.ReturnData),
@@ -29,10 +34,18 @@ func (ds *DataSource) buildMetricDataQuery(ctx context.Context, query *models.Cl
 	case models.GMDApiModeMathExpression:
 		mdq.Period = aws.Int32(int32(query.Period))
 		mdq.Expression = aws.String(query.Expression)
+		if mdq.Label != nil {
+			mdq.Label = aws.String(mathExpressionLabel(query))
+		}
 	case models.GMDApiModeSQLExpression:
 		mdq.Period = aws.Int32(int32(query.Period))
 		mdq.Expression = aws.String(query.SqlExpression)
 	case models.GMDApiModeInferredSearchExpression:
+		if query.AggregateDimension != "" {
+			mdq.Expression = aws.String(buildAggregateExpression(query, query.Statistic))
+			mdq.Label = aws.String(aggregateExpressionLabel(query))
+			break
+		}
 		mdq.Expression = aws.String(buildSearchExpression(query, query.Statistic))
 		if features.IsEnabled(ctx, features.FlagCloudWatchNewLabelParsing) {
 			mdq.Label = aws.String(buildSearchExpressionLabel(query))
@@ -112,24 +125,51 @@ func buildSearchExpression(query *models.CloudWatchQuery, stat string) string {
 		account = fmt.Sprintf(":aws.AccountId=%q", *query.AccountId)
 	}
 
+	namespaces := append([]string{query.Namespace}, query.ExtraNamespaces...)
 	if query.MatchExact {
-		schema := fmt.Sprintf("%q", query.Namespace)
-		if len(dimensionNames) > 0 {
-			sort.Strings(dimensionNames)
-			schema += fmt.Sprintf(",%s", join(dimensionNames, ",", `"`, `"`))
+		sort.Strings(dimensionNames)
+		schemas := make([]string, 0, len(namespaces))
+		for _, namespace := range namespaces {
+			schema := fmt.Sprintf("%q", namespace)
+			if len(dimensionNames) > 0 {
+				schema += fmt.Sprintf(",%s", join(dimensionNames, ",", `"`, `"`))
+			}
+			schemas = append(schemas, fmt.Sprintf("{%s}", schema))
 		}
-		schema = fmt.Sprintf("{%s}", schema)
+		schema := orExpression(schemas)
 		schemaSearchTermAndAccount := strings.TrimSpace(strings.Join([]string{schema, searchTerm, account}, " "))
 		return fmt.Sprintf("REMOVE_EMPTY(SEARCH('%s', '%s', %d))", schemaSearchTermAndAccount, stat, query.Period)
 	}
 
 	sort.Strings(dimensionNamesWithoutKnownValues)
 	searchTerm = appendSearch(searchTerm, join(dimensionNamesWithoutKnownValues, " ", `"`, `"`))
-	namespace := fmt.Sprintf("Namespace=%q", query.Namespace)
+	namespaceFilters := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		namespaceFilters = append(namespaceFilters, fmt.Sprintf("Namespace=%q", namespace))
+	}
+	namespace := orExpression(namespaceFilters)
 	namespaceSearchTermAndAccount := strings.TrimSpace(strings.Join([]string{namespace, searchTerm, account}, " "))
 	return fmt.Sprintf(`REMOVE_EMPTY(SEARCH('%s', '%s', %d))`, namespaceSearchTermAndAccount, stat, query.Period)
 }
 
+// buildAggregateExpression returns the expression aggregating the series of the metric of query across its aggregate
+// dimension, which is searched for any value of the dimension.
+func buildAggregateExpression(query *models.CloudWatchQuery, stat string) string {
+	search := *query
+	search.Dimensions = maps.Clone(query.Dimensions)
+	search.Dimensions[query.AggregateDimension] = []string{"*"}
+	return fmt.Sprintf("%s(%s)", query.AggregateFunction, buildSearchExpression(&search, stat))
+}
+
+// aggregateExpressionLabel returns the label of the series aggregated across a dimension. The series has no
+// properties to build a dynamic label from, so the label of the query is used as is.
+func aggregateExpressionLabel(query *models.CloudWatchQuery) string {
+	if query.Label != "" {
+		return query.Label
+	}
+	return fmt.Sprintf("%s %s across %s", query.AggregateFunction, query.MetricName, query.AggregateDimension)
+}
+
 func buildSearchExpressionLabel(query *models.CloudWatchQuery) string {
 	label := "${LABEL}"
 	if len(query.Label) > 0 {
@@ -146,9 +186,21 @@ func buildSearchExpressionLabel(query *models.CloudWatchQuery) string {
 	for _, key := range multiDims {
 		label += fmt.Sprintf("%s${PROP('Dim.%s')}", keySeparator, key)
 	}
+	// series of different namespaces may have the same metric name and dimensions
+	if len(query.ExtraNamespaces) > 0 {
+		label += fmt.Sprintf("%s${PROP('Namespace')}", keySeparator)
+	}
 	return label
 }
 
+// orExpression joins the search terms with OR, in parentheses if there's more than one.
+func orExpression(terms []string) string {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return fmt.Sprintf("(%s)", strings.Join(terms, " OR "))
+}
+
 func escapeQuotes(arr []string) []string {
 	result := []string{}
 	for _, value := range arr {
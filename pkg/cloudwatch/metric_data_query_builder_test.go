@@ -78,6 +78,19 @@ func TestMetricDataQueryBuilder(t *testing.T) {
 			assert.Equal(t, query.Expression, *mdq.Expression)
 		})
 
+		t.Run("should fill in label templates of user defined math expression", func(t *testing.T) {
+			query := getBaseQuery()
+			query.MetricEditorMode = models.MetricEditorModeRaw
+			query.MetricQueryType = models.MetricQueryTypeSearch
+			query.Id = "rate"
+			query.Expression = `RATE(m1)`
+			query.Label = "{{id}}: {{label}} #{{index}}"
+			mdq, err := ds.buildMetricDataQuery(context.Background(), query)
+			require.NoError(t, err)
+			require.NotNil(t, mdq.Label)
+			assert.Equal(t, "rate: ${LABEL} #{{index}}", *mdq.Label)
+		})
+
 		t.Run("should set period in user defined expression", func(t *testing.T) {
 			query := getBaseQuery()
 			query.MetricEditorMode = models.MetricEditorModeRaw
@@ -462,6 +475,63 @@ func TestMetricDataQueryBuilder(t *testing.T) {
 		})
 	})
 
+	t.Run("Query searches several namespaces", func(t *testing.T) {
+		query := &models.CloudWatchQuery{
+			Namespace:       "AWS/EC2",
+			ExtraNamespaces: []string{"AWS/EBS"},
+			MetricName:      "CPUUtilization",
+			Dimensions: map[string][]string{
+				"InstanceId": {"i-123"},
+			},
+			Period:           300,
+			MatchExact:       true,
+			Statistic:        "Average",
+			MetricQueryType:  models.MetricQueryTypeSearch,
+			MetricEditorMode: models.MetricEditorModeBuilder,
+		}
+
+		mdq, err := ds.buildMetricDataQuery(contextWithFeaturesEnabled(features.FlagCloudWatchNewLabelParsing), query)
+		require.NoError(t, err)
+		assert.Nil(t, mdq.MetricStat)
+		assert.Equal(t, `REMOVE_EMPTY(SEARCH('({"AWS/EC2","InstanceId"} OR {"AWS/EBS","InstanceId"}) MetricName="CPUUtilization" "InstanceId"="i-123"', 'Average', 300))`, *mdq.Expression)
+		assert.Equal(t, "${LABEL}|&|${PROP('Namespace')}", *mdq.Label)
+
+		query.MatchExact = false
+		mdq, err = ds.buildMetricDataQuery(contextWithFeaturesEnabled(features.FlagCloudWatchNewLabelParsing), query)
+		require.NoError(t, err)
+		assert.Equal(t, `REMOVE_EMPTY(SEARCH('(Namespace="AWS/EC2" OR Namespace="AWS/EBS") MetricName="CPUUtilization" "InstanceId"="i-123"', 'Average', 300))`, *mdq.Expression)
+	})
+
+	t.Run("Query aggregates across a dimension", func(t *testing.T) {
+		query := &models.CloudWatchQuery{
+			Namespace:  "AWS/EC2",
+			MetricName: "CPUUtilization",
+			Dimensions: map[string][]string{
+				"AutoScalingGroupName": {"asg"},
+			},
+			Period:             300,
+			MatchExact:         true,
+			Statistic:          "Average",
+			AggregateDimension: "InstanceId",
+			AggregateFunction:  "SUM",
+			MetricQueryType:    models.MetricQueryTypeSearch,
+			MetricEditorMode:   models.MetricEditorModeBuilder,
+		}
+
+		mdq, err := ds.buildMetricDataQuery(contextWithFeaturesEnabled(features.FlagCloudWatchNewLabelParsing), query)
+		require.NoError(t, err)
+		assert.Nil(t, mdq.MetricStat)
+		assert.Equal(t, `SUM(REMOVE_EMPTY(SEARCH('{"AWS/EC2","AutoScalingGroupName","InstanceId"} MetricName="CPUUtilization" "AutoScalingGroupName"="asg"', 'Average', 300)))`, *mdq.Expression)
+		assert.Equal(t, "SUM CPUUtilization across InstanceId", *mdq.Label)
+		assert.Equal(t, []string{"asg"}, query.Dimensions["AutoScalingGroupName"])
+		assert.NotContains(t, query.Dimensions, "InstanceId", "the dimensions of the query are left untouched")
+
+		query.Label = "Fleet CPU"
+		mdq, err = ds.buildMetricDataQuery(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, "Fleet CPU", *mdq.Label)
+	})
+
 	t.Run("Query has invalid characters in dimension values", func(t *testing.T) {
 		query := &models.CloudWatchQuery{
 			Namespace:  "AWS/EC2",
@@ -0,0 +1,70 @@
+package cloudwatch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_metric_metadata_route(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.GrafanaSettings.ListMetricsPageLimit = 100
+	})
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.MetricMetadataHandler))
+
+	t.Run("returns the series of the metric and when they were last seen", func(t *testing.T) {
+		client := &mocks.MetricsAPI{Metrics: []cloudwatchtypes.Metric{{
+			Namespace:  aws.String("AWS/Lambda"),
+			MetricName: aws.String("Invocations"),
+			Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("FunctionName"), Value: aws.String("checkout")}},
+		}}}
+		client.On("ListMetrics").Return(nil)
+		client.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+				Id:         aws.String("m0"),
+				Timestamps: []time.Time{time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+				Values:     []float64{12},
+			}},
+		}, nil)
+		NewCWClient = func(aws.Config) models.CWClient {
+			return client
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", `/metric-metadata?region=us-east-1&namespace=AWS/Lambda&metricName=Invocations&dimensionFilters={"FunctionName":"checkout"}`, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"namespace": "AWS/Lambda",
+			"metricName": "Invocations",
+			"lastSeen": "2024-05-01T10:00:00Z",
+			"series": [{
+				"dimensions": {"FunctionName": "checkout"},
+				"active": true,
+				"firstSeen": "2024-05-01T10:00:00Z",
+				"lastSeen": "2024-05-01T10:00:00Z"
+			}]
+		}`, rr.Body.String())
+	})
+
+	t.Run("returns an error when the metric is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metric-metadata?region=us-east-1&namespace=AWS/Lambda", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
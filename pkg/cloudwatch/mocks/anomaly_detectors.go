@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/stretchr/testify/mock"
+)
+
+type FakeAnomalyDetectorsClient struct {
+	mock.Mock
+}
+
+func (a *FakeAnomalyDetectorsClient) DescribeAnomalyDetectors(_ context.Context, input *cloudwatch.DescribeAnomalyDetectorsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAnomalyDetectorsOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*cloudwatch.DescribeAnomalyDetectorsOutput), args.Error(1)
+}
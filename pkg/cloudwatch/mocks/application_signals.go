@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/applicationsignals"
+	"github.com/stretchr/testify/mock"
+)
+
+type FakeApplicationSignalsClient struct {
+	mock.Mock
+}
+
+func (a *FakeApplicationSignalsClient) ListServices(_ context.Context, input *applicationsignals.ListServicesInput, _ ...func(*applicationsignals.Options)) (*applicationsignals.ListServicesOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*applicationsignals.ListServicesOutput), args.Error(1)
+}
+
+func (a *FakeApplicationSignalsClient) ListServiceLevelObjectives(_ context.Context, input *applicationsignals.ListServiceLevelObjectivesInput, _ ...func(*applicationsignals.Options)) (*applicationsignals.ListServiceLevelObjectivesOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*applicationsignals.ListServiceLevelObjectivesOutput), args.Error(1)
+}
@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/stretchr/testify/mock"
+)
+
+type FakeInsightRulesClient struct {
+	mock.Mock
+}
+
+func (a *FakeInsightRulesClient) DescribeInsightRules(_ context.Context, input *cloudwatch.DescribeInsightRulesInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeInsightRulesOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*cloudwatch.DescribeInsightRulesOutput), args.Error(1)
+}
+
+func (a *FakeInsightRulesClient) GetInsightRuleReport(_ context.Context, input *cloudwatch.GetInsightRuleReportInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.GetInsightRuleReportOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*cloudwatch.GetInsightRuleReportOutput), args.Error(1)
+}
@@ -25,6 +25,12 @@ func (l *LogsAPI) GetLogGroupFields(_ context.Context, input *cloudwatchlogs.Get
 	return args.Get(0).(*cloudwatchlogs.GetLogGroupFieldsOutput), args.Error(1)
 }
 
+func (l *LogsAPI) DescribeQueryDefinitions(_ context.Context, input *cloudwatchlogs.DescribeQueryDefinitionsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	args := l.Called(input)
+
+	return args.Get(0).(*cloudwatchlogs.DescribeQueryDefinitionsOutput), args.Error(1)
+}
+
 type LogsService struct {
 	mock.Mock
 }
@@ -41,6 +47,12 @@ func (l *LogsService) GetLogGroupFields(_ context.Context, request resources.Log
 	return args.Get(0).([]resources.ResourceResponse[resources.LogGroupField]), args.Error(1)
 }
 
+func (l *LogsService) GetQueryDefinitions(_ context.Context, request resources.QueryDefinitionsRequest) ([]resources.ResourceResponse[resources.QueryDefinition], error) {
+	args := l.Called(request)
+
+	return args.Get(0).([]resources.ResourceResponse[resources.QueryDefinition]), args.Error(1)
+}
+
 type MockLogEvents struct {
 	mock.Mock
 }
@@ -66,3 +78,21 @@ func (m *MockLogEvents) GetLogEvents(ctx context.Context, input *cloudwatchlogs.
 
 	return args.Get(0).(*cloudwatchlogs.GetLogEventsOutput), args.Error(1)
 }
+
+func (m *MockLogEvents) FilterLogEvents(ctx context.Context, input *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	args := m.Called(ctx, input, optFns)
+
+	return args.Get(0).(*cloudwatchlogs.FilterLogEventsOutput), args.Error(1)
+}
+
+func (m *MockLogEvents) DescribeQueryDefinitions(context.Context, *cloudwatchlogs.DescribeQueryDefinitionsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	return &cloudwatchlogs.DescribeQueryDefinitionsOutput{}, nil
+}
+
+func (m *MockLogEvents) PutQueryDefinition(context.Context, *cloudwatchlogs.PutQueryDefinitionInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutQueryDefinitionOutput, error) {
+	return &cloudwatchlogs.PutQueryDefinitionOutput{}, nil
+}
+
+func (m *MockLogEvents) GetLogRecord(context.Context, *cloudwatchlogs.GetLogRecordInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogRecordOutput, error) {
+	return &cloudwatchlogs.GetLogRecordOutput{}, nil
+}
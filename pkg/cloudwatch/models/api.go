@@ -4,10 +4,12 @@ import (
 	"context"
 	"net/url"
 
+	"github.com/aws/aws-sdk-go-v2/service/applicationsignals"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/oam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -36,12 +38,21 @@ type ListMetricsProvider interface {
 type LogGroupsProvider interface {
 	GetLogGroups(ctx context.Context, request resources.LogGroupsRequest) ([]resources.ResourceResponse[resources.LogGroup], error)
 	GetLogGroupFields(ctx context.Context, request resources.LogGroupFieldsRequest) ([]resources.ResourceResponse[resources.LogGroupField], error)
+	GetQueryDefinitions(ctx context.Context, request resources.QueryDefinitionsRequest) ([]resources.ResourceResponse[resources.QueryDefinition], error)
 }
 
 type AccountsProvider interface {
 	GetAccountsForCurrentUserOrRole(ctx context.Context) ([]resources.ResourceResponse[resources.Account], error)
 }
 
+type AnomalyDetectorsProvider interface {
+	GetAnomalyDetectors(ctx context.Context, request resources.AnomalyDetectorsRequest) ([]resources.ResourceResponse[resources.AnomalyDetector], error)
+}
+
+type MetricMetadataProvider interface {
+	GetMetricMetadata(ctx context.Context, request resources.MetricMetadataRequest) (resources.MetricMetadata, error)
+}
+
 type RegionsAPIProvider interface {
 	GetRegions(ctx context.Context) ([]resources.ResourceResponse[resources.Region], error)
 }
@@ -57,6 +68,28 @@ type CloudWatchMetricsAPIProvider interface {
 type CloudWatchLogsAPIProvider interface {
 	cloudwatchlogs.DescribeLogGroupsAPIClient
 	GetLogGroupFields(ctx context.Context, in *cloudwatchlogs.GetLogGroupFieldsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogGroupFieldsOutput, error)
+	QueryDefinitionsAPI
+}
+
+// QueryDefinitionsAPI lists the saved Logs Insights query definitions.
+type QueryDefinitionsAPI interface {
+	DescribeQueryDefinitions(ctx context.Context, in *cloudwatchlogs.DescribeQueryDefinitionsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error)
+}
+
+type AnomalyDetectorsAPIProvider interface {
+	cloudwatch.DescribeAnomalyDetectorsAPIClient
+}
+
+// InsightRulesAPIProvider lists the Contributor Insights rules and reports their top contributors.
+type InsightRulesAPIProvider interface {
+	cloudwatch.DescribeInsightRulesAPIClient
+	GetInsightRuleReport(ctx context.Context, in *cloudwatch.GetInsightRuleReportInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetInsightRuleReportOutput, error)
+}
+
+// ApplicationSignalsAPIProvider lists the services Application Signals discovered and their service level objectives.
+type ApplicationSignalsAPIProvider interface {
+	applicationsignals.ListServicesAPIClient
+	applicationsignals.ListServiceLevelObjectivesAPIClient
 }
 
 type OAMAPIProvider interface {
@@ -69,13 +102,27 @@ type EC2APIProvider interface {
 	ec2.DescribeInstancesAPIClient
 }
 
+type STSAPIProvider interface {
+	GetCallerIdentity(ctx context.Context, in *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
 type CWLogsClient interface {
 	StartQuery(context.Context, *cloudwatchlogs.StartQueryInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error)
 	StopQuery(context.Context, *cloudwatchlogs.StopQueryInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error)
 	GetQueryResults(context.Context, *cloudwatchlogs.GetQueryResultsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error)
 
 	cloudwatchlogs.GetLogEventsAPIClient
+	cloudwatchlogs.FilterLogEventsAPIClient
 	cloudwatchlogs.DescribeLogGroupsAPIClient
+	QueryDefinitionsAPI
+	PutQueryDefinition(context.Context, *cloudwatchlogs.PutQueryDefinitionInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutQueryDefinitionOutput, error)
+	GetLogRecord(context.Context, *cloudwatchlogs.GetLogRecordInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogRecordOutput, error)
+}
+
+// LogsLiveTailProvider starts Live Tail sessions. It returns the event stream of the session rather than the output of
+// StartLiveTail, whose stream can't be set outside of the SDK.
+type LogsLiveTailProvider interface {
+	StartLiveTail(context.Context, *cloudwatchlogs.StartLiveTailInput) (cloudwatchlogs.StartLiveTailResponseStreamReader, error)
 }
 
 type CWClient interface {
@@ -1,12 +1,14 @@
 package models
 
 import (
+	"cmp"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -68,6 +70,7 @@ type CloudWatchQuery struct {
 	Region            string
 	Id                string
 	Namespace         string
+	ExtraNamespaces   []string // namespaces searched for the metric in addition to Namespace
 	MetricName        string
 	Statistic         string
 	Expression        string
@@ -76,13 +79,47 @@ type CloudWatchQuery struct {
 	ReturnData        bool
 	Dimensions        map[string][]string
 	Period            int
+	RequestedPeriod   int           // the period set on the query, 0 if it is picked automatically
+	PanelInterval     time.Duration // the interval of the panel, the floor of automatically picked periods
 	Label             string
 	MatchExact        bool
+	Instant           bool // only the latest datapoint of each series is returned, as a table
+	SeriesSortBy      dataquery.SeriesSortBy
+	SeriesSortOrder   dataquery.SeriesSortOrder
+	SeriesLimit       int // the maximum number of series returned, 0 if unlimited
 	UsedExpression    string
 	TimezoneUTCOffset string
 	MetricQueryType   dataquery.MetricQueryType
 	MetricEditorMode  dataquery.MetricEditorMode
 	AccountId         *string
+
+	// SeriesFilter keeps the series whose name, or value of SeriesFilterDimension, it matches, or drops them if
+	// SeriesFilterExclude is set. Nil keeps every series.
+	SeriesFilter          *regexp.Regexp
+	SeriesFilterDimension string
+	SeriesFilterExclude   bool
+
+	AlarmThresholds bool // the thresholds of the alarms of the metric are set on its series
+
+	InstanceNames bool // the EC2 series are labeled with the Name tag of their instance
+
+	FallbackStatistic string // the statistic queried when the series have no datapoints of Statistic, "" if none
+
+	IncludeSampleCount bool // the SampleCount of the metric is queried too and returned as a second field of its series
+
+	// AggregateDimension is the dimension the series of the query are aggregated across with AggregateFunction, into
+	// one series. It isn't one of Dimensions. "" doesn't aggregate.
+	AggregateDimension string
+	AggregateFunction  string
+
+	// Percentiles are queried in place of Statistic, in ascending order, and returned as one frame per series with a
+	// field per percentile. Nil queries Statistic.
+	Percentiles []string
+
+	// MatrixRegions and MatrixAccountIds are the regions and accounts a matrix query queries its metric in. No
+	// accounts only queries the account of the data source.
+	MatrixRegions    []string
+	MatrixAccountIds []string
 }
 
 func (q *CloudWatchQuery) GetGetMetricDataAPIMode() GMDApiMode {
@@ -122,6 +159,16 @@ func (q *CloudWatchQuery) IsInferredSearchExpression() bool {
 		return true
 	}
 
+	// the series aggregated across a dimension are those of a search of every value of the dimension
+	if q.AggregateDimension != "" {
+		return true
+	}
+
+	// a metric stat can only refer to a single namespace
+	if len(q.ExtraNamespaces) > 0 {
+		return true
+	}
+
 	if len(q.Dimensions) == 0 {
 		return !q.MatchExact
 	}
@@ -162,6 +209,15 @@ func (q *CloudWatchQuery) IsMultiValuedDimensionExpression() bool {
 	return false
 }
 
+// ExpectedDatapoints returns the number of datapoints a single series of the query has for the given period
+// in the query time range.
+func (q *CloudWatchQuery) ExpectedDatapoints(period int) int {
+	if period <= 0 {
+		return 0
+	}
+	return int(math.Ceil(q.EndTime.Sub(q.StartTime).Seconds() / float64(period)))
+}
+
 func (q *CloudWatchQuery) BuildDeepLink(startTime time.Time, endTime time.Time) (string, error) {
 	if q.IsMathExpression() || q.MetricQueryType == MetricQueryTypeQuery {
 		return "", nil
@@ -225,6 +281,11 @@ const timeSeriesQuery = "timeSeriesQuery"
 
 var validMetricDataID = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
 
+var validPercentile = regexp.MustCompile(`^p\d{1,2}(\.\d+)?$`)
+
+// maxPercentiles bounds the percentiles of a query, as each of them is a metric data query of the request
+const maxPercentiles = 10
+
 type metricsDataQuery struct {
 	dataquery.CloudWatchMetricsQuery
 	Sql               *sqlExpression `json:"sql,omitempty"`
@@ -235,8 +296,16 @@ type metricsDataQuery struct {
 // ParseMetricDataQueries decodes the metric data queries json, validates, sets default values and returns an array of CloudWatchQueries.
 // The CloudWatchQuery has a 1 to 1 mapping to a query editor row
 func ParseMetricDataQueries(dataQueries []backend.DataQuery, startTime time.Time, endTime time.Time, defaultRegion string, logger log.Logger,
-	crossAccountQueryingEnabled bool) ([]*CloudWatchQuery, error) {
+	crossAccountQueryingEnabled bool, variables map[string]string) ([]*CloudWatchQuery, error) {
+	return parseMetricDataQueries(dataQueries, []string{timeSeriesQuery, ""}, startTime, endTime, defaultRegion, logger,
+		crossAccountQueryingEnabled, variables)
+}
+
+// parseMetricDataQueries parses the data queries of the given query types.
+func parseMetricDataQueries(dataQueries []backend.DataQuery, queryTypes []string, startTime time.Time, endTime time.Time,
+	defaultRegion string, logger log.Logger, crossAccountQueryingEnabled bool, variables map[string]string) ([]*CloudWatchQuery, error) {
 	var metricDataQueries = make(map[string]metricsDataQuery)
+	panelIntervals := make(map[string]time.Duration)
 	for _, query := range dataQueries {
 		var metricsDataQuery metricsDataQuery
 		err := json.Unmarshal(query.JSON, &metricsDataQuery)
@@ -244,17 +313,21 @@ func ParseMetricDataQueries(dataQueries []backend.DataQuery, startTime time.Time
 			return nil, &QueryError{Err: err, RefID: query.RefID}
 		}
 
-		queryType := metricsDataQuery.Type
-		if queryType != timeSeriesQuery && queryType != "" {
+		if !slices.Contains(queryTypes, metricsDataQuery.Type) {
 			continue
 		}
 
 		metricDataQueries[query.RefID] = metricsDataQuery
+		panelIntervals[query.RefID] = query.Interval
 	}
 
 	result := make([]*CloudWatchQuery, 0, len(metricDataQueries))
 
 	for refId, mdq := range metricDataQueries {
+		if err := interpolatePeriodAndStatistic(&mdq, variables); err != nil {
+			return nil, &QueryError{Err: err, RefID: refId}
+		}
+
 		cwQuery := &CloudWatchQuery{
 			logger:            logger,
 			StartTime:         startTime,
@@ -264,6 +337,7 @@ func ParseMetricDataQueries(dataQueries []backend.DataQuery, startTime time.Time
 			Region:            mdq.Region,
 			Namespace:         mdq.Namespace,
 			TimezoneUTCOffset: mdq.TimezoneUTCOffset,
+			PanelInterval:     panelIntervals[refId],
 		}
 
 		if mdq.MetricName != nil {
@@ -298,6 +372,10 @@ func ParseMetricDataQueries(dataQueries []backend.DataQuery, startTime time.Time
 
 		cwQuery.migrateLegacyQuery(mdq)
 
+		if err := cwQuery.setPercentiles(mdq); err != nil {
+			return nil, &QueryError{Err: err, RefID: refId}
+		}
+
 		result = append(result, cwQuery)
 	}
 
@@ -306,7 +384,8 @@ func ParseMetricDataQueries(dataQueries []backend.DataQuery, startTime time.Time
 
 func (q *CloudWatchQuery) applyMacros(startTime, endTime time.Time) {
 	if q.GetGetMetricDataAPIMode() == GMDApiModeMathExpression {
-		q.Expression = strings.ReplaceAll(q.Expression, "$__period_auto", strconv.Itoa(calculatePeriodBasedOnTimeRange(startTime, endTime)))
+		q.Expression = strings.ReplaceAll(q.Expression, "$__period_auto", strconv.Itoa(retainedPeriod(0, q.PanelInterval, startTime, endTime)))
+		q.Expression = InterpolateGlobalVariables(q.Expression, backend.TimeRange{From: startTime, To: endTime}, q.PanelInterval)
 	}
 }
 
@@ -322,10 +401,11 @@ func (q *CloudWatchQuery) validateAndSetDefaults(refId string, metricsDataQuery
 	}
 
 	var err error
-	q.Period, err = getPeriod(metricsDataQuery, startTime, endTime)
+	q.RequestedPeriod, err = parseRequestedPeriod(metricsDataQuery)
 	if err != nil {
 		return err
 	}
+	q.Period = retainedPeriod(q.RequestedPeriod, q.PanelInterval, startTime, endTime)
 
 	q.Dimensions = map[string][]string{}
 	if metricsDataQuery.Dimensions != nil {
@@ -339,6 +419,8 @@ func (q *CloudWatchQuery) validateAndSetDefaults(refId string, metricsDataQuery
 		q.AccountId = metricsDataQuery.AccountId
 	}
 
+	q.ExtraNamespaces = parseExtraNamespaces(q.Namespace, metricsDataQuery.AdditionalNamespaces)
+
 	if metricsDataQuery.Id == "" {
 		// Why not just use refId if id is not specified in the frontend? When specifying an id in the editor,
 		// and alphabetical must be used. The id must be unique, so if an id like for example a, b or c would be used,
@@ -356,6 +438,29 @@ func (q *CloudWatchQuery) validateAndSetDefaults(refId string, metricsDataQuery
 		q.MatchExact = *metricsDataQuery.MatchExact
 	}
 
+	q.Instant = metricsDataQuery.Instant != nil && *metricsDataQuery.Instant
+	q.AlarmThresholds = metricsDataQuery.AlarmThresholds != nil && *metricsDataQuery.AlarmThresholds
+	q.InstanceNames = metricsDataQuery.InstanceNames != nil && *metricsDataQuery.InstanceNames
+	q.IncludeSampleCount = metricsDataQuery.IncludeSampleCount != nil && *metricsDataQuery.IncludeSampleCount
+
+	if metricsDataQuery.FallbackStatistic != nil && *metricsDataQuery.FallbackStatistic != "" {
+		if !validStatistic.MatchString(*metricsDataQuery.FallbackStatistic) {
+			return backend.DownstreamError(fmt.Errorf("invalid fallback statistic %q", *metricsDataQuery.FallbackStatistic))
+		}
+		q.FallbackStatistic = *metricsDataQuery.FallbackStatistic
+	}
+
+	if err := q.setAggregation(metricsDataQuery); err != nil {
+		return err
+	}
+
+	q.MatrixRegions = compactValues(metricsDataQuery.MatrixRegions)
+	q.MatrixAccountIds = compactValues(metricsDataQuery.MatrixAccountIds)
+
+	if err := q.setSeriesSortAndLimit(metricsDataQuery); err != nil {
+		return err
+	}
+
 	q.ReturnData = true
 	if metricsDataQuery.Hide != nil {
 		q.ReturnData = !*metricsDataQuery.Hide
@@ -385,6 +490,75 @@ func (q *CloudWatchQuery) validateAndSetDefaults(refId string, metricsDataQuery
 	return nil
 }
 
+// setPercentiles validates the percentiles of the query and orders them ascending.
+func (q *CloudWatchQuery) setPercentiles(query metricsDataQuery) error {
+	percentiles := compactValues(query.Percentiles)
+	if len(percentiles) == 0 {
+		return nil
+	}
+	if q.MetricQueryType != MetricQueryTypeSearch || q.MetricEditorMode != MetricEditorModeBuilder {
+		return backend.DownstreamError(fmt.Errorf("percentiles can only be queried by metric search queries in the builder"))
+	}
+	if len(percentiles) > maxPercentiles {
+		return backend.DownstreamError(fmt.Errorf("a query can query up to %d percentiles, got %d", maxPercentiles, len(percentiles)))
+	}
+	values := make(map[string]float64, len(percentiles))
+	for _, percentile := range percentiles {
+		value, err := strconv.ParseFloat(strings.TrimPrefix(percentile, "p"), 64)
+		if !validPercentile.MatchString(percentile) || err != nil {
+			return backend.DownstreamError(fmt.Errorf("invalid percentile %q, must be a percentile statistic such as p99 or p99.9", percentile))
+		}
+		values[percentile] = value
+	}
+	slices.SortFunc(percentiles, func(a, b string) int {
+		return cmp.Compare(values[a], values[b])
+	})
+	q.Percentiles = percentiles
+	return nil
+}
+
+// setSeriesSortAndLimit validates how the series of the query are filtered, ordered and limited.
+func (q *CloudWatchQuery) setSeriesSortAndLimit(query metricsDataQuery) error {
+	if query.SeriesSortBy != nil && *query.SeriesSortBy != "" {
+		switch *query.SeriesSortBy {
+		case dataquery.SeriesSortByLast, dataquery.SeriesSortByAvg, dataquery.SeriesSortByMax:
+			q.SeriesSortBy = *query.SeriesSortBy
+		default:
+			return backend.DownstreamError(fmt.Errorf("unknown series sort %q, must be one of Last, Avg or Max", *query.SeriesSortBy))
+		}
+	}
+
+	q.SeriesSortOrder = dataquery.SeriesSortOrderDesc
+	if query.SeriesSortOrder != nil && *query.SeriesSortOrder != "" {
+		switch *query.SeriesSortOrder {
+		case dataquery.SeriesSortOrderDesc, dataquery.SeriesSortOrderAsc:
+			q.SeriesSortOrder = *query.SeriesSortOrder
+		default:
+			return backend.DownstreamError(fmt.Errorf("unknown series sort order %q, must be Desc or Asc", *query.SeriesSortOrder))
+		}
+	}
+
+	if query.SeriesLimit != nil {
+		if *query.SeriesLimit < 0 {
+			return backend.DownstreamError(fmt.Errorf("series limit must not be negative, got %d", *query.SeriesLimit))
+		}
+		q.SeriesLimit = int(*query.SeriesLimit)
+	}
+
+	if query.SeriesFilter != nil && *query.SeriesFilter != "" {
+		filter, err := regexp.Compile(*query.SeriesFilter)
+		if err != nil {
+			return backend.DownstreamError(fmt.Errorf("invalid series filter: %w", err))
+		}
+		q.SeriesFilter = filter
+		if query.SeriesFilterDimension != nil {
+			q.SeriesFilterDimension = strings.TrimSpace(*query.SeriesFilterDimension)
+		}
+		q.SeriesFilterExclude = query.SeriesFilterExclude != nil && *query.SeriesFilterExclude
+	}
+	return nil
+}
+
 // getStatistic determines the value of Statistic in a CloudWatchQuery from the metricsDataQuery input
 // migrates queries that has a `statistics` field to use the `statistic` field instead.
 // In case the query used more than one stat, the first stat in the slice will be used in the statistic field
@@ -422,30 +596,31 @@ func getLabel(query metricsDataQuery) string {
 		return ""
 	}
 
-	var result string
 	fullAliasField := ""
 	if deprecatedAlias != nil {
 		fullAliasField = *deprecatedAlias
 	}
-	matches := legacyAliasRegexp.FindAllStringSubmatch(fullAliasField, -1)
+	return aliasToLabel(fullAliasField)
+}
+
+// aliasToLabel converts the {{pattern}} syntax of a legacy alias to the dynamic labels syntax.
+func aliasToLabel(alias string) string {
+	matches := legacyAliasRegexp.FindAllStringSubmatch(alias, -1)
 
 	for _, groups := range matches {
 		fullMatch := groups[0]
 		subgroup := groups[1]
 		if dynamicLabel, ok := aliasPatterns[subgroup]; ok {
-			fullAliasField = strings.ReplaceAll(fullAliasField, fullMatch, dynamicLabel)
+			alias = strings.ReplaceAll(alias, fullMatch, dynamicLabel)
 		} else {
-			fullAliasField = strings.ReplaceAll(fullAliasField, fullMatch, fmt.Sprintf(`${PROP('Dim.%s')}`, subgroup))
+			alias = strings.ReplaceAll(alias, fullMatch, fmt.Sprintf(`${PROP('Dim.%s')}`, subgroup))
 		}
 	}
-	result = fullAliasField
-	return result
+	return alias
 }
 
-func calculatePeriodBasedOnTimeRange(startTime, endTime time.Time) int {
-	deltaInSeconds := endTime.Sub(startTime).Seconds()
-	periods := getRetainedPeriods(time.Since(startTime))
-	datapoints := int(math.Ceil(deltaInSeconds / 2000))
+func calculatePeriod(periods []int, timeRange time.Duration) int {
+	datapoints := int(math.Ceil(timeRange.Seconds() / 2000))
 	period := periods[len(periods)-1]
 	for _, value := range periods {
 		if datapoints <= value {
@@ -457,28 +632,60 @@ func calculatePeriodBasedOnTimeRange(startTime, endTime time.Time) int {
 	return period
 }
 
-func getPeriod(query metricsDataQuery, startTime, endTime time.Time) (int, error) {
+// parseRequestedPeriod returns the period set on the query in seconds, or 0 if it should be picked automatically.
+func parseRequestedPeriod(query metricsDataQuery) (int, error) {
 	periodString := ""
 	if query.Period != nil {
 		periodString = *query.Period
 	}
-	var period int
-	var err error
 	if strings.ToLower(periodString) == "auto" || periodString == "" {
-		period = calculatePeriodBasedOnTimeRange(startTime, endTime)
-	} else {
-		period, err = strconv.Atoi(periodString)
+		return 0, nil
+	}
+	period, err := strconv.Atoi(periodString)
+	if err != nil {
+		d, err := time.ParseDuration(periodString)
 		if err != nil {
-			d, err := time.ParseDuration(periodString)
-			if err != nil {
-				return 0, fmt.Errorf("failed to parse period as duration: %v", err)
-			}
-			period = int(d.Seconds())
+			return 0, fmt.Errorf("failed to parse period as duration: %v", err)
 		}
+		period = int(d.Seconds())
 	}
 	return period, nil
 }
 
+// retainedPeriod returns the period to query the time range with. An automatic period is picked based on the
+// length and age of the time range, and is at least the panel interval, since the panel can't render finer data.
+// A requested period is raised if CloudWatch no longer retains data at that resolution for the start of the time
+// range, since it would otherwise return no datapoints.
+func retainedPeriod(requestedPeriod int, panelInterval time.Duration, startTime, endTime time.Time) int {
+	return retainedPeriodForAge(requestedPeriod, panelInterval, time.Since(startTime), endTime.Sub(startTime))
+}
+
+// retainedPeriodForAge is retainedPeriod for a time range of the given length that starts age ago.
+func retainedPeriodForAge(requestedPeriod int, panelInterval time.Duration, age time.Duration, timeRange time.Duration) int {
+	periods := getRetainedPeriods(age)
+	if requestedPeriod == 0 {
+		return max(calculatePeriod(periods, timeRange), panelIntervalPeriod(periods, panelInterval))
+	}
+	// data younger than 15 days is retained at 1 minute, or finer for high resolution metrics
+	if minPeriod := periods[0]; minPeriod > 60 && requestedPeriod < minPeriod {
+		return minPeriod
+	}
+	return requestedPeriod
+}
+
+// panelIntervalPeriod returns the finest of the periods that isn't finer than the panel interval, or 0 without one.
+func panelIntervalPeriod(periods []int, panelInterval time.Duration) int {
+	if panelInterval <= 0 {
+		return 0
+	}
+	for _, period := range periods {
+		if time.Duration(period)*time.Second >= panelInterval {
+			return period
+		}
+	}
+	return periods[len(periods)-1]
+}
+
 func getRetainedPeriods(timeSince time.Duration) []int {
 	// See https://aws.amazon.com/about-aws/whats-new/2016/11/cloudwatch-extends-metrics-retention-and-new-user-interface/
 	if timeSince > time.Duration(455)*24*time.Hour {
@@ -508,6 +715,32 @@ func parseDimensions(dimensions dataquery.Dimensions) (map[string][]string, erro
 	return parsedDimensions, nil
 }
 
+// compactValues returns the values without blanks and duplicates.
+func compactValues(values []string) []string {
+	var compacted []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !slices.Contains(compacted, value) {
+			compacted = append(compacted, value)
+		}
+	}
+	return compacted
+}
+
+// parseExtraNamespaces returns the additional namespaces of a query without blanks, duplicates and the namespace of the
+// query itself.
+func parseExtraNamespaces(namespace string, additionalNamespaces []string) []string {
+	var namespaces []string
+	for _, ns := range additionalNamespaces {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || ns == namespace || slices.Contains(namespaces, ns) {
+			continue
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
 func getEndpoint(region string) (string, error) {
 	resolver := cloudwatch.NewDefaultEndpointResolver()
 	endpoint, err := resolver.ResolveEndpoint(region, cloudwatch.EndpointResolverOptions{})
@@ -523,3 +756,23 @@ func getEndpoint(region string) (string, error) {
 	}
 	return fmt.Sprintf("%s.%s", region, consoleURL), nil
 }
+
+// setAggregation sets the dimension and function the series of the query are aggregated across. The dimension is
+// removed from the dimensions of the query, as filtering it would leave nothing to aggregate across.
+func (q *CloudWatchQuery) setAggregation(metricsDataQuery metricsDataQuery) error {
+	if metricsDataQuery.AggregateDimension == nil || strings.TrimSpace(*metricsDataQuery.AggregateDimension) == "" {
+		return nil
+	}
+	q.AggregateDimension = strings.TrimSpace(*metricsDataQuery.AggregateDimension)
+	q.AggregateFunction = string(dataquery.AggregateFunctionSUM)
+	if metricsDataQuery.AggregateFunction != nil && *metricsDataQuery.AggregateFunction != "" {
+		switch function := *metricsDataQuery.AggregateFunction; function {
+		case dataquery.AggregateFunctionSUM, dataquery.AggregateFunctionAVG, dataquery.AggregateFunctionMIN, dataquery.AggregateFunctionMAX:
+			q.AggregateFunction = string(function)
+		default:
+			return backend.DownstreamError(fmt.Errorf("invalid aggregate function %q", function))
+		}
+	}
+	delete(q.Dimensions, q.AggregateDimension)
+	return nil
+}
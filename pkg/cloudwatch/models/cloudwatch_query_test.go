@@ -292,7 +292,7 @@ func TestRequestParser(t *testing.T) {
 			},
 		}
 
-		migratedQueries, err := ParseMetricDataQueries(oldQuery, time.Now(), time.Now(), "us-east-2", logger, false)
+		migratedQueries, err := ParseMetricDataQueries(oldQuery, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 		require.Len(t, migratedQueries, 1)
 		require.NotNil(t, migratedQueries[0])
@@ -327,7 +327,7 @@ func TestRequestParser(t *testing.T) {
 			},
 		}
 
-		migratedQueries, err := ParseMetricDataQueries(oldQuery, time.Now(), time.Now(), "us-east-2", logger, false)
+		migratedQueries, err := ParseMetricDataQueries(oldQuery, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 		require.Len(t, migratedQueries, 1)
 		require.NotNil(t, migratedQueries[0])
@@ -358,7 +358,7 @@ func TestRequestParser(t *testing.T) {
 			},
 		}
 
-		results, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		results, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 		res := results[0]
@@ -401,7 +401,7 @@ func TestRequestParser(t *testing.T) {
 			},
 		}
 
-		results, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		results, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 		require.Len(t, results, 1)
 		res := results[0]
@@ -434,7 +434,7 @@ func TestRequestParser(t *testing.T) {
 			},
 		}
 
-		_, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		_, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.Error(t, err)
 
 		assert.Equal(t, `error parsing query "", json: cannot unmarshal number into Go value of type string
@@ -464,7 +464,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			},
 		}
 
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -496,7 +496,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			to := time.Now()
 			from := to.Local().Add(time.Minute * time.Duration(5))
 
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 60, res[0].Period)
@@ -506,7 +506,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			to := time.Now()
 			from := to.AddDate(0, 0, -1)
 
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 60, res[0].Period)
@@ -515,7 +515,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 		t.Run("Time range is 2 days", func(t *testing.T) {
 			to := time.Now()
 			from := to.AddDate(0, 0, -2)
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 300, res[0].Period)
@@ -525,7 +525,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			to := time.Now()
 			from := to.AddDate(0, 0, -7)
 
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 900, res[0].Period)
@@ -535,7 +535,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			to := time.Now()
 			from := to.AddDate(0, 0, -30)
 
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 3600, res[0].Period)
@@ -545,7 +545,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			to := time.Now()
 			from := to.AddDate(0, 0, -90)
 
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 21600, res[0].Period)
@@ -555,7 +555,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			to := time.Now()
 			from := to.AddDate(-1, 0, 0)
 
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.Nil(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 21600, res[0].Period)
@@ -565,7 +565,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			to := time.Now()
 			from := to.AddDate(-2, 0, 0)
 
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 86400, res[0].Period)
@@ -574,7 +574,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 		t.Run("Time range is 2 days, but 16 days ago", func(t *testing.T) {
 			to := time.Now().AddDate(0, 0, -14)
 			from := to.AddDate(0, 0, -2)
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 300, res[0].Period)
@@ -583,7 +583,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 		t.Run("Time range is 2 days, but 90 days ago", func(t *testing.T) {
 			to := time.Now().AddDate(0, 0, -88)
 			from := to.AddDate(0, 0, -2)
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 3600, res[0].Period)
@@ -592,7 +592,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 		t.Run("Time range is 2 days, but 456 days ago", func(t *testing.T) {
 			to := time.Now().AddDate(0, 0, -454)
 			from := to.AddDate(0, 0, -2)
-			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, from, to, "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			assert.Equal(t, 21600, res[0].Period)
@@ -607,7 +607,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 				}`),
 			},
 		}
-		_, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		_, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.Error(t, err)
 		assert.Equal(t, `error parsing query "", failed to parse period as duration: time: invalid duration "invalid"`, err.Error())
 	})
@@ -622,7 +622,7 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 			},
 		}
 
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 
 		require.Len(t, res, 1)
@@ -630,6 +630,71 @@ func Test_ParseMetricDataQueries_periods(t *testing.T) {
 	})
 }
 
+func Test_ParseMetricDataQueries_fallback_statistic(t *testing.T) {
+	t.Run("is parsed", func(t *testing.T) {
+		query := []backend.DataQuery{{JSON: json.RawMessage(`{"statistic":"Average","fallbackStatistic":"SampleCount"}`)}}
+
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, "SampleCount", res[0].FallbackStatistic)
+	})
+
+	t.Run("returns error if the statistic is invalid", func(t *testing.T) {
+		query := []backend.DataQuery{{JSON: json.RawMessage(`{"statistic":"Average","fallbackStatistic":"Count"}`)}}
+
+		_, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+		assert.EqualError(t, err, `error parsing query "", invalid fallback statistic "Count"`)
+	})
+}
+
+func Test_ParseMetricDataQueries_aggregation(t *testing.T) {
+	t.Run("is parsed and defaults to SUM", func(t *testing.T) {
+		query := []backend.DataQuery{{JSON: json.RawMessage(`{
+			"statistic":"Average",
+			"metricName":"CPUUtilization",
+			"dimensions":{"AutoScalingGroupName":"asg","InstanceId":"*"},
+			"aggregateDimension":"InstanceId"
+		}`)}}
+
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, "InstanceId", res[0].AggregateDimension)
+		assert.Equal(t, "SUM", res[0].AggregateFunction)
+		assert.Equal(t, map[string][]string{"AutoScalingGroupName": {"asg"}}, res[0].Dimensions)
+		assert.Equal(t, GMDApiModeInferredSearchExpression, res[0].GetGetMetricDataAPIMode())
+	})
+
+	t.Run("returns error if the function is invalid", func(t *testing.T) {
+		query := []backend.DataQuery{{JSON: json.RawMessage(`{"statistic":"Average","aggregateDimension":"InstanceId","aggregateFunction":"AVERAGE"}`)}}
+
+		_, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+		assert.EqualError(t, err, `error parsing query "", invalid aggregate function "AVERAGE"`)
+	})
+}
+
+func Test_ParseMetricDataQueries_panel_interval(t *testing.T) {
+	t.Run("is the floor of auto periods", func(t *testing.T) {
+		query := []backend.DataQuery{{JSON: json.RawMessage(`{"statistic":"Average","period":"auto"}`), Interval: 10 * time.Minute}}
+
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, 10*time.Minute, res[0].PanelInterval)
+		assert.Equal(t, 900, res[0].Period)
+	})
+
+	t.Run("leaves requested periods untouched", func(t *testing.T) {
+		query := []backend.DataQuery{{JSON: json.RawMessage(`{"statistic":"Average","period":"60"}`), Interval: 10 * time.Minute}}
+
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, 60, res[0].Period)
+	})
+}
+
 func Test_ParseMetricDataQueries_query_type_and_metric_editor_mode_and_GMD_query_api_mode(t *testing.T) {
 	const dummyTestEditorMode dataquery.MetricEditorMode = 99
 	testCases := map[string]struct {
@@ -709,7 +774,7 @@ func Test_ParseMetricDataQueries_query_type_and_metric_editor_mode_and_GMD_query
 					),
 				},
 			}
-			res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false)
+			res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 			require.NoError(t, err)
 			require.Len(t, res, 1)
 			require.NotNil(t, res[0])
@@ -735,7 +800,7 @@ func Test_ParseMetricDataQueries_hide_and_ReturnData(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -756,7 +821,7 @@ func Test_ParseMetricDataQueries_hide_and_ReturnData(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -777,7 +842,7 @@ func Test_ParseMetricDataQueries_hide_and_ReturnData(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -796,7 +861,7 @@ func Test_ParseMetricDataQueries_hide_and_ReturnData(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -817,7 +882,7 @@ func Test_ParseMetricDataQueries_hide_and_ReturnData(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -838,7 +903,7 @@ func Test_ParseMetricDataQueries_hide_and_ReturnData(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -861,7 +926,7 @@ func Test_ParseMetricDataQueries_ID(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -882,7 +947,7 @@ func Test_ParseMetricDataQueries_ID(t *testing.T) {
 				}`),
 			},
 		}
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
 		require.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -891,6 +956,109 @@ func Test_ParseMetricDataQueries_ID(t *testing.T) {
 	})
 }
 
+func Test_ParseMetricDataQueries_additional_namespaces(t *testing.T) {
+	query := []backend.DataQuery{
+		{
+			RefID: "A",
+			JSON: json.RawMessage(`{
+			   "refId":"A",
+			   "region":"us-east-1",
+			   "namespace":"AWS/EC2",
+			   "additionalNamespaces":["AWS/EBS", "", "AWS/EC2", " AWS/ELB ", "AWS/EBS"],
+			   "metricName":"CPUUtilization",
+			   "statistic":"Average",
+			   "dimensions":{"InstanceId":"i-123"},
+			   "metricQueryType":0,
+			   "metricEditorMode":0
+			}`),
+		},
+	}
+	res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, []string{"AWS/EBS", "AWS/ELB"}, res[0].ExtraNamespaces)
+	assert.Equal(t, GMDApiModeInferredSearchExpression, res[0].GetGetMetricDataAPIMode())
+}
+
+func Test_ParseMetricDataQueries_series_sort_and_limit(t *testing.T) {
+	parse := func(fields string) ([]*CloudWatchQuery, error) {
+		return ParseMetricDataQueries([]backend.DataQuery{{
+			RefID: "A",
+			JSON:  json.RawMessage(`{"refId":"A","region":"us-east-1","namespace":"AWS/EC2","metricName":"CPUUtilization","statistic":"Average",` + fields + `}`),
+		}}, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+	}
+
+	t.Run("defaults to unsorted, descending and unlimited", func(t *testing.T) {
+		res, err := parse(`"id":"a"`)
+		require.NoError(t, err)
+		assert.Equal(t, dataquery.SeriesSortBy(""), res[0].SeriesSortBy)
+		assert.Equal(t, dataquery.SeriesSortOrderDesc, res[0].SeriesSortOrder)
+		assert.Equal(t, 0, res[0].SeriesLimit)
+	})
+
+	t.Run("sets the series filter", func(t *testing.T) {
+		res, err := parse(`"seriesFilter":"^canary-","seriesFilterDimension":" InstanceId ","seriesFilterExclude":true`)
+		require.NoError(t, err)
+		require.NotNil(t, res[0].SeriesFilter)
+		assert.Equal(t, "^canary-", res[0].SeriesFilter.String())
+		assert.Equal(t, "InstanceId", res[0].SeriesFilterDimension)
+		assert.True(t, res[0].SeriesFilterExclude)
+	})
+
+	t.Run("sets the sort and limit", func(t *testing.T) {
+		res, err := parse(`"seriesSortBy":"Max","seriesSortOrder":"Asc","seriesLimit":10`)
+		require.NoError(t, err)
+		assert.Equal(t, dataquery.SeriesSortByMax, res[0].SeriesSortBy)
+		assert.Equal(t, dataquery.SeriesSortOrderAsc, res[0].SeriesSortOrder)
+		assert.Equal(t, 10, res[0].SeriesLimit)
+	})
+
+	for fields, expected := range map[string]string{
+		`"seriesSortBy":"Min"`:      `unknown series sort "Min", must be one of Last, Avg or Max`,
+		`"seriesSortOrder":"Up"`:    `unknown series sort order "Up", must be Desc or Asc`,
+		`"seriesLimit":-1`:          "series limit must not be negative, got -1",
+		`"seriesFilter":"canary-("`: "invalid series filter: error parsing regexp: missing closing ): `canary-(`",
+	} {
+		t.Run("rejects "+fields, func(t *testing.T) {
+			_, err := parse(fields)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+}
+
+func Test_ParseMetricDataQueries_percentiles(t *testing.T) {
+	parse := func(fields string) ([]*CloudWatchQuery, error) {
+		return ParseMetricDataQueries([]backend.DataQuery{{
+			RefID: "A",
+			JSON:  json.RawMessage(`{"refId":"A","region":"us-east-1","namespace":"AWS/ELB","metricName":"Latency","statistic":"Average",` + fields + `}`),
+		}}, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "us-east-2", logger, false, nil)
+	}
+
+	t.Run("queries the statistic without percentiles", func(t *testing.T) {
+		res, err := parse(`"percentiles":[" "]`)
+		require.NoError(t, err)
+		assert.Nil(t, res[0].Percentiles)
+	})
+
+	t.Run("orders the percentiles ascending", func(t *testing.T) {
+		res, err := parse(`"percentiles":["p99.9","p50","p99","p9","p50"]`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p9", "p50", "p99", "p99.9"}, res[0].Percentiles)
+	})
+
+	for fields, expected := range map[string]string{
+		`"percentiles":["p50","tm99"]`: `invalid percentile "tm99", must be a percentile statistic such as p99 or p99.9`,
+		`"percentiles":["p100"]`:       `invalid percentile "p100"`,
+		`"percentiles":["p1","p2","p3","p4","p5","p6","p7","p8","p9","p10","p11"]`: "a query can query up to 10 percentiles, got 11",
+		`"percentiles":["p50"],"metricEditorMode":1,"expression":"SUM(m1)"`:        "percentiles can only be queried by metric search queries in the builder",
+	} {
+		t.Run("rejects "+fields, func(t *testing.T) {
+			_, err := parse(fields)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+}
+
 func Test_ParseMetricDataQueries_sets_label_when_label_is_present_in_json_query(t *testing.T) {
 	query := []backend.DataQuery{
 		{
@@ -908,7 +1076,7 @@ func Test_ParseMetricDataQueries_sets_label_when_label_is_present_in_json_query(
 		},
 	}
 
-	res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false)
+	res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 	assert.NoError(t, err)
 	require.Len(t, res, 1)
 	require.NotNil(t, res[0])
@@ -972,7 +1140,7 @@ func Test_ParseMetricDataQueries_migrate_alias_to_label(t *testing.T) {
 			},
 		}
 
-		res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 
 		require.Len(t, res, 1)
@@ -1018,7 +1186,7 @@ func Test_ParseMetricDataQueries_migrate_alias_to_label(t *testing.T) {
 			},
 		}
 
-		res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 		require.Len(t, res, 2)
 
@@ -1077,7 +1245,7 @@ func Test_ParseMetricDataQueries_migrate_alias_to_label(t *testing.T) {
 				}`, tc.labelJson)),
 					},
 				}
-				res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false)
+				res, err := ParseMetricDataQueries(query, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 				assert.NoError(t, err)
 
 				require.Len(t, res, 1)
@@ -1103,7 +1271,7 @@ func Test_ParseMetricDataQueries_statistics_and_query_type_validation_and_MatchE
 				{
 					JSON: []byte("{}"),
 				},
-			}, time.Now(), time.Now(), "us-east-2", logger, false)
+			}, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.Error(t, err)
 		assert.Equal(t, `error parsing query "", query must have either statistic or statistics field`, err.Error())
 
@@ -1116,7 +1284,7 @@ func Test_ParseMetricDataQueries_statistics_and_query_type_validation_and_MatchE
 				{
 					JSON: []byte(`{"type":"some other type", "statistic":"Average", "matchExact":false}`),
 				},
-			}, time.Now(), time.Now(), "us-east-2", logger, false)
+			}, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 
 		assert.Empty(t, actual)
@@ -1128,7 +1296,7 @@ func Test_ParseMetricDataQueries_statistics_and_query_type_validation_and_MatchE
 				{
 					JSON: []byte(`{"statistic":"Average"}`),
 				},
-			}, time.Now(), time.Now(), "us-east-2", logger, false)
+			}, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 
 		assert.NotEmpty(t, actual)
@@ -1140,7 +1308,7 @@ func Test_ParseMetricDataQueries_statistics_and_query_type_validation_and_MatchE
 				{
 					JSON: []byte(`{"statistic":"Average"}`),
 				},
-			}, time.Now(), time.Now(), "us-east-2", logger, false)
+			}, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 
 		assert.Len(t, actual, 1)
@@ -1154,7 +1322,7 @@ func Test_ParseMetricDataQueries_statistics_and_query_type_validation_and_MatchE
 				{
 					JSON: []byte(`{"statistic":"Average","matchExact":false}`),
 				},
-			}, time.Now(), time.Now(), "us-east-2", logger, false)
+			}, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 
 		assert.Len(t, actual, 1)
@@ -1170,7 +1338,7 @@ func Test_ParseMetricDataQueries_account_Id(t *testing.T) {
 				{
 					JSON: []byte(`{"accountId":"some account id", "statistic":"Average"}`),
 				},
-			}, time.Now(), time.Now(), "us-east-2", logger, true)
+			}, time.Now(), time.Now(), "us-east-2", logger, true, nil)
 		assert.NoError(t, err)
 
 		require.Len(t, actual, 1)
@@ -1185,7 +1353,7 @@ func Test_ParseMetricDataQueries_account_Id(t *testing.T) {
 				{
 					JSON: []byte(`{"accountId":"some account id", "statistic":"Average"}`),
 				},
-			}, time.Now(), time.Now(), "us-east-2", logger, false)
+			}, time.Now(), time.Now(), "us-east-2", logger, false, nil)
 		assert.NoError(t, err)
 
 		require.Len(t, actual, 1)
@@ -1217,7 +1385,7 @@ func Test_ParseMetricDataQueries_default_region(t *testing.T) {
 		}
 
 		region := "us-east-2"
-		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), region, logger, false)
+		res, err := ParseMetricDataQueries(query, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), region, logger, false, nil)
 		assert.NoError(t, err)
 		require.Len(t, res, 1)
 		require.NotNil(t, res[0])
@@ -1264,7 +1432,7 @@ func Test_ParseMetricDataQueries_ApplyMacros(t *testing.T) {
 								"metricEditorMode": 1
 							 }`),
 						},
-					}, tc.startTime, time.Now(), "us-east-1", logger, false)
+					}, tc.startTime, time.Now(), "us-east-1", logger, false, nil)
 				assert.NoError(t, err)
 				assert.Equal(t, fmt.Sprintf("SEARCH('{AWS/EC2,InstanceId}', 'Average', %s)", tc.expectedPeriod), actual[0].Expression)
 			})
@@ -1289,7 +1457,7 @@ func Test_ParseMetricDataQueries_ApplyMacros(t *testing.T) {
 						"metricEditorMode": 1
 					 }`),
 				},
-			}, time.Now(), time.Now(), "us-east-1", logger, false)
+			}, time.Now(), time.Now(), "us-east-1", logger, false, nil)
 		assert.NoError(t, err)
 		assert.Equal(t, "SEARCH('{AWS/EC2,InstanceId}', 'Average', $__period_auto)", actual[0].Expression)
 	})
@@ -5,3 +5,15 @@ import "fmt"
 // put misc expected user errors here
 
 var ErrMissingRegion = fmt.Errorf("missing default region")
+
+var ErrMissingWebIdentityToken = fmt.Errorf("user identity pass-through requires the user's OAuth identity to be forwarded, but no identity token was found on the request")
+
+var ErrNoWebIdentityRole = fmt.Errorf("no role is mapped to the current user for user identity pass-through")
+
+var ErrNoOrgRole = fmt.Errorf("no role is mapped to the current organization")
+
+var ErrQueryRoleNotAllowed = fmt.Errorf("the role of the query isn't one of the roles the data source allows queries to assume")
+
+var ErrQueryRoleWithMappedRoles = fmt.Errorf("queries can't assume their own role when roles are mapped per organization or user")
+
+var ErrRegionNotEnabled = fmt.Errorf("region not enabled for this account")
@@ -1,5 +1,9 @@
 package models
 
+// MaxDatapointsPerRequest is the maximum number of datapoints a single GetMetricData request returns.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/APIReference/API_GetMetricData.html
+const MaxDatapointsPerRequest = 100800
+
 const (
 	MaxMetricsExceeded         = "MaxMetricsExceeded"
 	MaxQueryTimeRangeExceeded  = "MaxQueryTimeRangeExceeded"
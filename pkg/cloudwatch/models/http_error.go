@@ -2,10 +2,22 @@ package models
 
 import "fmt"
 
+// Machine-readable codes of the errors of the resource handlers, so that the frontend can tell them apart.
+const (
+	HttpErrorCodeBadRequest   = "BadRequest"
+	HttpErrorCodeAccessDenied = "AccessDenied"
+	HttpErrorCodeThrottled    = "Throttled"
+	HttpErrorCodeTimeout      = "Timeout"
+	HttpErrorCodeInternal     = "InternalError"
+)
+
 type HttpError struct {
 	Message    string
 	Error      string
 	StatusCode int
+	// Code is a machine-readable code of the error, either one of the HttpErrorCode constants or the code of the
+	// AWS API error it was caused by.
+	Code string `json:",omitempty"`
 }
 
 func NewHttpError(message string, statusCode int, err error) *HttpError {
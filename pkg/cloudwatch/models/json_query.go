@@ -0,0 +1,83 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// maxMetricDataQueries is the maximum number of MetricDataQueries of a GetMetricData request.
+const maxMetricDataQueries = 500
+
+// highResolutionPeriods are the periods below a minute GetMetricData accepts. Longer periods must be multiples of 60.
+var highResolutionPeriods = []int32{1, 5, 10, 20, 30}
+
+// ParseMetricDataQueriesJSON parses the MetricDataQueries array of an advanced JSON query, as given to the
+// GetMetricData API, e.g. `[{"Id": "m1", "MetricStat": {"Metric": {...}, "Period": 300, "Stat": "Sum"}}]`. The
+// queries are validated here rather than by the API, so that mistakes are reported with the query they are in.
+func ParseMetricDataQueriesJSON(metricDataQueries string) ([]cloudwatchtypes.MetricDataQuery, error) {
+	var queries []cloudwatchtypes.MetricDataQuery
+	decoder := json.NewDecoder(bytes.NewReader([]byte(metricDataQueries)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&queries); err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("invalid MetricDataQueries: %w", err))
+	}
+	if len(queries) == 0 {
+		return nil, backend.DownstreamError(errors.New("invalid MetricDataQueries: at least one query is required"))
+	}
+	if len(queries) > maxMetricDataQueries {
+		return nil, backend.DownstreamError(fmt.Errorf("invalid MetricDataQueries: at most %d queries are allowed", maxMetricDataQueries))
+	}
+
+	ids := make(map[string]bool, len(queries))
+	returnsData := false
+	for i, query := range queries {
+		if err := validateMetricDataQuery(query); err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid MetricDataQueries[%d]: %w", i, err))
+		}
+		if ids[*query.Id] {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid MetricDataQueries[%d]: the Id %q is used by another query", i, *query.Id))
+		}
+		ids[*query.Id] = true
+		returnsData = returnsData || query.ReturnData == nil || *query.ReturnData
+	}
+	if !returnsData {
+		return nil, backend.DownstreamError(errors.New("invalid MetricDataQueries: at least one query must return data"))
+	}
+	return queries, nil
+}
+
+func validateMetricDataQuery(query cloudwatchtypes.MetricDataQuery) error {
+	if query.Id == nil || !validMetricDataID.MatchString(*query.Id) {
+		return errors.New("the Id must start with a lowercase letter and contain only letters, numbers and underscores")
+	}
+	if (query.MetricStat == nil) == (query.Expression == nil) {
+		return errors.New("exactly one of MetricStat and Expression is required")
+	}
+	if query.Expression != nil {
+		if *query.Expression == "" {
+			return errors.New("the Expression must not be empty")
+		}
+		return nil
+	}
+
+	stat := query.MetricStat
+	if stat.Metric == nil || stat.Metric.Namespace == nil || stat.Metric.MetricName == nil {
+		return errors.New("the Metric of a MetricStat requires a Namespace and MetricName")
+	}
+	if stat.Stat == nil || *stat.Stat == "" {
+		return errors.New("the Stat of a MetricStat is required")
+	}
+	if stat.Period == nil || *stat.Period <= 0 {
+		return errors.New("the Period of a MetricStat must be a positive number of seconds")
+	}
+	if *stat.Period < 60 && !slices.Contains(highResolutionPeriods, *stat.Period) || *stat.Period >= 60 && *stat.Period%60 != 0 {
+		return fmt.Errorf("the Period of a MetricStat must be 1, 5, 10, 20, 30 or a multiple of 60, got %d", *stat.Period)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricDataQueriesJSON(t *testing.T) {
+	t.Run("parses the queries as given to the API", func(t *testing.T) {
+		queries, err := ParseMetricDataQueriesJSON(`[
+			{"Id": "m1", "MetricStat": {"Metric": {"Namespace": "AWS/EC2", "MetricName": "CPUUtilization",
+				"Dimensions": [{"Name": "InstanceId", "Value": "i-123"}]}, "Period": 10, "Stat": "p99"}, "ReturnData": false},
+			{"Id": "e1", "Expression": "m1 * 2", "Label": "Doubled", "AccountId": "111111111111"}
+		]`)
+
+		require.NoError(t, err)
+		require.Len(t, queries, 2)
+		assert.Equal(t, "CPUUtilization", *queries[0].MetricStat.Metric.MetricName)
+		assert.Equal(t, "i-123", *queries[0].MetricStat.Metric.Dimensions[0].Value)
+		assert.Equal(t, aws.Int32(10), queries[0].MetricStat.Period)
+		assert.Equal(t, aws.Bool(false), queries[0].ReturnData)
+		assert.Equal(t, "m1 * 2", *queries[1].Expression)
+		assert.Equal(t, "111111111111", *queries[1].AccountId)
+	})
+
+	tests := map[string]struct {
+		json    string
+		message string
+	}{
+		"malformed json":     {json: `[{"Id": "m1",}]`, message: "invalid MetricDataQueries: invalid character"},
+		"not an array":       {json: `{"Id": "m1"}`, message: "cannot unmarshal object"},
+		"unknown field":      {json: `[{"Id": "m1", "Expresion": "SEARCH('x', 'Sum')"}]`, message: `unknown field "Expresion"`},
+		"no queries":         {json: `[]`, message: "at least one query is required"},
+		"invalid id":         {json: `[{"Id": "M1", "Expression": "1"}]`, message: "MetricDataQueries[0]: the Id must start with a lowercase letter"},
+		"duplicate id":       {json: `[{"Id": "e1", "Expression": "1"}, {"Id": "e1", "Expression": "2"}]`, message: `MetricDataQueries[1]: the Id "e1" is used by another query`},
+		"both stat and expr": {json: `[{"Id": "e1", "Expression": "1", "MetricStat": {}}]`, message: "exactly one of MetricStat and Expression"},
+		"neither":            {json: `[{"Id": "e1"}]`, message: "exactly one of MetricStat and Expression"},
+		"missing metric":     {json: `[{"Id": "m1", "MetricStat": {"Period": 60, "Stat": "Sum"}}]`, message: "requires a Namespace and MetricName"},
+		"missing stat": {json: `[{"Id": "m1", "MetricStat": {"Metric": {"Namespace": "AWS/EC2", "MetricName": "CPUUtilization"},
+			"Period": 60}}]`, message: "the Stat of a MetricStat is required"},
+		"invalid period": {json: `[{"Id": "m1", "MetricStat": {"Metric": {"Namespace": "AWS/EC2", "MetricName": "CPUUtilization"},
+			"Period": 90, "Stat": "Sum"}}]`, message: "must be 1, 5, 10, 20, 30 or a multiple of 60, got 90"},
+		"no data returned": {json: `[{"Id": "e1", "Expression": "1", "ReturnData": false}]`, message: "at least one query must return data"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseMetricDataQueriesJSON(tc.json)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.message)
+			assert.True(t, backend.IsDownstreamError(err))
+		})
+	}
+
+	t.Run("fails more queries than a request allows", func(t *testing.T) {
+		queries := make([]string, maxMetricDataQueries+1)
+		for i := range queries {
+			queries[i] = `{"Id": "e` + strings.Repeat("x", i%10) + `", "Expression": "1"}`
+		}
+		_, err := ParseMetricDataQueriesJSON("[" + strings.Join(queries, ",") + "]")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "at most 500 queries are allowed")
+	})
+}
@@ -8,11 +8,10 @@ type LogsQuery struct {
 	dataquery.CloudWatchLogsQuery
 	StartTime     *int64
 	EndTime       *int64
-	Limit         *int32
 	LogGroupName  string
-	LogStreamName string
+	LogStreamName string `json:"logStreamName"`
 	QueryId       string
 	QueryString   string
-	StartFromHead bool
+	StartFromHead bool `json:"startFromHead"`
 	Subtype       string
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// MatrixQueryType is the type of queries returning the latest value of a metric in each region and account of a
+// matrix.
+const MatrixQueryType = "matrixQuery"
+
+// ParseMatrixQueries parses the matrix queries of dataQueries. A matrix query queries a single metric, so it has to
+// be a builder query without wildcard dimensions, and it has to have at least one region.
+func ParseMatrixQueries(dataQueries []backend.DataQuery, startTime time.Time, endTime time.Time, defaultRegion string,
+	logger log.Logger, variables map[string]string) ([]*CloudWatchQuery, error) {
+	queries, err := parseMetricDataQueries(dataQueries, []string{MatrixQueryType}, startTime, endTime, defaultRegion, logger,
+		false, variables)
+	if err != nil {
+		return nil, err
+	}
+	for _, query := range queries {
+		if query.GetGetMetricDataAPIMode() != GMDApiModeMetricStat {
+			return nil, &QueryError{Err: backend.DownstreamError(errors.New("a matrix query must query a single metric, without wildcard or multiple dimension values")), RefID: query.RefId}
+		}
+		if len(query.MatrixRegions) == 0 {
+			return nil, &QueryError{Err: backend.DownstreamError(errors.New("a matrix query must query at least one region")), RefID: query.RefId}
+		}
+	}
+	return queries, nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+)
+
+// MigrateQueries upgrades the json of saved queries to the current dataquery schema, applying the migrations the
+// frontend applies when loading a dashboard:
+//   - a `statistics` array becomes a `statistic`, and every other statistic of the array gets a query of its own
+//   - dimension values stored as strings become arrays of strings
+//   - an `alias` using the {{pattern}} syntax becomes a `label` using dynamic labels
+//   - `metricQueryType` and `metricEditorMode` get their default values
+//
+// Queries are normalized to the canonical form of their kind beforehand, see normalizeQuery, and rejected if they
+// don't have the shape of their kind afterwards.
+func MigrateQueries(queries []backend.DataQuery) ([]map[string]any, error) {
+	migrated := make([]map[string]any, 0, len(queries))
+	// like in the frontend, the queries of the other statistics come after the queries of the request
+	var extras []map[string]any
+	refIds := make(map[string]bool, len(queries))
+	for _, query := range queries {
+		refIds[query.RefID] = true
+	}
+
+	for _, query := range queries {
+		var model map[string]any
+		if err := json.Unmarshal(query.JSON, &model); err != nil {
+			return nil, &QueryError{Err: backend.DownstreamError(err), RefID: query.RefID}
+		}
+		model["refId"] = query.RefID
+		if err := normalizeQuery(model); err != nil {
+			return nil, &QueryError{Err: backend.DownstreamError(err), RefID: query.RefID}
+		}
+
+		if model["queryMode"] != string(dataquery.CloudWatchQueryModeMetrics) {
+			if err := checkQueryKind(model); err != nil {
+				return nil, &QueryError{Err: backend.DownstreamError(err), RefID: query.RefID}
+			}
+			migrated = append(migrated, model)
+			continue
+		}
+
+		extraStatistics := migrateStatistics(model)
+		if err := migrateDimensions(model); err != nil {
+			return nil, &QueryError{Err: backend.DownstreamError(err), RefID: query.RefID}
+		}
+		migrateAlias(model)
+		migrateEditorMode(model)
+		if err := checkQueryKind(model); err != nil {
+			return nil, &QueryError{Err: backend.DownstreamError(err), RefID: query.RefID}
+		}
+		migrated = append(migrated, model)
+
+		for _, statistic := range extraStatistics {
+			extra := make(map[string]any, len(model))
+			for key, value := range model {
+				extra[key] = value
+			}
+			extra["statistic"] = statistic
+			extra["refId"] = nextRefId(refIds)
+			refIds[extra["refId"].(string)] = true
+			extras = append(extras, extra)
+		}
+	}
+
+	return append(migrated, extras...), nil
+}
+
+// migrateStatistics sets the statistic of a query with a legacy statistics array to the first statistic of the
+// array, and returns the others, which need a query of their own. Read more here
+// https://github.com/grafana/grafana/issues/30629
+func migrateStatistics(model map[string]any) []string {
+	statistics, ok := model["statistics"].([]any)
+	delete(model, "statistics")
+	if !ok {
+		return nil
+	}
+	if _, ok := model["statistic"]; ok {
+		return nil
+	}
+
+	var stats []string
+	for _, statistic := range statistics {
+		if stat, ok := statistic.(string); ok && stat != "" {
+			stats = append(stats, stat)
+		}
+	}
+	if len(stats) == 0 {
+		// same fallback as getStatistic
+		model["statistic"] = "Average"
+		return nil
+	}
+	model["statistic"] = stats[0]
+	return stats[1:]
+}
+
+// migrateDimensions converts the dimension values stored as strings before 6.5 to arrays of strings.
+func migrateDimensions(model map[string]any) error {
+	dimensions, ok := model["dimensions"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	for key, value := range dimensions {
+		switch v := value.(type) {
+		case string:
+			dimensions[key] = []any{v}
+		case []any:
+		default:
+			return fmt.Errorf("unknown type as value of dimension %q", key)
+		}
+	}
+	return nil
+}
+
+// migrateAlias sets the label of a query without one from its legacy alias. See
+// https://github.com/grafana/grafana/issues/48434
+func migrateAlias(model map[string]any) {
+	if _, ok := model["label"]; ok {
+		return
+	}
+	alias, _ := model["alias"].(string)
+	model["label"] = aliasToLabel(alias)
+}
+
+// migrateEditorMode sets the query type and editor mode of queries saved before there were SQL queries and a builder.
+func migrateEditorMode(model map[string]any) {
+	if _, ok := model["metricQueryType"]; !ok {
+		model["metricQueryType"] = MetricQueryTypeSearch
+	}
+	if _, ok := model["metricEditorMode"]; ok {
+		return
+	}
+	expression, _ := model["expression"].(string)
+	if model["metricQueryType"] == float64(MetricQueryTypeQuery) || expression != "" {
+		model["metricEditorMode"] = MetricEditorModeRaw
+	} else {
+		model["metricEditorMode"] = MetricEditorModeBuilder
+	}
+}
+
+// nextRefId returns the first refId, in the A, B, ..., Z, AA, AB, ... order of the query editor, that isn't taken.
+func nextRefId(taken map[string]bool) string {
+	for num := 0; ; num++ {
+		if refId := refIdFor(num); !taken[refId] {
+			return refId
+		}
+	}
+}
+
+func refIdFor(num int) string {
+	if num < 26 {
+		return string(rune('A' + num))
+	}
+	return refIdFor(num/26-1) + refIdFor(num%26)
+}
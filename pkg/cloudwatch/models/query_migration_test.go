@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func migratedJSON(t *testing.T, queries []backend.DataQuery) []string {
+	t.Helper()
+	migrated, err := MigrateQueries(queries)
+	require.NoError(t, err)
+	result := make([]string, len(migrated))
+	for i, query := range migrated {
+		j, err := json.Marshal(query)
+		require.NoError(t, err)
+		result[i] = string(j)
+	}
+	return result
+}
+
+func Test_MigrateQueries(t *testing.T) {
+	t.Run("splits legacy statistics into one query per statistic", func(t *testing.T) {
+		migrated := migratedJSON(t, []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"refId":"A","namespace":"AWS/EC2","metricName":"CPUUtilization","statistics":["Average","Maximum","p99"],"label":"cpu"}`)},
+			{RefID: "B", JSON: json.RawMessage(`{"refId":"B","namespace":"AWS/EC2","metricName":"NetworkIn","statistic":"Sum","label":"net"}`)},
+		})
+
+		require.Len(t, migrated, 4)
+		assert.JSONEq(t, `{"refId":"A","queryMode":"Metrics","namespace":"AWS/EC2","metricName":"CPUUtilization","statistic":"Average","label":"cpu","metricQueryType":0,"metricEditorMode":0}`, migrated[0])
+		assert.JSONEq(t, `{"refId":"B","queryMode":"Metrics","namespace":"AWS/EC2","metricName":"NetworkIn","statistic":"Sum","label":"net","metricQueryType":0,"metricEditorMode":0}`, migrated[1])
+		assert.JSONEq(t, `{"refId":"C","queryMode":"Metrics","namespace":"AWS/EC2","metricName":"CPUUtilization","statistic":"Maximum","label":"cpu","metricQueryType":0,"metricEditorMode":0}`, migrated[2])
+		assert.JSONEq(t, `{"refId":"D","queryMode":"Metrics","namespace":"AWS/EC2","metricName":"CPUUtilization","statistic":"p99","label":"cpu","metricQueryType":0,"metricEditorMode":0}`, migrated[3])
+	})
+
+	t.Run("falls back to Average for an empty statistics array and keeps an existing statistic", func(t *testing.T) {
+		migrated := migratedJSON(t, []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"statistics":[],"label":""}`)},
+			{RefID: "B", JSON: json.RawMessage(`{"statistic":"Minimum","statistics":["Maximum"],"label":""}`)},
+		})
+
+		require.Len(t, migrated, 2)
+		assert.JSONEq(t, `{"refId":"A","queryMode":"Metrics","statistic":"Average","label":"","metricQueryType":0,"metricEditorMode":0}`, migrated[0])
+		assert.JSONEq(t, `{"refId":"B","queryMode":"Metrics","statistic":"Minimum","label":"","metricQueryType":0,"metricEditorMode":0}`, migrated[1])
+	})
+
+	t.Run("converts string dimension values to arrays", func(t *testing.T) {
+		migrated := migratedJSON(t, []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"statistic":"Average","label":"","dimensions":{"InstanceId":"i-123","AutoScalingGroupName":["a","b"]}}`)},
+		})
+
+		assert.JSONEq(t, `{"refId":"A","queryMode":"Metrics","statistic":"Average","label":"","dimensions":{"InstanceId":["i-123"],"AutoScalingGroupName":["a","b"]},"metricQueryType":0,"metricEditorMode":0}`, migrated[0])
+	})
+
+	t.Run("migrates the alias to a label", func(t *testing.T) {
+		migrated := migratedJSON(t, []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"statistic":"Average","alias":"{{metric}} {{ InstanceId }} {{stat}}"}`)},
+			{RefID: "B", JSON: json.RawMessage(`{"statistic":"Average","alias":"{{metric}}","label":"kept"}`)},
+		})
+
+		assert.JSONEq(t, `{"refId":"A","queryMode":"Metrics","statistic":"Average","alias":"{{metric}} {{ InstanceId }} {{stat}}","label":"${PROP('MetricName')} ${PROP('Dim.InstanceId')} ${PROP('Stat')}","metricQueryType":0,"metricEditorMode":0}`, migrated[0])
+		assert.JSONEq(t, `{"refId":"B","queryMode":"Metrics","statistic":"Average","alias":"{{metric}}","label":"kept","metricQueryType":0,"metricEditorMode":0}`, migrated[1])
+	})
+
+	t.Run("sets the editor mode to code for queries with an expression and insights queries", func(t *testing.T) {
+		migrated := migratedJSON(t, []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"statistic":"Average","label":"","expression":"SUM(METRICS())"}`)},
+			{RefID: "B", JSON: json.RawMessage(`{"statistic":"Average","label":"","metricQueryType":1}`)},
+			{RefID: "C", JSON: json.RawMessage(`{"statistic":"Average","label":"","metricQueryType":0,"metricEditorMode":1}`)},
+		})
+
+		assert.JSONEq(t, `{"refId":"A","queryMode":"Metrics","statistic":"Average","label":"","expression":"SUM(METRICS())","metricQueryType":0,"metricEditorMode":1}`, migrated[0])
+		assert.JSONEq(t, `{"refId":"B","queryMode":"Metrics","statistic":"Average","label":"","metricQueryType":1,"metricEditorMode":1}`, migrated[1])
+		assert.JSONEq(t, `{"refId":"C","queryMode":"Metrics","statistic":"Average","label":"","metricQueryType":0,"metricEditorMode":1}`, migrated[2])
+	})
+
+	t.Run("leaves logs queries as they are", func(t *testing.T) {
+		migrated := migratedJSON(t, []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"queryMode":"Logs","expression":"fields @message","statistics":["Average"]}`)},
+		})
+
+		assert.JSONEq(t, `{"refId":"A","queryMode":"Logs","expression":"fields @message","statistics":["Average"]}`, migrated[0])
+	})
+
+	t.Run("fails on dimension values of an unknown type", func(t *testing.T) {
+		_, err := MigrateQueries([]backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"statistic":"Average","dimensions":{"InstanceId":1}}`)},
+		})
+
+		var queryErr *QueryError
+		require.ErrorAs(t, err, &queryErr)
+		assert.Equal(t, "A", queryErr.RefID)
+	})
+}
+
+func Test_nextRefId(t *testing.T) {
+	assert.Equal(t, "A", nextRefId(map[string]bool{}))
+	assert.Equal(t, "C", nextRefId(map[string]bool{"A": true, "B": true}))
+
+	taken := map[string]bool{}
+	for num := 0; num < 26; num++ {
+		taken[refIdFor(num)] = true
+	}
+	assert.Equal(t, "AA", nextRefId(taken))
+}
+
+func Test_MigrateQueries_normalizes_provisioned_queries(t *testing.T) {
+	t.Run("removes null fields and converts enum names and numeric periods", func(t *testing.T) {
+		migrated := migratedJSON(t, []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"queryMode":"Metrics","metricQueryType":"Insights","metricEditorMode":"code","sqlExpression":"SELECT AVG(CPUUtilization) FROM SCHEMA(\"AWS/EC2\")","period":300,"statistic":null,"label":"","accountId":null}`)},
+			{RefID: "B", JSON: json.RawMessage(`{"queryMode":"Annotations","metricEditorMode":"Builder","period":60,"statistic":"Average","prefixMatching":false}`)},
+		})
+
+		assert.JSONEq(t, `{"refId":"A","queryMode":"Metrics","metricQueryType":1,"metricEditorMode":1,"sqlExpression":"SELECT AVG(CPUUtilization) FROM SCHEMA(\"AWS/EC2\")","period":"300","label":""}`, migrated[0])
+		assert.JSONEq(t, `{"refId":"B","queryMode":"Annotations","metricEditorMode":0,"period":"60","statistic":"Average","prefixMatching":false}`, migrated[1])
+	})
+
+	t.Run("converts converted queries to themselves", func(t *testing.T) {
+		queries := []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"namespace":"AWS/EC2","metricName":"CPUUtilization","statistics":["Average","Maximum"],"dimensions":{"InstanceId":"i-123"},"alias":"{{InstanceId}}","period":60}`)},
+			{RefID: "B", JSON: json.RawMessage(`{"queryMode":"Logs","expression":"fields @message","logGroups":[{"arn":"arn:aws:logs:us-east-1:123456789012:log-group:app","name":"app"}],"region":null}`)},
+		}
+		migrated := migratedJSON(t, queries)
+
+		converted := make([]backend.DataQuery, len(migrated))
+		for i, query := range migrated {
+			var model struct {
+				RefId string `json:"refId"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(query), &model))
+			converted[i] = backend.DataQuery{RefID: model.RefId, JSON: json.RawMessage(query)}
+		}
+		assert.Equal(t, migrated, migratedJSON(t, converted))
+	})
+
+	t.Run("rejects queries with values of the wrong type or unknown enum values", func(t *testing.T) {
+		for expected, query := range map[string]string{
+			"queryMode: unknown query mode Traces":                                `{"queryMode":"Traces"}`,
+			`metricQueryType: unknown value "SQL"`:                                `{"metricQueryType":"SQL","statistic":"Average"}`,
+			"metricEditorMode: unknown value 2":                                   `{"metricEditorMode":2,"statistic":"Average"}`,
+			"period: 60.5 isn't a whole number of seconds":                        `{"period":60.5,"statistic":"Average"}`,
+			"matchExact: expected a value of type bool, got string":               `{"matchExact":"true","statistic":"Average"}`,
+			"logGroupNames: expected a value of type []string, got string":        `{"queryMode":"Logs","logGroupNames":"app"}`,
+			"prefixMatching: expected a value of type bool, got number":           `{"queryMode":"Annotations","prefixMatching":1}`,
+			"additionalNamespaces: expected a value of type []string, got string": `{"additionalNamespaces":"AWS/EBS","statistic":"Average"}`,
+		} {
+			_, err := MigrateQueries([]backend.DataQuery{{RefID: "A", JSON: json.RawMessage(query)}})
+
+			var queryErr *QueryError
+			require.ErrorAs(t, err, &queryErr, query)
+			assert.ErrorContains(t, queryErr.Err, expected, query)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+)
+
+var metricQueryTypes = map[string]dataquery.MetricQueryType{
+	"search":   dataquery.MetricQueryTypeSearch,
+	"insights": dataquery.MetricQueryTypeInsights,
+	"json":     dataquery.MetricQueryTypeJSON,
+	"tags":     dataquery.MetricQueryTypeTags,
+	"slo":      dataquery.MetricQueryTypeSLO,
+}
+
+var metricEditorModes = map[string]dataquery.MetricEditorMode{
+	"builder": dataquery.MetricEditorModeBuilder,
+	"code":    dataquery.MetricEditorModeCode,
+}
+
+// normalizeQuery converts the values of a query written by hand or by tools provisioning dashboards as code, e.g.
+// Terraform, to their canonical form in the dataquery kinds, so that a converted query converts to itself:
+//   - fields set to null are removed, as the kinds omit fields without a value
+//   - the query mode is set, queries without one being metrics queries
+//   - a metricQueryType or metricEditorMode given by name, e.g. "Insights" or "Code", becomes its numeric value
+//   - a period given as a number of seconds becomes a string
+func normalizeQuery(model map[string]any) error {
+	for key, value := range model {
+		if value == nil {
+			delete(model, key)
+		}
+	}
+
+	queryMode, ok := model["queryMode"]
+	if !ok {
+		queryMode = string(dataquery.CloudWatchQueryModeMetrics)
+		model["queryMode"] = queryMode
+	}
+	switch queryMode {
+	case string(dataquery.CloudWatchQueryModeMetrics), string(dataquery.CloudWatchQueryModeAnnotations):
+	case string(dataquery.CloudWatchQueryModeLogs):
+		return nil
+	default:
+		return fmt.Errorf("queryMode: unknown query mode %v", queryMode)
+	}
+
+	if err := normalizeEnum(model, "metricQueryType", metricQueryTypes); err != nil {
+		return err
+	}
+	if err := normalizeEnum(model, "metricEditorMode", metricEditorModes); err != nil {
+		return err
+	}
+
+	if period, ok := model["period"].(float64); ok {
+		if period != math.Trunc(period) {
+			return fmt.Errorf("period: %v isn't a whole number of seconds", period)
+		}
+		model["period"] = strconv.FormatFloat(period, 'f', -1, 64)
+	}
+	return nil
+}
+
+// normalizeEnum converts the value of an enum field given by name to the numeric value of the kinds.
+func normalizeEnum[T ~int64](model map[string]any, field string, values map[string]T) error {
+	switch value := model[field].(type) {
+	case string:
+		numeric, ok := values[strings.ToLower(value)]
+		if !ok {
+			return fmt.Errorf("%s: unknown value %q", field, value)
+		}
+		model[field] = numeric
+	case float64:
+		for _, numeric := range values {
+			if value == float64(numeric) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: unknown value %v", field, value)
+	}
+	return nil
+}
+
+// checkQueryKind returns an error naming the field of the query that doesn't have the type of its kind, so that
+// a query of the wrong shape is rejected when converted instead of failing when it runs.
+func checkQueryKind(model map[string]any) error {
+	j, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	var kind any
+	switch model["queryMode"] {
+	case string(dataquery.CloudWatchQueryModeLogs):
+		kind = &LogsQuery{}
+	case string(dataquery.CloudWatchQueryModeAnnotations):
+		kind = &dataquery.CloudWatchAnnotationQuery{}
+	default:
+		kind = &metricsDataQuery{}
+	}
+	if err := json.Unmarshal(j, kind); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return fmt.Errorf("%s: expected a value of type %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return err
+	}
+	return nil
+}
@@ -14,6 +14,16 @@ type QueryRowResponse struct {
 	PermissionErrorMessage string
 	Metrics                []*cloudwatchtypes.MetricDataResult
 	StatusCode             cloudwatchtypes.StatusCode
+	// DatapointLimitReached is set when a page of the GetMetricData response containing results of the row
+	// returned MaxDatapointsPerRequest datapoints or more, which means series may have been cut short.
+	DatapointLimitReached bool
+	// TruncatedAfterPages is the number of pages the GetMetricData response the row belongs to was cut short after
+	// by the page cap of the data source, 0 if it wasn't.
+	TruncatedAfterPages int
+	// Pages is the number of pages of the GetMetricData response the row belongs to.
+	Pages int
+	// SampleCounts are the results of the SampleCount of the metric of the row by label, when it was queried too.
+	SampleCounts map[string]*cloudwatchtypes.MetricDataResult
 }
 
 func NewQueryRowResponse(errors map[string]bool) QueryRowResponse {
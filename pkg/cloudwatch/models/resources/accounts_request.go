@@ -0,0 +1,23 @@
+package resources
+
+import (
+	"net/url"
+	"strings"
+)
+
+type AccountsRequest struct {
+	*ResourceRequest
+	LabelSearch string
+}
+
+func ParseAccountsRequest(parameters url.Values) (AccountsRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return AccountsRequest{}, err
+	}
+
+	return AccountsRequest{
+		ResourceRequest: resourceRequest,
+		LabelSearch:     strings.TrimSpace(parameters.Get("labelSearch")),
+	}, nil
+}
@@ -0,0 +1,26 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := ParseAccountsRequest(map[string][]string{
+			"region":      {"us-east-1"},
+			"labelSearch": {" prod "},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+		assert.Equal(t, "prod", request.LabelSearch)
+	})
+
+	t.Run("Should return an error if region is not provided", func(t *testing.T) {
+		_, err := ParseAccountsRequest(map[string][]string{"labelSearch": {"prod"}})
+		require.Error(t, err)
+		assert.Equal(t, "region is required", err.Error())
+	})
+}
@@ -0,0 +1,31 @@
+package resources
+
+import (
+	"net/url"
+)
+
+type AnomalyDetectorsRequest struct {
+	*ResourceRequest
+	Namespace       string
+	MetricName      string
+	DimensionFilter []*Dimension
+}
+
+func ParseAnomalyDetectorsRequest(parameters url.Values) (AnomalyDetectorsRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return AnomalyDetectorsRequest{}, err
+	}
+
+	dimensions, err := parseDimensionFilter(parameters.Get("dimensionFilters"))
+	if err != nil {
+		return AnomalyDetectorsRequest{}, err
+	}
+
+	return AnomalyDetectorsRequest{
+		ResourceRequest: resourceRequest,
+		Namespace:       parameters.Get("namespace"),
+		MetricName:      parameters.Get("metricName"),
+		DimensionFilter: dimensions,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnomalyDetectorsRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := ParseAnomalyDetectorsRequest(map[string][]string{
+			"region":           {"us-east-1"},
+			"namespace":        {"AWS/EC2"},
+			"metricName":       {"CPUUtilization"},
+			"dimensionFilters": {`{"InstanceId":"i-123"}`},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+		assert.Equal(t, "AWS/EC2", request.Namespace)
+		assert.Equal(t, "CPUUtilization", request.MetricName)
+		assert.Equal(t, []*Dimension{{Name: "InstanceId", Value: "i-123"}}, request.DimensionFilter)
+	})
+
+	t.Run("Should return an error if region is not provided", func(t *testing.T) {
+		_, err := ParseAnomalyDetectorsRequest(map[string][]string{"namespace": {"AWS/EC2"}})
+		require.Error(t, err)
+		assert.Equal(t, "region is required", err.Error())
+	})
+}
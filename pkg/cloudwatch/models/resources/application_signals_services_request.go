@@ -0,0 +1,18 @@
+package resources
+
+import (
+	"net/url"
+)
+
+type ApplicationSignalsServicesRequest struct {
+	*ResourceRequest
+}
+
+func ParseApplicationSignalsServicesRequest(parameters url.Values) (ApplicationSignalsServicesRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return ApplicationSignalsServicesRequest{}, err
+	}
+
+	return ApplicationSignalsServicesRequest{ResourceRequest: resourceRequest}, nil
+}
@@ -0,0 +1,18 @@
+package resources
+
+import (
+	"net/url"
+)
+
+type InsightRulesRequest struct {
+	*ResourceRequest
+}
+
+func ParseInsightRulesRequest(parameters url.Values) (InsightRulesRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return InsightRulesRequest{}, err
+	}
+
+	return InsightRulesRequest{ResourceRequest: resourceRequest}, nil
+}
@@ -0,0 +1,67 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const defaultLogContextLimit = 10
+
+type LogContextRequest struct {
+	ResourceRequest
+	LogGroupName  string
+	LogStreamName string
+	// Timestamp is the epoch milliseconds of the event whose context is requested
+	Timestamp int64
+	// Ptr is the @ptr of the event in the results of a Logs Insights query, which identifies its log stream and
+	// timestamp when the request doesn't
+	Ptr string
+	// Limit is the number of events returned before, or after, the event
+	Limit int32
+	// Forward returns the events after the event instead of the ones before it
+	Forward bool
+}
+
+func ParseLogContextRequest(parameters url.Values) (LogContextRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return LogContextRequest{}, err
+	}
+
+	request := LogContextRequest{
+		ResourceRequest: *resourceRequest,
+		LogGroupName:    parameters.Get("logGroupName"),
+		LogStreamName:   parameters.Get("logStreamName"),
+		Ptr:             parameters.Get("ptr"),
+		Limit:           defaultLogContextLimit,
+	}
+
+	if timestamp := parameters.Get("timestamp"); timestamp != "" {
+		request.Timestamp, err = strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return LogContextRequest{}, fmt.Errorf("timestamp must be epoch milliseconds, got %q", timestamp)
+		}
+	}
+	if request.Ptr == "" && (request.LogGroupName == "" || request.LogStreamName == "" || request.Timestamp == 0) {
+		return LogContextRequest{}, fmt.Errorf("you need to specify either ptr or logGroupName, logStreamName and timestamp")
+	}
+
+	if limit := parameters.Get("limit"); limit != "" {
+		parsed, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil || parsed < 1 {
+			return LogContextRequest{}, fmt.Errorf("limit must be a positive integer, got %q", limit)
+		}
+		request.Limit = int32(parsed)
+	}
+
+	switch direction := parameters.Get("direction"); direction {
+	case "", "backward":
+	case "forward":
+		request.Forward = true
+	default:
+		return LogContextRequest{}, fmt.Errorf("direction must be backward or forward, got %q", direction)
+	}
+
+	return request, nil
+}
@@ -0,0 +1,59 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogContextRequest(t *testing.T) {
+	t.Run("Should parse valid parameters", func(t *testing.T) {
+		request, err := ParseLogContextRequest(map[string][]string{
+			"region":        {"us-east-1"},
+			"logGroupName":  {"/aws/lambda/checkout"},
+			"logStreamName": {"2024/01/01/[$LATEST]abc"},
+			"timestamp":     {"1704067200000"},
+			"limit":         {"50"},
+			"direction":     {"forward"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, LogContextRequest{
+			ResourceRequest: ResourceRequest{Region: "us-east-1"},
+			LogGroupName:    "/aws/lambda/checkout",
+			LogStreamName:   "2024/01/01/[$LATEST]abc",
+			Timestamp:       1704067200000,
+			Limit:           50,
+			Forward:         true,
+		}, request)
+	})
+
+	t.Run("Should default to the 10 events before the event of a pointer", func(t *testing.T) {
+		request, err := ParseLogContextRequest(map[string][]string{
+			"region": {"us-east-1"},
+			"ptr":    {"CmAKJQohMTIzNDU2Nzg5MDEyOi9hd3MvbGFtYmRh"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), request.Limit)
+		assert.False(t, request.Forward)
+	})
+
+	t.Run("Should return an error if the event isn't identified", func(t *testing.T) {
+		_, err := ParseLogContextRequest(map[string][]string{
+			"region":       {"us-east-1"},
+			"logGroupName": {"/aws/lambda/checkout"},
+		})
+		assert.EqualError(t, err, "you need to specify either ptr or logGroupName, logStreamName and timestamp")
+	})
+
+	t.Run("Should return an error for invalid parameters", func(t *testing.T) {
+		for name, parameters := range map[string]map[string][]string{
+			"timestamp": {"region": {"us-east-1"}, "ptr": {"p"}, "timestamp": {"yesterday"}},
+			"limit":     {"region": {"us-east-1"}, "ptr": {"p"}, "limit": {"0"}},
+			"direction": {"region": {"us-east-1"}, "ptr": {"p"}, "direction": {"up"}},
+		} {
+			_, err := ParseLogContextRequest(parameters)
+			assert.ErrorContains(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type MetricMetadataRequest struct {
+	*ResourceRequest
+	Namespace       string
+	MetricName      string
+	DimensionFilter []*Dimension
+}
+
+func ParseMetricMetadataRequest(parameters url.Values) (MetricMetadataRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return MetricMetadataRequest{}, err
+	}
+
+	request := MetricMetadataRequest{
+		ResourceRequest: resourceRequest,
+		Namespace:       parameters.Get("namespace"),
+		MetricName:      parameters.Get("metricName"),
+	}
+	if request.Namespace == "" {
+		return MetricMetadataRequest{}, fmt.Errorf("namespace is required")
+	}
+	if request.MetricName == "" {
+		return MetricMetadataRequest{}, fmt.Errorf("metricName is required")
+	}
+
+	request.DimensionFilter, err = parseDimensionFilter(parameters.Get("dimensionFilters"))
+	if err != nil {
+		return MetricMetadataRequest{}, err
+	}
+
+	return request, nil
+}
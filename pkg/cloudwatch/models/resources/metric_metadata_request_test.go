@@ -0,0 +1,40 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricMetadataRequest(t *testing.T) {
+	t.Run("Should parse parameters", func(t *testing.T) {
+		request, err := ParseMetricMetadataRequest(map[string][]string{
+			"region":           {"us-east-1"},
+			"namespace":        {"AWS/Lambda"},
+			"metricName":       {"Invocations"},
+			"dimensionFilters": {`{"FunctionName":"checkout"}`},
+			"accountId":        {"123456789012"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "us-east-1", request.Region)
+		assert.Equal(t, "123456789012", *request.AccountId)
+		assert.Equal(t, "AWS/Lambda", request.Namespace)
+		assert.Equal(t, "Invocations", request.MetricName)
+		assert.Equal(t, []*Dimension{{Name: "FunctionName", Value: "checkout"}}, request.DimensionFilter)
+	})
+
+	t.Run("Should return an error if region is not provided", func(t *testing.T) {
+		_, err := ParseMetricMetadataRequest(map[string][]string{"namespace": {"AWS/Lambda"}, "metricName": {"Invocations"}})
+		require.Error(t, err)
+		assert.Equal(t, "region is required", err.Error())
+	})
+
+	t.Run("Should return an error if the metric is not provided", func(t *testing.T) {
+		_, err := ParseMetricMetadataRequest(map[string][]string{"region": {"us-east-1"}, "metricName": {"Invocations"}})
+		assert.EqualError(t, err, "namespace is required")
+
+		_, err = ParseMetricMetadataRequest(map[string][]string{"region": {"us-east-1"}, "namespace": {"AWS/Lambda"}})
+		assert.EqualError(t, err, "metricName is required")
+	})
+}
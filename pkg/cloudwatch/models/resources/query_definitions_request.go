@@ -0,0 +1,19 @@
+package resources
+
+import "net/url"
+
+type QueryDefinitionsRequest struct {
+	ResourceRequest
+	// NamePrefix only lists the query definitions whose name starts with it. Folders are part of the name, e.g.
+	// `lambda/errors`.
+	NamePrefix *string
+}
+
+func ParseQueryDefinitionsRequest(parameters url.Values) QueryDefinitionsRequest {
+	return QueryDefinitionsRequest{
+		ResourceRequest: ResourceRequest{
+			Region: parameters.Get("region"),
+		},
+		NamePrefix: setIfNotEmptyString(parameters.Get("namePrefix")),
+	}
+}
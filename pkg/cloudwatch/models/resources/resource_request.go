@@ -3,6 +3,7 @@ package resources
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 )
 
 const useLinkedAccountsId = "all"
@@ -10,12 +11,24 @@ const useLinkedAccountsId = "all"
 type ResourceRequest struct {
 	Region    string
 	AccountId *string
+	// PageLimit lowers the number of ListMetrics pages fetched for the request, 0 when unset
+	PageLimit int
 }
 
 func (r *ResourceRequest) ShouldTargetAllAccounts() bool {
 	return r.AccountId != nil && *r.AccountId == useLinkedAccountsId
 }
 
+// ListMetricsPageLimit returns the number of ListMetrics pages to fetch for the request, so that interactive requests
+// such as autocompletion can ask for fewer pages than heavyweight ones such as variable queries. The page limit of the
+// request is capped by the page limit of the data source.
+func (r *ResourceRequest) ListMetricsPageLimit(maxPageLimit int) int {
+	if r.PageLimit > 0 && r.PageLimit < maxPageLimit {
+		return r.PageLimit
+	}
+	return maxPageLimit
+}
+
 func getResourceRequest(parameters url.Values) (*ResourceRequest, error) {
 	request := &ResourceRequest{
 		Region: parameters.Get("region"),
@@ -30,6 +43,14 @@ func getResourceRequest(parameters url.Values) (*ResourceRequest, error) {
 		return nil, fmt.Errorf("region is required")
 	}
 
+	if pageLimit := parameters.Get("pageLimit"); pageLimit != "" {
+		limit, err := strconv.Atoi(pageLimit)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("pageLimit must be a positive integer, got %q", pageLimit)
+		}
+		request.PageLimit = limit
+	}
+
 	return request, nil
 }
 
@@ -13,4 +13,28 @@ func TestResourceRequest(t *testing.T) {
 		require.Empty(t, request)
 		assert.Equal(t, "region is required", err.Error())
 	})
+
+	t.Run("Should parse the page limit", func(t *testing.T) {
+		request, err := getResourceRequest(map[string][]string{"region": {"us-east-1"}, "pageLimit": {"5"}})
+		require.NoError(t, err)
+		assert.Equal(t, 5, request.PageLimit)
+
+		request, err = getResourceRequest(map[string][]string{"region": {"us-east-1"}})
+		require.NoError(t, err)
+		assert.Equal(t, 0, request.PageLimit)
+	})
+
+	t.Run("Should return an error if the page limit is not a positive integer", func(t *testing.T) {
+		_, err := getResourceRequest(map[string][]string{"region": {"us-east-1"}, "pageLimit": {"0"}})
+		assert.EqualError(t, err, `pageLimit must be a positive integer, got "0"`)
+
+		_, err = getResourceRequest(map[string][]string{"region": {"us-east-1"}, "pageLimit": {"all"}})
+		assert.EqualError(t, err, `pageLimit must be a positive integer, got "all"`)
+	})
+
+	t.Run("Should cap the page limit by the page limit of the data source", func(t *testing.T) {
+		assert.Equal(t, 500, (&ResourceRequest{}).ListMetricsPageLimit(500))
+		assert.Equal(t, 2, (&ResourceRequest{PageLimit: 2}).ListMetricsPageLimit(500))
+		assert.Equal(t, 500, (&ResourceRequest{PageLimit: 1000}).ListMetricsPageLimit(500))
+	})
 }
@@ -0,0 +1,25 @@
+package resources
+
+import (
+	"net/url"
+)
+
+type ServiceLevelObjectivesRequest struct {
+	*ResourceRequest
+	// Service and Environment narrow the SLOs down to the ones of a service, when Service is set
+	Service     string
+	Environment string
+}
+
+func ParseServiceLevelObjectivesRequest(parameters url.Values) (ServiceLevelObjectivesRequest, error) {
+	resourceRequest, err := getResourceRequest(parameters)
+	if err != nil {
+		return ServiceLevelObjectivesRequest{}, err
+	}
+
+	return ServiceLevelObjectivesRequest{
+		ResourceRequest: resourceRequest,
+		Service:         parameters.Get("service"),
+		Environment:     parameters.Get("environment"),
+	}, nil
+}
@@ -1,6 +1,8 @@
 package resources
 
 import (
+	"time"
+
 	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 )
 
@@ -12,6 +14,9 @@ type Dimension struct {
 type ResourceResponse[T any] struct {
 	AccountId *string `json:"accountId,omitempty"`
 	Value     T       `json:"value"`
+	Label     string  `json:"label,omitempty"`
+	// Stale is set on resources served from the cache of their route because AWS throttled the request.
+	Stale bool `json:"stale,omitempty"`
 }
 
 type MetricResponse struct {
@@ -27,7 +32,64 @@ type Account struct {
 }
 
 type Region struct {
-	Name string `json:"name"`
+	Name        string `json:"name"`
+	OptInStatus string `json:"optInStatus,omitempty"`
+}
+
+// AnomalyDetector is an anomaly detection model of a single metric. State is the state of its training, one of
+// PENDING_TRAINING, TRAINED_INSUFFICIENT_DATA and TRAINED.
+type AnomalyDetector struct {
+	Namespace  string            `json:"namespace"`
+	MetricName string            `json:"metricName"`
+	Dimensions map[string]string `json:"dimensions"`
+	Stat       string            `json:"stat"`
+	State      string            `json:"state"`
+}
+
+// InsightRule is a Contributor Insights rule the top contributors of can be queried
+type InsightRule struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	// ManagedRule tells whether the rule is managed by an AWS service, rather than created in the account
+	ManagedRule bool `json:"managedRule"`
+}
+
+// ApplicationSignalsService is a service Application Signals discovered. KeyAttributes identify it, e.g. its Type, Name
+// and Environment.
+type ApplicationSignalsService struct {
+	Name          string            `json:"name"`
+	Environment   string            `json:"environment"`
+	KeyAttributes map[string]string `json:"keyAttributes"`
+}
+
+// ServiceLevelObjective is an Application Signals SLO, whose attainment and error budget metrics are published to the
+// AWS/ApplicationSignals namespace by its name. KeyAttributes identify the service it's about, if any.
+type ServiceLevelObjective struct {
+	Name           string            `json:"name"`
+	Arn            string            `json:"arn"`
+	KeyAttributes  map[string]string `json:"keyAttributes,omitempty"`
+	OperationName  string            `json:"operationName,omitempty"`
+	EvaluationType string            `json:"evaluationType,omitempty"`
+}
+
+// MetricMetadata tells when the series of a metric last had datapoints and which of them are active, to help find out
+// why a query of the metric returns no data. Datapoints are looked up in the last 14 days, the time ListMetrics lists
+// metrics for, at an hourly resolution. Truncated is set when the metric has more series than are looked up.
+type MetricMetadata struct {
+	Namespace  string         `json:"namespace"`
+	MetricName string         `json:"metricName"`
+	LastSeen   *time.Time     `json:"lastSeen,omitempty"`
+	Series     []MetricSeries `json:"series"`
+	Truncated  bool           `json:"truncated,omitempty"`
+}
+
+// MetricSeries is a dimension combination of a metric. Active series have had datapoints in the last 3 hours.
+type MetricSeries struct {
+	AccountId  *string           `json:"accountId,omitempty"`
+	Dimensions map[string]string `json:"dimensions"`
+	Active     bool              `json:"active"`
+	FirstSeen  *time.Time        `json:"firstSeen,omitempty"`
+	LastSeen   *time.Time        `json:"lastSeen,omitempty"`
 }
 
 type Metric struct {
@@ -40,7 +102,60 @@ type LogGroup struct {
 	Name string `json:"name"`
 }
 
+// QueryDefinition is a Logs Insights query saved in the account, which the query editor offers as a template.
+type QueryDefinition struct {
+	Id            string   `json:"id"`
+	Name          string   `json:"name"`
+	QueryString   string   `json:"queryString"`
+	QueryLanguage string   `json:"queryLanguage,omitempty"`
+	LogGroupNames []string `json:"logGroupNames"`
+}
+
+// QuerySnippet is a reusable logs query of the snippet catalog of the logs query editor
+type QuerySnippet struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Service       string `json:"service"`
+	QueryLanguage string `json:"queryLanguage"`
+	QueryString   string `json:"queryString"`
+	// Placeholders are the names of the placeholders of the query string, in the order they first appear in
+	Placeholders []string `json:"placeholders"`
+}
+
+// RunningLogsQuery is a Logs Insights query the data source started that hasn't finished yet
+type RunningLogsQuery struct {
+	QueryId   string    `json:"queryId"`
+	Region    string    `json:"region"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
 type LogGroupField struct {
 	Percent int64  `json:"percent"`
 	Name    string `json:"name"`
 }
+
+// LogsQueryPreset is a ready-made Logs Insights query of the log groups a feature of AWS writes to
+type LogsQueryPreset struct {
+	Id            string   `json:"id"`
+	Description   string   `json:"description"`
+	Expression    string   `json:"expression"`
+	LogGroupNames []string `json:"logGroupNames"`
+	StatsGroups   []string `json:"statsGroups"`
+}
+
+// DiscoveredAccount is an account the credentials of the data source can query, with the regions it recently
+// published metrics in
+type DiscoveredAccount struct {
+	Id                  string             `json:"id"`
+	Label               string             `json:"label"`
+	IsMonitoringAccount bool               `json:"isMonitoringAccount"`
+	Regions             []DiscoveredRegion `json:"regions"`
+}
+
+// DiscoveredRegion is a region of a DiscoveredAccount, with the namespaces of the metrics the account recently
+// published in it
+type DiscoveredRegion struct {
+	Name       string   `json:"name"`
+	Namespaces []string `json:"namespaces"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// retentionBoundaries are the ages at which CloudWatch rolls datapoints up to a coarser resolution, oldest first.
+// See https://aws.amazon.com/about-aws/whats-new/2016/11/cloudwatch-extends-metrics-retention-and-new-user-interface/
+var retentionBoundaries = []time.Duration{
+	455 * 24 * time.Hour,
+	63 * 24 * time.Hour,
+	15 * 24 * time.Hour,
+}
+
+type TimeSegment struct {
+	StartTime time.Time
+	EndTime   time.Time
+	// Age is how old StartTime was when the time range was split
+	Age time.Duration
+}
+
+// RetentionSegments splits the time range at the ages where CloudWatch rolls datapoints up to a coarser
+// resolution, so that each segment can be queried at the finest period still retained for it.
+func RetentionSegments(startTime, endTime, now time.Time) []TimeSegment {
+	segments := []TimeSegment{}
+	segmentStart := startTime
+	for _, age := range retentionBoundaries {
+		boundary := now.Add(-age)
+		if boundary.After(segmentStart) && boundary.Before(endTime) {
+			segments = append(segments, TimeSegment{StartTime: segmentStart, EndTime: boundary, Age: now.Sub(segmentStart)})
+			segmentStart = boundary
+		}
+	}
+	return append(segments, TimeSegment{StartTime: segmentStart, EndTime: endTime, Age: now.Sub(segmentStart)})
+}
+
+// ForTimeSegment returns a copy of the query for part of its time range, with the period picked for that part.
+func (q *CloudWatchQuery) ForTimeSegment(segment TimeSegment) *CloudWatchQuery {
+	segmentQuery := *q
+	segmentQuery.StartTime = segment.StartTime
+	segmentQuery.EndTime = segment.EndTime
+	segmentQuery.Period = retainedPeriodForAge(q.RequestedPeriod, q.PanelInterval, segment.Age, segment.EndTime.Sub(segment.StartTime))
+	return &segmentQuery
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionSegments(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	age := func(days int) time.Duration { return time.Duration(days) * 24 * time.Hour }
+
+	t.Run("does not split ranges within one retention period", func(t *testing.T) {
+		assert.Equal(t, []TimeSegment{{StartTime: daysAgo(7), EndTime: now, Age: age(7)}}, RetentionSegments(daysAgo(7), now, now))
+		assert.Equal(t, []TimeSegment{{StartTime: daysAgo(60), EndTime: daysAgo(20), Age: age(60)}}, RetentionSegments(daysAgo(60), daysAgo(20), now))
+	})
+
+	t.Run("splits ranges at each retention boundary they cross", func(t *testing.T) {
+		assert.Equal(t, []TimeSegment{
+			{StartTime: daysAgo(500), EndTime: daysAgo(455), Age: age(500)},
+			{StartTime: daysAgo(455), EndTime: daysAgo(63), Age: age(455)},
+			{StartTime: daysAgo(63), EndTime: daysAgo(15), Age: age(63)},
+			{StartTime: daysAgo(15), EndTime: now, Age: age(15)},
+		}, RetentionSegments(daysAgo(500), now, now))
+
+		assert.Equal(t, []TimeSegment{
+			{StartTime: daysAgo(90), EndTime: daysAgo(63), Age: age(90)},
+			{StartTime: daysAgo(63), EndTime: daysAgo(30), Age: age(63)},
+		}, RetentionSegments(daysAgo(90), daysAgo(30), now))
+	})
+}
+
+func TestCloudWatchQuery_ForTimeSegment(t *testing.T) {
+	now := time.Now()
+	query := &CloudWatchQuery{
+		StartTime:       now.AddDate(0, 0, -90),
+		EndTime:         now,
+		Period:          3600,
+		RequestedPeriod: 60,
+		Dimensions:      map[string][]string{"InstanceId": {"i-1"}},
+	}
+
+	recent := query.ForTimeSegment(TimeSegment{StartTime: now.AddDate(0, 0, -15), EndTime: now, Age: 15 * 24 * time.Hour})
+	assert.Equal(t, 60, recent.Period)
+	assert.Equal(t, now.AddDate(0, 0, -15), recent.StartTime)
+	assert.Equal(t, now, recent.EndTime)
+
+	old := query.ForTimeSegment(TimeSegment{StartTime: now.AddDate(0, 0, -90), EndTime: now.AddDate(0, 0, -63), Age: 90 * 24 * time.Hour})
+	assert.Equal(t, 3600, old.Period)
+
+	assert.Equal(t, 3600, query.Period, "the original query is not modified")
+}
+
+func Test_retainedPeriod(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, 10, retainedPeriod(10, 0, now.Add(-time.Hour), now), "high resolution periods are kept for recent data")
+	assert.Equal(t, 60, retainedPeriod(60, 0, now.AddDate(0, 0, -10), now))
+	assert.Equal(t, 300, retainedPeriod(60, 0, now.AddDate(0, 0, -20), now), "1 minute data is rolled up after 15 days")
+	assert.Equal(t, 3600, retainedPeriod(300, 0, now.AddDate(0, 0, -90), now), "5 minute data is rolled up after 63 days")
+	assert.Equal(t, 86400, retainedPeriod(86400, 0, now.AddDate(0, 0, -90), now), "coarser periods are kept")
+	assert.Equal(t, 3600, retainedPeriod(0, 0, now.AddDate(0, 0, -30), now), "auto periods are calculated")
+}
+
+func Test_retainedPeriod_panel_interval(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, 60, retainedPeriod(0, 30*time.Second, now.Add(-time.Hour), now), "intervals finer than the auto period are ignored")
+	assert.Equal(t, 300, retainedPeriod(0, 2*time.Minute, now.Add(-time.Hour), now), "auto periods are raised to the period covering the interval")
+	assert.Equal(t, 3600, retainedPeriod(0, time.Hour, now.Add(-time.Hour), now))
+	assert.Equal(t, 86400, retainedPeriod(0, 7*24*time.Hour, now.Add(-time.Hour), now), "intervals coarser than every period use the coarsest")
+	assert.Equal(t, 60, retainedPeriod(60, time.Hour, now.Add(-time.Hour), now), "requested periods are kept")
+}
@@ -10,6 +10,17 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
+// defaultMaxSeriesPerQuery is the series cap of metric queries of data sources that don't configure one.
+const defaultMaxSeriesPerQuery = 1000
+
+// defaultMetricDataMaxPages is the number of pages of a GetMetricData request followed for data sources that don't
+// configure one.
+const defaultMetricDataMaxPages = 100
+
+// defaultMetricDataConcurrency is the number of GetMetricData requests a metric query request of data sources that
+// don't configure one runs at the same time.
+const defaultMetricDataConcurrency = 10
+
 type Duration struct {
 	time.Duration
 }
@@ -18,11 +29,176 @@ type CloudWatchSettings struct {
 	Namespace               string   `json:"customMetricsNamespaces"`
 	SecureSocksProxyEnabled bool     `json:"enableSecureSocksProxy"` // this can be removed when https://github.com/grafana/grafana/issues/39089 is implemented
 	LogsTimeout             Duration `json:"logsTimeout"`
+	ReadOnly                bool     `json:"readOnly"` // restricts the data source to read-only resource routes and AWS APIs
+
+	// UserIdentityPassThrough exchanges the requesting user's forwarded OAuth identity for credentials of the
+	// role WebIdentityRoleMap maps them to, keyed by "login:<login>", "email:<email>", "role:<org role>" or "*"
+	UserIdentityPassThrough bool              `json:"userIdentityPassThrough"`
+	WebIdentityRoleMap      map[string]string `json:"webIdentityRoleMap"`
+
+	// OrgRoleMap maps Grafana org IDs to the role assumed for requests from that org. Team membership
+	// isn't exposed to plugins, so roles can't be mapped per team.
+	OrgRoleMap map[string]string `json:"orgRoleMap"`
+
+	// QueryRoleARNs are the roles queries may assume instead of the data source's role with their assumeRoleArn, so
+	// that one data source can query several accounts without cross-account observability. Empty disables it.
+	QueryRoleARNs []string `json:"queryRoleArns"`
+
+	// IdentityCenterStartURL is the AWS access portal URL of an IAM Identity Center organization, e.g.
+	// https://my-org.awsapps.com/start. With IdentityCenterRoleName, console links go through the portal, which signs
+	// users in to the account of the link with the permission set of that name before redirecting them to the console.
+	// IdentityCenterAccountId is the account of links to resources of the data source's own account.
+	IdentityCenterStartURL  string `json:"identityCenterStartUrl"`
+	IdentityCenterRoleName  string `json:"identityCenterRoleName"`
+	IdentityCenterAccountId string `json:"identityCenterAccountId"`
+
+	// DashboardAPIBudget limits the AWS API calls a single dashboard can make per minute, 0 means unlimited.
+	// DashboardAPIBudgets overrides it for individual dashboard UIDs.
+	DashboardAPIBudget  int            `json:"dashboardApiBudget"`
+	DashboardAPIBudgets map[string]int `json:"dashboardApiBudgets"`
+
+	// RequestAPICallBudget limits the AWS API calls a single query or resource request can make, 0 means unlimited.
+	// Calls past the budget fail the request rather than being made.
+	RequestAPICallBudget int `json:"requestApiCallBudget"`
+
+	// SplitRangesByRetention queries metric time ranges that span CloudWatch's retention boundaries in parts,
+	// each at the finest period retained for it, instead of at the period retained for the start of the range
+	SplitRangesByRetention bool `json:"splitRangesByRetention"`
+
+	// QueryCacheTTL is how long metric query results are cached for, 0 disables the cache
+	QueryCacheTTL Duration `json:"queryCacheTTL"`
+
+	// MetricDataCacheTTL is how long GetMetricData responses are reused by identical requests, so that panels
+	// sharing queries make a single request per refresh, 0 disables the cache
+	MetricDataCacheTTL Duration `json:"metricDataCacheTTL"`
+
+	// ListMetricsCacheTTL is how long the metrics listed for the query editor are reused, by region, namespace and
+	// account, 0 disables the cache. The cache can be invalidated with the /cache/invalidate resource route.
+	ListMetricsCacheTTL Duration `json:"listMetricsCacheTTL"`
+
+	// LogsQueryReuseTTL is how long a started Logs Insights query is reused by identical queries instead of starting
+	// a new one, 0 disables reuse
+	LogsQueryReuseTTL Duration `json:"logsQueryReuseTTL"`
+
+	// SlowQueryThreshold is how long a query may take before its request, responses and AWS API calls are logged with
+	// a correlation ID shown to the user, so that reports of intermittent slowness can be traced. 0 disables it.
+	SlowQueryThreshold Duration `json:"slowQueryThreshold"`
+
+	// DeltaFetch only fetches the datapoints newer than the last result of a metric query when a panel refreshes
+	DeltaFetch bool `json:"deltaFetch"`
+
+	// RecordedQueries are Logs Insights queries executed in the background with the data source's own credentials.
+	// Queries referencing one by name are served its last result instead of scanning the log groups again.
+	RecordedQueries []RecordedQuery `json:"recordedQueries"`
+
+	// MaskingRules redact values of logs results before they're returned, so that sensitive data such as emails or
+	// tokens never reaches the browser, whatever fields a query projects
+	MaskingRules []MaskingRule `json:"maskingRules"`
+
+	// LabelRules rename and rewrite the labels of metric series, so that the naming conventions of an organization
+	// don't have to be implemented by every dashboard
+	LabelRules []LabelRule `json:"labelRules"`
+
+	// DimensionTagLabels label metric series with a tag of the resource a dimension of theirs identifies, so that
+	// legends can show friendly names instead of the IDs of load balancers or databases
+	DimensionTagLabels []DimensionTagLabel `json:"dimensionTagLabels"`
+
+	// QuerySnippets are logs query snippets of the organization, offered by the logs query editor along with the
+	// built-in ones
+	QuerySnippets []QuerySnippet `json:"querySnippets"`
+
+	// ScopeDimensions are dimension filters added to every metric query, replacing the values the query has for them,
+	// and ScopeAccountId the account every metric query is restricted to, so that a data source shared by several
+	// teams can be scoped to a slice of the metrics of an account
+	ScopeDimensions map[string][]string `json:"scopeDimensions"`
+	ScopeAccountId  string              `json:"scopeAccountId"`
+
+	// Variables are the values of the template variables the period and statistic of metric queries may reference,
+	// for queries that aren't interpolated by the frontend such as alert rules
+	Variables map[string]string `json:"variables"`
+
+	// MaxSeriesPerQuery caps the series a metric query returns, so that a search matching far more series than a panel
+	// can show doesn't send them all to the browser. 0 uses defaultMaxSeriesPerQuery and a negative value disables it.
+	MaxSeriesPerQuery int `json:"maxSeriesPerQuery"`
+
+	// MetricDataConcurrency is the number of GetMetricData requests run at the same time for the batches of a metric
+	// query request, so that dashboards with hundreds of queries neither wait on each batch in turn nor hit the
+	// throttling limits of the API. 0 or less uses defaultMetricDataConcurrency.
+	MetricDataConcurrency int `json:"metricDataConcurrency"`
+
+	// MetricDataMaxPages caps the pages of a GetMetricData request followed through NextToken, so that a query matching
+	// far more data than a panel can show doesn't page through it all. Series cut short by the cap are flagged with a
+	// notice. 0 uses defaultMetricDataMaxPages and a negative value disables it.
+	MetricDataMaxPages int `json:"metricDataMaxPages"`
+
+	// MaxLogsQueryLimit caps the log events a Logs Insights query returns, whatever limit the query asks for. 0 leaves
+	// queries at the maximum of Logs Insights.
+	MaxLogsQueryLimit int `json:"maxLogsQueryLimit"`
 
 	// GrafanaSettings are fetched from the GrafanaCfg in the context
 	GrafanaSettings awsds.AuthSettings `json:"-"`
 }
 
+// RecordedQuery is a Logs Insights query executed on a schedule, whose result is served from memory.
+type RecordedQuery struct {
+	Name          string   `json:"name"`
+	Region        string   `json:"region"`
+	LogGroupNames []string `json:"logGroupNames"`
+	Expression    string   `json:"expression"`
+	// StatsGroups are the fields the results are grouped by into separate frames
+	StatsGroups []string `json:"statsGroups"`
+	// Interval is how often the query is executed
+	Interval Duration `json:"interval"`
+	// TimeRange is how far back from each execution the query covers
+	TimeRange Duration `json:"timeRange"`
+}
+
+// MaskingRule replaces matches of a regular expression in the values of the logs result fields it applies to.
+type MaskingRule struct {
+	// Fields is a regular expression matched against field names, an empty one applies the rule to every field
+	Fields string `json:"fields"`
+	// Pattern is the regular expression replaced in the values, an empty one replaces whole values
+	Pattern string `json:"pattern"`
+	// Replacement replaces the matches and can refer to submatches, e.g. $1. It defaults to ****
+	Replacement string `json:"replacement"`
+}
+
+// LabelRule renames the labels of metric series matching a regular expression and rewrites their values, e.g. to
+// map the FunctionName dimension to a `function` label or strip the prefix of ARNs.
+type LabelRule struct {
+	// Labels is a regular expression matched against label names, an empty one applies the rule to every label
+	Labels string `json:"labels"`
+	// Rename replaces the matches of Labels in the names of the labels and can refer to submatches, e.g. $1. An empty
+	// one keeps the names
+	Rename string `json:"rename"`
+	// Pattern is the regular expression replaced in the values, an empty one keeps the values
+	Pattern string `json:"pattern"`
+	// Replacement replaces the matches of Pattern and can refer to submatches, e.g. $1
+	Replacement string `json:"replacement"`
+}
+
+// DimensionTagLabel labels the series of a namespace with a tag of the resource their dimension identifies, e.g. the
+// Name tag of the database of the DBInstanceIdentifier dimension of AWS/RDS.
+type DimensionTagLabel struct {
+	Namespace string `json:"namespace"`
+	Dimension string `json:"dimension"`
+	Tag       string `json:"tag"`
+	// Label is the name of the label, the dimension followed by the tag if empty, e.g. DBInstanceIdentifierName
+	Label string `json:"label"`
+}
+
+// QuerySnippet is a reusable logs query. Its query string may contain placeholders such as {{logGroup}}, which the
+// user replaces after inserting it.
+type QuerySnippet struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Service is the AWS service or team the snippet is listed under, Custom if empty
+	Service string `json:"service"`
+	// QueryLanguage is CWLI, SQL or PPL, CWLI if empty
+	QueryLanguage string `json:"queryLanguage"`
+	QueryString   string `json:"queryString"`
+}
+
 func LoadCloudWatchSettings(ctx context.Context, config backend.DataSourceInstanceSettings) (CloudWatchSettings, error) {
 	instance := CloudWatchSettings{}
 
@@ -43,6 +219,18 @@ func LoadCloudWatchSettings(ctx context.Context, config backend.DataSourceInstan
 		instance.LogsTimeout = Duration{30 * time.Minute}
 	}
 
+	if instance.MaxSeriesPerQuery == 0 {
+		instance.MaxSeriesPerQuery = defaultMaxSeriesPerQuery
+	}
+
+	if instance.MetricDataConcurrency <= 0 {
+		instance.MetricDataConcurrency = defaultMetricDataConcurrency
+	}
+
+	if instance.MetricDataMaxPages == 0 {
+		instance.MetricDataMaxPages = defaultMetricDataMaxPages
+	}
+
 	authSettings, _ := awsds.ReadAuthSettingsFromContext(ctx)
 	instance.GrafanaSettings = *authSettings
 
@@ -116,6 +116,39 @@ func Test_Settings_LoadCloudWatchSettings(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, time.Minute*30, s.LogsTimeout.Duration)
 	})
+	t.Run("Should set maxSeriesPerQuery to the default if it is not defined, and keep it otherwise", func(t *testing.T) {
+		for jsonData, expected := range map[string]int{
+			`{}`:                        defaultMaxSeriesPerQuery,
+			`{"maxSeriesPerQuery": 50}`: 50,
+			`{"maxSeriesPerQuery": -1}`: -1,
+		} {
+			s, err := LoadCloudWatchSettings(settingCtx, backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)})
+			require.NoError(t, err)
+			assert.Equal(t, expected, s.MaxSeriesPerQuery, jsonData)
+		}
+	})
+	t.Run("Should set metricDataConcurrency to the default if it is not positive, and keep it otherwise", func(t *testing.T) {
+		for jsonData, expected := range map[string]int{
+			`{}`:                            defaultMetricDataConcurrency,
+			`{"metricDataConcurrency": 4}`:  4,
+			`{"metricDataConcurrency": -1}`: defaultMetricDataConcurrency,
+		} {
+			s, err := LoadCloudWatchSettings(settingCtx, backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)})
+			require.NoError(t, err)
+			assert.Equal(t, expected, s.MetricDataConcurrency, jsonData)
+		}
+	})
+	t.Run("Should set metricDataMaxPages to the default if it is not defined, and keep it otherwise", func(t *testing.T) {
+		for jsonData, expected := range map[string]int{
+			`{}`:                         defaultMetricDataMaxPages,
+			`{"metricDataMaxPages": 5}`:  5,
+			`{"metricDataMaxPages": -1}`: -1,
+		} {
+			s, err := LoadCloudWatchSettings(settingCtx, backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)})
+			require.NoError(t, err)
+			assert.Equal(t, expected, s.MetricDataMaxPages, jsonData)
+		}
+	})
 	t.Run("Should set logsTimeout to default duration if it is empty string", func(t *testing.T) {
 		settings := backend.DataSourceInstanceSettings{
 			ID: 33,
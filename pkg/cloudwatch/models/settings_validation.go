@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// SettingsError is an invalid field of the settings of a data source.
+type SettingsError struct {
+	// Field is the path of the field in the data source, e.g. jsonData.authType
+	Field   string
+	Message string
+}
+
+func (e SettingsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// settingsFields are the fields of the json data that are validated, as they were saved, so that missing fields can
+// be told apart from fields with their zero value.
+type settingsFields struct {
+	AuthType      *string `json:"authType"`
+	AssumeRoleARN string  `json:"assumeRoleArn"`
+	Endpoint      string  `json:"endpoint"`
+	Region        string  `json:"region"`
+	DefaultRegion string  `json:"defaultRegion"`
+}
+
+// ValidateSettings returns the invalid fields of the settings of a data source, which would otherwise only fail once
+// queried:
+//   - assuming a role needs an auth type to get the credentials assuming it
+//   - a custom endpoint must be an http or https URL
+//   - a default region is needed to run queries using the "default" region
+func ValidateSettings(config backend.DataSourceInstanceSettings) []SettingsError {
+	var fields settingsFields
+	if len(config.JSONData) > 1 {
+		if err := json.Unmarshal(config.JSONData, &fields); err != nil {
+			return []SettingsError{{Field: "jsonData", Message: fmt.Sprintf("invalid json: %s", err)}}
+		}
+	}
+
+	var errs []SettingsError
+	if fields.AuthType == nil || strings.TrimSpace(*fields.AuthType) == "" {
+		if fields.AssumeRoleARN != "" {
+			errs = append(errs, SettingsError{Field: "jsonData.authType", Message: "an auth type is required to assume a role"})
+		}
+	} else if _, err := awsds.ToAuthType(*fields.AuthType); err != nil {
+		errs = append(errs, SettingsError{Field: "jsonData.authType", Message: err.Error()})
+	}
+
+	if fields.Endpoint != "" {
+		endpoint, err := url.Parse(fields.Endpoint)
+		if err != nil {
+			errs = append(errs, SettingsError{Field: "jsonData.endpoint", Message: fmt.Sprintf("invalid URL: %s", err)})
+		} else if endpoint.Scheme != "https" && endpoint.Scheme != "http" {
+			errs = append(errs, SettingsError{Field: "jsonData.endpoint", Message: fmt.Sprintf("the endpoint must be an https or http URL, e.g. https://monitoring.us-east-1.amazonaws.com, got %q", fields.Endpoint)})
+		} else if endpoint.Host == "" {
+			errs = append(errs, SettingsError{Field: "jsonData.endpoint", Message: fmt.Sprintf("the endpoint has no host: %q", fields.Endpoint)})
+		}
+	}
+
+	region := fields.Region
+	if region == "" || region == defaultRegion {
+		region = fields.DefaultRegion
+	}
+	if region == "" || region == defaultRegion {
+		errs = append(errs, SettingsError{Field: "jsonData.defaultRegion", Message: `a default region is required to run queries using the "default" region`})
+	}
+
+	return errs
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		expected []SettingsError
+	}{
+		{
+			name:     "valid settings",
+			jsonData: `{"authType":"keys","assumeRoleArn":"arn:aws:iam::123456789012:role/grafana","endpoint":"https://monitoring.example.com","defaultRegion":"us-east-1"}`,
+		},
+		{
+			name:     "region instead of the deprecated default region",
+			jsonData: `{"authType":"default","region":"eu-west-1"}`,
+		},
+		{
+			name:     "assume role without auth type",
+			jsonData: `{"assumeRoleArn":"arn:aws:iam::123456789012:role/grafana","defaultRegion":"us-east-1"}`,
+			expected: []SettingsError{{Field: "jsonData.authType", Message: "an auth type is required to assume a role"}},
+		},
+		{
+			name:     "unknown auth type",
+			jsonData: `{"authType":"password","defaultRegion":"us-east-1"}`,
+			expected: []SettingsError{{Field: "jsonData.authType", Message: "invalid auth type: password"}},
+		},
+		{
+			name:     "endpoint without scheme",
+			jsonData: `{"authType":"default","endpoint":"monitoring.us-east-1.amazonaws.com","defaultRegion":"us-east-1"}`,
+			expected: []SettingsError{{Field: "jsonData.endpoint", Message: `the endpoint must be an https or http URL, e.g. https://monitoring.us-east-1.amazonaws.com, got "monitoring.us-east-1.amazonaws.com"`}},
+		},
+		{
+			name:     "endpoint without host",
+			jsonData: `{"authType":"default","endpoint":"https://","defaultRegion":"us-east-1"}`,
+			expected: []SettingsError{{Field: "jsonData.endpoint", Message: `the endpoint has no host: "https://"`}},
+		},
+		{
+			name:     "missing default region",
+			jsonData: `{"authType":"default","region":"default"}`,
+			expected: []SettingsError{{Field: "jsonData.defaultRegion", Message: `a default region is required to run queries using the "default" region`}},
+		},
+		{
+			name:     "every invalid field is reported",
+			jsonData: `{"assumeRoleArn":"arn:aws:iam::123456789012:role/grafana","endpoint":"ftp://monitoring.example.com"}`,
+			expected: []SettingsError{
+				{Field: "jsonData.authType", Message: "an auth type is required to assume a role"},
+				{Field: "jsonData.endpoint", Message: `the endpoint must be an https or http URL, e.g. https://monitoring.us-east-1.amazonaws.com, got "ftp://monitoring.example.com"`},
+				{Field: "jsonData.defaultRegion", Message: `a default region is required to run queries using the "default" region`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ValidateSettings(backend.DataSourceInstanceSettings{JSONData: []byte(tt.jsonData)}))
+		})
+	}
+}
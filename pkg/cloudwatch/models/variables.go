@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// variableReference matches a field that is a single template variable, in any of the syntaxes Grafana supports:
+// $name, ${name} and [[name]], with an optional format that is ignored as the values are single values.
+var variableReference = regexp.MustCompile(`^\s*(?:\$(\w+)|\$\{(\w+)(?::\w+)?\}|\[\[(\w+)(?::\w+)?\]\])\s*$`)
+
+// validStatistic matches the statistics GetMetricData accepts. See
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/Statistics-definitions.html
+var validStatistic = regexp.MustCompile(`^(Average|Sum|Minimum|Maximum|SampleCount|IQM|(p|tm|wm|tc|ts)\d{1,2}(\.\d+)?|(TM|WM|TC|TS|PR)\(\d*(\.\d+)?%?:\d*(\.\d+)?%?\))$`)
+
+// interpolatePeriodAndStatistic replaces the period and statistic of the query with the values of the template
+// variables they reference, which the frontend has already done for the queries of dashboards. The values are
+// validated as they're taken from the data source settings rather than picked in the editor.
+func interpolatePeriodAndStatistic(query *metricsDataQuery, variables map[string]string) error {
+	if query.Period != nil {
+		period, interpolated, err := interpolateVariable(*query.Period, variables)
+		if err != nil {
+			return err
+		}
+		if interpolated && !isValidPeriod(period) {
+			return backend.DownstreamError(fmt.Errorf("invalid period %q of variable %s", period, strings.TrimSpace(*query.Period)))
+		}
+		query.Period = &period
+	}
+	if query.Statistic != nil {
+		statistic, interpolated, err := interpolateVariable(*query.Statistic, variables)
+		if err != nil {
+			return err
+		}
+		if interpolated && !validStatistic.MatchString(statistic) {
+			return backend.DownstreamError(fmt.Errorf("invalid statistic %q of variable %s", statistic, strings.TrimSpace(*query.Statistic)))
+		}
+		query.Statistic = &statistic
+	}
+	return nil
+}
+
+// interpolateVariable returns the value of the variable the field references, or the field itself if it isn't a
+// variable reference.
+func interpolateVariable(field string, variables map[string]string) (string, bool, error) {
+	match := variableReference.FindStringSubmatch(field)
+	if match == nil {
+		return field, false, nil
+	}
+	name := match[1] + match[2] + match[3]
+	value, ok := variables[name]
+	if !ok {
+		return "", false, backend.DownstreamError(fmt.Errorf("template variable %s isn't defined in the data source settings", strings.TrimSpace(field)))
+	}
+	return strings.TrimSpace(value), true, nil
+}
+
+// isValidPeriod returns whether the period is auto, a positive number of seconds or a positive duration.
+func isValidPeriod(period string) bool {
+	if period == "" || strings.ToLower(period) == "auto" {
+		return true
+	}
+	if seconds, err := strconv.Atoi(period); err == nil {
+		return seconds > 0
+	}
+	duration, err := time.ParseDuration(period)
+	return err == nil && duration >= time.Second
+}
+
+// globalVariable matches a reference to a global variable of Grafana, e.g. $__from or ${__from:date:iso}.
+var globalVariable = regexp.MustCompile(`\$\{(__\w+)(?::([\w:]+))?\}|\$(__\w+)`)
+
+// InterpolateGlobalVariables replaces the global variables of Grafana that depend only on the time range and interval
+// of a query, such as $__from, $__range or $__interval_ms, with their values. The frontend replaces them in the
+// queries of dashboards, but alert rules and public dashboards send them as they are. References to other variables,
+// formats that aren't supported and interval variables when the interval is unknown are left as they are.
+func InterpolateGlobalVariables(text string, timeRange backend.TimeRange, interval time.Duration) string {
+	if !strings.Contains(text, "$__") && !strings.Contains(text, "${__") {
+		return text
+	}
+	rangeDuration := timeRange.To.Sub(timeRange.From)
+	return globalVariable.ReplaceAllStringFunc(text, func(reference string) string {
+		match := globalVariable.FindStringSubmatch(reference)
+		name, format := match[1]+match[3], match[2]
+		switch name {
+		case "__from", "__to":
+			t := timeRange.From
+			if name == "__to" {
+				t = timeRange.To
+			}
+			switch format {
+			case "", "date":
+				return strconv.FormatInt(t.UnixMilli(), 10)
+			case "date:seconds":
+				return strconv.FormatInt(t.Unix(), 10)
+			case "date:iso":
+				return t.UTC().Format("2006-01-02T15:04:05.000Z")
+			}
+		case "__range":
+			if format == "" {
+				return strconv.FormatInt(int64(rangeDuration.Seconds()), 10) + "s"
+			}
+		case "__range_s":
+			if format == "" {
+				return strconv.FormatInt(int64(rangeDuration.Seconds()), 10)
+			}
+		case "__range_ms":
+			if format == "" {
+				return strconv.FormatInt(rangeDuration.Milliseconds(), 10)
+			}
+		case "__interval":
+			if format == "" && interval > 0 {
+				return gtime.FormatInterval(interval)
+			}
+		case "__interval_ms":
+			if format == "" && interval > 0 {
+				return strconv.FormatInt(interval.Milliseconds(), 10)
+			}
+		}
+		return reference
+	})
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_interpolateVariable(t *testing.T) {
+	variables := map[string]string{"period": " 300 ", "statistic": "p99"}
+
+	for _, field := range []string{"$period", "${period}", "${period:raw}", "[[period]]", " $period "} {
+		value, interpolated, err := interpolateVariable(field, variables)
+		require.NoError(t, err, field)
+		assert.True(t, interpolated, field)
+		assert.Equal(t, "300", value, field)
+	}
+
+	value, interpolated, err := interpolateVariable("60", variables)
+	require.NoError(t, err)
+	assert.False(t, interpolated)
+	assert.Equal(t, "60", value)
+
+	_, _, err = interpolateVariable("$interval", variables)
+	assert.EqualError(t, err, "template variable $interval isn't defined in the data source settings")
+}
+
+func Test_ParseMetricDataQueries_interpolates_period_and_statistic(t *testing.T) {
+	query := func(period, statistic string) []backend.DataQuery {
+		return []backend.DataQuery{{
+			RefID: "A",
+			JSON: []byte(`{
+				"refId":"A",
+				"region":"us-east-1",
+				"namespace":"AWS/EC2",
+				"metricName":"CPUUtilization",
+				"statistic":"` + statistic + `",
+				"period":"` + period + `"
+			}`),
+		}}
+	}
+	from, to := time.Now().Add(-time.Hour), time.Now()
+
+	t.Run("replaces the variables with their values", func(t *testing.T) {
+		variables := map[string]string{"period": "5m", "statistic": "tm90"}
+		res, err := ParseMetricDataQueries(query("$period", "${statistic}"), from, to, "us-east-2", logger, false, variables)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, 300, res[0].Period)
+		assert.Equal(t, "tm90", res[0].Statistic)
+	})
+
+	t.Run("an auto period variable picks the period", func(t *testing.T) {
+		res, err := ParseMetricDataQueries(query("$period", "Average"), from, to, "us-east-2", logger, false, map[string]string{"period": "auto"})
+		require.NoError(t, err)
+		assert.Equal(t, 0, res[0].RequestedPeriod)
+	})
+
+	t.Run("rejects undefined variables and invalid values", func(t *testing.T) {
+		_, err := ParseMetricDataQueries(query("$period", "Average"), from, to, "us-east-2", logger, false, nil)
+		assert.ErrorContains(t, err, "template variable $period isn't defined in the data source settings")
+
+		_, err = ParseMetricDataQueries(query("$period", "Average"), from, to, "us-east-2", logger, false, map[string]string{"period": "-60"})
+		assert.ErrorContains(t, err, `invalid period "-60" of variable $period`)
+
+		_, err = ParseMetricDataQueries(query("60", "$statistic"), from, to, "us-east-2", logger, false, map[string]string{"statistic": "Median"})
+		assert.ErrorContains(t, err, `invalid statistic "Median" of variable $statistic`)
+	})
+}
+
+func TestInterpolateGlobalVariables(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+	}
+
+	for text, expected := range map[string]string{
+		"$__from":                            "1704067200000",
+		"${__to}":                            "1704088800000",
+		"${__from:date:seconds}":             "1704067200",
+		"${__to:date:iso}":                   "2024-01-01T06:00:00.000Z",
+		"$__range":                           "21600s",
+		"$__range_s":                         "21600",
+		"${__range_ms}":                      "21600000",
+		"$__interval":                        "1m",
+		"$__interval_ms":                     "60000",
+		"stats count(*) by bin($__interval)": "stats count(*) by bin(1m)",
+		"$__period_auto":                     "$__period_auto",
+		"${__from:date:YYYY-MM}":             "${__from:date:YYYY-MM}",
+		"$region":                            "$region",
+	} {
+		assert.Equal(t, expected, InterpolateGlobalVariables(text, timeRange, time.Minute), text)
+	}
+
+	assert.Equal(t, "bin($__interval)", InterpolateGlobalVariables("bin($__interval)", timeRange, 0))
+}
+
+func Test_ParseMetricDataQueries_interpolates_global_variables_of_math_expressions(t *testing.T) {
+	from, to := time.Now().Add(-time.Hour), time.Now()
+	res, err := ParseMetricDataQueries([]backend.DataQuery{{
+		RefID:    "A",
+		Interval: time.Minute,
+		JSON: []byte(`{
+			"refId":"A",
+			"region":"us-east-1",
+			"metricQueryType":0,
+			"metricEditorMode":1,
+			"statistic":"Average",
+			"expression":"RATE(m1) * $__interval_ms / 1000"
+		}`),
+	}}, from, to, "us-east-2", logger, false, nil)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "RATE(m1) * 60000 / 1000", res[0].Expression)
+}
@@ -0,0 +1,233 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+)
+
+const (
+	// allRegions is the region of queries fanning out to every region enabled in the account.
+	allRegions = "all"
+	// regionLabel is the label the series of a multi-region query are given, naming the region they come from.
+	regionLabel = "region"
+)
+
+// isMultiRegion returns whether region is the region of a query fanning out to several regions, i.e. `all` or a
+// comma separated list of regions.
+func isMultiRegion(region string) bool {
+	return region == allRegions || strings.Contains(region, ",")
+}
+
+// hasMultiRegionQueries returns whether any of the time series queries fans out to several regions.
+func hasMultiRegionQueries(queries []backend.DataQuery) bool {
+	return slices.ContainsFunc(queries, func(query backend.DataQuery) bool {
+		return isMultiRegion(dataQueryRegion(query))
+	})
+}
+
+func dataQueryRegion(query backend.DataQuery) string {
+	var model struct {
+		Region string `json:"region"`
+	}
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return ""
+	}
+	return model.Region
+}
+
+// multiRegions returns the regions a multi-region query fans out to: every region enabled in the account for `all`,
+// and the regions of the list otherwise. Lists may be written as a multi-value template variable is interpolated by
+// default, e.g. `{us-east-1,eu-west-1}`.
+func (ds *DataSource) multiRegions(ctx context.Context, region string) ([]string, error) {
+	if region != allRegions {
+		var regions []string
+		for _, r := range strings.Split(strings.Trim(region, "{}"), ",") {
+			r = strings.TrimSpace(r)
+			if r == defaultRegion {
+				r = ds.Settings.Region
+			}
+			if r != "" && !slices.Contains(regions, r) {
+				regions = append(regions, r)
+			}
+		}
+		return regions, nil
+	}
+
+	service, err := ds.GetRegionsService(ctx, defaultRegion)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := service.GetRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	regions := make([]string, 0, len(resp))
+	for _, r := range resp {
+		if r.Value.OptInStatus != services.OptInStatusNotOptedIn {
+			regions = append(regions, r.Value.Name)
+		}
+	}
+	slices.Sort(regions)
+	return regions, nil
+}
+
+// executeMultiRegionTimeSeriesQuery fans the queries of several regions out to a request per region, executed
+// concurrently, so that global services can be shown without duplicating their queries per region. The series each
+// region returns are labelled with it and merged into the response of their query. A region failing, e.g. because it
+// isn't enabled, only adds a notice unless every region failed. Math expressions of a multi-region query can only
+// reference the queries of the same regions.
+func (ds *DataSource) executeMultiRegionTimeSeriesQuery(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	var singleRegionQueries []backend.DataQuery
+	queriesByRegion := map[string][]backend.DataQuery{}
+	regionsByRefId := map[string][]string{}
+	for _, query := range req.Queries {
+		region := dataQueryRegion(query)
+		if !isMultiRegion(region) {
+			singleRegionQueries = append(singleRegionQueries, query)
+			continue
+		}
+		regions, err := ds.multiRegions(ctx, region)
+		if err == nil && len(regions) == 0 {
+			err = backend.DownstreamError(fmt.Errorf("no region to query in %q", region))
+		}
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(fmt.Errorf("failed to get the regions of the query: %w", err))
+			continue
+		}
+		for _, r := range regions {
+			regionQuery, err := withRegion(query, r)
+			if err != nil {
+				return nil, backend.DownstreamError(err)
+			}
+			queriesByRegion[r] = append(queriesByRegion[r], regionQuery)
+		}
+		regionsByRefId[query.RefID] = regions
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	regionResponses := map[string]*backend.QueryDataResponse{}
+	execute := func(region string, queries []backend.DataQuery) {
+		defer wg.Done()
+		regionResp, err := ds.executeTimeSeriesQuery(ctx, &backend.QueryDataRequest{
+			PluginContext: req.PluginContext,
+			Headers:       req.Headers,
+			Queries:       queries,
+		})
+		if err != nil {
+			regionResp = backend.NewQueryDataResponse()
+			for _, query := range queries {
+				regionResp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		regionResponses[region] = regionResp
+	}
+	for region, queries := range queriesByRegion {
+		wg.Add(1)
+		go execute(region, queries)
+	}
+	if len(singleRegionQueries) > 0 {
+		wg.Add(1)
+		// single region queries are executed together, keyed by an empty region no multi-region query can have
+		go execute("", singleRegionQueries)
+	}
+	wg.Wait()
+
+	if singleRegionResp, ok := regionResponses[""]; ok {
+		for refId, res := range singleRegionResp.Responses {
+			resp.Responses[refId] = res
+		}
+	}
+	for refId, regions := range regionsByRefId {
+		resp.Responses[refId] = mergeRegionResponses(refId, regions, regionResponses)
+	}
+	return resp, nil
+}
+
+// mergeRegionResponses merges the responses of each region of a multi-region query, labelling the series with their
+// region.
+func mergeRegionResponses(refId string, regions []string, regionResponses map[string]*backend.QueryDataResponse) backend.DataResponse {
+	var merged, failed backend.DataResponse
+	var notices []data.Notice
+	for _, region := range regions {
+		res, ok := regionResponses[region].Responses[refId]
+		if !ok {
+			// errors concerning every query of the request aren't keyed by the ref id of a query
+			res = regionResponses[region].Responses[""]
+		}
+		if res.Error != nil {
+			if failed.Error == nil {
+				failed = res
+			}
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("The query failed in %s: %s", region, res.Error),
+			})
+			continue
+		}
+		for _, frame := range res.Frames {
+			labelRegion(frame, region)
+		}
+		merged.Frames = append(merged.Frames, res.Frames...)
+	}
+
+	// every region failing fails the query, otherwise the failing regions are only reported
+	if len(notices) == len(regions) {
+		return failed
+	}
+	if len(notices) > 0 {
+		if len(merged.Frames) == 0 {
+			merged.Frames = data.Frames{data.NewFrame(refId)}
+			merged.Frames[0].RefID = refId
+		}
+		merged.Frames[0].AppendNotices(notices...)
+	}
+	return merged
+}
+
+// labelRegion labels the value fields of frame with region, and appends it to their names, so that the series of
+// each region can be told apart.
+func labelRegion(frame *data.Frame, region string) {
+	if frame.Name != "" {
+		frame.Name = fmt.Sprintf("%s (%s)", frame.Name, region)
+	}
+	for _, field := range frame.Fields {
+		if !field.Type().Numeric() {
+			continue
+		}
+		if field.Labels == nil {
+			field.Labels = data.Labels{}
+		}
+		field.Labels[regionLabel] = region
+		if field.Config != nil && field.Config.DisplayNameFromDS != "" {
+			field.Config.DisplayNameFromDS = fmt.Sprintf("%s (%s)", field.Config.DisplayNameFromDS, region)
+		}
+	}
+}
+
+// withRegion returns a copy of query querying region.
+func withRegion(query backend.DataQuery, region string) (backend.DataQuery, error) {
+	var model map[string]any
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return query, err
+	}
+	model["region"] = region
+	queryJSON, err := json.Marshal(model)
+	if err != nil {
+		return query, err
+	}
+	query.JSON = queryJSON
+	return query, nil
+}
@@ -0,0 +1,117 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_MultiRegion(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	now := time.Now().Truncate(time.Minute)
+	metricAPI := func(value float64) *mocks.MetricsAPI {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+				Id: aws.String("a"), Label: aws.String("Errors"), Values: []float64{value}, Timestamps: []time.Time{now},
+				StatusCode: cloudwatchtypes.StatusCodeComplete,
+			}},
+		}, nil)
+		return api
+	}
+	queryData := func(t *testing.T, apis map[string]*mocks.MetricsAPI, queries ...backend.DataQuery) *backend.QueryDataResponse {
+		t.Helper()
+		NewCWClient = func(cfg aws.Config) models.CWClient {
+			return apis[cfg.Region]
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = regionConfigProvider{}
+			ds.Settings.Region = "us-east-1"
+		})
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries:       queries,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	query := func(refId, region string) backend.DataQuery {
+		return backend.DataQuery{
+			RefID:     refId,
+			TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+			JSON: json.RawMessage(`{"type":"timeSeriesQuery","id":"a","region":"` + region + `","namespace":"AWS/Lambda",
+				"metricName":"Errors","statistic":"Sum","period":"60","metricQueryType":0,"metricEditorMode":0}`),
+		}
+	}
+
+	t.Run("fans the query out to each region and labels the series with their region", func(t *testing.T) {
+		east, west := metricAPI(1), metricAPI(2)
+
+		resp := queryData(t, map[string]*mocks.MetricsAPI{"us-east-1": east, "eu-west-1": west}, query("A", "default,eu-west-1"))
+
+		res := resp.Responses["A"]
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 2)
+		assert.Equal(t, "Errors (us-east-1)", res.Frames[0].Name)
+		assert.Equal(t, "us-east-1", res.Frames[0].Fields[1].Labels["region"])
+		assert.Equal(t, "Errors (us-east-1)", res.Frames[0].Fields[1].Config.DisplayNameFromDS)
+		assert.Equal(t, "eu-west-1", res.Frames[1].Fields[1].Labels["region"])
+		assert.Equal(t, 2.0, res.Frames[1].Fields[1].At(0))
+		assert.Len(t, east.Calls, 1)
+		assert.Len(t, west.Calls, 1)
+	})
+
+	t.Run("executes single region queries alongside", func(t *testing.T) {
+		east, west := metricAPI(1), metricAPI(2)
+
+		resp := queryData(t, map[string]*mocks.MetricsAPI{"us-east-1": east, "eu-west-1": west},
+			query("A", "{us-east-1,eu-west-1}"), query("B", "eu-west-1"))
+
+		require.Len(t, resp.Responses["A"].Frames, 2)
+		require.NoError(t, resp.Responses["B"].Error)
+		require.Len(t, resp.Responses["B"].Frames, 1)
+		assert.Equal(t, "Errors", resp.Responses["B"].Frames[0].Name)
+		assert.NotContains(t, resp.Responses["B"].Frames[0].Fields[1].Labels, "region")
+	})
+
+	t.Run("a failing region only adds a notice", func(t *testing.T) {
+		south := &mocks.MetricsAPI{}
+		south.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return((*cloudwatch.GetMetricDataOutput)(nil), errors.New("UnrecognizedClientException"))
+
+		resp := queryData(t, map[string]*mocks.MetricsAPI{"us-east-1": metricAPI(1), "af-south-1": south}, query("A", "us-east-1,af-south-1"))
+
+		res := resp.Responses["A"]
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+		assert.Equal(t, data.NoticeSeverityWarning, res.Frames[0].Meta.Notices[0].Severity)
+		assert.Contains(t, res.Frames[0].Meta.Notices[0].Text, "The query failed in af-south-1")
+	})
+
+	t.Run("fails the query when every region fails", func(t *testing.T) {
+		south := &mocks.MetricsAPI{}
+		south.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return((*cloudwatch.GetMetricDataOutput)(nil), errors.New("UnrecognizedClientException"))
+
+		resp := queryData(t, map[string]*mocks.MetricsAPI{"af-south-1": south, "ap-east-1": south}, query("A", "af-south-1,ap-east-1"))
+
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Contains(t, resp.Responses["A"].Error.Error(), "UnrecognizedClientException")
+	})
+}
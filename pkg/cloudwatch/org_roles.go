@@ -0,0 +1,27 @@
+package cloudwatch
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// assumeRoleARN returns the role to assume for the org making the request, or the role of the queries of the request
+// when they assume their own. When an org role map is configured, orgs without an entry are rejected rather than
+// falling back to the shared role, so that one provisioned data source can't be used to reach another tenant's account.
+func (ds *DataSource) assumeRoleARN(ctx context.Context) (string, error) {
+	if roleARN := queryRoleFromContext(ctx); roleARN != "" {
+		return roleARN, nil
+	}
+	if len(ds.Settings.OrgRoleMap) == 0 {
+		return ds.Settings.AssumeRoleARN, nil
+	}
+	orgID := strconv.FormatInt(backend.PluginConfigFromContext(ctx).OrgID, 10)
+	roleARN, ok := ds.Settings.OrgRoleMap[orgID]
+	if !ok {
+		return "", models.ErrNoOrgRole
+	}
+	return roleARN, nil
+}
@@ -0,0 +1,77 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsauth"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type settingsCapturingConfigProvider struct {
+	settings []awsauth.Settings
+}
+
+func (p *settingsCapturingConfigProvider) GetConfig(_ context.Context, authSettings awsauth.Settings) (aws.Config, error) {
+	p.settings = append(p.settings, authSettings)
+	return aws.Config{}, nil
+}
+
+func Test_newAWSConfig_orgRoleMap(t *testing.T) {
+	orgContext := func(orgID int64) context.Context {
+		return backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: orgID})
+	}
+
+	t.Run("assumes the role mapped to the requesting org", func(t *testing.T) {
+		provider := &settingsCapturingConfigProvider{}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = provider
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.AssumeRoleARN = "arn:aws:iam::111111111111:role/shared"
+			ds.Settings.OrgRoleMap = map[string]string{
+				"1": "arn:aws:iam::222222222222:role/org-1",
+				"2": "arn:aws:iam::333333333333:role/org-2",
+			}
+		})
+
+		_, err := ds.getRequestContext(orgContext(2), defaultRegion)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, provider.settings)
+		for _, settings := range provider.settings {
+			assert.Equal(t, "arn:aws:iam::333333333333:role/org-2", settings.AssumeRoleARN)
+		}
+	})
+
+	t.Run("rejects orgs without a mapped role", func(t *testing.T) {
+		provider := &settingsCapturingConfigProvider{}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = provider
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.AssumeRoleARN = "arn:aws:iam::111111111111:role/shared"
+			ds.Settings.OrgRoleMap = map[string]string{"1": "arn:aws:iam::222222222222:role/org-1"}
+		})
+
+		_, err := ds.getRequestContext(orgContext(3), defaultRegion)
+		assert.ErrorIs(t, err, models.ErrNoOrgRole)
+		assert.Empty(t, provider.settings)
+	})
+
+	t.Run("uses the configured role without a map", func(t *testing.T) {
+		provider := &settingsCapturingConfigProvider{}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = provider
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.AssumeRoleARN = "arn:aws:iam::111111111111:role/shared"
+		})
+
+		_, err := ds.newAWSConfig(orgContext(3), defaultRegion)
+		require.NoError(t, err)
+		require.Len(t, provider.settings, 1)
+		assert.Equal(t, "arn:aws:iam::111111111111:role/shared", provider.settings[0].AssumeRoleARN)
+	})
+}
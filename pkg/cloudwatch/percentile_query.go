@@ -0,0 +1,152 @@
+package cloudwatch
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// percentileQuery is a query of a percentile of a query with percentiles
+type percentileQuery struct {
+	parent     *models.CloudWatchQuery
+	percentile string
+}
+
+// expandPercentileQueries replaces the queries with percentiles by a query per percentile, so that all the
+// percentiles are queried in the same GetMetricData request. The queries of the percentiles are returned by ref ID,
+// for their results to be merged back by mergePercentileResults.
+func expandPercentileQueries(queries []*models.CloudWatchQuery, percentileQueries map[string]percentileQuery) []*models.CloudWatchQuery {
+	expanded := make([]*models.CloudWatchQuery, 0, len(queries))
+	for _, query := range queries {
+		if len(query.Percentiles) == 0 {
+			expanded = append(expanded, query)
+			continue
+		}
+		for _, percentile := range query.Percentiles {
+			child := *query
+			child.RefId = query.RefId + "#" + percentile
+			child.Id = query.Id + "_" + strings.ReplaceAll(percentile, ".", "_")
+			child.Statistic = percentile
+			child.FallbackStatistic = ""
+			child.IncludeSampleCount = false
+			child.Percentiles = nil
+			percentileQueries[child.RefId] = percentileQuery{parent: query, percentile: percentile}
+			expanded = append(expanded, &child)
+		}
+	}
+	return expanded
+}
+
+// mergePercentileResults merges the results of the percentiles of a query into a frame per series, with a field per
+// percentile in the order of the percentiles of the query. The query fails if any of its percentiles failed.
+func mergePercentileResults(query *models.CloudWatchQuery, results map[string]backend.DataResponse) backend.DataResponse {
+	for _, percentile := range query.Percentiles {
+		if result, ok := results[percentile]; ok && result.Error != nil {
+			return result
+		}
+	}
+
+	type series struct {
+		name   string
+		labels data.Labels
+		meta   *data.FrameMeta
+		links  []data.DataLink
+		values map[string]map[time.Time]*float64
+	}
+	var seriesList []*series
+	seriesByKey := map[string]*series{}
+	for _, percentile := range query.Percentiles {
+		for _, frame := range results[percentile].Frames {
+			labels := seriesFrameLabels(frame)
+			key := frame.Name
+			if labels != nil {
+				key = labels.String()
+			}
+			s, ok := seriesByKey[key]
+			if !ok {
+				s = &series{name: frame.Name, labels: labels, meta: frame.Meta, values: map[string]map[time.Time]*float64{}}
+				for _, field := range frame.Fields {
+					if field.Config != nil && len(field.Config.Links) > 0 {
+						s.links = field.Config.Links
+					}
+				}
+				seriesByKey[key] = s
+				seriesList = append(seriesList, s)
+			}
+			if s.values[percentile] == nil {
+				s.values[percentile] = map[time.Time]*float64{}
+			}
+			addPercentileValues(frame, s.values[percentile])
+		}
+	}
+
+	frames := make(data.Frames, 0, len(seriesList))
+	for _, s := range seriesList {
+		seen := map[time.Time]bool{}
+		var timestamps []time.Time
+		for _, values := range s.values {
+			for timestamp := range values {
+				if !seen[timestamp] {
+					seen[timestamp] = true
+					timestamps = append(timestamps, timestamp)
+				}
+			}
+		}
+		slices.SortFunc(timestamps, time.Time.Compare)
+
+		fields := []*data.Field{data.NewField(data.TimeSeriesTimeFieldName, nil, timestamps).SetConfig(periodFieldConfig(query.Period))}
+		for _, percentile := range query.Percentiles {
+			values := make([]*float64, len(timestamps))
+			for i, timestamp := range timestamps {
+				values[i] = s.values[percentile][timestamp]
+			}
+			field := data.NewField(percentile, s.labels.Copy(), values)
+			displayName := percentile
+			if len(seriesList) > 1 {
+				displayName = s.name + " " + percentile
+			}
+			field.SetConfig(&data.FieldConfig{DisplayNameFromDS: displayName, Links: s.links})
+			fields = append(fields, field)
+		}
+
+		frame := data.NewFrame(s.name, fields...)
+		frame.RefID = query.RefId
+		if s.meta != nil {
+			meta := *s.meta
+			frame.Meta = &meta
+		}
+		frames = append(frames, frame)
+	}
+	return backend.DataResponse{Frames: frames}
+}
+
+// addPercentileValues adds the datapoints of a series frame of a percentile to values, by timestamp.
+func addPercentileValues(frame *data.Frame, values map[time.Time]*float64) {
+	timeIndex, valueIndex := -1, -1
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			timeIndex = i
+		} else if field.Type().Numeric() {
+			valueIndex = i
+		}
+	}
+	if timeIndex == -1 || valueIndex == -1 {
+		return
+	}
+	for row := 0; row < frame.Rows(); row++ {
+		timestamp, ok := frame.Fields[timeIndex].ConcreteAt(row)
+		if !ok {
+			continue
+		}
+		value, err := frame.Fields[valueIndex].NullableFloatAt(row)
+		if err != nil {
+			continue
+		}
+		values[timestamp.(time.Time).UTC()] = value
+	}
+}
@@ -0,0 +1,95 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_Percentiles(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	now := time.Now().UTC().Truncate(time.Minute)
+	queryData := func(t *testing.T, api *mocks.MetricsAPI) backend.DataResponse {
+		t.Helper()
+		NewCWClient = func(aws.Config) models.CWClient {
+			return api
+		}
+		resp, err := newTestDatasource().QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON: json.RawMessage(`{"type":"timeSeriesQuery","id":"a","region":"us-east-1","namespace":"AWS/ELB",
+					"metricName":"Latency","dimensions":{"LoadBalancerName":["web"]},"matchExact":true,"period":"60",
+					"statistic":"Average","percentiles":["p99.9","p50"]}`),
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("queries the percentiles in one request and returns a field per percentile", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{StatusCode: "Complete", Id: aws.String("a_p50"), Label: aws.String("Latency"),
+					Values: []float64{1, 2}, Timestamps: []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Minute)}},
+				{StatusCode: "Complete", Id: aws.String("a_p99_9"), Label: aws.String("Latency"),
+					Values: []float64{9}, Timestamps: []time.Time{now.Add(-time.Minute)}},
+			},
+		}, nil)
+
+		response := queryData(t, api)
+
+		require.NoError(t, response.Error)
+		require.Len(t, api.Calls, 1)
+		input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		require.Len(t, input.MetricDataQueries, 2)
+		stats := map[string]string{}
+		for _, query := range input.MetricDataQueries {
+			require.NotNil(t, query.MetricStat)
+			stats[aws.ToString(query.Id)] = aws.ToString(query.MetricStat.Stat)
+		}
+		assert.Equal(t, map[string]string{"a_p50": "p50", "a_p99_9": "p99.9"}, stats)
+
+		require.Len(t, response.Frames, 1)
+		frame := response.Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		require.Len(t, frame.Fields, 3)
+		assert.Equal(t, 2, frame.Fields[0].Len())
+		assert.Equal(t, "p50", frame.Fields[1].Name)
+		assert.Equal(t, "p50", frame.Fields[1].Config.DisplayNameFromDS)
+		assert.Equal(t, "p99.9", frame.Fields[2].Name)
+		assert.Equal(t, "web", frame.Fields[2].Labels["LoadBalancerName"])
+		assert.Equal(t, []*float64{aws.Float64(1), aws.Float64(2)}, []*float64{frame.Fields[1].At(0).(*float64), frame.Fields[1].At(1).(*float64)})
+		assert.Nil(t, frame.Fields[2].At(0).(*float64))
+		assert.Equal(t, 9.0, *frame.Fields[2].At(1).(*float64))
+	})
+
+	t.Run("fails when a percentile fails", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).
+			Return(&cloudwatch.GetMetricDataOutput{}, errors.New("Error in query 'a_p99_9': invalid statistic"))
+
+		response := queryData(t, api)
+
+		assert.ErrorContains(t, response.Error, "invalid statistic")
+		assert.Empty(t, response.Frames)
+	})
+}
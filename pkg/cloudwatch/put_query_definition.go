@@ -0,0 +1,86 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// putQueryDefinitionRequest is the body of a request to the put-query-definition resource route. The definition with
+// QueryDefinitionId is updated if set, otherwise a new one is created.
+type putQueryDefinitionRequest struct {
+	Region            string                       `json:"region"`
+	QueryDefinitionId string                       `json:"queryDefinitionId"`
+	Name              string                       `json:"name"`
+	QueryString       string                       `json:"queryString"`
+	QueryLanguage     *dataquery.LogsQueryLanguage `json:"queryLanguage"`
+	LogGroupNames     []string                     `json:"logGroupNames"`
+}
+
+type putQueryDefinitionResponse struct {
+	QueryDefinitionId string `json:"queryDefinitionId"`
+}
+
+// handlePutQueryDefinition saves a Logs Insights query as a query definition of the account, so that queries written
+// in Grafana show up among the saved queries of the CloudWatch console, and the other way round.
+func (ds *DataSource) handlePutQueryDefinition(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		respondWithError(rw, models.NewHttpError("Invalid method", http.StatusMethodNotAllowed, nil))
+		return
+	}
+
+	ctx := req.Context()
+	var request putQueryDefinitionRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		respondWithError(rw, models.NewHttpError("error in PutQueryDefinitionHandler", http.StatusBadRequest, err))
+		return
+	}
+	if request.Name == "" || request.QueryString == "" {
+		respondWithError(rw, models.NewHttpError("error in PutQueryDefinitionHandler", http.StatusBadRequest,
+			fmt.Errorf("a query definition needs a name and a query string")))
+		return
+	}
+	if request.Region == "" {
+		request.Region = defaultRegion
+	}
+
+	client, err := ds.getCWLogsClient(ctx, request.Region)
+	if err != nil {
+		respondWithError(rw, models.NewHttpError("newCWLogsClient error", http.StatusInternalServerError, err))
+		return
+	}
+	input := &cloudwatchlogs.PutQueryDefinitionInput{
+		Name:          aws.String(request.Name),
+		QueryString:   aws.String(request.QueryString),
+		LogGroupNames: request.LogGroupNames,
+	}
+	if request.QueryDefinitionId != "" {
+		input.QueryDefinitionId = aws.String(request.QueryDefinitionId)
+	}
+	if request.QueryLanguage != nil {
+		input.QueryLanguage = cloudwatchlogstypes.QueryLanguage(*request.QueryLanguage)
+	}
+	output, err := client.PutQueryDefinition(ctx, input)
+	if err != nil {
+		ds.logger.FromContext(ctx).Error("Error handling resource request", "error", err)
+		respondWithError(rw, newResourceHttpError("PutQueryDefinition error", err))
+		return
+	}
+
+	response, err := json.Marshal(putQueryDefinitionResponse{QueryDefinitionId: aws.ToString(output.QueryDefinitionId)})
+	if err != nil {
+		respondWithError(rw, models.NewHttpError("PutQueryDefinitionHandler json error", http.StatusInternalServerError, err))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	if _, err := rw.Write(response); err != nil {
+		ds.logger.FromContext(ctx).Error("Error handling resource request", "error", err)
+	}
+}
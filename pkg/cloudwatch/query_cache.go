@@ -0,0 +1,153 @@
+package cloudwatch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	queryCacheHitStat = "Result cache hit"
+	queryCacheAgeStat = "Result cache age"
+)
+
+// volatileQueryFields are query model fields that don't change the result of a query and are left out of the cache key.
+var volatileQueryFields = []string{"datasource", "intervalMs", "maxDataPoints", "key", "hide", "queryType"}
+
+type queryCacheEntry struct {
+	response backend.DataResponse
+	cachedAt time.Time
+}
+
+// executeTimeSeriesQueryWithCache serves time series queries from the result cache where possible and executes
+// the rest. Time ranges are aligned to the cache TTL so that refreshes within the same window share a cache entry.
+func (ds *DataSource) executeTimeSeriesQueryWithCache(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	ttl := ds.Settings.QueryCacheTTL.Duration
+	resp := backend.NewQueryDataResponse()
+
+	keysByRefId := map[string]string{}
+	misses := make([]backend.DataQuery, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		query.TimeRange = alignTimeRange(query.TimeRange, ttl)
+		key, err := queryCacheKey(ctx, query, ds.Settings.UserIdentityPassThrough)
+		if err != nil {
+			misses = append(misses, query)
+			continue
+		}
+		if cached, found := ds.queryCache.Get(key); found {
+			entry := cached.(queryCacheEntry)
+			resp.Responses[query.RefID] = withQueryCacheStats(entry.response, true, time.Since(entry.cachedAt))
+			continue
+		}
+		keysByRefId[query.RefID] = key
+		misses = append(misses, query)
+	}
+
+	if len(misses) == 0 {
+		return resp, nil
+	}
+
+	missesReq := *req
+	missesReq.Queries = misses
+	missesResp, err := ds.runTimeSeriesQuery(ctx, &missesReq)
+	if err != nil {
+		return nil, err
+	}
+	for refId, response := range missesResp.Responses {
+		if key, ok := keysByRefId[refId]; ok && response.Error == nil {
+			ds.queryCache.Set(key, queryCacheEntry{response: response, cachedAt: time.Now()}, ttl)
+		}
+		resp.Responses[refId] = withQueryCacheStats(response, false, 0)
+	}
+	return resp, nil
+}
+
+// alignTimeRange widens the time range to multiples of alignment.
+func alignTimeRange(timeRange backend.TimeRange, alignment time.Duration) backend.TimeRange {
+	aligned := backend.TimeRange{From: timeRange.From.Truncate(alignment), To: timeRange.To.Truncate(alignment)}
+	if aligned.To.Before(timeRange.To) {
+		aligned.To = aligned.To.Add(alignment)
+	}
+	return aligned
+}
+
+// queryCacheKey identifies a query result. Results are cached per org, since orgs may be mapped to different roles,
+// and per user when the user's own identity is used to query AWS.
+func queryCacheKey(ctx context.Context, query backend.DataQuery, perUser bool) (string, error) {
+	var model map[string]any
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return "", err
+	}
+	for _, field := range volatileQueryFields {
+		delete(model, field)
+	}
+
+	return scopedCacheKey(ctx, perUser, map[string]any{
+		"query": model,
+		"from":  query.TimeRange.From.UnixMilli(),
+		"to":    query.TimeRange.To.UnixMilli(),
+	})
+}
+
+// scopedCacheKey hashes values together with the org, and user when perUser is set, of the request in ctx.
+func scopedCacheKey(ctx context.Context, perUser bool, values map[string]any) (string, error) {
+	pCtx := backend.PluginConfigFromContext(ctx)
+	user := ""
+	if perUser && pCtx.User != nil {
+		user = pCtx.User.Login
+	}
+	values["orgId"] = pCtx.OrgID
+	values["user"] = user
+
+	// map keys are marshalled in sorted order, so equal values produce equal keys
+	key, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// roleScopedCacheKey hashes values together with the role assumed for the request in ctx, and its org and user like
+// query results are, so that organizations and users mapped to different roles don't share cached resources.
+func (ds *DataSource) roleScopedCacheKey(ctx context.Context, values map[string]any) (string, error) {
+	roleARN, err := ds.assumeRoleARN(ctx)
+	if err != nil {
+		return "", err
+	}
+	values["role"] = roleARN
+	return scopedCacheKey(ctx, ds.Settings.UserIdentityPassThrough, values)
+}
+
+// withQueryCacheStats returns a copy of the response whose frames report whether they were served from the cache.
+// The cached frames themselves are left untouched.
+func withQueryCacheStats(response backend.DataResponse, hit bool, age time.Duration) backend.DataResponse {
+	hitValue := 0.0
+	if hit {
+		hitValue = 1
+	}
+	stats := []data.QueryStat{
+		{FieldConfig: data.FieldConfig{DisplayName: queryCacheHitStat}, Value: hitValue},
+		{FieldConfig: data.FieldConfig{DisplayName: queryCacheAgeStat, Unit: "s"}, Value: age.Truncate(time.Second).Seconds()},
+	}
+
+	frames := make(data.Frames, 0, len(response.Frames))
+	for _, frame := range response.Frames {
+		frameCopy := *frame
+		meta := data.FrameMeta{}
+		if frame.Meta != nil {
+			meta = *frame.Meta
+		}
+		meta.Stats = append(slices.Clone(meta.Stats), stats...)
+		frameCopy.Meta = &meta
+		frames = append(frames, &frameCopy)
+	}
+	response.Frames = frames
+	return response
+}
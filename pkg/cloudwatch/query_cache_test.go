@@ -0,0 +1,110 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_alignTimeRange(t *testing.T) {
+	from := time.Date(2024, 1, 1, 10, 0, 25, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 11, 0, 25, 0, time.UTC)
+
+	assert.Equal(t, backend.TimeRange{
+		From: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 1, 11, 1, 0, 0, time.UTC),
+	}, alignTimeRange(backend.TimeRange{From: from, To: to}, time.Minute))
+
+	aligned := backend.TimeRange{From: from.Truncate(time.Minute), To: to.Truncate(time.Minute)}
+	assert.Equal(t, aligned, alignTimeRange(aligned, time.Minute), "aligned ranges are unchanged")
+}
+
+func Test_queryCacheKey(t *testing.T) {
+	timeRange := backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)}
+	orgContext := func(orgID int64, login string) context.Context {
+		return backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: orgID, User: &backend.User{Login: login}})
+	}
+	key := func(ctx context.Context, queryJSON string, perUser bool) string {
+		k, err := queryCacheKey(ctx, backend.DataQuery{JSON: json.RawMessage(queryJSON), TimeRange: timeRange}, perUser)
+		require.NoError(t, err)
+		return k
+	}
+
+	base := key(orgContext(1, "alice"), `{"metricName":"CPUUtilization","namespace":"AWS/EC2"}`, false)
+	assert.Equal(t, base, key(orgContext(1, "alice"), `{"namespace":"AWS/EC2","metricName":"CPUUtilization","intervalMs":1000,"maxDataPoints":500}`, false),
+		"field order and volatile fields don't matter")
+	assert.Equal(t, base, key(orgContext(1, "bob"), `{"metricName":"CPUUtilization","namespace":"AWS/EC2"}`, false))
+	assert.NotEqual(t, base, key(orgContext(2, "alice"), `{"metricName":"CPUUtilization","namespace":"AWS/EC2"}`, false))
+	assert.NotEqual(t, base, key(orgContext(1, "bob"), `{"metricName":"CPUUtilization","namespace":"AWS/EC2"}`, true))
+	assert.NotEqual(t, base, key(orgContext(1, "alice"), `{"metricName":"NetworkIn","namespace":"AWS/EC2"}`, false))
+}
+
+func TestQueryData_queryCache(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+	now := time.Now()
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+			StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: []float64{1}, Timestamps: []time.Time{now},
+		}}}, nil)
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.queryCache = cache.New(cache.NoExpiration, 0)
+		ds.Settings.QueryCacheTTL = models.Duration{Duration: time.Minute}
+	})
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+			JSON: json.RawMessage(`{
+				"type": "timeSeriesQuery",
+				"namespace": "AWS/EC2",
+				"metricName": "CPUUtilization",
+				"dimensions": {"InstanceId": "i-1"},
+				"region": "us-east-1",
+				"id": "a",
+				"statistic": "Average",
+				"period": "60",
+				"matchExact": true,
+				"refId": "A"
+			}`),
+		}},
+	}
+
+	first, err := ds.QueryData(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, first.Responses["A"].Frames, 1)
+	assert.Equal(t, 0.0, first.Responses["A"].Frames[0].Meta.Stats[0].Value)
+
+	second, err := ds.QueryData(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, second.Responses["A"].Frames, 1)
+	stats := second.Responses["A"].Frames[0].Meta.Stats
+	require.Len(t, stats, 2)
+	assert.Equal(t, queryCacheHitStat, stats[0].DisplayName)
+	assert.Equal(t, 1.0, stats[0].Value)
+	assert.Equal(t, queryCacheAgeStat, stats[1].DisplayName)
+
+	api.AssertNumberOfCalls(t, "GetMetricData", 1)
+	input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+	assert.Equal(t, now.Add(-time.Hour).Truncate(time.Minute), *input.StartTime, "the time range is aligned to the cache TTL")
+}
@@ -0,0 +1,39 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// ConvertQueryDataRequest upgrades the legacy json of the queries of the request to the current dataquery schema, so
+// that queries saved in old dashboards keep working wherever Grafana converts them instead of the frontend, and
+// normalizes queries provisioned as code to the canonical form of their kind, so that converting them is round-trip
+// safe.
+func ConvertQueryDataRequest(_ context.Context, req *backend.QueryDataRequest) (*backend.QueryConversionResponse, error) {
+	migrated, err := models.MigrateQueries(req.Queries)
+	if err != nil {
+		var queryErr *models.QueryError
+		if errors.As(err, &queryErr) {
+			return &backend.QueryConversionResponse{
+				Result: &backend.StatusResult{
+					Status:  "Failure",
+					Message: queryErr.Error(),
+					Reason:  "BadRequest",
+					Code:    http.StatusBadRequest,
+				},
+			}, nil
+		}
+		return nil, err
+	}
+
+	queries := make([]any, len(migrated))
+	for i, query := range migrated {
+		queries[i] = query
+	}
+	return &backend.QueryConversionResponse{Queries: queries}, nil
+}
@@ -0,0 +1,46 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConvertQueryDataRequest(t *testing.T) {
+	t.Run("converts legacy queries", func(t *testing.T) {
+		resp, err := ConvertQueryDataRequest(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", JSON: json.RawMessage(`{"refId":"A","namespace":"AWS/EC2","metricName":"CPUUtilization","statistics":["Average","Maximum"],"dimensions":{"InstanceId":"i-123"},"alias":"{{InstanceId}}"}`)},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Nil(t, resp.Result)
+		require.Len(t, resp.Queries, 2)
+		for i, expected := range []string{
+			`{"refId":"A","queryMode":"Metrics","namespace":"AWS/EC2","metricName":"CPUUtilization","statistic":"Average","dimensions":{"InstanceId":["i-123"]},"alias":"{{InstanceId}}","label":"${PROP('Dim.InstanceId')}","metricQueryType":0,"metricEditorMode":0}`,
+			`{"refId":"B","queryMode":"Metrics","namespace":"AWS/EC2","metricName":"CPUUtilization","statistic":"Maximum","dimensions":{"InstanceId":["i-123"]},"alias":"{{InstanceId}}","label":"${PROP('Dim.InstanceId')}","metricQueryType":0,"metricEditorMode":0}`,
+		} {
+			actual, err := json.Marshal(resp.Queries[i])
+			require.NoError(t, err)
+			assert.JSONEq(t, expected, string(actual))
+		}
+	})
+
+	t.Run("reports invalid queries in the result", func(t *testing.T) {
+		resp, err := ConvertQueryDataRequest(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: json.RawMessage(`{"dimensions":{"InstanceId":1}}`)}},
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, resp.Result)
+		assert.Equal(t, "Failure", resp.Result.Status)
+		assert.Equal(t, int32(http.StatusBadRequest), resp.Result.Code)
+		assert.Empty(t, resp.Queries)
+	})
+}
@@ -0,0 +1,120 @@
+package cloudwatch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
+)
+
+func TestQueryDefinitionsRoute(t *testing.T) {
+	origLogGroupsService := services.NewLogGroupsService
+	t.Cleanup(func() {
+		services.NewLogGroupsService = origLogGroupsService
+	})
+	var mockLogsService mocks.LogsService
+	services.NewLogGroupsService = func(_ models.CloudWatchLogsAPIProvider, _ bool) models.LogGroupsProvider {
+		return &mockLogsService
+	}
+
+	t.Run("returns the query definitions whose name starts with the prefix", func(t *testing.T) {
+		mockLogsService = mocks.LogsService{}
+		mockLogsService.On("GetQueryDefinitions", resources.QueryDefinitionsRequest{
+			ResourceRequest: resources.ResourceRequest{Region: "us-east-1"},
+			NamePrefix:      utils.Pointer("lambda/"),
+		}).Return([]resources.ResourceResponse[resources.QueryDefinition]{{
+			Label: "lambda/errors",
+			Value: resources.QueryDefinition{Id: "1", Name: "lambda/errors", QueryString: "filter @message like /ERROR/", LogGroupNames: []string{"/aws/lambda/checkout"}},
+		}}, nil)
+
+		rr := httptest.NewRecorder()
+		ds := newTestDatasource()
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.QueryDefinitionsHandler))
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/query-definitions?region=us-east-1&namePrefix=lambda/", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"label":"lambda/errors","value":{"id":"1","name":"lambda/errors","queryString":"filter @message like /ERROR/","logGroupNames":["/aws/lambda/checkout"]}}]`, rr.Body.String())
+	})
+
+	t.Run("returns 403 if the role can't list query definitions", func(t *testing.T) {
+		mockLogsService = mocks.LogsService{}
+		mockLogsService.On("GetQueryDefinitions", mock.Anything).Return([]resources.ResourceResponse[resources.QueryDefinition]{},
+			&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized to perform logs:DescribeQueryDefinitions"})
+
+		rr := httptest.NewRecorder()
+		ds := newTestDatasource()
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.QueryDefinitionsHandler))
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/query-definitions?region=us-east-1", nil))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
+
+func TestPutQueryDefinitionRoute(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli fakeCWLogsClient
+	NewCWLogsClient = func(aws.Config) models.CWLogsClient {
+		return &cli
+	}
+
+	t.Run("creates a query definition", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("POST", "/put-query-definition", strings.NewReader(
+			`{"region":"us-east-1","name":"lambda/errors","queryString":"filter @message like /ERROR/","queryLanguage":"CWLI","logGroupNames":["/aws/lambda/checkout"]}`)))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"queryDefinitionId":"new-query-definition"}`, rr.Body.String())
+		require.Len(t, cli.calls.putQueryDefinition, 1)
+		assert.Equal(t, "lambda/errors", aws.ToString(cli.calls.putQueryDefinition[0].Name))
+		assert.Equal(t, "filter @message like /ERROR/", aws.ToString(cli.calls.putQueryDefinition[0].QueryString))
+		assert.Equal(t, cloudwatchlogstypes.QueryLanguageCwli, cli.calls.putQueryDefinition[0].QueryLanguage)
+		assert.Equal(t, []string{"/aws/lambda/checkout"}, cli.calls.putQueryDefinition[0].LogGroupNames)
+		assert.Nil(t, cli.calls.putQueryDefinition[0].QueryDefinitionId)
+	})
+
+	t.Run("updates the query definition with the ID", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("POST", "/put-query-definition", strings.NewReader(
+			`{"region":"us-east-1","queryDefinitionId":"1","name":"lambda/errors","queryString":"filter @message like /WARN/"}`)))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"queryDefinitionId":"1"}`, rr.Body.String())
+		require.Len(t, cli.calls.putQueryDefinition, 1)
+		assert.Equal(t, "1", aws.ToString(cli.calls.putQueryDefinition[0].QueryDefinitionId))
+	})
+
+	t.Run("rejects query definitions without a name or query string", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("POST", "/put-query-definition", strings.NewReader(
+			`{"region":"us-east-1","queryString":"filter @message like /WARN/"}`)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Empty(t, cli.calls.putQueryDefinition)
+	})
+
+	t.Run("rejects other methods than POST", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newTestDatasource().newResourceMux().ServeHTTP(rr, httptest.NewRequest("GET", "/put-query-definition", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+}
@@ -0,0 +1,285 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	exportFormatCSV     = "csv"
+	exportFormatParquet = "parquet"
+
+	exportParquetRowGroupSize = 64 * 1024
+)
+
+// exportRequest is the body of a request to the export resource route. From and To are epoch milliseconds, and
+// Queries are query models as saved in panels. All queries must be either metrics or logs queries.
+type exportRequest struct {
+	Format  string            `json:"format"`
+	From    int64             `json:"from"`
+	To      int64             `json:"to"`
+	Queries []json.RawMessage `json:"queries"`
+}
+
+// handleExport executes the queries of the request and writes their full result as a CSV or Parquet file, so that
+// results can be handed on without the row limits of panels.
+func (ds *DataSource) handleExport(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		respondWithError(rw, models.NewHttpError("Invalid method", http.StatusMethodNotAllowed, nil))
+		return
+	}
+
+	ctx := req.Context()
+	var request exportRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		respondWithError(rw, models.NewHttpError("error in ExportHandler", http.StatusBadRequest, err))
+		return
+	}
+	if request.Format == "" {
+		request.Format = exportFormatCSV
+	}
+	if request.Format != exportFormatCSV && request.Format != exportFormatParquet {
+		respondWithError(rw, models.NewHttpError("error in ExportHandler", http.StatusBadRequest, fmt.Errorf("unsupported format %q", request.Format)))
+		return
+	}
+	if len(request.Queries) == 0 {
+		respondWithError(rw, models.NewHttpError("error in ExportHandler", http.StatusBadRequest, fmt.Errorf("request contains no queries")))
+		return
+	}
+
+	frames, err := ds.executeExportQueries(ctx, request)
+	if err != nil {
+		ds.logger.FromContext(ctx).Error("Error handling resource request", "error", err)
+		respondWithError(rw, models.NewHttpError("error in ExportHandler", http.StatusBadRequest, err))
+		return
+	}
+
+	filename := "cloudwatch-export." + request.Format
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if request.Format == exportFormatParquet {
+		rw.Header().Set("Content-Type", "application/vnd.apache.parquet")
+		err = writeExportParquet(rw, exportFrame(frames))
+	} else {
+		rw.Header().Set("Content-Type", "text/csv")
+		err = writeExportCSV(rw, frames)
+	}
+	if err != nil {
+		// the status has already been sent, so the error can only be logged
+		ds.logger.FromContext(ctx).Error("Error writing export", "error", err)
+	}
+}
+
+func (ds *DataSource) executeExportQueries(ctx context.Context, request exportRequest) (data.Frames, error) {
+	queryRequest := &backend.QueryDataRequest{PluginContext: backend.PluginConfigFromContext(ctx)}
+	timeRange := backend.TimeRange{From: time.UnixMilli(request.From), To: time.UnixMilli(request.To)}
+	for i, queryJSON := range request.Queries {
+		var model struct {
+			RefId string `json:"refId"`
+		}
+		if err := json.Unmarshal(queryJSON, &model); err != nil {
+			return nil, err
+		}
+		if model.RefId == "" {
+			model.RefId = fmt.Sprintf("query%d", i)
+		}
+		queryRequest.Queries = append(queryRequest.Queries, backend.DataQuery{RefID: model.RefId, TimeRange: timeRange, JSON: queryJSON})
+	}
+
+	// like in QueryData, the queries of each role they assume are executed with a config of their own
+	roles, queriesByRole := groupQueriesByRole(queryRequest.Queries)
+	frames := data.Frames{}
+	for _, role := range roles {
+		roleCtx, err := ds.withValidQueryRole(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		roleRequest := *queryRequest
+		roleRequest.Queries = queriesByRole[role]
+		roleFrames, err := ds.executeQueriesToCompletion(roleCtx, &roleRequest)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, roleFrames...)
+	}
+	return frames, nil
+}
+
+// executeQueriesToCompletion executes the queries of queryRequest, which must be either all metrics or all logs
+// queries, and returns the frames of all of them, or the error of the first that failed. Unlike for panels, logs
+// queries are run to completion rather than left for the frontend to poll.
+func (ds *DataSource) executeQueriesToCompletion(ctx context.Context, queryRequest *backend.QueryDataRequest) (data.Frames, error) {
+	var model DataQueryJson
+	if err := json.Unmarshal(queryRequest.Queries[0].JSON, &model); err != nil {
+		return nil, err
+	}
+	var resp *backend.QueryDataResponse
+	var err error
+	if string(model.QueryMode) == logsQueryMode && model.LogsMode == dataquery.LogsModeEvents {
+		resp, err = ds.executeLogEventsQueries(ctx, queryRequest)
+	} else if string(model.QueryMode) == logsQueryMode && model.LogsMode == dataquery.LogsModeFilter {
+		resp, err = ds.executeLogFilterQueries(ctx, queryRequest)
+	} else if string(model.QueryMode) == logsQueryMode {
+		// logs queries are run to completion, as the frontend that would otherwise poll for their results isn't involved
+		resp, err = executeSyncLogQuery(ctx, ds, queryRequest)
+	} else {
+		resp, err = ds.executeTimeSeriesQuery(ctx, queryRequest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	frames := data.Frames{}
+	for _, query := range queryRequest.Queries {
+		response := resp.Responses[query.RefID]
+		if response.Error != nil {
+			return nil, fmt.Errorf("query %s: %w", query.RefID, response.Error)
+		}
+		for _, frame := range response.Frames {
+			if frame.RefID == "" {
+				frame.RefID = query.RefID
+			}
+		}
+		frames = append(frames, response.Frames...)
+	}
+	return frames, nil
+}
+
+// exportColumns are the columns of the long-format export of frames: a column identifying the series each row belongs
+// to, followed by a column for each field name in the order the fields first appear. Columns whose type differs
+// between frames are exported as strings.
+type exportColumns struct {
+	series string
+	names  []string
+	types  map[string]data.FieldType
+}
+
+func newExportColumns(frames data.Frames) exportColumns {
+	columns := exportColumns{types: map[string]data.FieldType{}}
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			fieldType := field.Type().NullableType()
+			existing, ok := columns.types[field.Name]
+			if !ok {
+				columns.names = append(columns.names, field.Name)
+				columns.types[field.Name] = fieldType
+			} else if existing != fieldType {
+				columns.types[field.Name] = data.FieldTypeNullableString
+			}
+		}
+	}
+	// the series column is named so that it doesn't clash with a field of the frames
+	columns.series = "series"
+	for {
+		if _, taken := columns.types[columns.series]; !taken {
+			break
+		}
+		columns.series = "_" + columns.series
+	}
+	return columns
+}
+
+// exportFrame combines frames into a single long-format frame with the export columns of frames.
+func exportFrame(frames data.Frames) *data.Frame {
+	exportColumns := newExportColumns(frames)
+	rows := 0
+	for _, frame := range frames {
+		rows += frame.Rows()
+	}
+
+	series := data.NewFieldFromFieldType(data.FieldTypeString, rows)
+	series.Name = exportColumns.series
+	fields := []*data.Field{series}
+	columns := map[string]*data.Field{}
+	for _, name := range exportColumns.names {
+		column := data.NewFieldFromFieldType(exportColumns.types[name], rows)
+		column.Name = name
+		columns[name] = column
+		fields = append(fields, column)
+	}
+
+	row := 0
+	for _, frame := range frames {
+		key := seriesKey(frame)
+		for i := 0; i < frame.Rows(); i++ {
+			series.Set(row, key)
+			for _, field := range frame.Fields {
+				value, ok := field.ConcreteAt(i)
+				if !ok {
+					continue
+				}
+				column := columns[field.Name]
+				if column.Type() != field.Type().NullableType() {
+					value = fmt.Sprint(value)
+				}
+				column.SetConcrete(row, value)
+			}
+			row++
+		}
+	}
+	return data.NewFrame("export", fields...)
+}
+
+// writeExportCSV writes the export columns of frames as CSV, row by row, so that the rows don't have to be combined
+// into a single frame first.
+func writeExportCSV(w io.Writer, frames data.Frames) error {
+	columns := newExportColumns(frames)
+	writer := csv.NewWriter(w)
+	record := append([]string{columns.series}, columns.names...)
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	positions := map[string]int{}
+	for i, name := range columns.names {
+		positions[name] = i + 1
+	}
+	for _, frame := range frames {
+		key := seriesKey(frame)
+		for row := 0; row < frame.Rows(); row++ {
+			clear(record)
+			record[0] = key
+			for _, field := range frame.Fields {
+				record[positions[field.Name]] = exportValue(field, row)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func exportValue(field *data.Field, row int) string {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return ""
+	}
+	if timestamp, ok := value.(time.Time); ok {
+		return timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+func writeExportParquet(w io.Writer, frame *data.Frame) error {
+	table, err := data.FrameToArrowTable(frame)
+	if err != nil {
+		return err
+	}
+	defer table.Release()
+	return pqarrow.WriteTable(table, w, exportParquetRowGroupSize,
+		parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy)), pqarrow.DefaultWriterProps())
+}
@@ -0,0 +1,131 @@
+package cloudwatch
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_exportFrame(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	frames := data.Frames{
+		seriesFrame("cpu", start, start.Add(time.Minute)),
+		data.NewFrame("logs",
+			data.NewField("@message", nil, []string{"started"}),
+			data.NewField(data.TimeSeriesValueFieldName, nil, []string{"n/a"})),
+	}
+
+	frame := exportFrame(frames)
+
+	require.Equal(t, 3, frame.Rows())
+	require.Len(t, frame.Fields, 4)
+	assert.Equal(t, "series", frame.Fields[0].Name)
+	assert.Equal(t, data.FieldTypeNullableString, frame.Fields[2].Type(), "columns with conflicting types are exported as strings")
+
+	var csvOutput bytes.Buffer
+	require.NoError(t, writeExportCSV(&csvOutput, frames))
+	assert.Equal(t, `series,Time,Value,@message
+cpu{InstanceId=i-1},2024-01-01T10:00:00Z,0,
+cpu{InstanceId=i-1},2024-01-01T10:01:00Z,1,
+logs,,n/a,started
+`, csvOutput.String())
+
+	var parquetOutput bytes.Buffer
+	require.NoError(t, writeExportParquet(&parquetOutput, frame))
+	assert.True(t, bytes.HasPrefix(parquetOutput.Bytes(), []byte("PAR1")))
+	assert.True(t, bytes.HasSuffix(parquetOutput.Bytes(), []byte("PAR1")))
+
+	t.Run("names the series column after the fields of the frames", func(t *testing.T) {
+		frames := data.Frames{data.NewFrame("logs",
+			data.NewField("series", nil, []string{"a"}),
+			data.NewField("_series", nil, []string{"b"}))}
+
+		frame := exportFrame(frames)
+		require.Len(t, frame.Fields, 3)
+		assert.Equal(t, "__series", frame.Fields[0].Name)
+
+		var csvOutput bytes.Buffer
+		require.NoError(t, writeExportCSV(&csvOutput, frames))
+		assert.Equal(t, "__series,series,_series\nlogs,a,b\n", csvOutput.String())
+	})
+}
+
+func TestExportHandler(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+	timestamp := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+			StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: []float64{42}, Timestamps: []time.Time{timestamp},
+		}}}, nil)
+	ds := newTestDatasource()
+
+	t.Run("exports the result of the queries as CSV", func(t *testing.T) {
+		body := `{"from": 1704100000000, "to": 1704103600000, "queries": [{
+			"refId": "A", "type": "timeSeriesQuery", "namespace": "AWS/EC2", "metricName": "CPUUtilization", "dimensions": {"InstanceId": "i-1"},
+			"region": "us-east-1", "id": "a", "statistic": "Average", "period": "60", "matchExact": true
+		}]}`
+		rw := httptest.NewRecorder()
+		ds.newResourceMux().ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/export", strings.NewReader(body)))
+
+		require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+		assert.Equal(t, "text/csv", rw.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="cloudwatch-export.csv"`, rw.Header().Get("Content-Disposition"))
+		lines := strings.Split(strings.TrimSpace(rw.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[1], "2024-01-01T10:00:00Z,42")
+	})
+
+	t.Run("exports the queries assuming a role with the role", func(t *testing.T) {
+		provider := &roleRecordingConfigProvider{}
+		roleDs := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = provider
+			ds.Settings.QueryRoleARNs = []string{"arn:aws:iam::222222222222:role/grafana"}
+		})
+		query := func(roleARN string) string {
+			return `{"refId": "A", "type": "timeSeriesQuery", "namespace": "AWS/EC2", "metricName": "CPUUtilization",
+				"region": "us-east-1", "id": "a", "statistic": "Average", "period": "60", "assumeRoleArn": "` + roleARN + `"}`
+		}
+
+		rw := httptest.NewRecorder()
+		roleDs.newResourceMux().ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/export",
+			strings.NewReader(`{"from": 1704100000000, "to": 1704103600000, "queries": [`+query("arn:aws:iam::222222222222:role/grafana")+`]}`)))
+		require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+		assert.Equal(t, []string{"arn:aws:iam::222222222222:role/grafana"}, provider.roles)
+
+		rw = httptest.NewRecorder()
+		roleDs.newResourceMux().ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/export",
+			strings.NewReader(`{"from": 1704100000000, "to": 1704103600000, "queries": [`+query("arn:aws:iam::333333333333:role/admin")+`]}`)))
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Len(t, provider.roles, 1)
+	})
+
+	t.Run("rejects unsupported formats and methods", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		ds.newResourceMux().ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/export", strings.NewReader(`{"format": "xlsx", "queries": [{}]}`)))
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+		rw = httptest.NewRecorder()
+		ds.newResourceMux().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/export", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	})
+}
@@ -0,0 +1,112 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type queryRoleKey struct{}
+
+// withQueryRole stores the role the queries of a request assume in ctx, so newAWSConfig assumes it instead of the
+// data source's role. The config provider caches the credentials of each role and region it's asked for, so the
+// role is only assumed again once its credentials expire.
+func withQueryRole(ctx context.Context, roleARN string) context.Context {
+	if roleARN == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, queryRoleKey{}, roleARN)
+}
+
+func queryRoleFromContext(ctx context.Context) string {
+	roleARN, _ := ctx.Value(queryRoleKey{}).(string)
+	return roleARN
+}
+
+// validateQueryRole returns an error unless queries may assume roleARN. Only the roles listed in the settings may be
+// assumed, so that users editing queries can't reach accounts the data source wasn't set up for, and none when roles
+// are mapped per organization or user, as the listed roles would be reachable by every organization and user.
+func (ds *DataSource) validateQueryRole(roleARN string) error {
+	if len(ds.Settings.OrgRoleMap) > 0 || ds.Settings.UserIdentityPassThrough {
+		return models.ErrQueryRoleWithMappedRoles
+	}
+	if !slices.Contains(ds.Settings.QueryRoleARNs, roleARN) {
+		return models.ErrQueryRoleNotAllowed
+	}
+	return nil
+}
+
+// queryRole returns the role the query assumes, or an empty string if it assumes the data source's role. Queries
+// that can't be parsed assume the data source's role, and fail with its queries.
+func queryRole(query backend.DataQuery) string {
+	var model struct {
+		AssumeRoleARN string `json:"assumeRoleArn"`
+	}
+	_ = json.Unmarshal(query.JSON, &model)
+	return model.AssumeRoleARN
+}
+
+// groupQueriesByRole groups queries by the role they assume, returning the roles in the order of their first query.
+func groupQueriesByRole(queries []backend.DataQuery) ([]string, map[string][]backend.DataQuery) {
+	var roles []string
+	queriesByRole := map[string][]backend.DataQuery{}
+	for _, query := range queries {
+		role := queryRole(query)
+		if _, ok := queriesByRole[role]; !ok {
+			roles = append(roles, role)
+		}
+		queriesByRole[role] = append(queriesByRole[role], query)
+	}
+	return roles, queriesByRole
+}
+
+// withRoleOfQuery returns ctx with the role the query assumes, if it may assume it. Queries executed outside of
+// QueryData, e.g. by streams and exports, are executed with it, so that they read the account of their role too.
+func (ds *DataSource) withRoleOfQuery(ctx context.Context, query backend.DataQuery) (context.Context, error) {
+	return ds.withValidQueryRole(ctx, queryRole(query))
+}
+
+func (ds *DataSource) withValidQueryRole(ctx context.Context, roleARN string) (context.Context, error) {
+	if roleARN == "" {
+		return ctx, nil
+	}
+	if err := ds.validateQueryRole(roleARN); err != nil {
+		return nil, err
+	}
+	return withQueryRole(ctx, roleARN), nil
+}
+
+// queryDataByRole executes the queries of each role they assume in a request of their own, as the AWS config of a
+// request is shared by all of its queries.
+func (ds *DataSource) queryDataByRole(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	roles, queriesByRole := groupQueriesByRole(req.Queries)
+	if len(roles) == 1 && roles[0] == "" {
+		return ds.queryData(ctx, req)
+	}
+
+	resp := backend.NewQueryDataResponse()
+	for _, role := range roles {
+		queries := queriesByRole[role]
+		roleCtx, err := ds.withValidQueryRole(ctx, role)
+		var roleResp *backend.QueryDataResponse
+		if err == nil {
+			roleReq := *req
+			roleReq.Queries = queries
+			roleResp, err = ds.queryData(roleCtx, &roleReq)
+		}
+		if err != nil {
+			for _, query := range queries {
+				resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			}
+			continue
+		}
+		for refId, res := range roleResp.Responses {
+			resp.Responses[refId] = res
+		}
+	}
+	return resp, nil
+}
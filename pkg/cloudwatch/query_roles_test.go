@@ -0,0 +1,92 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsauth"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// roleRecordingConfigProvider records the roles configs are requested for.
+type roleRecordingConfigProvider struct {
+	mu    sync.Mutex
+	roles []string
+}
+
+func (p *roleRecordingConfigProvider) GetConfig(_ context.Context, authSettings awsauth.Settings) (aws.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roles = append(p.roles, authSettings.AssumeRoleARN)
+	return aws.Config{Region: authSettings.Region}, nil
+}
+
+func TestQuery_QueryRoles(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &fakeCWAnnotationsClient{describeAlarmsOutput: &cloudwatch.DescribeAlarmsOutput{}}
+	}
+	const allowedRole = "arn:aws:iam::222222222222:role/grafana"
+	alarmQuery := func(refId, roleARN string) backend.DataQuery {
+		return backend.DataQuery{
+			RefID: refId,
+			JSON: json.RawMessage(`{"queryMode":"Alarms","region":"us-east-1","prefixMatching":true,
+				"assumeRoleArn":"` + roleARN + `"}`),
+		}
+	}
+	queryData := func(t *testing.T, settings func(*models.CloudWatchSettings), queries ...backend.DataQuery) (*backend.QueryDataResponse, []string) {
+		t.Helper()
+		provider := &roleRecordingConfigProvider{}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.AWSConfigProvider = provider
+			ds.Settings.AssumeRoleARN = "arn:aws:iam::111111111111:role/grafana"
+			ds.Settings.QueryRoleARNs = []string{allowedRole}
+			if settings != nil {
+				settings(&ds.Settings)
+			}
+		})
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries:       queries,
+		})
+		require.NoError(t, err)
+		return resp, provider.roles
+	}
+
+	t.Run("queries assume their role, and the others the data source's role", func(t *testing.T) {
+		resp, roles := queryData(t, nil, alarmQuery("A", allowedRole), alarmQuery("B", ""))
+
+		require.NoError(t, resp.Responses["A"].Error)
+		require.NoError(t, resp.Responses["B"].Error)
+		assert.ElementsMatch(t, []string{allowedRole, "arn:aws:iam::111111111111:role/grafana"}, roles)
+	})
+
+	t.Run("fails queries assuming a role that isn't allowed", func(t *testing.T) {
+		resp, roles := queryData(t, nil, alarmQuery("A", "arn:aws:iam::333333333333:role/admin"), alarmQuery("B", ""))
+
+		require.ErrorIs(t, resp.Responses["A"].Error, models.ErrQueryRoleNotAllowed)
+		assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+		require.NoError(t, resp.Responses["B"].Error)
+		assert.Equal(t, []string{"arn:aws:iam::111111111111:role/grafana"}, roles)
+	})
+
+	t.Run("fails query roles when roles are mapped per organization", func(t *testing.T) {
+		resp, roles := queryData(t, func(settings *models.CloudWatchSettings) {
+			settings.OrgRoleMap = map[string]string{"1": "arn:aws:iam::111111111111:role/org-1"}
+		}, alarmQuery("A", allowedRole))
+
+		require.ErrorIs(t, resp.Responses["A"].Error, models.ErrQueryRoleWithMappedRoles)
+		assert.Empty(t, roles)
+	})
+}
@@ -0,0 +1,43 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// scopeQuery restricts a metric query to the dimensions and account the data source is scoped to, overriding the
+// values the query has for them, so that a data source shared by several teams only returns their slice of the
+// metrics of an account. Queries written in code can't be scoped, so they're rejected, except for math expressions
+// only referencing other queries.
+func (ds *DataSource) scopeQuery(query *models.CloudWatchQuery) error {
+	if len(ds.Settings.ScopeDimensions) == 0 && ds.Settings.ScopeAccountId == "" {
+		return nil
+	}
+
+	switch query.GetGetMetricDataAPIMode() {
+	case models.GMDApiModeSQLExpression:
+		return backend.DownstreamError(fmt.Errorf("the data source is scoped to a set of dimensions or an account, so Metric Insights queries can't be run"))
+	case models.GMDApiModeMathExpression:
+		if query.IsUserDefinedSearchExpression() {
+			return backend.DownstreamError(fmt.Errorf("the data source is scoped to a set of dimensions or an account, so search expressions can only be built with the query builder"))
+		}
+		return nil
+	}
+
+	if len(ds.Settings.ScopeDimensions) > 0 {
+		dimensions := maps.Clone(query.Dimensions)
+		if dimensions == nil {
+			dimensions = make(map[string][]string, len(ds.Settings.ScopeDimensions))
+		}
+		maps.Copy(dimensions, ds.Settings.ScopeDimensions)
+		query.Dimensions = dimensions
+	}
+	if ds.Settings.ScopeAccountId != "" {
+		query.AccountId = &ds.Settings.ScopeAccountId
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestScopeQuery(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.ScopeDimensions = map[string][]string{"LoadBalancer": {"prod-lb"}, "AvailabilityZone": {"us-east-1a", "us-east-1b"}}
+		ds.Settings.ScopeAccountId = "123456789012"
+	})
+
+	t.Run("overrides the dimensions and account of builder queries", func(t *testing.T) {
+		query := getBaseQuery()
+		query.MetricEditorMode = models.MetricEditorModeBuilder
+		query.MetricQueryType = models.MetricQueryTypeSearch
+		query.Dimensions = map[string][]string{"LoadBalancer": {"dev-lb"}, "TargetGroup": {"*"}}
+
+		mdq, err := ds.buildMetricDataQuery(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, `REMOVE_EMPTY(SEARCH('{"AWS/EC2","AvailabilityZone","LoadBalancer","TargetGroup"} MetricName="CPUUtilization" "AvailabilityZone"=("us-east-1a" OR "us-east-1b") "LoadBalancer"="prod-lb" :aws.AccountId="123456789012"', '', 300))`, *mdq.Expression)
+		assert.Equal(t, map[string][]string{"LoadBalancer": {"prod-lb"}, "AvailabilityZone": {"us-east-1a", "us-east-1b"}, "TargetGroup": {"*"}}, query.Dimensions)
+		assert.Equal(t, map[string][]string{"LoadBalancer": {"prod-lb"}, "AvailabilityZone": {"us-east-1a", "us-east-1b"}}, ds.Settings.ScopeDimensions)
+	})
+
+	t.Run("adds the dimensions and account to metric stat queries", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.ScopeDimensions = map[string][]string{"ClusterName": {"prod-eks"}}
+			ds.Settings.ScopeAccountId = "123456789012"
+		})
+		query := getBaseQuery()
+		query.MetricEditorMode = models.MetricEditorModeBuilder
+		query.MetricQueryType = models.MetricQueryTypeSearch
+		query.Dimensions = nil
+
+		mdq, err := ds.buildMetricDataQuery(context.Background(), query)
+		require.NoError(t, err)
+		require.NotNil(t, mdq.MetricStat)
+		require.Len(t, mdq.MetricStat.Metric.Dimensions, 1)
+		assert.Equal(t, "ClusterName", *mdq.MetricStat.Metric.Dimensions[0].Name)
+		assert.Equal(t, "prod-eks", *mdq.MetricStat.Metric.Dimensions[0].Value)
+		assert.Equal(t, "123456789012", *mdq.AccountId)
+	})
+
+	t.Run("allows math expressions referencing other queries", func(t *testing.T) {
+		query := getBaseQuery()
+		query.MetricEditorMode = models.MetricEditorModeRaw
+		query.MetricQueryType = models.MetricQueryTypeSearch
+		query.Expression = "m1 * 2"
+
+		mdq, err := ds.buildMetricDataQuery(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, "m1 * 2", *mdq.Expression)
+	})
+
+	t.Run("rejects queries written in code", func(t *testing.T) {
+		query := getBaseQuery()
+		query.MetricEditorMode = models.MetricEditorModeRaw
+		query.MetricQueryType = models.MetricQueryTypeSearch
+		query.Expression = `SEARCH('{AWS/EC2,InstanceId} MetricName="CPUUtilization"', 'Average', 300)`
+		_, err := ds.buildMetricDataQuery(context.Background(), query)
+		assert.ErrorContains(t, err, "search expressions can only be built with the query builder")
+
+		query = getBaseQuery()
+		query.MetricQueryType = models.MetricQueryTypeQuery
+		query.SqlExpression = `SELECT AVG(CPUUtilization) FROM "AWS/EC2"`
+		_, err = ds.buildMetricDataQuery(context.Background(), query)
+		assert.ErrorContains(t, err, "Metric Insights queries can't be run")
+	})
+
+	t.Run("leaves queries of data sources without scope alone", func(t *testing.T) {
+		query := getBaseQuery()
+		query.MetricQueryType = models.MetricQueryTypeQuery
+		query.SqlExpression = `SELECT AVG(CPUUtilization) FROM "AWS/EC2"`
+		_, err := newTestDatasource().buildMetricDataQuery(context.Background(), query)
+		assert.NoError(t, err)
+	})
+}
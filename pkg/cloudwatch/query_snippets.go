@@ -0,0 +1,179 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+// customQuerySnippetService is the service the snippets of the settings are listed under when they don't have one
+const customQuerySnippetService = "Custom"
+
+// querySnippetPlaceholder matches the placeholders of query snippets, e.g. {{statusCode}}
+var querySnippetPlaceholder = regexp.MustCompile(`\{\{(\w+)\}\}`)
+
+// querySnippets are the built-in snippets of the catalog, per AWS service
+var querySnippets = []resources.QuerySnippet{
+	{
+		Id:            "lambda/coldStarts",
+		Name:          "Cold starts",
+		Description:   "Number and duration of the cold starts of the functions.",
+		Service:       "Lambda",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `filter @type = "REPORT" and ispresent(@initDuration) | stats count(*) as coldStarts, avg(@initDuration) as avgInitDuration by bin({{interval}})`,
+	},
+	{
+		Id:            "lambda/overProvisionedMemory",
+		Name:          "Over-provisioned memory",
+		Description:   "Memory the functions were given compared to the memory they used.",
+		Service:       "Lambda",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `filter @type = "REPORT" | stats max(@memorySize / 1000 / 1000) as provisionedMB, max(@maxMemoryUsed / 1000 / 1000) as maxUsedMB, provisionedMB - maxUsedMB as overProvisionedMB`,
+	},
+	{
+		Id:            "apiGateway/statusCodes",
+		Name:          "Requests by status code",
+		Description:   "Requests of an access log by status code.",
+		Service:       "API Gateway",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `stats count(*) as requests by status, bin({{interval}})`,
+	},
+	{
+		Id:            "apiGateway/slowestRequests",
+		Name:          "Slowest requests",
+		Description:   "Requests of an access log that took the longest.",
+		Service:       "API Gateway",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `fields @timestamp, httpMethod, resourcePath, status, responseLatency | sort responseLatency desc | limit {{limit}}`,
+	},
+	{
+		Id:            "cloudTrail/eventsByUser",
+		Name:          "Events of a user",
+		Description:   "API calls made by a user of the account.",
+		Service:       "CloudTrail",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `filter userIdentity.arn like /{{userName}}/ | fields @timestamp, eventSource, eventName, sourceIPAddress, errorCode | sort @timestamp desc`,
+	},
+	{
+		Id:            "cloudTrail/accessDenied",
+		Name:          "Denied API calls",
+		Description:   "API calls denied by IAM, by principal and action.",
+		Service:       "CloudTrail",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `filter errorCode in ["AccessDenied", "AccessDeniedException", "UnauthorizedOperation"] | stats count(*) as denied by userIdentity.arn, eventSource, eventName | sort denied desc`,
+	},
+	{
+		Id:            "ecs/errors",
+		Name:          "Container errors",
+		Description:   "Latest lines of the container logs mentioning an error.",
+		Service:       "ECS",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `filter @message like /(?i)(error|exception|panic)/ | fields @timestamp, @logStream, @message | sort @timestamp desc | limit {{limit}}`,
+	},
+	{
+		Id:            "vpc/rejectedFlows",
+		Name:          "Rejected flows",
+		Description:   "Source addresses of the most rejected flows.",
+		Service:       "VPC",
+		QueryLanguage: string(dataquery.LogsQueryLanguageCWLI),
+		QueryString:   `filter action = "REJECT" | stats count(*) as flows by srcAddr, dstPort | sort flows desc | limit {{limit}}`,
+	},
+	{
+		Id:            "generic/errorsOverTime",
+		Name:          "Errors over time",
+		Description:   "Events matching a pattern, per interval.",
+		Service:       "Generic",
+		QueryLanguage: string(dataquery.LogsQueryLanguageSQL),
+		QueryString:   "SELECT date_trunc('{{unit}}', `@timestamp`) AS time, count(*) AS errors FROM `{{logGroup}}` WHERE `@message` LIKE '%{{pattern}}%' GROUP BY 1 ORDER BY 1",
+	},
+	{
+		Id:            "generic/topMessages",
+		Name:          "Most frequent messages",
+		Description:   "Messages of the log group that occur the most often.",
+		Service:       "Generic",
+		QueryLanguage: string(dataquery.LogsQueryLanguagePPL),
+		QueryString:   "source = `{{logGroup}}` | stats count() as occurrences by `@message` | sort - occurrences | head {{limit}}",
+	},
+}
+
+func init() {
+	for i := range querySnippets {
+		querySnippets[i].Placeholders = querySnippetPlaceholders(querySnippets[i].QueryString)
+	}
+}
+
+// compileQuerySnippets returns the snippets of the settings as snippets of the catalog.
+func compileQuerySnippets(snippets []models.QuerySnippet) ([]resources.QuerySnippet, error) {
+	compiled := make([]resources.QuerySnippet, 0, len(snippets))
+	for i, snippet := range snippets {
+		if snippet.Name == "" {
+			return nil, backend.DownstreamError(fmt.Errorf("query snippet %d: missing name", i+1))
+		}
+		if snippet.QueryString == "" {
+			return nil, backend.DownstreamError(fmt.Errorf("query snippet %d: missing query string", i+1))
+		}
+		language := dataquery.LogsQueryLanguage(snippet.QueryLanguage)
+		switch language {
+		case "":
+			language = dataquery.LogsQueryLanguageCWLI
+		case dataquery.LogsQueryLanguageCWLI, dataquery.LogsQueryLanguageSQL, dataquery.LogsQueryLanguagePPL:
+		default:
+			return nil, backend.DownstreamError(fmt.Errorf("query snippet %d: unknown query language %q", i+1, snippet.QueryLanguage))
+		}
+		service := snippet.Service
+		if service == "" {
+			service = customQuerySnippetService
+		}
+		compiled = append(compiled, resources.QuerySnippet{
+			Id:            "settings/" + strconv.Itoa(i+1),
+			Name:          snippet.Name,
+			Description:   snippet.Description,
+			Service:       service,
+			QueryLanguage: string(language),
+			QueryString:   snippet.QueryString,
+			Placeholders:  querySnippetPlaceholders(snippet.QueryString),
+		})
+	}
+	return compiled, nil
+}
+
+func querySnippetPlaceholders(queryString string) []string {
+	placeholders := []string{}
+	for _, match := range querySnippetPlaceholder.FindAllStringSubmatch(queryString, -1) {
+		if !slices.Contains(placeholders, match[1]) {
+			placeholders = append(placeholders, match[1])
+		}
+	}
+	return placeholders
+}
+
+// QuerySnippetsHandler returns the snippets of the settings of the data source followed by the built-in ones,
+// restricted to the service and query language given by the service and queryLanguage parameters if there are any.
+func (ds *DataSource) QuerySnippetsHandler(_ context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	service, queryLanguage := parameters.Get("service"), parameters.Get("queryLanguage")
+
+	response := []resources.ResourceResponse[resources.QuerySnippet]{}
+	for _, snippet := range slices.Concat(ds.querySnippets, querySnippets) {
+		if service != "" && snippet.Service != service || queryLanguage != "" && snippet.QueryLanguage != queryLanguage {
+			continue
+		}
+		response = append(response, resources.ResourceResponse[resources.QuerySnippet]{Label: snippet.Name, Value: snippet})
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in QuerySnippetsHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
@@ -0,0 +1,82 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func Test_compileQuerySnippets(t *testing.T) {
+	_, err := compileQuerySnippets([]models.QuerySnippet{{QueryString: "fields @message"}})
+	assert.ErrorContains(t, err, "query snippet 1: missing name")
+	_, err = compileQuerySnippets([]models.QuerySnippet{{Name: "Messages"}})
+	assert.ErrorContains(t, err, "query snippet 1: missing query string")
+	_, err = compileQuerySnippets([]models.QuerySnippet{{Name: "Messages", QueryString: "fields @message", QueryLanguage: "KQL"}})
+	assert.ErrorContains(t, err, `query snippet 1: unknown query language "KQL"`)
+
+	snippets, err := compileQuerySnippets([]models.QuerySnippet{
+		{Name: "Orders of a customer", QueryString: `filter customerId = "{{customerId}}" and orderId = "{{orderId}}" or customerId = "{{customerId}}"`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []resources.QuerySnippet{{
+		Id:            "settings/1",
+		Name:          "Orders of a customer",
+		Service:       "Custom",
+		QueryLanguage: "CWLI",
+		QueryString:   `filter customerId = "{{customerId}}" and orderId = "{{orderId}}" or customerId = "{{customerId}}"`,
+		Placeholders:  []string{"customerId", "orderId"},
+	}}, snippets)
+}
+
+func Test_query_snippets_route(t *testing.T) {
+	custom, err := compileQuerySnippets([]models.QuerySnippet{
+		{Name: "Checkout errors", Service: "Payments", QueryString: `filter service = "checkout" and level = "{{level}}"`},
+	})
+	require.NoError(t, err)
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.querySnippets = custom
+	})
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.QuerySnippetsHandler))
+	getSnippets := func(t *testing.T, query string) []resources.ResourceResponse[resources.QuerySnippet] {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/query-snippets"+query, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var snippets []resources.ResourceResponse[resources.QuerySnippet]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &snippets))
+		return snippets
+	}
+
+	t.Run("returns the snippets of the settings before the built-in ones", func(t *testing.T) {
+		snippets := getSnippets(t, "")
+
+		require.Len(t, snippets, len(querySnippets)+1)
+		assert.Equal(t, "Checkout errors", snippets[0].Label)
+		assert.Equal(t, []string{"level"}, snippets[0].Value.Placeholders)
+		assert.Equal(t, querySnippets[0], snippets[1].Value)
+	})
+
+	t.Run("filters the snippets by service", func(t *testing.T) {
+		snippets := getSnippets(t, "?service=Lambda")
+
+		require.Len(t, snippets, 2)
+		assert.Equal(t, "lambda/coldStarts", snippets[0].Value.Id)
+		assert.Equal(t, []string{"interval"}, snippets[0].Value.Placeholders)
+		assert.Equal(t, "lambda/overProvisionedMemory", snippets[1].Value.Id)
+	})
+
+	t.Run("filters the snippets by query language", func(t *testing.T) {
+		snippets := getSnippets(t, "?queryLanguage=PPL")
+
+		require.Len(t, snippets, 1)
+		assert.Equal(t, "generic/topMessages", snippets[0].Value.Id)
+		assert.Equal(t, []string{"logGroup", "limit"}, snippets[0].Value.Placeholders)
+	})
+}
@@ -0,0 +1,85 @@
+package cloudwatch
+
+import (
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// queryStatsMetaKey is the key of the execution stats of a query in the custom metadata of its frames
+const queryStatsMetaKey = "stats"
+
+// metricQueryStats are the execution stats of a metric query, which tell users what the query cost in the query
+// inspector. Queries sharing a GetMetricData request share its calls and pages.
+type metricQueryStats struct {
+	// GetMetricDataCalls is the number of GetMetricData calls made for the query, 0 if its results were cached
+	GetMetricDataCalls int `json:"getMetricDataCalls"`
+	// Pages is the number of pages of the GetMetricData responses the results of the query were read from
+	Pages int `json:"pages"`
+	// Datapoints is the number of datapoints returned for the query
+	Datapoints int `json:"datapoints"`
+}
+
+func (s metricQueryStats) add(other metricQueryStats) metricQueryStats {
+	return metricQueryStats{
+		GetMetricDataCalls: s.GetMetricDataCalls + other.GetMetricDataCalls,
+		Pages:              s.Pages + other.Pages,
+		Datapoints:         s.Datapoints + other.Datapoints,
+	}
+}
+
+// newMetricQueryStats returns the stats of the query of a response row, read from a GetMetricData request that
+// took the given number of calls.
+func newMetricQueryStats(response models.QueryRowResponse, calls int) metricQueryStats {
+	stats := metricQueryStats{GetMetricDataCalls: calls, Pages: response.Pages}
+	for _, metric := range response.Metrics {
+		stats.Datapoints += len(metric.Values)
+	}
+	return stats
+}
+
+// logsQueryStats are the statistics of a logs query as reported by GetQueryResults
+type logsQueryStats struct {
+	RecordsScanned float64 `json:"recordsScanned"`
+	RecordsMatched float64 `json:"recordsMatched"`
+	BytesScanned   float64 `json:"bytesScanned"`
+}
+
+func newLogsQueryStats(statistics *cloudwatchlogstypes.QueryStatistics) logsQueryStats {
+	return logsQueryStats{
+		RecordsScanned: statistics.RecordsScanned,
+		RecordsMatched: statistics.RecordsMatched,
+		BytesScanned:   statistics.BytesScanned,
+	}
+}
+
+// setQueryStats sets the execution stats of a query in the custom metadata of its frames.
+func setQueryStats(frames data.Frames, stats any) {
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		if frame.Meta.Custom == nil {
+			frame.Meta.Custom = map[string]any{}
+		}
+		if custom, ok := frame.Meta.Custom.(map[string]any); ok {
+			custom[queryStatsMetaKey] = stats
+		}
+	}
+}
+
+// metricQueryStatsOf returns the execution stats of a metric query set in the metadata of its frames.
+func metricQueryStatsOf(frames data.Frames) (metricQueryStats, bool) {
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			continue
+		}
+		if custom, ok := frame.Meta.Custom.(map[string]any); ok {
+			if stats, ok := custom[queryStatsMetaKey].(metricQueryStats); ok {
+				return stats, true
+			}
+		}
+	}
+	return metricQueryStats{}, false
+}
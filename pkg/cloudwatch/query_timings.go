@@ -0,0 +1,175 @@
+package cloudwatch
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// queryTimingsMetaKey is the key of the timings in the custom metadata of the frames
+const queryTimingsMetaKey = "timings"
+
+type queryTimingsKey struct{}
+
+type apiCallTimingKey struct{}
+
+// maxRecordedAPICalls bounds the API calls whose details are kept for the slow query log of a request
+const maxRecordedAPICalls = 50
+
+// queryTimings accumulates how long the AWS API calls made for a request took, and how long the request waited
+// before calls could be made, on internal limiters or on the SDK backing off from throttled and failed attempts. It
+// tells users whether CloudWatch was slow or the plugin held their queries back.
+type queryTimings struct {
+	mu        sync.Mutex
+	queueWait time.Duration
+	apiTime   time.Duration
+	apiCalls  int
+	// apiCallBudget is the number of AWS API calls the request may make, 0 if unlimited
+	apiCallBudget int
+	// calls are the details of the first maxRecordedAPICalls calls, logged if the request turns out to be slow
+	calls []apiCallRecord
+}
+
+// apiCallRecord is an AWS API call made for a request, as logged for slow queries
+type apiCallRecord struct {
+	Service    string `json:"service"`
+	Operation  string `json:"operation"`
+	Region     string `json:"region"`
+	DurationMs int64  `json:"durationMs"`
+	RequestId  string `json:"requestId,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// apiCallTiming is how long the attempts of a single AWS API call took
+type apiCallTiming struct {
+	attempts time.Duration
+}
+
+func withQueryTimings(ctx context.Context) (context.Context, *queryTimings) {
+	timings := &queryTimings{}
+	return context.WithValue(ctx, queryTimingsKey{}, timings), timings
+}
+
+func queryTimingsFromContext(ctx context.Context) *queryTimings {
+	timings, _ := ctx.Value(queryTimingsKey{}).(*queryTimings)
+	return timings
+}
+
+// recordQueueWait records time the request spent waiting before it could make an AWS API call.
+func recordQueueWait(ctx context.Context, wait time.Duration) {
+	timings := queryTimingsFromContext(ctx)
+	if timings == nil {
+		return
+	}
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	timings.queueWait += wait
+}
+
+func recordAPICall(ctx context.Context, attempts time.Duration, call apiCallRecord) {
+	timings := queryTimingsFromContext(ctx)
+	if timings == nil {
+		return
+	}
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	timings.apiTime += attempts
+	timings.apiCalls++
+	if len(timings.calls) < maxRecordedAPICalls {
+		timings.calls = append(timings.calls, call)
+	}
+}
+
+// withQueryTiming returns a copy of cfg whose clients record how long their calls took in the timings of the request
+// they're made for. Time spent on a call outside of its attempts, backing off between retries or waiting for the
+// retry rate limiter, counts as queue wait.
+func withQueryTiming(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(slices.Clone(cfg.APIOptions), func(stack *middleware.Stack) error {
+		if err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("QueryTiming",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				if queryTimingsFromContext(ctx) == nil {
+					return next.HandleInitialize(ctx, in)
+				}
+				call := &apiCallTiming{}
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(context.WithValue(ctx, apiCallTimingKey{}, call), in)
+				record := apiCallRecord{
+					Service:    awsmiddleware.GetServiceID(ctx),
+					Operation:  awsmiddleware.GetOperationName(ctx),
+					Region:     awsmiddleware.GetRegion(ctx),
+					DurationMs: call.attempts.Milliseconds(),
+				}
+				record.RequestId, _ = awsmiddleware.GetRequestIDMetadata(metadata)
+				if err != nil {
+					record.Error = err.Error()
+				}
+				recordAPICall(ctx, call.attempts, record)
+				recordQueueWait(ctx, max(time.Since(start)-call.attempts, 0))
+				return out, metadata, err
+			}), middleware.After); err != nil {
+			return err
+		}
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("QueryAttemptTiming",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, metadata, err := next.HandleFinalize(ctx, in)
+				if call, ok := ctx.Value(apiCallTimingKey{}).(*apiCallTiming); ok {
+					call.attempts += time.Since(start)
+				}
+				return out, metadata, err
+			}), "Retry", middleware.After)
+	})
+	return cfg
+}
+
+// withQueryTimingsMeta returns the response with the timings of the request added to the custom metadata of its
+// frames. Responses are copied rather than modified, as their frames may be cached. Requests that didn't call AWS
+// have no timings to report and are returned as they are.
+func withQueryTimingsMeta(resp *backend.QueryDataResponse, timings *queryTimings) *backend.QueryDataResponse {
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	if resp == nil || timings.apiCalls == 0 {
+		return resp
+	}
+	meta := map[string]any{
+		"queueWaitMs": timings.queueWait.Milliseconds(),
+		"awsCallMs":   timings.apiTime.Milliseconds(),
+		"awsCalls":    timings.apiCalls,
+	}
+	if timings.apiCallBudget > 0 {
+		meta["awsCallBudget"] = timings.apiCallBudget
+	}
+
+	result := backend.NewQueryDataResponse()
+	for refId, response := range resp.Responses {
+		frames := make(data.Frames, 0, len(response.Frames))
+		for _, frame := range response.Frames {
+			frameCopy := *frame
+			frameMeta := data.FrameMeta{}
+			if frame.Meta != nil {
+				frameMeta = *frame.Meta
+			}
+			switch custom := frameMeta.Custom.(type) {
+			case nil:
+				frameMeta.Custom = map[string]any{queryTimingsMetaKey: meta}
+			case map[string]any:
+				customCopy := maps.Clone(custom)
+				customCopy[queryTimingsMetaKey] = meta
+				frameMeta.Custom = customCopy
+			}
+			frameCopy.Meta = &frameMeta
+			frames = append(frames, &frameCopy)
+		}
+		response.Frames = frames
+		result.Responses[refId] = response
+	}
+	return result
+}
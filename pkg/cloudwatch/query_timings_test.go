@@ -0,0 +1,91 @@
+package cloudwatch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// throttlingHTTPClient throttles the first request and answers the following ones with an empty JSON object.
+type throttlingHTTPClient struct {
+	requests int
+}
+
+func (c *throttlingHTTPClient) Do(*http.Request) (*http.Response, error) {
+	c.requests++
+	if c.requests == 1 {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"X-Amzn-Errortype": []string{"ThrottlingException"}},
+			Body:       io.NopCloser(strings.NewReader(`{"__type":"ThrottlingException","message":"Rate exceeded"}`)),
+		}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+}
+
+func Test_withQueryTiming(t *testing.T) {
+	httpClient := &throttlingHTTPClient{}
+	cfg := withQueryTiming(aws.Config{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		HTTPClient:       httpClient,
+		RetryMaxAttempts: 2,
+	})
+	ctx, timings := withQueryTimings(context.Background())
+
+	_, err := cloudwatchlogs.NewFromConfig(cfg).DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, httpClient.requests)
+	assert.Equal(t, 1, timings.apiCalls)
+	require.Len(t, timings.calls, 1)
+	assert.Equal(t, "CloudWatch Logs", timings.calls[0].Service)
+	assert.Equal(t, "DescribeLogGroups", timings.calls[0].Operation)
+	assert.Equal(t, "us-east-1", timings.calls[0].Region)
+	// the retry backs off from the throttled attempt before making the second one
+	assert.Greater(t, timings.queueWait, time.Duration(0))
+
+	t.Run("calls made outside of a request aren't timed", func(t *testing.T) {
+		_, err := cloudwatchlogs.NewFromConfig(cfg).DescribeLogGroups(context.Background(), &cloudwatchlogs.DescribeLogGroupsInput{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, timings.apiCalls)
+	})
+}
+
+func Test_withQueryTimingsMeta(t *testing.T) {
+	frame := data.NewFrame("logs")
+	frame.Meta = &data.FrameMeta{Custom: map[string]any{"Status": "Complete"}}
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame, data.NewFrame("series")}}}}
+
+	t.Run("requests without AWS calls are returned as they are", func(t *testing.T) {
+		assert.Same(t, resp, withQueryTimingsMeta(resp, &queryTimings{}))
+	})
+
+	t.Run("timings are added to copies of the frames", func(t *testing.T) {
+		result := withQueryTimingsMeta(resp, &queryTimings{queueWait: 1500 * time.Millisecond, apiTime: 250 * time.Millisecond, apiCalls: 3})
+
+		timings := map[string]any{"queueWaitMs": int64(1500), "awsCallMs": int64(250), "awsCalls": 3}
+		frames := result.Responses["A"].Frames
+		assert.Equal(t, map[string]any{"Status": "Complete", "timings": timings}, frames[0].Meta.Custom)
+		assert.Equal(t, map[string]any{"timings": timings}, frames[1].Meta.Custom)
+		assert.Equal(t, map[string]any{"Status": "Complete"}, frame.Meta.Custom)
+	})
+
+	t.Run("the per-request budget is added when set", func(t *testing.T) {
+		result := withQueryTimingsMeta(resp, &queryTimings{apiCalls: 3, apiCallBudget: 10})
+
+		timings := result.Responses["A"].Frames[1].Meta.Custom.(map[string]any)["timings"].(map[string]any)
+		assert.Equal(t, 3, timings["awsCalls"])
+		assert.Equal(t, 10, timings["awsCallBudget"])
+	})
+}
@@ -0,0 +1,132 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// readOnlyResourceRoutes are the resource routes that may be served when the data source is
+// in read-only mode. Any route not listed here, including routes added in the future, is rejected.
+var readOnlyResourceRoutes = []string{
+	"/ebs-volume-ids",
+	"/ec2-instance-attribute",
+	"/resource-arns",
+	"/log-groups",
+	"/metrics",
+	"/dimension-values",
+	"/dimension-keys",
+	"/accounts",
+	"/namespaces",
+	"/log-group-fields",
+	"/query-definitions",
+	"/query-snippets",
+	"/running-logs-queries",
+	"/log-context",
+	"/external-id",
+	"/regions",
+	"/discovery",
+	"/anomaly-detectors",
+	"/insight-rules",
+	"/application-signals-services",
+	"/service-level-objectives",
+	"/metric-metadata",
+	"/lambda-insights-presets",
+	"/eks-control-plane-presets",
+	"/waf-presets",
+	"/resolver-query-log-presets",
+	"/export",
+	"/cache/invalidate",
+	"/legacy-log-groups",
+}
+
+// readOnlyAPIs are the AWS API operations, keyed by service ID, that may be called when the
+// data source is in read-only mode. Starting and stopping Logs Insights queries is allowed as it
+// doesn't modify any resources.
+var readOnlyAPIs = map[string][]string{
+	"Application Signals": {
+		"ListServiceLevelObjectives",
+		"ListServices",
+	},
+	"CloudWatch": {
+		"DescribeAlarmHistory",
+		"DescribeAlarms",
+		"DescribeAlarmsForMetric",
+		"DescribeAnomalyDetectors",
+		"DescribeInsightRules",
+		"GetInsightRuleReport",
+		"GetMetricData",
+		"ListMetrics",
+	},
+	"CloudWatch Logs": {
+		"DescribeLogGroups",
+		"DescribeQueryDefinitions",
+		"FilterLogEvents",
+		"GetLogEvents",
+		"GetLogGroupFields",
+		"GetLogRecord",
+		"GetQueryResults",
+		"StartLiveTail",
+		"StartQuery",
+		"StopQuery",
+	},
+	"EC2": {
+		"DescribeInstances",
+		"DescribeRegions",
+	},
+	"OAM": {
+		"ListAttachedLinks",
+		"ListSinks",
+	},
+	"Resource Groups Tagging API": {
+		"GetResources",
+	},
+	"Service Quotas": {
+		"ListServiceQuotas",
+	},
+	"STS": {
+		"AssumeRole",
+		"AssumeRoleWithWebIdentity",
+		"GetCallerIdentity",
+	},
+}
+
+func isReadOnlyAPI(serviceID, operation string) bool {
+	return slices.Contains(readOnlyAPIs[serviceID], operation)
+}
+
+// readOnlyRouteGuard rejects requests to resource routes that are not known to be read-only
+// when the data source is in read-only mode.
+func (ds *DataSource) readOnlyRouteGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if ds.Settings.ReadOnly && !slices.Contains(readOnlyResourceRoutes, req.URL.Path) {
+			respondWithError(rw, models.NewHttpError("Route not allowed", http.StatusForbidden,
+				fmt.Errorf("%s is not allowed in read-only mode", req.URL.Path)))
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// withReadOnlyAPIGuard returns a copy of cfg whose clients fail any AWS API call that is not in readOnlyAPIs.
+func withReadOnlyAPIGuard(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(slices.Clone(cfg.APIOptions), func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ReadOnlyAPIGuard",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				serviceID, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+				if !isReadOnlyAPI(serviceID, operation) {
+					return middleware.InitializeOutput{}, middleware.Metadata{},
+						backend.DownstreamError(fmt.Errorf("%s %s is not allowed in read-only mode", serviceID, operation))
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.After)
+	})
+	return cfg
+}
@@ -0,0 +1,158 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_readOnlyAPIs(t *testing.T) {
+	t.Run("allows only the read APIs the data source uses", func(t *testing.T) {
+		assert.Equal(t, map[string][]string{
+			"Application Signals":         {"ListServiceLevelObjectives", "ListServices"},
+			"CloudWatch":                  {"DescribeAlarmHistory", "DescribeAlarms", "DescribeAlarmsForMetric", "DescribeAnomalyDetectors", "DescribeInsightRules", "GetInsightRuleReport", "GetMetricData", "ListMetrics"},
+			"CloudWatch Logs":             {"DescribeLogGroups", "DescribeQueryDefinitions", "FilterLogEvents", "GetLogEvents", "GetLogGroupFields", "GetLogRecord", "GetQueryResults", "StartLiveTail", "StartQuery", "StopQuery"},
+			"EC2":                         {"DescribeInstances", "DescribeRegions"},
+			"OAM":                         {"ListAttachedLinks", "ListSinks"},
+			"Resource Groups Tagging API": {"GetResources"},
+			"Service Quotas":              {"ListServiceQuotas"},
+			"STS":                         {"AssumeRole", "AssumeRoleWithWebIdentity", "GetCallerIdentity"},
+		}, readOnlyAPIs)
+	})
+
+	t.Run("rejects mutating APIs", func(t *testing.T) {
+		for _, api := range []struct{ service, operation string }{
+			{"CloudWatch Logs", "PutQueryDefinition"},
+			{"CloudWatch Logs", "CreateExportTask"},
+			{"CloudWatch Logs", "DeleteLogGroup"},
+			{"CloudWatch", "PutMetricData"},
+			{"CloudWatch", "PutMetricAlarm"},
+			{"OAM", "CreateLink"},
+		} {
+			assert.False(t, isReadOnlyAPI(api.service, api.operation), "%s %s", api.service, api.operation)
+		}
+	})
+}
+
+type failingHTTPClient struct{}
+
+var errReachedTransport = errors.New("reached transport")
+
+func (failingHTTPClient) Do(*http.Request) (*http.Response, error) {
+	return nil, errReachedTransport
+}
+
+func Test_withReadOnlyAPIGuard(t *testing.T) {
+	cfg := withReadOnlyAPIGuard(aws.Config{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		HTTPClient:       failingHTTPClient{},
+		RetryMaxAttempts: 1,
+	})
+
+	t.Run("blocks mutating calls before they are sent", func(t *testing.T) {
+		_, err := cloudwatchlogs.NewFromConfig(cfg).PutQueryDefinition(context.Background(), &cloudwatchlogs.PutQueryDefinitionInput{
+			Name:        aws.String("query"),
+			QueryString: aws.String("fields @message"),
+		})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "CloudWatch Logs PutQueryDefinition is not allowed in read-only mode")
+		assert.NotErrorIs(t, err, errReachedTransport)
+	})
+
+	t.Run("lets read calls through", func(t *testing.T) {
+		_, err := cloudwatch.NewFromConfig(cfg).ListMetrics(context.Background(), &cloudwatch.ListMetricsInput{})
+		assert.ErrorIs(t, err, errReachedTransport)
+	})
+
+	t.Run("lets live tails through", func(t *testing.T) {
+		_, err := cloudwatchlogs.NewFromConfig(cfg).StartLiveTail(context.Background(), &cloudwatchlogs.StartLiveTailInput{
+			LogGroupIdentifiers: []string{"arn:aws:logs:us-east-1:123456789012:log-group:api"},
+		})
+		assert.ErrorIs(t, err, errReachedTransport)
+	})
+
+	t.Run("lets quota lookups through", func(t *testing.T) {
+		_, err := servicequotas.NewFromConfig(cfg).ListServiceQuotas(context.Background(), &servicequotas.ListServiceQuotasInput{
+			ServiceCode: aws.String(logsServiceCode),
+		})
+		assert.ErrorIs(t, err, errReachedTransport)
+	})
+
+	t.Run("does not modify the original config", func(t *testing.T) {
+		original := aws.Config{}
+		_ = withReadOnlyAPIGuard(original)
+		assert.Empty(t, original.APIOptions)
+	})
+}
+
+func TestQuery_readOnly_LogFilterMode(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+			o.Credentials = aws.AnonymousCredentials{}
+			o.HTTPClient = failingHTTPClient{}
+			o.RetryMaxAttempts = 1
+		})
+	}
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.ReadOnly = true
+		ds.AWSConfigProvider = regionConfigProvider{}
+	})
+
+	resp, err := ds.QueryData(context.Background(), filterQueryRequest(`{
+		"queryMode": "Logs",
+		"logsMode": "Filter",
+		"region": "us-east-1",
+		"expression": "ERROR",
+		"logGroupNames": ["api"]
+	}`))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, resp.Responses["A"].Error, errReachedTransport, "filter queries are allowed in read-only mode")
+}
+
+func Test_readOnlyRouteGuard(t *testing.T) {
+	t.Run("rejects routes that are not known to be read-only", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.ReadOnly = true
+		})
+		rr := httptest.NewRecorder()
+		ds.newResourceMux().ServeHTTP(rr, httptest.NewRequest("POST", "/put-query-definition?region=us-east-1", nil))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.JSONEq(t, `{"Message":"Route not allowed: /put-query-definition is not allowed in read-only mode","Error":"/put-query-definition is not allowed in read-only mode","StatusCode":403}`, rr.Body.String())
+	})
+
+	t.Run("serves read-only routes", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.ReadOnly = true
+		})
+		rr := httptest.NewRecorder()
+		ds.newResourceMux().ServeHTTP(rr, httptest.NewRequest("GET", "/external-id", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("does not restrict routes when read-only mode is off", func(t *testing.T) {
+		ds := newTestDatasource()
+		rr := httptest.NewRecorder()
+		ds.newResourceMux().ServeHTTP(rr, httptest.NewRequest("POST", "/delete-query-definition?region=us-east-1", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
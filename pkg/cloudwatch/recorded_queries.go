@@ -0,0 +1,167 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	defaultRecordedQueryInterval  = 5 * time.Minute
+	defaultRecordedQueryTimeRange = time.Hour
+	// minRecordedQueryInterval keeps recorded queries from scanning the log groups more often than a dashboard would
+	minRecordedQueryInterval = time.Minute
+)
+
+type recordedQueryResult struct {
+	frames     data.Frames
+	err        error
+	recordedAt time.Time
+	timeRange  backend.TimeRange
+}
+
+// recordedQueries holds the last result of each recorded query.
+type recordedQueries struct {
+	mu      sync.RWMutex
+	results map[string]recordedQueryResult
+	stop    context.CancelFunc
+}
+
+// startRecordedQueries executes each recorded query right away and then once per interval, until the data source is
+// disposed of.
+func (ds *DataSource) startRecordedQueries() {
+	ctx, stop := context.WithCancel(context.Background())
+	ds.recordedQueries = &recordedQueries{results: map[string]recordedQueryResult{}, stop: stop}
+
+	for _, recordedQuery := range ds.Settings.RecordedQueries {
+		go func() {
+			ticker := time.NewTicker(recordedQueryInterval(recordedQuery))
+			defer ticker.Stop()
+			for {
+				opCtx, done, err := ds.operations.begin(ctx)
+				if err != nil {
+					return
+				}
+				ds.recordQuery(opCtx, recordedQuery, time.Now())
+				done()
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+func recordedQueryInterval(recordedQuery models.RecordedQuery) time.Duration {
+	if recordedQuery.Interval.Duration == 0 {
+		return defaultRecordedQueryInterval
+	}
+	return max(recordedQuery.Interval.Duration, minRecordedQueryInterval)
+}
+
+// recordQuery executes the recorded query over the time range ending at now, and stores its result.
+func (ds *DataSource) recordQuery(ctx context.Context, recordedQuery models.RecordedQuery, now time.Time) {
+	lookback := recordedQuery.TimeRange.Duration
+	if lookback == 0 {
+		lookback = defaultRecordedQueryTimeRange
+	}
+	timeRange := backend.TimeRange{From: now.Add(-lookback), To: now}
+
+	result := recordedQueryResult{recordedAt: now, timeRange: timeRange}
+	queryJSON, err := json.Marshal(dataquery.CloudWatchLogsQuery{
+		QueryMode:     dataquery.CloudWatchQueryModeLogs,
+		Region:        recordedQuery.Region,
+		Expression:    &recordedQuery.Expression,
+		LogGroupNames: recordedQuery.LogGroupNames,
+		StatsGroups:   recordedQuery.StatsGroups,
+		RefId:         recordedQuery.Name,
+	})
+	if err == nil {
+		var resp *backend.QueryDataResponse
+		resp, err = executeSyncLogQuery(ctx, ds, &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: recordedQuery.Name, TimeRange: timeRange, JSON: queryJSON}},
+		})
+		if err == nil {
+			response := resp.Responses[recordedQuery.Name]
+			result.frames, err = response.Frames, response.Error
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		ds.logger.Warn("Recorded query failed", "name", recordedQuery.Name, "error", err)
+	}
+
+	ds.recordedQueries.mu.Lock()
+	defer ds.recordedQueries.mu.Unlock()
+	if err != nil {
+		if previous, ok := ds.recordedQueries.results[recordedQuery.Name]; ok && previous.err == nil {
+			// keep serving the last successful result, it will be retried on the next interval
+			return
+		}
+	}
+	ds.recordedQueries.results[recordedQuery.Name] = result
+}
+
+// executeRecordedQueries serves the last result of the recorded queries referenced by the queries of the request.
+func (ds *DataSource) executeRecordedQueries(_ context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		var model DataQueryJson
+		if err := json.Unmarshal(query.JSON, &model); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+		resp.Responses[query.RefID] = ds.recordedQueryResponse(model.RecordedQuery, query.RefID)
+	}
+	return resp, nil
+}
+
+func (ds *DataSource) recordedQueryResponse(name string, refId string) backend.DataResponse {
+	if ds.recordedQueries == nil {
+		return backend.ErrorResponseWithErrorSource(backend.DownstreamError(fmt.Errorf("recorded query %q is not configured", name)))
+	}
+	ds.recordedQueries.mu.RLock()
+	result, ok := ds.recordedQueries.results[name]
+	ds.recordedQueries.mu.RUnlock()
+	if !ok {
+		for _, recordedQuery := range ds.Settings.RecordedQueries {
+			if recordedQuery.Name == name {
+				return backend.ErrorResponseWithErrorSource(backend.DownstreamError(fmt.Errorf("recorded query %q hasn't completed its first run yet", name)))
+			}
+		}
+		return backend.ErrorResponseWithErrorSource(backend.DownstreamError(fmt.Errorf("recorded query %q is not configured", name)))
+	}
+	if result.err != nil {
+		return backend.ErrorResponseWithErrorSource(backend.DownstreamError(fmt.Errorf("recorded query %q failed: %w", name, result.err)))
+	}
+
+	notice := data.Notice{
+		Severity: data.NoticeSeverityInfo,
+		Text: fmt.Sprintf("Recorded at %s over %s to %s; the panel's time range isn't applied to recorded queries.",
+			result.recordedAt.UTC().Format(time.RFC3339), result.timeRange.From.UTC().Format(time.RFC3339), result.timeRange.To.UTC().Format(time.RFC3339)),
+	}
+	frames := make(data.Frames, 0, len(result.frames))
+	for _, frame := range result.frames {
+		frameCopy := *frame
+		frameCopy.RefID = refId
+		meta := data.FrameMeta{}
+		if frame.Meta != nil {
+			meta = *frame.Meta
+		}
+		meta.Notices = append(append([]data.Notice{}, meta.Notices...), notice)
+		frameCopy.Meta = &meta
+		frames = append(frames, &frameCopy)
+	}
+	return backend.DataResponse{Frames: frames}
+}
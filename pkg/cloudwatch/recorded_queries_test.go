@@ -0,0 +1,140 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubExecuteSyncLogQuery(t *testing.T, stub func(req *backend.QueryDataRequest) (*backend.QueryDataResponse, error)) {
+	t.Helper()
+	origExecuteSyncLogQuery := executeSyncLogQuery
+	t.Cleanup(func() {
+		executeSyncLogQuery = origExecuteSyncLogQuery
+	})
+	executeSyncLogQuery = func(_ context.Context, _ *DataSource, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		return stub(req)
+	}
+}
+
+func recordedQueryRequest(refId, name string) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{Queries: []backend.DataQuery{{
+		RefID: refId,
+		JSON:  json.RawMessage(`{"queryMode":"Logs","recordedQuery":"` + name + `"}`),
+	}}}
+}
+
+func TestRecordedQueries(t *testing.T) {
+	recordedQuery := models.RecordedQuery{
+		Name:          "errors",
+		Region:        "us-east-1",
+		LogGroupNames: []string{"/aws/lambda/checkout"},
+		Expression:    "filter @message like /ERROR/ | stats count(*) by bin(5m)",
+		TimeRange:     models.Duration{Duration: 24 * time.Hour},
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("executes the query over its time range and serves the result", func(t *testing.T) {
+		var executed *backend.QueryDataRequest
+		stubExecuteSyncLogQuery(t, func(req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			executed = req
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["errors"] = backend.DataResponse{Frames: data.Frames{data.NewFrame("errors", data.NewField("count", nil, []float64{3}))}}
+			return resp, nil
+		})
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.RecordedQueries = []models.RecordedQuery{recordedQuery}
+			ds.recordedQueries = &recordedQueries{results: map[string]recordedQueryResult{}}
+		})
+
+		ds.recordQuery(context.Background(), recordedQuery, now)
+
+		require.NotNil(t, executed)
+		require.Len(t, executed.Queries, 1)
+		assert.Equal(t, backend.TimeRange{From: now.Add(-24 * time.Hour), To: now}, executed.Queries[0].TimeRange)
+		assert.JSONEq(t, `{"queryMode":"Logs","id":"","region":"us-east-1","refId":"errors","expression":"filter @message like /ERROR/ | stats count(*) by bin(5m)","logGroupNames":["/aws/lambda/checkout"]}`,
+			string(executed.Queries[0].JSON))
+
+		resp, err := ds.QueryData(context.Background(), recordedQueryRequest("A", "errors"))
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		frame := resp.Responses["A"].Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		require.Len(t, frame.Meta.Notices, 1)
+		assert.Contains(t, frame.Meta.Notices[0].Text, "Recorded at 2024-01-01T12:00:00Z")
+	})
+
+	t.Run("keeps serving the last successful result when a run fails", func(t *testing.T) {
+		fail := false
+		stubExecuteSyncLogQuery(t, func(req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			if fail {
+				return nil, errors.New("throttled")
+			}
+			resp := backend.NewQueryDataResponse()
+			resp.Responses["errors"] = backend.DataResponse{Frames: data.Frames{data.NewFrame("errors")}}
+			return resp, nil
+		})
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.recordedQueries = &recordedQueries{results: map[string]recordedQueryResult{}}
+		})
+
+		ds.recordQuery(context.Background(), recordedQuery, now)
+		fail = true
+		ds.recordQuery(context.Background(), recordedQuery, now.Add(time.Minute))
+
+		response := ds.recordedQueryResponse("errors", "A")
+		require.NoError(t, response.Error)
+		assert.Contains(t, response.Frames[0].Meta.Notices[0].Text, "Recorded at 2024-01-01T12:00:00Z")
+	})
+
+	t.Run("reports queries that aren't configured or haven't run yet", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.RecordedQueries = []models.RecordedQuery{recordedQuery}
+			ds.recordedQueries = &recordedQueries{results: map[string]recordedQueryResult{}}
+		})
+
+		response := ds.recordedQueryResponse("errors", "A")
+		require.Error(t, response.Error)
+		assert.Contains(t, response.Error.Error(), "hasn't completed its first run yet")
+		assert.Equal(t, backend.ErrorSourceDownstream, response.ErrorSource)
+
+		response = ds.recordedQueryResponse("unknown", "A")
+		require.Error(t, response.Error)
+		assert.Contains(t, response.Error.Error(), "is not configured")
+	})
+
+	t.Run("runs the queries in the background until disposed", func(t *testing.T) {
+		var runs atomic.Int32
+		stubExecuteSyncLogQuery(t, func(req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			runs.Add(1)
+			return backend.NewQueryDataResponse(), nil
+		})
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.RecordedQueries = []models.RecordedQuery{recordedQuery}
+		})
+
+		ds.startRecordedQueries()
+		t.Cleanup(ds.Dispose)
+
+		assert.Eventually(t, func() bool {
+			_, err := ds.QueryData(context.Background(), recordedQueryRequest("A", "errors"))
+			return err == nil && runs.Load() == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func Test_recordedQueryInterval(t *testing.T) {
+	assert.Equal(t, defaultRecordedQueryInterval, recordedQueryInterval(models.RecordedQuery{}))
+	assert.Equal(t, minRecordedQueryInterval, recordedQueryInterval(models.RecordedQuery{Interval: models.Duration{Duration: time.Second}}))
+	assert.Equal(t, time.Hour, recordedQueryInterval(models.RecordedQuery{Interval: models.Duration{Duration: time.Hour}}))
+}
@@ -0,0 +1,90 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+)
+
+// regionDisabledErrorCode is the code STS fails with in regions the account didn't opt in to
+const regionDisabledErrorCode = "RegionDisabledException"
+
+// invalidTokenErrorCodes are the codes AWS APIs reject the credentials of a call with. Regions the account didn't opt
+// in to reject every token, so they're only told apart from invalid credentials by the opt-in status of the region.
+var invalidTokenErrorCodes = []string{"InvalidClientTokenId", "UnrecognizedClientException", "AuthFailure"}
+
+// isRegionNotEnabledError returns whether err is an AWS API call failing because the account didn't opt in to the
+// region it was made in.
+func (ds *DataSource) isRegionNotEnabledError(ctx context.Context, err error, region string) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.ErrorCode() == regionDisabledErrorCode {
+		return true
+	}
+	if !slices.Contains(invalidTokenErrorCodes, apiErr.ErrorCode()) {
+		return false
+	}
+	optInStatus, ok := ds.regionOptInStatus(ctx, region)
+	return ok && optInStatus == services.OptInStatusNotOptedIn
+}
+
+type regionOptInLookupKey struct{}
+
+// regionOptInStatus returns the opt-in status of region in the account of the request, describing the regions of
+// the account if they aren't cached yet.
+func (ds *DataSource) regionOptInStatus(ctx context.Context, region string) (string, bool) {
+	regionsCacheKey, err := ds.regionsCacheKey(ctx)
+	if err != nil {
+		return "", false
+	}
+	if optInStatus, ok := services.CachedOptInStatus(ds.regionsCache, regionsCacheKey, region); ok {
+		return optInStatus, true
+	}
+
+	// the calls describing the regions aren't checked themselves, so that their failing doesn't describe them again
+	lookupCtx := context.WithValue(ctx, regionOptInLookupKey{}, true)
+	service, err := ds.GetRegionsService(lookupCtx, defaultRegion)
+	if err != nil {
+		return "", false
+	}
+	regions, err := service.GetRegions(lookupCtx)
+	if err != nil {
+		return "", false
+	}
+	for _, r := range regions {
+		if r.Value.Name == region {
+			return r.Value.OptInStatus, true
+		}
+	}
+	return "", false
+}
+
+// withRegionNotEnabledErrors returns a copy of cfg whose clients fail AWS API calls made in a region the account
+// didn't opt in to with an error saying so, rather than with the credentials error AWS returns for them.
+func (ds *DataSource) withRegionNotEnabledErrors(cfg aws.Config) aws.Config {
+	region := cfg.Region
+	cfg.APIOptions = append(slices.Clone(cfg.APIOptions), func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("RegionNotEnabled",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				out, metadata, err := next.HandleInitialize(ctx, in)
+				if err != nil && ctx.Value(regionOptInLookupKey{}) == nil && ds.isRegionNotEnabledError(ctx, err, region) {
+					err = backend.DownstreamError(fmt.Errorf(
+						"%w: %s is an opt-in region the AWS account hasn't enabled; enable it in the account settings or choose another region: %w",
+						models.ErrRegionNotEnabled, region, err))
+				}
+				return out, metadata, err
+			}), middleware.Before)
+	})
+	return cfg
+}
@@ -0,0 +1,119 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_withRegionNotEnabledErrors(t *testing.T) {
+	origNewEC2API := NewEC2API
+	t.Cleanup(func() {
+		NewEC2API = origNewEC2API
+	})
+	listMetrics := func(ds *DataSource, region string, credentialsErr error) error {
+		client := cloudwatch.NewFromConfig(ds.withRegionNotEnabledErrors(aws.Config{
+			Region: region,
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{}, credentialsErr
+			}),
+			HTTPClient:       failingHTTPClient{},
+			RetryMaxAttempts: 1,
+		}))
+		_, err := client.ListMetrics(context.Background(), &cloudwatch.ListMetricsInput{})
+		return err
+	}
+	regionsCache := cache.New(cache.NoExpiration, 0)
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.regionsCache = regionsCache
+	})
+	regionsCacheKey, err := ds.regionsCacheKey(context.Background())
+	require.NoError(t, err)
+	regionsCache.Set(regionsCacheKey, []ec2types.Region{
+		{RegionName: aws.String("us-east-1"), OptInStatus: aws.String("opt-in-not-required")},
+		{RegionName: aws.String("me-south-1"), OptInStatus: aws.String("not-opted-in")},
+	}, cache.NoExpiration)
+
+	t.Run("STS failing in a disabled region", func(t *testing.T) {
+		err := listMetrics(newTestDatasource(), "ap-east-1", &smithy.GenericAPIError{
+			Code: "RegionDisabledException", Message: "STS is not activated in this region for account:123456789012"})
+
+		assert.ErrorIs(t, err, models.ErrRegionNotEnabled)
+		assert.ErrorContains(t, err, "region not enabled for this account: ap-east-1 is an opt-in region the AWS account hasn't enabled")
+		assert.True(t, backend.IsDownstreamError(err))
+	})
+
+	t.Run("invalid token in a region the account didn't opt in to", func(t *testing.T) {
+		err := listMetrics(ds, "me-south-1", &smithy.GenericAPIError{Code: "InvalidClientTokenId", Message: "The security token included in the request is invalid"})
+
+		assert.ErrorIs(t, err, models.ErrRegionNotEnabled)
+	})
+
+	t.Run("invalid token in an enabled region", func(t *testing.T) {
+		err := listMetrics(ds, "us-east-1", &smithy.GenericAPIError{Code: "InvalidClientTokenId", Message: "The security token included in the request is invalid"})
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, models.ErrRegionNotEnabled)
+	})
+
+	t.Run("invalid token in a region the account didn't opt in to when the regions aren't cached yet", func(t *testing.T) {
+		ec2Mock := &mocks.EC2Mock{}
+		ec2Mock.On("DescribeRegions").Return(&ec2.DescribeRegionsOutput{Regions: []ec2types.Region{
+			{RegionName: aws.String("me-south-1"), OptInStatus: aws.String("not-opted-in")},
+		}}, nil)
+		NewEC2API = func(aws.Config) models.EC2APIProvider {
+			return ec2Mock
+		}
+		coldDs := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+			ds.AWSConfigProvider = regionConfigProvider{}
+			ds.regionsCache = cache.New(cache.NoExpiration, 0)
+		})
+
+		err := listMetrics(coldDs, "me-south-1", &smithy.GenericAPIError{Code: "InvalidClientTokenId", Message: "The security token included in the request is invalid"})
+
+		assert.ErrorIs(t, err, models.ErrRegionNotEnabled)
+		ec2Mock.AssertNumberOfCalls(t, "DescribeRegions", 1)
+	})
+
+	t.Run("invalid token when the regions of the account can't be described", func(t *testing.T) {
+		invalidToken := &smithy.GenericAPIError{Code: "InvalidClientTokenId", Message: "The security token included in the request is invalid"}
+		NewEC2API = func(cfg aws.Config) models.EC2APIProvider {
+			return ec2.NewFromConfig(cfg, func(o *ec2.Options) {
+				o.Credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return aws.Credentials{}, invalidToken
+				})
+				o.RetryMaxAttempts = 1
+			})
+		}
+		coldDs := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+			ds.AWSConfigProvider = regionConfigProvider{}
+			ds.regionsCache = cache.New(cache.NoExpiration, 0)
+		})
+
+		err := listMetrics(coldDs, "me-south-1", invalidToken)
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, models.ErrRegionNotEnabled)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		err := listMetrics(ds, "me-south-1", nil)
+
+		assert.ErrorIs(t, err, errReachedTransport)
+		assert.NotErrorIs(t, err, models.ErrRegionNotEnabled)
+	})
+}
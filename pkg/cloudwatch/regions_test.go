@@ -1,9 +1,12 @@
 package cloudwatch
 
 import (
+	"context"
 	"errors"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/patrickmn/go-cache"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,6 +16,7 @@ import (
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRegionsRoute(t *testing.T) {
@@ -21,7 +25,7 @@ func TestRegionsRoute(t *testing.T) {
 		services.NewRegionsService = origNewRegionsService
 	})
 	var mockRegionService mocks.RegionsService
-	services.NewRegionsService = func(models.EC2APIProvider, log.Logger) models.RegionsAPIProvider {
+	services.NewRegionsService = func(models.EC2APIProvider, log.Logger, string, *cache.Cache, string) models.RegionsAPIProvider {
 		return &mockRegionService
 	}
 
@@ -45,6 +49,27 @@ func TestRegionsRoute(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "us-east-1")
 	})
 
+	t.Run("leaves out regions the account didn't opt in to", func(t *testing.T) {
+		mockRegionService = mocks.RegionsService{}
+		mockRegionService.On("GetRegions", mock.Anything).Return([]resources.ResourceResponse[resources.Region]{
+			{Value: resources.Region{Name: "us-east-1", OptInStatus: "opt-in-not-required"}},
+			{Value: resources.Region{Name: "me-south-1", OptInStatus: "not-opted-in"}, Label: "me-south-1 (not enabled)"},
+			{Value: resources.Region{Name: "af-south-1", OptInStatus: "opted-in"}},
+		}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+		})
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.RegionsHandler))
+		req := httptest.NewRequest("GET", `/regions`, nil)
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "af-south-1")
+		assert.NotContains(t, rr.Body.String(), "me-south-1")
+	})
+
 	t.Run("returns 400 when the service returns a missing region error", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 		ds := newTestDatasource(func(ds *DataSource) {
@@ -73,3 +98,23 @@ func TestRegionsRoute(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "Error in Regions Handler while fetching regions: aws is having some kind of outage")
 	})
 }
+
+func Test_regionsCacheKey(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.OrgRoleMap = map[string]string{
+			"1": "arn:aws:iam::111111111111:role/org-1",
+			"2": "arn:aws:iam::222222222222:role/org-2",
+		}
+	})
+	orgKey := func(orgID int64) string {
+		key, err := ds.regionsCacheKey(backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: orgID}))
+		require.NoError(t, err)
+		return key
+	}
+
+	assert.Equal(t, orgKey(1), orgKey(1))
+	assert.NotEqual(t, orgKey(1), orgKey(2), "the regions of the accounts of different roles are cached separately")
+
+	_, err := ds.regionsCacheKey(backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 3}))
+	assert.ErrorIs(t, err, models.ErrNoOrgRole)
+}
@@ -0,0 +1,61 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// validLogGroupName matches the names CloudWatch Logs allows for log groups
+var validLogGroupName = regexp.MustCompile(`^[a-zA-Z0-9_/.#-]{1,512}$`)
+
+// resolverQueryLogPresets are queries of the Route 53 Resolver query logs. See
+// https://docs.aws.amazon.com/Route53/latest/DeveloperGuide/resolver-query-logs-format.html
+var resolverQueryLogPresets = []logsQueryPreset{
+	{
+		id:          "topDomains",
+		label:       "Top domains",
+		description: "Domains that were queried the most.",
+		query:       "stats count(*) as queries by query_name | sort queries desc | limit 25",
+	},
+	{
+		id:          "nxdomainRate",
+		label:       "NXDOMAIN rate",
+		description: "Percentage of the queries answered with NXDOMAIN, i.e. for domains that don't exist.",
+		query:       `stats sum(rcode = "NXDOMAIN") / count(*) * 100 as nxdomain_rate by bin(auto)`,
+	},
+	{
+		id:          "topNxdomainDomains",
+		label:       "Top NXDOMAIN domains",
+		description: "Domains that don't exist that were queried the most, often a sign of misconfiguration or malware.",
+		query:       `filter rcode = "NXDOMAIN" | stats count(*) as queries by query_name | sort queries desc | limit 25`,
+	},
+	{
+		id:          "queriesByVpc",
+		label:       "Queries by VPC",
+		description: "Number of queries made from each VPC.",
+		query:       "stats count(*) as queries by bin(auto), vpc_id",
+		statsGroups: []string{"vpc_id"},
+	},
+}
+
+// ResolverQueryLogPresetsHandler returns the query presets of the Route 53 Resolver query logs delivered to the log
+// group given by the logGroupName parameter.
+func (ds *DataSource) ResolverQueryLogPresetsHandler(_ context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	logGroupName := parameters.Get("logGroupName")
+	if !validLogGroupName.MatchString(logGroupName) {
+		return nil, models.NewHttpError("error in ResolverQueryLogPresetsHandler", http.StatusBadRequest, errors.New("parameter 'logGroupName' must be the name of a log group"))
+	}
+
+	response := logsQueryPresetsResponse(resolverQueryLogPresets, logGroupName, "")
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in ResolverQueryLogPresetsHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
@@ -0,0 +1,46 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func Test_resolver_query_log_presets_route(t *testing.T) {
+	ds := newTestDatasource()
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.ResolverQueryLogPresetsHandler))
+
+	t.Run("returns the presets of the log group", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/resolver-query-log-presets?logGroupName=/route53/resolver-queries", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var presets []resources.ResourceResponse[resources.LogsQueryPreset]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &presets))
+		require.Len(t, presets, len(resolverQueryLogPresets))
+		assert.Equal(t, resources.ResourceResponse[resources.LogsQueryPreset]{
+			Label: "Queries by VPC",
+			Value: resources.LogsQueryPreset{
+				Id:            "queriesByVpc",
+				Description:   "Number of queries made from each VPC.",
+				Expression:    "stats count(*) as queries by bin(auto), vpc_id",
+				LogGroupNames: []string{"/route53/resolver-queries"},
+				StatsGroups:   []string{"vpc_id"},
+			},
+		}, presets[3])
+		assert.Equal(t, `stats sum(rcode = "NXDOMAIN") / count(*) * 100 as nxdomain_rate by bin(auto)`, presets[1].Value.Expression)
+	})
+
+	t.Run("rejects a missing log group name", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/resolver-query-log-presets", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -4,38 +4,57 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strings"
 
-	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/clients"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/features"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
-	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
 
-func (ds *DataSource) newResourceMux() *http.ServeMux {
+func (ds *DataSource) newResourceMux() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ebs-volume-ids", ds.handleResourceReq(ds.handleGetEbsVolumeIds))
 	mux.HandleFunc("/ec2-instance-attribute", ds.handleResourceReq(ds.handleGetEc2InstanceAttribute))
 	mux.HandleFunc("/resource-arns", ds.handleResourceReq(ds.handleGetResourceArns))
-	mux.HandleFunc("/log-groups", ds.resourceRequestMiddleware(ds.LogGroupsHandler))
-	mux.HandleFunc("/metrics", ds.resourceRequestMiddleware(ds.MetricsHandler))
-	mux.HandleFunc("/dimension-values", ds.resourceRequestMiddleware(ds.DimensionValuesHandler))
-	mux.HandleFunc("/dimension-keys", ds.resourceRequestMiddleware(ds.DimensionKeysHandler))
+	mux.HandleFunc("/log-groups", ds.resourceRequestMiddleware(ds.staleOnThrottle("log-groups", ds.LogGroupsHandler)))
+	mux.HandleFunc("/metrics", ds.resourceRequestMiddleware(ds.staleOnThrottle("metrics", ds.MetricsHandler)))
+	mux.HandleFunc("/dimension-values", ds.resourceRequestMiddleware(ds.staleOnThrottle("dimension-values", ds.DimensionValuesHandler)))
+	mux.HandleFunc("/dimension-keys", ds.resourceRequestMiddleware(ds.staleOnThrottle("dimension-keys", ds.DimensionKeysHandler)))
 	mux.HandleFunc("/accounts", ds.resourceRequestMiddleware(ds.AccountsHandler))
 	mux.HandleFunc("/namespaces", ds.resourceRequestMiddleware(ds.NamespacesHandler))
 	mux.HandleFunc("/log-group-fields", ds.resourceRequestMiddleware(ds.LogGroupFieldsHandler))
+	mux.HandleFunc("/query-definitions", ds.resourceRequestMiddleware(ds.QueryDefinitionsHandler))
+	mux.HandleFunc("/put-query-definition", ds.handlePutQueryDefinition)
+	mux.HandleFunc("/query-snippets", ds.resourceRequestMiddleware(ds.QuerySnippetsHandler))
+	mux.HandleFunc("/running-logs-queries", ds.resourceRequestMiddleware(ds.RunningLogsQueriesHandler))
+	mux.HandleFunc("/log-context", ds.resourceRequestMiddleware(ds.LogContextHandler))
 	mux.HandleFunc("/external-id", ds.resourceRequestMiddleware(ds.ExternalIdHandler))
 	mux.HandleFunc("/regions", ds.resourceRequestMiddleware(ds.RegionsHandler))
+	mux.HandleFunc("/discovery", ds.resourceRequestMiddleware(ds.staleOnThrottle("discovery", ds.DiscoveryHandler)))
+	mux.HandleFunc("/anomaly-detectors", ds.resourceRequestMiddleware(ds.AnomalyDetectorsHandler))
+	mux.HandleFunc("/insight-rules", ds.resourceRequestMiddleware(ds.InsightRulesHandler))
+	mux.HandleFunc("/application-signals-services", ds.resourceRequestMiddleware(ds.ApplicationSignalsServicesHandler))
+	mux.HandleFunc("/service-level-objectives", ds.resourceRequestMiddleware(ds.ServiceLevelObjectivesHandler))
+	mux.HandleFunc("/metric-metadata", ds.resourceRequestMiddleware(ds.MetricMetadataHandler))
+	mux.HandleFunc("/lambda-insights-presets", ds.resourceRequestMiddleware(ds.LambdaInsightsPresetsHandler))
+	mux.HandleFunc("/eks-control-plane-presets", ds.resourceRequestMiddleware(ds.EKSControlPlanePresetsHandler))
+	mux.HandleFunc("/waf-presets", ds.resourceRequestMiddleware(ds.WAFPresetsHandler))
+	mux.HandleFunc("/resolver-query-log-presets", ds.resourceRequestMiddleware(ds.ResolverQueryLogPresetsHandler))
+	mux.HandleFunc("/export", ds.handleExport)
+	mux.HandleFunc("/cache/invalidate", ds.handleInvalidateCache)
 	// remove this once AWS's Cross Account Observability is supported in GovCloud
 	mux.HandleFunc("/legacy-log-groups", ds.handleResourceReq(ds.handleGetLogGroups))
 
-	return mux
+	return ds.readOnlyRouteGuard(mux)
 }
 
 type handleFn func(ctx context.Context, parameters url.Values) ([]suggestData, error)
@@ -44,20 +63,21 @@ type handleFn func(ctx context.Context, parameters url.Values) ([]suggestData, e
 func (ds *DataSource) handleResourceReq(handleFunc handleFn) func(rw http.ResponseWriter, req *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
-		logger := ds.logger.FromContext(ctx)
 		err := req.ParseForm()
 		if err != nil {
-			writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("unexpected error %v", err), logger)
+			respondWithError(rw, newResourceHttpError("unexpected error", err))
 			return
 		}
 		data, err := handleFunc(ctx, req.URL.Query())
 		if err != nil {
-			writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("unexpected error %v", err), logger)
+			respondWithError(rw, newResourceHttpError("unexpected error", err))
 			return
 		}
 		body, err := json.Marshal(data)
 		if err != nil {
-			writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("unexpected error %v", err), logger)
+			httpError := models.NewHttpError("unexpected error", http.StatusInternalServerError, err)
+			httpError.Code = models.HttpErrorCodeInternal
+			respondWithError(rw, httpError)
 			return
 		}
 		rw.WriteHeader(http.StatusOK)
@@ -69,14 +89,37 @@ func (ds *DataSource) handleResourceReq(handleFunc handleFn) func(rw http.Respon
 	}
 }
 
-func writeResponse(rw http.ResponseWriter, code int, msg string, logger log.Logger) {
-	rw.WriteHeader(code)
-	_, err := rw.Write([]byte(msg))
-	if err != nil {
-		logger.Error("Unable to write HTTP response", "error", err)
+// newResourceHttpError returns the error of a resource request failing with err. Errors of the AWS APIs get the
+// status code telling their cause, i.e. 403 when access is denied, 429 when throttled and 504 on timeouts, and their
+// AWS error code. Other errors are caused by the parameters of the request.
+func newResourceHttpError(message string, err error) *models.HttpError {
+	statusCode, code := http.StatusBadRequest, models.HttpErrorCodeBadRequest
+	var apiErr smithy.APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout():
+		statusCode, code = http.StatusGatewayTimeout, models.HttpErrorCodeTimeout
+	case errors.As(err, &apiErr):
+		code = apiErr.ErrorCode()
+		if _, ok := retry.DefaultThrottleErrorCodes[code]; ok {
+			statusCode, code = http.StatusTooManyRequests, models.HttpErrorCodeThrottled
+		} else if slices.Contains(accessDeniedErrorCodes, code) {
+			statusCode, code = http.StatusForbidden, models.HttpErrorCodeAccessDenied
+		} else if slices.Contains(timeoutErrorCodes, code) {
+			statusCode, code = http.StatusGatewayTimeout, models.HttpErrorCodeTimeout
+		}
 	}
+	httpError := models.NewHttpError(message, statusCode, err)
+	httpError.Code = code
+	return httpError
 }
 
+// accessDeniedErrorCodes are the codes AWS APIs deny access with.
+var accessDeniedErrorCodes = []string{"AccessDenied", "AccessDeniedException", "UnauthorizedOperation", "AuthorizationError"}
+
+// timeoutErrorCodes are the codes AWS APIs time out with.
+var timeoutErrorCodes = []string{"RequestTimeout", "RequestTimeoutException"}
+
 func (ds *DataSource) LogGroupsHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
 	request, err := resources.ParseLogGroupsRequest(parameters)
 	if err != nil {
@@ -90,7 +133,7 @@ func (ds *DataSource) LogGroupsHandler(ctx context.Context, parameters url.Value
 
 	logGroups, err := service.GetLogGroups(ctx, request)
 	if err != nil {
-		return nil, models.NewHttpError("GetLogGroups error", http.StatusInternalServerError, err)
+		return nil, newDiscoveryHttpError("GetLogGroups error", err)
 	}
 
 	logGroupsResponse, err := json.Marshal(logGroups)
@@ -106,7 +149,7 @@ func (ds *DataSource) MetricsHandler(ctx context.Context, parameters url.Values)
 		return nil, models.NewHttpError("error in MetricsHandler", http.StatusBadRequest, err)
 	}
 
-	service, err := ds.GetListMetricsService(ctx, metricsRequest.Region)
+	service, err := ds.GetListMetricsService(ctx, metricsRequest.Region, metricsRequest.ListMetricsPageLimit(ds.Settings.GrafanaSettings.ListMetricsPageLimit))
 	if err != nil {
 		return nil, models.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, err)
 	}
@@ -121,7 +164,7 @@ func (ds *DataSource) MetricsHandler(ctx context.Context, parameters url.Values)
 		response, err = service.GetMetricsByNamespace(ctx, metricsRequest)
 	}
 	if err != nil {
-		return nil, models.NewHttpError("error in MetricsHandler", http.StatusInternalServerError, err)
+		return nil, newDiscoveryHttpError("error in MetricsHandler", err)
 	}
 
 	metricsResponse, err := json.Marshal(response)
@@ -138,14 +181,14 @@ func (ds *DataSource) DimensionValuesHandler(ctx context.Context, parameters url
 		return nil, models.NewHttpError("error in DimensionValuesHandler", http.StatusBadRequest, err)
 	}
 
-	service, err := ds.GetListMetricsService(ctx, dimensionValuesRequest.Region)
+	service, err := ds.GetListMetricsService(ctx, dimensionValuesRequest.Region, dimensionValuesRequest.ListMetricsPageLimit(ds.Settings.GrafanaSettings.ListMetricsPageLimit))
 	if err != nil {
 		return nil, models.NewHttpError("error in DimensionValuesHandler", http.StatusInternalServerError, err)
 	}
 
 	response, err := service.GetDimensionValuesByDimensionFilter(ctx, dimensionValuesRequest)
 	if err != nil {
-		return nil, models.NewHttpError("error in DimensionValuesHandler", http.StatusInternalServerError, err)
+		return nil, newDiscoveryHttpError("error in DimensionValuesHandler", err)
 	}
 
 	dimensionValuesResponse, err := json.Marshal(response)
@@ -162,7 +205,7 @@ func (ds *DataSource) DimensionKeysHandler(ctx context.Context, parameters url.V
 		return nil, models.NewHttpError("error in DimensionKeyHandler", http.StatusBadRequest, err)
 	}
 
-	service, err := ds.GetListMetricsService(ctx, dimensionKeysRequest.Region)
+	service, err := ds.GetListMetricsService(ctx, dimensionKeysRequest.Region, dimensionKeysRequest.ListMetricsPageLimit(ds.Settings.GrafanaSettings.ListMetricsPageLimit))
 	if err != nil {
 		return nil, models.NewHttpError("error in DimensionKeyHandler", http.StatusInternalServerError, err)
 	}
@@ -175,7 +218,7 @@ func (ds *DataSource) DimensionKeysHandler(ctx context.Context, parameters url.V
 		response, err = services.GetHardCodedDimensionKeysByNamespace(dimensionKeysRequest.Namespace)
 	}
 	if err != nil {
-		return nil, models.NewHttpError("error in DimensionKeyHandler", http.StatusInternalServerError, err)
+		return nil, newDiscoveryHttpError("error in DimensionKeyHandler", err)
 	}
 
 	jsonResponse, err := json.Marshal(response)
@@ -187,12 +230,12 @@ func (ds *DataSource) DimensionKeysHandler(ctx context.Context, parameters url.V
 }
 
 func (ds *DataSource) AccountsHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
-	region := parameters.Get("region")
-	if region == "" {
-		return nil, models.NewHttpError("error in AccountsHandler", http.StatusBadRequest, fmt.Errorf("region is required"))
+	request, err := resources.ParseAccountsRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in AccountsHandler", http.StatusBadRequest, err)
 	}
 
-	service, err := ds.GetAccountsService(ctx, region)
+	service, err := ds.GetAccountsService(ctx, request.Region)
 	if err != nil {
 		return nil, models.NewHttpError("error in AccountsHandler", http.StatusInternalServerError, err)
 	}
@@ -208,7 +251,7 @@ func (ds *DataSource) AccountsHandler(ctx context.Context, parameters url.Values
 		}
 	}
 
-	accountsResponse, err := json.Marshal(accounts)
+	accountsResponse, err := json.Marshal(services.FilterAccountsByLabel(accounts, request.LabelSearch))
 	if err != nil {
 		return nil, models.NewHttpError("error in AccountsHandler", http.StatusInternalServerError, err)
 	}
@@ -216,6 +259,54 @@ func (ds *DataSource) AccountsHandler(ctx context.Context, parameters url.Values
 	return accountsResponse, nil
 }
 
+func (ds *DataSource) AnomalyDetectorsHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	request, err := resources.ParseAnomalyDetectorsRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in AnomalyDetectorsHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := ds.GetAnomalyDetectorsService(ctx, request.Region)
+	if err != nil {
+		return nil, models.NewHttpError("error in AnomalyDetectorsHandler", http.StatusInternalServerError, err)
+	}
+
+	anomalyDetectors, err := service.GetAnomalyDetectors(ctx, request)
+	if err != nil {
+		return nil, models.NewHttpError("error in AnomalyDetectorsHandler", http.StatusInternalServerError, err)
+	}
+
+	anomalyDetectorsResponse, err := json.Marshal(anomalyDetectors)
+	if err != nil {
+		return nil, models.NewHttpError("error in AnomalyDetectorsHandler", http.StatusInternalServerError, err)
+	}
+
+	return anomalyDetectorsResponse, nil
+}
+
+func (ds *DataSource) MetricMetadataHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	request, err := resources.ParseMetricMetadataRequest(parameters)
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricMetadataHandler", http.StatusBadRequest, err)
+	}
+
+	service, err := ds.GetMetricMetadataService(ctx, request.Region, request.ListMetricsPageLimit(ds.Settings.GrafanaSettings.ListMetricsPageLimit))
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricMetadataHandler", http.StatusInternalServerError, err)
+	}
+
+	metadata, err := service.GetMetricMetadata(ctx, request)
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricMetadataHandler", http.StatusInternalServerError, err)
+	}
+
+	metadataResponse, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, models.NewHttpError("error in MetricMetadataHandler", http.StatusInternalServerError, err)
+	}
+
+	return metadataResponse, nil
+}
+
 func (ds *DataSource) NamespacesHandler(_ context.Context, _ url.Values) ([]byte, *models.HttpError) {
 	response := services.GetHardCodedNamespaces()
 	customNamespace := ds.Settings.Namespace
@@ -261,6 +352,27 @@ func (ds *DataSource) LogGroupFieldsHandler(ctx context.Context, parameters url.
 	return logGroupsResponse, nil
 }
 
+func (ds *DataSource) QueryDefinitionsHandler(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	request := resources.ParseQueryDefinitionsRequest(parameters)
+
+	service, err := ds.GetLogGroupsService(ctx, request.Region)
+	if err != nil {
+		return nil, models.NewHttpError("newLogGroupsService error", http.StatusInternalServerError, err)
+	}
+
+	queryDefinitions, err := service.GetQueryDefinitions(ctx, request)
+	if err != nil {
+		return nil, newResourceHttpError("GetQueryDefinitions error", err)
+	}
+
+	queryDefinitionsResponse, err := json.Marshal(queryDefinitions)
+	if err != nil {
+		return nil, models.NewHttpError("QueryDefinitionsHandler json error", http.StatusInternalServerError, err)
+	}
+
+	return queryDefinitionsResponse, nil
+}
+
 func (ds *DataSource) ExternalIdHandler(_ context.Context, _ url.Values) ([]byte, *models.HttpError) {
 	response := map[string]string{
 		"externalId": ds.Settings.GrafanaSettings.ExternalID,
@@ -286,6 +398,10 @@ func (ds *DataSource) RegionsHandler(ctx context.Context, _ url.Values) ([]byte,
 	if err != nil {
 		return nil, models.NewHttpError("Error in Regions Handler while fetching regions", http.StatusInternalServerError, err)
 	}
+	// regions the account didn't opt in to can't be queried, so they aren't offered
+	regions = slices.DeleteFunc(regions, func(region resources.ResourceResponse[resources.Region]) bool {
+		return region.Value.OptInStatus == services.OptInStatusNotOptedIn
+	})
 
 	regionsResponse, err := json.Marshal(regions)
 	if err != nil {
@@ -303,12 +419,12 @@ func (ds *DataSource) GetLogGroupsService(ctx context.Context, region string) (m
 	return services.NewLogGroupsService(NewLogsAPI(awsConfig), features.IsEnabled(ctx, features.FlagCloudWatchCrossAccountQuerying)), nil
 }
 
-func (ds *DataSource) GetListMetricsService(ctx context.Context, region string) (models.ListMetricsProvider, error) {
+func (ds *DataSource) GetListMetricsService(ctx context.Context, region string, pageLimit int) (models.ListMetricsProvider, error) {
 	awsConfig, err := ds.newAWSConfig(ctx, region)
 	if err != nil {
 		return nil, err
 	}
-	return services.NewListMetricsService(clients.NewMetricsClient(NewCWClient(awsConfig), ds.Settings.GrafanaSettings.ListMetricsPageLimit)), nil
+	return services.NewListMetricsService(ds.newCachedMetricsClient(region, NewCWClient(awsConfig), pageLimit)), nil
 }
 
 func (ds *DataSource) GetAccountsService(ctx context.Context, region string) (models.AccountsProvider, error) {
@@ -319,12 +435,38 @@ func (ds *DataSource) GetAccountsService(ctx context.Context, region string) (mo
 	return services.NewAccountsService(NewOAMAPI(awsCfg)), nil
 }
 
+func (ds *DataSource) GetAnomalyDetectorsService(ctx context.Context, region string) (models.AnomalyDetectorsProvider, error) {
+	awsCfg, err := ds.newAWSConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewAnomalyDetectorsService(NewAnomalyDetectorsAPI(awsCfg)), nil
+}
+
+func (ds *DataSource) GetMetricMetadataService(ctx context.Context, region string, pageLimit int) (models.MetricMetadataProvider, error) {
+	awsCfg, err := ds.newAWSConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	client := NewCWClient(awsCfg)
+	return services.NewMetricMetadataService(ds.newCachedMetricsClient(region, client, pageLimit), client), nil
+}
+
 func (ds *DataSource) GetRegionsService(ctx context.Context, region string) (models.RegionsAPIProvider, error) {
 	awsCfg, err := ds.newAWSConfig(ctx, region)
 	if err != nil {
 		return nil, err
 	}
-	return services.NewRegionsService(NewEC2API(awsCfg), ds.logger), nil
+	regionsCacheKey, err := ds.regionsCacheKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewRegionsService(NewEC2API(awsCfg), ds.logger, ds.Settings.Region, ds.regionsCache, regionsCacheKey), nil
+}
+
+// regionsCacheKey identifies the regions of the account of the role assumed for the request in ctx.
+func (ds *DataSource) regionsCacheKey(ctx context.Context) (string, error) {
+	return ds.roleScopedCacheKey(ctx, map[string]any{"cache": "ec2Regions"})
 }
 
 // TODO: merge this and handleResourceReq
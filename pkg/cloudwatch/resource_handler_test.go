@@ -0,0 +1,69 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_handleResourceReq_errors(t *testing.T) {
+	tests := map[string]struct {
+		err        error
+		statusCode int
+		code       string
+	}{
+		"access denied": {
+			err:        fmt.Errorf("operation error EC2: DescribeInstances: %w", &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "not authorized"}),
+			statusCode: http.StatusForbidden,
+			code:       models.HttpErrorCodeAccessDenied,
+		},
+		"throttled": {
+			err:        &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"},
+			statusCode: http.StatusTooManyRequests,
+			code:       models.HttpErrorCodeThrottled,
+		},
+		"timed out": {
+			err:        fmt.Errorf("operation error Resource Groups Tagging API: GetResources: %w", context.DeadlineExceeded),
+			statusCode: http.StatusGatewayTimeout,
+			code:       models.HttpErrorCodeTimeout,
+		},
+		"other api error": {
+			err:        &smithy.GenericAPIError{Code: "InvalidParameterValue", Message: "invalid instance id"},
+			statusCode: http.StatusBadRequest,
+			code:       "InvalidParameterValue",
+		},
+		"invalid parameters": {
+			err:        errors.New("region is required"),
+			statusCode: http.StatusBadRequest,
+			code:       models.HttpErrorCodeBadRequest,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := newTestDatasource().handleResourceReq(func(context.Context, url.Values) ([]suggestData, error) {
+				return nil, tc.err
+			})
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest(http.MethodGet, "/ebs-volume-ids?region=us-east-1", nil))
+
+			assert.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			var httpError models.HttpError
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &httpError))
+			assert.Equal(t, tc.statusCode, httpError.StatusCode)
+			assert.Equal(t, tc.code, httpError.Code)
+			assert.Equal(t, tc.err.Error(), httpError.Error)
+		})
+	}
+}
@@ -3,12 +3,15 @@ package cloudwatch
 import (
 	"context"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/features"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
@@ -19,13 +22,15 @@ import (
 // matches a dynamic label
 var dynamicLabel = regexp.MustCompile(`\$\{.+\}`)
 
-func (ds *DataSource) parseResponse(ctx context.Context, metricDataOutputs []*cloudwatch.GetMetricDataOutput,
+// parseResponse returns the responses of the queries of a GetMetricData request, which took the given number of calls.
+func (ds *DataSource) parseResponse(ctx context.Context, metricDataOutputs []*cloudwatch.GetMetricDataOutput, calls int,
 	queries []*models.CloudWatchQuery) ([]*responseWrapper, error) {
 	aggregatedResponse := aggregateResponse(metricDataOutputs)
 	queriesById := map[string]*models.CloudWatchQuery{}
 	for _, query := range queries {
 		queriesById[query.Id] = query
 	}
+	separateSampleCounts(aggregatedResponse, queriesById)
 
 	results := []*responseWrapper{}
 	for id, response := range aggregatedResponse {
@@ -45,6 +50,10 @@ func (ds *DataSource) parseResponse(ctx context.Context, metricDataOutputs []*cl
 		if err != nil {
 			return nil, err
 		}
+		ds.setDimensionTagLabels(ctx, dataRes.Frames, queryRow)
+		ds.rewriteLabels(dataRes.Frames)
+		ds.setIdentityCenterConsoleLinks(dataRes.Frames, queryRow)
+		setQueryStats(dataRes.Frames, newMetricQueryStats(response, calls))
 
 		results = append(results, &responseWrapper{
 			DataResponse: &dataRes,
@@ -71,7 +80,14 @@ func aggregateResponse(getMetricDataOutputs []*cloudwatch.GetMetricDataOutput) m
 			}
 		}
 	}
+	pages := truncatedPages(getMetricDataOutputs)
 	for _, gmdo := range getMetricDataOutputs {
+		// a page is cut short once it reaches the limit, which only the results it contains are affected by
+		datapoints := 0
+		for _, r := range gmdo.MetricDataResults {
+			datapoints += len(r.Values)
+		}
+		datapointLimitReached := datapoints >= models.MaxDatapointsPerRequest
 		for _, r := range gmdo.MetricDataResults {
 			id := *r.Id
 
@@ -79,6 +95,9 @@ func aggregateResponse(getMetricDataOutputs []*cloudwatch.GetMetricDataOutput) m
 			if _, exists := responseByID[id]; exists {
 				response = responseByID[id]
 			}
+			response.DatapointLimitReached = response.DatapointLimitReached || datapointLimitReached
+			response.TruncatedAfterPages = pages
+			response.Pages = len(getMetricDataOutputs)
 
 			for _, message := range r.Messages {
 				if *message.Code == "ArithmeticError" {
@@ -124,9 +143,14 @@ func parseLabels(cloudwatchLabel string, query *models.CloudWatchQuery) (string,
 			continue
 		}
 
-		labels[dim] = splitLabels[labelsIndex]
+		if labelsIndex < len(splitLabels) {
+			labels[dim] = splitLabels[labelsIndex]
+		}
 		labelsIndex++
 	}
+	if len(query.ExtraNamespaces) > 0 && labelsIndex < len(splitLabels) {
+		labels["Namespace"] = splitLabels[labelsIndex]
+	}
 	return name, labels
 }
 
@@ -168,10 +192,18 @@ func getLabels(cloudwatchLabel string, query *models.CloudWatchQuery, addSeriesL
 func buildDataFrames(ctx context.Context, aggregatedResponse models.QueryRowResponse,
 	query *models.CloudWatchQuery) (data.Frames, error) {
 	frames := data.Frames{}
-	hasStaticLabel := query.Label != "" && !dynamicLabel.MatchString(query.Label)
+	isMathExpression := query.GetGetMetricDataAPIMode() == models.GMDApiModeMathExpression
+	staticLabel := query.Label
+	if isMathExpression {
+		staticLabel = mathExpressionLabel(query)
+	}
+	hasStaticLabel := staticLabel != "" && !dynamicLabel.MatchString(staticLabel)
 
-	for _, metric := range aggregatedResponse.Metrics {
+	for i, metric := range aggregatedResponse.Metrics {
 		label := *metric.Label
+		if isMathExpression {
+			label = expandSeriesIndex(label, i+1)
+		}
 
 		deepLink, err := query.BuildDeepLink(query.StartTime, query.EndTime)
 		if err != nil {
@@ -186,8 +218,9 @@ func buildDataFrames(ctx context.Context, aggregatedResponse models.QueryRowResp
 			}
 			series := 0
 			multiValuedDimension := ""
-			for key, values := range query.Dimensions {
-				if len(values) > series {
+			// dimensions are checked in order so that the same one is picked when several have as many values
+			for _, key := range slices.Sorted(maps.Keys(query.Dimensions)) {
+				if values := query.Dimensions[key]; len(values) > series {
 					series = len(values)
 					multiValuedDimension = key
 				}
@@ -201,7 +234,7 @@ func buildDataFrames(ctx context.Context, aggregatedResponse models.QueryRowResp
 					}
 				}
 
-				timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, []*time.Time{})
+				timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, []*time.Time{}).SetConfig(periodFieldConfig(query.Period))
 				valueField := data.NewField(data.TimeSeriesValueFieldName, labels, []*float64{})
 
 				valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: label, Links: createDataLinks(deepLink)})
@@ -230,12 +263,15 @@ func buildDataFrames(ctx context.Context, aggregatedResponse models.QueryRowResp
 			labels = getLabels(label, query, false)
 		}
 
-		timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, metric.Timestamps)
+		timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, metric.Timestamps).SetConfig(periodFieldConfig(query.Period))
 		valueField := data.NewField(data.TimeSeriesValueFieldName, labels, metric.Values)
 
 		// CloudWatch appends the dimensions to the returned label if the query label is not dynamic, so static labels need to be set
 		if hasStaticLabel {
-			name = query.Label
+			name = staticLabel
+			if isMathExpression {
+				name = expandSeriesIndex(name, i+1)
+			}
 		}
 
 		valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: name, Links: createDataLinks(deepLink)})
@@ -248,6 +284,9 @@ func buildDataFrames(ctx context.Context, aggregatedResponse models.QueryRowResp
 			RefID: query.RefId,
 			Meta:  createMeta(query),
 		}
+		if field := sampleCountField(aggregatedResponse, metric, name, labels); field != nil {
+			frame.Fields = append(frame.Fields, field)
+		}
 
 		for code := range aggregatedResponse.ErrorCodes {
 			if aggregatedResponse.ErrorCodes[code] {
@@ -258,24 +297,55 @@ func buildDataFrames(ctx context.Context, aggregatedResponse models.QueryRowResp
 			}
 		}
 
-		if aggregatedResponse.StatusCode != "Complete" {
-			frame.AppendNotices(data.Notice{
-				Severity: data.NoticeSeverityWarning,
-				Text:     "cloudwatch GetMetricData error: Too many datapoints requested - your search has been limited. Please try to reduce the time range",
-			})
+		// series cut short by the page cap are partial regardless of the datapoint limit
+		if aggregatedResponse.TruncatedAfterPages > 0 {
+			frame.AppendNotices(pagesTruncatedNotice(aggregatedResponse.TruncatedAfterPages))
+		} else if notice := datapointLimitNotice(aggregatedResponse, query); notice != nil {
+			frame.AppendNotices(*notice)
 		}
 
 		frames = append(frames, &frame)
 	}
 
+	sortFramesBySeries(frames)
 	return frames, nil
 }
 
+// suggestedPeriods are the periods suggested to users whose queries hit the GetMetricData datapoint limit
+var suggestedPeriods = []int{60, 300, 900, 3600, 21600, 86400}
+
+// datapointLimitNotice returns a notice explaining how to get complete results if the series of the query
+// have been, or are bound to be, cut short by the GetMetricData datapoint limit.
+func datapointLimitNotice(aggregatedResponse models.QueryRowResponse, query *models.CloudWatchQuery) *data.Notice {
+	exceedsLimit := query.ExpectedDatapoints(query.Period) > models.MaxDatapointsPerRequest
+	if aggregatedResponse.StatusCode == cloudwatchtypes.StatusCodeComplete && !aggregatedResponse.DatapointLimitReached && !exceedsLimit {
+		return nil
+	}
+
+	seriesCount := max(len(aggregatedResponse.Metrics), 1)
+	guidance := "Please try to reduce the time range"
+	for _, period := range suggestedPeriods {
+		if period > query.Period && query.ExpectedDatapoints(period)*seriesCount <= models.MaxDatapointsPerRequest {
+			guidance = fmt.Sprintf("Please try to increase the period to %d seconds or more, or reduce the time range", period)
+			break
+		}
+	}
+
+	return &data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text: fmt.Sprintf("cloudwatch GetMetricData error: Too many datapoints requested - CloudWatch returns at most %d datapoints per request, so this series may have been cut short. %s",
+			models.MaxDatapointsPerRequest, guidance),
+	}
+}
+
+// consoleLinkTitle is the title of the links of series to the CloudWatch console
+const consoleLinkTitle = "View in CloudWatch console"
+
 func createDataLinks(link string) []data.DataLink {
 	dataLinks := []data.DataLink{}
 	if link != "" {
 		dataLinks = append(dataLinks, data.DataLink{
-			Title:       "View in CloudWatch console",
+			Title:       consoleLinkTitle,
 			TargetBlank: true,
 			URL:         link,
 		})
@@ -283,12 +353,22 @@ func createDataLinks(link string) []data.DataLink {
 	return dataLinks
 }
 
+// createMeta returns the meta of the frames of a query. Besides the period, the custom meta has the time range the
+// query was executed for, in epoch milliseconds, so that the extent of the series can be told apart from missing data.
 func createMeta(query *models.CloudWatchQuery) *data.FrameMeta {
 	return &data.FrameMeta{
 		ExecutedQueryString: query.UsedExpression,
-		Custom: fmt.Sprintf(`{
-			"period": %d,
-			"id":     %s,
-		}`, query.Period, query.Id),
+		Custom: map[string]any{
+			"period":    query.Period,
+			"id":        query.Id,
+			"startTime": query.StartTime.UnixMilli(),
+			"endTime":   query.EndTime.UnixMilli(),
+		},
 	}
 }
+
+// periodFieldConfig returns the config of the time field of a series of the given period in seconds. The interval
+// is the step of the series, which Grafana draws bars and points as wide as, and fills gaps of the series with.
+func periodFieldConfig(period int) *data.FieldConfig {
+	return &data.FieldConfig{Interval: float64(period) * 1000}
+}
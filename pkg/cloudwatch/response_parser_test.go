@@ -1,6 +1,7 @@
 package cloudwatch
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -222,6 +223,41 @@ func Test_buildDataFrames_parse_label_to_name_and_labels(t *testing.T) {
 		assert.Equal(t, "tg", frame2.Fields[1].Labels["TargetGroup"])
 	})
 
+	t.Run("sets the period as the interval of the time field and the executed time range as meta", func(t *testing.T) {
+		response := &models.QueryRowResponse{
+			Metrics: []*cloudwatchtypes.MetricDataResult{{
+				Id:         aws.String("id1"),
+				Label:      aws.String("lb1"),
+				Timestamps: []time.Time{startTime},
+				Values:     []float64{10},
+				StatusCode: cloudwatchtypes.StatusCodeComplete,
+			}},
+		}
+		query := &models.CloudWatchQuery{
+			StartTime:        startTime,
+			EndTime:          endTime,
+			RefId:            "refId1",
+			Region:           "us-east-1",
+			Namespace:        "AWS/ApplicationELB",
+			MetricName:       "TargetResponseTime",
+			Statistic:        "Average",
+			Period:           300,
+			MetricQueryType:  models.MetricQueryTypeSearch,
+			MetricEditorMode: models.MetricEditorModeBuilder,
+		}
+		frames, err := buildDataFrames(contextWithFeaturesEnabled(features.FlagCloudWatchNewLabelParsing), *response, query)
+		require.NoError(t, err)
+
+		require.Len(t, frames, 1)
+		assert.Equal(t, 300000.0, frames[0].Fields[0].Config.Interval)
+		assert.Equal(t, map[string]any{
+			"period":    300,
+			"id":        "",
+			"startTime": startTime.UnixMilli(),
+			"endTime":   endTime.UnixMilli(),
+		}, frames[0].Meta.Custom)
+	})
+
 	t.Run("using multiple wildcard filters", func(t *testing.T) {
 		timestamp := time.Unix(0, 0)
 		response := &models.QueryRowResponse{
@@ -386,6 +422,52 @@ func Test_buildDataFrames_parse_label_to_name_and_labels(t *testing.T) {
 		assert.Equal(t, "res", frames[1].Fields[1].Labels["Resource"])
 	})
 
+	t.Run("when several namespaces are searched", func(t *testing.T) {
+		timestamp := time.Unix(0, 0)
+		response := &models.QueryRowResponse{
+			Metrics: []*cloudwatchtypes.MetricDataResult{
+				{
+					Id:         aws.String("id1"),
+					Label:      aws.String("some label|&|i-1|&|AWS/EC2"),
+					Timestamps: []time.Time{timestamp},
+					Values:     []float64{10},
+					StatusCode: cloudwatchtypes.StatusCodeComplete,
+				},
+				{
+					Id:         aws.String("id1"),
+					Label:      aws.String("some label|&|i-1|&|AWS/EBS"),
+					Timestamps: []time.Time{timestamp},
+					Values:     []float64{20},
+					StatusCode: cloudwatchtypes.StatusCodeComplete,
+				},
+			},
+		}
+		query := &models.CloudWatchQuery{
+			StartTime:       startTime,
+			EndTime:         endTime,
+			RefId:           "refId1",
+			Region:          "us-east-1",
+			Namespace:       "AWS/EC2",
+			ExtraNamespaces: []string{"AWS/EBS"},
+			MetricName:      "CPUUtilization",
+			Dimensions: map[string][]string{
+				"InstanceId": {"*"},
+			},
+			Statistic:        "Average",
+			Period:           60,
+			MetricQueryType:  models.MetricQueryTypeSearch,
+			MetricEditorMode: models.MetricEditorModeBuilder,
+		}
+		frames, err := buildDataFrames(contextWithFeaturesEnabled(features.FlagCloudWatchNewLabelParsing), *response, query)
+		require.NoError(t, err)
+
+		require.Len(t, frames, 2)
+		assert.Equal(t, "i-1", frames[0].Fields[1].Labels["InstanceId"])
+		assert.Equal(t, "AWS/EBS", frames[0].Fields[1].Labels["Namespace"])
+		assert.Equal(t, "i-1", frames[1].Fields[1].Labels["InstanceId"])
+		assert.Equal(t, "AWS/EC2", frames[1].Fields[1].Labels["Namespace"])
+	})
+
 	t.Run("when not using multi-value dimension filters on a `MetricSearch` query", func(t *testing.T) {
 		timestamp := time.Unix(0, 0)
 		response := &models.QueryRowResponse{
@@ -552,6 +634,49 @@ func Test_buildDataFrames_parse_label_to_name_and_labels(t *testing.T) {
 		assert.Equal(t, "some label", frames[0].Fields[1].Labels["Series"])
 	})
 
+	t.Run("when a math expression label has templates", func(t *testing.T) {
+		timestamp := time.Unix(0, 0)
+		response := &models.QueryRowResponse{
+			Metrics: []*cloudwatchtypes.MetricDataResult{
+				{
+					Id:         aws.String("rate"),
+					Label:      aws.String("rate"),
+					Timestamps: []time.Time{timestamp},
+					Values:     []float64{23},
+					StatusCode: cloudwatchtypes.StatusCodeComplete,
+				},
+				{
+					Id:         aws.String("rate"),
+					Label:      aws.String("rate"),
+					Timestamps: []time.Time{timestamp},
+					Values:     []float64{42},
+					StatusCode: cloudwatchtypes.StatusCodeComplete,
+				},
+			},
+		}
+
+		query := &models.CloudWatchQuery{
+			StartTime:        startTime,
+			EndTime:          endTime,
+			RefId:            "refId1",
+			Id:               "rate",
+			Region:           "us-east-1",
+			Expression:       "RATE(SEARCH('{AWS/EC2,InstanceId} MetricName=\"NetworkIn\"', 'Sum'))",
+			Dimensions:       map[string][]string{},
+			Statistic:        "Average",
+			Period:           60,
+			MetricQueryType:  models.MetricQueryTypeSearch,
+			MetricEditorMode: models.MetricEditorModeRaw,
+			Label:            "{{id}} #{{index}}",
+		}
+		frames, err := buildDataFrames(contextWithFeaturesEnabled(features.FlagCloudWatchNewLabelParsing), *response, query)
+		require.NoError(t, err)
+
+		require.Len(t, frames, 2)
+		assert.Equal(t, "rate #1", frames[0].Name)
+		assert.Equal(t, "rate #2", frames[1].Name)
+	})
+
 	t.Run("when `MetricQuery` query has no label set and `GROUP BY` clause has multiple fields", func(t *testing.T) {
 		timestamp := time.Unix(0, 0)
 		response := &models.QueryRowResponse{
@@ -726,3 +851,105 @@ func Test_buildDataFrames_parse_label_to_name_and_labels(t *testing.T) {
 		assert.Equal(t, "", frame.Fields[1].Config.DisplayName)
 	})
 }
+
+func Test_datapointLimitNotice(t *testing.T) {
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := &models.CloudWatchQuery{
+		StartTime: startTime,
+		EndTime:   startTime.Add(24 * time.Hour),
+		Period:    60,
+	}
+
+	t.Run("returns no notice for complete responses within the limit", func(t *testing.T) {
+		response := models.QueryRowResponse{StatusCode: cloudwatchtypes.StatusCodeComplete}
+		assert.Nil(t, datapointLimitNotice(response, query))
+	})
+
+	t.Run("suggests a larger period when the response reached the datapoint limit", func(t *testing.T) {
+		response := models.QueryRowResponse{
+			StatusCode:            cloudwatchtypes.StatusCodeComplete,
+			DatapointLimitReached: true,
+			Metrics:               make([]*cloudwatchtypes.MetricDataResult, 500),
+		}
+		notice := datapointLimitNotice(response, query)
+		require.NotNil(t, notice)
+		assert.Equal(t, "cloudwatch GetMetricData error: Too many datapoints requested - CloudWatch returns at most 100800 datapoints per request, "+
+			"so this series may have been cut short. Please try to increase the period to 900 seconds or more, or reduce the time range", notice.Text)
+	})
+
+	t.Run("suggests reducing the time range when no period fits", func(t *testing.T) {
+		response := models.QueryRowResponse{
+			StatusCode: cloudwatchtypes.StatusCodePartialData,
+			Metrics:    make([]*cloudwatchtypes.MetricDataResult, 500),
+		}
+		longQuery := &models.CloudWatchQuery{StartTime: startTime, EndTime: startTime.Add(450 * 24 * time.Hour), Period: 86400}
+		notice := datapointLimitNotice(response, longQuery)
+		require.NotNil(t, notice)
+		assert.Contains(t, notice.Text, "Please try to reduce the time range")
+	})
+
+	t.Run("warns when a single series exceeds the limit", func(t *testing.T) {
+		response := models.QueryRowResponse{StatusCode: cloudwatchtypes.StatusCodeComplete}
+		longQuery := &models.CloudWatchQuery{StartTime: startTime, EndTime: startTime.Add(90 * 24 * time.Hour), Period: 60}
+		notice := datapointLimitNotice(response, longQuery)
+		require.NotNil(t, notice)
+		assert.Contains(t, notice.Text, "increase the period to 300 seconds")
+	})
+}
+
+func Test_aggregateResponse_datapointLimitReached(t *testing.T) {
+	values := make([]float64, models.MaxDatapointsPerRequest)
+	outputs := []*cloudwatch.GetMetricDataOutput{{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{
+			{Id: aws.String("a"), Label: aws.String("label"), Values: values, StatusCode: cloudwatchtypes.StatusCodeComplete},
+		},
+	}}
+	assert.True(t, aggregateResponse(outputs)["a"].DatapointLimitReached)
+
+	outputs[0].MetricDataResults[0].Values = values[:10]
+	assert.False(t, aggregateResponse(outputs)["a"].DatapointLimitReached)
+
+	t.Run("only the results of the pages that reached the limit are truncated", func(t *testing.T) {
+		outputs := []*cloudwatch.GetMetricDataOutput{
+			{MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("a"), Label: aws.String("label"), Values: values, StatusCode: cloudwatchtypes.StatusCodePartialData},
+			}},
+			{MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("a"), Label: aws.String("label"), Values: values[:10], StatusCode: cloudwatchtypes.StatusCodeComplete},
+				{Id: aws.String("b"), Label: aws.String("label"), Values: values[:10], StatusCode: cloudwatchtypes.StatusCodeComplete},
+			}},
+		}
+		responses := aggregateResponse(outputs)
+
+		assert.True(t, responses["a"].DatapointLimitReached)
+		assert.False(t, responses["b"].DatapointLimitReached)
+	})
+}
+
+func Test_aggregateResponse_truncatedAfterPages(t *testing.T) {
+	outputs := []*cloudwatch.GetMetricDataOutput{
+		{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("a"), Label: aws.String("label"), Values: []float64{1}, StatusCode: cloudwatchtypes.StatusCodePartialData},
+			},
+			NextToken: aws.String("next"),
+		},
+		{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("a"), Label: aws.String("label"), Values: []float64{2}, StatusCode: cloudwatchtypes.StatusCodePartialData},
+			},
+			NextToken: aws.String("next"),
+		},
+	}
+	response := aggregateResponse(outputs)["a"]
+	assert.Equal(t, 2, response.TruncatedAfterPages)
+
+	frames, err := buildDataFrames(context.Background(), response, &models.CloudWatchQuery{RefId: "A", Region: "us-east-1", Period: 60, MetricQueryType: models.MetricQueryTypeSearch})
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	require.Len(t, frames[0].Meta.Notices, 1)
+	assert.Contains(t, frames[0].Meta.Notices[0].Text, "Results truncated after 2 pages")
+
+	outputs[1].NextToken = nil
+	assert.Equal(t, 0, aggregateResponse(outputs)["a"].TruncatedAfterPages)
+}
@@ -0,0 +1,91 @@
+package cloudwatch
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// executeMetricDataQueriesByRetention queries each part of the time range that CloudWatch retains at a different
+// resolution separately, at the finest period available for it, and stitches the series of the parts back together.
+func (ds *DataSource) executeMetricDataQueriesByRetention(ctx context.Context, ectx context.Context, region string, startTime, endTime time.Time,
+	requestQueries []*models.CloudWatchQuery) ([]*responseWrapper, error) {
+	segments := models.RetentionSegments(startTime, endTime, time.Now())
+	if len(segments) == 1 {
+		return ds.executeMetricDataQueries(ctx, ectx, region, startTime, endTime, requestQueries)
+	}
+
+	stitched := []*responseWrapper{}
+	responsesByRefId := map[string]*responseWrapper{}
+	for _, segment := range segments {
+		segmentQueries := make([]*models.CloudWatchQuery, 0, len(requestQueries))
+		for _, query := range requestQueries {
+			segmentQueries = append(segmentQueries, query.ForTimeSegment(segment))
+		}
+
+		res, err := ds.executeMetricDataQueries(ctx, ectx, region, segment.StartTime, segment.EndTime, segmentQueries)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, response := range res {
+			if existing, ok := responsesByRefId[response.RefId]; ok {
+				stitchDataResponse(existing.DataResponse, response.DataResponse)
+				continue
+			}
+			responsesByRefId[response.RefId] = response
+			stitched = append(stitched, response)
+		}
+	}
+
+	return stitched, nil
+}
+
+// stitchDataResponse appends the series of a later time segment to the matching series of an earlier one.
+// Series only present in the later segment are added as they are. The stats of the segments add up.
+func stitchDataResponse(into *backend.DataResponse, from *backend.DataResponse) {
+	if into.Error != nil {
+		return
+	}
+	if from.Error != nil {
+		into.Error = from.Error
+		into.ErrorSource = from.ErrorSource
+		return
+	}
+
+	intoStats, intoHasStats := metricQueryStatsOf(into.Frames)
+	fromStats, fromHasStats := metricQueryStatsOf(from.Frames)
+	for _, frame := range from.Frames {
+		target := findSeriesFrame(into.Frames, frame)
+		if target == nil {
+			into.Frames = append(into.Frames, frame)
+			continue
+		}
+		for row := 0; row < frame.Rows(); row++ {
+			target.AppendRow(frame.RowCopy(row)...)
+		}
+		if frame.Meta != nil && len(frame.Meta.Notices) > 0 {
+			target.AppendNotices(frame.Meta.Notices...)
+		}
+	}
+	if intoHasStats && fromHasStats {
+		setQueryStats(into.Frames, intoStats.add(fromStats))
+	}
+}
+
+// findSeriesFrame finds the frame in frames holding the same series as frame.
+func findSeriesFrame(frames data.Frames, frame *data.Frame) *data.Frame {
+	for _, candidate := range frames {
+		if candidate.Name != frame.Name || len(candidate.Fields) != len(frame.Fields) {
+			continue
+		}
+		if len(frame.Fields) > 1 && !candidate.Fields[1].Labels.Equals(frame.Fields[1].Labels) {
+			continue
+		}
+		return candidate
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeSeriesQuery_splitRangesByRetention(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	var api mocks.MetricsAPI
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -30)
+	oldPoint := now.AddDate(0, 0, -20)
+	recentPoint := now.AddDate(0, 0, -1)
+
+	isSegment := func(olderThan15Days bool) any {
+		return mock.MatchedBy(func(input *cloudwatch.GetMetricDataInput) bool {
+			return input.StartTime.Before(now.AddDate(0, 0, -16)) == olderThan15Days
+		})
+	}
+	result := func(timestamp time.Time, value float64) *cloudwatch.GetMetricDataOutput {
+		return &cloudwatch.GetMetricDataOutput{MetricDataResults: []cloudwatchtypes.MetricDataResult{{
+			StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: []float64{value}, Timestamps: []time.Time{timestamp},
+		}}}
+	}
+
+	query := func(ds *DataSource) (*backend.QueryDataResponse, error) {
+		return ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: now},
+				JSON: json.RawMessage(`{
+					"type": "timeSeriesQuery",
+					"namespace": "AWS/EC2",
+					"metricName": "CPUUtilization",
+					"dimensions": {"InstanceId": "i-1"},
+					"region": "us-east-1",
+					"id": "a",
+					"statistic": "Average",
+					"period": "60",
+					"matchExact": true,
+					"refId": "A"
+				}`),
+			}},
+		})
+	}
+
+	t.Run("queries each retention segment at its finest period and stitches the series", func(t *testing.T) {
+		api = mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, isSegment(true), mock.Anything).Return(result(oldPoint, 1), nil)
+		api.On("GetMetricData", mock.Anything, isSegment(false), mock.Anything).Return(result(recentPoint, 2), nil)
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.SplitRangesByRetention = true
+		})
+
+		resp, err := query(ds)
+		require.NoError(t, err)
+
+		require.Len(t, api.Calls, 2)
+		periods := []int32{}
+		for _, call := range api.Calls {
+			periods = append(periods, *call.Arguments.Get(1).(*cloudwatch.GetMetricDataInput).MetricDataQueries[0].MetricStat.Period)
+		}
+		assert.ElementsMatch(t, []int32{300, 60}, periods)
+
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		frame := resp.Responses["A"].Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, oldPoint, frame.Fields[0].At(0))
+		assert.Equal(t, recentPoint, frame.Fields[0].At(1))
+		assert.Equal(t, 1.0, frame.Fields[1].At(0))
+		assert.Equal(t, 2.0, frame.Fields[1].At(1))
+		stats, ok := metricQueryStatsOf(resp.Responses["A"].Frames)
+		require.True(t, ok)
+		assert.Equal(t, metricQueryStats{GetMetricDataCalls: 2, Pages: 2, Datapoints: 2}, stats, "the stats of the segments add up")
+	})
+
+	t.Run("queries the whole range at the period retained for its start when disabled", func(t *testing.T) {
+		api = mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(result(oldPoint, 1), nil)
+		ds := newTestDatasource()
+
+		_, err := query(ds)
+		require.NoError(t, err)
+
+		require.Len(t, api.Calls, 1)
+		assert.Equal(t, int32(300), *api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput).MetricDataQueries[0].MetricStat.Period)
+	})
+}
+
+func Test_stitchDataResponse(t *testing.T) {
+	series := func(name string, labels data.Labels, timestamp time.Time, value float64) *data.Frame {
+		return data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []*time.Time{&timestamp}),
+			data.NewField(data.TimeSeriesValueFieldName, labels, []*float64{&value}))
+	}
+	t1, t2 := time.Unix(100, 0), time.Unix(200, 0)
+
+	t.Run("appends matching series and adds new ones", func(t *testing.T) {
+		into := &backend.DataResponse{Frames: data.Frames{series("cpu", data.Labels{"InstanceId": "i-1"}, t1, 1)}}
+		stitchDataResponse(into, &backend.DataResponse{Frames: data.Frames{
+			series("cpu", data.Labels{"InstanceId": "i-1"}, t2, 2),
+			series("cpu", data.Labels{"InstanceId": "i-2"}, t2, 3),
+		}})
+
+		require.Len(t, into.Frames, 2)
+		assert.Equal(t, 2, into.Frames[0].Rows())
+		assert.Equal(t, data.Labels{"InstanceId": "i-2"}, into.Frames[1].Fields[1].Labels)
+	})
+
+	t.Run("keeps the error of a failed segment", func(t *testing.T) {
+		into := &backend.DataResponse{Frames: data.Frames{series("cpu", nil, t1, 1)}}
+		stitchDataResponse(into, &backend.DataResponse{Error: assert.AnError})
+
+		assert.Equal(t, assert.AnError, into.Error)
+	})
+}
@@ -0,0 +1,90 @@
+package cloudwatch
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const (
+	// sampleCountIdSuffix is appended to the ID of a query for the ID of the query of its SampleCount
+	sampleCountIdSuffix  = "_samplecount"
+	sampleCountStatistic = "SampleCount"
+)
+
+// sampleCountMetricDataQuery returns the query of the SampleCount of the metric of query, whose query is mdq, if it
+// includes its sample count. The SampleCount is queried in the same request as the statistic of the query, with the
+// same label, so that its series can be matched with those of the query by label.
+func sampleCountMetricDataQuery(query *models.CloudWatchQuery, mdq cloudwatchtypes.MetricDataQuery) (cloudwatchtypes.MetricDataQuery, bool) {
+	if !query.IncludeSampleCount || !query.ReturnData || query.Statistic == sampleCountStatistic {
+		return cloudwatchtypes.MetricDataQuery{}, false
+	}
+
+	sampleCount := mdq
+	sampleCount.Id = aws.String(query.Id + sampleCountIdSuffix)
+	switch query.GetGetMetricDataAPIMode() {
+	case models.GMDApiModeMetricStat:
+		metricStat := *mdq.MetricStat
+		metricStat.Stat = aws.String(sampleCountStatistic)
+		sampleCount.MetricStat = &metricStat
+	case models.GMDApiModeInferredSearchExpression:
+		if query.AggregateDimension != "" {
+			sampleCount.Expression = aws.String(buildAggregateExpression(query, sampleCountStatistic))
+		} else {
+			sampleCount.Expression = aws.String(buildSearchExpression(query, sampleCountStatistic))
+		}
+	default:
+		return cloudwatchtypes.MetricDataQuery{}, false
+	}
+	return sampleCount, true
+}
+
+// separateSampleCounts removes the results of the SampleCount queries from aggregatedResponse and sets them on the
+// rows of the queries they were queried for.
+func separateSampleCounts(aggregatedResponse map[string]models.QueryRowResponse, queriesById map[string]*models.CloudWatchQuery) {
+	for id, response := range aggregatedResponse {
+		queryId, isSampleCount := strings.CutSuffix(id, sampleCountIdSuffix)
+		if !isSampleCount || queriesById[id] != nil {
+			continue
+		}
+		delete(aggregatedResponse, id)
+		row, ok := aggregatedResponse[queryId]
+		if !ok {
+			continue
+		}
+		row.SampleCounts = make(map[string]*cloudwatchtypes.MetricDataResult, len(response.Metrics))
+		for _, metric := range response.Metrics {
+			row.SampleCounts[aws.ToString(metric.Label)] = metric
+		}
+		aggregatedResponse[queryId] = row
+	}
+}
+
+// sampleCountField returns the field of the sample counts of the series of metric at its timestamps, null where no
+// sample count was returned, or nil if the SampleCount of the series wasn't queried.
+func sampleCountField(aggregatedResponse models.QueryRowResponse, metric *cloudwatchtypes.MetricDataResult, name string,
+	labels data.Labels) *data.Field {
+	sampleCounts, ok := aggregatedResponse.SampleCounts[aws.ToString(metric.Label)]
+	if !ok {
+		return nil
+	}
+	byTimestamp := make(map[time.Time]float64, len(sampleCounts.Timestamps))
+	for i, timestamp := range sampleCounts.Timestamps {
+		if i < len(sampleCounts.Values) {
+			byTimestamp[timestamp] = sampleCounts.Values[i]
+		}
+	}
+	values := make([]*float64, len(metric.Timestamps))
+	for i, timestamp := range metric.Timestamps {
+		if value, ok := byTimestamp[timestamp]; ok {
+			values[i] = &value
+		}
+	}
+	return data.NewField(sampleCountStatistic, labels.Copy(), values).
+		SetConfig(&data.FieldConfig{DisplayNameFromDS: name + " " + sampleCountStatistic})
+}
@@ -0,0 +1,75 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_IncludeSampleCount(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+	now := time.Now().Truncate(time.Minute)
+	timestamps := []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Minute)}
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []cloudwatchtypes.MetricDataResult{
+			{StatusCode: "Complete", Id: aws.String("a"), Label: aws.String("Latency"), Values: []float64{120, 80}, Timestamps: timestamps},
+			{StatusCode: "Complete", Id: aws.String("a_samplecount"), Label: aws.String("Latency"), Values: []float64{1}, Timestamps: timestamps[1:]},
+		}}, nil)
+
+	ds := newTestDatasource()
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+			JSON: json.RawMessage(`{
+				"type": "timeSeriesQuery",
+				"namespace": "AWS/ApplicationELB",
+				"metricName": "Latency",
+				"dimensions": {"LoadBalancer": "app/lb"},
+				"region": "us-east-1",
+				"id": "a",
+				"statistic": "Average",
+				"period": "60",
+				"matchExact": true,
+				"includeSampleCount": true
+			}`),
+		}},
+	})
+	require.NoError(t, err)
+
+	input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+	require.Len(t, input.MetricDataQueries, 2)
+	assert.Equal(t, "a_samplecount", *input.MetricDataQueries[1].Id)
+	assert.Equal(t, "SampleCount", *input.MetricDataQueries[1].MetricStat.Stat)
+	assert.Equal(t, "Average", *input.MetricDataQueries[0].MetricStat.Stat)
+
+	require.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+	frame := resp.Responses["A"].Frames[0]
+	require.Len(t, frame.Fields, 3)
+	sampleCount := frame.Fields[2]
+	assert.Equal(t, "SampleCount", sampleCount.Name)
+	assert.Equal(t, frame.Fields[1].Labels, sampleCount.Labels)
+	assert.Nil(t, sampleCount.At(0), "timestamps without a sample count are null")
+	assert.Equal(t, 1.0, *sampleCount.At(1).(*float64))
+}
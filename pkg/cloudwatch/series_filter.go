@@ -0,0 +1,31 @@
+package cloudwatch
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// filterSeries keeps the series frames of a query matching its series filter, or drops them if the filter excludes
+// them, so that searches can be narrowed down without sending every series they match to the browser. The filter is
+// matched against the name of the series, or the value of the filter dimension, which series without that dimension
+// never match.
+func filterSeries(frames data.Frames, query *models.CloudWatchQuery) data.Frames {
+	if query.SeriesFilter == nil {
+		return frames
+	}
+
+	filtered := make(data.Frames, 0, len(frames))
+	for _, frame := range frames {
+		matches := false
+		if query.SeriesFilterDimension == "" {
+			matches = query.SeriesFilter.MatchString(frame.Name)
+		} else if value, ok := seriesFrameLabels(frame)[query.SeriesFilterDimension]; ok {
+			matches = query.SeriesFilter.MatchString(value)
+		}
+		if matches != query.SeriesFilterExclude {
+			filtered = append(filtered, frame)
+		}
+	}
+	return filtered
+}
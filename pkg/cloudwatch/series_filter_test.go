@@ -0,0 +1,49 @@
+package cloudwatch
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_filterSeries(t *testing.T) {
+	newSeries := func(name string, labels data.Labels) *data.Frame {
+		return data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []float64{}),
+			data.NewField(data.TimeSeriesValueFieldName, labels, []float64{}))
+	}
+	series := data.Frames{
+		newSeries("web-1", data.Labels{"InstanceId": "i-1", "Role": "web"}),
+		newSeries("canary-1", data.Labels{"InstanceId": "i-2", "Role": "canary"}),
+		newSeries("web-2", data.Labels{"InstanceId": "i-3"}),
+	}
+	names := func(frames data.Frames) []string {
+		result := []string{}
+		for _, frame := range frames {
+			result = append(result, frame.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		query    *models.CloudWatchQuery
+		expected []string
+	}{
+		{"no filter", &models.CloudWatchQuery{}, []string{"web-1", "canary-1", "web-2"}},
+		{"keeps series whose name matches", &models.CloudWatchQuery{SeriesFilter: regexp.MustCompile(`^web`)}, []string{"web-1", "web-2"}},
+		{"drops series whose name matches", &models.CloudWatchQuery{SeriesFilter: regexp.MustCompile(`canary`), SeriesFilterExclude: true}, []string{"web-1", "web-2"}},
+		{"keeps series whose dimension matches", &models.CloudWatchQuery{SeriesFilter: regexp.MustCompile(`^i-[12]$`), SeriesFilterDimension: "InstanceId"}, []string{"web-1", "canary-1"}},
+		{"series without the dimension never match", &models.CloudWatchQuery{SeriesFilter: regexp.MustCompile(`.*`), SeriesFilterDimension: "Role"}, []string{"web-1", "canary-1"}},
+		{"series without the dimension are kept when excluding", &models.CloudWatchQuery{SeriesFilter: regexp.MustCompile(`canary`), SeriesFilterDimension: "Role", SeriesFilterExclude: true}, []string{"web-1", "web-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, names(filterSeries(series, tt.query)))
+		})
+	}
+}
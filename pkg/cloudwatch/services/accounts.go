@@ -81,5 +81,35 @@ func (a *AccountsService) GetAccountsForCurrentUserOrRole(ctx context.Context) (
 		nextToken = links.NextToken
 	}
 
-	return valuesToListMetricRespone(response), nil
+	result := valuesToListMetricRespone(response)
+	for i := range result {
+		result[i].Label = accountDisplayLabel(result[i].Value)
+	}
+
+	return result, nil
+}
+
+// FilterAccountsByLabel returns the accounts whose label contains the search term, ignoring case.
+// The monitoring account is matched on its label like any other account.
+func FilterAccountsByLabel(accounts []resources.ResourceResponse[resources.Account], search string) []resources.ResourceResponse[resources.Account] {
+	if search == "" {
+		return accounts
+	}
+
+	search = strings.ToLower(search)
+	filtered := make([]resources.ResourceResponse[resources.Account], 0, len(accounts))
+	for _, account := range accounts {
+		if strings.Contains(strings.ToLower(account.Value.Label), search) {
+			filtered = append(filtered, account)
+		}
+	}
+
+	return filtered
+}
+
+func accountDisplayLabel(account resources.Account) string {
+	if account.Label == "" {
+		return account.Id
+	}
+	return fmt.Sprintf("%s (%s)", account.Label, account.Id)
 }
@@ -118,10 +118,10 @@ func TestHandleGetAccounts(t *testing.T) {
 		fakeOAMClient.AssertNumberOfCalls(t, "ListSinks", 2)
 		fakeOAMClient.AssertNumberOfCalls(t, "ListAttachedLinks", 2)
 		expectedAccounts := []resources.ResourceResponse[resources.Account]{
-			{Value: resources.Account{Id: "123456789012", Label: "Account 1", Arn: "arn:aws:logs:us-east-1:123456789012:log-group:my-log-group1", IsMonitoringAccount: true}},
-			{Value: resources.Account{Id: "123456789013", Label: "Account 10", Arn: "arn:aws:logs:us-east-1:123456789013:log-group:my-log-group10", IsMonitoringAccount: false}},
-			{Value: resources.Account{Id: "123456789014", Label: "Account 11", Arn: "arn:aws:logs:us-east-1:123456789014:log-group:my-log-group11", IsMonitoringAccount: false}},
-			{Value: resources.Account{Id: "123456789012", Label: "Account 12", Arn: "arn:aws:logs:us-east-1:123456789012:log-group:my-log-group12", IsMonitoringAccount: false}},
+			{Value: resources.Account{Id: "123456789012", Label: "Account 1", Arn: "arn:aws:logs:us-east-1:123456789012:log-group:my-log-group1", IsMonitoringAccount: true}, Label: "Account 1 (123456789012)"},
+			{Value: resources.Account{Id: "123456789013", Label: "Account 10", Arn: "arn:aws:logs:us-east-1:123456789013:log-group:my-log-group10", IsMonitoringAccount: false}, Label: "Account 10 (123456789013)"},
+			{Value: resources.Account{Id: "123456789014", Label: "Account 11", Arn: "arn:aws:logs:us-east-1:123456789014:log-group:my-log-group11", IsMonitoringAccount: false}, Label: "Account 11 (123456789014)"},
+			{Value: resources.Account{Id: "123456789012", Label: "Account 12", Arn: "arn:aws:logs:us-east-1:123456789012:log-group:my-log-group12", IsMonitoringAccount: false}, Label: "Account 12 (123456789012)"},
 		}
 		assert.Equal(t, expectedAccounts, resp)
 	})
@@ -165,3 +165,26 @@ func TestHandleGetAccounts(t *testing.T) {
 		assert.Equal(t, err.Error(), "ListAttachedLinks error: some error")
 	})
 }
+
+func TestFilterAccountsByLabel(t *testing.T) {
+	accounts := []resources.ResourceResponse[resources.Account]{
+		{Value: resources.Account{Id: "123456789012", Label: "Production EU"}},
+		{Value: resources.Account{Id: "123456789013", Label: "staging"}},
+		{Value: resources.Account{Id: "123456789014", Label: "prod-us"}},
+	}
+
+	t.Run("Should return all accounts when search is empty", func(t *testing.T) {
+		assert.Equal(t, accounts, FilterAccountsByLabel(accounts, ""))
+	})
+
+	t.Run("Should match label substrings ignoring case", func(t *testing.T) {
+		filtered := FilterAccountsByLabel(accounts, "PROD")
+		require.Len(t, filtered, 2)
+		assert.Equal(t, "Production EU", filtered[0].Value.Label)
+		assert.Equal(t, "prod-us", filtered[1].Value.Label)
+	})
+
+	t.Run("Should return an empty list when nothing matches", func(t *testing.T) {
+		assert.Empty(t, FilterAccountsByLabel(accounts, "dev"))
+	})
+}
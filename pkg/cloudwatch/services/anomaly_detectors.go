@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+type AnomalyDetectorsService struct {
+	models.AnomalyDetectorsAPIProvider
+}
+
+var NewAnomalyDetectorsService = func(client models.AnomalyDetectorsAPIProvider) models.AnomalyDetectorsProvider {
+	return &AnomalyDetectorsService{client}
+}
+
+// GetAnomalyDetectors returns the anomaly detectors of single metrics matching the request. Dimensions of the filter
+// without a value only need the metric to have the dimension, as DescribeAnomalyDetectors only filters on dimension
+// values.
+func (a *AnomalyDetectorsService) GetAnomalyDetectors(ctx context.Context, r resources.AnomalyDetectorsRequest) ([]resources.ResourceResponse[resources.AnomalyDetector], error) {
+	input := &cloudwatch.DescribeAnomalyDetectorsInput{
+		AnomalyDetectorTypes: []cloudwatchtypes.AnomalyDetectorType{cloudwatchtypes.AnomalyDetectorTypeSingleMetric},
+	}
+	if r.Namespace != "" {
+		input.Namespace = aws.String(r.Namespace)
+	}
+	if r.MetricName != "" {
+		input.MetricName = aws.String(r.MetricName)
+	}
+	for _, dimension := range r.DimensionFilter {
+		if dimension.Value != "" {
+			input.Dimensions = append(input.Dimensions, cloudwatchtypes.Dimension{Name: aws.String(dimension.Name), Value: aws.String(dimension.Value)})
+		}
+	}
+
+	response := []resources.ResourceResponse[resources.AnomalyDetector]{}
+	paginator := cloudwatch.NewDescribeAnomalyDetectorsPaginator(a, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("DescribeAnomalyDetectors error: %w", err)
+		}
+		for _, detector := range page.AnomalyDetectors {
+			metric := detector.SingleMetricAnomalyDetector
+			if metric == nil {
+				continue
+			}
+			dimensions := make(map[string]string, len(metric.Dimensions))
+			for _, dimension := range metric.Dimensions {
+				dimensions[aws.ToString(dimension.Name)] = aws.ToString(dimension.Value)
+			}
+			if !hasDimensions(dimensions, r.DimensionFilter) {
+				continue
+			}
+			response = append(response, resources.ResourceResponse[resources.AnomalyDetector]{
+				AccountId: metric.AccountId,
+				Value: resources.AnomalyDetector{
+					Namespace:  aws.ToString(metric.Namespace),
+					MetricName: aws.ToString(metric.MetricName),
+					Dimensions: dimensions,
+					Stat:       aws.ToString(metric.Stat),
+					State:      string(detector.StateValue),
+				},
+			})
+		}
+	}
+
+	return response, nil
+}
+
+func hasDimensions(dimensions map[string]string, filter []*resources.Dimension) bool {
+	for _, dimension := range filter {
+		if _, ok := dimensions[dimension.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
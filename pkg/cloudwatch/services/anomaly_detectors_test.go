@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func TestGetAnomalyDetectors(t *testing.T) {
+	detector := func(instanceId string, stateValue cloudwatchtypes.AnomalyDetectorStateValue) cloudwatchtypes.AnomalyDetector {
+		return cloudwatchtypes.AnomalyDetector{
+			SingleMetricAnomalyDetector: &cloudwatchtypes.SingleMetricAnomalyDetector{
+				Namespace:  aws.String("AWS/EC2"),
+				MetricName: aws.String("CPUUtilization"),
+				Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String(instanceId)}},
+				Stat:       aws.String("Average"),
+			},
+			StateValue: stateValue,
+		}
+	}
+
+	t.Run("Should return the single metric detectors of all pages", func(t *testing.T) {
+		client := &mocks.FakeAnomalyDetectorsClient{}
+		client.On("DescribeAnomalyDetectors", mock.MatchedBy(func(input *cloudwatch.DescribeAnomalyDetectorsInput) bool {
+			return input.NextToken == nil
+		})).Return(&cloudwatch.DescribeAnomalyDetectorsOutput{
+			AnomalyDetectors: []cloudwatchtypes.AnomalyDetector{
+				detector("i-1", cloudwatchtypes.AnomalyDetectorStateValueTrained),
+				{MetricMathAnomalyDetector: &cloudwatchtypes.MetricMathAnomalyDetector{}},
+			},
+			NextToken: aws.String("next"),
+		}, nil).Once()
+		client.On("DescribeAnomalyDetectors", mock.Anything).Return(&cloudwatch.DescribeAnomalyDetectorsOutput{
+			AnomalyDetectors: []cloudwatchtypes.AnomalyDetector{detector("i-2", cloudwatchtypes.AnomalyDetectorStateValuePendingTraining)},
+		}, nil).Once()
+
+		resp, err := NewAnomalyDetectorsService(client).GetAnomalyDetectors(context.Background(), resources.AnomalyDetectorsRequest{
+			Namespace:  "AWS/EC2",
+			MetricName: "CPUUtilization",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []resources.ResourceResponse[resources.AnomalyDetector]{
+			{Value: resources.AnomalyDetector{Namespace: "AWS/EC2", MetricName: "CPUUtilization", Dimensions: map[string]string{"InstanceId": "i-1"}, Stat: "Average", State: "TRAINED"}},
+			{Value: resources.AnomalyDetector{Namespace: "AWS/EC2", MetricName: "CPUUtilization", Dimensions: map[string]string{"InstanceId": "i-2"}, Stat: "Average", State: "PENDING_TRAINING"}},
+		}, resp)
+
+		input := client.Calls[0].Arguments.Get(0).(*cloudwatch.DescribeAnomalyDetectorsInput)
+		assert.Equal(t, "AWS/EC2", *input.Namespace)
+		assert.Equal(t, "CPUUtilization", *input.MetricName)
+		assert.Equal(t, []cloudwatchtypes.AnomalyDetectorType{cloudwatchtypes.AnomalyDetectorTypeSingleMetric}, input.AnomalyDetectorTypes)
+	})
+
+	t.Run("Should filter on dimension values and names", func(t *testing.T) {
+		client := &mocks.FakeAnomalyDetectorsClient{}
+		client.On("DescribeAnomalyDetectors", mock.Anything).Return(&cloudwatch.DescribeAnomalyDetectorsOutput{
+			AnomalyDetectors: []cloudwatchtypes.AnomalyDetector{detector("i-1", cloudwatchtypes.AnomalyDetectorStateValueTrained)},
+		}, nil)
+
+		resp, err := NewAnomalyDetectorsService(client).GetAnomalyDetectors(context.Background(), resources.AnomalyDetectorsRequest{
+			DimensionFilter: []*resources.Dimension{{Name: "InstanceId", Value: "i-1"}, {Name: "AutoScalingGroupName"}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp)
+
+		input := client.Calls[0].Arguments.Get(0).(*cloudwatch.DescribeAnomalyDetectorsInput)
+		assert.Equal(t, []cloudwatchtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}}, input.Dimensions)
+	})
+
+	t.Run("Should return an error if DescribeAnomalyDetectors fails", func(t *testing.T) {
+		client := &mocks.FakeAnomalyDetectorsClient{}
+		client.On("DescribeAnomalyDetectors", mock.Anything).Return(&cloudwatch.DescribeAnomalyDetectorsOutput{}, errors.New("some error"))
+
+		_, err := NewAnomalyDetectorsService(client).GetAnomalyDetectors(context.Background(), resources.AnomalyDetectorsRequest{})
+		assert.EqualError(t, err, "DescribeAnomalyDetectors error: some error")
+	})
+}
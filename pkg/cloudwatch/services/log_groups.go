@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
@@ -90,3 +91,48 @@ func (s *LogGroupsService) GetLogGroupFields(ctx context.Context, request resour
 
 	return result, nil
 }
+
+func (s *LogGroupsService) GetQueryDefinitions(ctx context.Context, request resources.QueryDefinitionsRequest) ([]resources.ResourceResponse[resources.QueryDefinition], error) {
+	queryDefinitions, err := ListQueryDefinitions(ctx, s.logGroupsAPI, request.NamePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]resources.ResourceResponse[resources.QueryDefinition], 0, len(queryDefinitions))
+	for _, queryDefinition := range queryDefinitions {
+		name := aws.ToString(queryDefinition.Name)
+		result = append(result, resources.ResourceResponse[resources.QueryDefinition]{
+			Label: name,
+			Value: resources.QueryDefinition{
+				Id:            aws.ToString(queryDefinition.QueryDefinitionId),
+				Name:          name,
+				QueryString:   aws.ToString(queryDefinition.QueryString),
+				QueryLanguage: string(queryDefinition.QueryLanguage),
+				LogGroupNames: queryDefinition.LogGroupNames,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// ListQueryDefinitions lists the saved Logs Insights query definitions whose name starts with namePrefix, or all of
+// them if it's nil.
+func ListQueryDefinitions(ctx context.Context, api models.QueryDefinitionsAPI, namePrefix *string) ([]cloudwatchlogstypes.QueryDefinition, error) {
+	input := &cloudwatchlogs.DescribeQueryDefinitionsInput{
+		MaxResults:                aws.Int32(1000),
+		QueryDefinitionNamePrefix: namePrefix,
+	}
+	var queryDefinitions []cloudwatchlogstypes.QueryDefinition
+	for {
+		response, err := api.DescribeQueryDefinitions(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		queryDefinitions = append(queryDefinitions, response.QueryDefinitions...)
+		if response.NextToken == nil {
+			return queryDefinitions, nil
+		}
+		input.NextToken = response.NextToken
+	}
+}
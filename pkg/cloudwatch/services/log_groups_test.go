@@ -395,3 +395,60 @@ func TestGetLogGroupFields(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestGetQueryDefinitions(t *testing.T) {
+	t.Run("Should list the query definitions of every page", func(t *testing.T) {
+		mockLogsAPI := &mocks.LogsAPI{}
+		mockLogsAPI.On("DescribeQueryDefinitions", &cloudwatchlogs.DescribeQueryDefinitionsInput{
+			MaxResults:                aws.Int32(1000),
+			QueryDefinitionNamePrefix: utils.Pointer("lambda/"),
+		}).Return(&cloudwatchlogs.DescribeQueryDefinitionsOutput{
+			QueryDefinitions: []cloudwatchlogstypes.QueryDefinition{{
+				QueryDefinitionId: utils.Pointer("1"),
+				Name:              utils.Pointer("lambda/errors"),
+				QueryString:       utils.Pointer("filter @message like /ERROR/"),
+				LogGroupNames:     []string{"/aws/lambda/checkout"},
+			}},
+			NextToken: utils.Pointer("next"),
+		}, nil).Once()
+		mockLogsAPI.On("DescribeQueryDefinitions", mock.Anything).Return(&cloudwatchlogs.DescribeQueryDefinitionsOutput{
+			QueryDefinitions: []cloudwatchlogstypes.QueryDefinition{{
+				QueryDefinitionId: utils.Pointer("2"),
+				Name:              utils.Pointer("lambda/cold starts"),
+				QueryString:       utils.Pointer("SELECT * FROM `logGroups`"),
+				QueryLanguage:     cloudwatchlogstypes.QueryLanguageSql,
+			}},
+		}, nil).Once()
+		service := NewLogGroupsService(mockLogsAPI, false)
+
+		resp, err := service.GetQueryDefinitions(context.Background(), resources.QueryDefinitionsRequest{NamePrefix: utils.Pointer("lambda/")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []resources.ResourceResponse[resources.QueryDefinition]{
+			{
+				Label: "lambda/errors",
+				Value: resources.QueryDefinition{
+					Id: "1", Name: "lambda/errors", QueryString: "filter @message like /ERROR/",
+					LogGroupNames: []string{"/aws/lambda/checkout"},
+				},
+			},
+			{
+				Label: "lambda/cold starts",
+				Value: resources.QueryDefinition{
+					Id: "2", Name: "lambda/cold starts", QueryString: "SELECT * FROM `logGroups`", QueryLanguage: "SQL",
+				},
+			},
+		}, resp)
+		mockLogsAPI.AssertNumberOfCalls(t, "DescribeQueryDefinitions", 2)
+	})
+
+	t.Run("Should return an error when the api fails", func(t *testing.T) {
+		mockLogsAPI := &mocks.LogsAPI{}
+		mockLogsAPI.On("DescribeQueryDefinitions", mock.Anything).Return((*cloudwatchlogs.DescribeQueryDefinitionsOutput)(nil), fmt.Errorf("access denied"))
+		service := NewLogGroupsService(mockLogsAPI, false)
+
+		_, err := service.GetQueryDefinitions(context.Background(), resources.QueryDefinitionsRequest{})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+const (
+	// metricMetadataLookback is the time ListMetrics lists metrics without datapoints for
+	metricMetadataLookback = 14 * 24 * time.Hour
+	metricMetadataPeriod   = 3600
+	// maxProbedSeries is the number of series a single GetMetricData request returns hourly datapoints of for the
+	// lookback, as a request returns at most 100,800 datapoints
+	maxProbedSeries = 300
+)
+
+type MetricMetadataService struct {
+	metricsClient    models.MetricsClientProvider
+	metricDataClient cloudwatch.GetMetricDataAPIClient
+}
+
+var NewMetricMetadataService = func(metricsClient models.MetricsClientProvider, metricDataClient cloudwatch.GetMetricDataAPIClient) models.MetricMetadataProvider {
+	return &MetricMetadataService{metricsClient: metricsClient, metricDataClient: metricDataClient}
+}
+
+// GetMetricMetadata returns the series of a metric matching the dimension filter of the request, which of them are
+// recently active according to ListMetrics, and when they had their first and last datapoints according to a
+// GetMetricData request probing the sample count of the series.
+func (s *MetricMetadataService) GetMetricMetadata(ctx context.Context, r resources.MetricMetadataRequest) (resources.MetricMetadata, error) {
+	input := &cloudwatch.ListMetricsInput{Namespace: aws.String(r.Namespace), MetricName: aws.String(r.MetricName)}
+	setDimensionFilter(input, r.DimensionFilter)
+	setAccount(input, r.ResourceRequest)
+	metrics, err := s.metricsClient.ListMetricsWithPageLimit(ctx, input)
+	if err != nil {
+		return resources.MetricMetadata{}, fmt.Errorf("ListMetrics error: %w", err)
+	}
+
+	activeInput := *input
+	activeInput.RecentlyActive = cloudwatchtypes.RecentlyActivePt3h
+	activeMetrics, err := s.metricsClient.ListMetricsWithPageLimit(ctx, &activeInput)
+	if err != nil {
+		return resources.MetricMetadata{}, fmt.Errorf("ListMetrics error: %w", err)
+	}
+	active := make(map[string]bool, len(activeMetrics))
+	for _, metric := range activeMetrics {
+		active[seriesKey(metric)] = true
+	}
+
+	response := resources.MetricMetadata{
+		Namespace:  r.Namespace,
+		MetricName: r.MetricName,
+		Series:     make([]resources.MetricSeries, 0, len(metrics)),
+	}
+	for _, metric := range metrics {
+		dimensions := make(map[string]string, len(metric.Metric.Dimensions))
+		for _, dimension := range metric.Metric.Dimensions {
+			dimensions[aws.ToString(dimension.Name)] = aws.ToString(dimension.Value)
+		}
+		response.Series = append(response.Series, resources.MetricSeries{
+			AccountId:  metric.AccountId,
+			Dimensions: dimensions,
+			Active:     active[seriesKey(metric)],
+		})
+	}
+
+	probed := metrics
+	if len(probed) > maxProbedSeries {
+		probed = probed[:maxProbedSeries]
+		response.Truncated = true
+	}
+	if err := s.probeSeries(ctx, probed, response.Series); err != nil {
+		return resources.MetricMetadata{}, err
+	}
+	for _, series := range response.Series {
+		if series.LastSeen != nil && (response.LastSeen == nil || series.LastSeen.After(*response.LastSeen)) {
+			response.LastSeen = series.LastSeen
+		}
+	}
+
+	return response, nil
+}
+
+// probeSeries sets the first and last datapoints of the series of the metrics, in the same order, from their
+// hourly sample counts.
+func (s *MetricMetadataService) probeSeries(ctx context.Context, metrics []resources.MetricResponse, series []resources.MetricSeries) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	endTime := time.Now()
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(endTime.Add(-metricMetadataLookback)),
+		EndTime:   aws.Time(endTime),
+		ScanBy:    cloudwatchtypes.ScanByTimestampDescending,
+	}
+	for i, metric := range metrics {
+		input.MetricDataQueries = append(input.MetricDataQueries, cloudwatchtypes.MetricDataQuery{
+			Id: aws.String(fmt.Sprintf("m%d", i)),
+			MetricStat: &cloudwatchtypes.MetricStat{
+				Metric: &metric.Metric,
+				Period: aws.Int32(metricMetadataPeriod),
+				Stat:   aws.String("SampleCount"),
+			},
+			AccountId:  metric.AccountId,
+			ReturnData: aws.Bool(true),
+		})
+	}
+
+	paginator := cloudwatch.NewGetMetricDataPaginator(s.metricDataClient, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("GetMetricData error: %w", err)
+		}
+		for _, result := range page.MetricDataResults {
+			var i int
+			if _, err := fmt.Sscanf(aws.ToString(result.Id), "m%d", &i); err != nil || i >= len(series) {
+				continue
+			}
+			for j, timestamp := range result.Timestamps {
+				if j < len(result.Values) && result.Values[j] == 0 {
+					continue
+				}
+				if series[i].FirstSeen == nil || timestamp.Before(*series[i].FirstSeen) {
+					series[i].FirstSeen = aws.Time(timestamp)
+				}
+				if series[i].LastSeen == nil || timestamp.After(*series[i].LastSeen) {
+					series[i].LastSeen = aws.Time(timestamp)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// seriesKey identifies the series of a metric across ListMetrics requests.
+func seriesKey(metric resources.MetricResponse) string {
+	dimensions := make([]string, 0, len(metric.Metric.Dimensions))
+	for _, dimension := range metric.Metric.Dimensions {
+		dimensions = append(dimensions, aws.ToString(dimension.Name)+"="+aws.ToString(dimension.Value))
+	}
+	slices.Sort(dimensions)
+	return aws.ToString(metric.AccountId) + "/" + strings.Join(dimensions, ",")
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func TestGetMetricMetadata(t *testing.T) {
+	function := func(name string) resources.MetricResponse {
+		return resources.MetricResponse{Metric: cloudwatchtypes.Metric{
+			Namespace:  aws.String("AWS/Lambda"),
+			MetricName: aws.String("Invocations"),
+			Dimensions: []cloudwatchtypes.Dimension{{Name: aws.String("FunctionName"), Value: aws.String(name)}},
+		}}
+	}
+	isRecentlyActive := func(input *cloudwatch.ListMetricsInput) bool {
+		return input.RecentlyActive == cloudwatchtypes.RecentlyActivePt3h
+	}
+	request := resources.MetricMetadataRequest{
+		ResourceRequest: &resources.ResourceRequest{Region: "us-east-1"},
+		Namespace:       "AWS/Lambda",
+		MetricName:      "Invocations",
+		DimensionFilter: []*resources.Dimension{{Name: "FunctionName"}},
+	}
+
+	t.Run("Should return the active series and their first and last datapoints", func(t *testing.T) {
+		metricsClient := &mocks.FakeMetricsClient{}
+		metricsClient.On("ListMetricsWithPageLimit", mock.MatchedBy(isRecentlyActive)).Return([]resources.MetricResponse{function("checkout")}, nil)
+		metricsClient.On("ListMetricsWithPageLimit", mock.Anything).Return([]resources.MetricResponse{function("checkout"), function("cart"), function("legacy")}, nil)
+
+		hour := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		metricDataClient := &mocks.MetricsAPI{}
+		metricDataClient.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{
+			MetricDataResults: []cloudwatchtypes.MetricDataResult{
+				{Id: aws.String("m0"), Timestamps: []time.Time{hour, hour.Add(-time.Hour), hour.Add(-2 * time.Hour)}, Values: []float64{3, 1, 2}},
+				{Id: aws.String("m1"), Timestamps: []time.Time{hour.Add(-48 * time.Hour), hour.Add(-72 * time.Hour)}, Values: []float64{0, 5}},
+				{Id: aws.String("m2")},
+			},
+		}, nil)
+
+		metadata, err := NewMetricMetadataService(metricsClient, metricDataClient).GetMetricMetadata(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, resources.MetricMetadata{
+			Namespace:  "AWS/Lambda",
+			MetricName: "Invocations",
+			LastSeen:   aws.Time(hour),
+			Series: []resources.MetricSeries{
+				{Dimensions: map[string]string{"FunctionName": "checkout"}, Active: true, FirstSeen: aws.Time(hour.Add(-2 * time.Hour)), LastSeen: aws.Time(hour)},
+				{Dimensions: map[string]string{"FunctionName": "cart"}, FirstSeen: aws.Time(hour.Add(-72 * time.Hour)), LastSeen: aws.Time(hour.Add(-72 * time.Hour))},
+				{Dimensions: map[string]string{"FunctionName": "legacy"}},
+			},
+		}, metadata)
+
+		listInput := metricsClient.Calls[0].Arguments.Get(0).(*cloudwatch.ListMetricsInput)
+		assert.Equal(t, "AWS/Lambda", *listInput.Namespace)
+		assert.Equal(t, "Invocations", *listInput.MetricName)
+		assert.Equal(t, []cloudwatchtypes.DimensionFilter{{Name: aws.String("FunctionName")}}, listInput.Dimensions)
+
+		input := metricDataClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		assert.Equal(t, 14*24*time.Hour, input.EndTime.Sub(*input.StartTime))
+		require.Len(t, input.MetricDataQueries, 3)
+		assert.Equal(t, "m1", *input.MetricDataQueries[1].Id)
+		assert.Equal(t, "cart", *input.MetricDataQueries[1].MetricStat.Metric.Dimensions[0].Value)
+		assert.Equal(t, "SampleCount", *input.MetricDataQueries[1].MetricStat.Stat)
+		assert.Equal(t, int32(3600), *input.MetricDataQueries[1].MetricStat.Period)
+	})
+
+	t.Run("Should only probe as many series as a GetMetricData request returns", func(t *testing.T) {
+		metrics := make([]resources.MetricResponse, maxProbedSeries+1)
+		for i := range metrics {
+			metrics[i] = function("function")
+		}
+		metricsClient := &mocks.FakeMetricsClient{}
+		metricsClient.On("ListMetricsWithPageLimit", mock.Anything).Return(metrics, nil)
+		metricDataClient := &mocks.MetricsAPI{}
+		metricDataClient.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{}, nil)
+
+		metadata, err := NewMetricMetadataService(metricsClient, metricDataClient).GetMetricMetadata(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, metadata.Truncated)
+		assert.Len(t, metadata.Series, maxProbedSeries+1)
+		assert.Len(t, metricDataClient.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput).MetricDataQueries, maxProbedSeries)
+	})
+
+	t.Run("Should not probe metrics without series", func(t *testing.T) {
+		metricsClient := &mocks.FakeMetricsClient{}
+		metricsClient.On("ListMetricsWithPageLimit", mock.Anything).Return([]resources.MetricResponse{}, nil)
+		metricDataClient := &mocks.MetricsAPI{}
+
+		metadata, err := NewMetricMetadataService(metricsClient, metricDataClient).GetMetricMetadata(context.Background(), request)
+		require.NoError(t, err)
+		assert.Empty(t, metadata.Series)
+		assert.Nil(t, metadata.LastSeen)
+		metricDataClient.AssertNotCalled(t, "GetMetricData", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should return an error if GetMetricData fails", func(t *testing.T) {
+		metricsClient := &mocks.FakeMetricsClient{}
+		metricsClient.On("ListMetricsWithPageLimit", mock.Anything).Return([]resources.MetricResponse{function("checkout")}, nil)
+		metricDataClient := &mocks.MetricsAPI{}
+		metricDataClient.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{}, errors.New("access denied"))
+
+		_, err := NewMetricMetadataService(metricsClient, metricDataClient).GetMetricMetadata(context.Background(), request)
+		assert.EqualError(t, err, "GetMetricData error: access denied")
+	})
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"sort"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/patrickmn/go-cache"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/constants"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
@@ -13,49 +16,112 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
 
+// OptInStatusNotOptedIn is the opt-in status of regions that aren't enabled in the account.
+const OptInStatusNotOptedIn = "not-opted-in"
+
 type RegionsService struct {
 	models.EC2APIProvider
 	log.Logger
+
+	defaultRegion string
+	regionsCache  *cache.Cache
+	// regionsCacheKey is the key of the regions in regionsCache, which identifies the account they're described in
+	regionsCacheKey string
 }
 
-var NewRegionsService = func(ec2client models.EC2APIProvider, logger log.Logger) models.RegionsAPIProvider {
+var NewRegionsService = func(ec2client models.EC2APIProvider, logger log.Logger, defaultRegion string, regionsCache *cache.Cache,
+	regionsCacheKey string) models.RegionsAPIProvider {
 	return &RegionsService{
 		ec2client,
 		logger,
+		defaultRegion,
+		regionsCache,
+		regionsCacheKey,
 	}
 }
 
-func mergeEC2RegionsAndConstantRegions(regions map[string]struct{}, ec2Regions []ec2types.Region) {
+// mergeEC2RegionsAndConstantRegions adds the regions returned by ec2 to the static regions, keyed by region name
+// so that regions present in both lists are only returned once. The value is the opt-in status of the region.
+func mergeEC2RegionsAndConstantRegions(regions map[string]string, ec2Regions []ec2types.Region) {
 	for _, region := range ec2Regions {
-		if _, ok := regions[*region.RegionName]; !ok {
-			regions[*region.RegionName] = struct{}{}
+		regions[*region.RegionName] = aws.ToString(region.OptInStatus)
+	}
+}
+
+// describeAllRegions returns all regions of the account, including opt-in regions that are not enabled. Successful
+// responses are cached under the key of the account they're described in until the entry expires, which is after an
+// hour for the cache of the data source, as the regions of an account rarely change.
+func (r *RegionsService) describeAllRegions(ctx context.Context) ([]ec2types.Region, error) {
+	if r.regionsCache != nil {
+		if cached, found := r.regionsCache.Get(r.regionsCacheKey); found {
+			if regions, ok := cached.([]ec2types.Region); ok {
+				return regions, nil
+			}
+		}
+	}
+
+	ec2Regions, err := r.DescribeRegions(ctx, &ec2.DescribeRegionsInput{AllRegions: aws.Bool(true)})
+	if err != nil || ec2Regions == nil {
+		return nil, err
+	}
+
+	if r.regionsCache != nil {
+		r.regionsCache.Set(r.regionsCacheKey, ec2Regions.Regions, cache.DefaultExpiration)
+	}
+	return ec2Regions.Regions, nil
+}
+
+// CachedOptInStatus returns the opt-in status of region in the account, if the regions service has fetched the
+// regions of the account into regionsCache under regionsCacheKey.
+func CachedOptInStatus(regionsCache *cache.Cache, regionsCacheKey string, region string) (string, bool) {
+	if regionsCache == nil {
+		return "", false
+	}
+	cached, found := regionsCache.Get(regionsCacheKey)
+	if !found {
+		return "", false
+	}
+	regions, ok := cached.([]ec2types.Region)
+	if !ok {
+		return "", false
+	}
+	for _, r := range regions {
+		if aws.ToString(r.RegionName) == region {
+			return aws.ToString(r.OptInStatus), true
 		}
 	}
+	return "", false
 }
 
 func (r *RegionsService) GetRegions(ctx context.Context) ([]resources.ResourceResponse[resources.Region], error) {
-	regions := constants.Regions()
+	regions := map[string]string{}
+	for region := range constants.RegionsForPartition(constants.PartitionForRegion(r.defaultRegion)) {
+		regions[region] = ""
+	}
 
 	result := make([]resources.ResourceResponse[resources.Region], 0)
 
-	ec2Regions, err := r.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
-	// we ignore this error and always send default regions
+	ec2Regions, err := r.describeAllRegions(ctx)
+	// we ignore this error and always send the static regions of the default region's partition
 	// we only fetch incase a user has enabled additional regions
 	// but we still log it in case the user is expecting to fetch regions specific to their account and are unable to
 	if err != nil {
-		r.Error("Failed to get regions: ", "error", err)
+		r.Error("Failed to get regions, falling back to the static region list: ", "error", err, "defaultRegion", r.defaultRegion)
 	}
 
-	if ec2Regions != nil {
-		mergeEC2RegionsAndConstantRegions(regions, ec2Regions.Regions)
-	}
+	mergeEC2RegionsAndConstantRegions(regions, ec2Regions)
 
-	for region := range regions {
-		result = append(result, resources.ResourceResponse[resources.Region]{
+	for region, optInStatus := range regions {
+		response := resources.ResourceResponse[resources.Region]{
 			Value: resources.Region{
-				Name: region,
+				Name:        region,
+				OptInStatus: optInStatus,
 			},
-		})
+		}
+		if optInStatus == OptInStatusNotOptedIn {
+			response.Label = fmt.Sprintf("%s (not enabled)", region)
+		}
+		result = append(result, response)
 	}
 
 	sort.Slice(result, func(i, j int) bool {
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -11,6 +13,7 @@ import (
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,7 +30,7 @@ func TestRegions(t *testing.T) {
 		}
 		ec2Mock := &mocks.EC2Mock{}
 		ec2Mock.On("DescribeRegions").Return(mockRegions, nil)
-		regions, err := NewRegionsService(ec2Mock, testLogger).GetRegions(context.Background())
+		regions, err := NewRegionsService(ec2Mock, testLogger, "us-east-1", nil, "ec2Regions").GetRegions(context.Background())
 		assert.NoError(t, err)
 		assert.Contains(t, regions, resources.ResourceResponse[resources.Region]{
 			Value: resources.Region{
@@ -47,7 +50,7 @@ func TestRegions(t *testing.T) {
 			Regions: []ec2types.Region{},
 		}
 		ec2Mock.On("DescribeRegions").Return(mockRegions, assert.AnError)
-		regions, err := NewRegionsService(ec2Mock, testLogger).GetRegions(context.Background())
+		regions, err := NewRegionsService(ec2Mock, testLogger, "us-east-1", nil, "ec2Regions").GetRegions(context.Background())
 		assert.NoError(t, err)
 		assert.Contains(t, regions, resources.ResourceResponse[resources.Region]{
 			Value: resources.Region{
@@ -55,4 +58,91 @@ func TestRegions(t *testing.T) {
 			},
 		})
 	})
+
+	t.Run("falls back to the static regions of the default region's partition when DescribeRegions is denied", func(t *testing.T) {
+		tests := []struct {
+			defaultRegion string
+			expected      string
+			unexpected    string
+		}{
+			{defaultRegion: "us-east-1", expected: "eu-west-1", unexpected: "cn-north-1"},
+			{defaultRegion: "us-gov-west-1", expected: "us-gov-east-1", unexpected: "us-east-1"},
+			{defaultRegion: "cn-north-1", expected: "cn-northwest-1", unexpected: "us-gov-west-1"},
+		}
+		for _, tc := range tests {
+			ec2Mock := &mocks.EC2Mock{}
+			ec2Mock.On("DescribeRegions").Return((*ec2.DescribeRegionsOutput)(nil), errors.New("AccessDeniedException"))
+			regions, err := NewRegionsService(ec2Mock, testLogger, tc.defaultRegion, nil, "ec2Regions").GetRegions(context.Background())
+			assert.NoError(t, err)
+			assert.Contains(t, regions, resources.ResourceResponse[resources.Region]{Value: resources.Region{Name: tc.expected}}, tc.defaultRegion)
+			assert.NotContains(t, regions, resources.ResourceResponse[resources.Region]{Value: resources.Region{Name: tc.unexpected}}, tc.defaultRegion)
+		}
+	})
+}
+
+func TestRegions_optInRegions(t *testing.T) {
+	mockRegions := &ec2.DescribeRegionsOutput{
+		Regions: []ec2types.Region{
+			{RegionName: utils.Pointer("us-east-1"), OptInStatus: utils.Pointer("opt-in-not-required")},
+			{RegionName: utils.Pointer("af-south-1"), OptInStatus: utils.Pointer("opted-in")},
+			{RegionName: utils.Pointer("me-south-1"), OptInStatus: utils.Pointer("not-opted-in")},
+		},
+	}
+
+	t.Run("marks regions that are not opted in and de-duplicates them with the static regions", func(t *testing.T) {
+		ec2Mock := &mocks.EC2Mock{}
+		ec2Mock.On("DescribeRegions").Return(mockRegions, nil)
+		regions, err := NewRegionsService(ec2Mock, testLogger, "us-east-1", nil, "ec2Regions").GetRegions(context.Background())
+		assert.NoError(t, err)
+
+		names := map[string]int{}
+		for _, region := range regions {
+			names[region.Value.Name]++
+		}
+		assert.Equal(t, 1, names["us-east-1"])
+		assert.Equal(t, 1, names["me-south-1"])
+		assert.Contains(t, regions, resources.ResourceResponse[resources.Region]{
+			Value: resources.Region{Name: "me-south-1", OptInStatus: "not-opted-in"},
+			Label: "me-south-1 (not enabled)",
+		})
+		assert.Contains(t, regions, resources.ResourceResponse[resources.Region]{
+			Value: resources.Region{Name: "af-south-1", OptInStatus: "opted-in"},
+		})
+	})
+
+	t.Run("caches the described regions", func(t *testing.T) {
+		ec2Mock := &mocks.EC2Mock{}
+		ec2Mock.On("DescribeRegions").Return(mockRegions, nil)
+		regionsCache := cache.New(time.Minute, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			_, err := NewRegionsService(ec2Mock, testLogger, "us-east-1", regionsCache, "ec2Regions").GetRegions(context.Background())
+			assert.NoError(t, err)
+		}
+		ec2Mock.AssertNumberOfCalls(t, "DescribeRegions", 1)
+	})
+
+	t.Run("caches the regions of each account separately", func(t *testing.T) {
+		ec2Mock := &mocks.EC2Mock{}
+		ec2Mock.On("DescribeRegions").Return(mockRegions, nil)
+		regionsCache := cache.New(time.Minute, time.Minute)
+
+		for _, key := range []string{"role-a", "role-b", "role-a"} {
+			_, err := NewRegionsService(ec2Mock, testLogger, "us-east-1", regionsCache, key).GetRegions(context.Background())
+			assert.NoError(t, err)
+		}
+		ec2Mock.AssertNumberOfCalls(t, "DescribeRegions", 2)
+	})
+
+	t.Run("does not cache failed calls", func(t *testing.T) {
+		ec2Mock := &mocks.EC2Mock{}
+		ec2Mock.On("DescribeRegions").Return((*ec2.DescribeRegionsOutput)(nil), errors.New("AccessDeniedException"))
+		regionsCache := cache.New(time.Minute, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			_, err := NewRegionsService(ec2Mock, testLogger, "us-east-1", regionsCache, "ec2Regions").GetRegions(context.Background())
+			assert.NoError(t, err)
+		}
+		ec2Mock.AssertNumberOfCalls(t, "DescribeRegions", 2)
+	})
 }
@@ -0,0 +1,214 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const (
+	// drainTimeout bounds how long disposing of a data source waits for its in-flight operations to finish once
+	// they're cancelled, e.g. for the Logs Insights queries of cancelled sync queries to be stopped
+	drainTimeout = 10 * time.Second
+	// stopRunningQueriesTimeout bounds stopping the Logs Insights queries left running when a data source is disposed of
+	stopRunningQueriesTimeout = 10 * time.Second
+)
+
+var errShuttingDown = errors.New("the data source is shutting down")
+
+// operations tracks the in-flight operations of a data source, i.e. its queries, resource requests and streams, so
+// that disposing of it can stop accepting new ones and wait for the ones in flight.
+type operations struct {
+	mu       sync.Mutex
+	draining bool
+	disposed bool
+	wg       sync.WaitGroup
+	// ctx is cancelled when the data source is disposed of, cancelling the contexts of the in-flight operations
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newOperations() *operations {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &operations{ctx: ctx, cancel: cancel}
+}
+
+// begin registers an operation, returning its context, which is also cancelled when the data source is disposed of,
+// and the function to call once it's done. It fails with errShuttingDown once the data source is being disposed of.
+// A nil tracker tracks no operations.
+func (o *operations) begin(ctx context.Context) (context.Context, func(), error) {
+	if o == nil {
+		return ctx, func() {}, nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.draining {
+		return ctx, func() {}, errShuttingDown
+	}
+	o.wg.Add(1)
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(o.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		o.wg.Done()
+	}, nil
+}
+
+// dispose returns whether the data source is disposed of for the first time, as Shutdown may dispose of an instance
+// the SDK disposes of too. A nil tracker is disposed of every time.
+func (o *operations) dispose() bool {
+	if o == nil {
+		return true
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	first := !o.disposed
+	o.disposed = true
+	return first
+}
+
+// drain stops accepting new operations, cancels the in-flight ones and waits up to timeout for them to finish. It
+// returns whether they finished in time.
+func (o *operations) drain(timeout time.Duration) bool {
+	if o == nil {
+		return true
+	}
+	o.mu.Lock()
+	o.draining = true
+	o.mu.Unlock()
+	o.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// instances are the data source instances that haven't been disposed of, which Shutdown disposes of when the plugin
+// exits, as the SDK only disposes of instances replaced by new settings.
+var instances = struct {
+	mu  sync.Mutex
+	all map[*DataSource]struct{}
+}{all: map[*DataSource]struct{}{}}
+
+func registerInstance(ds *DataSource) {
+	instances.mu.Lock()
+	defer instances.mu.Unlock()
+	instances.all[ds] = struct{}{}
+}
+
+func unregisterInstance(ds *DataSource) {
+	instances.mu.Lock()
+	defer instances.mu.Unlock()
+	delete(instances.all, ds)
+}
+
+// Shutdown disposes of the data source instances in use, concurrently, so that exiting the plugin, e.g. during a
+// rolling upgrade of Grafana, doesn't leave their Logs Insights queries running.
+func Shutdown() {
+	instances.mu.Lock()
+	all := make([]*DataSource, 0, len(instances.all))
+	for ds := range instances.all {
+		all = append(all, ds)
+	}
+	instances.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, ds := range all {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ds.Dispose()
+		}()
+	}
+	wg.Wait()
+}
+
+// Dispose drains the data source when it's replaced by new settings or the plugin exits: it stops accepting new
+// operations and recorded queries, cancels the in-flight operations, whose Logs Insights queries are then stopped,
+// stops the Logs Insights queries left running that can't be recovered by the next instance, persists the ones
+// that can be, and drops the AWS configs built with its settings.
+func (ds *DataSource) Dispose() {
+	if !ds.operations.dispose() {
+		return
+	}
+	if ds.recordedQueries != nil {
+		ds.recordedQueries.stop()
+	}
+	if !ds.operations.drain(drainTimeout) {
+		ds.logger.Warn("Timed out waiting for the in-flight operations of the data source to finish", "timeout", drainTimeout)
+	}
+	ds.stopRunningLogsQueries()
+	ds.runningLogs.flush()
+	if ds.awsConfigCache != nil {
+		ds.awsConfigCache.Flush()
+	}
+	unregisterInstance(ds)
+}
+
+// stopRunningLogsQueries stops the Logs Insights queries the data source started that are still running, so that they
+// don't keep consuming the concurrent queries quota. Queries persisted for the next instance to recover are left
+// running, as their panels re-attach to them.
+func (ds *DataSource) stopRunningLogsQueries() {
+	if ds.runningLogs == nil || ds.runningLogs.path != "" {
+		return
+	}
+	queries := ds.runningLogs.all()
+	if len(queries) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopRunningQueriesTimeout)
+	defer cancel()
+	for _, query := range queries {
+		queryCtx := ds.runningLogsQueryContext(ctx, query)
+		logsClient, err := ds.getCWLogsClient(queryCtx, query.Region)
+		if err == nil {
+			_, err = logsClient.StopQuery(queryCtx, &cloudwatchlogs.StopQueryInput{QueryId: aws.String(query.QueryId)})
+		}
+		if err != nil {
+			ds.logger.Warn("Failed to stop a running Logs Insights query", "queryId", query.QueryId, "region", query.Region, "error", err)
+			continue
+		}
+		ds.runningLogs.remove(query.Region, query.QueryId)
+	}
+}
+
+// shuttingDownQueryResponse fails each query of req as the data source is shutting down.
+func shuttingDownQueryResponse(req *backend.QueryDataRequest) *backend.QueryDataResponse {
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		resp.Responses[query.RefID] = backend.ErrDataResponseWithSource(backend.Status(http.StatusServiceUnavailable), backend.ErrorSourcePlugin, errShuttingDown.Error())
+	}
+	return resp
+}
+
+// sendShuttingDown fails a resource request as the data source is shutting down.
+func sendShuttingDown(sender backend.CallResourceResponseSender) error {
+	body, err := json.Marshal(models.NewHttpError("error in CallResource", http.StatusServiceUnavailable, errShuttingDown))
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  http.StatusServiceUnavailable,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
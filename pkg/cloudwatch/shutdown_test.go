@@ -0,0 +1,154 @@
+package cloudwatch
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_operations(t *testing.T) {
+	t.Run("drain cancels the in-flight operations and waits for them", func(t *testing.T) {
+		ops := newOperations()
+		ctx, done, err := ops.begin(context.Background())
+		require.NoError(t, err)
+		finished := make(chan struct{})
+		go func() {
+			<-ctx.Done()
+			close(finished)
+			done()
+		}()
+
+		assert.True(t, ops.drain(time.Second))
+		<-finished
+	})
+
+	t.Run("drain times out waiting for operations ignoring cancellation", func(t *testing.T) {
+		ops := newOperations()
+		_, done, err := ops.begin(context.Background())
+		require.NoError(t, err)
+		defer done()
+
+		assert.False(t, ops.drain(10*time.Millisecond))
+	})
+
+	t.Run("begin fails once draining", func(t *testing.T) {
+		ops := newOperations()
+		assert.True(t, ops.drain(time.Second))
+
+		_, done, err := ops.begin(context.Background())
+		done()
+		assert.ErrorIs(t, err, errShuttingDown)
+	})
+
+	t.Run("dispose returns true the first time only", func(t *testing.T) {
+		ops := newOperations()
+		assert.True(t, ops.dispose())
+		assert.False(t, ops.dispose())
+	})
+}
+
+func TestDataSource_Dispose(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	var cli fakeCWLogsClient
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return &cli
+	}
+
+	t.Run("stops the running Logs Insights queries that can't be recovered", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.operations = newOperations()
+			ds.runningLogs = newRunningLogsQueries()
+		})
+		ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "a"}, time.Hour)
+
+		ds.Dispose()
+
+		require.Len(t, cli.calls.stopQuery, 1)
+		assert.Equal(t, "a", *cli.calls.stopQuery[0].QueryId)
+		assert.Equal(t, 0, ds.runningLogs.count("us-east-1", ""))
+	})
+
+	t.Run("stops the queries in the account of the org, role and user they were started for", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		provider := &roleRecordingConfigProvider{}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.operations = newOperations()
+			ds.runningLogs = newRunningLogsQueries()
+			ds.AWSConfigProvider = provider
+			ds.Settings.OrgRoleMap = map[string]string{"2": "arn:aws:iam::222222222222:role/org-2"}
+		})
+		orgCtx := backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 2})
+		ds.runningLogs.add(ds.newRunningLogsQuery(orgCtx, "us-east-1", "a", ""), time.Hour)
+
+		ds.Dispose()
+
+		require.Len(t, cli.calls.stopQuery, 1)
+		assert.Equal(t, []string{"arn:aws:iam::222222222222:role/org-2"}, provider.roles)
+		assert.Equal(t, 0, ds.runningLogs.count("us-east-1", "arn:aws:iam::222222222222:role/org-2"))
+	})
+
+	t.Run("stops the queries of users with the credentials of their last request", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.operations = newOperations()
+			ds.runningLogs = newRunningLogsQueries()
+			ds.Settings.UserIdentityPassThrough = true
+			ds.Settings.WebIdentityRoleMap = map[string]string{"login:alice": "arn:aws:iam::123456789012:role/alice"}
+			ds.userCredsCache = cache.New(userCredentialsExpiration, userCredentialsExpiration)
+		})
+		userCtx := backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 1, User: &backend.User{Login: "alice"}})
+		ds.userCredentials(aws.Config{}, &backend.User{Login: "alice"}, "arn:aws:iam::123456789012:role/alice", "id-token")
+		ds.runningLogs.add(ds.newRunningLogsQuery(userCtx, "us-east-1", "a", ""), time.Hour)
+
+		ds.Dispose()
+
+		require.Len(t, cli.calls.stopQuery, 1)
+		assert.Equal(t, 0, ds.runningLogs.count("us-east-1", "arn:aws:iam::123456789012:role/alice"))
+	})
+
+	t.Run("leaves the persisted queries running for the next instance to recover", func(t *testing.T) {
+		cli = fakeCWLogsClient{}
+		path := filepath.Join(t.TempDir(), "datasource.json")
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.operations = newOperations()
+			ds.runningLogs = loadRunningLogsQueries(path, log.NewNullLogger())
+		})
+		ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "a"}, time.Hour)
+
+		ds.Dispose()
+
+		assert.Empty(t, cli.calls.stopQuery)
+		assert.Equal(t, 1, loadRunningLogsQueries(path, log.NewNullLogger()).count("us-east-1", ""))
+	})
+
+	t.Run("fails the queries received once disposed of", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.operations = newOperations()
+		})
+		ds.Dispose()
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A"}, {RefID: "B"}},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Responses, 2)
+		assert.Equal(t, backend.Status(http.StatusServiceUnavailable), resp.Responses["A"].Status)
+		assert.EqualError(t, resp.Responses["B"].Error, errShuttingDown.Error())
+	})
+}
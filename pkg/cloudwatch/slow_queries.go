@@ -0,0 +1,191 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const slowQueryLogMessage = "Slow query"
+
+// maxSlowQueryValueLength truncates the strings of the logged queries, e.g. long Logs Insights queries, so that a slow
+// query doesn't flood the logs
+const maxSlowQueryValueLength = 2000
+
+// slowQueryRequest is a query of a slow request, as logged
+type slowQueryRequest struct {
+	RefId         string         `json:"refId"`
+	QueryType     string         `json:"queryType,omitempty"`
+	From          time.Time      `json:"from"`
+	To            time.Time      `json:"to"`
+	MaxDataPoints int64          `json:"maxDataPoints,omitempty"`
+	IntervalMs    int64          `json:"intervalMs,omitempty"`
+	Model         map[string]any `json:"model,omitempty"`
+}
+
+// slowQueryResponse is the response of a query of a slow request, as logged
+type slowQueryResponse struct {
+	RefId  string `json:"refId"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Frames int    `json:"frames"`
+	Rows   int    `json:"rows"`
+}
+
+// captureSlowQuery logs the queries, responses and AWS API calls of a request that took longer than the slow query
+// threshold of the data source, under a correlation ID that's added to the responses for the user to quote when they
+// report the slowness. Responses are copied rather than modified, as their frames may be cached.
+func (ds *DataSource) captureSlowQuery(ctx context.Context, req *backend.QueryDataRequest, resp *backend.QueryDataResponse, err error, timings *queryTimings, elapsed time.Duration) *backend.QueryDataResponse {
+	threshold := ds.Settings.SlowQueryThreshold.Duration
+	if threshold <= 0 || elapsed < threshold {
+		return resp
+	}
+	correlationId := uuid.NewString()
+
+	params := []any{
+		"correlationId", correlationId,
+		"durationMs", elapsed.Milliseconds(),
+		"thresholdMs", threshold.Milliseconds(),
+	}
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		params = append(params, "datasourceUID", req.PluginContext.DataSourceInstanceSettings.UID)
+	}
+	if req.PluginContext.User != nil {
+		params = append(params, "user", req.PluginContext.User.Login)
+	}
+	if dashboard, ok := dashboardFromContext(ctx); ok {
+		params = append(params, "dashboardUID", dashboard.uid, "panelId", dashboard.panelID)
+	}
+	timings.mu.Lock()
+	params = append(params,
+		"queueWaitMs", timings.queueWait.Milliseconds(),
+		"awsCallMs", timings.apiTime.Milliseconds(),
+		"awsCalls", timings.apiCalls,
+		"apiCalls", marshalSlowQueryValue(timings.calls),
+	)
+	timings.mu.Unlock()
+	params = append(params, "queries", marshalSlowQueryValue(ds.slowQueryRequests(req)))
+	if resp != nil {
+		params = append(params, "responses", marshalSlowQueryValue(slowQueryResponses(resp)))
+	}
+	if err != nil {
+		params = append(params, "error", err.Error())
+	}
+	ds.logger.FromContext(ctx).Warn(slowQueryLogMessage, params...)
+
+	if resp == nil {
+		return resp
+	}
+	notice := data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text: fmt.Sprintf("The query took %s. Quote the slow query ID %s when reporting it, so that its details can be found in the Grafana server logs.",
+			elapsed.Round(time.Millisecond), correlationId),
+	}
+	result := backend.NewQueryDataResponse()
+	for refId, response := range resp.Responses {
+		if response.Error != nil {
+			response.Error = fmt.Errorf("%w (slow query ID %s)", response.Error, correlationId)
+		}
+		frames := make(data.Frames, 0, len(response.Frames))
+		for _, frame := range response.Frames {
+			frameCopy := *frame
+			frameMeta := data.FrameMeta{}
+			if frame.Meta != nil {
+				frameMeta = *frame.Meta
+			}
+			frameMeta.Notices = append(slices.Clone(frameMeta.Notices), notice)
+			frameCopy.Meta = &frameMeta
+			frames = append(frames, &frameCopy)
+		}
+		response.Frames = frames
+		result.Responses[refId] = response
+	}
+	return result
+}
+
+// slowQueryRequests returns the queries of a slow request as they're logged. The masking rules of the data source
+// are applied to every string of the queries, as queries may filter on the values the rules redact from results.
+func (ds *DataSource) slowQueryRequests(req *backend.QueryDataRequest) []slowQueryRequest {
+	queries := make([]slowQueryRequest, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		var model map[string]any
+		if err := json.Unmarshal(query.JSON, &model); err == nil {
+			delete(model, "datasource")
+			ds.sanitizeSlowQueryValues(model)
+		}
+		queries = append(queries, slowQueryRequest{
+			RefId:         query.RefID,
+			QueryType:     query.QueryType,
+			From:          query.TimeRange.From,
+			To:            query.TimeRange.To,
+			MaxDataPoints: query.MaxDataPoints,
+			IntervalMs:    query.Interval.Milliseconds(),
+			Model:         model,
+		})
+	}
+	return queries
+}
+
+// sanitizeSlowQueryValues masks and truncates the strings of a query model in place.
+func (ds *DataSource) sanitizeSlowQueryValues(value any) any {
+	switch value := value.(type) {
+	case string:
+		for _, rule := range ds.maskingRules {
+			value = rule.pattern.ReplaceAllString(value, rule.replacement)
+		}
+		if len(value) > maxSlowQueryValueLength {
+			value = strings.ToValidUTF8(value[:maxSlowQueryValueLength], "") + "…"
+		}
+		return value
+	case map[string]any:
+		for key, item := range value {
+			value[key] = ds.sanitizeSlowQueryValues(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = ds.sanitizeSlowQueryValues(item)
+		}
+	}
+	return value
+}
+
+func slowQueryResponses(resp *backend.QueryDataResponse) []slowQueryResponse {
+	responses := make([]slowQueryResponse, 0, len(resp.Responses))
+	for refId, response := range resp.Responses {
+		summary := slowQueryResponse{RefId: refId, Status: int(response.Status), Frames: len(response.Frames)}
+		if summary.Status == 0 {
+			summary.Status = int(backend.StatusOK)
+			if response.Error != nil {
+				summary.Status = int(backend.StatusInternal)
+			}
+		}
+		if response.Error != nil {
+			summary.Error = response.Error.Error()
+		}
+		for _, frame := range response.Frames {
+			if rows, err := frame.RowLen(); err == nil {
+				summary.Rows += rows
+			}
+		}
+		responses = append(responses, summary)
+	}
+	slices.SortFunc(responses, func(a, b slowQueryResponse) int {
+		return strings.Compare(a.RefId, b.RefId)
+	})
+	return responses
+}
+
+func marshalSlowQueryValue(value any) string {
+	marshalled, err := json.Marshal(value)
+	if err != nil {
+		return err.Error()
+	}
+	return string(marshalled)
+}
@@ -0,0 +1,83 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestDataSource_captureSlowQuery(t *testing.T) {
+	maskingRules, err := compileMaskingRules([]models.MaskingRule{{Pattern: `[a-z]+@example\.com`}})
+	require.NoError(t, err)
+	newDatasource := func(logger log.Logger) *DataSource {
+		return newTestDatasource(func(ds *DataSource) {
+			ds.logger = logger
+			ds.maskingRules = maskingRules
+			ds.Settings.SlowQueryThreshold = models.Duration{Duration: 5 * time.Second}
+		})
+	}
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			User:                       &backend.User{Login: "alice"},
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "cw-uid"},
+		},
+		Queries: []backend.DataQuery{{
+			RefID: "A",
+			JSON: json.RawMessage(`{"queryMode":"Logs","datasource":{"uid":"cw-uid"},
+				"expression":"filter user = 'bob@example.com'","logGroups":[{"arn":"arn:aws:logs:us-east-1:123456789012:log-group:app"}]}`),
+		}},
+	}
+	frame := data.NewFrame("logs", data.NewField("@message", nil, []string{"a", "b"}))
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{frame}},
+		"B": {Error: errors.New("throttled"), Status: backend.StatusTooManyRequests},
+	}}
+	timings := &queryTimings{apiCalls: 1, calls: []apiCallRecord{{Service: "CloudWatch Logs", Operation: "StartQuery", Region: "us-east-1"}}}
+
+	t.Run("queries faster than the threshold aren't logged", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewNullLogger()}
+
+		assert.Same(t, resp, newDatasource(logger).captureSlowQuery(context.Background(), req, resp, nil, timings, time.Second))
+		assert.Empty(t, logger.warns)
+	})
+
+	t.Run("slow queries are logged sanitized with a correlation ID shown to the user", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewNullLogger()}
+
+		result := newDatasource(logger).captureSlowQuery(context.Background(), req, resp, nil, timings, 7*time.Second)
+
+		require.Len(t, logger.warns, 1)
+		assert.Equal(t, slowQueryLogMessage, logger.warns[0].msg)
+		args := map[string]any{}
+		for i := 0; i+1 < len(logger.warns[0].args); i += 2 {
+			args[logger.warns[0].args[i].(string)] = logger.warns[0].args[i+1]
+		}
+		correlationId := args["correlationId"].(string)
+		require.NotEmpty(t, correlationId)
+		assert.Equal(t, int64(7000), args["durationMs"])
+		assert.Equal(t, "cw-uid", args["datasourceUID"])
+		assert.Equal(t, "alice", args["user"])
+		assert.Contains(t, args["apiCalls"], `"operation":"StartQuery"`)
+		assert.Contains(t, args["queries"], `filter user = '****'`)
+		assert.NotContains(t, args["queries"], "bob@example.com")
+		assert.NotContains(t, args["queries"], "datasource")
+		assert.JSONEq(t, `[{"refId":"A","status":200,"frames":1,"rows":2},{"refId":"B","status":429,"error":"throttled","frames":0,"rows":0}]`,
+			args["responses"].(string))
+
+		notices := result.Responses["A"].Frames[0].Meta.Notices
+		require.Len(t, notices, 1)
+		assert.Contains(t, notices[0].Text, correlationId)
+		assert.Nil(t, frame.Meta)
+		assert.ErrorContains(t, result.Responses["B"].Error, "throttled (slow query ID "+correlationId+")")
+	})
+}
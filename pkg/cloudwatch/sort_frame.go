@@ -1,9 +1,16 @@
 package cloudwatch
 
 import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 )
 
 // ByTime implements sort.Interface for data.Frame based on the frame's time field
@@ -47,3 +54,114 @@ func (a ByTime) Less(i, j int) bool {
 
 	return (timeField.At(i).(*time.Time)).Before(*timeField.At(j).(*time.Time))
 }
+
+// sortFramesBySeries orders the frames of a query's series by name and then by their labels, so that series keep their
+// position, and therefore their color and legend order, across refreshes regardless of the order CloudWatch returns them in.
+func sortFramesBySeries(frames data.Frames) {
+	slices.SortStableFunc(frames, func(a, b *data.Frame) int {
+		return cmp.Or(
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(seriesLabels(a), seriesLabels(b)),
+		)
+	})
+}
+
+func seriesLabels(frame *data.Frame) string {
+	for _, field := range frame.Fields {
+		if field.Labels != nil {
+			return field.Labels.String()
+		}
+	}
+	return ""
+}
+
+// sortAndLimitSeries orders the series frames of a query by the value of their datapoints the query sorts by, and
+// keeps the number of series the query is limited to, so that panels showing the top series of a search matching
+// thousands of them don't get all of them. Series without datapoints come last whatever the order.
+func sortAndLimitSeries(frames data.Frames, query *models.CloudWatchQuery) data.Frames {
+	if query.SeriesSortBy != "" {
+		scores := make(map[*data.Frame]float64, len(frames))
+		for _, frame := range frames {
+			scores[frame] = seriesScore(frame, query.SeriesSortBy)
+		}
+		slices.SortStableFunc(frames, func(a, b *data.Frame) int {
+			scoreA, scoreB := scores[a], scores[b]
+			if math.IsNaN(scoreA) || math.IsNaN(scoreB) {
+				return cmp.Compare(boolToInt(math.IsNaN(scoreA)), boolToInt(math.IsNaN(scoreB)))
+			}
+			if query.SeriesSortOrder == dataquery.SeriesSortOrderAsc {
+				return cmp.Compare(scoreA, scoreB)
+			}
+			return cmp.Compare(scoreB, scoreA)
+		})
+	}
+
+	if query.SeriesLimit > 0 && len(frames) > query.SeriesLimit {
+		frames = frames[:query.SeriesLimit]
+	}
+	return frames
+}
+
+// capSeries drops the series of a query beyond the series cap of the data source, and warns about it, so that a search
+// accidentally matching thousands of series doesn't send them all to the browser. Unless the query orders its series,
+// they are ordered by name and labels first so that the same series are kept on every refresh.
+func capSeries(frames data.Frames, query *models.CloudWatchQuery, maxSeries int) data.Frames {
+	if maxSeries <= 0 || len(frames) <= maxSeries {
+		return frames
+	}
+	if query.SeriesSortBy == "" {
+		sortFramesBySeries(frames)
+	}
+
+	total := len(frames)
+	frames = frames[:maxSeries]
+	frames[0].AppendNotices(data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text: fmt.Sprintf("The query returned %d series, only the first %d are shown. Narrow down the query, or sort and limit its series, to choose which series are shown.",
+			total, maxSeries),
+	})
+	return frames
+}
+
+// seriesScore returns the value of the datapoints of a series frame it's sorted by, or NaN if it has no datapoints.
+func seriesScore(frame *data.Frame, sortBy dataquery.SeriesSortBy) float64 {
+	if sortBy == dataquery.SeriesSortByLast {
+		if value, _, ok := latestDatapoint(frame); ok {
+			return value
+		}
+		return math.NaN()
+	}
+
+	var sum, maximum float64
+	count := 0
+	for _, field := range frame.Fields {
+		if !field.Type().Numeric() {
+			continue
+		}
+		for row := 0; row < field.Len(); row++ {
+			value, err := field.NullableFloatAt(row)
+			if err != nil || value == nil || math.IsNaN(*value) {
+				continue
+			}
+			if count == 0 || *value > maximum {
+				maximum = *value
+			}
+			sum += *value
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	if sortBy == dataquery.SeriesSortByMax {
+		return maximum
+	}
+	return sum / float64(count)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -8,7 +8,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFrameSort(t *testing.T) {
@@ -86,3 +90,118 @@ func TestFrameSort(t *testing.T) {
 		assert.Equal(t, *stringField.At(2).(*string), "test message 3")
 	})
 }
+
+func TestSortFramesBySeries(t *testing.T) {
+	newSeries := func(name string, labels data.Labels) *data.Frame {
+		return data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []*time.Time{}),
+			data.NewField(data.TimeSeriesValueFieldName, labels, []*float64{}))
+	}
+	frames := data.Frames{
+		newSeries("i-2", data.Labels{"InstanceId": "i-2"}),
+		newSeries("CPU", data.Labels{"InstanceId": "i-3"}),
+		newSeries("i-1", data.Labels{"InstanceId": "i-1"}),
+		newSeries("CPU", data.Labels{"InstanceId": "i-0"}),
+	}
+
+	sortFramesBySeries(frames)
+
+	var order []string
+	for _, frame := range frames {
+		order = append(order, frame.Name+" "+frame.Fields[1].Labels["InstanceId"])
+	}
+	assert.Equal(t, []string{"CPU i-0", "CPU i-3", "i-1 i-1", "i-2 i-2"}, order)
+}
+
+func TestSortAndLimitSeries(t *testing.T) {
+	now := time.Now()
+	value := func(v float64) *float64 { return &v }
+	newSeries := func(name string, values ...*float64) *data.Frame {
+		times := make([]*time.Time, len(values))
+		for i := range values {
+			timestamp := now.Add(time.Duration(i-len(values)) * time.Minute)
+			times[i] = &timestamp
+		}
+		return data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			data.NewField(data.TimeSeriesValueFieldName, nil, values))
+	}
+	series := func() data.Frames {
+		return data.Frames{
+			newSeries("a", value(1), value(9), value(2)),
+			newSeries("b", value(5), value(5), nil),
+			newSeries("c"),
+			newSeries("d", value(4), value(3), value(3)),
+		}
+	}
+	names := func(frames data.Frames) []string {
+		var result []string
+		for _, frame := range frames {
+			result = append(result, frame.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		query    *models.CloudWatchQuery
+		expected []string
+	}{
+		{"last value descending", &models.CloudWatchQuery{SeriesSortBy: dataquery.SeriesSortByLast, SeriesSortOrder: dataquery.SeriesSortOrderDesc}, []string{"b", "d", "a", "c"}},
+		{"average descending", &models.CloudWatchQuery{SeriesSortBy: dataquery.SeriesSortByAvg, SeriesSortOrder: dataquery.SeriesSortOrderDesc}, []string{"b", "a", "d", "c"}},
+		{"max ascending", &models.CloudWatchQuery{SeriesSortBy: dataquery.SeriesSortByMax, SeriesSortOrder: dataquery.SeriesSortOrderAsc}, []string{"d", "b", "a", "c"}},
+		{"top 2 by max", &models.CloudWatchQuery{SeriesSortBy: dataquery.SeriesSortByMax, SeriesSortOrder: dataquery.SeriesSortOrderDesc, SeriesLimit: 2}, []string{"a", "b"}},
+		{"limit without sort keeps the order", &models.CloudWatchQuery{SeriesLimit: 3}, []string{"a", "b", "c"}},
+		{"limit above the number of series", &models.CloudWatchQuery{SeriesLimit: 10}, []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, names(sortAndLimitSeries(series(), tt.query)))
+		})
+	}
+}
+
+func TestCapSeries(t *testing.T) {
+	newSeries := func(name string) *data.Frame {
+		return data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []*time.Time{}),
+			data.NewField(data.TimeSeriesValueFieldName, nil, []*float64{}))
+	}
+	series := func() data.Frames {
+		return data.Frames{newSeries("c"), newSeries("a"), newSeries("d"), newSeries("b")}
+	}
+	names := func(frames data.Frames) []string {
+		var result []string
+		for _, frame := range frames {
+			result = append(result, frame.Name)
+		}
+		return result
+	}
+
+	t.Run("keeps the first series by name and warns about the others", func(t *testing.T) {
+		frames := capSeries(series(), &models.CloudWatchQuery{}, 2)
+
+		assert.Equal(t, []string{"a", "b"}, names(frames))
+		require.NotNil(t, frames[0].Meta)
+		assert.Equal(t, []data.Notice{{
+			Severity: data.NoticeSeverityWarning,
+			Text:     "The query returned 4 series, only the first 2 are shown. Narrow down the query, or sort and limit its series, to choose which series are shown.",
+		}}, frames[0].Meta.Notices)
+		assert.Nil(t, frames[1].Meta)
+	})
+
+	t.Run("keeps the order of sorted series", func(t *testing.T) {
+		frames := capSeries(series(), &models.CloudWatchQuery{SeriesSortBy: dataquery.SeriesSortByLast}, 3)
+
+		assert.Equal(t, []string{"c", "a", "d"}, names(frames))
+	})
+
+	t.Run("leaves series under the cap or without cap untouched", func(t *testing.T) {
+		for _, maxSeries := range []int{4, 0, -1} {
+			frames := capSeries(series(), &models.CloudWatchQuery{}, maxSeries)
+
+			assert.Equal(t, []string{"c", "a", "d", "b"}, names(frames), maxSeries)
+			assert.Nil(t, frames[0].Meta, maxSeries)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// discoveryCacheExpiration is how long the responses of the discovery routes are kept to be served when AWS
+// throttles the requests refreshing them.
+const discoveryCacheExpiration = time.Hour * 6
+
+// isThrottlingError returns whether err is an AWS API throttling the request.
+func isThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	_, ok := retry.DefaultThrottleErrorCodes[apiErr.ErrorCode()]
+	return ok
+}
+
+// newDiscoveryHttpError returns the error of a discovery route whose AWS API call failed, coded as throttled when AWS
+// throttled it so that a stale response can be served in its place.
+func newDiscoveryHttpError(message string, err error) *models.HttpError {
+	httpError := models.NewHttpError(message, http.StatusInternalServerError, err)
+	if isThrottlingError(err) {
+		httpError.Code = models.HttpErrorCodeThrottled
+	}
+	return httpError
+}
+
+// staleOnThrottle caches the successful responses of the discovery route name, and serves the cached response of a
+// request, flagged as stale, when AWS throttles it. This keeps the autocompletion of the query editor usable during
+// throttling storms, which a dashboard full of editors easily causes.
+func (ds *DataSource) staleOnThrottle(name string, handleFunc models.RouteHandlerFunc) models.RouteHandlerFunc {
+	return func(ctx context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+		// the key is scoped by role and user, so that organizations and users mapped to different roles don't share
+		// their resources
+		key, err := ds.roleScopedCacheKey(ctx, map[string]any{"route": name, "parameters": parameters.Encode()})
+		if err != nil {
+			return handleFunc(ctx, parameters)
+		}
+
+		jsonResponse, httpError := handleFunc(ctx, parameters)
+		if httpError == nil {
+			ds.discoveryCache.Set(key, jsonResponse, cache.DefaultExpiration)
+			return jsonResponse, nil
+		}
+		if httpError.Code != models.HttpErrorCodeThrottled {
+			return nil, httpError
+		}
+		cached, ok := ds.discoveryCache.Get(key)
+		if !ok {
+			return nil, httpError
+		}
+		staleResponse, err := markStale(cached.([]byte))
+		if err != nil {
+			return nil, httpError
+		}
+		ds.logger.FromContext(ctx).Warn("Serving a stale resource response as the request was throttled", "route", name)
+		return staleResponse, nil
+	}
+}
+
+// markStale flags each resource of a JSON array of resource responses as stale.
+func markStale(jsonResponse []byte) ([]byte, error) {
+	var response []map[string]json.RawMessage
+	if err := json.Unmarshal(jsonResponse, &response); err != nil {
+		return nil, err
+	}
+	for _, resource := range response {
+		resource["stale"] = json.RawMessage("true")
+	}
+	return json.Marshal(response)
+}
@@ -0,0 +1,127 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/services"
+)
+
+func Test_staleOnThrottle(t *testing.T) {
+	origNewListMetricsServices := services.NewListMetricsService
+	t.Cleanup(func() {
+		services.NewListMetricsService = origNewListMetricsServices
+	})
+	var mockListMetricsService mocks.ListMetricsServiceMock
+	services.NewListMetricsService = func(provider models.MetricsClientProvider) models.ListMetricsProvider {
+		return &mockListMetricsService
+	}
+	throttled := &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"}
+	dimensionKeys := func(t *testing.T, ds *DataSource, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.staleOnThrottle("dimension-keys", ds.DimensionKeysHandler)))
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/dimension-keys?region=us-east-1&namespace=custom"+query, nil))
+		return rr
+	}
+	newDatasource := func() *DataSource {
+		return newTestDatasource(func(ds *DataSource) {
+			ds.discoveryCache = cache.New(discoveryCacheExpiration, discoveryCacheExpiration)
+		})
+	}
+
+	t.Run("serves the cached response flagged as stale when throttled", func(t *testing.T) {
+		mockListMetricsService = mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything).
+			Return([]resources.ResourceResponse[string]{{Value: "InstanceId"}}, nil).Once()
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything).
+			Return([]resources.ResourceResponse[string]{}, throttled).Once()
+		ds := newDatasource()
+
+		rr := dimensionKeys(t, ds, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"value":"InstanceId"}]`, rr.Body.String())
+
+		rr = dimensionKeys(t, ds, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response []resources.ResourceResponse[string]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, []resources.ResourceResponse[string]{{Value: "InstanceId", Stale: true}}, response)
+	})
+
+	t.Run("fails throttled requests without a cached response", func(t *testing.T) {
+		mockListMetricsService = mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything).
+			Return([]resources.ResourceResponse[string]{{Value: "InstanceId"}}, nil).Once()
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything).
+			Return([]resources.ResourceResponse[string]{}, throttled).Once()
+		ds := newDatasource()
+
+		dimensionKeys(t, ds, "")
+		rr := dimensionKeys(t, ds, "&metricName=Other")
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		var httpError models.HttpError
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &httpError))
+		assert.Equal(t, models.HttpErrorCodeThrottled, httpError.Code)
+	})
+
+	t.Run("fails requests that aren't throttled", func(t *testing.T) {
+		mockListMetricsService = mocks.ListMetricsServiceMock{}
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything).
+			Return([]resources.ResourceResponse[string]{{Value: "InstanceId"}}, nil).Once()
+		mockListMetricsService.On("GetDimensionKeysByDimensionFilter", mock.Anything).
+			Return([]resources.ResourceResponse[string]{}, errors.New("some error")).Once()
+		ds := newDatasource()
+
+		dimensionKeys(t, ds, "")
+		rr := dimensionKeys(t, ds, "")
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("doesn't serve the responses of a user to another when their own identity is used", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.discoveryCache = cache.New(discoveryCacheExpiration, discoveryCacheExpiration)
+			ds.Settings.UserIdentityPassThrough = true
+		})
+		userCtx := func(login string) context.Context {
+			return backend.WithPluginContext(context.Background(), backend.PluginContext{User: &backend.User{Login: login}})
+		}
+		handleFunc := ds.staleOnThrottle("dimension-keys", func(ctx context.Context, _ url.Values) ([]byte, *models.HttpError) {
+			if backend.PluginConfigFromContext(ctx).User.Login == "alice" {
+				return []byte(`[{"value":"InstanceId"}]`), nil
+			}
+			httpError := models.NewHttpError("throttled", http.StatusInternalServerError, throttled)
+			httpError.Code = models.HttpErrorCodeThrottled
+			return nil, httpError
+		})
+
+		_, httpError := handleFunc(userCtx("alice"), url.Values{})
+		require.Nil(t, httpError)
+		_, httpError = handleFunc(userCtx("bob"), url.Values{})
+		require.NotNil(t, httpError)
+		assert.Equal(t, models.HttpErrorCodeThrottled, httpError.Code)
+	})
+}
+
+func Test_isThrottlingError(t *testing.T) {
+	assert.True(t, isThrottlingError(errors.Join(context.Canceled, &smithy.GenericAPIError{Code: "ThrottlingException"})))
+	assert.False(t, isThrottlingError(&smithy.GenericAPIError{Code: "AccessDenied"}))
+	assert.False(t, isThrottlingError(errors.New("Throttling")))
+}
@@ -0,0 +1,164 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+const (
+	// logsProgressPathPrefix is the stream path prefix used to report the progress of a running Logs Insights query.
+	// The full path has the format logs-progress/<region>/<queryId>
+	logsProgressPathPrefix = "logs-progress/"
+
+	logsProgressPollPeriod = time.Second
+)
+
+func (ds *DataSource) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if strings.HasPrefix(req.Path, liveMetricsPathPrefix) {
+		if !ds.subscribeLiveMetrics(ctx, req.PluginContext, req.Path) {
+			return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+		}
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+	}
+	if strings.HasPrefix(req.Path, liveTailPathPrefix) {
+		if !ds.subscribeLiveTail(ctx, req.PluginContext, req.Path) {
+			return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+		}
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+	}
+	if !ds.subscribeLogsProgress(req.PluginContext, req.Path) {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+	return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+}
+
+func (ds *DataSource) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+}
+
+func (ds *DataSource) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	ctx, done, err := ds.operations.begin(ctx)
+	defer done()
+	if err != nil {
+		return err
+	}
+	ctx = instrumentContext(ctx, "runStream", req.PluginContext)
+	ctx = withWebIdentityToken(ctx, req.GetHTTPHeader)
+	if strings.HasPrefix(req.Path, liveMetricsPathPrefix) {
+		return ds.streamLiveMetrics(ctx, req.PluginContext, strings.TrimPrefix(req.Path, liveMetricsPathPrefix), sender)
+	}
+	if strings.HasPrefix(req.Path, liveTailPathPrefix) {
+		return ds.streamLiveTail(ctx, strings.TrimPrefix(req.Path, liveTailPathPrefix), sender)
+	}
+
+	region, queryId, err := parseLogsProgressPath(req.Path)
+	if err != nil {
+		return err
+	}
+	query, found := ds.runningLogs.get(ds.logsRegion(region), queryId)
+	if !found {
+		return fmt.Errorf("logs query %s is not running", queryId)
+	}
+	// the progress is read in the account the query runs in, rather than the one of whoever subscribed first
+	ctx = ds.runningLogsQueryContext(ctx, query)
+
+	logsClient, err := ds.getCWLogsClient(ctx, region)
+	if err != nil {
+		return err
+	}
+
+	return ds.streamLogsQueryProgress(ctx, logsClient, region, queryId, sender)
+}
+
+// subscribeLogsProgress reports whether the subscriber may receive the progress of the Logs Insights query at path,
+// which requires the query to be running for the subscriber's org and, when users' own identity is used to query
+// AWS, user.
+func (ds *DataSource) subscribeLogsProgress(pCtx backend.PluginContext, path string) bool {
+	region, queryId, err := parseLogsProgressPath(path)
+	if err != nil {
+		return false
+	}
+	query, found := ds.runningLogs.get(ds.logsRegion(region), queryId)
+	return found && ds.isRunningLogsQueryOf(pCtx, query)
+}
+
+// streamLogsQueryProgress polls GetQueryResults for a running query and sends a progress frame after every poll,
+// so that the frontend can show how far a long-running Logs Insights scan has come. It returns once the query
+// has reached a terminal state or the subscription is closed.
+func (ds *DataSource) streamLogsQueryProgress(ctx context.Context, logsClient models.CWLogsClient, region, queryId string,
+	sender *backend.StreamSender) error {
+	logsQuery := models.LogsQuery{
+		CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{
+			Region: region,
+		},
+		QueryId: queryId,
+	}
+
+	for {
+		if _, err := waitPollPeriod(ctx, logsProgressPollPeriod); err != nil {
+			return nil
+		}
+		res, err := ds.executeGetQueryResults(ctx, logsClient, logsQuery)
+		if err != nil {
+			return err
+		}
+		if err := sender.SendFrame(logsProgressFrame(res, time.Now()), data.IncludeAll); err != nil {
+			return err
+		}
+		if isTerminated(res.Status) {
+			return nil
+		}
+	}
+}
+
+func logsProgressFrame(res *cloudwatchlogs.GetQueryResultsOutput, now time.Time) *data.Frame {
+	var recordsMatched, recordsScanned, bytesScanned float64
+	if res.Statistics != nil {
+		recordsMatched = res.Statistics.RecordsMatched
+		recordsScanned = res.Statistics.RecordsScanned
+		bytesScanned = res.Statistics.BytesScanned
+	}
+
+	return data.NewFrame("logsProgress",
+		data.NewField("time", nil, []time.Time{now}),
+		data.NewField("status", nil, []string{string(res.Status)}),
+		data.NewField("recordsMatched", nil, []float64{recordsMatched}),
+		data.NewField("recordsScanned", nil, []float64{recordsScanned}),
+		data.NewField("bytesScanned", nil, []float64{bytesScanned}),
+	)
+}
+
+func parseLogsProgressPath(path string) (region string, queryId string, err error) {
+	if !strings.HasPrefix(path, logsProgressPathPrefix) {
+		return "", "", fmt.Errorf("unknown stream path: %s", path)
+	}
+	region, queryId, found := strings.Cut(strings.TrimPrefix(path, logsProgressPathPrefix), "/")
+	if !found || region == "" || queryId == "" {
+		return "", "", fmt.Errorf("invalid logs progress stream path: %s", path)
+	}
+	return region, queryId, nil
+}
+
+// logsProgressChannel returns the live channel that reports the progress of the given query, or an empty string if
+// the datasource uid is not known.
+func logsProgressChannel(ctx context.Context, region, queryId string) string {
+	pCtx := backend.PluginConfigFromContext(ctx)
+	if pCtx.DataSourceInstanceSettings == nil || pCtx.DataSourceInstanceSettings.UID == "" {
+		return ""
+	}
+	return live.Channel{
+		Scope:     live.ScopeDatasource,
+		Namespace: pCtx.DataSourceInstanceSettings.UID,
+		Path:      logsProgressPathPrefix + region + "/" + queryId,
+	}.String()
+}
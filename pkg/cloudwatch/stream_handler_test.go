@@ -0,0 +1,148 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+type fakeStreamPacketSender struct {
+	packets []*backend.StreamPacket
+}
+
+func (s *fakeStreamPacketSender) Send(packet *backend.StreamPacket) error {
+	s.packets = append(s.packets, packet)
+	return nil
+}
+
+func Test_parseLogsProgressPath(t *testing.T) {
+	region, queryId, err := parseLogsProgressPath("logs-progress/us-east-1/abcd-efgh")
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", region)
+	assert.Equal(t, "abcd-efgh", queryId)
+
+	for _, path := range []string{"logs-progress/us-east-1", "logs-progress//abcd", "metrics/us-east-1/abcd"} {
+		_, _, err := parseLogsProgressPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestSubscribeStream(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.Region = "us-east-1"
+		ds.runningLogs = newRunningLogsQueries()
+	})
+	ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "abcd", OrgId: 1}, time.Hour)
+	subscribe := func(orgId int64, path string) backend.SubscribeStreamStatus {
+		t.Helper()
+		resp, err := ds.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{
+			PluginContext: backend.PluginContext{OrgID: orgId, User: &backend.User{Login: "alice"}},
+			Path:          path,
+		})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	assert.Equal(t, backend.SubscribeStreamStatusOK, subscribe(1, "logs-progress/default/abcd"))
+	assert.Equal(t, backend.SubscribeStreamStatusOK, subscribe(1, "logs-progress/us-east-1/abcd"))
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe(2, "logs-progress/default/abcd"), "other orgs can't subscribe")
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe(1, "logs-progress/default/efgh"), "queries that aren't running can't be subscribed to")
+	assert.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe(1, "unknown/path"))
+
+	t.Run("only the user who started the query can subscribe with user identity pass-through", func(t *testing.T) {
+		ds.Settings.UserIdentityPassThrough = true
+		t.Cleanup(func() { ds.Settings.UserIdentityPassThrough = false })
+		ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "efgh", OrgId: 1, User: "bob"}, time.Hour)
+
+		assert.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe(1, "logs-progress/default/efgh"))
+		ds.runningLogs.add(runningLogsQuery{Region: "us-east-1", QueryId: "ijkl", OrgId: 1, User: "alice"}, time.Hour)
+		assert.Equal(t, backend.SubscribeStreamStatusOK, subscribe(1, "logs-progress/default/ijkl"))
+	})
+}
+
+func TestRunStream_logsProgress(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	cli := &fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{Status: cloudwatchlogstypes.QueryStatusComplete}}
+	NewCWLogsClient = func(aws.Config) models.CWLogsClient {
+		return cli
+	}
+	provider := &roleRecordingConfigProvider{}
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.Region = "us-east-1"
+		ds.Settings.OrgRoleMap = map[string]string{"2": "arn:aws:iam::222222222222:role/org-2"}
+		ds.AWSConfigProvider = provider
+		ds.runningLogs = newRunningLogsQueries()
+	})
+	orgCtx := backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 2})
+	ds.runningLogs.add(ds.newRunningLogsQuery(orgCtx, "us-east-1", "abcd", ""), time.Hour)
+
+	packetSender := &fakeStreamPacketSender{}
+	err := ds.RunStream(context.Background(), &backend.RunStreamRequest{
+		PluginContext: backend.PluginContext{OrgID: 2},
+		Path:          "logs-progress/default/abcd",
+	}, backend.NewStreamSender(packetSender))
+	require.NoError(t, err)
+	require.Len(t, packetSender.packets, 1)
+	require.NotEmpty(t, provider.roles)
+	for _, role := range provider.roles {
+		assert.Equal(t, "arn:aws:iam::222222222222:role/org-2", role)
+	}
+
+	err = ds.RunStream(context.Background(), &backend.RunStreamRequest{
+		PluginContext: backend.PluginContext{OrgID: 2},
+		Path:          "logs-progress/default/abcd",
+	}, backend.NewStreamSender(packetSender))
+	assert.Error(t, err, "the query completed")
+}
+
+func Test_streamLogsQueryProgress(t *testing.T) {
+	cli := &mockLogsSyncClient{}
+	cli.On("GetQueryResults", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetQueryResultsOutput{
+		Status:     cloudwatchlogstypes.QueryStatusRunning,
+		Statistics: &cloudwatchlogstypes.QueryStatistics{RecordsMatched: 10, RecordsScanned: 100, BytesScanned: 2048},
+	}, nil).Once()
+	cli.On("GetQueryResults", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatchlogs.GetQueryResultsOutput{
+		Status:     cloudwatchlogstypes.QueryStatusComplete,
+		Statistics: &cloudwatchlogstypes.QueryStatistics{RecordsMatched: 20, RecordsScanned: 200, BytesScanned: 4096},
+	}, nil).Once()
+
+	packetSender := &fakeStreamPacketSender{}
+	ds := newTestDatasource()
+	err := ds.streamLogsQueryProgress(context.Background(), cli, "us-east-1", "abcd", backend.NewStreamSender(packetSender))
+	require.NoError(t, err)
+
+	cli.AssertNumberOfCalls(t, "GetQueryResults", 2)
+	require.Len(t, packetSender.packets, 2)
+
+	var frame data.Frame
+	require.NoError(t, json.Unmarshal(packetSender.packets[1].Data, &frame))
+	require.Len(t, frame.Fields, 5)
+	assert.Equal(t, "Complete", frame.Fields[1].At(0))
+	assert.Equal(t, float64(20), frame.Fields[2].At(0))
+	assert.Equal(t, float64(200), frame.Fields[3].At(0))
+	assert.Equal(t, float64(4096), frame.Fields[4].At(0))
+}
+
+func Test_logsProgressChannel(t *testing.T) {
+	assert.Equal(t, "", logsProgressChannel(context.Background(), "us-east-1", "abcd"))
+
+	ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "cw-uid"},
+	})
+	assert.Equal(t, "ds/cw-uid/logs-progress/us-east-1/abcd", logsProgressChannel(ctx, "us-east-1", "abcd"))
+}
@@ -0,0 +1,187 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	resourcegroupstaggingapitypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+)
+
+// maxTagQueryResources is the number of resources a query by tag can expand to. The resources become the values of
+// a search expression, whose length GetMetricData limits.
+const maxTagQueryResources = 50
+
+// tagQueryResource is the resource the metrics of a namespace are about, as the tagging API and the dimensions of the
+// metrics identify it.
+type tagQueryResource struct {
+	// resourceType is the type of the resources in the tagging API, e.g. ec2:instance
+	resourceType string
+	// dimensionKey is the dimension whose values identify the resources in the metrics of the namespace
+	dimensionKey string
+	// dimensionValue returns the dimension value of the resource of an ARN, or false if the resource isn't one of the
+	// namespace's
+	dimensionValue func(resource string) (string, bool)
+}
+
+// tagQueryResources are the resources of the namespaces that can be queried by tag.
+var tagQueryResources = map[string]tagQueryResource{
+	"AWS/EC2":            {"ec2:instance", "InstanceId", resourceIdAfter("instance/")},
+	"AWS/EBS":            {"ec2:volume", "VolumeId", resourceIdAfter("volume/")},
+	"AWS/RDS":            {"rds:db", "DBInstanceIdentifier", resourceIdAfter("db:")},
+	"AWS/Lambda":         {"lambda:function", "FunctionName", resourceIdAfter("function:")},
+	"AWS/DynamoDB":       {"dynamodb:table", "TableName", resourceIdAfter("table/")},
+	"AWS/SQS":            {"sqs", "QueueName", resourceIdAfter("")},
+	"AWS/SNS":            {"sns", "TopicName", resourceIdAfter("")},
+	"AWS/S3":             {"s3", "BucketName", resourceIdAfter("")},
+	"AWS/ECS":            {"ecs:cluster", "ClusterName", resourceIdAfter("cluster/")},
+	"AWS/Kinesis":        {"kinesis:stream", "StreamName", resourceIdAfter("stream/")},
+	"AWS/ApplicationELB": {"elasticloadbalancing:loadbalancer", "LoadBalancer", loadBalancerId("app")},
+	"AWS/NetworkELB":     {"elasticloadbalancing:loadbalancer", "LoadBalancer", loadBalancerId("net")},
+}
+
+// resourceIdAfter returns the ID following prefix in the resource of an ARN, e.g. the ID of `instance/i-123`.
+func resourceIdAfter(prefix string) func(string) (string, bool) {
+	return func(resource string) (string, bool) {
+		id, ok := strings.CutPrefix(resource, prefix)
+		return id, ok && id != "" && !strings.ContainsAny(id, "/:")
+	}
+}
+
+// loadBalancerId returns the LoadBalancer dimension of the load balancers of a kind, app or net, e.g.
+// `app/my-alb/50dc6c495c0c9188` for the resource `loadbalancer/app/my-alb/50dc6c495c0c9188`.
+func loadBalancerId(kind string) func(string) (string, bool) {
+	return func(resource string) (string, bool) {
+		id, ok := strings.CutPrefix(resource, "loadbalancer/")
+		return id, ok && strings.HasPrefix(id, kind+"/")
+	}
+}
+
+type tagQueryModel struct {
+	MetricQueryType dataquery.MetricQueryType `json:"metricQueryType"`
+	Namespace       string                    `json:"namespace"`
+	Region          string                    `json:"region"`
+	TagFilters      dataquery.Dimensions      `json:"tagFilters"`
+}
+
+func isTagQuery(query backend.DataQuery) bool {
+	var model tagQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return false
+	}
+	return model.MetricQueryType == dataquery.MetricQueryTypeTags
+}
+
+// hasTagQueries returns whether any of the time series queries queries a metric by the tags of its resources.
+func hasTagQueries(queries []backend.DataQuery) bool {
+	return slices.ContainsFunc(queries, isTagQuery)
+}
+
+// expandTagQueries expands the queries by tag into search queries of the resources their tags currently match, so that
+// dashboards follow fleets whose resources come and go. The resources are looked up on every run. The responses of
+// the queries that can't run, because they failed or match no resource, are set in resp and the other queries are
+// returned.
+func (ds *DataSource) expandTagQueries(ctx context.Context, queries []backend.DataQuery, resp *backend.QueryDataResponse) []backend.DataQuery {
+	expanded := make([]backend.DataQuery, 0, len(queries))
+	for _, query := range queries {
+		if !isTagQuery(query) {
+			expanded = append(expanded, query)
+			continue
+		}
+		expandedQuery, ok, err := ds.expandTagQuery(ctx, query)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		if !ok {
+			frame := data.NewFrame(query.RefID)
+			frame.RefID = query.RefID
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityInfo,
+				Text:     "No resource matches the tag filters",
+			})
+			resp.Responses[query.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+			continue
+		}
+		expanded = append(expanded, expandedQuery)
+	}
+	return expanded
+}
+
+// expandTagQuery returns query as a search query of the resources matching its tags, or false if none does.
+func (ds *DataSource) expandTagQuery(ctx context.Context, query backend.DataQuery) (backend.DataQuery, bool, error) {
+	var model tagQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return query, false, backend.DownstreamError(err)
+	}
+	resource, ok := tagQueryResources[model.Namespace]
+	if !ok {
+		return query, false, backend.DownstreamError(fmt.Errorf("querying by tag isn't supported in namespace %q", model.Namespace))
+	}
+	if len(model.TagFilters) == 0 {
+		return query, false, backend.DownstreamError(fmt.Errorf("a query by tag needs at least one tag filter"))
+	}
+	filters := make([]resourcegroupstaggingapitypes.TagFilter, 0, len(model.TagFilters))
+	for key, values := range model.TagFilters {
+		filter := resourcegroupstaggingapitypes.TagFilter{Key: aws.String(key), Values: values.ArrayOfString}
+		if values.String != nil {
+			filter.Values = []string{*values.String}
+		}
+		filters = append(filters, filter)
+	}
+
+	region := model.Region
+	if region == "" || region == defaultRegion {
+		region = ds.Settings.Region
+	}
+	resources, err := ds.resourceGroupsGetResources(ctx, region, filters, []string{resource.resourceType})
+	if err != nil {
+		return query, false, backend.DownstreamError(fmt.Errorf("failed to get the resources matching the tags: %w", err))
+	}
+	var values []string
+	for _, mapping := range resources.ResourceTagMappingList {
+		resourceARN, err := arn.Parse(aws.ToString(mapping.ResourceARN))
+		if err != nil {
+			continue
+		}
+		if value, ok := resource.dimensionValue(resourceARN.Resource); ok && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return query, false, nil
+	}
+	if len(values) > maxTagQueryResources {
+		return query, false, backend.DownstreamError(fmt.Errorf("the tags match %d resources, more than the %d a query by tag can query, narrow the tag filters down",
+			len(values), maxTagQueryResources))
+	}
+	slices.Sort(values)
+
+	var queryModel map[string]any
+	if err := json.Unmarshal(query.JSON, &queryModel); err != nil {
+		return query, false, backend.DownstreamError(err)
+	}
+	dimensions, _ := queryModel["dimensions"].(map[string]any)
+	if dimensions == nil {
+		dimensions = map[string]any{}
+	}
+	dimensions[resource.dimensionKey] = values
+	queryModel["dimensions"] = dimensions
+	queryModel["metricQueryType"] = dataquery.MetricQueryTypeSearch
+	queryModel["metricEditorMode"] = dataquery.MetricEditorModeBuilder
+	delete(queryModel, "tagFilters")
+	queryJSON, err := json.Marshal(queryModel)
+	if err != nil {
+		return query, false, err
+	}
+	query.JSON = queryJSON
+	return query, true, nil
+}
@@ -0,0 +1,126 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	resourcegroupstaggingapitypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/mocks"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func TestQuery_TagQueries(t *testing.T) {
+	origNewCWClient, origNewRGTAClient := NewCWClient, NewRGTAClient
+	t.Cleanup(func() {
+		NewCWClient, NewRGTAClient = origNewCWClient, origNewRGTAClient
+	})
+	now := time.Now().Truncate(time.Minute)
+	queryData := func(t *testing.T, resources []string, queryJSON string) (*backend.QueryDataResponse, *mocks.MetricsAPI) {
+		t.Helper()
+		api := &mocks.MetricsAPI{}
+		api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Return(&cloudwatch.GetMetricDataOutput{}, nil)
+		NewCWClient = func(aws.Config) models.CWClient {
+			return api
+		}
+		var tagMapping []resourcegroupstaggingapitypes.ResourceTagMapping
+		for _, resource := range resources {
+			tagMapping = append(tagMapping, resourcegroupstaggingapitypes.ResourceTagMapping{ResourceARN: aws.String(resource)})
+		}
+		NewRGTAClient = func(aws.Config) resourcegroupstaggingapi.GetResourcesAPIClient {
+			return fakeRGTAClient{tagMapping: tagMapping}
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+		})
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON:      json.RawMessage(queryJSON),
+			}},
+		})
+		require.NoError(t, err)
+		return resp, api
+	}
+
+	t.Run("expands the resources matching the tags into the dimension identifying them", func(t *testing.T) {
+		resp, api := queryData(t, []string{
+			"arn:aws:ec2:us-east-1:123456789012:instance/i-0b2",
+			"arn:aws:ec2:us-east-1:123456789012:instance/i-0a1",
+		}, `{"type":"timeSeriesQuery","id":"a","region":"default","namespace":"AWS/EC2","metricName":"CPUUtilization",
+			"statistic":"Average","period":"60","metricQueryType":3,"matchExact":true,"tagFilters":{"team":["checkout"]}}`)
+
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, api.Calls, 1)
+		input := api.Calls[0].Arguments.Get(1).(*cloudwatch.GetMetricDataInput)
+		require.Len(t, input.MetricDataQueries, 1)
+		assert.Equal(t, `REMOVE_EMPTY(SEARCH('{"AWS/EC2","InstanceId"} MetricName="CPUUtilization" "InstanceId"=("i-0a1" OR "i-0b2")', 'Average', 60))`,
+			aws.ToString(input.MetricDataQueries[0].Expression))
+	})
+
+	t.Run("only notes that no resource matches the tags", func(t *testing.T) {
+		resp, api := queryData(t, []string{"arn:aws:ec2:us-east-1:123456789012:volume/vol-1"},
+			`{"type":"timeSeriesQuery","id":"a","namespace":"AWS/EC2","metricName":"CPUUtilization","statistic":"Average",
+			"period":"60","metricQueryType":3,"tagFilters":{"team":"checkout"}}`)
+
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		assert.Equal(t, data.NoticeSeverityInfo, resp.Responses["A"].Frames[0].Meta.Notices[0].Severity)
+		assert.Empty(t, api.Calls)
+	})
+
+	t.Run("fails queries by tag of namespaces whose resources aren't known", func(t *testing.T) {
+		resp, _ := queryData(t, nil, `{"type":"timeSeriesQuery","id":"a","namespace":"Custom/App","metricName":"Errors",
+			"statistic":"Sum","period":"60","metricQueryType":3,"tagFilters":{"team":["checkout"]}}`)
+
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+		assert.Contains(t, resp.Responses["A"].Error.Error(), `querying by tag isn't supported in namespace "Custom/App"`)
+	})
+}
+
+func Test_tagQueryResources(t *testing.T) {
+	tests := map[string]struct {
+		namespace, resource, value string
+		ok                         bool
+	}{
+		"lambda function":       {"AWS/Lambda", "function:checkout", "checkout", true},
+		"rds instance":          {"AWS/RDS", "db:orders", "orders", true},
+		"sqs queue":             {"AWS/SQS", "orders-dlq", "orders-dlq", true},
+		"application lb":        {"AWS/ApplicationELB", "loadbalancer/app/web/50dc6c495c0c9188", "app/web/50dc6c495c0c9188", true},
+		"network lb of albs":    {"AWS/ApplicationELB", "loadbalancer/net/nlb/50dc6c495c0c9188", "", false},
+		"dynamodb table index":  {"AWS/DynamoDB", "table/orders/index/by-customer", "", false},
+		"other ec2 resource":    {"AWS/EC2", "volume/vol-1", "", false},
+		"sns subscription":      {"AWS/SNS", "alerts:9d1b7b5e", "", false},
+		"ecs cluster":           {"AWS/ECS", "cluster/prod", "prod", true},
+		"kinesis stream":        {"AWS/Kinesis", "stream/clicks", "clicks", true},
+		"network lb":            {"AWS/NetworkELB", "loadbalancer/net/nlb/50dc6c495c0c9188", "net/nlb/50dc6c495c0c9188", true},
+		"ebs volume":            {"AWS/EBS", "volume/vol-1", "vol-1", true},
+		"s3 bucket":             {"AWS/S3", "logs", "logs", true},
+		"ec2 instance":          {"AWS/EC2", "instance/i-1", "i-1", true},
+		"dynamodb table":        {"AWS/DynamoDB", "table/orders", "orders", true},
+		"sns topic":             {"AWS/SNS", "alerts", "alerts", true},
+		"lambda function alias": {"AWS/Lambda", "function:checkout:live", "", false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			value, ok := tagQueryResources[tc.namespace].dimensionValue(tc.resource)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.value, value)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	resourcegroupstaggingapitypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/grafana/grafana-aws-sdk/pkg/awsauth"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
@@ -29,17 +30,22 @@ import (
 type fakeCWLogsClient struct {
 	calls logsQueryCalls
 
-	logGroups      []cloudwatchlogs.DescribeLogGroupsOutput
-	logGroupFields cloudwatchlogs.GetLogGroupFieldsOutput
-	queryResults   cloudwatchlogs.GetQueryResultsOutput
+	logGroups        []cloudwatchlogs.DescribeLogGroupsOutput
+	logGroupFields   cloudwatchlogs.GetLogGroupFieldsOutput
+	queryResults     cloudwatchlogs.GetQueryResultsOutput
+	queryDefinitions []cloudwatchlogstypes.QueryDefinition
+	logRecords       map[string]map[string]string
 
 	logGroupsIndex int
 }
 
 type logsQueryCalls struct {
-	startQuery        []*cloudwatchlogs.StartQueryInput
-	getEvents         []*cloudwatchlogs.GetLogEventsInput
-	describeLogGroups []*cloudwatchlogs.DescribeLogGroupsInput
+	startQuery         []*cloudwatchlogs.StartQueryInput
+	stopQuery          []*cloudwatchlogs.StopQueryInput
+	getEvents          []*cloudwatchlogs.GetLogEventsInput
+	filterEvents       []*cloudwatchlogs.FilterLogEventsInput
+	describeLogGroups  []*cloudwatchlogs.DescribeLogGroupsInput
+	putQueryDefinition []*cloudwatchlogs.PutQueryDefinitionInput
 }
 
 func (m *fakeCWLogsClient) GetQueryResults(_ context.Context, _ *cloudwatchlogs.GetQueryResultsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
@@ -54,7 +60,8 @@ func (m *fakeCWLogsClient) StartQuery(_ context.Context, input *cloudwatchlogs.S
 	}, nil
 }
 
-func (m *fakeCWLogsClient) StopQuery(_ context.Context, _ *cloudwatchlogs.StopQueryInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+func (m *fakeCWLogsClient) StopQuery(_ context.Context, input *cloudwatchlogs.StopQueryInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+	m.calls.stopQuery = append(m.calls.stopQuery, input)
 	return &cloudwatchlogs.StopQueryOutput{
 		Success: true,
 	}, nil
@@ -72,10 +79,26 @@ func (m *mockLogsSyncClient) GetLogEvents(context.Context, *cloudwatchlogs.GetLo
 	return nil, nil
 }
 
+func (m *mockLogsSyncClient) FilterLogEvents(context.Context, *cloudwatchlogs.FilterLogEventsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	return nil, nil
+}
+
 func (m *mockLogsSyncClient) DescribeLogGroups(context.Context, *cloudwatchlogs.DescribeLogGroupsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
 	return nil, nil
 }
 
+func (m *mockLogsSyncClient) DescribeQueryDefinitions(context.Context, *cloudwatchlogs.DescribeQueryDefinitionsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	return nil, nil
+}
+
+func (m *mockLogsSyncClient) GetLogRecord(context.Context, *cloudwatchlogs.GetLogRecordInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogRecordOutput, error) {
+	return nil, nil
+}
+
+func (m *mockLogsSyncClient) PutQueryDefinition(context.Context, *cloudwatchlogs.PutQueryDefinitionInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutQueryDefinitionOutput, error) {
+	return nil, nil
+}
+
 func (m *mockLogsSyncClient) GetQueryResults(ctx context.Context, input *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
 	args := m.Called(ctx, input, optFns)
 	return args.Get(0).(*cloudwatchlogs.GetQueryResultsOutput), args.Error(1)
@@ -96,6 +119,27 @@ func (m *fakeCWLogsClient) GetLogGroupFields(_ context.Context, _ *cloudwatchlog
 	return &m.logGroupFields, nil
 }
 
+func (m *fakeCWLogsClient) DescribeQueryDefinitions(_ context.Context, _ *cloudwatchlogs.DescribeQueryDefinitionsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	return &cloudwatchlogs.DescribeQueryDefinitionsOutput{QueryDefinitions: m.queryDefinitions}, nil
+}
+
+func (m *fakeCWLogsClient) GetLogRecord(_ context.Context, input *cloudwatchlogs.GetLogRecordInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogRecordOutput, error) {
+	record, ok := m.logRecords[aws.ToString(input.LogRecordPointer)]
+	if !ok {
+		return nil, &cloudwatchlogstypes.InvalidParameterException{Message: aws.String("invalid log record pointer")}
+	}
+	return &cloudwatchlogs.GetLogRecordOutput{LogRecord: record}, nil
+}
+
+func (m *fakeCWLogsClient) PutQueryDefinition(_ context.Context, input *cloudwatchlogs.PutQueryDefinitionInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutQueryDefinitionOutput, error) {
+	m.calls.putQueryDefinition = append(m.calls.putQueryDefinition, input)
+	id := aws.ToString(input.QueryDefinitionId)
+	if id == "" {
+		id = "new-query-definition"
+	}
+	return &cloudwatchlogs.PutQueryDefinitionOutput{QueryDefinitionId: aws.String(id)}, nil
+}
+
 func (m *fakeCWLogsClient) GetLogEvents(_ context.Context, input *cloudwatchlogs.GetLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
 	m.calls.getEvents = append(m.calls.getEvents, input)
 
@@ -104,6 +148,14 @@ func (m *fakeCWLogsClient) GetLogEvents(_ context.Context, input *cloudwatchlogs
 	}, nil
 }
 
+func (m *fakeCWLogsClient) FilterLogEvents(_ context.Context, input *cloudwatchlogs.FilterLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	m.calls.filterEvents = append(m.calls.filterEvents, input)
+
+	return &cloudwatchlogs.FilterLogEventsOutput{
+		Events: []cloudwatchlogstypes.FilteredLogEvent{},
+	}, nil
+}
+
 type fakeCWAnnotationsClient struct {
 	calls annontationsQueryCalls
 
@@ -195,10 +247,18 @@ func (c fakeRGTAClient) GetResources(_ context.Context, _ *resourcegroupstagging
 type fakeCheckHealthClient struct {
 	listMetricsFunction       func(context.Context, *cloudwatch.ListMetricsInput, ...func(*cloudwatch.Options)) (*cloudwatch.ListMetricsOutput, error)
 	describeLogGroupsFunction func(context.Context, *cloudwatchlogs.DescribeLogGroupsInput, ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+	getCallerIdentityFunction func(context.Context, *sts.GetCallerIdentityInput, ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
 
 	models.CWClient
 }
 
+func (c fakeCheckHealthClient) GetCallerIdentity(ctx context.Context, input *sts.GetCallerIdentityInput, _ ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if c.getCallerIdentityFunction != nil {
+		return c.getCallerIdentityFunction(ctx, input)
+	}
+	return &sts.GetCallerIdentityOutput{Arn: aws.String("arn:aws:iam::123456789012:user/grafana")}, nil
+}
+
 func (c fakeCheckHealthClient) ListMetrics(ctx context.Context, input *cloudwatch.ListMetricsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.ListMetricsOutput, error) {
 	if c.listMetricsFunction != nil {
 		return c.listMetricsFunction(ctx, input)
@@ -217,6 +277,10 @@ func (c fakeCheckHealthClient) GetLogGroupFields(_ context.Context, _ *cloudwatc
 	return nil, nil
 }
 
+func (c fakeCheckHealthClient) DescribeQueryDefinitions(_ context.Context, _ *cloudwatchlogs.DescribeQueryDefinitionsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeQueryDefinitionsOutput, error) {
+	return nil, nil
+}
+
 func testInstanceManagerWithSettings(settings models.CloudWatchSettings, awsAuthShouldFail bool) instancemgmt.InstanceManager {
 	return datasource.NewInstanceManager(func(ctx context.Context, s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		return DataSource{
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/utils"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 type responseWrapper struct {
@@ -25,9 +27,25 @@ func (ds *DataSource) executeTimeSeriesQuery(ctx context.Context, req *backend.Q
 	if len(req.Queries) == 0 {
 		return nil, backend.DownstreamError(fmt.Errorf("request contains no queries"))
 	}
+	if hasMultiRegionQueries(req.Queries) {
+		return ds.executeMultiRegionTimeSeriesQuery(ctx, req)
+	}
+	queries := req.Queries
+	if hasTagQueries(queries) {
+		if queries = ds.expandTagQueries(ctx, queries, resp); len(queries) == 0 {
+			return resp, nil
+		}
+	}
+	if hasSLOQueries(queries) {
+		if queries = expandSLOQueries(queries, resp); len(queries) == 0 {
+			return resp, nil
+		}
+	}
 
-	timeBatches := utils.BatchDataQueriesByTimeRange(req.Queries)
+	timeBatches := utils.BatchDataQueriesByTimeRange(queries)
 	requestQueriesByTimeAndRegion := make(map[string][]*models.CloudWatchQuery)
+	queriesByRefId := map[string]*models.CloudWatchQuery{}
+	percentileQueries := map[string]percentileQuery{}
 	for i, timeBatch := range timeBatches {
 		startTime := timeBatch[0].TimeRange.From
 		endTime := timeBatch[0].TimeRange.To
@@ -35,12 +53,14 @@ func (ds *DataSource) executeTimeSeriesQuery(ctx context.Context, req *backend.Q
 			return nil, backend.DownstreamError(fmt.Errorf("invalid time range: start time must be before end time"))
 		}
 		requestQueries, err := models.ParseMetricDataQueries(timeBatch, startTime, endTime, ds.Settings.Region, ds.logger.FromContext(ctx),
-			features.IsEnabled(ctx, features.FlagCloudWatchCrossAccountQuerying))
+			features.IsEnabled(ctx, features.FlagCloudWatchCrossAccountQuerying), ds.Settings.Variables)
 		if err != nil {
 			return nil, err
 		}
+		requestQueries = expandPercentileQueries(requestQueries, percentileQueries)
 
 		for _, query := range requestQueries {
+			queriesByRefId[query.RefId] = query
 			key := fmt.Sprintf("%d %s", i, query.Region)
 			if _, exist := requestQueriesByTimeAndRegion[key]; !exist {
 				requestQueriesByTimeAndRegion[key] = []*models.CloudWatchQuery{}
@@ -48,17 +68,50 @@ func (ds *DataSource) executeTimeSeriesQuery(ctx context.Context, req *backend.Q
 			requestQueriesByTimeAndRegion[key] = append(requestQueriesByTimeAndRegion[key], query)
 		}
 	}
+
+	requestIds := make(map[string]bool, len(queriesByRefId))
+	for _, query := range queriesByRefId {
+		requestIds[query.Id] = true
+	}
+	for key, queries := range requestQueriesByTimeAndRegion {
+		errs := validateMathExpressions(queries, requestIds)
+		if len(errs) == 0 {
+			continue
+		}
+		valid := make([]*models.CloudWatchQuery, 0, len(queries))
+		for _, query := range queries {
+			if err, ok := errs[query.RefId]; ok {
+				resp.Responses[query.RefId] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			valid = append(valid, query)
+		}
+		if len(valid) == 0 {
+			delete(requestQueriesByTimeAndRegion, key)
+			continue
+		}
+		requestQueriesByTimeAndRegion[key] = valid
+	}
+
 	if len(requestQueriesByTimeAndRegion) == 0 {
-		return backend.NewQueryDataResponse(), nil
+		return resp, nil
 	}
 
-	resultChan := make(chan *responseWrapper, len(req.Queries))
+	resultChan := make(chan *responseWrapper, len(queriesByRefId))
 	eg, ectx := errgroup.WithContext(ctx)
+	if ds.Settings.MetricDataConcurrency > 0 {
+		eg.SetLimit(ds.Settings.MetricDataConcurrency)
+	}
 	for _, timeAndRegionQueries := range requestQueriesByTimeAndRegion {
 		batches := [][]*models.CloudWatchQuery{timeAndRegionQueries}
 		if features.IsEnabled(ctx, features.FlagCloudWatchBatchQueries) {
 			batches = getMetricQueryBatches(timeAndRegionQueries, ds.logger.FromContext(ctx))
 		}
+		var requestBatches [][]*models.CloudWatchQuery
+		for _, batch := range batches {
+			requestBatches = append(requestBatches, splitBatchByRequestLimit(batch, maxMetricDataQueriesPerRequest)...)
+		}
+		batches = requestBatches
 
 		// region, startTime, and endTime are the same for the set of queries
 		region := timeAndRegionQueries[0].Region
@@ -81,27 +134,7 @@ func (ds *DataSource) executeTimeSeriesQuery(ctx context.Context, req *backend.Q
 					}
 				}()
 
-				client, err := ds.getCWClient(ctx, region)
-				if err != nil {
-					return err
-				}
-
-				metricDataInput, err := ds.buildMetricDataInput(ctx, startTime, endTime, requestQueries)
-				if err != nil {
-					return err
-				}
-
-				mdo, err := ds.executeRequest(ectx, client, metricDataInput)
-				if err != nil {
-					return err
-				}
-
-				requestQueries, err = ds.getDimensionValuesForWildcards(ctx, region, client, requestQueries, ds.tagValueCache, ds.Settings.GrafanaSettings.ListMetricsPageLimit, shouldSkipFetchingWildcards)
-				if err != nil {
-					return err
-				}
-
-				res, err := ds.parseResponse(ctx, mdo, requestQueries)
+				res, err := ds.runMetricDataQueries(ctx, ectx, region, startTime, endTime, requestQueries)
 				if err != nil {
 					return err
 				}
@@ -124,13 +157,86 @@ func (ds *DataSource) executeTimeSeriesQuery(ctx context.Context, req *backend.Q
 	}
 	close(resultChan)
 
+	percentileResults := map[*models.CloudWatchQuery]map[string]backend.DataResponse{}
 	for result := range resultChan {
+		if percentile, ok := percentileQueries[result.RefId]; ok {
+			if percentileResults[percentile.parent] == nil {
+				percentileResults[percentile.parent] = map[string]backend.DataResponse{}
+			}
+			percentileResults[percentile.parent][percentile.percentile] = *result.DataResponse
+			continue
+		}
+		if query, ok := queriesByRefId[result.RefId]; ok && result.DataResponse.Error == nil {
+			ds.applyFallbackStatistic(ctx, result, query)
+			result.DataResponse.Frames = ds.reduceSeries(result.DataResponse.Frames, query)
+			ds.setAlarmThresholds(ctx, result.DataResponse.Frames, query)
+			ds.setInstanceNames(ctx, result.DataResponse.Frames, query)
+		}
 		resp.Responses[result.RefId] = *result.DataResponse
 	}
+	for query, results := range percentileResults {
+		response := mergePercentileResults(query, results)
+		if response.Error == nil {
+			ds.setInstanceNames(ctx, response.Frames, query)
+		}
+		resp.Responses[query.RefId] = response
+	}
 
 	return resp, nil
 }
 
+// runMetricDataQueries runs a batch of queries sharing a region and time range, split by retention if the data source
+// is set to.
+func (ds *DataSource) runMetricDataQueries(ctx context.Context, ectx context.Context, region string, startTime, endTime time.Time,
+	requestQueries []*models.CloudWatchQuery) ([]*responseWrapper, error) {
+	if ds.Settings.SplitRangesByRetention {
+		return ds.executeMetricDataQueriesByRetention(ctx, ectx, region, startTime, endTime, requestQueries)
+	}
+	return ds.executeMetricDataQueries(ctx, ectx, region, startTime, endTime, requestQueries)
+}
+
+// executeMetricDataQueries runs a batch of queries sharing a region and time range through GetMetricData.
+func (ds *DataSource) executeMetricDataQueries(ctx context.Context, ectx context.Context, region string, startTime, endTime time.Time,
+	requestQueries []*models.CloudWatchQuery) ([]*responseWrapper, error) {
+	client, err := ds.getCWClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	metricDataInput, err := ds.buildMetricDataInput(ctx, startTime, endTime, requestQueries)
+	if err != nil {
+		return nil, err
+	}
+
+	mdo, cached, err := ds.executeRequestWithCache(ectx, region, client, metricDataInput)
+	if err != nil {
+		return nil, err
+	}
+	calls := len(mdo)
+	if cached {
+		calls = 0
+	}
+
+	requestQueries, err = ds.getDimensionValuesForWildcards(ctx, region, client, requestQueries, ds.tagValueCache, ds.Settings.GrafanaSettings.ListMetricsPageLimit, shouldSkipFetchingWildcards)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.parseResponse(ctx, mdo, calls, requestQueries)
+}
+
+// reduceSeries applies the filter, sort, limits and instant mode of a query to its series. Series are only reduced
+// once the segments of the time range have been stitched, as which series are kept depends on the whole time range.
+func (ds *DataSource) reduceSeries(frames data.Frames, query *models.CloudWatchQuery) data.Frames {
+	frames = filterSeries(frames, query)
+	frames = sortAndLimitSeries(frames, query)
+	frames = capSeries(frames, query, ds.Settings.MaxSeriesPerQuery)
+	if query.Instant {
+		frames = data.Frames{instantFrame(frames, query)}
+	}
+	return frames
+}
+
 func getQueryRefIdFromErrorString(err string, queriesByRegion map[string][]*models.CloudWatchQuery) string {
 	// error can be in format "Error in expression 'test': Invalid syntax"
 	// so we can find the query id or ref id between the quotations
@@ -3,6 +3,8 @@ package cloudwatch
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -782,3 +784,58 @@ func TestTimeSeriesQuery_CrossAccountQuerying(t *testing.T) {
 		assert.Equal(t, `REMOVE_EMPTY(SEARCH('{"AWS/EC2","InstanceId"} MetricName="NetworkOut" :aws.AccountId="some account Id"', 'Maximum', 300))`, *actualInput.MetricDataQueries[0].Expression)
 	})
 }
+
+func Test_executeTimeSeriesQuery_runs_batches_of_at_most_500_queries_through_a_bounded_pool(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	api := mocks.MetricsAPI{}
+	NewCWClient = func(aws.Config) models.CWClient {
+		return &api
+	}
+	var running, maxRunning atomic.Int32
+	api.On("GetMetricData", mock.Anything, mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		current := running.Add(1)
+		for previous := maxRunning.Load(); current > previous && !maxRunning.CompareAndSwap(previous, current); {
+			previous = maxRunning.Load()
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+	}).Return(&cloudwatch.GetMetricDataOutput{}, nil)
+
+	now := time.Now()
+	queries := make([]backend.DataQuery, 0, 1600)
+	for i := 0; i < cap(queries); i++ {
+		queries = append(queries, backend.DataQuery{
+			RefID:     fmt.Sprintf("q%d", i),
+			TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+			JSON: json.RawMessage(fmt.Sprintf(`{
+				"type": "timeSeriesQuery",
+				"namespace": "AWS/EC2",
+				"metricName": "CPUUtilization",
+				"dimensions": {"InstanceId": "i-%d"},
+				"region": "us-east-1",
+				"id": "q%d",
+				"statistic": "Average",
+				"period": "300",
+				"matchExact": true
+			}`, i, i)),
+		})
+	}
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.MetricDataConcurrency = 2
+	})
+	_, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries:       queries,
+	})
+	require.NoError(t, err)
+
+	api.AssertNumberOfCalls(t, "GetMetricData", 4)
+	for _, call := range api.Calls {
+		assert.LessOrEqual(t, len(call.Arguments.Get(1).(*cloudwatch.GetMetricDataInput).MetricDataQueries), 500)
+	}
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
@@ -0,0 +1,194 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	// webIdentityRoleMapWildcard is the role map key used when no more specific key matches the user.
+	webIdentityRoleMapWildcard = "*"
+
+	// the prefixes of the role map keys of user logins, emails and org roles, which keep a user whose login is an org
+	// role, e.g. "Admin", from being mapped to the role of that org role
+	webIdentityRoleMapLoginPrefix = "login:"
+	webIdentityRoleMapEmailPrefix = "email:"
+	webIdentityRoleMapRolePrefix  = "role:"
+
+	// userCredentialsExpiration is how long the credentials of a user and role are reused. They're refreshed by the
+	// credentials cache when they expire in the meantime.
+	userCredentialsExpiration = time.Hour
+)
+
+// maxRoleSessionNameLength is the longest role session name STS accepts.
+const maxRoleSessionNameLength = 64
+
+var invalidRoleSessionNameChars = regexp.MustCompile(`[^\w+=,.@-]`)
+
+type webIdentityTokenKey struct{}
+
+type userRoleKey struct{}
+
+// withWebIdentityToken stores the OAuth identity Grafana forwarded for the current user in ctx,
+// so newAWSConfig can exchange it for AWS credentials.
+func withWebIdentityToken(ctx context.Context, getHeader func(string) string) context.Context {
+	token := getHeader(backend.OAuthIdentityIDTokenHeaderName)
+	if token == "" {
+		token = strings.TrimPrefix(getHeader(backend.OAuthIdentityTokenHeaderName), "Bearer ")
+	}
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, webIdentityTokenKey{}, token)
+}
+
+func webIdentityTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(webIdentityTokenKey{}).(string)
+	return token
+}
+
+// withUserRole stores in ctx the role the user of ctx assumed in an earlier request, so that calls made on their
+// behalf without a request, e.g. to stop their queries on shutdown, reuse the credentials of that request.
+func withUserRole(ctx context.Context, roleARN string) context.Context {
+	return context.WithValue(ctx, userRoleKey{}, roleARN)
+}
+
+// webIdentityToken implements stscreds.IdentityTokenRetriever with the latest token forwarded for a user, so that
+// their cached credentials are refreshed with a token that hasn't expired.
+type webIdentityToken struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (t *webIdentityToken) set(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = token
+}
+
+func (t *webIdentityToken) GetIdentityToken() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return []byte(t.token), nil
+}
+
+// userCredentials are the credentials of a user for a role, with the token they're exchanged for.
+type userCredentials struct {
+	token       *webIdentityToken
+	credentials aws.CredentialsProvider
+}
+
+// webIdentityRoleForUser picks the role to assume for user from the configured role map. The user's
+// login, email and org role are tried in that order, by their prefixed keys, before falling back to the wildcard
+// entry.
+func (ds *DataSource) webIdentityRoleForUser(user *backend.User) (string, error) {
+	keys := []string{webIdentityRoleMapWildcard}
+	if user != nil {
+		keys = []string{
+			prefixedRoleMapKey(webIdentityRoleMapLoginPrefix, user.Login),
+			prefixedRoleMapKey(webIdentityRoleMapEmailPrefix, user.Email),
+			prefixedRoleMapKey(webIdentityRoleMapRolePrefix, user.Role),
+			webIdentityRoleMapWildcard,
+		}
+	}
+	for _, key := range keys {
+		if roleARN, ok := ds.Settings.WebIdentityRoleMap[key]; ok && key != "" {
+			return roleARN, nil
+		}
+	}
+	return "", models.ErrNoWebIdentityRole
+}
+
+// prefixedRoleMapKey returns the role map key of value, or an empty key, which is never looked up, if value is empty.
+func prefixedRoleMapKey(prefix, value string) string {
+	if value == "" {
+		return ""
+	}
+	return prefix + value
+}
+
+// withUserIdentity replaces the credentials in cfg with credentials for the role mapped to the
+// requesting user, obtained by exchanging their forwarded OAuth identity token.
+func (ds *DataSource) withUserIdentity(ctx context.Context, cfg aws.Config) (aws.Config, error) {
+	token := webIdentityTokenFromContext(ctx)
+	user := backend.PluginConfigFromContext(ctx).User
+	if token == "" {
+		if credentials, ok := ds.earlierUserCredentials(ctx, user); ok {
+			cfg.Credentials = credentials
+			return cfg, nil
+		}
+		return aws.Config{}, models.ErrMissingWebIdentityToken
+	}
+	roleARN, err := ds.webIdentityRoleForUser(user)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	cfg.Credentials = ds.userCredentials(cfg, user, roleARN, token)
+	return cfg, nil
+}
+
+// userCredentials returns the credentials of user for roleARN, reusing those of earlier requests of the user so that
+// the identity is only exchanged when the credentials expire, with the latest token forwarded for the user.
+func (ds *DataSource) userCredentials(cfg aws.Config, user *backend.User, roleARN string, token string) aws.CredentialsProvider {
+	if ds.userCredsCache == nil {
+		identityToken := &webIdentityToken{token: token}
+		return aws.NewCredentialsCache(NewWebIdentityCredentials(cfg, roleARN, roleSessionName(user), identityToken))
+	}
+
+	key := userCredentialsKey(user, roleARN)
+	if cached, ok := ds.userCredsCache.Get(key); ok {
+		credentials := cached.(*userCredentials)
+		credentials.token.set(token)
+		return credentials.credentials
+	}
+	identityToken := &webIdentityToken{token: token}
+	credentials := &userCredentials{
+		token:       identityToken,
+		credentials: aws.NewCredentialsCache(NewWebIdentityCredentials(cfg, roleARN, roleSessionName(user), identityToken)),
+	}
+	ds.userCredsCache.Set(key, credentials, cache.DefaultExpiration)
+	return credentials.credentials
+}
+
+// earlierUserCredentials returns the cached credentials of user for the role stored in ctx by withUserRole, if any.
+func (ds *DataSource) earlierUserCredentials(ctx context.Context, user *backend.User) (aws.CredentialsProvider, bool) {
+	roleARN, _ := ctx.Value(userRoleKey{}).(string)
+	if roleARN == "" || ds.userCredsCache == nil {
+		return nil, false
+	}
+	cached, ok := ds.userCredsCache.Get(userCredentialsKey(user, roleARN))
+	if !ok {
+		return nil, false
+	}
+	return cached.(*userCredentials).credentials, true
+}
+
+func userCredentialsKey(user *backend.User, roleARN string) string {
+	login := ""
+	if user != nil {
+		login = user.Login
+	}
+	return login + "|" + roleARN
+}
+
+// roleSessionName names the assumed role session after the Grafana user, so CloudTrail entries
+// can be traced back to them.
+func roleSessionName(user *backend.User) string {
+	name := "grafana"
+	if user != nil && user.Login != "" {
+		name = fmt.Sprintf("grafana-%s", invalidRoleSessionNameChars.ReplaceAllString(user.Login, "_"))
+	}
+	if len(name) > maxRoleSessionNameLength {
+		name = name[:maxRoleSessionNameLength]
+	}
+	return name
+}
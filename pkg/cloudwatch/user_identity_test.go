@@ -0,0 +1,190 @@
+package cloudwatch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_withWebIdentityToken(t *testing.T) {
+	t.Run("prefers the id token", func(t *testing.T) {
+		req := &backend.QueryDataRequest{}
+		req.SetHTTPHeader(backend.OAuthIdentityTokenHeaderName, "Bearer access-token")
+		req.SetHTTPHeader(backend.OAuthIdentityIDTokenHeaderName, "id-token")
+
+		ctx := withWebIdentityToken(context.Background(), req.GetHTTPHeader)
+		assert.Equal(t, "id-token", webIdentityTokenFromContext(ctx))
+	})
+
+	t.Run("falls back to the access token", func(t *testing.T) {
+		req := &backend.CallResourceRequest{}
+		req.SetHTTPHeader(backend.OAuthIdentityTokenHeaderName, "Bearer access-token")
+
+		ctx := withWebIdentityToken(context.Background(), req.GetHTTPHeader)
+		assert.Equal(t, "access-token", webIdentityTokenFromContext(ctx))
+	})
+
+	t.Run("leaves the context alone without forwarded identity", func(t *testing.T) {
+		ctx := withWebIdentityToken(context.Background(), (&backend.QueryDataRequest{}).GetHTTPHeader)
+		assert.Empty(t, webIdentityTokenFromContext(ctx))
+	})
+}
+
+func Test_webIdentityRoleForUser(t *testing.T) {
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.WebIdentityRoleMap = map[string]string{
+			"login:alice":              "arn:aws:iam::123456789012:role/alice",
+			"email:bob@example.com":    "arn:aws:iam::123456789012:role/bob",
+			"role:Editor":              "arn:aws:iam::123456789012:role/editors",
+			"role:Admin":               "arn:aws:iam::123456789012:role/admins",
+			webIdentityRoleMapWildcard: "arn:aws:iam::123456789012:role/everyone",
+		}
+	})
+
+	tests := []struct {
+		name     string
+		user     *backend.User
+		expected string
+	}{
+		{"by login", &backend.User{Login: "alice", Email: "bob@example.com", Role: "Editor"}, "arn:aws:iam::123456789012:role/alice"},
+		{"by email", &backend.User{Login: "bob", Email: "bob@example.com", Role: "Editor"}, "arn:aws:iam::123456789012:role/bob"},
+		{"by org role", &backend.User{Login: "carol", Role: "Editor"}, "arn:aws:iam::123456789012:role/editors"},
+		{"by wildcard", &backend.User{Login: "dave", Role: "Viewer"}, "arn:aws:iam::123456789012:role/everyone"},
+		{"not by a login named after an org role", &backend.User{Login: "Admin", Role: "Viewer"}, "arn:aws:iam::123456789012:role/everyone"},
+		{"not by an email named after an org role", &backend.User{Login: "erin", Email: "Editor", Role: "Viewer"}, "arn:aws:iam::123456789012:role/everyone"},
+		{"without a user", nil, "arn:aws:iam::123456789012:role/everyone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roleARN, err := ds.webIdentityRoleForUser(tt.user)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, roleARN)
+		})
+	}
+
+	t.Run("errors when nothing matches", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.WebIdentityRoleMap = map[string]string{"login:alice": "arn:aws:iam::123456789012:role/alice"}
+		})
+		_, err := ds.webIdentityRoleForUser(&backend.User{Login: "dave"})
+		assert.ErrorIs(t, err, models.ErrNoWebIdentityRole)
+	})
+}
+
+func Test_newAWSConfig_userIdentityPassThrough(t *testing.T) {
+	origNewWebIdentityCredentials := NewWebIdentityCredentials
+	t.Cleanup(func() {
+		NewWebIdentityCredentials = origNewWebIdentityCredentials
+	})
+
+	var gotRoleARN, gotSessionName, gotToken string
+	NewWebIdentityCredentials = func(_ aws.Config, roleARN string, sessionName string, token stscreds.IdentityTokenRetriever) aws.CredentialsProvider {
+		gotRoleARN, gotSessionName = roleARN, sessionName
+		tokenBytes, _ := token.GetIdentityToken()
+		gotToken = string(tokenBytes)
+		return aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "user-key", SecretAccessKey: "user-secret"}, nil
+		})
+	}
+
+	ds := newTestDatasource(func(ds *DataSource) {
+		ds.Settings.Region = "us-east-1"
+		ds.Settings.UserIdentityPassThrough = true
+		ds.Settings.WebIdentityRoleMap = map[string]string{"login:alice": "arn:aws:iam::123456789012:role/alice"}
+	})
+
+	t.Run("exchanges the forwarded identity for the mapped role's credentials", func(t *testing.T) {
+		ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{User: &backend.User{Login: "alice"}})
+		ctx = context.WithValue(ctx, webIdentityTokenKey{}, "id-token")
+
+		cfg, err := ds.newAWSConfig(ctx, defaultRegion)
+		require.NoError(t, err)
+		creds, err := cfg.Credentials.Retrieve(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, "user-key", creds.AccessKeyID)
+		assert.Equal(t, "arn:aws:iam::123456789012:role/alice", gotRoleARN)
+		assert.Equal(t, "grafana-alice", gotSessionName)
+		assert.Equal(t, "id-token", gotToken)
+	})
+
+	t.Run("reuses the credentials of a user and role", func(t *testing.T) {
+		exchanges := 0
+		var tokens []string
+		NewWebIdentityCredentials = func(_ aws.Config, _ string, _ string, token stscreds.IdentityTokenRetriever) aws.CredentialsProvider {
+			return aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				exchanges++
+				tokenBytes, _ := token.GetIdentityToken()
+				tokens = append(tokens, string(tokenBytes))
+				return aws.Credentials{AccessKeyID: "user-key", SecretAccessKey: "user-secret", CanExpire: true, Expires: time.Now().Add(time.Hour)}, nil
+			})
+		}
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.UserIdentityPassThrough = true
+			ds.Settings.WebIdentityRoleMap = map[string]string{webIdentityRoleMapWildcard: "arn:aws:iam::123456789012:role/users"}
+			ds.userCredsCache = cache.New(userCredentialsExpiration, userCredentialsExpiration)
+		})
+		retrieve := func(login, token string) {
+			ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{User: &backend.User{Login: login}})
+			ctx = context.WithValue(ctx, webIdentityTokenKey{}, token)
+			cfg, err := ds.newAWSConfig(ctx, defaultRegion)
+			require.NoError(t, err)
+			_, err = cfg.Credentials.Retrieve(ctx)
+			require.NoError(t, err)
+		}
+
+		retrieve("alice", "alice-token-1")
+		retrieve("alice", "alice-token-2")
+		retrieve("bob", "bob-token")
+		assert.Equal(t, 2, exchanges, "the identity of a user is exchanged once, and users don't share credentials")
+
+		// expired credentials are refreshed with the latest token of the user
+		cached, ok := ds.userCredsCache.Get("alice|arn:aws:iam::123456789012:role/users")
+		require.True(t, ok)
+		cached.(*userCredentials).credentials.(*aws.CredentialsCache).Invalidate()
+		retrieve("alice", "alice-token-3")
+		assert.Equal(t, []string{"alice-token-1", "bob-token", "alice-token-3"}, tokens)
+	})
+
+	t.Run("fails without a forwarded identity", func(t *testing.T) {
+		ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{User: &backend.User{Login: "alice"}})
+
+		_, err := ds.newAWSConfig(ctx, defaultRegion)
+		assert.ErrorIs(t, err, models.ErrMissingWebIdentityToken)
+	})
+
+	t.Run("reuses the credentials of an earlier request of the user for the role stored without a forwarded identity", func(t *testing.T) {
+		ds := newTestDatasource(func(ds *DataSource) {
+			ds.Settings.Region = "us-east-1"
+			ds.Settings.UserIdentityPassThrough = true
+			ds.userCredsCache = cache.New(userCredentialsExpiration, userCredentialsExpiration)
+		})
+		ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{User: &backend.User{Login: "alice"}})
+		ctx = withUserRole(ctx, "arn:aws:iam::123456789012:role/alice")
+
+		_, err := ds.newAWSConfig(ctx, defaultRegion)
+		assert.ErrorIs(t, err, models.ErrMissingWebIdentityToken, "the user made no earlier request")
+
+		earlier := ds.userCredentials(aws.Config{}, &backend.User{Login: "alice"}, "arn:aws:iam::123456789012:role/alice", "id-token")
+		cfg, err := ds.newAWSConfig(ctx, defaultRegion)
+		require.NoError(t, err)
+		assert.Same(t, earlier, cfg.Credentials)
+	})
+}
+
+func Test_roleSessionName(t *testing.T) {
+	assert.Equal(t, "grafana", roleSessionName(nil))
+	assert.Equal(t, "grafana-alice@example.com", roleSessionName(&backend.User{Login: "alice@example.com"}))
+	assert.Equal(t, "grafana-alice_smith", roleSessionName(&backend.User{Login: "alice smith"}))
+	assert.Len(t, roleSessionName(&backend.User{Login: strings.Repeat("a", 100)}), maxRoleSessionNameLength)
+}
@@ -0,0 +1,252 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// defaultFlowLogFormat is the format of the flow logs created without a custom format, i.e. the fields of version 2
+const defaultFlowLogFormat = "${version} ${account-id} ${interface-id} ${srcaddr} ${dstaddr} ${srcport} ${dstport} ${protocol} ${packets} ${bytes} ${start} ${end} ${action} ${log-status}"
+
+type flowLogFieldType int
+
+const (
+	flowLogString flowLogFieldType = iota
+	flowLogNumber
+	flowLogTime
+)
+
+// flowLogFieldTypes are the types of the fields of the flow log records of versions 2 to 5 that aren't strings. See
+// https://docs.aws.amazon.com/vpc/latest/userguide/flow-log-records.html
+var flowLogFieldTypes = map[string]flowLogFieldType{
+	"version":      flowLogNumber,
+	"srcport":      flowLogNumber,
+	"dstport":      flowLogNumber,
+	"protocol":     flowLogNumber,
+	"packets":      flowLogNumber,
+	"bytes":        flowLogNumber,
+	"start":        flowLogTime,
+	"end":          flowLogTime,
+	"tcp-flags":    flowLogNumber,
+	"traffic-path": flowLogNumber,
+}
+
+// flowLogField matches a field placeholder of a flow log format
+var flowLogField = regexp.MustCompile(`^\$\{([a-z0-9-]+)\}$`)
+
+// vpcFlowLogsAggregation is a canned aggregation of the flow log records, which needs the fields it aggregates to be
+// part of the format of the records.
+type vpcFlowLogsAggregation struct {
+	fields []string
+	query  string
+}
+
+var vpcFlowLogsAggregations = map[dataquery.VPCFlowLogsQuery]vpcFlowLogsAggregation{
+	dataquery.VPCFlowLogsQueryTopTalkers: {
+		fields: []string{"srcaddr", "dstaddr", "packets", "bytes"},
+		query:  "stats sum(bytes) as total_bytes, sum(packets) as total_packets by srcaddr, dstaddr | sort total_bytes desc | limit 10",
+	},
+	dataquery.VPCFlowLogsQueryRejectedConnections: {
+		fields: []string{"srcaddr", "dstaddr", "dstport", "action"},
+		query:  `filter action = "REJECT" | stats count(*) as rejected by srcaddr, dstaddr, dstport | sort rejected desc | limit 25`,
+	},
+}
+
+// executeVPCFlowLogsQueries runs the queries as Logs Insights queries parsing the flow log records of their log groups,
+// and converts the fields of the records to numbers and times.
+func (ds *DataSource) executeVPCFlowLogsQueries(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	insightsReq := &backend.QueryDataRequest{PluginContext: req.PluginContext, Headers: req.Headers}
+	fields := map[string][]string{}
+	for _, query := range req.Queries {
+		var logsQuery models.LogsQuery
+		if err := json.Unmarshal(query.JSON, &logsQuery); err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+			continue
+		}
+		insightsQuery, recordFields, err := buildVPCFlowLogsQuery(logsQuery)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		query.JSON, err = json.Marshal(insightsQuery)
+		if err != nil {
+			return nil, err
+		}
+		insightsReq.Queries = append(insightsReq.Queries, query)
+		fields[query.RefID] = recordFields
+	}
+	if len(insightsReq.Queries) == 0 {
+		return resp, nil
+	}
+
+	insightsResp, err := executeSyncLogQuery(ctx, ds, insightsReq)
+	if err != nil {
+		return nil, err
+	}
+	for refId, response := range insightsResp.Responses {
+		for _, frame := range response.Frames {
+			frame.RefID = refId
+			setFlowLogFieldTypes(frame, fields[refId])
+		}
+		resp.Responses[refId] = response
+	}
+	return resp, nil
+}
+
+// buildVPCFlowLogsQuery returns the Logs Insights query running the VPC Flow Logs query, along with the fields of the
+// flow log records it parses.
+func buildVPCFlowLogsQuery(logsQuery models.LogsQuery) (dataquery.CloudWatchLogsQuery, []string, error) {
+	format := defaultFlowLogFormat
+	if logsQuery.FlowLogFormat != nil && strings.TrimSpace(*logsQuery.FlowLogFormat) != "" {
+		format = *logsQuery.FlowLogFormat
+	}
+	fields, err := parseFlowLogFormat(format)
+	if err != nil {
+		return dataquery.CloudWatchLogsQuery{}, nil, err
+	}
+
+	aliases := make([]string, len(fields))
+	for i, field := range fields {
+		aliases[i] = flowLogFieldAlias(field)
+	}
+	expression := fmt.Sprintf("parse @message %s as %s", strconv.Quote(strings.TrimSpace(strings.Repeat("* ", len(fields)))), strings.Join(aliases, ", "))
+
+	vpcFlowLogsQuery := dataquery.VPCFlowLogsQueryRecords
+	if logsQuery.VpcFlowLogsQuery != nil {
+		vpcFlowLogsQuery = *logsQuery.VpcFlowLogsQuery
+	}
+	if vpcFlowLogsQuery == dataquery.VPCFlowLogsQueryRecords {
+		expression += " | sort @timestamp desc"
+	} else {
+		aggregation, ok := vpcFlowLogsAggregations[vpcFlowLogsQuery]
+		if !ok {
+			return dataquery.CloudWatchLogsQuery{}, nil, backend.DownstreamError(fmt.Errorf("unknown VPC Flow Logs query %q", vpcFlowLogsQuery))
+		}
+		for _, field := range aggregation.fields {
+			if !slices.Contains(fields, field) {
+				return dataquery.CloudWatchLogsQuery{}, nil, backend.DownstreamError(fmt.Errorf("the %s VPC Flow Logs query needs the ${%s} field in the flow log format", vpcFlowLogsQuery, field))
+			}
+		}
+		expression += " | " + aggregation.query
+	}
+
+	insightsQuery := logsQuery.CloudWatchLogsQuery
+	insightsQuery.LogsMode = nil
+	insightsQuery.QueryLanguage = nil
+	insightsQuery.StatsGroups = nil
+	insightsQuery.Expression = &expression
+	return insightsQuery, fields, nil
+}
+
+// parseFlowLogFormat returns the fields of a flow log format in the order they appear in the records.
+func parseFlowLogFormat(format string) ([]string, error) {
+	placeholders := strings.Fields(format)
+	fields := make([]string, 0, len(placeholders))
+	for _, placeholder := range placeholders {
+		match := flowLogField.FindStringSubmatch(placeholder)
+		if match == nil {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid flow log format: %q isn't a ${field} placeholder", placeholder))
+		}
+		fields = append(fields, match[1])
+	}
+	if len(fields) == 0 {
+		return nil, backend.DownstreamError(errors.New("invalid flow log format: no fields"))
+	}
+	return fields, nil
+}
+
+// flowLogFieldAlias returns the name of the Logs Insights field a flow log field is parsed into, as dashes can't be
+// part of field names.
+func flowLogFieldAlias(field string) string {
+	return strings.ReplaceAll(field, "-", "_")
+}
+
+// setFlowLogFieldTypes converts the fields of the frame parsed from flow log records to the type of the record field,
+// so that addresses and account IDs stay strings whatever they look like, and start and end become times. The "-"
+// records have for missing values becomes null.
+func setFlowLogFieldTypes(frame *data.Frame, recordFields []string) {
+	types := map[string]flowLogFieldType{}
+	for _, field := range recordFields {
+		types[flowLogFieldAlias(field)] = flowLogFieldTypes[field]
+	}
+
+	for i, field := range frame.Fields {
+		fieldType, ok := types[field.Name]
+		if !ok {
+			continue
+		}
+		var values any
+		switch fieldType {
+		case flowLogNumber:
+			numbers := make([]*float64, field.Len())
+			for row := range numbers {
+				if value, ok := flowLogValue(field, row); ok {
+					if number, err := strconv.ParseFloat(value, 64); err == nil {
+						numbers[row] = &number
+					}
+				}
+			}
+			values = numbers
+		case flowLogTime:
+			times := make([]*time.Time, field.Len())
+			for row := range times {
+				if value, ok := flowLogValue(field, row); ok {
+					if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+						t := time.Unix(seconds, 0).UTC()
+						times[row] = &t
+					}
+				}
+			}
+			values = times
+		default:
+			strs := make([]*string, field.Len())
+			for row := range strs {
+				if value, ok := flowLogValue(field, row); ok {
+					strs[row] = &value
+				}
+			}
+			values = strs
+		}
+		typed := data.NewField(field.Name, field.Labels, values)
+		typed.Config = field.Config
+		frame.Fields[i] = typed
+	}
+}
+
+// flowLogValue returns the value of a row of a field as the text of the record it was parsed from, whichever type
+// the Logs Insights results were converted to.
+func flowLogValue(field *data.Field, row int) (string, bool) {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return "", false
+	}
+	var text string
+	switch typed := value.(type) {
+	case string:
+		text = typed
+	case float64:
+		text = strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		text = strconv.FormatBool(typed)
+	default:
+		return "", false
+	}
+	if text == "" || text == "-" {
+		return "", false
+	}
+	return text, true
+}
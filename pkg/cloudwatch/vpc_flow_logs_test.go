@@ -0,0 +1,123 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/kinds/dataquery"
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+func Test_buildVPCFlowLogsQuery(t *testing.T) {
+	query := func(vpcFlowLogsQuery dataquery.VPCFlowLogsQuery, format string) models.LogsQuery {
+		return models.LogsQuery{CloudWatchLogsQuery: dataquery.CloudWatchLogsQuery{
+			LogGroupNames:    []string{"vpc-flow-logs"},
+			VpcFlowLogsQuery: &vpcFlowLogsQuery,
+			FlowLogFormat:    &format,
+		}}
+	}
+
+	t.Run("parses the records of the default format", func(t *testing.T) {
+		insightsQuery, fields, err := buildVPCFlowLogsQuery(query(dataquery.VPCFlowLogsQueryRecords, ""))
+		require.NoError(t, err)
+		assert.Equal(t, `parse @message "* * * * * * * * * * * * * *" as version, account_id, interface_id, srcaddr, dstaddr, srcport, dstport, protocol, packets, bytes, start, end, action, log_status | sort @timestamp desc`, *insightsQuery.Expression)
+		assert.Len(t, fields, 14)
+		assert.Equal(t, []string{"vpc-flow-logs"}, insightsQuery.LogGroupNames)
+		assert.Nil(t, insightsQuery.LogsMode)
+	})
+
+	t.Run("runs the aggregations on custom formats", func(t *testing.T) {
+		insightsQuery, fields, err := buildVPCFlowLogsQuery(query(dataquery.VPCFlowLogsQueryRejectedConnections, "${vpc-id} ${srcaddr} ${dstaddr} ${dstport} ${action}"))
+		require.NoError(t, err)
+		assert.Equal(t, `parse @message "* * * * *" as vpc_id, srcaddr, dstaddr, dstport, action | filter action = "REJECT" | stats count(*) as rejected by srcaddr, dstaddr, dstport | sort rejected desc | limit 25`, *insightsQuery.Expression)
+		assert.Equal(t, []string{"vpc-id", "srcaddr", "dstaddr", "dstport", "action"}, fields)
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		_, _, err := buildVPCFlowLogsQuery(query(dataquery.VPCFlowLogsQueryTopTalkers, "${srcaddr} ${dstaddr} ${bytes}"))
+		assert.ErrorContains(t, err, "the TopTalkers VPC Flow Logs query needs the ${packets} field in the flow log format")
+
+		_, _, err = buildVPCFlowLogsQuery(query(dataquery.VPCFlowLogsQueryRecords, "${srcaddr} dstaddr"))
+		assert.ErrorContains(t, err, `invalid flow log format: "dstaddr" isn't a ${field} placeholder`)
+
+		_, _, err = buildVPCFlowLogsQuery(query("Unknown", ""))
+		assert.ErrorContains(t, err, `unknown VPC Flow Logs query "Unknown"`)
+	})
+}
+
+func TestQuery_VPCFlowLogs(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+	})
+	cli := fakeCWLogsClient{queryResults: cloudwatchlogs.GetQueryResultsOutput{
+		Status: cloudwatchlogstypes.QueryStatusComplete,
+		Results: [][]cloudwatchlogstypes.ResultField{
+			{
+				{Field: aws.String("@timestamp"), Value: aws.String("2024-01-01 00:00:10.000")},
+				{Field: aws.String("account_id"), Value: aws.String("123456789012")},
+				{Field: aws.String("dstport"), Value: aws.String("443")},
+				{Field: aws.String("start"), Value: aws.String("1704067200")},
+			},
+			{
+				{Field: aws.String("@timestamp"), Value: aws.String("2024-01-01 00:00:20.000")},
+				{Field: aws.String("account_id"), Value: aws.String("123456789012")},
+				{Field: aws.String("dstport"), Value: aws.String("-")},
+				{Field: aws.String("start"), Value: aws.String("1704067210")},
+			},
+		},
+	}}
+	NewCWLogsClient = func(cfg aws.Config) models.CWLogsClient {
+		return &cli
+	}
+	ds := newTestDatasource()
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)},
+				JSON:      json.RawMessage(`{"queryMode": "Logs", "logsMode": "VPCFlowLogs", "vpcFlowLogsQuery": "Records", "logGroupNames": ["vpc-flow-logs"], "flowLogFormat": "${account-id} ${dstport} ${start}"}`),
+			},
+			{
+				RefID: "B",
+				JSON:  json.RawMessage(`{"queryMode": "Logs", "logsMode": "VPCFlowLogs", "vpcFlowLogsQuery": "TopTalkers", "flowLogFormat": "${srcaddr}"}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, cli.calls.startQuery, 1)
+	assert.Equal(t, []string{"vpc-flow-logs"}, cli.calls.startQuery[0].LogGroupNames)
+	assert.Contains(t, *cli.calls.startQuery[0].QueryString, `parse @message "* * *" as account_id, dstport, start | sort @timestamp desc`)
+
+	require.NoError(t, resp.Responses["A"].Error)
+	frames := resp.Responses["A"].Frames
+	require.Len(t, frames, 1)
+	assert.Equal(t, "A", frames[0].RefID)
+
+	accountId, _ := frames[0].FieldByName("account_id")
+	require.NotNil(t, accountId)
+	assert.Equal(t, "123456789012", *accountId.At(0).(*string))
+
+	dstPort, _ := frames[0].FieldByName("dstport")
+	require.NotNil(t, dstPort)
+	assert.Equal(t, 443.0, *dstPort.At(0).(*float64))
+	assert.Nil(t, dstPort.At(1))
+
+	start, _ := frames[0].FieldByName("start")
+	require.NotNil(t, start)
+	assert.Equal(t, time.Unix(1704067200, 0).UTC(), *start.At(0).(*time.Time))
+
+	assert.ErrorContains(t, resp.Responses["B"].Error, "needs the ${dstaddr} field")
+}
@@ -0,0 +1,65 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models"
+)
+
+// wafLogGroupPrefix is the prefix WAF requires the names of the log groups it delivers logs to to start with
+const wafLogGroupPrefix = "aws-waf-logs-"
+
+// validWebACLName matches the names WAF allows for web ACLs
+var validWebACLName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,128}$`)
+
+// wafPresets are queries of the WAF logs of a web ACL. The nested request fields are renamed to flat ones so that
+// they read well as columns. See https://docs.aws.amazon.com/waf/latest/developerguide/logging-fields.html
+var wafPresets = []logsQueryPreset{
+	{
+		id:          "blockedRequestsByRule",
+		label:       "Blocked requests by rule",
+		description: "Number of requests blocked by each rule of the web ACL.",
+		query:       `filter action = "BLOCK" | stats count(*) as blocked by bin(auto), terminatingRuleId`,
+		statsGroups: []string{"terminatingRuleId"},
+	},
+	{
+		id:          "topClientIps",
+		label:       "Top client IPs",
+		description: "Client addresses that sent the most requests, with the share of them that were blocked.",
+		query:       `fields httpRequest.clientIp as clientIp, httpRequest.country as country, action = "BLOCK" as isBlocked | stats count(*) as requests, sum(isBlocked) as blocked by clientIp, country | sort requests desc | limit 25`,
+	},
+	{
+		id:          "blockedRequests",
+		label:       "Blocked requests",
+		description: "Latest requests blocked by the web ACL.",
+		query:       `filter action = "BLOCK" | fields @timestamp, terminatingRuleId as rule, httpRequest.clientIp as clientIp, httpRequest.country as country, httpRequest.httpMethod as method, httpRequest.uri as uri | sort @timestamp desc`,
+	},
+}
+
+// WAFPresetsHandler returns the query presets of the WAF logs of the web ACL given by the webAclName parameter. The
+// logs are read from the log group given by the logGroupName parameter, or from the WAF log group named after the web
+// ACL if there is none.
+func (ds *DataSource) WAFPresetsHandler(_ context.Context, parameters url.Values) ([]byte, *models.HttpError) {
+	webACLName := parameters.Get("webAclName")
+	if !validWebACLName.MatchString(webACLName) {
+		return nil, models.NewHttpError("error in WAFPresetsHandler", http.StatusBadRequest, errors.New("parameter 'webAclName' must be the name of a web ACL"))
+	}
+	logGroupName := parameters.Get("logGroupName")
+	if logGroupName == "" {
+		logGroupName = wafLogGroupPrefix + webACLName
+	}
+
+	filter := "filter webaclId like " + strconv.Quote("/webacl/"+webACLName+"/") + " | "
+	response := logsQueryPresetsResponse(wafPresets, logGroupName, filter)
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		return nil, models.NewHttpError("error in WAFPresetsHandler", http.StatusInternalServerError, err)
+	}
+	return jsonResponse, nil
+}
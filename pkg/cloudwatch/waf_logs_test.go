@@ -0,0 +1,57 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch/models/resources"
+)
+
+func Test_waf_presets_route(t *testing.T) {
+	ds := newTestDatasource()
+	handler := http.HandlerFunc(ds.resourceRequestMiddleware(ds.WAFPresetsHandler))
+
+	t.Run("returns the presets of the web ACL", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/waf-presets?webAclName=storefront", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var presets []resources.ResourceResponse[resources.LogsQueryPreset]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &presets))
+		require.Len(t, presets, len(wafPresets))
+		assert.Equal(t, resources.ResourceResponse[resources.LogsQueryPreset]{
+			Label: "Blocked requests by rule",
+			Value: resources.LogsQueryPreset{
+				Id:            "blockedRequestsByRule",
+				Description:   "Number of requests blocked by each rule of the web ACL.",
+				Expression:    `filter webaclId like "/webacl/storefront/" | filter action = "BLOCK" | stats count(*) as blocked by bin(auto), terminatingRuleId`,
+				LogGroupNames: []string{"aws-waf-logs-storefront"},
+				StatsGroups:   []string{"terminatingRuleId"},
+			},
+		}, presets[0])
+		assert.Equal(t, "topClientIps", presets[1].Value.Id)
+		assert.Equal(t, "blockedRequests", presets[2].Value.Id)
+	})
+
+	t.Run("reads the logs from the given log group", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/waf-presets?webAclName=storefront&logGroupName=aws-waf-logs-all", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var presets []resources.ResourceResponse[resources.LogsQueryPreset]
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &presets))
+		assert.Equal(t, []string{"aws-waf-logs-all"}, presets[0].Value.LogGroupNames)
+	})
+
+	t.Run("rejects a web ACL name that isn't one", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/waf-presets?webAclName=store%22front", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/grafana/grafana-cloudwatch-datasource/pkg/cloudwatch"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
@@ -17,7 +18,16 @@ func main() {
 	// from Grafana to create different instances of SampleDatasource (per datasource
 	// ID). When datasource configuration changed Dispose method will be called and
 	// new datasource instance created using NewSampleDatasource factory.
-	if err := datasource.Manage("grafana-cloudwatch-datasource", cloudwatch.NewDatasource, datasource.ManageOpts{}); err != nil {
+	err := datasource.Manage("grafana-cloudwatch-datasource", cloudwatch.NewDatasource, datasource.ManageOpts{
+		// Admission and query conversion are stateless, so they're served outside of the datasource instances.
+		AdmissionHandler:       cloudwatch.NewAdmissionHandler(),
+		QueryConversionHandler: backend.ConvertQueryFunc(cloudwatch.ConvertQueryDataRequest),
+	})
+
+	// The SDK only disposes of the instances replaced by new settings, so the ones in use are disposed of on exit,
+	// whether Manage returned an error or not.
+	cloudwatch.Shutdown()
+	if err != nil {
 		log.DefaultLogger.Error(err.Error())
 		os.Exit(1)
 	}
@@ -127,6 +127,17 @@ export function setupMockedDataSource({
   datasource.resources.getMetrics = jest.fn().mockResolvedValue([]);
   datasource.resources.getAccounts = jest.fn().mockResolvedValue([]);
   datasource.resources.getLogGroups = jest.fn().mockResolvedValue([]);
+  datasource.resources.getLambdaInsightsPresets = jest.fn().mockResolvedValue([]);
+  datasource.resources.getQueryDefinitions = jest.fn().mockResolvedValue([]);
+  datasource.resources.getQuerySnippets = jest.fn().mockResolvedValue([]);
+  datasource.resources.getAnomalyDetectors = jest.fn().mockResolvedValue([]);
+  datasource.resources.getInsightRules = jest.fn().mockResolvedValue([]);
+  datasource.resources.getApplicationSignalsServices = jest.fn().mockResolvedValue([]);
+  datasource.resources.getServiceLevelObjectives = jest.fn().mockResolvedValue([]);
+  datasource.resources.getMetricMetadata = jest.fn().mockResolvedValue({ namespace: '', metricName: '', series: [] });
+  datasource.resources.getEKSControlPlanePresets = jest.fn().mockResolvedValue([]);
+  datasource.resources.getWAFPresets = jest.fn().mockResolvedValue([]);
+  datasource.resources.getResolverQueryLogPresets = jest.fn().mockResolvedValue([]);
   datasource.resources.isMonitoringAccount = jest.fn().mockResolvedValue(false);
   const fetchMock = jest.fn().mockReturnValue(of({}));
   setBackendSrv({
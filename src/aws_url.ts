@@ -1,3 +1,5 @@
+import { CloudWatchJsonData } from './types';
+
 const JSURL = require('jsurl');
 
 export interface AwsUrl {
@@ -31,3 +33,20 @@ export function encodeUrl(obj: AwsUrl, region: string): string {
     region
   )}/cloudwatch/home?region=${region}#logs-insights:queryDetail=${JSURL.stringify(obj)}`;
 }
+
+// Returns the console url through the access portal of IAM Identity Center, which signs the user in to the account with
+// the permission set of the data source before redirecting them to the console, or url itself if the data source isn't
+// set up for it or the account isn't known.
+export function identityCenterConsoleUrl(url: string, jsonData: CloudWatchJsonData, accountId?: string): string {
+  const startUrl = jsonData.identityCenterStartUrl?.replace(/\/+$/, '');
+  const account = accountId && accountId !== 'all' ? accountId : jsonData.identityCenterAccountId;
+  if (!startUrl || !jsonData.identityCenterRoleName || !account) {
+    return url;
+  }
+  const parameters = new URLSearchParams({
+    account_id: account,
+    role_name: jsonData.identityCenterRoleName,
+    destination: url,
+  });
+  return `${startUrl}/#/console?${parameters.toString()}`;
+}
@@ -14,7 +14,7 @@ import {
 } from '@grafana/data';
 import { ConfigSection } from '@grafana/plugin-ui';
 import { getAppEvents, usePluginInteractionReporter, getDataSourceSrv, config } from '@grafana/runtime';
-import { Alert, Input, FieldProps, Field, Divider, useStyles2 } from '@grafana/ui';
+import { Alert, Input, FieldProps, Field, Divider, Switch, useStyles2 } from '@grafana/ui';
 
 import { CloudWatchDatasource } from '../../datasource';
 import { SelectableResourceValue } from '../../resources/types';
@@ -119,6 +119,77 @@ export const ConfigEditor = (props: Props) => {
             onChange={onUpdateDatasourceJsonDataOption(props, 'customMetricsNamespaces')}
           />
         </Field>
+        <Field
+          htmlFor="readOnly"
+          label="Read-only mode"
+          description="Only allow read APIs to be called. Requests that would modify AWS resources are rejected."
+        >
+          <Switch
+            id="readOnly"
+            value={options.jsonData.readOnly ?? false}
+            onChange={(e) => updateDatasourcePluginJsonDataOption(props, 'readOnly', e.currentTarget.checked)}
+          />
+        </Field>
+        <Field
+          htmlFor="maxSeriesPerQuery"
+          label="Series limit per query"
+          description="Maximum number of series a metric query returns. Further series are dropped with a warning. Defaults to 1000, -1 disables the limit."
+        >
+          <Input
+            id="maxSeriesPerQuery"
+            type="number"
+            width={20}
+            placeholder="1000"
+            value={options.jsonData.maxSeriesPerQuery ?? ''}
+            onChange={(e) =>
+              updateDatasourcePluginJsonDataOption(
+                props,
+                'maxSeriesPerQuery',
+                e.currentTarget.value === '' ? undefined : parseInt(e.currentTarget.value, 10)
+              )
+            }
+          />
+        </Field>
+        <Field
+          htmlFor="metricDataConcurrency"
+          label="Concurrent metric requests"
+          description="Maximum number of GetMetricData requests run at the same time for the queries of a panel or alert rule. Queries are sent in batches of up to 500. Lower it if requests are throttled. Defaults to 10."
+        >
+          <Input
+            id="metricDataConcurrency"
+            type="number"
+            width={20}
+            placeholder="10"
+            value={options.jsonData.metricDataConcurrency ?? ''}
+            onChange={(e) =>
+              updateDatasourcePluginJsonDataOption(
+                props,
+                'metricDataConcurrency',
+                e.currentTarget.value === '' ? undefined : parseInt(e.currentTarget.value, 10)
+              )
+            }
+          />
+        </Field>
+        <Field
+          htmlFor="metricDataMaxPages"
+          label="Metric request page limit"
+          description="Maximum number of pages of results followed for a GetMetricData request. Series cut short by the limit are flagged with a notice. Defaults to 100, -1 disables the limit."
+        >
+          <Input
+            id="metricDataMaxPages"
+            type="number"
+            width={20}
+            placeholder="100"
+            value={options.jsonData.metricDataMaxPages ?? ''}
+            onChange={(e) =>
+              updateDatasourcePluginJsonDataOption(
+                props,
+                'metricDataMaxPages',
+                e.currentTarget.value === '' ? undefined : parseInt(e.currentTarget.value, 10)
+              )
+            }
+          />
+        </Field>
       </ConnectionConfig>
       {config.secureSocksDSProxyEnabled && (
         <SecureSocksProxySettingsNewStyling options={options} onOptionsChange={onOptionsChange} />
@@ -140,6 +211,26 @@ export const ConfigEditor = (props: Props) => {
             title={'The timeout must be a valid duration string, such as "15m" "30s" "2000ms" etc.'}
           />
         </Field>
+        <Field
+          htmlFor="maxLogsQueryLimit"
+          label="Log events limit per query"
+          description="Maximum number of log events a Logs Insights query returns, whatever limit the query sets. Defaults to 10000, the maximum of Logs Insights."
+        >
+          <Input
+            id="maxLogsQueryLimit"
+            type="number"
+            width={20}
+            placeholder="10000"
+            value={options.jsonData.maxLogsQueryLimit ?? ''}
+            onChange={(e) =>
+              updateDatasourcePluginJsonDataOption(
+                props,
+                'maxLogsQueryLimit',
+                e.currentTarget.value === '' ? undefined : parseInt(e.currentTarget.value, 10)
+              )
+            }
+          />
+        </Field>
         <Field
           label="Default Log Groups"
           description="Optionally, specify default log groups for CloudWatch Logs queries."
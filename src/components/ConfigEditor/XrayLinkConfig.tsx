@@ -28,7 +28,7 @@ export function XrayLinkConfig({ newFormStyling, datasourceUid, onChange }: Prop
   return newFormStyling ? (
     <ConfigSection
       title="X-ray trace link"
-      description="Grafana will automatically create a link to a trace in X-ray data source if logs contain @xrayTraceId field"
+      description="Grafana will automatically create a link to a trace in X-ray data source if logs contain @xrayTraceId field or fields of X-ray trace IDs"
     >
       {!hasXrayDatasource && (
         <Alert
@@ -52,7 +52,8 @@ export function XrayLinkConfig({ newFormStyling, datasourceUid, onChange }: Prop
       <h3 className="page-heading">X-ray trace link</h3>
 
       <div className={styles.infoText}>
-        Grafana will automatically create a link to a trace in X-ray data source if logs contain @xrayTraceId field
+        Grafana will automatically create a link to a trace in X-ray data source if logs contain @xrayTraceId field or
+        fields of X-ray trace IDs
       </div>
 
       {!hasXrayDatasource && (
@@ -0,0 +1,63 @@
+import { ChangeEvent } from 'react';
+
+import { QueryEditorProps } from '@grafana/data';
+import { EditorField, EditorRow, EditorSwitch } from '@grafana/plugin-ui';
+import { Input, Space } from '@grafana/ui';
+
+import { CloudWatchDatasource } from '../../../datasource';
+import { CloudWatchAnnotationQuery, CloudWatchJsonData, CloudWatchQuery, MetricStat } from '../../../types';
+import { MetricStatEditor } from '../../shared/MetricStatEditor/MetricStatEditor';
+
+export type Props = QueryEditorProps<CloudWatchDatasource, CloudWatchQuery, CloudWatchJsonData> & {
+  query: CloudWatchAnnotationQuery;
+};
+
+// Finds the alarms whose current state is shown, either by their metric or by the prefixes of their name and actions
+export const AlarmsQueryEditor = (props: Props) => {
+  const { query, onChange } = props;
+
+  return (
+    <>
+      <EditorRow>
+        <EditorField
+          label="Prefix matching"
+          optional={true}
+          tooltip="Find the alarms by the prefixes of their name and actions rather than by their metric."
+        >
+          <EditorSwitch
+            value={query.prefixMatching}
+            onChange={(e) => onChange({ ...query, prefixMatching: e.currentTarget.checked })}
+          />
+        </EditorField>
+        <EditorField label="Action" optional={true} disabled={!query.prefixMatching}>
+          <Input
+            value={query.actionPrefix || ''}
+            onChange={(event: ChangeEvent<HTMLInputElement>) =>
+              onChange({ ...query, actionPrefix: event.target.value })
+            }
+          />
+        </EditorField>
+        <EditorField label="Alarm Name" optional={true} disabled={!query.prefixMatching}>
+          <Input
+            value={query.alarmNamePrefix || ''}
+            onChange={(event: ChangeEvent<HTMLInputElement>) =>
+              onChange({ ...query, alarmNamePrefix: event.target.value })
+            }
+          />
+        </EditorField>
+      </EditorRow>
+      {!query.prefixMatching && (
+        <>
+          <Space v={0.5} />
+          <MetricStatEditor
+            {...props}
+            refId={query.refId}
+            metricStat={query}
+            disableExpressions={true}
+            onChange={(metricStat: MetricStat) => onChange({ ...query, ...metricStat })}
+          />
+        </>
+      )}
+    </>
+  );
+};
@@ -0,0 +1,98 @@
+import { useEffect, useState } from 'react';
+
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { EditorField, EditorRow } from '@grafana/plugin-ui';
+import { Input, RadioButtonGroup, Select } from '@grafana/ui';
+
+import { CloudWatchDatasource } from '../../../datasource';
+import {
+  CloudWatchContributorInsightsQuery,
+  CloudWatchJsonData,
+  CloudWatchQuery,
+  ContributorInsightsFormat,
+} from '../../../types';
+import { appendTemplateVariables } from '../../../utils/utils';
+
+export type Props = QueryEditorProps<CloudWatchDatasource, CloudWatchQuery, CloudWatchJsonData> & {
+  query: CloudWatchContributorInsightsQuery;
+};
+
+const orderByOptions: Array<SelectableValue<string>> = [
+  { label: 'Sum', value: 'Sum' },
+  { label: 'Maximum', value: 'Maximum' },
+];
+
+const formatOptions: Array<SelectableValue<ContributorInsightsFormat>> = [
+  { label: 'Time series', value: ContributorInsightsFormat.TimeSeries },
+  { label: 'Table', value: ContributorInsightsFormat.Table },
+];
+
+// Reports the top contributors of a Contributor Insights rule of the region of the query
+export const ContributorInsightsQueryEditor = ({ query, datasource, onChange }: Props) => {
+  const [rules, setRules] = useState<Array<SelectableValue<string>>>([]);
+  const region = datasource.templateSrv.replace(query.region, {});
+
+  useEffect(() => {
+    datasource.resources.getInsightRules(region).then((rules) => {
+      setRules(
+        appendTemplateVariables(
+          datasource,
+          rules.map(({ value }) => ({ label: value.name, value: value.name, description: value.state }))
+        )
+      );
+    });
+  }, [datasource, region]);
+
+  return (
+    <EditorRow>
+      <EditorField label="Rule" width={30}>
+        <Select
+          aria-label="Rule"
+          value={query.ruleName || null}
+          options={rules}
+          allowCustomValue
+          onChange={({ value }) => onChange({ ...query, ruleName: value ?? '' })}
+        />
+      </EditorField>
+      <EditorField label="Order by" tooltip="The statistic the contributors are ranked by.">
+        <Select
+          aria-label="Order by"
+          value={query.orderBy ?? 'Sum'}
+          options={orderByOptions}
+          onChange={({ value }) => onChange({ ...query, orderBy: value })}
+        />
+      </EditorField>
+      <EditorField label="Contributors" optional tooltip="The maximum number of top contributors, from 1 to 100.">
+        <Input
+          type="number"
+          min={1}
+          max={100}
+          placeholder="10"
+          value={query.maxContributorCount ?? ''}
+          onChange={(event) => {
+            const count = parseInt(event.currentTarget.value, 10);
+            onChange({ ...query, maxContributorCount: isNaN(count) ? undefined : count });
+          }}
+        />
+      </EditorField>
+      <EditorField
+        label="Period"
+        optional
+        tooltip="The period of the datapoints in seconds. Picked from the time range if empty."
+      >
+        <Input
+          placeholder="auto"
+          value={query.period ?? ''}
+          onChange={(event) => onChange({ ...query, period: event.currentTarget.value })}
+        />
+      </EditorField>
+      <EditorField label="Format">
+        <RadioButtonGroup
+          value={query.format ?? ContributorInsightsFormat.TimeSeries}
+          options={formatOptions}
+          onChange={(format) => onChange({ ...query, format })}
+        />
+      </EditorField>
+    </EditorRow>
+  );
+};
@@ -1,15 +1,16 @@
 import { memo, useCallback, useEffect, useState } from 'react';
-import { useEffectOnce } from 'react-use';
+import { useAsync, useEffectOnce } from 'react-use';
 
 import { QueryEditorProps, SelectableValue } from '@grafana/data';
 import { InlineSelect } from '@grafana/plugin-ui';
 
 import { CloudWatchDatasource } from '../../../datasource';
 import { DEFAULT_CWLI_QUERY_STRING, DEFAULT_PPL_QUERY_STRING, DEFAULT_SQL_QUERY_STRING } from '../../../defaultQueries';
-import { CloudWatchJsonData, CloudWatchLogsQuery, CloudWatchQuery, LogsQueryLanguage } from '../../../types';
+import { CloudWatchJsonData, CloudWatchLogsQuery, CloudWatchQuery, LogsMode, LogsQueryLanguage } from '../../../types';
 
 import { CloudWatchLink } from './CloudWatchLink';
 import { CloudWatchLogsQueryField } from './LogsQueryField';
+import { SaveQueryDefinition } from './SaveQueryDefinition';
 
 type Props = QueryEditorProps<CloudWatchDatasource, CloudWatchQuery, CloudWatchJsonData> & {
   query: CloudWatchLogsQuery;
@@ -22,11 +23,52 @@ const logsQueryLanguageOptions: Array<SelectableValue<LogsQueryLanguage>> = [
   { label: 'OpenSearch PPL', value: LogsQueryLanguage.PPL },
 ];
 
+const logsModeOptions: Array<SelectableValue<LogsMode>> = [
+  { label: 'Logs Insights', value: LogsMode.Insights },
+  { label: 'Log stream events', value: LogsMode.Events },
+  {
+    label: 'Filter pattern',
+    value: LogsMode.Filter,
+    description: 'Match events with FilterLogEvents. Logs Insights queries still run with Logs Insights.',
+  },
+  {
+    label: 'Container Insights',
+    value: LogsMode.ContainerInsights,
+    description: 'Pre-built aggregations of the Container Insights performance logs of a cluster.',
+  },
+  {
+    label: 'VPC Flow Logs',
+    value: LogsMode.VPCFlowLogs,
+    description: 'Typed flow log records of the log groups, or canned aggregations of them.',
+  },
+  {
+    label: 'Live Tail',
+    value: LogsMode.LiveTail,
+    description: 'Stream the new events of the log groups matching a filter pattern as they arrive.',
+  },
+];
+
 export const CloudWatchLogsQueryEditor = memo(function CloudWatchLogsQueryEditor(props: Props) {
   const { query, data, datasource, onChange, extraHeaderElementLeft } = props;
 
   const [isQueryNew, setIsQueryNew] = useState(true);
 
+  const { value: lambdaInsightsPresets } = useAsync(
+    () => datasource.resources.getLambdaInsightsPresets(),
+    [datasource]
+  );
+
+  // listing the saved queries may not be allowed, in which case they're simply not offered
+  const { value: queryDefinitions } = useAsync(
+    () => datasource.resources.getQueryDefinitions(query.region).catch(() => []),
+    [datasource, query.region]
+  );
+
+  const { value: querySnippets } = useAsync(
+    () => datasource.resources.getQuerySnippets(query.queryLanguage || LogsQueryLanguage.CWLI),
+    [datasource, query.queryLanguage]
+  );
+
   const onQueryLanguageChange = useCallback(
     (language: LogsQueryLanguage | undefined) => {
       if (isQueryNew) {
@@ -58,20 +100,119 @@ export const CloudWatchLogsQueryEditor = memo(function CloudWatchLogsQueryEditor
 
   useEffect(() => {
     extraHeaderElementLeft?.(
-      <InlineSelect
-        label="Query language"
-        value={query.queryLanguage || LogsQueryLanguage.CWLI}
-        options={logsQueryLanguageOptions}
-        onChange={({ value }) => {
-          onQueryLanguageChange(value);
-        }}
-      />
+      <>
+        <InlineSelect
+          label="Mode"
+          value={query.logsMode || LogsMode.Insights}
+          options={logsModeOptions}
+          onChange={({ value }) => {
+            const logsMode = value ?? LogsMode.Insights;
+            // filter patterns fall back to Logs Insights QL, the only language they can be told apart from
+            onChange(
+              logsMode === LogsMode.Filter || logsMode === LogsMode.LiveTail
+                ? { ...query, logsMode, queryLanguage: LogsQueryLanguage.CWLI }
+                : { ...query, logsMode }
+            );
+          }}
+        />
+        {(query.logsMode ?? LogsMode.Insights) === LogsMode.Insights && (
+          <InlineSelect
+            label="Query language"
+            value={query.queryLanguage || LogsQueryLanguage.CWLI}
+            options={logsQueryLanguageOptions}
+            onChange={({ value }) => {
+              onQueryLanguageChange(value);
+            }}
+          />
+        )}
+        {(query.logsMode ?? LogsMode.Insights) === LogsMode.Insights && !!lambdaInsightsPresets?.length && (
+          <InlineSelect
+            label="Lambda Insights"
+            placeholder="Choose preset"
+            value={null}
+            options={lambdaInsightsPresets.map(({ label, value }) => ({
+              label,
+              value,
+              description: value.description,
+            }))}
+            onChange={({ value: preset }) => {
+              if (!preset) {
+                return;
+              }
+              setIsQueryNew(false);
+              onChange({
+                ...query,
+                queryLanguage: LogsQueryLanguage.CWLI,
+                expression: preset.expression,
+                logGroups: undefined,
+                logGroupNames: preset.logGroupNames,
+                statsGroups: preset.statsGroups,
+              });
+            }}
+          />
+        )}
+        {(query.logsMode ?? LogsMode.Insights) === LogsMode.Insights && !!queryDefinitions?.length && (
+          <InlineSelect
+            label="Saved queries"
+            placeholder="Choose query"
+            value={null}
+            options={queryDefinitions.map(({ label, value }) => ({
+              label,
+              value,
+              description: value.logGroupNames.join(', '),
+            }))}
+            onChange={({ value: queryDefinition }) => {
+              if (!queryDefinition) {
+                return;
+              }
+              setIsQueryNew(false);
+              onChange({
+                ...query,
+                queryDefinitionId: undefined,
+                queryLanguage: (queryDefinition.queryLanguage as LogsQueryLanguage) || LogsQueryLanguage.CWLI,
+                expression: queryDefinition.queryString,
+                ...(queryDefinition.logGroupNames.length
+                  ? { logGroups: undefined, logGroupNames: queryDefinition.logGroupNames }
+                  : {}),
+              });
+            }}
+          />
+        )}
+        {(query.logsMode ?? LogsMode.Insights) === LogsMode.Insights && !!querySnippets?.length && (
+          <InlineSelect
+            label="Snippets"
+            placeholder="Insert snippet"
+            value={null}
+            options={querySnippets.map(({ label, value }) => ({
+              label: `${value.service}: ${label}`,
+              value,
+              description: value.description,
+            }))}
+            onChange={({ value: snippet }) => {
+              if (!snippet) {
+                return;
+              }
+              // the placeholders of the snippet are left for the user to replace
+              setIsQueryNew(false);
+              onChange({ ...query, queryDefinitionId: undefined, expression: snippet.queryString });
+            }}
+          />
+        )}
+      </>
     );
 
     return () => {
       extraHeaderElementLeft?.(undefined);
     };
-  }, [extraHeaderElementLeft, onChange, onQueryLanguageChange, query]);
+  }, [
+    extraHeaderElementLeft,
+    lambdaInsightsPresets,
+    queryDefinitions,
+    querySnippets,
+    onChange,
+    onQueryLanguageChange,
+    query,
+  ]);
 
   const onQueryStringChange = (query: CloudWatchQuery) => {
     onChange(query);
@@ -82,7 +223,14 @@ export const CloudWatchLogsQueryEditor = memo(function CloudWatchLogsQueryEditor
     <CloudWatchLogsQueryField
       {...props}
       onChange={onQueryStringChange}
-      ExtraFieldElement={<CloudWatchLink query={query} panelData={data} datasource={datasource} />}
+      ExtraFieldElement={
+        <>
+          <CloudWatchLink query={query} panelData={data} datasource={datasource} />
+          {(query.logsMode ?? LogsMode.Insights) === LogsMode.Insights && (
+            <SaveQueryDefinition query={query} datasource={datasource} />
+          )}
+        </>
+      }
     />
   );
 });
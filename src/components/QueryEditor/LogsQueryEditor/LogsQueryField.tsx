@@ -1,11 +1,20 @@
 import { css } from '@emotion/css';
 import { ReactNode, useCallback } from 'react';
 
-import { GrafanaTheme2, QueryEditorProps } from '@grafana/data';
-import { useStyles2 } from '@grafana/ui';
+import { GrafanaTheme2, QueryEditorProps, SelectableValue } from '@grafana/data';
+import { EditorField, EditorRow, EditorSwitch } from '@grafana/plugin-ui';
+import { Input, Select, useStyles2 } from '@grafana/ui';
 
 import { CloudWatchDatasource } from '../../../datasource';
-import { CloudWatchJsonData, CloudWatchLogsQuery, CloudWatchQuery, LogsQueryLanguage } from '../../../types';
+import {
+  CloudWatchJsonData,
+  CloudWatchLogsQuery,
+  CloudWatchQuery,
+  ContainerInsightsQuery,
+  LogsMode,
+  LogsQueryLanguage,
+  VPCFlowLogsQuery,
+} from '../../../types';
 import { LogGroupsFieldWrapper } from '../../shared/LogGroups/LogGroupsField';
 
 import { LogsQLCodeEditor } from './code-editors/LogsQLCodeEditor';
@@ -17,6 +26,23 @@ export interface CloudWatchLogsQueryFieldProps
   ExtraFieldElement?: ReactNode;
   query: CloudWatchLogsQuery;
 }
+const containerInsightsQueryOptions: Array<SelectableValue<ContainerInsightsQuery>> = [
+  { label: 'Pod CPU utilization', value: ContainerInsightsQuery.PodCPUUtilization },
+  { label: 'Pod memory utilization', value: ContainerInsightsQuery.PodMemoryUtilization },
+  { label: 'Pod restarts', value: ContainerInsightsQuery.PodRestarts },
+  { label: 'Node CPU utilization', value: ContainerInsightsQuery.NodeCPUUtilization },
+  { label: 'Node memory utilization', value: ContainerInsightsQuery.NodeMemoryUtilization },
+];
+const vpcFlowLogsQueryOptions: Array<SelectableValue<VPCFlowLogsQuery>> = [
+  { label: 'Records', value: VPCFlowLogsQuery.Records, description: 'Flow log records with typed fields.' },
+  { label: 'Top talkers', value: VPCFlowLogsQuery.TopTalkers, description: 'Address pairs that sent the most bytes.' },
+  {
+    label: 'Rejected connections',
+    value: VPCFlowLogsQuery.RejectedConnections,
+    description: 'Connections most often rejected by security groups and network ACLs.',
+  },
+];
+
 export const CloudWatchLogsQueryField = (props: CloudWatchLogsQueryFieldProps) => {
   const { query, datasource, onChange, ExtraFieldElement } = props;
 
@@ -29,6 +55,35 @@ export const CloudWatchLogsQueryField = (props: CloudWatchLogsQueryFieldProps) =
     [onChange]
   );
 
+  if (query.logsMode === LogsMode.ContainerInsights) {
+    return (
+      <EditorRow>
+        <EditorField label="Aggregation" width={30}>
+          <Select
+            inputId={`${query.refId}-cloudwatch-logs-query-editor-container-insights-query`}
+            value={query.containerInsightsQuery}
+            options={containerInsightsQueryOptions}
+            onChange={({ value }) => onChangeLogs({ ...query, containerInsightsQuery: value })}
+          />
+        </EditorField>
+        <EditorField label="Cluster" width={30} tooltip="The cluster whose performance log group is queried.">
+          <Input
+            id={`${query.refId}-cloudwatch-logs-query-editor-cluster-name`}
+            value={query.clusterName ?? ''}
+            onChange={(event) => onChangeLogs({ ...query, clusterName: event.currentTarget.value })}
+          />
+        </EditorField>
+        <EditorField label="Namespace" optional width={30} tooltip="Restrict pod aggregations to a Kubernetes namespace.">
+          <Input
+            id={`${query.refId}-cloudwatch-logs-query-editor-kubernetes-namespace`}
+            value={query.kubernetesNamespace ?? ''}
+            onChange={(event) => onChangeLogs({ ...query, kubernetesNamespace: event.currentTarget.value })}
+          />
+        </EditorField>
+      </EditorRow>
+    );
+  }
+
   return (
     <>
       <LogGroupsFieldWrapper
@@ -45,10 +100,94 @@ export const CloudWatchLogsQueryField = (props: CloudWatchLogsQueryFieldProps) =
           onChangeLogs({ ...query, logGroupNames });
         }}
       />
-      <div>
-        {getCodeEditor(query, datasource, onChange)}
-        <div className={styles.editor}>{ExtraFieldElement}</div>
-      </div>
+      {query.logsMode === LogsMode.Events ? (
+        <EditorRow>
+          <EditorField label="Log stream" width={52} tooltip="The log stream of the selected log group to read events from.">
+            <Input
+              id={`${query.refId}-cloudwatch-logs-query-editor-log-stream`}
+              value={query.logStreamName ?? ''}
+              onChange={(event) => onChangeLogs({ ...query, logStreamName: event.currentTarget.value })}
+            />
+          </EditorField>
+          <EditorField label="Start from head" tooltip="Read the earliest events of the time range instead of the latest.">
+            <EditorSwitch
+              id={`${query.refId}-cloudwatch-logs-query-editor-start-from-head`}
+              value={!!query.startFromHead}
+              onChange={(event) => onChangeLogs({ ...query, startFromHead: event.currentTarget.checked })}
+            />
+          </EditorField>
+        </EditorRow>
+      ) : query.logsMode === LogsMode.VPCFlowLogs ? (
+        <EditorRow>
+          <EditorField label="Query" width={30}>
+            <Select
+              inputId={`${query.refId}-cloudwatch-logs-query-editor-vpc-flow-logs-query`}
+              value={query.vpcFlowLogsQuery ?? VPCFlowLogsQuery.Records}
+              options={vpcFlowLogsQueryOptions}
+              onChange={({ value }) => onChangeLogs({ ...query, vpcFlowLogsQuery: value })}
+            />
+          </EditorField>
+          <EditorField
+            label="Log format"
+            optional
+            width={80}
+            tooltip="The ${field} list the flow log was created with. Leave empty for the default format."
+          >
+            <Input
+              id={`${query.refId}-cloudwatch-logs-query-editor-flow-log-format`}
+              value={query.flowLogFormat ?? ''}
+              placeholder="${version} ${account-id} ${interface-id} ${srcaddr} ${dstaddr} ..."
+              onChange={(event) => onChangeLogs({ ...query, flowLogFormat: event.currentTarget.value })}
+            />
+          </EditorField>
+        </EditorRow>
+      ) : (
+        <div>
+          {query.logsMode === LogsMode.LiveTail && (
+            <EditorRow>
+              <EditorField
+                label="Log stream"
+                width={52}
+                optional
+                tooltip="Only tail the events of this log stream. Requires a single log group."
+              >
+                <Input
+                  id={`${query.refId}-cloudwatch-logs-query-editor-live-tail-log-stream`}
+                  value={query.logStreamName ?? ''}
+                  onChange={(event) => onChangeLogs({ ...query, logStreamName: event.currentTarget.value })}
+                />
+              </EditorField>
+            </EditorRow>
+          )}
+          {getCodeEditor(query, datasource, onChange)}
+          {query.logsMode !== LogsMode.LiveTail && (
+            <EditorRow>
+              <EditorField
+                label="Limit"
+                optional
+                width={20}
+                tooltip="Maximum number of log events to return, capped by the maximum of the data source settings."
+              >
+                <Input
+                  id={`${query.refId}-cloudwatch-logs-query-editor-limit`}
+                  type="number"
+                  min={1}
+                  max={10000}
+                  placeholder="10000"
+                  value={query.limit ?? ''}
+                  onChange={(event) =>
+                    onChangeLogs({
+                      ...query,
+                      limit: event.currentTarget.value === '' ? undefined : parseInt(event.currentTarget.value, 10),
+                    })
+                  }
+                />
+              </EditorField>
+            </EditorRow>
+          )}
+          <div className={styles.editor}>{ExtraFieldElement}</div>
+        </div>
+      )}
     </>
   );
 };
@@ -0,0 +1,68 @@
+import { useState } from 'react';
+
+import { AppEvents } from '@grafana/data';
+import { getAppEvents } from '@grafana/runtime';
+import { Button, Field, Input, Modal } from '@grafana/ui';
+
+import { CloudWatchDatasource } from '../../../datasource';
+import { CloudWatchLogsQuery } from '../../../types';
+
+interface Props {
+  query: CloudWatchLogsQuery;
+  datasource: CloudWatchDatasource;
+}
+
+// Saves the query as a query definition of the account, so that it's listed among the saved queries of the
+// CloudWatch console too
+export function SaveQueryDefinition({ query, datasource }: Props) {
+  const [isModalOpen, setIsModalOpen] = useState(false);
+  const [name, setName] = useState('');
+  const [isSaving, setIsSaving] = useState(false);
+
+  const onSave = async () => {
+    setIsSaving(true);
+    try {
+      // eslint-disable-next-line deprecation/deprecation
+      const logGroupNames = query.logGroups?.length ? query.logGroups.map((group) => group.name) : query.logGroupNames;
+      await datasource.resources.putQueryDefinition({
+        region: query.region,
+        queryDefinitionId: query.queryDefinitionId,
+        name,
+        queryString: query.expression ?? '',
+        queryLanguage: query.queryLanguage,
+        logGroupNames: logGroupNames ?? [],
+      });
+      getAppEvents().publish({ type: AppEvents.alertSuccess.name, payload: [`Saved query ${name} to CloudWatch`] });
+      setIsModalOpen(false);
+    } catch (err) {
+      // the error of the request is already shown by the backend service
+    } finally {
+      setIsSaving(false);
+    }
+  };
+
+  return (
+    <>
+      <Button variant="secondary" icon="save" type="button" onClick={() => setIsModalOpen(true)}>
+        Save to CloudWatch
+      </Button>
+      <Modal title="Save query to CloudWatch" isOpen={isModalOpen} onDismiss={() => setIsModalOpen(false)}>
+        <Field label="Name" description="Use slashes to save the query in folders, e.g. lambda/errors">
+          <Input
+            id={`${query.refId}-cloudwatch-save-query-definition-name`}
+            value={name}
+            onChange={(e) => setName(e.currentTarget.value)}
+          />
+        </Field>
+        <Modal.ButtonRow>
+          <Button onClick={() => setIsModalOpen(false)} variant="secondary" type="button" fill="outline">
+            Cancel
+          </Button>
+          <Button onClick={onSave} type="button" disabled={!name || !query.expression || isSaving}>
+            Save
+          </Button>
+        </Modal.ButtonRow>
+      </Modal>
+    </>
+  );
+}
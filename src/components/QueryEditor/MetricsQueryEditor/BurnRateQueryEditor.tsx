@@ -0,0 +1,89 @@
+import { SelectableValue } from '@grafana/data';
+import { EditorField, EditorRow, EditorRows } from '@grafana/plugin-ui';
+import { Input, MultiSelect } from '@grafana/ui';
+
+import { CloudWatchMetricsQuery } from '../../../types';
+
+export interface Props {
+  query: CloudWatchMetricsQuery;
+  onChange: (query: CloudWatchMetricsQuery) => void;
+}
+
+const windowOptions: Array<SelectableValue<string>> = ['5m', '30m', '1h', '6h', '24h', '72h'].map((window) => ({
+  label: window,
+  value: window,
+}));
+
+// Computes the burn rates of the error budget of an SLO over several windows from the good and total events of the
+// SLO, so that SLO dashboards of CloudWatch metrics don't need an expression per window.
+export const BurnRateQueryEditor = ({ query, onChange }: Props) => {
+  return (
+    <EditorRows>
+      <EditorRow>
+        <EditorField
+          label="Good events"
+          width={60}
+          tooltip="Metric math or search expression of the events meeting the SLO, e.g. the requests that succeeded. All the series it returns are summed."
+        >
+          <Input
+            aria-label="Good events expression"
+            placeholder={`SEARCH('{AWS/ApplicationELB,LoadBalancer} MetricName="RequestCount"', 'Sum')`}
+            value={query.burnRateGoodExpression ?? ''}
+            onChange={(event) => onChange({ ...query, burnRateGoodExpression: event.currentTarget.value })}
+          />
+        </EditorField>
+        <EditorField
+          label="Total events"
+          width={60}
+          tooltip="Metric math or search expression of all the events of the SLO. All the series it returns are summed."
+        >
+          <Input
+            aria-label="Total events expression"
+            value={query.burnRateTotalExpression ?? ''}
+            onChange={(event) => onChange({ ...query, burnRateTotalExpression: event.currentTarget.value })}
+          />
+        </EditorField>
+      </EditorRow>
+      <EditorRow>
+        <EditorField label="Objective" tooltip="The objective of the SLO in percent, e.g. 99.9.">
+          <Input
+            aria-label="SLO objective"
+            type="number"
+            min={0}
+            max={100}
+            step="any"
+            placeholder="99.9"
+            value={query.sloObjective ?? ''}
+            onChange={(event) => {
+              const objective = parseFloat(event.currentTarget.value);
+              onChange({ ...query, sloObjective: isNaN(objective) ? undefined : objective });
+            }}
+          />
+        </EditorField>
+        <EditorField label="Windows" optional tooltip="The look-back windows to compute the burn rate over.">
+          <MultiSelect
+            aria-label="Burn rate windows"
+            allowCustomValue
+            placeholder="5m, 1h, 6h"
+            options={windowOptions}
+            value={query.burnRateWindows ?? []}
+            onChange={(options) =>
+              onChange({
+                ...query,
+                burnRateWindows: options.length ? options.map((option) => option.value!) : undefined,
+              })
+            }
+          />
+        </EditorField>
+        <EditorField label="Period" width={26} tooltip="Period in seconds the events are summed by. Defaults to 60.">
+          <Input
+            aria-label="Period"
+            value={query.period || ''}
+            placeholder="60"
+            onChange={(event) => onChange({ ...query, period: event.currentTarget.value })}
+          />
+        </EditorField>
+      </EditorRow>
+    </EditorRows>
+  );
+};
@@ -0,0 +1,36 @@
+import { CodeEditor } from '@grafana/ui';
+
+export interface Props {
+  onChange: (metricDataQueries: string) => void;
+  metricDataQueries: string;
+}
+
+const placeholder = `[
+  {
+    "Id": "m1",
+    "MetricStat": {
+      "Metric": { "Namespace": "AWS/EC2", "MetricName": "CPUUtilization" },
+      "Period": 300,
+      "Stat": "Average"
+    }
+  }
+]`;
+
+// Edits the MetricDataQueries array of an advanced JSON query, as given to the GetMetricData API
+export function MetricDataQueriesField({ metricDataQueries, onChange }: Props) {
+  return (
+    <CodeEditor
+      language="json"
+      height={240}
+      showLineNumbers={true}
+      showMiniMap={false}
+      monacoOptions={{ scrollBeyondLastLine: false, wordWrap: 'on' }}
+      value={metricDataQueries || placeholder}
+      onBlur={(value) => {
+        if (value !== metricDataQueries) {
+          onChange(value);
+        }
+      }}
+    />
+  );
+}
@@ -2,24 +2,34 @@ import { ChangeEvent, useCallback, useEffect, useState } from 'react';
 import * as React from 'react';
 
 import { QueryEditorProps, SelectableValue } from '@grafana/data';
-import { EditorField, EditorRow, InlineSelect } from '@grafana/plugin-ui';
-import { ConfirmModal, Input, RadioButtonGroup, Space } from '@grafana/ui';
+import { EditorField, EditorRow, EditorSwitch, InlineSelect } from '@grafana/plugin-ui';
+import { ConfirmModal, Input, MultiSelect, RadioButtonGroup, Select, Space } from '@grafana/ui';
 
 import { CloudWatchDatasource } from '../../../datasource';
 import { DEFAULT_METRICS_QUERY } from '../../../defaultQueries';
+import { useRegions } from '../../../hooks';
 import useMigratedMetricsQuery from '../../../migrations/useMigratedMetricsQuery';
+import { standardStatistics } from '../../../standardStatistics';
 import {
+  AggregateFunction,
   CloudWatchJsonData,
   CloudWatchMetricsQuery,
   CloudWatchQuery,
   MetricEditorMode,
   MetricQueryType,
   MetricStat,
+  MultiFilters,
+  SeriesSortBy,
+  SeriesSortOrder,
 } from '../../../types';
 import { MetricStatEditor } from '../../shared/MetricStatEditor';
+import { MultiFilter } from '../../VariableQueryEditor/MultiFilter';
 
+import { BurnRateQueryEditor } from './BurnRateQueryEditor';
 import { DynamicLabelsField } from './DynamicLabelsField';
 import { MathExpressionQueryField } from './MathExpressionQueryField';
+import { MetricDataQueriesField } from './MetricDataQueriesField';
+import { SLOQueryEditor } from './SLOQueryEditor';
 import { SQLBuilderEditor } from './SQLBuilderEditor';
 import { SQLCodeEditor } from './SQLCodeEditor';
 
@@ -32,17 +42,34 @@ export interface Props extends QueryEditorProps<CloudWatchDatasource, CloudWatch
 const metricEditorModes: Array<SelectableValue<MetricQueryType>> = [
   { label: 'Metric Search', value: MetricQueryType.Search },
   { label: 'Metric Insights', value: MetricQueryType.Insights },
+  { label: 'Advanced JSON', value: MetricQueryType.JSON },
+  { label: 'Query by tag', value: MetricQueryType.Tags },
+  { label: 'Application Signals SLO', value: MetricQueryType.SLO },
+  { label: 'SLO burn rate', value: MetricQueryType.BurnRate },
 ];
 const editorModes = [
   { label: 'Builder', value: MetricEditorMode.Builder },
   { label: 'Code', value: MetricEditorMode.Code },
 ];
+const seriesSortOptions: Array<SelectableValue<SeriesSortBy>> = [
+  { label: 'Last value', value: SeriesSortBy.Last },
+  { label: 'Average', value: SeriesSortBy.Avg },
+  { label: 'Max value', value: SeriesSortBy.Max },
+];
+const percentileOptions: Array<SelectableValue<string>> = ['p50', 'p75', 'p90', 'p95', 'p99', 'p99.9'].map(
+  (percentile) => ({ label: percentile, value: percentile })
+);
+const seriesSortOrders = [
+  { label: 'Desc', value: SeriesSortOrder.Desc },
+  { label: 'Asc', value: SeriesSortOrder.Asc },
+];
 
 export const MetricsQueryEditor = (props: Props) => {
   const { query, datasource, extraHeaderElementLeft, extraHeaderElementRight, onChange } = props;
   const [showConfirm, setShowConfirm] = useState(false);
   const [codeEditorIsDirty, setCodeEditorIsDirty] = useState(false);
   const migratedQuery = useMigratedMetricsQuery(query, props.onChange);
+  const [regions, regionIsLoading] = useRegions(datasource);
 
   const onEditorModeChange = useCallback(
     (newMetricEditorMode: MetricEditorMode) => {
@@ -81,12 +108,17 @@ export const MetricsQueryEditor = (props: Props) => {
 
     extraHeaderElementRight?.(
       <>
-        <RadioButtonGroup
-          options={editorModes}
-          size="sm"
-          value={query.metricEditorMode}
-          onChange={onEditorModeChange}
-        />
+        {query.metricQueryType !== MetricQueryType.JSON &&
+          query.metricQueryType !== MetricQueryType.Tags &&
+          query.metricQueryType !== MetricQueryType.SLO &&
+          query.metricQueryType !== MetricQueryType.BurnRate && (
+            <RadioButtonGroup
+              options={editorModes}
+              size="sm"
+              value={query.metricEditorMode}
+              onChange={onEditorModeChange}
+            />
+          )}
         <ConfirmModal
           isOpen={showConfirm}
           title="Are you sure?"
@@ -124,6 +156,29 @@ export const MetricsQueryEditor = (props: Props) => {
     onEditorModeChange,
   ]);
 
+  // advanced JSON queries are sent to GetMetricData as they are, so none of the other options apply to them
+  if (query.metricQueryType === MetricQueryType.JSON) {
+    return (
+      <>
+        <Space v={0.5} />
+        <MetricDataQueriesField
+          metricDataQueries={query.metricDataQueries ?? ''}
+          onChange={(metricDataQueries) => props.onChange({ ...query, metricDataQueries })}
+        />
+      </>
+    );
+  }
+
+  // burn rate queries are computed from their own expressions, so none of the other options apply to them either
+  if (query.metricQueryType === MetricQueryType.BurnRate) {
+    return (
+      <>
+        <Space v={0.5} />
+        <BurnRateQueryEditor query={query} onChange={props.onChange} />
+      </>
+    );
+  }
+
   return (
     <>
       <Space v={0.5} />
@@ -152,6 +207,32 @@ export const MetricsQueryEditor = (props: Props) => {
           )}
         </>
       )}
+      {query.metricQueryType === MetricQueryType.Tags && (
+        <>
+          <MetricStatEditor
+            {...props}
+            refId={query.refId}
+            metricStat={query}
+            onChange={(metricStat: MetricStat) => props.onChange({ ...query, ...metricStat })}
+          ></MetricStatEditor>
+          <EditorRow>
+            <EditorField
+              label="Tags"
+              tooltip="The resources with these tags are looked up on every run and queried by the dimension identifying them in the namespace."
+            >
+              <MultiFilter
+                filters={query.tagFilters as MultiFilters | undefined}
+                onChange={(tagFilters) => props.onChange({ ...query, tagFilters })}
+                keyPlaceholder="tag name"
+                datasource={datasource}
+              />
+            </EditorField>
+          </EditorRow>
+        </>
+      )}
+      {query.metricQueryType === MetricQueryType.SLO && (
+        <SLOQueryEditor query={query} datasource={datasource} onChange={props.onChange} />
+      )}
       {query.metricQueryType === MetricQueryType.Insights && (
         <>
           {query.metricEditorMode === MetricEditorMode.Code && (
@@ -215,7 +296,259 @@ export const MetricsQueryEditor = (props: Props) => {
             onChange={(label) => props.onChange({ ...query, label })}
           ></DynamicLabelsField>
         </EditorField>
+
+        <EditorField
+          label="Live"
+          optional
+          tooltip="Stream new datapoints to the panel as they arrive instead of re-running the query on every refresh. Math expressions can't be streamed."
+        >
+          <EditorSwitch
+            id={`${query.refId}-cloudwatch-metric-query-editor-live`}
+            value={!!query.live}
+            onChange={(e) => onChange({ ...migratedQuery, live: e.currentTarget.checked })}
+          />
+        </EditorField>
+
+        <EditorField
+          label="Instant"
+          optional
+          tooltip="Return only the latest datapoint of each series as a table, e.g. for stat panels and tables, instead of the time series of the time range."
+        >
+          <EditorSwitch
+            id={`${query.refId}-cloudwatch-metric-query-editor-instant`}
+            value={!!query.instant}
+            onChange={(e) => onChange({ ...migratedQuery, instant: e.currentTarget.checked })}
+          />
+        </EditorField>
+
+        <EditorField
+          label="Alarm thresholds"
+          optional
+          tooltip="Show the thresholds of the CloudWatch alarms watching each series as lines. Only alarms using the statistic of the query are shown."
+        >
+          <EditorSwitch
+            id={`${query.refId}-cloudwatch-metric-query-editor-alarm-thresholds`}
+            value={!!query.alarmThresholds}
+            onChange={(e) => onChange({ ...migratedQuery, alarmThresholds: e.currentTarget.checked })}
+          />
+        </EditorField>
+
+        {query.namespace === 'AWS/EC2' && (
+          <EditorField
+            label="Instance names"
+            optional
+            tooltip="Label the series of each instance with its Name tag, and show the name instead of the instance ID in the legend."
+          >
+            <EditorSwitch
+              id={`${query.refId}-cloudwatch-metric-query-editor-instance-names`}
+              value={!!query.instanceNames}
+              onChange={(e) => onChange({ ...migratedQuery, instanceNames: e.currentTarget.checked })}
+            />
+          </EditorField>
+        )}
+
+        <EditorField
+          label="Fallback statistic"
+          width={20}
+          optional
+          tooltip="Statistic shown instead when the series have no datapoints of the statistic of the query, e.g. SampleCount when a metric stops publishing Average. Only used by queries in the builder."
+        >
+          <Select
+            inputId={`${query.refId}-cloudwatch-metric-query-editor-fallback-statistic`}
+            isClearable
+            allowCustomValue
+            value={query.fallbackStatistic ? { label: query.fallbackStatistic, value: query.fallbackStatistic } : null}
+            options={standardStatistics.map((statistic) => ({ label: statistic, value: statistic }))}
+            onChange={(option) => onChange({ ...migratedQuery, fallbackStatistic: option?.value })}
+          />
+        </EditorField>
+
+        <EditorField
+          label="Sample count"
+          optional
+          tooltip="Also return the SampleCount of the metric as a second field of each series, e.g. to hide averages computed from too few samples. Only used by queries in the builder."
+        >
+          <EditorSwitch
+            id={`${query.refId}-cloudwatch-metric-query-editor-include-sample-count`}
+            value={!!query.includeSampleCount}
+            onChange={(e) => onChange({ ...migratedQuery, includeSampleCount: e.currentTarget.checked })}
+          />
+        </EditorField>
+
+        <EditorField
+          label="Aggregate across"
+          width={20}
+          optional
+          tooltip="Dimension the series are aggregated across into a single series, e.g. InstanceId to sum the metric of all instances of an Auto Scaling group. Only used by queries in the builder."
+        >
+          <Select
+            inputId={`${query.refId}-cloudwatch-metric-query-editor-aggregate-dimension`}
+            isClearable
+            allowCustomValue
+            value={query.aggregateDimension ? { label: query.aggregateDimension, value: query.aggregateDimension } : null}
+            options={Object.keys(query.dimensions ?? {}).map((key) => ({ label: key, value: key }))}
+            onChange={(option) => onChange({ ...migratedQuery, aggregateDimension: option?.value })}
+          />
+        </EditorField>
+
+        {query.aggregateDimension && (
+          <EditorField label="Aggregate function" width={16}>
+            <Select
+              inputId={`${query.refId}-cloudwatch-metric-query-editor-aggregate-function`}
+              value={query.aggregateFunction ?? AggregateFunction.SUM}
+              options={Object.values(AggregateFunction).map((fn) => ({ label: fn, value: fn }))}
+              onChange={(option) => onChange({ ...migratedQuery, aggregateFunction: option.value })}
+            />
+          </EditorField>
+        )}
       </EditorRow>
+
+      <EditorRow>
+        <EditorField
+          label="Filter series"
+          width={26}
+          optional
+          tooltip="Regular expression the series must match, e.g. to exclude canary instances. Matched against the series name, or the value of the dimension if one is set."
+        >
+          <Input
+            id={`${query.refId}-cloudwatch-metric-query-editor-series-filter`}
+            placeholder="canary"
+            value={query.seriesFilter ?? ''}
+            onChange={(event: ChangeEvent<HTMLInputElement>) =>
+              onChange({ ...migratedQuery, seriesFilter: event.target.value || undefined })
+            }
+          />
+        </EditorField>
+
+        <EditorField label="Dimension" width={20} optional tooltip="The dimension whose value the filter is matched against.">
+          <Input
+            id={`${query.refId}-cloudwatch-metric-query-editor-series-filter-dimension`}
+            placeholder="series name"
+            value={query.seriesFilterDimension ?? ''}
+            onChange={(event: ChangeEvent<HTMLInputElement>) =>
+              onChange({ ...migratedQuery, seriesFilterDimension: event.target.value || undefined })
+            }
+          />
+        </EditorField>
+
+        <EditorField label="Exclude" optional tooltip="Drop the series matching the filter instead of keeping them.">
+          <EditorSwitch
+            id={`${query.refId}-cloudwatch-metric-query-editor-series-filter-exclude`}
+            value={!!query.seriesFilterExclude}
+            onChange={(e) => onChange({ ...migratedQuery, seriesFilterExclude: e.currentTarget.checked })}
+          />
+        </EditorField>
+      </EditorRow>
+
+      <EditorRow>
+        <EditorField
+          label="Sort series by"
+          width={26}
+          optional
+          tooltip="Order the series by a value of their datapoints, e.g. to only show the top series with a limit."
+        >
+          <Select
+            inputId={`${query.refId}-cloudwatch-metric-query-editor-series-sort`}
+            isClearable
+            value={query.seriesSortBy ?? null}
+            options={seriesSortOptions}
+            onChange={(option) => onChange({ ...migratedQuery, seriesSortBy: option?.value })}
+          />
+        </EditorField>
+
+        <EditorField label="Order" optional>
+          <RadioButtonGroup
+            options={seriesSortOrders}
+            size="sm"
+            value={query.seriesSortOrder ?? SeriesSortOrder.Desc}
+            disabled={!query.seriesSortBy}
+            onChange={(seriesSortOrder) => onChange({ ...migratedQuery, seriesSortOrder })}
+          />
+        </EditorField>
+
+        <EditorField label="Limit" width={16} optional tooltip="Maximum number of series returned by the query.">
+          <Input
+            id={`${query.refId}-cloudwatch-metric-query-editor-series-limit`}
+            type="number"
+            min={0}
+            placeholder="all"
+            value={query.seriesLimit || ''}
+            onChange={(event: ChangeEvent<HTMLInputElement>) =>
+              onChange({ ...migratedQuery, seriesLimit: parseInt(event.target.value, 10) || undefined })
+            }
+          />
+        </EditorField>
+      </EditorRow>
+
+      {query.metricQueryType === MetricQueryType.Search && query.metricEditorMode === MetricEditorMode.Builder && (
+        <EditorRow>
+          <EditorField
+            label="Percentiles"
+            width={40}
+            optional
+            tooltip="Query these percentiles in place of the statistic in one request, and return them in ascending order as a field per percentile of each series, e.g. for heatmap and percentiles panels. Series filters, limits and instant mode don't apply to them."
+          >
+            <MultiSelect
+              inputId={`${query.refId}-cloudwatch-metric-query-editor-percentiles`}
+              allowCustomValue
+              options={percentileOptions}
+              value={query.percentiles ?? []}
+              onChange={(options) =>
+                onChange({
+                  ...migratedQuery,
+                  percentiles: options.length ? options.map((option) => option.value!) : undefined,
+                })
+              }
+            />
+          </EditorField>
+        </EditorRow>
+      )}
+
+      {query.metricQueryType === MetricQueryType.Search && query.metricEditorMode === MetricEditorMode.Builder && (
+        <EditorRow>
+          <EditorField
+            label="Region matrix"
+            width={40}
+            optional
+            tooltip="Query the metric in each of these regions and accounts, and return a table of its latest value in each, e.g. for global health panels. The metric must be a single series."
+          >
+            <MultiSelect
+              inputId={`${query.refId}-cloudwatch-metric-query-editor-matrix-regions`}
+              allowCustomValue
+              isLoading={regionIsLoading}
+              options={regions}
+              value={query.matrixRegions ?? []}
+              onChange={(options) =>
+                onChange({
+                  ...migratedQuery,
+                  matrixRegions: options.length ? options.map((option) => option.value!) : undefined,
+                })
+              }
+            />
+          </EditorField>
+
+          <EditorField
+            label="Accounts"
+            width={40}
+            optional
+            tooltip="Comma separated ids of the accounts queried in each region of the matrix. Only the account of the data source is queried if empty."
+          >
+            <Input
+              id={`${query.refId}-cloudwatch-metric-query-editor-matrix-accounts`}
+              placeholder="111111111111, 222222222222"
+              disabled={!query.matrixRegions?.length}
+              defaultValue={(query.matrixAccountIds ?? []).join(', ')}
+              onBlur={(event: React.FocusEvent<HTMLInputElement>) => {
+                const matrixAccountIds = event.target.value
+                  .split(',')
+                  .map((accountId) => accountId.trim())
+                  .filter(Boolean);
+                onChange({ ...migratedQuery, matrixAccountIds: matrixAccountIds.length ? matrixAccountIds : undefined });
+              }}
+            />
+          </EditorField>
+        </EditorRow>
+      )}
     </>
   );
 };
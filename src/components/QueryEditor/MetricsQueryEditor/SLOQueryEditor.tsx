@@ -0,0 +1,114 @@
+import { useEffect, useState } from 'react';
+
+import { SelectableValue } from '@grafana/data';
+import { EditorField, EditorRow } from '@grafana/plugin-ui';
+import { Input, Select } from '@grafana/ui';
+
+import { CloudWatchDatasource } from '../../../datasource';
+import { standardStatistics } from '../../../standardStatistics';
+import { CloudWatchMetricsQuery, SLOMetric } from '../../../types';
+import { appendTemplateVariables } from '../../../utils/utils';
+
+export interface Props {
+  query: CloudWatchMetricsQuery;
+  datasource: CloudWatchDatasource;
+  onChange: (query: CloudWatchMetricsQuery) => void;
+}
+
+const sloMetricOptions: Array<SelectableValue<SLOMetric>> = [
+  { label: 'Attainment rate', value: SLOMetric.AttainmentRate },
+  { label: 'Error budget remaining', value: SLOMetric.ErrorBudgetRemaining },
+  { label: 'Burn rate', value: SLOMetric.BurnRate },
+];
+
+// Queries the metrics Application Signals publishes for an SLO of the region of the query. The service only narrows
+// the SLOs listed down, the query is by SLO name.
+export const SLOQueryEditor = ({ query, datasource, onChange }: Props) => {
+  const [services, setServices] = useState<Array<SelectableValue<string>>>([]);
+  const [service, setService] = useState<SelectableValue<string> | null>(null);
+  const [slos, setSLOs] = useState<Array<SelectableValue<string>>>([]);
+  const region = datasource.templateSrv.replace(query.region, {});
+
+  useEffect(() => {
+    datasource.resources.getApplicationSignalsServices(region).then((services) => {
+      setServices(
+        services.map(({ value }) => ({
+          label: value.name,
+          value: JSON.stringify([value.name, value.environment]),
+          description: value.environment,
+        }))
+      );
+    });
+  }, [datasource, region]);
+
+  useEffect(() => {
+    const [name, environment] = service?.value ? JSON.parse(service.value) : ['', ''];
+    datasource.resources.getServiceLevelObjectives({ region, service: name, environment }).then((slos) => {
+      setSLOs(
+        appendTemplateVariables(
+          datasource,
+          slos.map(({ value }) => ({ label: value.name, value: value.name, description: value.operationName }))
+        )
+      );
+    });
+  }, [datasource, region, service]);
+
+  const sloMetric = query.sloMetric ?? SLOMetric.AttainmentRate;
+
+  return (
+    <EditorRow>
+      <EditorField label="Service" optional width={30} tooltip="Only list the SLOs of this service.">
+        <Select
+          aria-label="Service"
+          value={service}
+          options={services}
+          isClearable
+          onChange={(option) => setService(option ?? null)}
+        />
+      </EditorField>
+      <EditorField label="SLO" width={30}>
+        <Select
+          aria-label="SLO"
+          value={query.sloName || null}
+          options={slos}
+          allowCustomValue
+          onChange={({ value }) => onChange({ ...query, sloName: value ?? '' })}
+        />
+      </EditorField>
+      <EditorField label="Metric">
+        <Select
+          aria-label="SLO metric"
+          value={sloMetric}
+          options={sloMetricOptions}
+          onChange={({ value }) => onChange({ ...query, sloMetric: value })}
+        />
+      </EditorField>
+      {sloMetric === SLOMetric.BurnRate && (
+        <EditorField
+          label="Window"
+          tooltip="The look-back window of the burn rate in minutes, as set in one of the burn rate configurations of the SLO."
+        >
+          <Input
+            aria-label="Burn rate window"
+            type="number"
+            min={1}
+            placeholder="60"
+            value={query.burnRateWindowMinutes ?? ''}
+            onChange={(event) => {
+              const minutes = parseInt(event.currentTarget.value, 10);
+              onChange({ ...query, burnRateWindowMinutes: isNaN(minutes) ? undefined : minutes });
+            }}
+          />
+        </EditorField>
+      )}
+      <EditorField label="Statistic">
+        <Select
+          aria-label="Statistic"
+          value={query.statistic || 'Average'}
+          options={standardStatistics.map((statistic) => ({ label: statistic, value: statistic }))}
+          onChange={({ value }) => onChange({ ...query, statistic: value })}
+        />
+      </EditorField>
+    </EditorRow>
+  );
+};
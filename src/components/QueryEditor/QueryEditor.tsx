@@ -3,9 +3,16 @@ import { useCallback, useEffect, useState } from 'react';
 import { QueryEditorProps } from '@grafana/data';
 
 import { CloudWatchDatasource } from '../../datasource';
-import { isCloudWatchLogsQuery, isCloudWatchMetricsQuery } from '../../guards';
+import {
+  isCloudWatchAlarmQuery,
+  isCloudWatchContributorInsightsQuery,
+  isCloudWatchLogsQuery,
+  isCloudWatchMetricsQuery,
+} from '../../guards';
 import { CloudWatchJsonData, CloudWatchQuery } from '../../types';
 
+import { AlarmsQueryEditor } from './AlarmsQueryEditor/AlarmsQueryEditor';
+import { ContributorInsightsQueryEditor } from './ContributorInsightsQueryEditor/ContributorInsightsQueryEditor';
 import LogsQueryEditor from './LogsQueryEditor/LogsQueryEditor';
 import { MetricsQueryEditor } from './MetricsQueryEditor/MetricsQueryEditor';
 import QueryHeader from './QueryHeader';
@@ -57,6 +64,10 @@ export const QueryEditor = (props: Props) => {
           extraHeaderElementLeft={setExtraHeaderElementLeft}
         />
       )}
+      {isCloudWatchAlarmQuery(query) && <AlarmsQueryEditor {...props} query={query} onChange={onChangeInternal} />}
+      {isCloudWatchContributorInsightsQuery(query) && (
+        <ContributorInsightsQueryEditor {...props} query={query} onChange={onChangeInternal} />
+      )}
     </>
   );
 };
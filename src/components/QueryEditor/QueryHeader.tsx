@@ -17,6 +17,8 @@ export interface Props extends QueryEditorProps<CloudWatchDatasource, CloudWatch
 const apiModes: Array<SelectableValue<CloudWatchQueryMode>> = [
   { label: 'CloudWatch Metrics', value: 'Metrics' },
   { label: 'CloudWatch Logs', value: 'Logs' },
+  { label: 'CloudWatch Alarms', value: 'Alarms' },
+  { label: 'CloudWatch Contributor Insights', value: 'ContributorInsights' },
 ];
 
 const QueryHeader = ({
@@ -33,6 +35,15 @@ const QueryHeader = ({
   const isMonitoringAccount = useIsMonitoringAccount(datasource.resources, query.region);
   const [regions, regionIsLoading] = useRegions(datasource);
   const emptyLogsExpression = isCloudWatchLogsQuery(query) ? !query.expression : false;
+  // metrics queries may fan out to every region, returning the series of each labelled with their region
+  // queries may assume one of the roles the data source allows instead of its own
+  const roleOptions: Array<SelectableValue<string>> = [
+    { label: 'Data source role', value: '' },
+    ...datasource.queryRoleArns.map((roleArn) => ({ label: roleArn, value: roleArn })),
+  ];
+  const regionOptions = isCloudWatchMetricsQuery(query)
+    ? [{ label: 'All regions', value: 'all' }, ...regions]
+    : regions;
 
   const onQueryModeChange = ({ value }: SelectableValue<CloudWatchQueryMode>) => {
     if (value && value !== queryMode) {
@@ -45,7 +56,7 @@ const QueryHeader = ({
     }
   };
   const onRegionChange = async (region: string) => {
-    if (config.featureToggles.cloudWatchCrossAccountQuerying && isCloudWatchMetricsQuery(query)) {
+    if (config.featureToggles.cloudWatchCrossAccountQuerying && isCloudWatchMetricsQuery(query) && region !== 'all') {
       const isMonitoringAccount = await datasource.resources.isMonitoringAccount(region);
       onChange({ ...query, region, accountId: isMonitoringAccount ? query.accountId : undefined });
     } else {
@@ -64,10 +75,19 @@ const QueryHeader = ({
           placeholder="Select region"
           allowCustomValue
           onChange={({ value: region }) => region && onRegionChange(region)}
-          options={regions}
+          options={regionOptions}
           isLoading={regionIsLoading}
         />
 
+        {datasource.queryRoleArns.length > 0 && (isCloudWatchMetricsQuery(query) || isCloudWatchLogsQuery(query)) && (
+          <InlineSelect
+            label="Role"
+            value={query.assumeRoleArn ?? ''}
+            options={roleOptions}
+            onChange={({ value }) => onChange({ ...query, assumeRoleArn: value || undefined })}
+          />
+        )}
+
         <InlineSelect
           aria-label="Query mode"
           value={queryMode}
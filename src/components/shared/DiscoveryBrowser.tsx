@@ -0,0 +1,70 @@
+import { useState } from 'react';
+
+import { EditorField } from '@grafana/plugin-ui';
+import { Button, Cascader, CascaderOption } from '@grafana/ui';
+
+import { CloudWatchDatasource } from '../../datasource';
+import { DiscoveredAccountResponse } from '../../resources/types';
+
+export interface Props {
+  datasource: CloudWatchDatasource;
+  // called with the account, region and namespace browsed to. The account is undefined for the data source account.
+  onSelect: (accountId: string | undefined, region: string, namespace: string) => void;
+}
+
+const toCascaderOptions = (accounts: DiscoveredAccountResponse[]): CascaderOption[] =>
+  accounts
+    .filter((account) => account.regions.length)
+    .map((account) => ({
+      label: account.label,
+      value: account.id,
+      items: account.regions.map((region) => ({
+        label: region.name,
+        value: `${account.id}/${region.name}`,
+        items: region.namespaces.map((namespace) => ({
+          label: namespace,
+          value: JSON.stringify([account.id, region.name, namespace]),
+        })),
+      })),
+    }));
+
+// Lets users who don't know what exists browse from the accounts of the data source to the regions they recently
+// published metrics in and the namespaces of these metrics. The discovery probes every region, so it only runs once
+// asked to.
+export const DiscoveryBrowser = ({ datasource, onSelect }: Props) => {
+  const [options, setOptions] = useState<CascaderOption[]>();
+  const [loading, setLoading] = useState(false);
+
+  const browse = () => {
+    setLoading(true);
+    datasource.resources
+      .getDiscovery()
+      .then((accounts) => setOptions(toCascaderOptions(accounts)))
+      .finally(() => setLoading(false));
+  };
+
+  return (
+    <EditorField
+      label="Browse"
+      optional
+      tooltip="Pick a namespace among those with metrics published in the last 3 hours, by account and region."
+    >
+      {options ? (
+        <Cascader
+          options={options}
+          placeholder={options.length ? 'Account / region / namespace' : 'No recent metrics found'}
+          width={40}
+          displayAllSelectedLevels
+          onSelect={(value: string) => {
+            const [accountId, region, namespace] = JSON.parse(value);
+            onSelect(accountId || undefined, region, namespace);
+          }}
+        />
+      ) : (
+        <Button variant="secondary" size="sm" icon={loading ? 'spinner' : 'search'} disabled={loading} onClick={browse}>
+          Browse metrics
+        </Button>
+      )}
+    </EditorField>
+  );
+};
@@ -13,6 +13,7 @@ import { MetricStat } from '../../../types';
 import { appendTemplateVariables, toOption } from '../../../utils/utils';
 import { Account } from '../Account';
 import { Dimensions } from '../Dimensions/Dimensions';
+import { DiscoveryBrowser } from '../DiscoveryBrowser';
 
 export type Props = {
   refId: string;
@@ -70,6 +71,16 @@ export const MetricStatEditor = ({
 
   return (
     <EditorRows>
+      {!disableExpressions && (
+        <EditorRow>
+          <DiscoveryBrowser
+            datasource={datasource}
+            onSelect={(accountId, region, namespace) =>
+              onChange({ ...metricStat, accountId, region, namespace, metricName: '', dimensions: {} })
+            }
+          />
+        </EditorRow>
+      )}
       <EditorRow>
         {!disableExpressions && config.featureToggles.cloudWatchCrossAccountQuerying && (
           <Account
@@ -26,7 +26,7 @@ composableKinds: DataQuery: {
 			version: [0, 0]
 			schema: {
 				#MetricStat: {
-					// AWS region to query for the metric
+					// AWS region to query for the metric. Metrics queries may query several regions, given as a comma separated list or `all` for every enabled region, their series being labelled with their region.
 					region: string
 					// A namespace is a container for CloudWatch metrics. Metrics in different namespaces are isolated from each other, so that metrics from different applications are not mistakenly aggregated into the same statistics. For example, Amazon EC2 uses the AWS/EC2 namespace.
 					namespace: string
@@ -54,9 +54,9 @@ composableKinds: DataQuery: {
 					common.DataQuery
 					#MetricStat
 
-					// Whether a query is a Metrics, Logs, or Annotations query
+					// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
 					queryMode?: #CloudWatchQueryMode
-					// Whether to use a metric search or metric insights query
+					// Whether to use a metric search, metric insights, advanced JSON, query by tag or Application Signals SLO query
 					metricQueryType?: #MetricQueryType
 					// Whether to use the query builder or code editor to create the query
 					metricEditorMode?: #MetricEditorMode
@@ -65,7 +65,7 @@ composableKinds: DataQuery: {
 					// Deprecated: use label
 					// @deprecated use label
 					alias?: string
-					// Change the time series legend names using dynamic labels. See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/graph-dynamic-labels.html for more details.
+					// Change the time series legend names using dynamic labels. See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/graph-dynamic-labels.html for more details. The labels of math expressions may also use {{id}}, {{label}} and {{index}} to name each series the expression returns.
 					label?: string
 					// Math expression query
 					expression?: string
@@ -73,11 +73,71 @@ composableKinds: DataQuery: {
 					sqlExpression?: string
 					// When the metric query type is set to `Insights` and the `metricEditorMode` is set to `Builder`, this field is used to build up an object representation of a SQL query.
 					sql?: #SQLExpression
+					// Whether to stream new datapoints of the query to the panel over Grafana Live.
+					live?: bool
+					// Whether to return only the latest datapoint of each series, as a table with a row per series, instead of the time series of the time range.
+					instant?: bool
+					// Further namespaces to search for the metric in addition to `namespace`, so that series of several namespaces can be shown in one query. Only used by search queries in the builder.
+					additionalNamespaces?: [...string]
+					// Orders the series of the query by a value of their datapoints, so that only the top or bottom series are returned when combined with `seriesLimit`.
+					seriesSortBy?: #SeriesSortBy
+					// Whether series are ordered by descending or ascending value. Defaults to descending.
+					seriesSortOrder?: #SeriesSortOrder
+					// The maximum number of series returned by the query, after they have been ordered. 0 returns every series.
+					seriesLimit?: int64
+					// Regular expression the returned series must match, e.g. to drop canary instances from the results of a search. Matched against the series name, or against the value of `seriesFilterDimension` if set.
+					seriesFilter?: string
+					// The dimension whose value `seriesFilter` is matched against.
+					seriesFilterDimension?: string
+					// Whether the series matching `seriesFilter` are dropped rather than kept.
+					seriesFilterExclude?: bool
+					// Whether to set the thresholds of the returned series to the thresholds of the CloudWatch alarms of their metric, so that panels show the lines the alarms use.
+					alarmThresholds?: bool
+					// Whether to label the series of EC2 instances with the Name tag of their instance, as the InstanceName label, and show the name in place of the instance ID in their legend.
+					instanceNames?: bool
+					// Statistic requested again when the series of the query have no datapoints of `statistic`, e.g. SampleCount when a metric stops publishing Average, so that health panels show whether the metric is still published. Only used by queries in the builder.
+					fallbackStatistic?: string
+					// Whether to also query the SampleCount of the metric, and return it as a second field of each series, e.g. to hide averages computed from too few samples. Only used by queries in the builder.
+					includeSampleCount?: bool
+					// Dimension the series of the query are aggregated across with `aggregateFunction`, e.g. InstanceId to sum a metric over every instance. The metric is searched for every value of the dimension, filtered by the other dimensions, and the matching series are aggregated into one. Only used by queries in the builder.
+					aggregateDimension?: string
+					// Function aggregating the series across `aggregateDimension`. Defaults to `SUM`.
+					aggregateFunction?: #AggregateFunction
+					// Percentiles queried in place of `statistic` in one request, e.g. p50, p90, p99 and p99.9, and returned in ascending order as one frame per series with a field per percentile, e.g. for heatmap and percentiles panels. Series filters, limits and instant mode don't apply to them. Only used by search queries in the builder.
+					percentiles?: [...string]
+					// Regions a matrix query queries the metric in. A matrix query returns a table of the latest value of the metric in each combination of `matrixRegions` and `matrixAccountIds`, e.g. for global health panels.
+					matrixRegions?: [...string]
+					// Accounts a matrix query queries the metric in, in each of `matrixRegions`. Only the account of the data source is queried if empty.
+					matrixAccountIds?: [...string]
+					// When the metric query type is set to `JSON`, the MetricDataQueries array of the GetMetricData request, as given to the API. Used for API features the editor doesn't support yet.
+					metricDataQueries?: string
+					// When the metric query type is set to `Tags`, the tags of the resources to query the metric of. The resources matching them are looked up with the tagging API on every run, and expanded into the values of the dimension identifying them in the namespace.
+					tagFilters?: #Dimensions
+					// When the metric query type is set to `SLO`, the name of the Application Signals service level objective to query the metrics of.
+					sloName?: string
+					// When the metric query type is set to `SLO`, the metric of the SLO to query. Defaults to `AttainmentRate`.
+					sloMetric?: #SLOMetric
+					// When `sloMetric` is `BurnRate`, the look-back window of the burn rate in minutes. Must be the window of one of the burn rate configurations of the SLO.
+					burnRateWindowMinutes?: int64
+					// When the metric query type is set to `BurnRate`, the metric math or search expression of the good events of the SLO, e.g. the requests that succeeded. The series it returns are summed.
+					burnRateGoodExpression?: string
+					// When the metric query type is set to `BurnRate`, the metric math or search expression of all the events of the SLO. The series it returns are summed.
+					burnRateTotalExpression?: string
+					// When the metric query type is set to `BurnRate`, the objective of the SLO in percent, e.g. 99.9.
+					sloObjective?: float64
+					// When the metric query type is set to `BurnRate`, the look-back windows to compute the burn rate over, as durations such as `5m` or `1h`. Defaults to 5m, 1h and 6h.
+					burnRateWindows?: [...string]
+					// Role to assume instead of the data source's role, so that one data source can query several accounts without cross-account observability. Must be one of the roles the data source settings allow queries to assume.
+					assumeRoleArn?: string
 				} @cuetsy(kind="interface")
 
-				#CloudWatchQueryMode: "Metrics" | "Logs" | "Annotations" @cuetsy(kind="type")
-				#MetricQueryType:     0 | 1                              @cuetsy(kind="enum", memberNames="Search|Insights")
-				#MetricEditorMode:    0 | 1                              @cuetsy(kind="enum", memberNames="Builder|Code")
+				#CloudWatchQueryMode: "Metrics" | "Logs" | "Annotations" | "Alarms" | "ContributorInsights" @cuetsy(kind="type")
+				#MetricQueryType:     0 | 1 | 2 | 3 | 4 | 5                                                 @cuetsy(kind="enum", memberNames="Search|Insights|JSON|Tags|SLO|BurnRate")
+				#MetricEditorMode:    0 | 1                                                                 @cuetsy(kind="enum", memberNames="Builder|Code")
+				#SeriesSortBy:        "Last" | "Avg" | "Max"                                                @cuetsy(kind="enum")
+				#SeriesSortOrder:     "Desc" | "Asc"                                                        @cuetsy(kind="enum")
+				#SLOMetric:           "AttainmentRate" | "ErrorBudgetRemaining" | "BurnRate"                @cuetsy(kind="enum")
+				#AggregateFunction:   "SUM" | "AVG" | "MIN" | "MAX"                                         @cuetsy(kind="enum")
 				#SQLExpression: {
 					// SELECT part of the SQL expression
 					select?: #QueryEditorFunctionExpression
@@ -148,12 +208,16 @@ composableKinds: DataQuery: {
 				#QueryEditorExpression: #QueryEditorArrayExpression | #QueryEditorPropertyExpression | #QueryEditorGroupByExpression | #QueryEditorFunctionExpression | #QueryEditorFunctionParameterExpression | #QueryEditorOperatorExpression @cuetsy(kind="type")
 
 				#LogsQueryLanguage: "CWLI" | "SQL" | "PPL" @cuetsy(kind="enum")
+				#LogsMode:          "Insights" | "Events" | "Filter" | "ContainerInsights" | "VPCFlowLogs" | "LiveTail" @cuetsy(kind="enum")
+				#ContainerInsightsQuery: "PodCPUUtilization" | "PodMemoryUtilization" | "PodRestarts" | "NodeCPUUtilization" | "NodeMemoryUtilization" @cuetsy(kind="enum")
+				#VPCFlowLogsQuery: "Records" | "TopTalkers" | "RejectedConnections" @cuetsy(kind="enum")
+				#LogsSupplementaryType: "LogsVolume" @cuetsy(kind="enum")
 
 				// Shape of a CloudWatch Logs query
 				#CloudWatchLogsQuery: {
 					common.DataQuery
 
-					// Whether a query is a Metrics, Logs, or Annotations query
+					// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
 					queryMode: #CloudWatchQueryMode
 					id:        string
 					// AWS region to query for the logs
@@ -168,6 +232,38 @@ composableKinds: DataQuery: {
 					logGroupNames?: [...string]
 					// Language used for querying logs, can be CWLI, SQL, or PPL. If empty, the default language is CWLI.
 					queryLanguage?: #LogsQueryLanguage
+					// Name of a recorded query configured in the data source settings to serve the last result of
+					recordedQuery?: string
+					// Whether to query the log groups with Logs Insights, to read the events of a single log stream, to match the events of the log groups against a filter pattern, or to tail the new events of the log groups matching a filter pattern with Live Tail. If empty, the default mode is Insights.
+					logsMode?: #LogsMode
+					// Log stream to read the events of when the logs mode is Events, or to only tail when the logs mode is LiveTail
+					logStreamName?: string
+					// Whether to read the earliest events of the time range rather than the latest when the logs mode is Events
+					startFromHead?: bool
+					// Whether to extract the key=value pairs of logfmt formatted log lines into fields
+					parseLogfmt?: bool
+					// Dot separated paths of JSON log lines whose values are extracted into fields, e.g. request.status
+					jsonPaths?: [...string]
+					// Whether to extract the metrics and dimensions declared in the _aws metadata of Embedded Metric Format log lines into fields
+					parseEmf?: bool
+					// Pre-built aggregation of the Container Insights performance logs to run when the logs mode is ContainerInsights
+					containerInsightsQuery?: #ContainerInsightsQuery
+					// Name of the cluster whose Container Insights performance logs are queried
+					clusterName?: string
+					// Kubernetes namespace to restrict the pod aggregations of Container Insights to
+					kubernetesNamespace?: string
+					// Records or canned aggregation of the VPC Flow Logs of the log groups to run when the logs mode is VPCFlowLogs
+					vpcFlowLogsQuery?: #VPCFlowLogsQuery
+					// Format of the flow log records, the list of ${field} placeholders the flow log was created with. If empty, the default version 2 format.
+					flowLogFormat?: string
+					// Role to assume instead of the data source's role, so that one data source can query several accounts without cross-account observability. Must be one of the roles the data source settings allow queries to assume.
+					assumeRoleArn?: string
+					// ID of a saved Logs Insights query definition to run in place of the expression. The log groups of the definition are queried unless the query selects log groups.
+					queryDefinitionId?: string
+					// Maximum number of log events the Logs Insights query returns, capped by the maximum of the data source settings. If empty, the maximum of the data source settings or of Logs Insights, 10000.
+					limit?: int32
+					// Supplementary query the logs query is run as, LogsVolume counting the log events matching it by time and level for the histogram of Explore
+					supplementaryType?: #LogsSupplementaryType
 				} @cuetsy(kind="interface")
 				#LogGroup: {
 					// ARN of the log group
@@ -180,14 +276,14 @@ composableKinds: DataQuery: {
 					accountLabel?: string
 				} @cuetsy(kind="interface")
 
-				#CloudWatchQueryMode: "Metrics" | "Logs" | "Annotations" @cuetsy(kind="type")
+				#CloudWatchQueryMode: "Metrics" | "Logs" | "Annotations" | "Alarms" | "ContributorInsights" @cuetsy(kind="type")
 
 				// Shape of a CloudWatch Annotation query
 				#CloudWatchAnnotationQuery: {
 					common.DataQuery
 					#MetricStat
 
-					// Whether a query is a Metrics, Logs, or Annotations query
+					// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
 					queryMode: #CloudWatchQueryMode
 					// Enable matching on the prefix of the action name or alarm name, specify the prefixes with actionPrefix and/or alarmNamePrefix
 					prefixMatching?: bool
@@ -203,6 +299,28 @@ composableKinds: DataQuery: {
 					alarmNamePrefix?: string
 				} @cuetsy(kind="interface")
 
+				// Shape of a CloudWatch Contributor Insights query
+				#CloudWatchContributorInsightsQuery: {
+					common.DataQuery
+
+					// Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
+					queryMode: #CloudWatchQueryMode
+					// AWS region of the Contributor Insights rule
+					region: string
+					// Name of the Contributor Insights rule to report the top contributors of
+					ruleName: string
+					// Statistic the contributors are ranked by, Sum or Maximum. If empty, Sum.
+					orderBy?: string
+					// Maximum number of top contributors to return, from 1 to 100. If empty, 10.
+					maxContributorCount?: int32
+					// The period of the datapoints of the contributors in seconds. If empty, it's picked from the time range.
+					period?: string
+					// Whether to return a time series per contributor or a table of the aggregate values of the contributors. If empty, TimeSeries.
+					format?: #ContributorInsightsFormat
+				} @cuetsy(kind="interface")
+
+				#ContributorInsightsFormat: "TimeSeries" | "Table" @cuetsy(kind="enum")
+
 				// TS type is CloudWatchDefaultQuery = Omit<CloudWatchLogsQuery, 'queryMode'> & CloudWatchMetricsQuery, declared in veneer
 				// #CloudWatchDefaultQuery: #CloudWatchLogsQuery & #CloudWatchMetricsQuery @cuetsy(kind="type")
 			}
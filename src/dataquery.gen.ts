@@ -36,7 +36,7 @@ export interface MetricStat {
    */
   period?: string;
   /**
-   * AWS region to query for the metric
+   * AWS region to query for the metric. Metrics queries may query several regions, given as a comma separated list or `all` for every enabled region, their series being labelled with their region.
    */
   region: string;
   /**
@@ -62,35 +62,143 @@ export type Dimensions = Record<string, string | string[]>;
  * Shape of a CloudWatch Metrics query
  */
 export interface CloudWatchMetricsQuery extends common.DataQuery, MetricStat {
+  /**
+   * Further namespaces to search for the metric in addition to `namespace`, so that series of several namespaces can be shown in one query. Only used by search queries in the builder.
+   */
+  additionalNamespaces?: string[];
+  /**
+   * Dimension the series of the query are aggregated across with `aggregateFunction`, e.g. InstanceId to sum a metric over every instance. The metric is searched for every value of the dimension, filtered by the other dimensions, and the matching series are aggregated into one. Only used by queries in the builder.
+   */
+  aggregateDimension?: string;
+  /**
+   * Function aggregating the series across `aggregateDimension`. Defaults to `SUM`.
+   */
+  aggregateFunction?: AggregateFunction;
+  /**
+   * Whether to set the thresholds of the returned series to the thresholds of the CloudWatch alarms of their metric, so that panels show the lines the alarms use.
+   */
+  alarmThresholds?: boolean;
   /**
    * Deprecated: use label
    * @deprecated use label
    */
   alias?: string;
+  /**
+   * Role to assume instead of the data source's role, so that one data source can query several accounts without cross-account observability. Must be one of the roles the data source settings allow queries to assume.
+   */
+  assumeRoleArn?: string;
+  /**
+   * When the metric query type is set to `BurnRate`, the metric math or search expression of the good events of the SLO, e.g. the requests that succeeded. The series it returns are summed.
+   */
+  burnRateGoodExpression?: string;
+  /**
+   * When the metric query type is set to `BurnRate`, the metric math or search expression of all the events of the SLO. The series it returns are summed.
+   */
+  burnRateTotalExpression?: string;
+  /**
+   * When `sloMetric` is `BurnRate`, the look-back window of the burn rate in minutes. Must be the window of one of the burn rate configurations of the SLO.
+   */
+  burnRateWindowMinutes?: number;
+  /**
+   * When the metric query type is set to `BurnRate`, the look-back windows to compute the burn rate over, as durations such as `5m` or `1h`. Defaults to 5m, 1h and 6h.
+   */
+  burnRateWindows?: string[];
   /**
    * Math expression query
    */
   expression?: string;
+  /**
+   * Statistic requested again when the series of the query have no datapoints of `statistic`, e.g. SampleCount when a metric stops publishing Average, so that health panels show whether the metric is still published. Only used by queries in the builder.
+   */
+  fallbackStatistic?: string;
   /**
    * ID can be used to reference other queries in math expressions. The ID can include numbers, letters, and underscore, and must start with a lowercase letter.
    */
   id: string;
   /**
-   * Change the time series legend names using dynamic labels. See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/graph-dynamic-labels.html for more details.
+   * Whether to also query the SampleCount of the metric, and return it as a second field of each series, e.g. to hide averages computed from too few samples. Only used by queries in the builder.
+   */
+  includeSampleCount?: boolean;
+  /**
+   * Whether to label the series of EC2 instances with the Name tag of their instance, as the InstanceName label, and show the name in place of the instance ID in their legend.
+   */
+  instanceNames?: boolean;
+  /**
+   * Whether to return only the latest datapoint of each series, as a table with a row per series, instead of the time series of the time range.
+   */
+  instant?: boolean;
+  /**
+   * Change the time series legend names using dynamic labels. See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/graph-dynamic-labels.html for more details. The labels of math expressions may also use {{id}}, {{label}} and {{index}} to name each series the expression returns.
    */
   label?: string;
+  /**
+   * Whether to stream new datapoints of the query to the panel over Grafana Live.
+   */
+  live?: boolean;
+  /**
+   * Accounts a matrix query queries the metric in, in each of `matrixRegions`. Only the account of the data source is queried if empty.
+   */
+  matrixAccountIds?: string[];
+  /**
+   * Regions a matrix query queries the metric in. A matrix query returns a table of the latest value of the metric in each combination of `matrixRegions` and `matrixAccountIds`, e.g. for global health panels.
+   */
+  matrixRegions?: string[];
+  /**
+   * When the metric query type is set to `JSON`, the MetricDataQueries array of the GetMetricData request, as given to the API. Used for API features the editor doesn't support yet.
+   */
+  metricDataQueries?: string;
   /**
    * Whether to use the query builder or code editor to create the query
    */
   metricEditorMode?: MetricEditorMode;
   /**
-   * Whether to use a metric search or metric insights query
+   * Whether to use a metric search, metric insights, advanced JSON, query by tag or Application Signals SLO query
    */
   metricQueryType?: MetricQueryType;
   /**
-   * Whether a query is a Metrics, Logs, or Annotations query
+   * Percentiles queried in place of `statistic` in one request, e.g. p50, p90, p99 and p99.9, and returned in ascending order as one frame per series with a field per percentile, e.g. for heatmap and percentiles panels. Series filters, limits and instant mode don't apply to them. Only used by search queries in the builder.
+   */
+  percentiles?: string[];
+  /**
+   * Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
    */
   queryMode?: CloudWatchQueryMode;
+  /**
+   * Regular expression the returned series must match, e.g. to drop canary instances from the results of a search. Matched against the series name, or against the value of `seriesFilterDimension` if set.
+   */
+  seriesFilter?: string;
+  /**
+   * The dimension whose value `seriesFilter` is matched against.
+   */
+  seriesFilterDimension?: string;
+  /**
+   * Whether the series matching `seriesFilter` are dropped rather than kept.
+   */
+  seriesFilterExclude?: boolean;
+  /**
+   * The maximum number of series returned by the query, after they have been ordered. 0 returns every series.
+   */
+  seriesLimit?: number;
+  /**
+   * Orders the series of the query by a value of their datapoints, so that only the top or bottom series are returned when combined with `seriesLimit`.
+   */
+  seriesSortBy?: SeriesSortBy;
+  /**
+   * Whether series are ordered by descending or ascending value. Defaults to descending.
+   */
+  seriesSortOrder?: SeriesSortOrder;
+  /**
+   * When the metric query type is set to `SLO`, the metric of the SLO to query. Defaults to `AttainmentRate`.
+   */
+  sloMetric?: SLOMetric;
+  /**
+   * When the metric query type is set to `SLO`, the name of the Application Signals service level objective to query the metrics of.
+   */
+  sloName?: string;
+  /**
+   * When the metric query type is set to `BurnRate`, the objective of the SLO in percent, e.g. 99.9.
+   */
+  sloObjective?: number;
   /**
    * When the metric query type is set to `Insights` and the `metricEditorMode` is set to `Builder`, this field is used to build up an object representation of a SQL query.
    */
@@ -99,13 +207,21 @@ export interface CloudWatchMetricsQuery extends common.DataQuery, MetricStat {
    * When the metric query type is set to `Insights`, this field is used to specify the query string.
    */
   sqlExpression?: string;
+  /**
+   * When the metric query type is set to `Tags`, the tags of the resources to query the metric of. The resources matching them are looked up with the tagging API on every run, and expanded into the values of the dimension identifying them in the namespace.
+   */
+  tagFilters?: Dimensions;
 }
 
-export type CloudWatchQueryMode = 'Metrics' | 'Logs' | 'Annotations';
+export type CloudWatchQueryMode = 'Metrics' | 'Logs' | 'Annotations' | 'Alarms' | 'ContributorInsights';
 
 export enum MetricQueryType {
+  BurnRate = 5,
   Insights = 1,
+  JSON = 2,
+  SLO = 4,
   Search = 0,
+  Tags = 3,
 }
 
 export enum MetricEditorMode {
@@ -113,6 +229,30 @@ export enum MetricEditorMode {
   Code = 1,
 }
 
+export enum SeriesSortBy {
+  Avg = 'Avg',
+  Last = 'Last',
+  Max = 'Max',
+}
+
+export enum SeriesSortOrder {
+  Asc = 'Asc',
+  Desc = 'Desc',
+}
+
+export enum SLOMetric {
+  AttainmentRate = 'AttainmentRate',
+  BurnRate = 'BurnRate',
+  ErrorBudgetRemaining = 'ErrorBudgetRemaining',
+}
+
+export enum AggregateFunction {
+  AVG = 'AVG',
+  MAX = 'MAX',
+  MIN = 'MIN',
+  SUM = 'SUM',
+}
+
 export interface SQLExpression {
   /**
    * FROM part of the SQL expression
@@ -228,15 +368,70 @@ export enum LogsQueryLanguage {
   SQL = 'SQL',
 }
 
+export enum LogsMode {
+  ContainerInsights = 'ContainerInsights',
+  Events = 'Events',
+  Filter = 'Filter',
+  Insights = 'Insights',
+  LiveTail = 'LiveTail',
+  VPCFlowLogs = 'VPCFlowLogs',
+}
+
+export enum ContainerInsightsQuery {
+  NodeCPUUtilization = 'NodeCPUUtilization',
+  NodeMemoryUtilization = 'NodeMemoryUtilization',
+  PodCPUUtilization = 'PodCPUUtilization',
+  PodMemoryUtilization = 'PodMemoryUtilization',
+  PodRestarts = 'PodRestarts',
+}
+
+export enum VPCFlowLogsQuery {
+  Records = 'Records',
+  RejectedConnections = 'RejectedConnections',
+  TopTalkers = 'TopTalkers',
+}
+
+export enum LogsSupplementaryType {
+  LogsVolume = 'LogsVolume',
+}
+
 /**
  * Shape of a CloudWatch Logs query
  */
 export interface CloudWatchLogsQuery extends common.DataQuery {
+  /**
+   * Role to assume instead of the data source's role, so that one data source can query several accounts without cross-account observability. Must be one of the roles the data source settings allow queries to assume.
+   */
+  assumeRoleArn?: string;
+  /**
+   * Name of the cluster whose Container Insights performance logs are queried
+   */
+  clusterName?: string;
+  /**
+   * Pre-built aggregation of the Container Insights performance logs to run when the logs mode is ContainerInsights
+   */
+  containerInsightsQuery?: ContainerInsightsQuery;
   /**
    * The CloudWatch Logs Insights query to execute
    */
   expression?: string;
+  /**
+   * Format of the flow log records, the list of ${field} placeholders the flow log was created with. If empty, the default version 2 format.
+   */
+  flowLogFormat?: string;
   id: string;
+  /**
+   * Dot separated paths of JSON log lines whose values are extracted into fields, e.g. request.status
+   */
+  jsonPaths?: string[];
+  /**
+   * Kubernetes namespace to restrict the pod aggregations of Container Insights to
+   */
+  kubernetesNamespace?: string;
+  /**
+   * Maximum number of log events the Logs Insights query returns, capped by the maximum of the data source settings. If empty, the maximum of the data source settings or of Logs Insights, 10000.
+   */
+  limit?: number;
   /**
    * @deprecated use logGroups
    */
@@ -245,22 +440,58 @@ export interface CloudWatchLogsQuery extends common.DataQuery {
    * Log groups to query
    */
   logGroups?: LogGroup[];
+  /**
+   * Log stream to read the events of when the logs mode is Events, or to only tail when the logs mode is LiveTail
+   */
+  logStreamName?: string;
+  /**
+   * Whether to query the log groups with Logs Insights, to read the events of a single log stream, to match the events of the log groups against a filter pattern, or to tail the new events of the log groups matching a filter pattern with Live Tail. If empty, the default mode is Insights.
+   */
+  logsMode?: LogsMode;
+  /**
+   * Whether to extract the metrics and dimensions declared in the _aws metadata of Embedded Metric Format log lines into fields
+   */
+  parseEmf?: boolean;
+  /**
+   * Whether to extract the key=value pairs of logfmt formatted log lines into fields
+   */
+  parseLogfmt?: boolean;
+  /**
+   * ID of a saved Logs Insights query definition to run in place of the expression. The log groups of the definition are queried unless the query selects log groups.
+   */
+  queryDefinitionId?: string;
   /**
    * Language used for querying logs, can be CWLI, SQL, or PPL. If empty, the default language is CWLI.
    */
   queryLanguage?: LogsQueryLanguage;
   /**
-   * Whether a query is a Metrics, Logs, or Annotations query
+   * Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
    */
   queryMode: CloudWatchQueryMode;
+  /**
+   * Name of a recorded query configured in the data source settings to serve the last result of
+   */
+  recordedQuery?: string;
   /**
    * AWS region to query for the logs
    */
   region: string;
+  /**
+   * Whether to read the earliest events of the time range rather than the latest when the logs mode is Events
+   */
+  startFromHead?: boolean;
   /**
    * Fields to group the results by, this field is automatically populated whenever the query is updated
    */
   statsGroups?: string[];
+  /**
+   * Supplementary query the logs query is run as, LogsVolume counting the log events matching it by time and level for the histogram of Explore
+   */
+  supplementaryType?: LogsSupplementaryType;
+  /**
+   * Records or canned aggregation of the VPC Flow Logs of the log groups to run when the logs mode is VPCFlowLogs
+   */
+  vpcFlowLogsQuery?: VPCFlowLogsQuery;
 }
 
 export const defaultCloudWatchLogsQuery: Partial<CloudWatchLogsQuery> = {
@@ -315,9 +546,48 @@ export interface CloudWatchAnnotationQuery extends common.DataQuery, MetricStat
    */
   prefixMatching?: boolean;
   /**
-   * Whether a query is a Metrics, Logs, or Annotations query
+   * Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
+   */
+  queryMode: CloudWatchQueryMode;
+}
+
+/**
+ * Shape of a CloudWatch Contributor Insights query
+ */
+export interface CloudWatchContributorInsightsQuery extends common.DataQuery {
+  /**
+   * Whether to return a time series per contributor or a table of the aggregate values of the contributors. If empty, TimeSeries.
+   */
+  format?: ContributorInsightsFormat;
+  /**
+   * Maximum number of top contributors to return, from 1 to 100. If empty, 10.
+   */
+  maxContributorCount?: number;
+  /**
+   * Statistic the contributors are ranked by, Sum or Maximum. If empty, Sum.
+   */
+  orderBy?: string;
+  /**
+   * The period of the datapoints of the contributors in seconds. If empty, it's picked from the time range.
+   */
+  period?: string;
+  /**
+   * Whether a query is a Metrics, Logs, Annotations, Alarms, or ContributorInsights query
    */
   queryMode: CloudWatchQueryMode;
+  /**
+   * AWS region of the Contributor Insights rule
+   */
+  region: string;
+  /**
+   * Name of the Contributor Insights rule to report the top contributors of
+   */
+  ruleName: string;
+}
+
+export enum ContributorInsightsFormat {
+  Table = 'Table',
+  TimeSeries = 'TimeSeries',
 }
 
 export interface CloudWatchDataQuery {}
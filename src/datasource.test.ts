@@ -1,7 +1,7 @@
 import { lastValueFrom } from 'rxjs';
 import { toArray } from 'rxjs/operators';
 
-import { CoreApp, Field } from '@grafana/data';
+import { CoreApp, Field, SupplementaryQueryType } from '@grafana/data';
 
 import {
   CloudWatchSettings,
@@ -20,6 +20,7 @@ import {
   CloudWatchMetricsQuery,
   CloudWatchQuery,
   LogsQueryLanguage,
+  LogsSupplementaryType,
   MetricEditorMode,
   MetricQueryType,
 } from './types';
@@ -355,6 +356,31 @@ describe('datasource', () => {
     });
   });
 
+  describe('supplementary queries', () => {
+    it('should query the logs volume of Logs Insights QL queries', () => {
+      const { datasource } = setupMockedDataSource();
+
+      expect(
+        datasource.getSupplementaryQuery({ type: SupplementaryQueryType.LogsVolume }, validLogsQuery)
+      ).toMatchObject({
+        refId: `log-volume-${validLogsQuery.refId}`,
+        supplementaryType: LogsSupplementaryType.LogsVolume,
+      });
+    });
+
+    it('should not query the logs volume of queries whose results are not log events', () => {
+      const { datasource } = setupMockedDataSource();
+
+      for (const query of [
+        { ...validLogsQuery, expression: 'fields @message | stats count(*) by bin(5m)' },
+        { ...validLogsQuery, queryLanguage: LogsQueryLanguage.SQL },
+        validMetricSearchBuilderQuery,
+      ]) {
+        expect(datasource.getSupplementaryQuery({ type: SupplementaryQueryType.LogsVolume }, query)).toBeUndefined();
+      }
+    });
+  });
+
   describe('resource requests', () => {
     it('should map resource response to metric response', async () => {
       const datasource = setupMockedDataSource({
@@ -7,16 +7,25 @@ import {
   DataQueryResponse,
   DataSourceInstanceSettings,
   DataSourceWithLogsContextSupport,
+  DataSourceWithSupplementaryQueriesSupport,
   LoadingState,
   LogRowContextOptions,
   LogRowModel,
   ScopedVars,
+  SupplementaryQueryOptions,
+  SupplementaryQueryType,
 } from '@grafana/data';
 import { DataSourceWithBackend, TemplateSrv, getTemplateSrv } from '@grafana/runtime';
 
 import { CloudWatchAnnotationSupport } from './annotationSupport';
 import { DEFAULT_METRICS_QUERY, getDefaultLogsQuery } from './defaultQueries';
-import { isCloudWatchAnnotationQuery, isCloudWatchLogsQuery, isCloudWatchMetricsQuery } from './guards';
+import {
+  isCloudWatchAlarmQuery,
+  isCloudWatchAnnotationQuery,
+  isCloudWatchContributorInsightsQuery,
+  isCloudWatchLogsQuery,
+  isCloudWatchMetricsQuery,
+} from './guards';
 import { CloudWatchLogsLanguageProvider } from './language/cloudwatch-logs/CloudWatchLogsLanguageProvider';
 import {
   LogsSQLCompletionItemProvider,
@@ -34,27 +43,35 @@ import {
 } from './language/logs/completion/CompletionItemProvider';
 import { MetricMathCompletionItemProvider } from './language/metric-math/completion/CompletionItemProvider';
 import { CloudWatchAnnotationQueryRunner } from './query-runner/CloudWatchAnnotationQueryRunner';
+import { CloudWatchContributorInsightsQueryRunner } from './query-runner/CloudWatchContributorInsightsQueryRunner';
 import { CloudWatchLogsQueryRunner } from './query-runner/CloudWatchLogsQueryRunner';
 import { CloudWatchMetricsQueryRunner } from './query-runner/CloudWatchMetricsQueryRunner';
 import { ResourcesAPI } from './resources/ResourcesAPI';
 import {
   CloudWatchAnnotationQuery,
+  CloudWatchContributorInsightsQuery,
   CloudWatchJsonData,
   CloudWatchLogsQuery,
   CloudWatchMetricsQuery,
   CloudWatchQuery,
+  LogsMode,
+  LogsQueryLanguage,
+  LogsSupplementaryType,
 } from './types';
 import { CloudWatchVariableSupport } from './variables';
 
 export class CloudWatchDatasource
   extends DataSourceWithBackend<CloudWatchQuery, CloudWatchJsonData>
-  implements DataSourceWithLogsContextSupport<CloudWatchLogsQuery>
+  implements
+    DataSourceWithLogsContextSupport<CloudWatchLogsQuery>,
+    DataSourceWithSupplementaryQueriesSupport<CloudWatchQuery>
 {
   defaultRegion?: string;
   languageProvider: CloudWatchLogsLanguageProvider;
   sqlCompletionItemProvider: SQLCompletionItemProvider;
   metricMathCompletionItemProvider: MetricMathCompletionItemProvider;
   defaultLogGroups?: string[];
+  queryRoleArns: string[];
   logsSqlCompletionItemProviderFunc: (queryContext: queryContext) => LogsSQLCompletionItemProvider;
   logsCompletionItemProviderFunc: (queryContext: queryContext) => LogsCompletionItemProvider;
   pplCompletionItemProviderFunc: (queryContext: queryContext) => PPLCompletionItemProvider;
@@ -63,6 +80,7 @@ export class CloudWatchDatasource
 
   private metricsQueryRunner: CloudWatchMetricsQueryRunner;
   private annotationQueryRunner: CloudWatchAnnotationQueryRunner;
+  private contributorInsightsQueryRunner: CloudWatchContributorInsightsQueryRunner;
   logsQueryRunner: CloudWatchLogsQueryRunner;
   resources: ResourcesAPI;
 
@@ -78,10 +96,12 @@ export class CloudWatchDatasource
     this.metricsQueryRunner = new CloudWatchMetricsQueryRunner(instanceSettings, templateSrv);
     this.logsQueryRunner = new CloudWatchLogsQueryRunner(instanceSettings, templateSrv);
     this.annotationQueryRunner = new CloudWatchAnnotationQueryRunner(instanceSettings, templateSrv);
+    this.contributorInsightsQueryRunner = new CloudWatchContributorInsightsQueryRunner(instanceSettings, templateSrv);
     this.variables = new CloudWatchVariableSupport(this.resources);
     this.annotations = CloudWatchAnnotationSupport;
     // eslint-disable-next-line deprecation/deprecation
     this.defaultLogGroups = instanceSettings.jsonData.defaultLogGroups;
+    this.queryRoleArns = instanceSettings.jsonData.queryRoleArns ?? [];
 
     this.metricMathCompletionItemProvider = new MetricMathCompletionItemProvider(this.resources, this.templateSrv);
     this.logsCompletionItemProviderFunc = LogsCompletionItemProviderFunc(this.resources, this.templateSrv);
@@ -104,12 +124,31 @@ export class CloudWatchDatasource
     let queries = options.targets.filter(this.filterQuery);
 
     const logQueries: CloudWatchLogsQuery[] = [];
+    const recordedLogQueries: CloudWatchLogsQuery[] = [];
+    const logEventsQueries: CloudWatchLogsQuery[] = [];
     const metricsQueries: CloudWatchMetricsQuery[] = [];
     const annotationQueries: CloudWatchAnnotationQuery[] = [];
+    const alarmQueries: CloudWatchAnnotationQuery[] = [];
+    const contributorInsightsQueries: CloudWatchContributorInsightsQuery[] = [];
 
     queries.forEach((query) => {
       if (isCloudWatchAnnotationQuery(query)) {
         annotationQueries.push(query);
+      } else if (isCloudWatchAlarmQuery(query)) {
+        alarmQueries.push(query);
+      } else if (isCloudWatchContributorInsightsQuery(query)) {
+        contributorInsightsQueries.push(query);
+      } else if (isCloudWatchLogsQuery(query) && query.recordedQuery) {
+        recordedLogQueries.push(query);
+      } else if (
+        isCloudWatchLogsQuery(query) &&
+        (query.logsMode === LogsMode.Events ||
+          query.logsMode === LogsMode.Filter ||
+          query.logsMode === LogsMode.ContainerInsights ||
+          query.logsMode === LogsMode.VPCFlowLogs ||
+          query.logsMode === LogsMode.LiveTail)
+      ) {
+        logEventsQueries.push(query);
       } else if (isCloudWatchLogsQuery(query)) {
         logQueries.push(query);
       } else {
@@ -122,6 +161,18 @@ export class CloudWatchDatasource
       dataQueryResponses.push(this.logsQueryRunner.handleLogQueries(logQueries, options, super.query.bind(this)));
     }
 
+    if (recordedLogQueries.length) {
+      dataQueryResponses.push(
+        this.logsQueryRunner.handleRecordedQueries(recordedLogQueries, options, super.query.bind(this))
+      );
+    }
+
+    if (logEventsQueries.length) {
+      dataQueryResponses.push(
+        this.logsQueryRunner.handleLogEventsQueries(logEventsQueries, options, super.query.bind(this))
+      );
+    }
+
     if (metricsQueries.length) {
       dataQueryResponses.push(
         this.metricsQueryRunner.handleMetricQueries(metricsQueries, options, super.query.bind(this))
@@ -133,6 +184,22 @@ export class CloudWatchDatasource
         this.annotationQueryRunner.handleAnnotationQuery(annotationQueries, options, super.query.bind(this))
       );
     }
+
+    if (alarmQueries.length) {
+      dataQueryResponses.push(
+        this.annotationQueryRunner.handleAlarmQuery(alarmQueries, options, super.query.bind(this))
+      );
+    }
+
+    if (contributorInsightsQueries.length) {
+      dataQueryResponses.push(
+        this.contributorInsightsQueryRunner.handleContributorInsightsQueries(
+          contributorInsightsQueries,
+          options,
+          super.query.bind(this)
+        )
+      );
+    }
     // No valid targets, return the empty result to save a round trip.
     if (isEmpty(dataQueryResponses)) {
       return of({
@@ -144,6 +211,40 @@ export class CloudWatchDatasource
     return merge(...dataQueryResponses);
   }
 
+  getSupportedSupplementaryQueryTypes(): SupplementaryQueryType[] {
+    return [SupplementaryQueryType.LogsVolume];
+  }
+
+  getSupplementaryRequest(
+    type: SupplementaryQueryType,
+    request: DataQueryRequest<CloudWatchQuery>
+  ): DataQueryRequest<CloudWatchQuery> | undefined {
+    const targets = request.targets
+      .map((query) => this.getSupplementaryQuery({ type }, query))
+      .filter((query): query is CloudWatchQuery => query !== undefined);
+    if (!targets.length) {
+      return undefined;
+    }
+    return { ...request, requestId: `${request.requestId}_${type}`, targets };
+  }
+
+  // The logs volume of a Logs Insights QL query is counted by the backend, which rewrites the query into one counting
+  // its log events by time and level. Queries whose results aren't log events have no volume.
+  getSupplementaryQuery(options: SupplementaryQueryOptions, query: CloudWatchQuery): CloudWatchQuery | undefined {
+    if (
+      options.type !== SupplementaryQueryType.LogsVolume ||
+      !isCloudWatchLogsQuery(query) ||
+      query.hide ||
+      query.recordedQuery ||
+      (query.logsMode ?? LogsMode.Insights) !== LogsMode.Insights ||
+      (query.queryLanguage ?? LogsQueryLanguage.CWLI) !== LogsQueryLanguage.CWLI ||
+      /(^|\|)\s*(stats|pattern|diff|anomaly)\b/i.test(query.expression ?? '')
+    ) {
+      return undefined;
+    }
+    return { ...query, refId: `log-volume-${query.refId}`, supplementaryType: LogsSupplementaryType.LogsVolume };
+  }
+
   interpolateVariablesInQueries(queries: CloudWatchQuery[], scopedVars: ScopedVars): CloudWatchQuery[] {
     if (!queries.length) {
       return queries;
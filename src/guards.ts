@@ -1,6 +1,12 @@
 import { AnnotationQuery } from '@grafana/data';
 
-import { CloudWatchAnnotationQuery, CloudWatchLogsQuery, CloudWatchMetricsQuery, CloudWatchQuery } from './types';
+import {
+  CloudWatchAnnotationQuery,
+  CloudWatchContributorInsightsQuery,
+  CloudWatchLogsQuery,
+  CloudWatchMetricsQuery,
+  CloudWatchQuery,
+} from './types';
 
 export const isCloudWatchLogsQuery = (cloudwatchQuery: CloudWatchQuery): cloudwatchQuery is CloudWatchLogsQuery =>
   cloudwatchQuery.queryMode === 'Logs';
@@ -12,5 +18,12 @@ export const isCloudWatchAnnotationQuery = (
   cloudwatchQuery: CloudWatchQuery
 ): cloudwatchQuery is CloudWatchAnnotationQuery => cloudwatchQuery.queryMode === 'Annotations';
 
+export const isCloudWatchAlarmQuery = (cloudwatchQuery: CloudWatchQuery): cloudwatchQuery is CloudWatchAnnotationQuery =>
+  cloudwatchQuery.queryMode === 'Alarms';
+
+export const isCloudWatchContributorInsightsQuery = (
+  cloudwatchQuery: CloudWatchQuery
+): cloudwatchQuery is CloudWatchContributorInsightsQuery => cloudwatchQuery.queryMode === 'ContributorInsights';
+
 export const isCloudWatchAnnotation = (query: unknown): query is AnnotationQuery<CloudWatchAnnotationQuery> =>
   (query as AnnotationQuery<CloudWatchAnnotationQuery>).target?.queryMode === 'Annotations';
@@ -7,7 +7,7 @@ import { CloudWatchAnnotationQuery, CloudWatchJsonData, CloudWatchQuery } from '
 
 import { CloudWatchRequest } from './CloudWatchRequest';
 
-// This class handles execution of CloudWatch annotation queries
+// This class handles execution of CloudWatch annotation and alarm queries
 export class CloudWatchAnnotationQueryRunner extends CloudWatchRequest {
   constructor(instanceSettings: DataSourceInstanceSettings<CloudWatchJsonData>, templateSrv: TemplateSrv) {
     super(instanceSettings, templateSrv);
@@ -35,4 +35,27 @@ export class CloudWatchAnnotationQueryRunner extends CloudWatchRequest {
       })),
     });
   }
+
+  handleAlarmQuery(
+    queries: CloudWatchAnnotationQuery[],
+    options: DataQueryRequest<CloudWatchQuery>,
+    queryFn: (request: DataQueryRequest<CloudWatchQuery>) => Observable<DataQueryResponse>
+  ): Observable<DataQueryResponse> {
+    return queryFn({
+      ...options,
+      targets: queries.map((query) => ({
+        ...query,
+        statistic: this.templateSrv.replace(query.statistic),
+        region: this.templateSrv.replace(this.getActualRegion(query.region)),
+        namespace: this.templateSrv.replace(query.namespace),
+        metricName: this.templateSrv.replace(query.metricName),
+        dimensions: this.convertDimensionFormat(query.dimensions ?? {}, {}),
+        period: query.period ?? '',
+        actionPrefix: this.templateSrv.replace(query.actionPrefix ?? ''),
+        alarmNamePrefix: this.templateSrv.replace(query.alarmNamePrefix ?? ''),
+        type: 'alarmQuery',
+        datasource: this.ref,
+      })),
+    });
+  }
 }
@@ -0,0 +1,32 @@
+import { Observable } from 'rxjs';
+
+import { DataQueryRequest, DataQueryResponse, DataSourceInstanceSettings } from '@grafana/data';
+import { TemplateSrv } from '@grafana/runtime';
+
+import { CloudWatchContributorInsightsQuery, CloudWatchJsonData, CloudWatchQuery } from '../types';
+
+import { CloudWatchRequest } from './CloudWatchRequest';
+
+// This class handles execution of CloudWatch Contributor Insights queries
+export class CloudWatchContributorInsightsQueryRunner extends CloudWatchRequest {
+  constructor(instanceSettings: DataSourceInstanceSettings<CloudWatchJsonData>, templateSrv: TemplateSrv) {
+    super(instanceSettings, templateSrv);
+  }
+
+  handleContributorInsightsQueries(
+    queries: CloudWatchContributorInsightsQuery[],
+    options: DataQueryRequest<CloudWatchQuery>,
+    queryFn: (request: DataQueryRequest<CloudWatchQuery>) => Observable<DataQueryResponse>
+  ): Observable<DataQueryResponse> {
+    return queryFn({
+      ...options,
+      targets: queries.map((query) => ({
+        ...query,
+        region: this.templateSrv.replace(this.getActualRegion(query.region), options.scopedVars),
+        ruleName: this.templateSrv.replace(query.ruleName, options.scopedVars),
+        period: this.templateSrv.replace(query.period ?? '', options.scopedVars),
+        datasource: this.ref,
+      })),
+    });
+  }
+}
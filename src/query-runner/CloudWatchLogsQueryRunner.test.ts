@@ -94,6 +94,40 @@ describe('CloudWatchLogsQueryRunner', () => {
       });
     });
 
+    it('should start the query and get its results with the role the query assumes', async () => {
+      const { runner } = setupMockedLogsQueryRunner();
+      const assumeRoleArn = 'arn:aws:iam::123456789012:role/query-role';
+      const queries: CloudWatchLogsQuery[] = [{ ...rawLogQueriesStub[0], assumeRoleArn }];
+      const options: DataQueryRequest<CloudWatchLogsQuery> = {
+        ...LogsRequestMock,
+        targets: queries,
+      };
+
+      const queryFn = jest
+        .fn()
+        .mockReturnValueOnce(of(startQuerySuccessResponseStub))
+        .mockReturnValueOnce(of(getQueryLoadingResponseStub))
+        .mockReturnValueOnce(of(getQueryErrorResponseStub))
+        .mockReturnValueOnce(of(stopQueryResponseStub));
+
+      await lastValueFrom(runner.handleLogQueries(queries, options, queryFn));
+
+      expect(queryFn).toHaveBeenCalledTimes(4);
+      for (const [call, subtype] of [
+        [1, 'StartQuery'],
+        [2, 'GetQueryResults'],
+        [3, 'GetQueryResults'],
+        [4, 'StopQuery'],
+      ] as const) {
+        expect(queryFn).toHaveBeenNthCalledWith(
+          call,
+          expect.objectContaining({
+            targets: [expect.objectContaining({ subtype, assumeRoleArn })],
+          })
+        );
+      }
+    });
+
     it('should call getQueryResults until the query returns with a status of complete', async () => {
       const { runner } = setupMockedLogsQueryRunner();
 
@@ -18,6 +18,8 @@ import {
 
 import {
   DataFrame,
+  DataFrameJSON,
+  dataFrameFromJSON,
   DataQueryError,
   DataQueryErrorType,
   DataQueryRequest,
@@ -30,9 +32,10 @@ import {
   getDefaultTimeRange,
   rangeUtil,
 } from '@grafana/data';
-import { TemplateSrv } from '@grafana/runtime';
+import { getBackendSrv, TemplateSrv } from '@grafana/runtime';
 import { type CustomFormatterVariable } from '@grafana/scenes';
 
+import { identityCenterConsoleUrl } from '../aws_url';
 import {
   CloudWatchJsonData,
   CloudWatchLogsQuery,
@@ -54,11 +57,12 @@ import { CloudWatchRequest } from './CloudWatchRequest';
 
 export const LOG_IDENTIFIER_INTERNAL = '__log__grafana_internal__';
 export const LOGSTREAM_IDENTIFIER_INTERNAL = '__logstream__grafana_internal__';
+export const LOG_PTR_INTERNAL = '__ptr__grafana_internal__';
 
 // This class handles execution of CloudWatch logs query data queries
 export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
   logsTimeout: string;
-  logQueries: Record<string, { id: string; region: string; statsQuery: boolean }> = {};
+  logQueries: Record<string, { id: string; region: string; statsQuery: boolean; assumeRoleArn?: string }> = {};
   tracingDataSourceUid?: string;
 
   constructor(instanceSettings: DataSourceInstanceSettings<CloudWatchJsonData>, templateSrv: TemplateSrv) {
@@ -146,6 +150,10 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
         logGroups,
         logGroupNames,
         queryLanguage: target.queryLanguage,
+        queryDefinitionId: target.queryDefinitionId,
+        limit: target.limit,
+        supplementaryType: target.supplementaryType,
+        assumeRoleArn: target.assumeRoleArn,
       };
     });
 
@@ -171,7 +179,8 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
               this.replaceVariableAndDisplayWarningIfMulti.bind(this),
               this.expandVariableToArray.bind(this),
               this.getActualRegion.bind(this),
-              this.tracingDataSourceUid
+              this.tracingDataSourceUid,
+              (url, accountId) => identityCenterConsoleUrl(url, this.instanceSettings.jsonData, accountId)
             );
 
             return dataQueryResponse;
@@ -181,6 +190,44 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
     );
   };
 
+  /**
+   * Recorded queries are executed on a schedule by the backend, which serves their last result, so there is nothing to poll for.
+   */
+  public handleRecordedQueries = (
+    recordedQueries: CloudWatchLogsQuery[],
+    options: DataQueryRequest<CloudWatchQuery>,
+    queryFn: (request: DataQueryRequest<CloudWatchQuery>) => Observable<DataQueryResponse>
+  ): Observable<DataQueryResponse> => {
+    return queryFn({
+      ...options,
+      targets: recordedQueries.map((query) => ({ ...query, datasource: this.ref })),
+    });
+  };
+
+  /**
+   * Log events queries read a single log stream with GetLogEvents, or filter the events of the log groups with
+   * FilterLogEvents, both of which return their result right away. Container Insights and VPC Flow Logs queries are
+   * awaited by the backend, so they are returned right away too.
+   */
+  public handleLogEventsQueries = (
+    logEventsQueries: CloudWatchLogsQuery[],
+    options: DataQueryRequest<CloudWatchQuery>,
+    queryFn: (request: DataQueryRequest<CloudWatchQuery>) => Observable<DataQueryResponse>
+  ): Observable<DataQueryResponse> => {
+    return queryFn({
+      ...options,
+      targets: logEventsQueries.map((query) => ({
+        ...query,
+        region: this.templateSrv.replace(this.getActualRegion(query.region), options.scopedVars),
+        logStreamName: this.templateSrv.replace(query.logStreamName ?? '', options.scopedVars),
+        expression: this.templateSrv.replace(query.expression ?? '', options.scopedVars),
+        clusterName: this.templateSrv.replace(query.clusterName ?? '', options.scopedVars),
+        kubernetesNamespace: this.templateSrv.replace(query.kubernetesNamespace ?? '', options.scopedVars),
+        datasource: this.ref,
+      })),
+    });
+  };
+
   /**
    * Called by datasource.ts, invoked when user clicks on a log row in the logs visualization and the "show context button"
    */
@@ -207,6 +254,22 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
       }
     }
 
+    // the results of SQL and PPL queries don't identify the log stream of their events, which is looked up by the
+    // pointer of the event instead
+    const ptrField = row.dataFrame.fields.find((field) => field.name === LOG_PTR_INTERNAL);
+    if ((logStreamField === null || logField === null) && ptrField) {
+      const frame = await getBackendSrv().get<DataFrameJSON>(
+        `/api/datasources/${this.instanceSettings.id}/resources/log-context`,
+        {
+          region: this.templateSrv.replace(this.getActualRegion(query?.region)),
+          ptr: ptrField.values[row.rowIndex],
+          limit,
+          direction: direction === LogRowContextQueryDirection.Backward ? 'backward' : 'forward',
+        }
+      );
+      return { data: [dataFrameFromJSON(frame)] };
+    }
+
     const requestParams: GetLogEventsRequest = {
       refId: query?.refId || 'A', // dummy
       limit,
@@ -214,6 +277,7 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
       region: this.templateSrv.replace(this.getActualRegion(query?.region)),
       logGroupName: parseLogGroupName(logField!.values[row.rowIndex]),
       logStreamName: logStreamField!.values[row.rowIndex],
+      assumeRoleArn: query?.assumeRoleArn,
     };
 
     if (direction === LogRowContextQueryDirection.Backward) {
@@ -259,8 +323,13 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
       startQueryResponse.data.map((dataFrame) => ({
         queryId: dataFrame.fields[0].values[0],
         region: dataFrame.meta?.custom?.['Region'] ?? 'default',
+        // the effective limit of the query, so that the results tell whether they reached it
+        limit: dataFrame.meta?.custom?.['Limit'],
         refId: dataFrame.refId!,
         statsGroups: logQueries.find((target) => target.refId === dataFrame.refId)?.statsGroups,
+        supplementaryType: logQueries.find((target) => target.refId === dataFrame.refId)?.supplementaryType,
+        // the results of a query are read with the role it was started with
+        assumeRoleArn: logQueries.find((target) => target.refId === dataFrame.refId)?.assumeRoleArn,
       })),
       timeoutFunc,
       queryFn,
@@ -284,10 +353,12 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
         id: param.queryId,
         region: param.region,
         statsQuery: (param.statsGroups?.length ?? 0) > 0,
+        assumeRoleArn: param.assumeRoleArn,
       };
     });
 
-    const responses = increasingInterval({ startPeriod: 100, endPeriod: 1000, step: 300 }).pipe(
+    // jittered so that the panels of a dashboard refreshed together don't poll together
+    const responses = increasingInterval({ startPeriod: 100, endPeriod: 1000, step: 300, jitter: 0.2 }).pipe(
       concatMap((_) => this.makeLogActionRequest('GetQueryResults', queryParams, queryFn)),
       repeat(),
       share()
@@ -387,6 +458,7 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
           region: logQuery.region,
           queryString: '',
           refId: '',
+          assumeRoleArn: logQuery.assumeRoleArn,
         })),
         queryFn
       ).pipe(
@@ -438,6 +510,11 @@ export class CloudWatchLogsQueryRunner extends CloudWatchRequest {
     const hasMissingLogGroups = !query.logGroups?.length;
     const hasMissingQueryString = !query.expression?.length;
 
+    // the query string and log groups of queries running a saved query definition are the definition's
+    if (query.queryDefinitionId) {
+      return true;
+    }
+
     // log groups are not mandatory if language is SQL
     const isInvalidCWLIQuery = query.queryLanguage !== 'SQL' && hasMissingLogGroups && hasMissingLegacyLogGroupNames;
     if (isInvalidCWLIQuery || hasMissingQueryString) {
@@ -1,6 +1,6 @@
 import { isEmpty } from 'lodash';
 import { createElement } from 'react';
-import { catchError, map, Observable, of } from 'rxjs';
+import { catchError, map, merge, Observable, of } from 'rxjs';
 
 import {
   AppEvents,
@@ -19,7 +19,7 @@ import { TemplateSrv, getAppEvents } from '@grafana/runtime';
 import { ThrottlingErrorMessage } from '../components/Errors/ThrottlingErrorMessage';
 import memoizedDebounce from '../memoizedDebounce';
 import { migrateMetricQuery } from '../migrations/metricQueryMigrations';
-import { CloudWatchJsonData, CloudWatchMetricsQuery, CloudWatchQuery } from '../types';
+import { CloudWatchJsonData, CloudWatchMetricsQuery, CloudWatchQuery, MetricQueryType } from '../types';
 import { filterMetricsQuery } from '../utils/utils';
 
 import { CloudWatchRequest } from './CloudWatchRequest';
@@ -65,7 +65,7 @@ export class CloudWatchMetricsQueryRunner extends CloudWatchRequest {
         intervalMs: options.intervalMs,
         maxDataPoints: options.maxDataPoints,
         ...migratedAndIterpolatedQuery,
-        type: 'timeSeriesQuery',
+        type: migratedAndIterpolatedQuery.matrixRegions?.length ? 'matrixQuery' : 'timeSeriesQuery',
         datasource: this.ref,
       };
     });
@@ -75,12 +75,34 @@ export class CloudWatchMetricsQueryRunner extends CloudWatchRequest {
       return of({ data: [] });
     }
 
-    const request: DataQueryRequest<CloudWatchQuery> = {
-      ...options,
-      targets: validMetricsQueries,
-    };
+    // matrix, advanced JSON and burn rate queries are executed separately by the backend, so they are sent in their
+    // own requests
+    const isJSONQuery = (query: CloudWatchMetricsQuery) => query.metricQueryType === MetricQueryType.JSON;
+    const isBurnRateQuery = (query: CloudWatchMetricsQuery) => query.metricQueryType === MetricQueryType.BurnRate;
+    const isSeparateQuery = (query: CloudWatchMetricsQuery) => isJSONQuery(query) || isBurnRateQuery(query);
+    const timeSeriesQueries = validMetricsQueries.filter(
+      (query) => query.type === 'timeSeriesQuery' && !isSeparateQuery(query)
+    );
+    const matrixQueries = validMetricsQueries.filter(
+      (query) => query.type === 'matrixQuery' && !isSeparateQuery(query)
+    );
+    const jsonQueries = validMetricsQueries.filter(isJSONQuery);
+    const burnRateQueries = validMetricsQueries.filter(isBurnRateQuery);
+    const responses: Array<Observable<DataQueryResponse>> = [];
+    if (timeSeriesQueries.length) {
+      responses.push(this.performTimeSeriesQuery({ ...options, targets: timeSeriesQueries }, queryFn));
+    }
+    if (matrixQueries.length) {
+      responses.push(queryFn({ ...options, targets: matrixQueries }));
+    }
+    if (jsonQueries.length) {
+      responses.push(queryFn({ ...options, targets: jsonQueries }));
+    }
+    if (burnRateQueries.length) {
+      responses.push(queryFn({ ...options, targets: burnRateQueries }));
+    }
 
-    return this.performTimeSeriesQuery(request, queryFn);
+    return merge(...responses);
   };
 
   interpolateMetricsQueryVariables(
@@ -88,7 +110,14 @@ export class CloudWatchMetricsQueryRunner extends CloudWatchRequest {
     scopedVars: ScopedVars
   ): Pick<
     CloudWatchMetricsQuery,
-    'alias' | 'metricName' | 'namespace' | 'period' | 'dimensions' | 'sqlExpression' | 'expression'
+    | 'alias'
+    | 'metricName'
+    | 'namespace'
+    | 'period'
+    | 'dimensions'
+    | 'sqlExpression'
+    | 'expression'
+    | 'metricDataQueries'
   > {
     return {
       // eslint-disable-next-line deprecation/deprecation
@@ -97,6 +126,7 @@ export class CloudWatchMetricsQueryRunner extends CloudWatchRequest {
       namespace: this.replaceVariableAndDisplayWarningIfMulti(query.namespace, scopedVars),
       period: this.replaceVariableAndDisplayWarningIfMulti(query.period, scopedVars),
       expression: this.templateSrv.replace(query.expression, scopedVars),
+      metricDataQueries: this.templateSrv.replace(query.metricDataQueries, scopedVars),
       sqlExpression: this.replaceVariableAndDisplayWarningIfMulti(query.sqlExpression, scopedVars),
       dimensions: this.convertDimensionFormat(query.dimensions ?? {}, scopedVars),
     };
@@ -112,8 +142,9 @@ export class CloudWatchMetricsQueryRunner extends CloudWatchRequest {
 
         dataframes.forEach((frame) => {
           frame.fields.forEach((field) => {
-            if (field.type === FieldType.time) {
-              // field.config.interval is populated in order for Grafana to fill in null values at frame intervals
+            if (field.type === FieldType.time && field.config.interval === undefined) {
+              // field.config.interval is populated in order for Grafana to fill in null values at frame intervals. The
+              // backend sets it from the period, this covers the frames of responses cached before it did
               field.config.interval = frame.meta?.custom?.period * 1000;
             }
           });
@@ -191,6 +222,21 @@ export class CloudWatchMetricsQueryRunner extends CloudWatchRequest {
     query.id = this.templateSrv.replace(query.id, scopedVars);
     query.expression = this.templateSrv.replace(query.expression, scopedVars);
     query.sqlExpression = this.templateSrv.replace(query.sqlExpression, scopedVars, 'raw');
+    if (query.metricDataQueries) {
+      query.metricDataQueries = this.templateSrv.replace(query.metricDataQueries, scopedVars);
+    }
+    if (query.tagFilters) {
+      query.tagFilters = this.convertDimensionFormat(query.tagFilters, scopedVars);
+    }
+    if (query.sloName) {
+      query.sloName = this.templateSrv.replace(query.sloName, scopedVars);
+    }
+    if (query.burnRateGoodExpression) {
+      query.burnRateGoodExpression = this.templateSrv.replace(query.burnRateGoodExpression, scopedVars);
+    }
+    if (query.burnRateTotalExpression) {
+      query.burnRateTotalExpression = this.templateSrv.replace(query.burnRateTotalExpression, scopedVars);
+    }
     if (query.accountId) {
       query.accountId = this.templateSrv.replace(query.accountId, scopedVars);
     }
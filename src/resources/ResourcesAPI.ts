@@ -18,6 +18,21 @@ import {
   MetricResponse,
   SelectableResourceValue,
   RegionResponse,
+  LogsQueryPreset,
+  GetAnomalyDetectorsRequest,
+  AnomalyDetectorResponse,
+  InsightRuleResponse,
+  ApplicationSignalsServiceResponse,
+  DiscoveredAccountResponse,
+  ServiceLevelObjectiveResponse,
+  GetServiceLevelObjectivesRequest,
+  GetMetricMetadataRequest,
+  MetricMetadataResponse,
+  QueryDefinition,
+  PutQueryDefinitionRequest,
+  PutQueryDefinitionResponse,
+  QuerySnippet,
+  RunningLogsQuery,
 } from './types';
 
 export class ResourcesAPI extends CloudWatchRequest {
@@ -34,6 +49,11 @@ export class ResourcesAPI extends CloudWatchRequest {
     return getBackendSrv().get(`/api/datasources/${this.instanceSettings.id}/resources/${subtype}`, parameters);
   }
 
+  // the parameter is left out when unset, so that the backend uses the page limit of the Grafana instance
+  private pageLimitParameter(pageLimit?: number): Record<string, number> {
+    return pageLimit ? { pageLimit } : {};
+  }
+
   async getExternalId(): Promise<string> {
     return await this.memoizedGetRequest<{ externalId: string }>('external-id').then(({ externalId }) => externalId);
   }
@@ -63,6 +83,100 @@ export class ResourcesAPI extends CloudWatchRequest {
     });
   }
 
+  // the accounts, regions and namespaces of the data source, for browsing to a metric. The backend caches the probes
+  // of the regions, as they take a ListMetrics call per region
+  getDiscovery(): Promise<DiscoveredAccountResponse[]> {
+    return this.memoizedGetRequest<Array<ResourceResponse<DiscoveredAccountResponse>>>('discovery').then((accounts) =>
+      accounts.map((account) => account.value)
+    );
+  }
+
+  // not memoized, as the state of the detectors changes while they're trained
+  getAnomalyDetectors({
+    region,
+    namespace = '',
+    metricName = '',
+    dimensionFilters = {},
+    accountId,
+  }: GetAnomalyDetectorsRequest): Promise<Array<ResourceResponse<AnomalyDetectorResponse>>> {
+    return this.getRequest<Array<ResourceResponse<AnomalyDetectorResponse>>>('anomaly-detectors', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      namespace: this.templateSrv.replace(namespace),
+      metricName: this.templateSrv.replace(metricName.trim()),
+      dimensionFilters: JSON.stringify(this.convertDimensionFormat(dimensionFilters, {})),
+      accountId: this.templateSrv.replace(accountId),
+    });
+  }
+
+  getInsightRules(region: string): Promise<Array<ResourceResponse<InsightRuleResponse>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<InsightRuleResponse>>>('insight-rules', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+    });
+  }
+
+  getApplicationSignalsServices(region: string): Promise<Array<ResourceResponse<ApplicationSignalsServiceResponse>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<ApplicationSignalsServiceResponse>>>(
+      'application-signals-services',
+      { region: this.templateSrv.replace(this.getActualRegion(region)) }
+    );
+  }
+
+  getServiceLevelObjectives({
+    region,
+    service = '',
+    environment = '',
+  }: GetServiceLevelObjectivesRequest): Promise<Array<ResourceResponse<ServiceLevelObjectiveResponse>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<ServiceLevelObjectiveResponse>>>('service-level-objectives', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      service: this.templateSrv.replace(service),
+      environment: this.templateSrv.replace(environment),
+    });
+  }
+
+  // not memoized, as it's used to find out whether datapoints have arrived
+  getMetricMetadata({
+    region,
+    namespace,
+    metricName,
+    dimensionFilters = {},
+    accountId,
+    pageLimit,
+  }: GetMetricMetadataRequest): Promise<MetricMetadataResponse> {
+    return this.getRequest<MetricMetadataResponse>('metric-metadata', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      namespace: this.templateSrv.replace(namespace),
+      metricName: this.templateSrv.replace(metricName.trim()),
+      dimensionFilters: JSON.stringify(this.convertDimensionFormat(dimensionFilters, {})),
+      accountId: this.templateSrv.replace(accountId),
+      ...this.pageLimitParameter(pageLimit),
+    });
+  }
+
+  getLambdaInsightsPresets(functionName?: string): Promise<Array<ResourceResponse<LogsQueryPreset>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<LogsQueryPreset>>>('lambda-insights-presets', {
+      functionName: this.templateSrv.replace(functionName ?? ''),
+    });
+  }
+
+  getEKSControlPlanePresets(clusterName: string): Promise<Array<ResourceResponse<LogsQueryPreset>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<LogsQueryPreset>>>('eks-control-plane-presets', {
+      clusterName: this.templateSrv.replace(clusterName),
+    });
+  }
+
+  getWAFPresets(webAclName: string, logGroupName?: string): Promise<Array<ResourceResponse<LogsQueryPreset>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<LogsQueryPreset>>>('waf-presets', {
+      webAclName: this.templateSrv.replace(webAclName),
+      logGroupName: this.templateSrv.replace(logGroupName ?? ''),
+    });
+  }
+
+  getResolverQueryLogPresets(logGroupName: string): Promise<Array<ResourceResponse<LogsQueryPreset>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<LogsQueryPreset>>>('resolver-query-log-presets', {
+      logGroupName: this.templateSrv.replace(logGroupName),
+    });
+  }
+
   getNamespaces() {
     return this.memoizedGetRequest<Array<ResourceResponse<string>>>('namespaces').then((namespaces) =>
       namespaces.map((n) => ({ label: n.value, value: n.value }))
@@ -85,7 +199,50 @@ export class ResourcesAPI extends CloudWatchRequest {
     });
   }
 
-  getMetrics({ region, namespace, accountId }: GetMetricsRequest): Promise<Array<SelectableValue<string>>> {
+  getQueryDefinitions(region: string, namePrefix?: string): Promise<Array<ResourceResponse<QueryDefinition>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<QueryDefinition>>>('query-definitions', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      namePrefix: namePrefix ?? '',
+    });
+  }
+
+  getQuerySnippets(queryLanguage?: string): Promise<Array<ResourceResponse<QuerySnippet>>> {
+    return this.memoizedGetRequest<Array<ResourceResponse<QuerySnippet>>>('query-snippets', {
+      queryLanguage: queryLanguage ?? '',
+    });
+  }
+
+  // not memoized, as the queries finish
+  getRunningLogsQueries(region?: string): Promise<RunningLogsQuery[]> {
+    return this.getRequest<RunningLogsQuery[]>('running-logs-queries', {
+      region: region ? this.templateSrv.replace(this.getActualRegion(region)) : '',
+    });
+  }
+
+  // not memoized, as saving the same query twice must save it twice
+  putQueryDefinition({ region, ...queryDefinition }: PutQueryDefinitionRequest): Promise<PutQueryDefinitionResponse> {
+    return getBackendSrv().post(`/api/datasources/${this.instanceSettings.id}/resources/put-query-definition`, {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      ...queryDefinition,
+    });
+  }
+
+  // invalidates the metrics cached by the backend for the region and namespace, and all the memoized resources, so
+  // that the metrics of newly created resources can be listed
+  invalidateCache({ region, namespace, accountId }: Partial<GetMetricsRequest> = {}): Promise<{ invalidated: number }> {
+    this.memoizedGetRequest.cache.clear?.();
+    const parameters = {
+      ...(region && { region: this.templateSrv.replace(this.getActualRegion(region)) }),
+      ...(namespace && { namespace: this.templateSrv.replace(namespace) }),
+      ...(accountId && accountId !== 'all' && { accountId: this.templateSrv.replace(accountId) }),
+    };
+    const search = new URLSearchParams(parameters).toString();
+    return getBackendSrv().post(
+      `/api/datasources/${this.instanceSettings.id}/resources/cache/invalidate${search ? `?${search}` : ''}`
+    );
+  }
+
+  getMetrics({ region, namespace, accountId, pageLimit }: GetMetricsRequest): Promise<Array<SelectableValue<string>>> {
     if (!namespace) {
       return Promise.resolve([]);
     }
@@ -94,18 +251,20 @@ export class ResourcesAPI extends CloudWatchRequest {
       region: this.templateSrv.replace(this.getActualRegion(region)),
       namespace: this.templateSrv.replace(namespace),
       accountId: this.templateSrv.replace(accountId),
+      ...this.pageLimitParameter(pageLimit),
     }).then((metrics) => metrics.map((m) => ({ label: m.value.name, value: m.value.name })));
   }
 
-  getAllMetrics({ region, accountId }: GetMetricsRequest): Promise<Array<{ metricName?: string; namespace: string }>> {
+  getAllMetrics({ region, accountId, pageLimit }: GetMetricsRequest): Promise<Array<{ metricName?: string; namespace: string }>> {
     return this.memoizedGetRequest<Array<ResourceResponse<MetricResponse>>>('metrics', {
       region: this.templateSrv.replace(this.getActualRegion(region)),
       accountId: this.templateSrv.replace(accountId),
+      ...this.pageLimitParameter(pageLimit),
     }).then((metrics) => metrics.map((m) => ({ metricName: m.value.name, namespace: m.value.namespace })));
   }
 
   getDimensionKeys(
-    { region, namespace = '', dimensionFilters = {}, metricName = '', accountId }: GetDimensionKeysRequest,
+    { region, namespace = '', dimensionFilters = {}, metricName = '', accountId, pageLimit }: GetDimensionKeysRequest,
     displayErrorIfIsMultiTemplateVariable?: boolean
   ): Promise<Array<SelectableValue<string>>> {
     return this.memoizedGetRequest<Array<ResourceResponse<string>>>('dimension-keys', {
@@ -116,6 +275,7 @@ export class ResourcesAPI extends CloudWatchRequest {
       dimensionFilters: JSON.stringify(
         this.convertDimensionFormat(dimensionFilters, {}, displayErrorIfIsMultiTemplateVariable)
       ),
+      ...this.pageLimitParameter(pageLimit),
     }).then((r) => r.map((r) => ({ label: r.value, value: r.value })));
   }
 
@@ -126,6 +286,7 @@ export class ResourcesAPI extends CloudWatchRequest {
     dimensionFilters = {},
     metricName = '',
     accountId,
+    pageLimit,
   }: GetDimensionValuesRequest) {
     if (!namespace || !metricName) {
       return Promise.resolve([]);
@@ -138,6 +299,7 @@ export class ResourcesAPI extends CloudWatchRequest {
       dimensionKey: this.replaceVariableAndDisplayWarningIfMulti(dimensionKey, {}, true),
       dimensionFilters: JSON.stringify(this.convertDimensionFormat(dimensionFilters, {})),
       accountId: this.templateSrv.replace(accountId),
+      ...this.pageLimitParameter(pageLimit),
     }).then((r) => r.map((r) => ({ label: r.value, value: r.value })));
   }
 
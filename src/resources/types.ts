@@ -5,11 +5,16 @@ import { Dimensions } from '../types';
 export interface ResourceResponse<T> {
   accountId?: string;
   value: T;
+  label?: string;
+  // Set when the resource was served from the backend's cache because AWS throttled the request
+  stale?: boolean;
 }
 
 export interface ResourceRequest {
   region: string;
   accountId?: string;
+  // Number of ListMetrics pages to scan, capped by the page limit of the Grafana instance.
+  pageLimit?: number;
 }
 
 export interface GetDimensionKeysRequest extends ResourceRequest {
@@ -25,6 +30,18 @@ export interface GetDimensionValuesRequest extends ResourceRequest {
   dimensionFilters?: Dimensions;
 }
 
+export interface GetAnomalyDetectorsRequest extends ResourceRequest {
+  namespace?: string;
+  metricName?: string;
+  dimensionFilters?: Dimensions;
+}
+
+export interface GetMetricMetadataRequest extends ResourceRequest {
+  namespace: string;
+  metricName: string;
+  dimensionFilters?: Dimensions;
+}
+
 export interface GetMetricsRequest extends ResourceRequest {
   namespace?: string;
 }
@@ -58,6 +75,117 @@ export interface RegionResponse {
   name: string;
 }
 
+export interface AnomalyDetectorResponse {
+  namespace: string;
+  metricName: string;
+  dimensions: Record<string, string>;
+  stat: string;
+  state: 'PENDING_TRAINING' | 'TRAINED_INSUFFICIENT_DATA' | 'TRAINED';
+}
+
+export interface InsightRuleResponse {
+  name: string;
+  state: string;
+  // Whether the rule is managed by an AWS service rather than created in the account
+  managedRule: boolean;
+}
+
+// An account the data source can query, with the regions it recently published metrics in and their namespaces
+export interface DiscoveredAccountResponse {
+  id: string;
+  label: string;
+  isMonitoringAccount: boolean;
+  regions: Array<{ name: string; namespaces: string[] }>;
+}
+
+export interface ApplicationSignalsServiceResponse {
+  name: string;
+  environment: string;
+  keyAttributes: Record<string, string>;
+}
+
+export interface ServiceLevelObjectiveResponse {
+  name: string;
+  arn: string;
+  // Identify the service the SLO is about, if any
+  keyAttributes?: Record<string, string>;
+  operationName?: string;
+  evaluationType?: string;
+}
+
+export interface GetServiceLevelObjectivesRequest extends ResourceRequest {
+  service?: string;
+  environment?: string;
+}
+
+export interface MetricSeriesMetadata {
+  accountId?: string;
+  dimensions: Record<string, string>;
+  // Whether the series had datapoints in the last 3 hours.
+  active: boolean;
+  // ISO timestamps of the first and last hourly datapoints of the series in the last 14 days.
+  firstSeen?: string;
+  lastSeen?: string;
+}
+
+export interface MetricMetadataResponse {
+  namespace: string;
+  metricName: string;
+  lastSeen?: string;
+  series: MetricSeriesMetadata[];
+  // Set when the metric has more series than the backend looks up datapoints of.
+  truncated?: boolean;
+}
+
+export interface LogsQueryPreset {
+  id: string;
+  description: string;
+  expression: string;
+  logGroupNames: string[];
+  statsGroups: string[];
+}
+
+// A reusable logs query of the snippet catalog, whose placeholders, e.g. {{logGroup}}, are replaced by the user
+export interface QuerySnippet {
+  id: string;
+  name: string;
+  description: string;
+  service: string;
+  queryLanguage: string;
+  queryString: string;
+  placeholders: string[];
+}
+
+// A Logs Insights query the data source started that hasn't finished yet, which can be re-attached to by its ID
+export interface RunningLogsQuery {
+  queryId: string;
+  region: string;
+  startedAt: string;
+}
+
+// A Logs Insights query saved in the account, offered as a template by the query editor
+export interface QueryDefinition {
+  id: string;
+  name: string;
+  queryString: string;
+  queryLanguage?: string;
+  logGroupNames: string[];
+}
+
+export interface PutQueryDefinitionRequest {
+  region: string;
+  // the query definition to update, a new one is created if unset
+  queryDefinitionId?: string;
+  name: string;
+  queryString: string;
+  queryLanguage?: string;
+  logGroupNames: string[];
+}
+
+export interface PutQueryDefinitionResponse {
+  queryDefinitionId: string;
+}
+
 export interface SelectableResourceValue extends SelectableValue<string> {
   text: string;
 }
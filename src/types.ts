@@ -10,6 +10,7 @@ export type CloudWatchQuery =
   | raw.CloudWatchMetricsQuery
   | raw.CloudWatchLogsQuery
   | raw.CloudWatchAnnotationQuery
+  | raw.CloudWatchContributorInsightsQuery
   | CloudWatchDefaultQuery;
 
 // We want to allow setting defaults for both Logs and Metrics queries
@@ -32,6 +33,59 @@ export enum CloudWatchLogsQueryStatus {
   Timeout = 'Timeout',
 }
 
+export interface RecordedQuery {
+  name: string;
+  region: string;
+  logGroupNames: string[];
+  expression: string;
+  statsGroups?: string[];
+  // Duration strings like 5m, defaulting to 5m and 1h.
+  interval?: string;
+  timeRange?: string;
+}
+
+export interface MaskingRule {
+  // Regular expression matched against field names, empty applies the rule to every field.
+  fields?: string;
+  // Regular expression replaced in the values, empty replaces whole values.
+  pattern?: string;
+  // Defaults to ****, can refer to submatches like $1.
+  replacement?: string;
+}
+
+export interface LabelRule {
+  // Regular expression matched against label names, empty applies the rule to every label.
+  labels?: string;
+  // Replaces the matches of labels in the label names, can refer to submatches like $1.
+  rename?: string;
+  // Regular expression replaced in the label values, empty keeps the values.
+  pattern?: string;
+  // Replaces the matches of pattern, can refer to submatches like $1.
+  replacement?: string;
+}
+
+export interface DimensionTagLabel {
+  // Namespace whose series are labeled, e.g. AWS/RDS.
+  namespace: string;
+  // Dimension identifying the resources of the namespace, e.g. DBInstanceIdentifier.
+  dimension: string;
+  // Tag of the resources whose value is the label, e.g. Name.
+  tag: string;
+  // Name of the label, defaulting to the dimension followed by the tag, e.g. DBInstanceIdentifierName.
+  label?: string;
+}
+
+export interface QuerySnippet {
+  name: string;
+  description?: string;
+  // Service or team the snippet is listed under, defaulting to Custom.
+  service?: string;
+  // Language of the query string, defaulting to CWLI.
+  queryLanguage?: LogsQueryLanguage;
+  // Query whose placeholders, e.g. {{logGroup}}, are replaced by the user after inserting it.
+  queryString: string;
+}
+
 export interface CloudWatchJsonData extends AwsAuthDataSourceJsonData {
   timeField?: string;
   database?: string;
@@ -41,6 +95,66 @@ export interface CloudWatchJsonData extends AwsAuthDataSourceJsonData {
   logsTimeout?: string;
   // Used to create links if logs contain traceId.
   tracingDatasourceUid?: string;
+  // Restricts the data source to read-only AWS APIs.
+  readOnly?: boolean;
+  // Exchanges the Grafana user's forwarded OAuth identity for credentials of the role mapped to them.
+  userIdentityPassThrough?: boolean;
+  // Role ARNs keyed by 'login:<login>', 'email:<email>', 'role:<org role>' or '*'.
+  webIdentityRoleMap?: Record<string, string>;
+  // Assume-role ARNs keyed by Grafana org ID.
+  orgRoleMap?: Record<string, string>;
+  // AWS access portal URL of IAM Identity Center, e.g. https://my-org.awsapps.com/start, that console links go through
+  // to sign users in to the account of the link with the identityCenterRoleName permission set.
+  identityCenterStartUrl?: string;
+  identityCenterRoleName?: string;
+  // Account of the console links to resources of the data source's own account.
+  identityCenterAccountId?: string;
+  // Role ARNs queries may assume instead of the data source's role.
+  queryRoleArns?: string[];
+  // AWS API calls a dashboard may make per minute, 0 or unset means unlimited.
+  dashboardApiBudget?: number;
+  // Per-dashboard UID overrides of dashboardApiBudget.
+  dashboardApiBudgets?: Record<string, number>;
+  // AWS API calls a single query or resource request may make, 0 or unset means unlimited.
+  requestApiCallBudget?: number;
+  // Query ranges spanning CloudWatch's retention boundaries in parts, each at the finest retained period.
+  splitRangesByRetention?: boolean;
+  // Duration string like 30s or 5m to cache metric query results for, unset disables the cache.
+  queryCacheTTL?: string;
+  // Duration string like 30s to reuse GetMetricData responses for in identical requests, unset disables the cache.
+  metricDataCacheTTL?: string;
+  // Duration string like 10m to reuse the metrics listed for the query editor for, unset disables the cache.
+  listMetricsCacheTTL?: string;
+  // Duration string like 1m to reuse started Logs Insights queries for in identical queries, unset disables reuse.
+  logsQueryReuseTTL?: string;
+  // Duration string like 10s after which a query is logged as slow with a correlation ID, unset disables the log.
+  slowQueryThreshold?: string;
+  // Only fetch the datapoints newer than the last result of a metric query when a panel refreshes.
+  deltaFetch?: boolean;
+  // Logs Insights queries executed in the background, served to queries referencing them by name.
+  recordedQueries?: RecordedQuery[];
+  // Redact values of logs results before they leave the backend.
+  maskingRules?: MaskingRule[];
+  // Rename and rewrite the labels of metric series before they leave the backend.
+  labelRules?: LabelRule[];
+  // Label metric series with a tag of the resource identified by one of their dimensions, e.g. its Name tag.
+  dimensionTagLabels?: DimensionTagLabel[];
+  // Logs query snippets of the organization, offered by the logs query editor along with the built-in ones.
+  querySnippets?: QuerySnippet[];
+  // Maximum number of series a metric query returns, unset or 0 means 1000 and a negative value means unlimited.
+  maxSeriesPerQuery?: number;
+  // Number of GetMetricData requests a metric query request runs at the same time, unset or 0 means 10.
+  metricDataConcurrency?: number;
+  // Maximum number of pages of a GetMetricData request followed, unset or 0 means 100 and a negative value means unlimited.
+  metricDataMaxPages?: number;
+  // Maximum number of log events a Logs Insights query returns, unset or 0 means the maximum of Logs Insights, 10000.
+  maxLogsQueryLimit?: number;
+  // Dimension filters added to every metric query, overriding the values the query has for them.
+  scopeDimensions?: Record<string, string[]>;
+  // Account every metric query is restricted to.
+  scopeAccountId?: string;
+  // Values of the template variables the period and statistic of metric queries interpolated by the backend may reference.
+  variables?: Record<string, string>;
 
   logGroups?: raw.LogGroup[];
   /**
@@ -65,6 +179,10 @@ export interface GetLogEventsRequest extends DataQuery {
    * The name of the log stream.
    */
   logStreamName: string;
+  /**
+   * Role the request assumes instead of the data source's role.
+   */
+  assumeRoleArn?: string;
   /**
    * The start of the time range, expressed as the number of milliseconds after Jan 1, 1970 00:00:00 UTC. Events with a timestamp equal to this time or later than this time are included. Events with a timestamp earlier than this time are not included.
    */
@@ -149,12 +267,24 @@ export interface StartQueryRequest extends DataQuery {
    * The query string to use. For more information, see CloudWatch Logs Insights Query Syntax.
    */
   queryString: string;
+  /**
+   * ID of a saved query definition to run in place of the query string.
+   */
+  queryDefinitionId?: string;
   /**
    * The maximum number of log events to return in the query. If the query string uses the fields command, only the specified fields and their values are returned. The default is 1000.
    */
   limit?: number;
   refId: string;
   region: string;
+  /**
+   * Supplementary query to run the query as, e.g. the logs volume of the query.
+   */
+  supplementaryType?: raw.LogsSupplementaryType;
+  /**
+   * Role the query assumes instead of the data source's role.
+   */
+  assumeRoleArn?: string;
 }
 
 export interface QueryParam extends DataQuery {
@@ -163,6 +293,8 @@ export interface QueryParam extends DataQuery {
   limit?: number;
   region: string;
   statsGroups?: string[];
+  supplementaryType?: raw.LogsSupplementaryType;
+  assumeRoleArn?: string;
 }
 
 export interface MetricRequest {
@@ -212,4 +212,106 @@ describe('addDataLinksToLogsResponse', () => {
       ],
     });
   });
+
+  it('should add a link to the matching logs to the patterns of the pattern command', async () => {
+    const mockResponse: DataQueryResponse = {
+      data: [
+        {
+          fields: [
+            {
+              name: '@pattern',
+              config: {},
+            },
+          ],
+          refId: 'A',
+        },
+      ],
+    };
+
+    const mockOptions = {
+      targets: [
+        {
+          refId: 'A',
+          datasource: { type: 'cloudwatch', uid: 'cloudwatchUid' },
+          expression: 'pattern @message',
+          logGroups: [{ arn: 'arn:aws:logs:us-east-1:111111111111:log-group:/aws/lambda/test' }],
+          region: 'us-east-1',
+        } as CloudWatchQuery,
+      ],
+      range: { ...time, raw: time },
+    } as DataQueryRequest<CloudWatchQuery>;
+
+    setDataSourceSrv({
+      getInstanceSettings() {
+        return { name: 'CloudWatch' };
+      },
+    } as unknown as DataSourceSrv);
+
+    await addDataLinksToLogsResponse(
+      mockResponse,
+      mockOptions,
+      (s) => s ?? '',
+      (v) => [v],
+      (r) => r
+    );
+    expect(mockResponse.data[0].fields[0].config.links).toMatchObject([
+      {
+        title: 'View matching logs',
+        internal: {
+          query: {
+            queryMode: 'Logs',
+            region: 'us-east-1',
+            logGroups: [{ arn: 'arn:aws:logs:us-east-1:111111111111:log-group:/aws/lambda/test' }],
+            expression:
+              'fields @timestamp, @message, @logStream, @log' +
+              ' | filter @message like /${__data.fields["@regexString"]}/ | sort @timestamp desc',
+          },
+          datasourceUid: 'cloudwatchUid',
+          datasourceName: 'CloudWatch',
+        },
+      },
+      { title: 'View in CloudWatch console' },
+    ]);
+  });
+
+  it('should link the fields of X-Ray trace IDs to their trace', async () => {
+    const mockResponse: DataQueryResponse = {
+      data: [
+        {
+          fields: [
+            { name: 'traceId', config: {}, values: ['1-58406520-a006649127e371903a2de979', null] },
+            { name: 'requestId', config: {}, values: ['1-58406520-a006649127e371903a2de979', 'c0ffee'] },
+          ],
+          refId: 'A',
+        },
+      ],
+    };
+
+    const mockOptions = {
+      targets: [{ refId: 'A', expression: 'fields traceId, requestId', region: 'us-east-1' } as CloudWatchQuery],
+      range: { ...time, raw: time },
+    } as DataQueryRequest<CloudWatchQuery>;
+
+    setDataSourceSrv({
+      async get() {
+        return { name: 'Xray' };
+      },
+    } as DataSourceSrv);
+
+    await addDataLinksToLogsResponse(
+      mockResponse,
+      mockOptions,
+      (s) => s ?? '',
+      (v) => [v],
+      (r) => r,
+      'xrayUid'
+    );
+    expect(mockResponse.data[0].fields[0].config.links).toMatchObject([
+      {
+        title: 'Xray',
+        internal: { query: { query: '${__value.raw}', queryType: 'getTrace' }, datasourceUid: 'xrayUid' },
+      },
+    ]);
+    expect(mockResponse.data[0].fields[1].config.links).toMatchObject([{ title: 'View in CloudWatch console' }]);
+  });
 });
@@ -1,9 +1,14 @@
-import { DataFrame, DataLink, DataQueryRequest, DataQueryResponse, ScopedVars, TimeRange } from '@grafana/data';
+import { DataFrame, DataLink, DataQueryRequest, DataQueryResponse, Field, ScopedVars, TimeRange } from '@grafana/data';
 import { getDataSourceSrv } from '@grafana/runtime';
 
 import { AwsUrl, encodeUrl } from '../aws_url';
+import { LogsQueryLanguage } from '../dataquery.gen';
 import { CloudWatchLogsQuery, CloudWatchQuery } from '../types';
 
+// X-Ray trace IDs are a version, the time of the request in hex seconds and 96 random bits, e.g.
+// 1-58406520-a006649127e371903a2de979
+const xrayTraceIdPattern = /^1-[0-9a-f]{8}-[0-9a-f]{24}$/;
+
 type ReplaceFn = (
   target?: string,
   scopedVars?: ScopedVars,
@@ -17,7 +22,9 @@ export async function addDataLinksToLogsResponse(
   replaceFn: ReplaceFn,
   getVariableValueFn: (value: string, scopedVars: ScopedVars) => string[],
   getRegion: (region: string) => string,
-  tracingDatasourceUid?: string
+  tracingDatasourceUid?: string,
+  // turns console urls into urls signing the user in to the account of the log groups first
+  toConsoleUrl: (url: string, accountId?: string) => string = (url) => url
 ): Promise<void> {
   const replace = (target: string, fieldName?: string) => replaceFn(target, request.scopedVars, false, fieldName);
   const getVariableValue = (target: string) => getVariableValueFn(target, request.scopedVars);
@@ -27,23 +34,37 @@ export async function addDataLinksToLogsResponse(
     const interpolatedRegion = getRegion(replace(curTarget.region ?? '', 'region'));
 
     for (const field of dataFrame.fields) {
-      if (field.name === '@xrayTraceId' && tracingDatasourceUid) {
-        getRegion(replace(curTarget.region ?? '', 'region'));
+      if (tracingDatasourceUid && isXrayTraceIdField(field)) {
         const xrayLink = await createInternalXrayLink(tracingDatasourceUid, interpolatedRegion);
         if (xrayLink) {
           field.config.links = [xrayLink];
         }
+      } else if (field.name === '@pattern' && curTarget.datasource?.uid) {
+        field.config.links = [
+          createPatternLogsLink(curTarget, curTarget.datasource.uid, interpolatedRegion),
+          createAwsConsoleLink(curTarget, request.range, interpolatedRegion, replace, getVariableValue, toConsoleUrl),
+        ];
       } else {
         // Right now we add generic link to open the query in xray console to every field so it shows in the logs row
         // details. Unfortunately this also creates link for all values inside table which look weird.
         field.config.links = [
-          createAwsConsoleLink(curTarget, request.range, interpolatedRegion, replace, getVariableValue),
+          createAwsConsoleLink(curTarget, request.range, interpolatedRegion, replace, getVariableValue, toConsoleUrl),
         ];
       }
     }
   }
 }
 
+// Fields are linked to their trace when they're the trace ID Lambda logs, or when all their values are X-Ray trace IDs,
+// e.g. a trace ID the application logs in a JSON field that Logs Insights discovered.
+export function isXrayTraceIdField(field: Field): boolean {
+  if (field.name === '@xrayTraceId') {
+    return true;
+  }
+  const values = (field.values ?? []).filter((value) => value !== null && value !== undefined && value !== '');
+  return values.length > 0 && values.every((value) => typeof value === 'string' && xrayTraceIdPattern.test(value));
+}
+
 async function createInternalXrayLink(datasourceUid: string, region: string): Promise<DataLink | undefined> {
   let ds;
   try {
@@ -64,12 +85,39 @@ async function createInternalXrayLink(datasourceUid: string, region: string): Pr
   };
 }
 
+// Links a pattern of the results of the pattern command to the logs of the query matching it, using the regular
+// expression of the pattern that's returned alongside it.
+function createPatternLogsLink(target: CloudWatchLogsQuery, datasourceUid: string, region: string): DataLink {
+  const datasourceName = getDataSourceSrv().getInstanceSettings(datasourceUid)?.name ?? '';
+  return {
+    title: 'View matching logs',
+    url: '',
+    internal: {
+      query: {
+        refId: target.refId,
+        queryMode: 'Logs',
+        queryLanguage: LogsQueryLanguage.CWLI,
+        region: region,
+        logGroups: target.logGroups,
+        // eslint-disable-next-line deprecation/deprecation
+        logGroupNames: target.logGroupNames,
+        expression:
+          'fields @timestamp, @message, @logStream, @log | filter @message like /${__data.fields["@regexString"]}/' +
+          ' | sort @timestamp desc',
+      },
+      datasourceUid: datasourceUid,
+      datasourceName: datasourceName,
+    },
+  };
+}
+
 function createAwsConsoleLink(
   target: CloudWatchLogsQuery,
   range: TimeRange,
   region: string,
   replace: (target: string, fieldName?: string) => string,
-  getVariableValue: (value: string) => string[]
+  getVariableValue: (value: string) => string[],
+  toConsoleUrl: (url: string, accountId?: string) => string
 ) {
   const arns = (target.logGroups ?? [])
     .filter((group) => group?.arn)
@@ -91,8 +139,10 @@ function createAwsConsoleLink(
   };
 
   const encodedUrl = encodeUrl(urlProps, region);
+  // the account of the log groups is the fifth part of their ARNs
+  const accountId = arns.length ? arns[0].split(':')[4] : undefined;
   return {
-    url: encodedUrl,
+    url: toConsoleUrl(encodedUrl, accountId),
     title: 'View in CloudWatch console',
     targetBlank: true,
   };
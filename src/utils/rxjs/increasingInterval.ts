@@ -3,9 +3,11 @@ import { SchedulerLike, Observable, SchedulerAction, Subscriber, asyncScheduler
 /**
  * Creates an Observable that emits sequential numbers after increasing intervals of time
  * starting with `startPeriod`, ending with `endPeriod` and incrementing by `step`.
+ * Each interval is randomly lengthened or shortened by up to `jitter` of it, so that
+ * observables created at the same time don't keep emitting at the same time.
  */
 export const increasingInterval = (
-  { startPeriod = 0, endPeriod = 5000, step = 1000 },
+  { startPeriod = 0, endPeriod = 5000, step = 1000, jitter = 0 },
   scheduler: SchedulerLike = asyncScheduler
 ): Observable<number> => {
   return new Observable<number>((subscriber) => {
@@ -15,9 +17,10 @@ export const increasingInterval = (
       period: startPeriod,
       step,
       endPeriod,
+      jitter,
     };
 
-    subscriber.add(scheduler.schedule(dispatch, startPeriod, state));
+    subscriber.add(scheduler.schedule(dispatch, jittered(startPeriod, jitter), state));
     return subscriber;
   });
 };
@@ -26,10 +29,17 @@ function dispatch(this: SchedulerAction<IntervalState>, state?: IntervalState) {
   if (!state) {
     return;
   }
-  const { subscriber, counter, period, step, endPeriod } = state;
+  const { subscriber, counter, period, step, endPeriod, jitter } = state;
   subscriber.next(counter);
   const newPeriod = Math.min(period + step, endPeriod);
-  this.schedule({ subscriber, counter: counter + 1, period: newPeriod, step, endPeriod }, newPeriod);
+  this.schedule(
+    { subscriber, counter: counter + 1, period: newPeriod, step, endPeriod, jitter },
+    jittered(newPeriod, jitter)
+  );
+}
+
+function jittered(period: number, jitter: number): number {
+  return jitter ? period * (1 + (Math.random() * 2 - 1) * jitter) : period;
 }
 
 interface IntervalState {
@@ -38,4 +48,5 @@ interface IntervalState {
   period: number;
   endPeriod: number;
   step: number;
+  jitter: number;
 }
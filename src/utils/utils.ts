@@ -1,3 +1,5 @@
+import { isEmpty } from 'lodash';
+
 import { SelectableValue } from '@grafana/data';
 
 import { CloudWatchMetricsQuery, MetricQueryType, MetricEditorMode } from '../types';
@@ -12,8 +14,22 @@ export const appendTemplateVariables = (datasource: CloudWatchDatasource, values
 ];
 
 export const filterMetricsQuery = (query: CloudWatchMetricsQuery): boolean => {
-  const { region, metricQueryType, metricEditorMode, expression, metricName, namespace, sqlExpression, statistic } =
-    query;
+  const {
+    region,
+    metricQueryType,
+    metricEditorMode,
+    expression,
+    metricName,
+    namespace,
+    sqlExpression,
+    statistic,
+    metricDataQueries,
+    tagFilters,
+    sloName,
+    burnRateGoodExpression,
+    burnRateTotalExpression,
+    sloObjective,
+  } = query;
   if (!region) {
     return false;
   }
@@ -24,6 +40,14 @@ export const filterMetricsQuery = (query: CloudWatchMetricsQuery): boolean => {
   } else if (metricQueryType === MetricQueryType.Insights) {
     // still TBD how to validate the visual query builder for SQL
     return !!sqlExpression;
+  } else if (metricQueryType === MetricQueryType.JSON) {
+    return !!metricDataQueries;
+  } else if (metricQueryType === MetricQueryType.Tags) {
+    return !!namespace && !!metricName && !!statistic && !isEmpty(tagFilters);
+  } else if (metricQueryType === MetricQueryType.SLO) {
+    return !!sloName;
+  } else if (metricQueryType === MetricQueryType.BurnRate) {
+    return !!burnRateGoodExpression && !!burnRateTotalExpression && !!sloObjective;
   }
 
   return false;